@@ -304,6 +304,10 @@ func mainSrc(checkVersionCompatibility func(string) bool) string {
 	})))
 
 	if err := dispatch(ctx); err != nil {
+		var crash crashError
+		if errors.As(err, &crash) {
+			os.Exit(telemetry.CrashExitCode)
+		}
 		os.Exit(2)
 	}
 }
@@ -316,6 +320,32 @@ func unwrapError(rerr error) string {
 	return rerr.Error()
 }
 
+// crashError is returned by dispatch when a function panicked, so that the
+// runtime exits with telemetry.CrashExitCode and the engine reports the call
+// as crashed.
+type crashError struct {
+	error
+}
+
+// recordCrash reports a panic with its stack trace, so that it can be told
+// apart from an error returned by a function.
+func recordCrash(ctx context.Context, r any) error {
+	stack := string(debug.Stack())
+	err := fmt.Errorf("panic: %v", r)
+	_, span := Tracer().Start(ctx, "panic", trace.WithAttributes(
+		attribute.String(telemetry.RuntimeLanguageAttr, "go"),
+		attribute.String(telemetry.RuntimeVersionAttr, runtime.Version()),
+	))
+	span.AddEvent(semconv.ExceptionEventName, trace.WithAttributes(
+		semconv.ExceptionType(fmt.Sprintf("%T", r)),
+		semconv.ExceptionMessage(fmt.Sprint(r)),
+		semconv.ExceptionStacktrace(stack),
+	))
+	span.SetStatus(codes.Error, err.Error())
+	span.End()
+	return crashError{err}
+}
+
 func dispatch(ctx context.Context) (rerr error) {
 	ctx = telemetry.InitEmbedded(ctx, resource.NewWithAttributes(
 		semconv.SchemaURL,
@@ -337,6 +367,11 @@ func dispatch(ctx context.Context) (rerr error) {
 			}
 		}
 	}()
+	defer func() {
+		if r := recover(); r != nil {
+			rerr = recordCrash(ctx, r)
+		}
+	}()
 
 	parentName, err := fnCall.ParentName(ctx)
 	if err != nil {
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+// alertExitCode is the exit code used by default for alerts with an
+// exit-code sink, distinguishing them from failures and SLO violations.
+const alertExitCode = 4
+
+// webhookTimeout bounds how long we wait on each webhook, so that an
+// unresponsive one never holds up the CLI for long.
+const webhookTimeout = 10 * time.Second
+
+var alertsPath = os.Getenv("DAGGER_ALERTS")
+
+func loadAlerts() (*dagui.AlertConfig, error) {
+	if alertsPath == "" {
+		return nil, nil
+	}
+	return dagui.LoadAlertConfig(alertsPath)
+}
+
+// raiseAlerts evaluates the alert rules against the completed run, sending
+// any alerts to their sinks and reporting them to w. An error is returned
+// for alerts with an exit-code sink.
+func raiseAlerts(w io.Writer, db *dagui.DB, cfg *dagui.AlertConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	var exitCode int
+	for _, alert := range cfg.Evaluate(db) {
+		fmt.Fprintf(w, "Alert: %s (%d matching spans)\n", alert.Rule, len(alert.Spans))
+		for _, sink := range alert.Sinks {
+			switch sink.Type {
+			case dagui.AlertSinkExitCode:
+				if exitCode == 0 {
+					exitCode = cmp.Or(sink.Code, alertExitCode)
+				}
+			case dagui.AlertSinkWebhook:
+				if err := postJSON(sink.URL, alert); err != nil {
+					fmt.Fprintf(w, "failed to send alert %q to webhook: %v\n", alert.Rule, err)
+				}
+			case dagui.AlertSinkFile:
+				if err := appendAlert(sink.Path, alert); err != nil {
+					fmt.Fprintf(w, "failed to write alert %q to file: %v\n", alert.Rule, err)
+				}
+			}
+		}
+	}
+	if exitCode != 0 {
+		return ExitError{Code: exitCode}
+	}
+	return nil
+}
+
+// postJSON posts a value to a webhook as JSON.
+func postJSON(url string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func appendAlert(path string, alert dagui.Alert) error {
+	line, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,496 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/spf13/cobra"
+
+	"dagger.io/dagger"
+	"github.com/dagger/dagger/core/modules"
+	"github.com/dagger/dagger/engine"
+	"github.com/dagger/dagger/engine/client"
+)
+
+// bundleManifestFilename is the name of the manifest at the root of a bundle
+// directory.
+const bundleManifestFilename = "bundle.json"
+
+var (
+	offline bool
+
+	bundleImages  []string
+	bundleModules []string
+)
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Manage pre-seeded bundles for offline mode",
+	Long: `Manage pre-seeded bundles for offline mode.
+
+A bundle is a directory containing container images and module sources that
+can be copied to an air-gapped environment. Bundling a module also bundles its
+git dependencies, its SDK if that is a module, and the images pulled by the
+runtimes of the builtin Python and TypeScript SDKs. Once loaded, running with
+--offline will only use content from the bundle, failing fast with a list of
+everything else that is needed.`,
+	Annotations: map[string]string{
+		"experimental": "true",
+	},
+}
+
+var bundleCreateCmd = &cobra.Command{
+	Use:   "create [options] <dir>",
+	Short: "Create a bundle from images and module sources",
+	Example: strings.TrimSpace(`
+dagger bundle create ./bundle --image alpine:3.20 --module github.com/dagger/dagger/modules/wolfi
+`),
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withEngine(cmd.Context(), client.Params{}, func(ctx context.Context, engineClient *client.Client) error {
+			return createBundle(ctx, engineClient.Dagger(), args[0], bundleImages, bundleModules)
+		})
+	},
+}
+
+var bundleLoadCmd = &cobra.Command{
+	Use:   "load [options] <dir>",
+	Short: "Load a bundle into the engine and use it for offline mode",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := filepath.Abs(args[0])
+		if err != nil {
+			return err
+		}
+		manifest, err := readBundleManifest(dir)
+		if err != nil {
+			return err
+		}
+		if err := manifest.Verify(dir); err != nil {
+			return err
+		}
+		return withEngine(cmd.Context(), client.Params{}, func(ctx context.Context, engineClient *client.Client) error {
+			dag := engineClient.Dagger()
+			for _, img := range manifest.Images {
+				tarball := dag.Host().File(filepath.Join(dir, img.Path))
+				if _, err := dag.Container().Import(tarball).Sync(ctx); err != nil {
+					return fmt.Errorf("load image %s: %w", img.Ref, err)
+				}
+			}
+			if err := setActiveBundle(dir); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Loaded %d images and %d modules from %s\n",
+				len(manifest.Images), len(manifest.Modules), dir)
+			return nil
+		})
+	},
+}
+
+func init() {
+	bundleCreateCmd.Flags().StringSliceVar(&bundleImages, "image", nil, "Container image to include in the bundle")
+	bundleCreateCmd.Flags().StringSliceVar(&bundleModules, "module", nil, "Module source to include in the bundle")
+
+	bundleCmd.AddCommand(bundleCreateCmd, bundleLoadCmd)
+	rootCmd.AddCommand(bundleCmd)
+}
+
+type bundleManifest struct {
+	Images  []bundleImage  `json:"images"`
+	Modules []bundleModule `json:"modules"`
+}
+
+type bundleImage struct {
+	// Ref is the image ref as requested by the pipeline, e.g. alpine:3.20.
+	Ref string `json:"ref"`
+	// Canonical is the digest-pinned ref that Ref resolved to.
+	Canonical string `json:"canonical"`
+	// Path is the OCI tarball, relative to the bundle directory.
+	Path string `json:"path"`
+}
+
+type bundleModule struct {
+	// Ref is the module source ref as passed to -m or listed as a
+	// dependency, e.g. a git URL.
+	Ref string `json:"ref"`
+	// Path is the module source root, relative to the bundle directory.
+	Path string `json:"path"`
+}
+
+// bundleSDKImages are the images pulled by the runtimes of the builtin SDKs
+// that don't ship them with the engine, kept in sync with
+// sdk/python/runtime/Dockerfile and sdk/typescript/runtime by
+// TestBundleSDKImages.
+var bundleSDKImages = map[string][]string{
+	"python": {
+		"python:3.12-slim@sha256:34656cd90456349040784165b9decccbcee4de66f3ead0a1168ba893455afd1e",
+		"ghcr.io/astral-sh/uv:0.5.30@sha256:bb74263127d6451222fe7f71b330edfb189ab1c98d7898df2401fbf4f272d9b9",
+	},
+	"typescript": {
+		"node:22.11.0-alpine@sha256:b64ced2e7cd0a4816699fe308ce6e8a08ccba463c757c00c14cd372e3d2c763e",
+		"oven/bun:1.1.38-alpine@sha256:5148f6742ac31fac28e6eab391ab1f11f6dfc0c8512c7a3679b374ec470f5982",
+	},
+}
+
+// bundleSDKModules are the builtin SDKs implemented as modules, which are
+// bundled like any other remote module.
+var bundleSDKModules = []string{"java", "php", "elixir"}
+
+// moduleRequirements is the remote content needed to load a module.
+type moduleRequirements struct {
+	// Modules are the refs of the module's git dependencies, and of its SDK
+	// if that is a module.
+	Modules []moduleRequirement
+	// Images are pulled by the module's SDK runtime.
+	Images []string
+}
+
+type moduleRequirement struct {
+	Ref string
+	Pin string
+}
+
+// requiredByModule reads the configuration of the module rooted at root, and
+// of its local dependencies, returning the remote content it needs to load.
+// Local dependencies and SDKs are part of the module's context directory,
+// which is bundled with it.
+func requiredByModule(root string) (*moduleRequirements, error) {
+	reqs := &moduleRequirements{}
+	seen := map[string]bool{}
+	var walk func(root string) error
+	walk = func(root string) error {
+		if seen[root] {
+			return nil
+		}
+		seen[root] = true
+		configBytes, err := os.ReadFile(filepath.Join(root, modules.Filename))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return fmt.Errorf("read module config: %w", err)
+		}
+		var modCfg modules.ModuleConfig
+		if err := json.Unmarshal(configBytes, &modCfg); err != nil {
+			return fmt.Errorf("parse module config %s: %w", filepath.Join(root, modules.Filename), err)
+		}
+		for _, dep := range modCfg.Dependencies {
+			if local := filepath.Join(root, dep.Source); isLocalModuleRef(local) {
+				if err := walk(local); err != nil {
+					return err
+				}
+				continue
+			}
+			reqs.addModule(dep.Source, dep.Pin)
+		}
+		if modCfg.SDK == nil || modCfg.SDK.Source == "" {
+			return nil
+		}
+		sdkName, sdkVersion, _ := strings.Cut(modCfg.SDK.Source, "@")
+		switch {
+		case sdkName == "go":
+		case bundleSDKImages[sdkName] != nil:
+			for _, img := range bundleSDKImages[sdkName] {
+				if !slices.Contains(reqs.Images, img) {
+					reqs.Images = append(reqs.Images, img)
+				}
+			}
+		case slices.Contains(bundleSDKModules, sdkName):
+			ref := "github.com/dagger/dagger/sdk/" + sdkName
+			if sdkVersion != "" {
+				ref += "@" + sdkVersion
+			}
+			reqs.addModule(ref, "")
+		default:
+			if local := filepath.Join(root, modCfg.SDK.Source); isLocalModuleRef(local) {
+				return walk(local)
+			}
+			reqs.addModule(modCfg.SDK.Source, "")
+		}
+		return nil
+	}
+	if err := walk(root); err != nil {
+		return nil, err
+	}
+	return reqs, nil
+}
+
+func (reqs *moduleRequirements) addModule(ref, pin string) {
+	for _, req := range reqs.Modules {
+		if req.Ref == ref {
+			return
+		}
+	}
+	reqs.Modules = append(reqs.Modules, moduleRequirement{Ref: ref, Pin: pin})
+}
+
+func isLocalModuleRef(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// ImageRefs maps the bundled images' refs to their canonical refs, keyed the
+// way the engine looks them up in offline mode.
+func (manifest *bundleManifest) ImageRefs() (map[string]string, error) {
+	refs := make(map[string]string, len(manifest.Images))
+	for _, img := range manifest.Images {
+		refName, err := engine.NormalizeImageRef(img.Ref)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundled image ref %q: %w", img.Ref, err)
+		}
+		refs[refName.String()] = img.Canonical
+	}
+	return refs, nil
+}
+
+// ModulePaths maps the bundled modules' refs to the host paths of their
+// bundled copies, the way the engine looks them up in offline mode.
+func (manifest *bundleManifest) ModulePaths(dir string) map[string]string {
+	paths := make(map[string]string, len(manifest.Modules))
+	for _, mod := range manifest.Modules {
+		paths[mod.Ref] = filepath.Join(dir, mod.Path)
+	}
+	return paths
+}
+
+func (manifest *bundleManifest) Module(ref string) (bundleModule, bool) {
+	for _, mod := range manifest.Modules {
+		if mod.Ref == ref {
+			return mod, true
+		}
+	}
+	return bundleModule{}, false
+}
+
+// Verify checks that everything listed in the manifest is present in the
+// bundle directory, reporting all missing content at once.
+func (manifest *bundleManifest) Verify(dir string) error {
+	var missing []string
+	for _, img := range manifest.Images {
+		if _, err := os.Stat(filepath.Join(dir, img.Path)); err != nil {
+			missing = append(missing, "image "+img.Ref)
+		}
+	}
+	for _, mod := range manifest.Modules {
+		if _, err := os.Stat(filepath.Join(dir, mod.Path)); err != nil {
+			missing = append(missing, "module "+mod.Ref)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("bundle %s is missing content:\n  %s", dir, strings.Join(missing, "\n  "))
+	}
+	return nil
+}
+
+// Missing returns everything needed to load the bundled module at path that
+// is missing from the bundle, following its git dependencies through the
+// bundle.
+func (manifest *bundleManifest) Missing(dir, path string) ([]string, error) {
+	imageRefs, err := manifest.ImageRefs()
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	seen := map[string]bool{}
+	queue := []string{path}
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+		reqs, err := requiredByModule(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, req := range reqs.Modules {
+			if seen[req.Ref] {
+				continue
+			}
+			seen[req.Ref] = true
+			mod, ok := manifest.Module(req.Ref)
+			if !ok {
+				missing = append(missing, "module "+req.Ref)
+				continue
+			}
+			queue = append(queue, filepath.Join(dir, mod.Path))
+		}
+		for _, img := range reqs.Images {
+			if seen[img] {
+				continue
+			}
+			seen[img] = true
+			refName, err := engine.NormalizeImageRef(img)
+			if err != nil {
+				return nil, fmt.Errorf("invalid SDK image ref %q: %w", img, err)
+			}
+			if _, ok := imageRefs[refName.String()]; !ok {
+				missing = append(missing, "image "+img)
+			}
+		}
+	}
+	return missing, nil
+}
+
+var unsafeBundlePathChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+func bundlePathName(ref string) string {
+	return strings.Trim(unsafeBundlePathChars.ReplaceAllString(ref, "_"), "_")
+}
+
+func createBundle(ctx context.Context, dag *dagger.Client, dir string, images, modules []string) error {
+	if len(images) == 0 && len(modules) == 0 {
+		return errors.New("nothing to bundle; specify --image and/or --module")
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	manifest := &bundleManifest{}
+
+	// bundle the modules first, along with everything they need to load, so
+	// that their SDKs' images are bundled too
+	queue := make([]moduleRequirement, 0, len(modules))
+	for _, ref := range modules {
+		queue = append(queue, moduleRequirement{Ref: ref})
+	}
+	for len(queue) > 0 {
+		req := queue[0]
+		queue = queue[1:]
+		if _, ok := manifest.Module(req.Ref); ok {
+			continue
+		}
+		mod, err := bundleModuleSource(ctx, dag, dir, req)
+		if err != nil {
+			return err
+		}
+		manifest.Modules = append(manifest.Modules, mod)
+		reqs, err := requiredByModule(filepath.Join(dir, mod.Path))
+		if err != nil {
+			return fmt.Errorf("module %s: %w", req.Ref, err)
+		}
+		queue = append(queue, reqs.Modules...)
+		for _, img := range reqs.Images {
+			if !slices.Contains(images, img) {
+				images = append(images, img)
+			}
+		}
+	}
+
+	for _, ref := range images {
+		ctr := dag.Container().From(ref)
+		canonical, err := ctr.ImageRef(ctx)
+		if err != nil {
+			return fmt.Errorf("resolve image %s: %w", ref, err)
+		}
+		img := bundleImage{
+			Ref:       ref,
+			Canonical: canonical,
+			Path:      filepath.Join("images", bundlePathName(ref)+".tar"),
+		}
+		if _, err := ctr.Export(ctx, filepath.Join(dir, img.Path)); err != nil {
+			return fmt.Errorf("export image %s: %w", ref, err)
+		}
+		manifest.Images = append(manifest.Images, img)
+	}
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, bundleManifestFilename), manifestBytes, 0o644)
+}
+
+func bundleModuleSource(ctx context.Context, dag *dagger.Client, dir string, req moduleRequirement) (bundleModule, error) {
+	src := dag.ModuleSource(req.Ref, dagger.ModuleSourceOpts{RefPin: req.Pin})
+	subpath, err := src.SourceRootSubpath(ctx)
+	if err != nil {
+		return bundleModule{}, fmt.Errorf("resolve module %s: %w", req.Ref, err)
+	}
+	contextDir := filepath.Join("modules", bundlePathName(req.Ref))
+	if _, err := src.ContextDirectory().Export(ctx, filepath.Join(dir, contextDir)); err != nil {
+		return bundleModule{}, fmt.Errorf("export module %s: %w", req.Ref, err)
+	}
+	// mark the context directory the way a git checkout would, so that the
+	// bundled module can still reach local dependencies outside its root
+	if err := os.MkdirAll(filepath.Join(dir, contextDir, ".git"), 0o755); err != nil {
+		return bundleModule{}, err
+	}
+	return bundleModule{
+		Ref:  req.Ref,
+		Path: filepath.Join(contextDir, subpath),
+	}, nil
+}
+
+func readBundleManifest(dir string) (*bundleManifest, error) {
+	manifestBytes, err := os.ReadFile(filepath.Join(dir, bundleManifestFilename))
+	if err != nil {
+		return nil, fmt.Errorf("read bundle manifest: %w", err)
+	}
+	var manifest bundleManifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return nil, fmt.Errorf("parse bundle manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+func activeBundlePath() string {
+	return filepath.Join(xdg.StateHome, "dagger", "bundle")
+}
+
+func setActiveBundle(dir string) error {
+	if err := os.MkdirAll(filepath.Dir(activeBundlePath()), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(activeBundlePath(), []byte(dir), 0o644)
+}
+
+// activeBundle returns the directory and manifest of the bundle most recently
+// loaded with `dagger bundle load`.
+func activeBundle() (string, *bundleManifest, error) {
+	dir, err := os.ReadFile(activeBundlePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil, errors.New("offline mode requires a bundle; run `dagger bundle load` first")
+		}
+		return "", nil, err
+	}
+	manifest, err := readBundleManifest(string(dir))
+	if err != nil {
+		return "", nil, err
+	}
+	return string(dir), manifest, nil
+}
+
+// offlineModuleRef maps a module ref to its bundled copy when running in
+// offline mode. Local paths are passed through as-is.
+func offlineModuleRef(ref string) (string, error) {
+	if !offline {
+		return ref, nil
+	}
+	dir, manifest, err := activeBundle()
+	if err != nil {
+		return "", err
+	}
+	path := ref
+	if mod, ok := manifest.Module(ref); ok {
+		path = filepath.Join(dir, mod.Path)
+	} else if !isLocalModuleRef(ref) {
+		return "", fmt.Errorf("offline mode: module not found in pre-seeded bundle %s: %s", dir, ref)
+	}
+	// report everything the module needs that isn't bundled at once, rather
+	// than failing on each in turn
+	missing, err := manifest.Missing(dir, path)
+	if err != nil {
+		return "", err
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("offline mode: pre-seeded bundle %s is missing content needed by %s:\n  %s\n(add with `dagger bundle create`)",
+			dir, ref, strings.Join(missing, "\n  "))
+	}
+	return path, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundleSDKImages(t *testing.T) {
+	dockerfile, err := os.ReadFile("../../sdk/python/runtime/Dockerfile")
+	require.NoError(t, err)
+	var python []string
+	for _, match := range regexp.MustCompile(`(?m)^FROM\s+(\S+)\s+AS`).FindAllSubmatch(dockerfile, -1) {
+		python = append(python, string(match[1]))
+	}
+	require.Equal(t, python, bundleSDKImages["python"])
+
+	runtime, err := os.ReadFile("../../sdk/typescript/runtime/main.go")
+	require.NoError(t, err)
+	consts := map[string]string{}
+	for _, match := range regexp.MustCompile(`(?m)^\s+(\w+)\s+= "([^"]+)"`).FindAllSubmatch(runtime, -1) {
+		consts[string(match[1])] = string(match[2])
+	}
+	require.Equal(t, []string{
+		"node:" + consts["nodeVersion"] + "-alpine@" + consts["nodeImageDigest"],
+		"oven/bun:" + consts["bunVersion"] + "-alpine@" + consts["bunImageDigest"],
+	}, bundleSDKImages["typescript"])
+}
+
+func TestBundleMissing(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig := func(path, config string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, path), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path, "dagger.json"), []byte(config), 0o644))
+	}
+	// a local module with a local dependency, which has git dependencies of
+	// its own, one of which is bundled
+	writeConfig("app", `{"name": "app", "sdk": "go", "dependencies": [{"name": "lib", "source": "../lib"}]}`)
+	writeConfig("lib", `{"name": "lib", "sdk": "python", "dependencies": [
+		{"name": "wolfi", "source": "github.com/dagger/dagger/modules/wolfi@v0.15.0", "pin": "abc123"},
+		{"name": "alpine", "source": "github.com/dagger/dagger/modules/alpine"}
+	]}`)
+	writeConfig("modules/wolfi", `{"name": "wolfi", "sdk": "java"}`)
+
+	reqs, err := requiredByModule(filepath.Join(dir, "app"))
+	require.NoError(t, err)
+	require.Equal(t, []moduleRequirement{
+		{Ref: "github.com/dagger/dagger/modules/wolfi@v0.15.0", Pin: "abc123"},
+		{Ref: "github.com/dagger/dagger/modules/alpine"},
+	}, reqs.Modules)
+	require.Equal(t, bundleSDKImages["python"], reqs.Images)
+
+	manifest := &bundleManifest{
+		Images: []bundleImage{{
+			Ref:       bundleSDKImages["python"][0],
+			Canonical: bundleSDKImages["python"][0],
+		}},
+		Modules: []bundleModule{{
+			Ref:  "github.com/dagger/dagger/modules/wolfi@v0.15.0",
+			Path: "modules/wolfi",
+		}},
+	}
+	require.Equal(t, map[string]string{
+		"github.com/dagger/dagger/modules/wolfi@v0.15.0": filepath.Join(dir, "modules/wolfi"),
+	}, manifest.ModulePaths(dir))
+
+	// everything missing is reported at once, including the requirements of
+	// bundled dependencies
+	missing, err := manifest.Missing(dir, filepath.Join(dir, "app"))
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"module github.com/dagger/dagger/modules/alpine",
+		"image " + bundleSDKImages["python"][1],
+		"module github.com/dagger/dagger/sdk/java",
+	}, missing)
+}
@@ -28,6 +28,7 @@ func isPrintTraceLinkEnabled(annotations map[string]string) bool {
 var funcCmds = []*FuncCommand{
 	callModCmd,
 	callCoreCmd,
+	upModCmd,
 }
 
 var callCoreCmd = &FuncCommand{
@@ -48,6 +49,27 @@ var callModCmd = &FuncCommand{
 	},
 }
 
+var upModCmd = &FuncCommand{
+	Name:  "up [options]",
+	Short: "Run a daemon function's service until interrupted",
+	Long: strings.ReplaceAll(`Run the service returned by a daemon function until interrupted.
+
+Daemon functions return a Service that keeps running after the call returns,
+until it's stopped or the session ends. This command calls the function and
+forwards the service's ports to the host, like ´dagger call <function> up´,
+keeping the session open until interrupted.
+`,
+		"´",
+		"`",
+	),
+	Example: `dagger up serve --port 8080`,
+	Daemons: true,
+	Annotations: map[string]string{
+		"experimental":    "true",
+		printTraceLinkKey: "true",
+	},
+}
+
 var funcListCmd = &cobra.Command{
 	Use:   "functions [options] [function]...",
 	Short: `List available functions`,
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"dagger.io/dagger/telemetry"
+)
+
+var (
+	otelAttrLimits = os.Getenv("DAGGER_OTEL_ATTR_LIMITS")
+
+	// cardinalityGuard bounds the attributes of spans sent to the OTEL_*
+	// exporter, if enabled.
+	cardinalityGuard *telemetry.CardinalityGuard
+)
+
+// startCardinalityGuard bounds the attributes of spans sent to the OTEL_*
+// exporter, if enabled.
+func startCardinalityGuard() error {
+	if otelAttrLimits == "" {
+		return nil
+	}
+	limits, err := telemetry.ParseCardinalityLimits(otelAttrLimits)
+	if err != nil {
+		return fmt.Errorf("--otel-attr-limits: %w", err)
+	}
+	cardinalityGuard = telemetry.NewCardinalityGuard(limits)
+	return nil
+}
+
+// reportTruncations lists the span attributes that were truncated before
+// being exported, if any.
+func reportTruncations(w io.Writer) {
+	if cardinalityGuard == nil {
+		return
+	}
+	report := cardinalityGuard.Report()
+	if len(report) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "Truncated span attributes to fit --otel-attr-limits:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, t := range report {
+		fmt.Fprintf(tw, "  %s\t%s\t%d\n", t.Key, t.Reason, t.Count)
+	}
+	tw.Flush()
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 
 	"dagger.io/dagger/telemetry"
@@ -10,19 +11,73 @@ import (
 	"github.com/dagger/dagger/engine/client"
 	"github.com/dagger/dagger/engine/slog"
 	enginetel "github.com/dagger/dagger/engine/telemetry"
+	"go.opentelemetry.io/otel/attribute"
 	sdklog "go.opentelemetry.io/otel/sdk/log"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type runClientCallback func(context.Context, *client.Client) error
 
+// failureLogLines is the number of log lines shown for each failure in the
+// report printed at the end of --keep-going runs.
+const failureLogLines = 10
+
 func withEngine(
 	ctx context.Context,
 	params client.Params,
 	fn runClientCallback,
 ) error {
-	return Frontend.Run(ctx, opts, func(ctx context.Context) (rerr error) {
+	slos, err := parseSLOs()
+	if err != nil {
+		return err
+	}
+	if err := parseReports(); err != nil {
+		return err
+	}
+	params.ExportConflict, err = engine.ParseExportConflictPolicy(exportConflict)
+	if err != nil {
+		return err
+	}
+	params.AllowedHostPorts, err = parseHostPortGrants()
+	if err != nil {
+		return err
+	}
+	alerts, err := loadAlerts()
+	if err != nil {
+		return err
+	}
+	// only load the history for commands that run, rather than every command
+	opts.Durations = loadDurationHistory()
+	compactTraces()
+	stopWebUI, err := startWebUI(ctx)
+	if err != nil {
+		return err
+	}
+	defer stopWebUI()
+	stopMetrics, err := startMetrics(ctx)
+	if err != nil {
+		return err
+	}
+	defer stopMetrics()
+	finishStatsd, err := startStatsd()
+	if err != nil {
+		return err
+	}
+	if err := startCardinalityGuard(); err != nil {
+		return err
+	}
+	if err := parseSessionLabels(); err != nil {
+		return err
+	}
+	var connected bool
+	runOpts := opts
+	journal := openJournal()
+	if journal != nil {
+		runOpts.Store = journal
+	}
+	err = Frontend.Run(ctx, runOpts, func(ctx context.Context) (rerr error) {
 		// Init tracing as early as possible and shutdown after the command
 		// completes, ensuring progress is fully flushed to the frontend.
 		ctx, cleanupTelemetry := initEngineTelemetry(ctx)
@@ -37,8 +92,27 @@ func withEngine(
 		}
 
 		params.DisableHostRW = disableHostRW
+		params.SessionTimeout = sessionTimeout
+		params.KeepGoing = keepGoing
+		params.PayloadSizes = payloadSizes
+
+		if offline {
+			bundleDir, bundle, err := activeBundle()
+			if err != nil {
+				return err
+			}
+			params.Offline = true
+			params.OfflineImages, err = bundle.ImageRefs()
+			if err != nil {
+				return err
+			}
+			params.OfflineModules = bundle.ModulePaths(bundleDir)
+		}
 
-		params.EngineCallback = Frontend.ConnectedToEngine
+		params.EngineCallback = func(ctx context.Context, name, version, clientID string) {
+			connected = true
+			Frontend.ConnectedToEngine(ctx, name, version, clientID)
+		}
 		params.CloudURLCallback = Frontend.SetCloudURL
 
 		params.EngineTrace = telemetry.SpanForwarder{
@@ -60,8 +134,56 @@ func withEngine(
 		}
 		defer sess.Close()
 
+		Frontend.SetCancelSpan(func(ctx context.Context, spanID dagui.SpanID) error {
+			return sess.CancelSpan(ctx, spanID.SpanID)
+		})
+		defer Frontend.SetCancelSpan(nil)
+
+		// record pauses under the command's span, so they show up in its trace
+		// and are left out of its duration
+		cmdSpan := trace.SpanContextFromContext(ctx)
+		Frontend.SetPauseRun(func(pauseCtx context.Context, paused bool) error {
+			return sess.SetPaused(trace.ContextWithSpanContext(pauseCtx, cmdSpan), paused)
+		})
+		defer Frontend.SetPauseRun(nil)
+
 		return fn(ctx, sess)
 	})
+	recordTrace(Frontend.DB(), journal)
+	notifyCompletion(Frontend.DB(), sendDesktopNotification)
+	// alerts are raised for failed runs too, but their exit code only
+	// applies to runs that otherwise succeeded
+	alertErr := raiseAlerts(os.Stderr, Frontend.DB(), alerts)
+	err = finishRun(err, slos, alertErr)
+	finishStatsd(err)
+	if junitReportPath != "" {
+		err = writeJUnitReport(os.Stderr, Frontend.DB(), err)
+	}
+	if reportPath != "" {
+		return writeReport(os.Stderr, Frontend.DB(), connected, err)
+	}
+	return err
+}
+
+// finishRun reports the failures of a completed run and checks it against
+// its SLOs, returning the error the CLI should exit with.
+func finishRun(err error, slos []dagui.SLO, alertErr error) error {
+	if Frontend.DB().IsQuarantinedError(err, opts.Quarantine) {
+		// quarantined failures are reported as warnings, without failing the run
+		fmt.Fprintln(os.Stderr, "Only quarantined steps failed; pass --quarantine=fail to fail the run.")
+		err = nil
+	}
+	if err != nil {
+		if keepGoing {
+			// list everything that failed, now that it's all done
+			_ = dagui.WriteFailureReport(os.Stderr, Frontend.DB().Failures(failureLogLines))
+		}
+		return err
+	}
+	if err := checkSLOs(os.Stderr, Frontend.DB(), slos); err != nil {
+		return err
+	}
+	return alertErr
 }
 
 func initEngineTelemetry(ctx context.Context) (context.Context, func(error)) {
@@ -70,10 +192,23 @@ func initEngineTelemetry(ctx context.Context) (context.Context, func(error)) {
 		Detect:   true,
 		Resource: Resource(ctx),
 
+		CardinalityGuard: cardinalityGuard,
+
 		LiveTraceExporters:  []sdktrace.SpanExporter{Frontend.SpanExporter()},
 		LiveLogExporters:    []sdklog.Exporter{Frontend.LogExporter()},
 		LiveMetricExporters: []sdkmetric.Exporter{Frontend.MetricExporter()},
 	}
+	if webUIServer != nil {
+		telemetryCfg.LiveTraceExporters = append(telemetryCfg.LiveTraceExporters, webUIServer.SpanExporter())
+		telemetryCfg.LiveLogExporters = append(telemetryCfg.LiveLogExporters, webUIServer.LogExporter())
+		telemetryCfg.LiveMetricExporters = append(telemetryCfg.LiveMetricExporters, webUIServer.MetricExporter())
+	}
+	if metricsServer != nil {
+		telemetryCfg.LiveTraceExporters = append(telemetryCfg.LiveTraceExporters, metricsServer.SpanExporter())
+	}
+	if statsdExporter != nil {
+		telemetryCfg.LiveTraceExporters = append(telemetryCfg.LiveTraceExporters, statsdExporter.SpanExporter())
+	}
 	if spans, logs, metrics, ok := enginetel.ConfiguredCloudExporters(ctx); ok {
 		telemetryCfg.LiveTraceExporters = append(telemetryCfg.LiveTraceExporters, spans)
 		telemetryCfg.LiveLogExporters = append(telemetryCfg.LiveLogExporters, logs)
@@ -101,7 +236,15 @@ func initEngineTelemetry(ctx context.Context) (context.Context, func(error)) {
 
 	return ctx, func(rerr error) {
 		stdio.Close()
+		if ctx.Err() != nil {
+			// the run was interrupted, e.g. with Ctrl+C; the frontend shows the
+			// cause on whatever was still running
+			span.SetAttributes(
+				attribute.Bool(telemetry.CanceledAttr, true),
+				attribute.String(telemetry.CanceledByAttr, telemetry.CanceledByUser))
+		}
 		telemetry.End(span, func() error { return rerr })
 		telemetry.Close()
+		reportTruncations(os.Stderr)
 	}
 }
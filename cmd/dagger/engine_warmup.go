@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	"dagger.io/dagger"
+	"github.com/dagger/dagger/dagql/dagui"
+	"github.com/dagger/dagger/engine/client"
+)
+
+var warmupManifestPath string
+
+var engineCmd = &cobra.Command{
+	Use:   "engine",
+	Short: "Manage the Dagger Engine",
+	Annotations: map[string]string{
+		"experimental": "true",
+	},
+}
+
+var engineWarmupCmd = &cobra.Command{
+	Use:   "warmup [options]",
+	Short: "Pre-pull images and pre-resolve modules used by a previous run",
+	Long: `Pre-pull images and pre-resolve modules used by a previous run.
+
+The manifest can be generated from a trace recorded with --record-trace, with
+"dagger trace manifest". Running this ahead of a scheduled pipeline ensures it starts with
+a warm cache. Local module paths in the manifest are relative to it, and are
+skipped since they are loaded from the host anyway.`,
+	Example: `dagger --record-trace call build
+dagger trace manifest > pipeline.json
+dagger engine warmup --from pipeline.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manifestBytes, err := os.ReadFile(warmupManifestPath)
+		if err != nil {
+			return fmt.Errorf("read manifest: %w", err)
+		}
+		var manifest dagui.WarmupManifest
+		if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+			return fmt.Errorf("parse manifest: %w", err)
+		}
+		// local module paths are relative to the manifest
+		manifestDir := filepath.Dir(warmupManifestPath)
+		return withEngine(cmd.Context(), client.Params{}, func(ctx context.Context, engineClient *client.Client) error {
+			return warmup(ctx, engineClient.Dagger(), &manifest, manifestDir)
+		})
+	},
+}
+
+func init() {
+	engineWarmupCmd.Flags().StringVar(&warmupManifestPath, "from", "", "Path to a manifest generated by \"dagger trace manifest\"")
+	engineWarmupCmd.MarkFlagRequired("from")
+	engineWarmupCmd.MarkFlagFilename("from", "json")
+
+	engineCmd.AddCommand(engineWarmupCmd)
+	rootCmd.AddCommand(engineCmd)
+}
+
+func warmup(ctx context.Context, dag *dagger.Client, manifest *dagui.WarmupManifest, manifestDir string) error {
+	eg, ctx := errgroup.WithContext(ctx)
+	for _, ref := range manifest.Images {
+		eg.Go(func() error {
+			// syncing the container only resolves its config, so sync its
+			// rootfs to pull the layers too
+			if _, err := dag.Container().From(ref).Rootfs().Sync(ctx); err != nil {
+				return fmt.Errorf("pull %s: %w", ref, err)
+			}
+			return nil
+		})
+	}
+	for _, ref := range manifest.Modules {
+		path := ref
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(manifestDir, path)
+		}
+		if _, err := os.Stat(path); err == nil {
+			// local modules are loaded from the host anyway
+			continue
+		}
+		eg.Go(func() error {
+			if _, err := dag.ModuleSource(ref).AsModule().Initialize().ID(ctx); err != nil {
+				return fmt.Errorf("resolve module %s: %w", ref, err)
+			}
+			return nil
+		})
+	}
+	return eg.Wait()
+}
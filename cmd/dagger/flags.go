@@ -465,6 +465,9 @@ func (v *fileValue) Get(_ context.Context, dag *dagger.Client, _ *dagger.ModuleS
 // plaintext value.
 type secretValue struct {
 	uri string
+
+	// plaintext is set instead of the URI when the value was prompted for.
+	plaintext string
 }
 
 func (v *secretValue) Type() string {
@@ -491,7 +494,10 @@ func (v *secretValue) String() string {
 	return v.uri
 }
 
-func (v *secretValue) Get(ctx context.Context, c *dagger.Client, _ *dagger.ModuleSource, _ *modFunctionArg) (any, error) {
+func (v *secretValue) Get(ctx context.Context, c *dagger.Client, _ *dagger.ModuleSource, arg *modFunctionArg) (any, error) {
+	if v.plaintext != "" {
+		return c.SetSecret(arg.FlagName(), v.plaintext), nil
+	}
 	return c.Secret(v.uri), nil
 }
 
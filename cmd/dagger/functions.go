@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"text/template"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -18,6 +19,7 @@ import (
 	"dagger.io/dagger"
 	"dagger.io/dagger/querybuilder"
 	"dagger.io/dagger/telemetry"
+	"github.com/dagger/dagger/dagql/call"
 	"github.com/dagger/dagger/dagql/idtui"
 	"github.com/dagger/dagger/engine/client"
 	"github.com/dagger/dagger/engine/client/pathutil"
@@ -30,6 +32,12 @@ var (
 
 	// outputPath is the parsed value of the `--output` flag.
 	outputPath string
+
+	// outputFormatFlag is the parsed value of the `--output-format` flag.
+	outputFormatFlag string
+
+	// callTargets are the parsed values of the `--target` flag.
+	callTargets []string
 )
 
 const (
@@ -82,6 +90,10 @@ type FuncCommand struct {
 	// DisableModuleLoad skips adding a flag for loading a user Dagger Module.
 	DisableModuleLoad bool
 
+	// Daemons limits the command to daemon functions, and the objects that
+	// lead to them, running the daemon's service until interrupted.
+	Daemons bool
+
 	// cmd is the parent cobra command.
 	cmd *cobra.Command
 
@@ -99,6 +111,10 @@ type FuncCommand struct {
 	// arguments rather than a debug level log.
 	warnSkipped bool
 
+	// prompted are the required arguments that were prompted for, as
+	// "<function> --<flag>".
+	prompted []string
+
 	q   *querybuilder.Selection
 	c   *client.Client
 	ctx context.Context
@@ -164,7 +180,9 @@ func (fc *FuncCommand) Command() *cobra.Command {
 					c.SetContext(idtui.WithPrintTraceLink(c.Context(), true))
 				}
 
-				return withEngine(c.Context(), client.Params{}, func(ctx context.Context, engineClient *client.Client) (rerr error) {
+				return withEngine(c.Context(), client.Params{
+					CallTargets: callTargets,
+				}, func(ctx context.Context, engineClient *client.Client) (rerr error) {
 					fc.c = engineClient
 					fc.q = querybuilder.Query().Client(engineClient.Dagger().GraphQLClient())
 
@@ -218,6 +236,11 @@ func (fc *FuncCommand) Command() *cobra.Command {
 		fc.cmd.PersistentFlags().StringVarP(&outputPath, "output", "o", "", "Save the result to a local file or directory")
 
 		fc.cmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "j", false, "Present result as JSON")
+
+		fc.cmd.PersistentFlags().StringVar(&outputFormatFlag, "output-format", "", `Present result in this format: "json", "yaml", or "go-template=TEMPLATE". Objects include their ID as "_id"`)
+		fc.cmd.MarkFlagsMutuallyExclusive("json", "output-format")
+
+		fc.cmd.PersistentFlags().StringSliceVar(&callTargets, "target", nil, "Only run these functions, and the functions they call, when called from other functions; skip the rest")
 	}
 	return fc.cmd
 }
@@ -377,6 +400,11 @@ func (fc *FuncCommand) cobraBuilder(ctx context.Context, fn *modFunction) func(*
 			return nil
 		}
 
+		// Ask for missing arguments rather than failing, when interactive.
+		if err := fc.promptMissingArgs(c, fn); err != nil {
+			return err
+		}
+
 		// Validate before accessing values for select.
 		if err := c.ValidateRequiredFlags(); err != nil {
 			return err
@@ -451,6 +479,9 @@ func (fc *FuncCommand) addSubCommands(ctx context.Context, cmd *cobra.Command, t
 	fns, skipped := GetSupportedFunctions(fnProvider)
 
 	for _, fn := range fns {
+		if fc.Daemons && !fn.Daemon && (fn.ReturnType.AsFunctionProvider() == nil || fn.ReturnsCoreObject()) {
+			continue
+		}
 		subCmd := fc.makeSubCmd(ctx, fn)
 		cmd.AddCommand(subCmd)
 	}
@@ -529,6 +560,16 @@ func (fc *FuncCommand) selectFunc(fn *modFunction, cmd *cobra.Command) error {
 // RunE is the final command in the function chain, where the API request is made.
 func (fc *FuncCommand) RunE(ctx context.Context, fn *modFunction) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, args []string) error {
+		if fc.Daemons {
+			return fc.runDaemon(ctx, fn)
+		}
+
+		// check the format before running anything, rather than failing
+		// to print the result at the end
+		if err := checkOutputFormat(outputFormatFlag); err != nil {
+			return err
+		}
+
 		q, err := handleObjectLeaf(ctx, fc.q, fn.ReturnType)
 		if err != nil {
 			return err
@@ -558,6 +599,17 @@ func (fc *FuncCommand) RunE(ctx context.Context, fn *modFunction) func(*cobra.Co
 	}
 }
 
+// runDaemon calls a daemon function and forwards its service's ports to the
+// host, blocking until interrupted.
+func (fc *FuncCommand) runDaemon(ctx context.Context, fn *modFunction) error {
+	if !fn.Daemon {
+		return fmt.Errorf("function %q is not a daemon", fn.CmdName())
+	}
+	fc.showUsage = false
+	var response any
+	return makeRequest(ctx, fc.q.Select("up"), &response)
+}
+
 func handleObjectLeaf(ctx context.Context, q *querybuilder.Selection, typeDef *modTypeDef) (*querybuilder.Selection, error) {
 	obj := typeDef.AsFunctionProvider()
 	if obj == nil {
@@ -600,6 +652,10 @@ func handleObjectLeaf(ctx context.Context, q *querybuilder.Selection, typeDef *m
 	for _, f := range fns {
 		names = append(names, f.Name)
 	}
+	if outputFormatFlag != "" && typeName != "Query" {
+		// shown as "_id" by prettyObjectIDs
+		names = append(names, "id")
+	}
 	if len(names) > 0 {
 		q = q.SelectMultiple(names...)
 	}
@@ -669,6 +725,9 @@ func handleResponse(returnType *modTypeDef, response any, o, e io.Writer) error
 			}
 			response = r
 		}
+		if outputFormatFlag != "" {
+			response = prettyObjectIDs(response)
+		}
 	}
 
 	buf := new(bytes.Buffer)
@@ -711,10 +770,14 @@ func outputFormat(typeDef *modTypeDef) string {
 		}
 	}
 
-	// The --json flag has precedence over the autodetected format above.
+	// The --json and --output-format flags have precedence over the
+	// autodetected format above.
 	if jsonOutput {
 		outputFormat = "json"
 	}
+	if outputFormatFlag != "" {
+		outputFormat = outputFormatFlag
+	}
 
 	return outputFormat
 }
@@ -740,7 +803,70 @@ func printResponse(w io.Writer, response any, format string) error {
 		return printPlainResult(w, response)
 
 	default:
-		return fmt.Errorf("wrong output format %q", format)
+		tmpl, err := parseOutputTemplate(format)
+		if err != nil {
+			return err
+		}
+		return tmpl.Execute(w, response)
+	}
+}
+
+// checkOutputFormat returns an error if results can't be printed in the
+// format.
+func checkOutputFormat(format string) error {
+	switch format {
+	case "", "json", "yaml":
+		return nil
+	}
+	_, err := parseOutputTemplate(format)
+	return err
+}
+
+// parseOutputTemplate parses the template of a go-template=TEMPLATE format.
+func parseOutputTemplate(format string) (*template.Template, error) {
+	text, ok := strings.CutPrefix(format, "go-template=")
+	if !ok {
+		return nil, fmt.Errorf("wrong output format %q: expected json, yaml, or go-template=TEMPLATE", format)
+	}
+	tmpl, err := template.New("output").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse output template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// prettyObjectIDs replaces the IDs of objects in a response with the chain of
+// calls they stand for, under "_id", so they can be read and scripted against
+// without decoding them.
+func prettyObjectIDs(response any) any {
+	switch t := response.(type) {
+	case []any:
+		r := make([]any, len(t))
+		for i, v := range t {
+			r[i] = prettyObjectIDs(v)
+		}
+		return r
+	case map[string]any:
+		encoded, ok := t["id"].(string)
+		if !ok {
+			return t
+		}
+		r := make(map[string]any, len(t))
+		for k, v := range t {
+			if k != "id" {
+				r[k] = v
+			}
+		}
+		var id call.ID
+		if err := id.Decode(encoded); err != nil {
+			// not an ID after all, e.g. a field of a module object
+			r["_id"] = encoded
+		} else {
+			r["_id"] = id.Display()
+		}
+		return r
+	default:
+		return response
 	}
 }
 
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/dagger/dagger/dagql/call"
+)
+
+func TestOutputFormat(t *testing.T) {
+	ctrType := &ast.Type{NamedType: "Container", NonNull: true}
+	ctr := call.New().Append(ctrType, "container", "", nil, false, 0, "").
+		Append(ctrType, "from", "", nil, false, 0, "",
+			call.NewArgument("address", call.NewLiteralString("alpine"), false))
+	encoded, err := ctr.Encode()
+	require.NoError(t, err)
+
+	response := prettyObjectIDs([]any{
+		map[string]any{"_type": "Container", "id": encoded, "platform": "linux/amd64"},
+		map[string]any{"_type": "Container"},
+	})
+	require.Equal(t, []any{
+		map[string]any{
+			"_type":    "Container",
+			"_id":      `container.from(address: "alpine"): Container!`,
+			"platform": "linux/amd64",
+		},
+		map[string]any{"_type": "Container"},
+	}, response)
+
+	var buf bytes.Buffer
+	require.NoError(t, printResponse(&buf, response.([]any)[0], "go-template={{._type}} {{._id}}"))
+	require.Equal(t, `Container container.from(address: "alpine"): Container!`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, printResponse(&buf, map[string]any{"name": "foo"}, "yaml"))
+	require.Equal(t, "name: foo\n", buf.String())
+
+	require.NoError(t, checkOutputFormat("json"))
+	require.NoError(t, checkOutputFormat("go-template={{.name}}"))
+	require.ErrorContains(t, checkOutputFormat("xml"), `wrong output format "xml"`)
+	require.ErrorContains(t, checkOutputFormat("go-template={{.name"), "parse output template")
+	// missing fields are reported rather than printed as "<no value>"
+	require.Error(t, printResponse(&buf, map[string]any{"name": "foo"}, "go-template={{.version}}"))
+}
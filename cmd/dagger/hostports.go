@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dagger/dagger/engine"
+)
+
+var allowedHostPorts = splitHostPorts(os.Getenv("DAGGER_ALLOW_HOST_PORTS"))
+
+func splitHostPorts(ports string) []string {
+	if ports == "" {
+		return nil
+	}
+	return strings.Split(ports, ",")
+}
+
+// parseHostPortGrants validates the ports given with --allow-host-port, which
+// containers may call back to through host.reverseTunnel.
+func parseHostPortGrants() ([]string, error) {
+	grants := make([]string, 0, len(allowedHostPorts))
+	for _, str := range allowedHostPorts {
+		grant, err := engine.ParseHostPortGrant(str)
+		if err != nil {
+			return nil, fmt.Errorf("--allow-host-port: %w", err)
+		}
+		grants = append(grants, grant)
+	}
+	return grants, nil
+}
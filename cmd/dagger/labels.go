@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"dagger.io/dagger/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+var (
+	sessionLabelFlags = splitLabels(os.Getenv("DAGGER_SESSION_LABELS"))
+
+	// sessionLabelValues are the labels parsed from --session-label, set on
+	// the CLI's telemetry resource.
+	sessionLabelValues map[string]string
+)
+
+func splitLabels(labels string) []string {
+	if labels == "" {
+		return nil
+	}
+	return strings.Split(labels, ",")
+}
+
+// parseSessionLabels validates the labels given with --session-label.
+func parseSessionLabels() error {
+	labels, err := dagui.ParseLabels(sessionLabelFlags)
+	if err != nil {
+		return fmt.Errorf("--session-label: %w", err)
+	}
+	sessionLabelValues = labels
+	return nil
+}
+
+// sessionLabelAttrs returns the resource attributes for the session's
+// labels.
+func sessionLabelAttrs() []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(sessionLabelValues))
+	for name, val := range sessionLabelValues {
+		attrs = append(attrs, attribute.String(telemetry.SessionLabelPrefix+name, val))
+	}
+	return attrs
+}
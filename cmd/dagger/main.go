@@ -12,6 +12,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/google/shlex"
@@ -64,6 +65,29 @@ var (
 	dotFocusField     string
 	dotShowInternal   bool
 
+	spanNameFlags []string
+	glyphs        = os.Getenv("DAGGER_GLYPHS")
+	quarantine    = os.Getenv("DAGGER_QUARANTINE")
+	retention     = os.Getenv("DAGGER_RETENTION")
+
+	terminalProgress, _ = strconv.ParseBool(os.Getenv("DAGGER_TERMINAL_PROGRESS"))
+	terminalBell, _     = strconv.ParseBool(os.Getenv("DAGGER_TERMINAL_BELL"))
+
+	cacheReport, _ = strconv.ParseBool(os.Getenv("DAGGER_CACHE_REPORT"))
+
+	debugEffects, _ = strconv.ParseBool(os.Getenv("DAGGER_DEBUG_EFFECTS"))
+
+	sessionTimeout, _ = time.ParseDuration(os.Getenv("DAGGER_TIMEOUT"))
+
+	keepGoing, _ = strconv.ParseBool(os.Getenv("DAGGER_KEEP_GOING"))
+
+	payloadSizes, _ = strconv.ParseBool(os.Getenv("DAGGER_PAYLOAD_SIZES"))
+
+	recordTraces, _ = strconv.ParseBool(os.Getenv("DAGGER_RECORD_TRACE"))
+	traceRetention  = os.Getenv("DAGGER_TRACE_RETENTION")
+
+	exportConflict = os.Getenv("DAGGER_EXPORT_CONFLICT")
+
 	stdoutIsTTY = isatty.IsTerminal(os.Stdout.Fd())
 	stderrIsTTY = isatty.IsTerminal(os.Stderr.Fd())
 
@@ -94,6 +118,7 @@ func init() {
 		funcListCmd,
 		callCoreCmd.Command(),
 		callModCmd.Command(),
+		upModCmd.Command(),
 		sessionCmd(),
 		newGenCmd(),
 		shellCmd,
@@ -223,11 +248,39 @@ func installGlobalFlags(flags *pflag.FlagSet) {
 	flags.CountVarP(&quiet, "quiet", "q", "Reduce verbosity (show progress, but clean up at the end)")
 	flags.BoolVarP(&silent, "silent", "s", silent, "Do not show progress at all")
 	flags.BoolVarP(&debug, "debug", "d", debug, "Show debug logs and full verbosity")
-	flags.StringVar(&progress, "progress", "auto", "Progress output format (auto, plain, tty)")
+	flags.StringVar(&progress, "progress", "auto", "Progress output format (auto, plain, tty, tap, github)")
 	flags.BoolVarP(&interactive, "interactive", "i", false, "Spawn a terminal on container exec failure")
 	flags.StringVar(&interactiveCommand, "interactive-command", "/bin/sh", "Change the default command for interactive mode")
 	flags.BoolVarP(&web, "web", "w", false, "Open trace URL in a web browser")
 	flags.BoolVarP(&noExit, "no-exit", "E", false, "Leave the TUI running after completion")
+	flags.BoolVar(&offline, "offline", os.Getenv("DAGGER_OFFLINE") != "", "Only use images and modules from the loaded bundle")
+	flags.BoolVar(&terminalProgress, "terminal-progress", terminalProgress, "Report progress to the terminal emulator, e.g. in its tab or taskbar")
+	flags.BoolVar(&terminalBell, "terminal-bell", terminalBell, "With --terminal-progress, ring the terminal bell when the run completes, e.g. for tmux to flag the window")
+	flags.StringVar(&webUIAddr, "web-ui", webUIAddr, "Serve a live web UI for the run on the given address, e.g. localhost:8080, with its span tree, logs and metrics")
+	flags.BoolVar(&cacheReport, "cache-report", cacheReport, "Print how the run used the cache once it completes, with --progress=plain")
+	flags.BoolVar(&debugEffects, "debug-effects", debugEffects, "Print the effects that didn't complete once the run ends, with the spans that installed them, to debug steps stuck pending")
+	flags.StringVar(&metricsAddr, "metrics", metricsAddr, "Serve Prometheus metrics for the run at /metrics on the given address, e.g. localhost:9090")
+	flags.StringVar(&statsdAddr, "statsd", statsdAddr, "Send metrics for the run to a statsd or DogStatsD server, e.g. localhost:8125 or unix:///var/run/datadog/dsd.socket")
+	flags.StringArrayVar(&statsdTags, "statsd-tag", statsdTags, "Add a tag to the metrics sent with --statsd, e.g. env:ci")
+	flags.StringArrayVar(&sessionLabelFlags, "session-label", sessionLabelFlags, "Label the session to find its trace later, e.g. pr=123; shown in the TUI header and \"dagger trace ls\"")
+	flags.StringVar(&otelAttrLimits, "otel-attr-limits", otelAttrLimits, "Truncate span attributes sent to the OTEL_* exporter, e.g. length=1024,slice=32,distinct=1000,exempt=<key>")
+	flags.BoolVar(&notify, "notify", notify, "Send a desktop notification when the run completes")
+	flags.StringVar(&glyphs, "glyphs", glyphs, "Status glyphs to use: unicode, ascii, nerd, or emoji, optionally followed by overrides, e.g. ascii,cached=c")
+	flags.DurationVar(&sessionTimeout, "timeout", sessionTimeout, "Cancel the run in the engine if it doesn't complete within the given duration, e.g. 30m")
+	flags.BoolVarP(&keepGoing, "keep-going", "k", keepGoing, "Keep running independent steps after a failure, and report all failures at the end")
+	flags.BoolVar(&recordTraces, "record-trace", recordTraces, "Record the run's trace locally, to inspect it later with \"dagger trace\"")
+	flags.StringVar(&traceRetention, "trace-retention", traceRetention, "Limit the traces kept by --record-trace, removing older ones in the background, e.g. traces=50,age=720h,size=1GB")
+	flags.BoolVar(&payloadSizes, "payload-sizes", payloadSizes, "Record the size of each call's arguments and result as metrics, warning about large ones")
+	flags.StringVar(&exportConflict, "export-conflict", exportConflict, "What to do when exports to the host overlap, e.g. two exports to the same directory: wait to run them one after the other, or fail")
+	flags.StringArrayVar(&allowedHostPorts, "allow-host-port", allowedHostPorts, "Let containers call back to a port on the host with host.reverseTunnel, e.g. 3000 for localhost:3000, or db.local:5432")
+	flags.StringVar(&quarantine, "quarantine", quarantine, "How to report failures of quarantined steps: warn, to report them as warnings without failing the run, or fail, to treat them as any other failure")
+	flags.StringVar(&retention, "retention", retention, "Limit the spans kept in memory in long sessions by pruning completed steps, e.g. spans=10000,age=1h,keep-failed")
+	flags.StringArrayVar(&spanNameFlags, "span-name", nil, `Customize the title of spans for a function with a template, e.g. 'withExec={{join .Args.args " "}}'`)
+	flags.StringVar(&alertsPath, "alerts", alertsPath, "Evaluate the alert rules in the given JSON file when the run completes")
+	flags.StringArrayVar(&reportFlags, "report", reportFlags, "Write a deterministic report of the run to the given file, exiting with git bisect run codes (0 pass, 1 fail, 125 skip, 130 abort), or junit=<path> to write the outcome of test steps as JUnit XML")
+	flags.StringArrayVar(&sloFlags, "slo", nil, "Fail with exit code 3 if the run misses an SLO, e.g. total<10m, step:build<2m, cache>80%")
+	flags.StringArrayVar(&slowThresholdFlags, "slow-threshold", nil, "Highlight steps running longer than a duration, for all steps or those whose name matches a pattern, e.g. 5m or 'withExec*=10m'")
+	flags.StringVar(&slowNotify, "slow-notify", slowNotify, "Notify when a step gets slow (see --slow-threshold): desktop, or a webhook URL to POST JSON to")
 
 	flags.StringVar(&dotOutputFilePath, "dot-output", "", "If set, write the calls made during execution to a dot file at the given path before exiting")
 	flags.StringVar(&dotFocusField, "dot-focus-field", "", "In dot output, filter out vertices that aren't this field or descendents of this field")
@@ -266,6 +319,25 @@ func parseGlobalFlags() {
 	}
 }
 
+// parseSpanNames parses the span name templates configured by
+// DAGGER_SPAN_NAMES, one per line, followed by any --span-name flags.
+func parseSpanNames() (dagui.SpanNames, error) {
+	specs := spanNameFlags
+	if env := os.Getenv("DAGGER_SPAN_NAMES"); env != "" {
+		specs = append(strings.Split(strings.TrimSpace(env), "\n"), specs...)
+	}
+	names := dagui.SpanNames{}
+	for _, spec := range specs {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		if err := names.Set(spec); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
 func Tracer() trace.Tracer {
 	return otel.Tracer("dagger.io/cli")
 }
@@ -278,6 +350,7 @@ func Resource(ctx context.Context) *resource.Resource {
 	for k, v := range enginetel.LoadDefaultLabels(workdir, engine.Version) {
 		attrs = append(attrs, attribute.String(k, v))
 	}
+	attrs = append(attrs, sessionLabelAttrs()...)
 	res, err := resource.New(ctx,
 		resource.WithSchemaURL(semconv.SchemaURL),
 		resource.WithAttributes(attrs...),
@@ -325,10 +398,57 @@ func main() {
 	opts.DotOutputFilePath = dotOutputFilePath
 	opts.DotFocusField = dotFocusField
 	opts.DotShowInternal = dotShowInternal
+	spanNames, err := parseSpanNames()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	opts.SpanNames = spanNames
+	if glyphs != "" {
+		opts.Glyphs, err = dagui.ParseStatusGlyphs(glyphs)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	opts.Quarantine, err = dagui.ParseQuarantineMode(quarantine)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	opts.Retention, err = dagui.ParseRetentionPolicy(retention)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if _, err := dagui.ParseTraceRetention(traceRetention); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	opts.SlowThresholds, err = parseSlowThresholds()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	opts.OnSlowSpan, err = slowSpanNotifier(slowNotify)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if sessionTimeout > 0 {
+		opts.Deadline = time.Now().Add(sessionTimeout)
+	}
+	opts.TerminalProgress = terminalProgress
+	opts.TerminalBell = terminalBell
+	opts.CacheReport = cacheReport
+	opts.DebugEffects = debugEffects
 	if progress == "auto" {
-		if hasTTY {
+		switch {
+		case hasTTY:
 			progress = "tty"
-		} else {
+		case os.Getenv("GITHUB_ACTIONS") == "true":
+			progress = "github"
+		default:
 			progress = "plain"
 		}
 	}
@@ -347,6 +467,10 @@ func main() {
 		Frontend = idtui.NewPretty()
 	case "report":
 		Frontend = idtui.NewReporter()
+	case "tap":
+		Frontend = idtui.NewTAP()
+	case "github":
+		Frontend = idtui.NewGitHub()
 	default:
 		fmt.Fprintf(os.Stderr, "unknown progress type %q\n", progress)
 		os.Exit(1)
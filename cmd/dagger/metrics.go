@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/dagger/dagger/dagql/dagui"
+	"github.com/dagger/dagger/dagql/dagui/metrics"
+)
+
+var (
+	metricsAddr = os.Getenv("DAGGER_METRICS")
+
+	// metricsServer serves Prometheus metrics for the current run, if
+	// enabled.
+	metricsServer *metrics.Server
+)
+
+// startMetrics serves Prometheus metrics for the run, if enabled, returning a
+// function to stop serving them.
+func startMetrics(ctx context.Context) (func(), error) {
+	if metricsAddr == "" {
+		return func() {}, nil
+	}
+	l, err := net.Listen("tcp", metricsAddr)
+	if err != nil {
+		return nil, fmt.Errorf("metrics: %w", err)
+	}
+	db := dagui.NewDB()
+	db.SetRetention(opts.Retention)
+	metricsServer = metrics.NewServer(db)
+	if recordTraces {
+		metricsServer.TraceStore = traceStore()
+	}
+	fmt.Fprintf(os.Stderr, "Serving metrics at http://%s/metrics\n", l.Addr())
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serveHTTP(ctx, l, metricsServer)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}, nil
+}
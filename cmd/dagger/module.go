@@ -725,8 +725,12 @@ func getModuleConfigurationForSourceRef(
 ) (*configuredModule, error) {
 	conf := &configuredModule{}
 
+	srcRefStr, err := offlineModuleRef(srcRefStr)
+	if err != nil {
+		return nil, err
+	}
+
 	conf.Source = dag.ModuleSource(srcRefStr, srcOpts...)
-	var err error
 	conf.SourceKind, err = conf.Source.Kind(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get module ref kind: %w", err)
@@ -903,6 +903,7 @@ func (f *modField) AsFunction() *modFunction {
 type modFunction struct {
 	Name        string
 	Description string
+	Daemon      bool
 	ReturnType  *modTypeDef
 	Args        []*modFunctionArg
 	cmdName     string
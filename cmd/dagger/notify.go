@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+
+	sddaemon "github.com/coreos/go-systemd/v22/daemon"
+
+	"github.com/dagger/dagger/dagql/dagui"
+	"github.com/dagger/dagger/engine/slog"
+)
+
+var notify, _ = strconv.ParseBool(os.Getenv("DAGGER_NOTIFY"))
+
+// notifyTimeout bounds how long we wait on the desktop notification daemon,
+// so that a missing or stuck one never holds up the CLI.
+const notifyTimeout = 5 * time.Second
+
+// notifyCompletion sends a notification with send, and a systemd status
+// update when running as a service, reporting the outcome of the run.
+func notifyCompletion(db *dagui.DB, send func(title, body string)) {
+	if !notify {
+		return
+	}
+	primary := db.Spans.Map[db.PrimarySpan]
+	if primary == nil {
+		return
+	}
+	status := "succeeded"
+	if primary.IsFailedOrCausedFailure() {
+		status = "failed"
+	}
+	title := fmt.Sprintf("Dagger %s", status)
+	body := fmt.Sprintf("%s %s in %s", primary.Name, status,
+		dagui.FormatDuration(primary.EndTimeOrNow().Sub(primary.StartTime)))
+
+	if os.Getenv("NOTIFY_SOCKET") != "" {
+		if _, err := sddaemon.SdNotify(false, "STATUS="+body); err != nil {
+			slog.Debug("failed to notify systemd", "error", err)
+		}
+	}
+
+	send(title, body)
+}
+
+// sendDesktopNotification shows a notification on the desktop, if the OS has
+// a way to.
+func sendDesktopNotification(title, body string) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd":
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=dagger", title, body)
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "osascript", "-e",
+			fmt.Sprintf("display notification %q with title %q", body, title))
+	default:
+		return
+	}
+	if err := cmd.Run(); err != nil {
+		slog.Debug("failed to send desktop notification", "error", err)
+	}
+}
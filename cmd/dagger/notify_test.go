@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+func TestNotifyCompletion(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	defer func(old bool) { notify = old }(notify)
+
+	type notification struct{ title, body string }
+	run := func(status sdktrace.Status) []notification {
+		start := time.Now().Add(-time.Hour)
+		db := dagui.NewDB()
+		db.SetPrimarySpan(dagui.SpanID{SpanID: trace.SpanID{1}})
+		db.ImportSnapshots([]dagui.SpanSnapshot{{
+			ID:        dagui.SpanID{SpanID: trace.SpanID{1}},
+			TraceID:   dagui.TraceID{TraceID: trace.TraceID{1}},
+			Name:      "dagger call test",
+			StartTime: start,
+			EndTime:   start.Add(90 * time.Second),
+			Status:    status,
+		}})
+		var sent []notification
+		notifyCompletion(db, func(title, body string) {
+			sent = append(sent, notification{title, body})
+		})
+		return sent
+	}
+
+	notify = false
+	require.Empty(t, run(sdktrace.Status{Code: codes.Ok}))
+
+	notify = true
+	require.Equal(t, []notification{{
+		title: "Dagger succeeded",
+		body:  "dagger call test succeeded in 1m30s",
+	}}, run(sdktrace.Status{Code: codes.Ok}))
+	require.Equal(t, []notification{{
+		title: "Dagger failed",
+		body:  "dagger call test failed in 1m30s",
+	}}, run(sdktrace.Status{Code: codes.Error, Description: "exit code: 1"}))
+
+	// nothing is sent for a run that never got a primary span
+	var sent bool
+	notifyCompletion(dagui.NewDB(), func(string, string) { sent = true })
+	require.False(t, sent)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"mvdan.cc/sh/v3/syntax"
+
+	"dagger.io/dagger/telemetry"
+	"github.com/dagger/dagger/dagql/idtui"
+	"github.com/dagger/dagger/engine/client"
+)
+
+var playbookCmd = &cobra.Command{
+	Use:   "playbook [options] <file>",
+	Short: "Run a sequence of function calls from a file",
+	Long: `Run a sequence of function calls from a file.
+
+A playbook lists steps, each calling a function like in dagger shell. Each
+step's result is stored under its name, so later steps can pass it to the
+functions they call, e.g. "$build". The steps run one after the other in a
+single session, and stop at the first one that fails.`,
+	Example: `# playbook.yaml:
+#
+# steps:
+#   - name: build
+#     call: build --src .
+#   - name: test
+#     call: test --binary $build
+#   - name: publish
+#     call: publish --binary $build --tag v1.2.3
+
+dagger playbook playbook.yaml`,
+	Args: cobra.ExactArgs(1),
+	Annotations: map[string]string{
+		"experimental": "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		contents, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		pb, err := parsePlaybook(args[0], contents)
+		if err != nil {
+			return err
+		}
+		cmd.SetContext(idtui.WithPrintTraceLink(cmd.Context(), true))
+		return withEngine(cmd.Context(), client.Params{}, func(ctx context.Context, engineClient *client.Client) error {
+			handler := &shellCallHandler{
+				dag:    engineClient.Dagger(),
+				stdin:  cmd.InOrStdin(),
+				stdout: cmd.OutOrStdout(),
+				stderr: cmd.ErrOrStderr(),
+				debug:  debug,
+			}
+			if err := handler.Initialize(ctx); err != nil {
+				return err
+			}
+			for _, step := range pb.Steps {
+				if err := handler.runPlaybookStep(ctx, step); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(playbookCmd)
+}
+
+// Playbook is a sequence of function calls run in a single session, each
+// able to use the results of the previous ones.
+type Playbook struct {
+	Steps []PlaybookStep `yaml:"steps"`
+}
+
+// PlaybookStep is a function call of a playbook.
+type PlaybookStep struct {
+	// Name is the name the step's result is stored under, as a dagger shell
+	// variable, e.g. "build" for "$build".
+	Name string `yaml:"name"`
+
+	// Call is the call to make, in dagger shell syntax, e.g.
+	// "build --src . | with-exec go test ./...".
+	Call string `yaml:"call"`
+}
+
+var playbookStepName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parsePlaybook parses and checks a playbook, so that mistakes like referring
+// to a step before it runs are caught before running any of it.
+func parsePlaybook(name string, contents []byte) (*Playbook, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(contents))
+	dec.KnownFields(true)
+	var pb Playbook
+	if err := dec.Decode(&pb); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", name, err)
+	}
+	if len(pb.Steps) == 0 {
+		return nil, fmt.Errorf("parse %s: no steps", name)
+	}
+	steps := map[string]int{}
+	for i, step := range pb.Steps {
+		if _, ok := steps[step.Name]; !ok {
+			steps[step.Name] = i
+		}
+	}
+	var errs []error
+	for i, step := range pb.Steps {
+		if !playbookStepName.MatchString(step.Name) {
+			errs = append(errs, fmt.Errorf("step %d: invalid name %q: must be letters, digits, and underscores", i+1, step.Name))
+			continue
+		}
+		if steps[step.Name] != i {
+			errs = append(errs, fmt.Errorf("step %q: name already used by step %d", step.Name, steps[step.Name]+1))
+			continue
+		}
+		if strings.TrimSpace(step.Call) == "" {
+			errs = append(errs, fmt.Errorf("step %q: no call", step.Name))
+			continue
+		}
+		file, err := parseShell(strings.NewReader(step.Call), step.Name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("step %q: %w", step.Name, err))
+			continue
+		}
+		syntax.Walk(file, func(node syntax.Node) bool {
+			param, ok := node.(*syntax.ParamExp)
+			if !ok || param.Param == nil {
+				return true
+			}
+			if ref, ok := steps[param.Param.Value]; ok && ref >= i {
+				errs = append(errs, fmt.Errorf("step %q: refers to %q, which hasn't run yet", step.Name, param.Param.Value))
+			}
+			return true
+		})
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("parse %s: %w", name, errors.Join(errs...))
+	}
+	return &pb, nil
+}
+
+// runPlaybookStep runs a step in its own span, so that steps are shown side
+// by side, storing its result under its name and printing it.
+func (h *shellCallHandler) runPlaybookStep(ctx context.Context, step PlaybookStep) (rerr error) {
+	ctx, span := Tracer().Start(ctx, step.Name)
+	defer telemetry.End(span, func() error { return rerr })
+	// the variable holds the call, unevaluated, or the error of making it
+	if err := h.run(ctx, strings.NewReader(step.Name+"=$("+step.Call+")"), step.Name); err != nil {
+		return err
+	}
+	// printing the result evaluates it, failing if the call failed
+	return h.run(ctx, strings.NewReader("$"+step.Name), step.Name)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePlaybook(t *testing.T) {
+	pb, err := parsePlaybook("playbook.yaml", []byte(`
+steps:
+  - name: build
+    call: build --src .
+  - name: test
+    call: test --binary $build | stdout
+`))
+	require.NoError(t, err)
+	require.Equal(t, []PlaybookStep{
+		{Name: "build", Call: "build --src ."},
+		{Name: "test", Call: "test --binary $build | stdout"},
+	}, pb.Steps)
+
+	_, err = parsePlaybook("playbook.yaml", []byte(`
+steps:
+  - name: test
+    call: test --binary $build
+  - name: build
+    call: build --src .
+  - name: build
+    call: build --src ./other
+  - name: bad-name
+    call: build
+  - name: empty
+`))
+	require.ErrorContains(t, err, `step "test": refers to "build", which hasn't run yet`)
+	require.ErrorContains(t, err, `step "build": name already used by step 2`)
+	require.ErrorContains(t, err, `step 4: invalid name "bad-name"`)
+	require.ErrorContains(t, err, `step "empty": no call`)
+
+	_, err = parsePlaybook("playbook.yaml", []byte("steps:\n  - name: build\n    cal: build\n"))
+	require.ErrorContains(t, err, "field cal not found")
+
+	_, err = parsePlaybook("playbook.yaml", []byte("steps: []\n"))
+	require.ErrorContains(t, err, "no steps")
+}
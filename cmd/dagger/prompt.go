@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"dagger.io/dagger"
+	"dagger.io/dagger/telemetry"
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/term"
+)
+
+// promptMissingArgs prompts for the values of the required arguments of a
+// function that weren't set on the command line, when running in an
+// interactive terminal. The prompted arguments are recorded on the run's
+// span, without their values.
+func (fc *FuncCommand) promptMissingArgs(c *cobra.Command, fn *modFunction) error {
+	if !canPrompt() {
+		return nil
+	}
+	var missing []*modFunctionArg
+	for _, arg := range fn.SupportedArgs() {
+		if !arg.IsRequired() {
+			continue
+		}
+		flag, err := arg.GetFlag(c.Flags())
+		if err != nil {
+			return err
+		}
+		if !flag.Changed {
+			missing = append(missing, arg)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	err := withPromptTerminal(func(stdin io.Reader, stdout io.Writer) error {
+		in := bufio.NewReader(stdin)
+		if fn.Name == "" {
+			fmt.Fprintf(stdout, "Missing required arguments:\n")
+		} else {
+			fmt.Fprintf(stdout, "Missing required arguments for %s:\n", fn.CmdName())
+		}
+		for _, arg := range missing {
+			if err := promptArg(in, stdout, c, arg); err != nil {
+				return err
+			}
+			fc.prompted = append(fc.prompted, c.Name()+" --"+arg.FlagName())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	trace.SpanFromContext(c.Context()).SetAttributes(
+		attribute.StringSlice(telemetry.PromptedArgsAttr, fc.prompted))
+	return nil
+}
+
+// promptArg prompts for the value of an argument until it's valid for its
+// flag, with an input suited to its type.
+func promptArg(in *bufio.Reader, out io.Writer, c *cobra.Command, arg *modFunctionArg) error {
+	flag, err := arg.GetFlag(c.Flags())
+	if err != nil {
+		return err
+	}
+	label := "--" + arg.FlagName()
+	if hint := promptHint(arg.TypeDef); hint != "" {
+		label += " (" + hint + ")"
+	}
+	if arg.Description != "" {
+		fmt.Fprintf(out, "  %s\n", strings.SplitN(arg.Description, "\n", 2)[0])
+	}
+	for {
+		fmt.Fprintf(out, "%s: ", label)
+		if secret, ok := flag.Value.(*secretValue); ok {
+			// read the plaintext without echoing it
+			plaintext, err := term.ReadPassword(int(os.Stdin.Fd()))
+			fmt.Fprintln(out)
+			if err != nil {
+				return fmt.Errorf("read %s: %w", label, err)
+			}
+			if len(plaintext) == 0 {
+				continue
+			}
+			secret.plaintext = string(plaintext)
+			flag.Changed = true
+			return nil
+		}
+		line, err := in.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("read %s: %w", label, err)
+		}
+		value := strings.TrimSpace(line)
+		if value == "" {
+			continue
+		}
+		if arg.TypeDef.Kind == dagger.TypeDefKindBooleanKind {
+			switch strings.ToLower(value) {
+			case "y", "yes":
+				value = "true"
+			case "n", "no":
+				value = "false"
+			}
+		}
+		if err := c.Flags().Set(arg.FlagName(), value); err != nil {
+			fmt.Fprintf(out, "  invalid value: %s\n", err)
+			continue
+		}
+		return nil
+	}
+}
+
+// promptHint describes the values expected for a type.
+func promptHint(typeDef *modTypeDef) string {
+	switch typeDef.Kind {
+	case dagger.TypeDefKindBooleanKind:
+		return "y/n"
+	case dagger.TypeDefKindIntegerKind:
+		return "integer"
+	case dagger.TypeDefKindFloatKind:
+		return "number"
+	case dagger.TypeDefKindEnumKind:
+		names := make([]string, 0, len(typeDef.AsEnum.Values))
+		for _, val := range typeDef.AsEnum.Values {
+			names = append(names, val.Name)
+		}
+		return "one of " + strings.Join(names, ", ")
+	case dagger.TypeDefKindListKind:
+		return "comma-separated " + typeDef.AsList.ElementTypeDef.String() + " values"
+	case dagger.TypeDefKindObjectKind:
+		switch typeDef.AsObject.Name {
+		case Secret:
+			return "secret, hidden"
+		case Directory, File:
+			return "path or git URL"
+		case Container:
+			return "image address"
+		}
+	}
+	return typeDef.String()
+}
+
+// canPrompt returns whether the user can be prompted for input, i.e. both
+// stdin and the progress output are terminals.
+func canPrompt() bool {
+	return !silent && hasTTY && term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// withPromptTerminal runs fn with the terminal, suspending the TUI if it's
+// running.
+func withPromptTerminal(fn func(stdin io.Reader, stdout io.Writer) error) error {
+	terminalMu.Lock()
+	defer terminalMu.Unlock()
+	if progress == "auto" && hasTTY || progress == "tty" {
+		return Frontend.Background(&terminalSession{
+			fn: func(stdin io.Reader, stdout, _ io.Writer) error {
+				return fn(stdin, stdout)
+			},
+		}, false)
+	}
+	return fn(os.Stdin, os.Stderr)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"dagger.io/dagger"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromptArg(t *testing.T) {
+	cmd := &cobra.Command{Use: "build"}
+	args := []*modFunctionArg{
+		{Name: "jobs", TypeDef: &modTypeDef{Kind: dagger.TypeDefKindIntegerKind}},
+		{Name: "race", TypeDef: &modTypeDef{Kind: dagger.TypeDefKindBooleanKind}},
+		{Name: "mode", TypeDef: &modTypeDef{
+			Kind: dagger.TypeDefKindEnumKind,
+			AsEnum: &modEnum{Name: "Mode", Values: []*modEnumValue{
+				{Name: "DEBUG"},
+				{Name: "RELEASE"},
+			}},
+		}},
+	}
+	for _, arg := range args {
+		require.NoError(t, arg.AddFlag(cmd.Flags()))
+	}
+
+	// invalid and empty values are asked for again
+	in := bufio.NewReader(strings.NewReader("many\n\n4\ny\nfast\nrelease\n"))
+	var out strings.Builder
+	for _, arg := range args {
+		require.NoError(t, promptArg(in, &out, cmd, arg))
+	}
+	require.Equal(t, "4", cmd.Flags().Lookup("jobs").Value.String())
+	require.Equal(t, "true", cmd.Flags().Lookup("race").Value.String())
+	require.Equal(t, "RELEASE", cmd.Flags().Lookup("mode").Value.String())
+	for _, arg := range args {
+		require.True(t, cmd.Flags().Lookup(arg.FlagName()).Changed)
+	}
+
+	require.Contains(t, out.String(), "--jobs (integer): ")
+	require.Contains(t, out.String(), "invalid value")
+	require.Contains(t, out.String(), "--race (y/n): ")
+	require.Contains(t, out.String(), "--mode (one of DEBUG, RELEASE): ")
+}
@@ -12,6 +12,7 @@ import (
 	"golang.org/x/term"
 
 	"dagger.io/dagger"
+	"github.com/dagger/dagger/dagql/call"
 	"github.com/dagger/dagger/dagql/idtui"
 	"github.com/dagger/dagger/engine/client"
 )
@@ -122,7 +123,74 @@ func getKVInput(kvs []string) map[string]interface{} {
 	return m
 }
 
+var queryIDCmd = &cobra.Command{
+	Use:   "id",
+	Short: "Inspect object IDs",
+}
+
+var queryIDDecodeCmd = &cobra.Command{
+	Use:   "decode <id>",
+	Short: "Decode an object ID into the chain of calls that produced it",
+	Long: `Decode an object ID into the chain of calls that produced it.
+
+The output is stable JSON, so that IDs can be compared with standard diffing
+tools. Pass - to read the ID from stdin.`,
+	Example: `dagger query id decode "$(dagger -c 'container | from alpine | id')"`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := readIDArg(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(id.Decoded())
+	},
+}
+
+var queryIDCanonicalCmd = &cobra.Command{
+	Use:   "canonical <id>",
+	Short: "Canonicalize an object ID",
+	Long: `Canonicalize an object ID, stripping fields that do not affect its result.
+
+IDs which would produce the same result have the same canonical ID, regardless
+of how they were constructed. Pass - to read the ID from stdin.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := readIDArg(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		encoded, err := id.Canonical().Encode()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(cmd.OutOrStdout(), encoded)
+		return err
+	},
+}
+
+// readIDArg decodes an ID passed as an argument, or read from stdin if "-".
+func readIDArg(cmd *cobra.Command, encoded string) (*call.ID, error) {
+	if encoded == "-" {
+		in, err := io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return nil, err
+		}
+		encoded = string(in)
+	}
+	var id call.ID
+	if err := id.Decode(strings.TrimSpace(encoded)); err != nil {
+		return nil, fmt.Errorf("invalid ID: %w", err)
+	}
+	return &id, nil
+}
+
 func init() {
+	queryIDCmd.AddCommand(queryIDDecodeCmd)
+	queryIDCmd.AddCommand(queryIDCanonicalCmd)
+	queryCmd.AddCommand(queryIDCmd)
+
 	queryCmd.Flags().StringVar(&queryFile, "doc", "", "Read query from file (defaults to reading from stdin)")
 	queryCmd.Flags().StringSliceVar(&queryVarsInput, "var", nil, "List of query variables, in key=value format")
 	queryCmd.Flags().StringVar(&queryVarsJSONInput, "var-json", "", "Query variables in JSON format (overrides --var)")
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+var (
+	reportFlags []string
+
+	// reportPath is where to write the report for git bisect run, if any.
+	reportPath string
+	// junitReportPath is where to write a JUnit XML report of the run's
+	// tests, if any.
+	junitReportPath string
+)
+
+func init() {
+	if path := os.Getenv("DAGGER_REPORT"); path != "" {
+		reportFlags = append(reportFlags, path)
+	}
+}
+
+// parseReports parses the --report flags: junit=<path> for a JUnit XML
+// report, or a path for the git bisect run report.
+func parseReports() error {
+	reportPath, junitReportPath = "", ""
+	for _, str := range reportFlags {
+		if path, ok := strings.CutPrefix(str, "junit="); ok {
+			if path == "" {
+				return fmt.Errorf("invalid report %q: missing path", str)
+			}
+			junitReportPath = path
+			continue
+		}
+		reportPath = str
+	}
+	return nil
+}
+
+// writeJUnitReport writes the JUnit XML report of the completed run's tests
+// to junitReportPath. Failing to write it fails a run that otherwise
+// succeeded, since CI would otherwise miss its test results.
+func writeJUnitReport(w io.Writer, db *dagui.DB, runErr error) error {
+	var buf bytes.Buffer
+	err := db.JUnitReport(opts.Quarantine).WriteXML(&buf)
+	if err == nil {
+		err = os.WriteFile(junitReportPath, buf.Bytes(), 0o644)
+	}
+	if err != nil {
+		fmt.Fprintln(w, rootCmd.ErrPrefix(), "write JUnit report:", err)
+		if runErr == nil {
+			return ExitError{Code: 1}
+		}
+	}
+	return runErr
+}
+
+// writeReport writes the report of the completed run to reportPath, and
+// replaces the run's error with one that exits with the report's exit code,
+// so that the CLI can be used with git bisect run. Errors that would
+// otherwise be printed by main are printed to w first.
+func writeReport(w io.Writer, db *dagui.DB, connected bool, runErr error) error {
+	report := db.Report(opts.Quarantine)
+	switch {
+	case runErr == nil:
+		// failures the run recovered from don't count
+		report.Result = dagui.ReportPass
+		report.Reason = ""
+	case errors.Is(runErr, context.Canceled):
+		report.Result = dagui.ReportAbort
+		report.Reason = "interrupted"
+	case !connected:
+		report.Result = dagui.ReportSkip
+		report.Reason = "engine unavailable"
+	case report.Result == dagui.ReportPass:
+		// failed after the run completed, e.g. an SLO violation
+		report.Result = dagui.ReportFail
+		report.Reason = errorReason(runErr)
+	}
+	var exit ExitError
+	if runErr != nil && !errors.As(runErr, &exit) && !errors.Is(runErr, context.Canceled) {
+		fmt.Fprintln(w, rootCmd.ErrPrefix(), runErr)
+	}
+	var buf bytes.Buffer
+	if err := report.WriteText(&buf); err != nil {
+		return err
+	}
+	if err := os.WriteFile(reportPath, buf.Bytes(), 0o644); err != nil {
+		// without a report, there's no telling what happened; stop bisecting
+		fmt.Fprintln(w, rootCmd.ErrPrefix(), "write report:", err)
+		return ExitError{Code: dagui.ReportAbort.ExitCode()}
+	}
+	if code := report.Result.ExitCode(); code != 0 {
+		return ExitError{Code: code}
+	}
+	return nil
+}
+
+func errorReason(err error) string {
+	var exit ExitError
+	if errors.As(err, &exit) {
+		return fmt.Sprintf("exit code %d", exit.Code)
+	}
+	return err.Error()
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"dagger.io/dagger"
+	"github.com/dagger/dagger/dagql/call"
+	"github.com/dagger/dagger/engine/client"
+)
+
+var rerunFrom string
+
+var rerunCmd = &cobra.Command{
+	Use:   "rerun [options] [trace]",
+	Short: "Resume a recorded run from a failed call",
+	Long: `Resume a recorded run from a failed call.
+
+The call is evaluated again from the IDs recorded in the trace, along with the
+calls made at the end of the run that depended on it. Everything leading up
+to it is reused from the cache, so the run resumes where it failed. Defaults
+to the first failed call of the latest trace.`,
+	Example: `dagger rerun
+dagger rerun --from 3c5a7f1e9b2d4680`,
+	Args: cobra.MaximumNArgs(1),
+	Annotations: map[string]string{
+		"experimental": "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, _, err := loadTrace(args)
+		if err != nil {
+			return err
+		}
+		plan, err := db.PlanRerun(rerunFrom)
+		if err != nil {
+			return err
+		}
+		ids := make([]*call.ID, len(plan.Calls))
+		for i, enc := range plan.Calls {
+			var id call.ID
+			if err := id.Decode(enc); err != nil {
+				return fmt.Errorf("decode call: %w", err)
+			}
+			ids[i] = &id
+		}
+		return withEngine(cmd.Context(), client.Params{}, func(ctx context.Context, engineClient *client.Client) error {
+			dag := engineClient.Dagger()
+			for _, mod := range plan.Modules {
+				if err := dag.Do(ctx, &dagger.Request{
+					Query:     `query Serve($id: ModuleID!) { loadModuleFromID(id: $id) { serve } }`,
+					Variables: map[string]any{"id": mod},
+				}, &dagger.Response{}); err != nil {
+					return fmt.Errorf("serve module: %w", err)
+				}
+			}
+			kinds, err := typeKinds(ctx, dag)
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				req, err := rerunRequest(id, kinds)
+				if err != nil {
+					return err
+				}
+				if err := dag.Do(ctx, req, &dagger.Response{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	},
+}
+
+func init() {
+	rerunCmd.Flags().StringVar(&rerunFrom, "from", "", "Span ID or call digest of the call to resume from")
+
+	rootCmd.AddCommand(rerunCmd)
+}
+
+// typeKinds returns the kind of each type in the schema, e.g. OBJECT.
+func typeKinds(ctx context.Context, dag *dagger.Client) (map[string]string, error) {
+	var res struct {
+		Schema struct {
+			Types []struct {
+				Name string
+				Kind string
+			}
+		} `json:"__schema"`
+	}
+	if err := dag.Do(ctx, &dagger.Request{
+		Query: `query Kinds { __schema { types { name kind } } }`,
+	}, &dagger.Response{Data: &res}); err != nil {
+		return nil, fmt.Errorf("introspect schema: %w", err)
+	}
+	kinds := make(map[string]string, len(res.Schema.Types))
+	for _, t := range res.Schema.Types {
+		kinds[t.Name] = t.Kind
+	}
+	return kinds, nil
+}
+
+// rerunRequest builds a request that evaluates the call again. Objects are
+// loaded from their ID, forcing any lazy evaluation with a sync; other values
+// are selected again from their receiver.
+func rerunRequest(id *call.ID, kinds map[string]string) (*dagger.Request, error) {
+	typ := id.Type().ToAST()
+	if typ.Elem == nil && kinds[typ.NamedType] == "OBJECT" {
+		enc, err := id.Encode()
+		if err != nil {
+			return nil, err
+		}
+		sel := "id"
+		switch typ.NamedType {
+		case "Container", "Directory", "File":
+			sel = "sync"
+		}
+		return &dagger.Request{
+			Query:     fmt.Sprintf(`query Rerun($id: %[1]sID!) { load%[1]sFromID(id: $id) { %[2]s } }`, typ.NamedType, sel),
+			Variables: map[string]any{"id": enc},
+		}, nil
+	}
+
+	var field strings.Builder
+	field.WriteString(id.Field())
+	if args := id.Args(); len(args) > 0 {
+		field.WriteString("(")
+		for i, arg := range args {
+			if i > 0 {
+				field.WriteString(", ")
+			}
+			fmt.Fprintf(&field, "%s: %s", arg.Name(), arg.Value().ToAST())
+		}
+		field.WriteString(")")
+	}
+	if typ.Elem != nil && kinds[typ.Elem.Name()] == "OBJECT" {
+		field.WriteString(" { id }")
+	}
+
+	recv := id.Receiver()
+	if recv == nil {
+		return &dagger.Request{
+			Query: fmt.Sprintf(`query Rerun { %s }`, field.String()),
+		}, nil
+	}
+	enc, err := recv.Encode()
+	if err != nil {
+		return nil, err
+	}
+	recvType := recv.Type().ToAST().Name()
+	return &dagger.Request{
+		Query:     fmt.Sprintf(`query Rerun($id: %[1]sID!) { load%[1]sFromID(id: $id) { %[2]s } }`, recvType, field.String()),
+		Variables: map[string]any{"id": enc},
+	}, nil
+}
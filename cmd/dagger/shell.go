@@ -167,6 +167,38 @@ type shellCallHandler struct {
 // - File: when a file path is provided as an argument
 // - Code: when code is passed inline using the `-c,--code` flag or via stdin
 func (h *shellCallHandler) RunAll(ctx context.Context, args []string) error {
+	if err := h.Initialize(ctx); err != nil {
+		return err
+	}
+
+	// Example: `dagger shell -c 'container | workdir'`
+	if shellCode != "" {
+		return h.run(ctx, strings.NewReader(shellCode), "")
+	}
+
+	// Use stdin only when no file paths are provided
+	if len(args) == 0 {
+		// Example: `dagger shell`
+		if isatty.IsTerminal(os.Stdin.Fd()) {
+			return h.runInteractive(ctx)
+		}
+		// Example: `echo 'container | workdir' | dagger shell`
+		return h.run(ctx, os.Stdin, "-")
+	}
+
+	// Example: `dagger shell job1.dsh job2.dsh`
+	for _, path := range args {
+		if err := h.runPath(ctx, path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Initialize creates the runner and loads the default module, if any, so
+// that code can be run.
+func (h *shellCallHandler) Initialize(ctx context.Context) error {
 	h.tty = !silent && (hasTTY && progress == "auto" || progress == "tty")
 
 	h.stdoutWriter = newTerminalWriter(func(b []byte) (int, error) {
@@ -250,28 +282,6 @@ func (h *shellCallHandler) RunAll(ctx context.Context, args []string) error {
 	h.modDefs.Store(ref, def)
 	h.registerCommands()
 
-	// Example: `dagger shell -c 'container | workdir'`
-	if shellCode != "" {
-		return h.run(ctx, strings.NewReader(shellCode), "")
-	}
-
-	// Use stdin only when no file paths are provided
-	if len(args) == 0 {
-		// Example: `dagger shell`
-		if isatty.IsTerminal(os.Stdin.Fd()) {
-			return h.runInteractive(ctx)
-		}
-		// Example: `echo 'container | workdir' | dagger shell`
-		return h.run(ctx, os.Stdin, "-")
-	}
-
-	// Example: `dagger shell job1.dsh job2.dsh`
-	for _, path := range args {
-		if err := h.runPath(ctx, path); err != nil {
-			return err
-		}
-	}
-
 	return nil
 }
 
@@ -324,7 +334,11 @@ func (h *shellCallHandler) run(ctx context.Context, reader io.Reader, name strin
 		return buf.Bytes(), err
 	})
 
-	err = h.runner.Run(ctx, file)
+	if keepGoing && !h.repl {
+		err = h.runKeepGoing(ctx, file)
+	} else {
+		err = h.runner.Run(ctx, file)
+	}
 	if exit, ok := interp.IsExitStatus(err); ok {
 		if int(exit) != shellHandlerExit {
 			return ExitError{Code: int(exit)}
@@ -334,6 +348,22 @@ func (h *shellCallHandler) run(ctx context.Context, reader io.Reader, name strin
 	return err
 }
 
+// runKeepGoing runs each of the file's statements even if earlier ones
+// failed, like make -k, returning the error of the last one that failed.
+func (h *shellCallHandler) runKeepGoing(ctx context.Context, file *syntax.File) error {
+	var failed error
+	for _, stmt := range file.Stmts {
+		err := h.runner.Run(ctx, stmt)
+		if h.runner.Exited() {
+			return err
+		}
+		if err != nil {
+			failed = err
+		}
+	}
+	return failed
+}
+
 func (h *shellCallHandler) checkExecError(err error) error {
 	exitCode := 1
 	var ex *dagger.ExecError
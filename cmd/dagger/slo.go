@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+// sloViolationExitCode is the exit code used when a run succeeds but fails a
+// performance gate, distinguishing slow runs from broken ones.
+const sloViolationExitCode = 3
+
+var sloFlags []string
+
+func parseSLOs() ([]dagui.SLO, error) {
+	slos := make([]dagui.SLO, 0, len(sloFlags))
+	for _, str := range sloFlags {
+		slo, err := dagui.ParseSLO(str)
+		if err != nil {
+			return nil, err
+		}
+		slos = append(slos, slo)
+	}
+	return slos, nil
+}
+
+// checkSLOs evaluates the SLOs against the completed run, reporting any
+// violations to w.
+func checkSLOs(w io.Writer, db *dagui.DB, slos []dagui.SLO) error {
+	var violated bool
+	for _, slo := range slos {
+		res := slo.Evaluate(db)
+		if res.OK {
+			continue
+		}
+		violated = true
+		fmt.Fprintf(w, "SLO violated: %s (actual: %s)\n", res.SLO, res.Actual)
+	}
+	if violated {
+		return ExitError{Code: sloViolationExitCode}
+	}
+	return nil
+}
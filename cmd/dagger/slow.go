@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dagger/dagger/dagql/dagui"
+	"github.com/dagger/dagger/engine/slog"
+)
+
+var (
+	slowThresholdFlags []string
+	slowNotify         = os.Getenv("DAGGER_SLOW_NOTIFY")
+)
+
+func parseSlowThresholds() (dagui.SlowThresholds, error) {
+	ths := make(dagui.SlowThresholds, 0, len(slowThresholdFlags))
+	for _, str := range slowThresholdFlags {
+		th, err := dagui.ParseSlowThreshold(str)
+		if err != nil {
+			return nil, err
+		}
+		ths = append(ths, th)
+	}
+	return ths, nil
+}
+
+// slowSpanEvent is the JSON posted to the --slow-notify webhook.
+type slowSpanEvent struct {
+	SpanID    string `json:"spanId"`
+	Name      string `json:"name"`
+	Duration  string `json:"duration"`
+	Threshold string `json:"threshold"`
+}
+
+// slowSpanNotifier returns how to notify about slow spans for --slow-notify:
+// a desktop notification for "desktop", or a POST to a webhook URL.
+func slowSpanNotifier(target string) (func(dagui.SlowSpan), error) {
+	switch {
+	case target == "":
+		return nil, nil
+	case target == "desktop":
+		return func(span dagui.SlowSpan) {
+			sendDesktopNotification("Dagger step is slow",
+				fmt.Sprintf("%s has been running for %s, over %s", span.Name,
+					dagui.FormatDuration(span.Duration), dagui.FormatDuration(span.Threshold)))
+		}, nil
+	case strings.HasPrefix(target, "http://"), strings.HasPrefix(target, "https://"):
+		return func(span dagui.SlowSpan) {
+			err := postJSON(target, slowSpanEvent{
+				SpanID:    span.ID.String(),
+				Name:      span.Name,
+				Duration:  span.Duration.String(),
+				Threshold: span.Threshold.String(),
+			})
+			if err != nil {
+				slog.Warn("failed to notify about slow span", "span", span.Name, "error", err)
+			}
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid slow span notification %q: must be desktop or an http(s) URL", target)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dagger/dagger/dagql/dagui"
+	"github.com/dagger/dagger/dagql/dagui/statsd"
+)
+
+var (
+	statsdAddr = os.Getenv("DAGGER_STATSD")
+	statsdTags = splitStatsdTags(os.Getenv("DAGGER_STATSD_TAGS"))
+
+	// statsdExporter sends metrics for the current run to statsd, if
+	// enabled.
+	statsdExporter *statsd.Exporter
+)
+
+func splitStatsdTags(tags string) []string {
+	if tags == "" {
+		return nil
+	}
+	return strings.Split(tags, ",")
+}
+
+// startStatsd sends metrics for the run to statsd, if enabled, returning a
+// function to send the run's metrics once it completes.
+func startStatsd() (func(error), error) {
+	if statsdAddr == "" {
+		return func(error) {}, nil
+	}
+	conn, err := statsd.Dial(statsdAddr)
+	if err != nil {
+		return nil, fmt.Errorf("statsd: %w", err)
+	}
+	db := dagui.NewDB()
+	db.SetRetention(opts.Retention)
+	statsdExporter = statsd.NewExporter(db, conn, statsd.Tags(statsdTags))
+	return func(err error) {
+		statsdExporter.Finish(err)
+		conn.Close()
+	}, nil
+}
@@ -0,0 +1,668 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/juju/ansiterm/tabwriter"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	"dagger.io/dagger"
+	"github.com/dagger/dagger/dagql/dagui"
+	"github.com/dagger/dagger/dagql/idtui"
+	"github.com/dagger/dagger/engine/client"
+	"github.com/dagger/dagger/engine/slog"
+)
+
+var traceCmd = &cobra.Command{
+	Use:   "trace",
+	Short: "Inspect traces of previous runs",
+	Long: `Inspect traces of previous runs.
+
+Runs record their trace locally when they complete if run with
+--record-trace, or with DAGGER_RECORD_TRACE=1 set.`,
+	Annotations: map[string]string{
+		"experimental": "true",
+	},
+}
+
+var traceListLabels []string
+
+var traceListCmd = &cobra.Command{
+	Use:     "ls [options]",
+	Aliases: []string{"list"},
+	Short:   "List recorded traces",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := dagui.ParseLabels(traceListLabels)
+		if err != nil {
+			return err
+		}
+		metas, err := traceStore().List()
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', tabwriter.DiscardEmptyColumns)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", "TRACE", "STARTED", "DURATION", "STATUS", "NAME", "LABELS")
+		for _, meta := range metas {
+			if !dagui.MatchesLabels(meta.Labels, filter) {
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				meta.TraceID,
+				meta.StartTime.Local().Format(time.DateTime),
+				dagui.FormatDuration(meta.Duration()),
+				traceStatus(meta),
+				meta.Name,
+				dagui.FormatLabels(meta.Labels),
+			)
+		}
+		return tw.Flush()
+	},
+}
+
+var traceManifestCmd = &cobra.Command{
+	Use:   "manifest [options] [trace]",
+	Short: "Print the images and modules used by a trace",
+	Long: `Print the images and modules used by a trace, as JSON.
+
+The output can be passed to "dagger engine warmup --from" to pre-fetch
+everything ahead of a later run. Defaults to the latest trace.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, _, err := loadTrace(args)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(db.WarmupManifest())
+	},
+}
+
+var (
+	traceExportFormat string
+	traceExportOutput string
+	traceExportPreset string
+)
+
+var traceExportCmd = &cobra.Command{
+	Use:   "export [options] [trace]",
+	Short: "Export a trace, as JSON, OTLP, or for Perfetto",
+	Long: `Export a trace, as JSON, OTLP, or for Perfetto.
+
+By default, the tree of spans shown for the trace is printed as JSON. The same
+rules for hiding internal, encapsulated, and passthrough spans are applied as
+when the trace was displayed, so the output matches what users see. Use -v and
+--debug to reveal more spans.
+
+With --format otlp or otlp-proto, every span, log, and metric of the trace is
+written to traces, logs, and metrics files in the --output directory, as OTLP
+JSON or protobuf, so the run can be imported into other tools like Jaeger,
+Tempo, or Honeycomb.
+
+With --preset honeycomb, the OTLP output is tuned for Honeycomb: array
+attributes are flattened, columns are derived for each span's operation,
+module, cache state, and failure category, and the service name, which
+Honeycomb uses as the dataset, is named after the run's module.
+
+With --format perfetto, the tree of spans is printed in the Chrome Trace Event
+format, to open in ui.perfetto.dev or chrome://tracing for a timeline of the
+run. Parallel steps are shown on separate tracks.
+
+Defaults to the latest trace.`,
+	Example: `dagger trace export --format otlp --output ./otlp
+dagger trace export --format otlp-proto --preset honeycomb --output ./otlp
+dagger trace export --format perfetto > trace.json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var otlpFormat string
+		switch traceExportFormat {
+		case "json", "perfetto":
+		case "otlp":
+			otlpFormat = dagui.OTLPFormatJSON
+		case "otlp-proto":
+			otlpFormat = dagui.OTLPFormatProto
+		default:
+			return fmt.Errorf("unknown format %q", traceExportFormat)
+		}
+		if otlpFormat != "" && traceExportOutput == "" {
+			return fmt.Errorf("--output is required with --format %s", traceExportFormat)
+		}
+		if otlpFormat == "" && traceExportPreset != "" {
+			return fmt.Errorf("--preset requires --format otlp or otlp-proto")
+		}
+		db, _, err := loadTrace(args)
+		if err != nil {
+			return err
+		}
+		if traceExportFormat == "perfetto" {
+			return db.WriteChromeTrace(cmd.OutOrStdout(), opts)
+		}
+		if otlpFormat == "" {
+			return db.WriteVisibleTree(cmd.OutOrStdout(), opts)
+		}
+		export, err := db.OTLPPreset(traceExportPreset)
+		if err != nil {
+			return err
+		}
+		paths, err := export.WriteFiles(traceExportOutput, otlpFormat)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			fmt.Fprintln(cmd.OutOrStdout(), path)
+		}
+		return nil
+	},
+}
+
+var (
+	traceQueryRuns      int
+	traceQueryFailMatch bool
+)
+
+var traceQueryCmd = &cobra.Command{
+	Use:   "query [options] <query> [trace]",
+	Short: "Find the spans of a trace that match a query",
+	Long: `Find the spans of a trace that match a query, printed as a JSON array.
+
+Queries compare span fields with literals, and combine comparisons with &&, ||,
+!, and parentheses. The name, id, parent, trace, call, status, and error fields
+are strings, compared with ==, !=, =~ (regexp match), and !~. The duration
+field is compared with durations like 1m30s. The cached, failed, canceled,
+skipped, internal, and running fields are booleans. Fields may be prefixed
+with "span.".
+
+AND, OR, NOT, =, and ~ may be used in place of &&, ||, !, ==, and =~, single
+words don't need quoting, and status=error matches failed spans.
+
+Defaults to the latest trace. Use --runs to search recent traces instead, and
+--fail-on-match to exit with an error if any spans match, e.g. for alerting.`,
+	Example: `dagger trace query 'span.duration > 30s && span.cached == false && name =~ "test"'
+dagger trace query --runs 10 --fail-on-match 'failed && !internal'
+dagger trace query 'status=error AND duration>30s AND name~"apt-get"'`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query, err := dagui.ParseSpanQuery(args[0])
+		if err != nil {
+			return fmt.Errorf("parse query: %w", err)
+		}
+		var dbs []*dagui.DB
+		if traceQueryRuns > 0 {
+			if len(args) > 1 {
+				return fmt.Errorf("--runs can't be used with a trace")
+			}
+			store := traceStore()
+			metas, err := store.List()
+			if err != nil {
+				return err
+			}
+			for _, meta := range metas[:min(traceQueryRuns, len(metas))] {
+				// queries only match spans, so leave out the logs
+				db, _, err := store.LoadWith(meta.TraceID.String(), dagui.TraceLoadOpts{SkipLogs: true})
+				if err != nil {
+					return err
+				}
+				dbs = append(dbs, db)
+			}
+		} else {
+			db, _, err := loadTrace(args[1:])
+			if err != nil {
+				return err
+			}
+			dbs = append(dbs, db)
+		}
+		var matches []*dagui.Span
+		for _, db := range dbs {
+			matches = append(matches, db.Select(query).Order...)
+		}
+		if err := dagui.WriteSpanMatches(cmd.OutOrStdout(), matches); err != nil {
+			return err
+		}
+		if traceQueryFailMatch && len(matches) > 0 {
+			return Fail
+		}
+		return nil
+	},
+}
+
+var traceVerifyCmd = &cobra.Command{
+	Use:   "verify [trace]",
+	Short: "Verify that a stored trace has not been modified",
+	Long: `Verify that a stored trace's spans and logs match the digest recorded when
+it was saved, e.g. before handing it over for an audit. Defaults to the latest
+trace.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id := "latest"
+		if len(args) > 0 {
+			id = args[0]
+		}
+		store := traceStore()
+		meta, err := store.Meta(id)
+		if err != nil {
+			return err
+		}
+		if err := store.Verify(meta); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s: OK %s\n", meta.TraceID, meta.Digest)
+		return nil
+	},
+}
+
+var traceHeatmapCmd = &cobra.Command{
+	Use:   "heatmap [options]",
+	Short: "Show how the duration of each step changed over recent runs",
+	Long: `Show how the duration of each step changed over recent runs, as a strip of
+cells per step colored by how each run compares to the step's usual duration.
+Steps that are gradually getting slower stand out at a glance.
+
+Steps are matched across runs by their logical identity, so they line up even
+as their inputs change. Use --format=html to write a standalone HTML page.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hist, err := traceStore().StepHistory(traceHeatmapRuns, traceHeatmapDepth, opts)
+		if err != nil {
+			return err
+		}
+		if len(hist.Runs) == 0 {
+			return errors.New("no traces stored")
+		}
+		switch traceHeatmapFormat {
+		case "text":
+			return idtui.RenderHeatmap(cmd.OutOrStdout(), hist, opts.Glyphs)
+		case "html":
+			return hist.WriteHTML(cmd.OutOrStdout())
+		default:
+			return fmt.Errorf("unknown format %q", traceHeatmapFormat)
+		}
+	},
+}
+
+var (
+	traceSeedTo string
+
+	traceHeatmapRuns   int
+	traceHeatmapDepth  int
+	traceHeatmapFormat string
+
+	traceSummaryFormat    string
+	traceSummarySlowest   int
+	traceSummaryLogLines  int
+	traceSummaryFlakyRuns int
+
+	traceCacheFormat string
+	traceCacheTop    int
+
+	traceEffectsFormat string
+
+	traceFailuresLogLines int
+
+	traceDiffFormat    string
+	traceDiffTolerance string
+	traceDiffMinDelta  time.Duration
+)
+
+var traceResumeCmd = &cobra.Command{
+	Use:   "resume [trace]",
+	Short: "Recover the trace of a run that didn't complete",
+	Long: `Recover the trace of a run that didn't complete, e.g. because the CLI
+crashed or was killed.
+
+Runs write their trace to a journal as it's received. The journal is
+rehydrated into the full trace, which is recorded alongside completed traces
+so that the other trace commands can inspect it, and then summarized.
+Defaults to the most recent journal.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store := traceStore()
+		id := "latest"
+		if len(args) > 0 {
+			id = args[0]
+		}
+		db, meta, err := store.Resume(id)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Recovered trace %s with %d spans\n\n", meta.TraceID, meta.Spans)
+		summary := db.Summary(traceSummarySlowest, traceSummaryLogLines, opts.Quarantine)
+		return summary.WriteText(cmd.OutOrStdout())
+	},
+}
+
+var traceSummaryCmd = &cobra.Command{
+	Use:   "summary [options] [trace]",
+	Short: "Summarize a trace",
+	Long: `Summarize a trace, including its status, duration, slowest steps, cache
+usage, and failures with the tail of their logs. Failures of steps that both
+passed and failed with the same inputs in recent runs are flagged as flaky.
+
+Use --format=md to render GitHub-flavored markdown, e.g. for CI to post as a
+pull request comment. Defaults to the latest trace.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, _, err := loadTrace(args)
+		if err != nil {
+			return err
+		}
+		summary := db.Summary(traceSummarySlowest, traceSummaryLogLines, opts.Quarantine)
+		flaky, err := traceStore().FlakySteps(traceSummaryFlakyRuns)
+		if err != nil {
+			return err
+		}
+		summary.MarkFlaky(flaky)
+		switch traceSummaryFormat {
+		case "text":
+			return summary.WriteText(cmd.OutOrStdout())
+		case "md", "markdown":
+			return summary.WriteMarkdown(cmd.OutOrStdout())
+		default:
+			return fmt.Errorf("unknown format %q", traceSummaryFormat)
+		}
+	},
+}
+
+var traceCacheCmd = &cobra.Command{
+	Use:   "cache [options] [trace]",
+	Short: "Analyze how a trace used the cache",
+	Long: `Analyze how a trace used the cache: the number of cached and executed
+calls, the time saved by cached calls, estimated from how long they took when
+they last ran, and the slowest calls that weren't cached.
+
+Use --format=json for a structured report, e.g. for CI to track over time.
+Defaults to the latest trace.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, _, err := loadTrace(args)
+		if err != nil {
+			return err
+		}
+		hist, err := traceStore().DurationHistory()
+		if err != nil {
+			return err
+		}
+		report := db.CacheReport(hist, traceCacheTop)
+		switch traceCacheFormat {
+		case "text":
+			return report.WriteText(cmd.OutOrStdout())
+		case "json":
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		default:
+			return fmt.Errorf("unknown format %q", traceCacheFormat)
+		}
+	},
+}
+
+var traceEffectsCmd = &cobra.Command{
+	Use:   "effects [options] [trace]",
+	Short: "Show the effects of a trace, to debug steps stuck pending",
+	Long: `Show the effects of a trace: which spans installed each effect, e.g. a
+call returning a container with an exec to run lazily, and which spans ran it.
+
+A step stays pending until all of its effects start, so an effect that never
+started, listed as pending, explains a step stuck pending. Only the effects that
+didn't complete are listed as text; use --format=json for all of them.
+Defaults to the latest trace.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, _, err := loadTrace(args)
+		if err != nil {
+			return err
+		}
+		graph := db.EffectGraph()
+		switch traceEffectsFormat {
+		case "text":
+			return graph.WriteText(cmd.OutOrStdout())
+		case "json":
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(graph)
+		default:
+			return fmt.Errorf("unknown format %q", traceEffectsFormat)
+		}
+	},
+}
+
+var traceFailuresCmd = &cobra.Command{
+	Use:   "failures [options] [trace]",
+	Short: "Report the failures of a trace as JSON",
+	Long: `Report the failures of a trace as JSON, e.g. for CI to turn into
+annotations.
+
+Each step that failed on its own is listed with its error, exit code, the tail
+of its logs, the steps its failure propagated through, and the chain of API
+calls that led to it. Failures are followed through links and effects, so
+steps that failed independently are all listed. Defaults to the latest trace.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, _, err := loadTrace(args)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(db.FailureReport(traceFailuresLogLines))
+	},
+}
+
+var traceDiffCmd = &cobra.Command{
+	Use:   "diff [options] <trace1> <trace2>",
+	Short: "Compare the calls made by two traces",
+	Long: `Compare the calls made by two traces, matched by their call digests.
+
+Reports calls that were cached in the first trace but not the second, that got
+slower or faster beyond the tolerance, and that newly failed in the second.
+
+Use --format=json for a structured report, e.g. for CI to act on.`,
+	Example: `dagger trace diff <trace-id> latest`,
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tolerance, err := parsePercent(traceDiffTolerance)
+		if err != nil {
+			return fmt.Errorf("invalid --tolerance: %w", err)
+		}
+		a, _, err := loadTrace(args[:1])
+		if err != nil {
+			return err
+		}
+		b, _, err := loadTrace(args[1:])
+		if err != nil {
+			return err
+		}
+		diff := dagui.DiffDBs(a, b, dagui.BaselineTolerance{
+			Ratio:    tolerance,
+			MinDelta: traceDiffMinDelta,
+		})
+		switch traceDiffFormat {
+		case "text":
+			return diff.WriteText(cmd.OutOrStdout())
+		case "json":
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(diff)
+		default:
+			return fmt.Errorf("unknown format %q", traceDiffFormat)
+		}
+	},
+}
+
+var traceSeedCmd = &cobra.Command{
+	Use:   "seed [options] [trace]",
+	Short: "Export a cache containing only the results used by a trace",
+	Long: `Export a cache containing only the results used by a trace.
+
+The final results of the trace are re-evaluated, which is fast against a warm
+engine, and the cache for everything they depended on is exported to the
+given destination. Ephemeral runners can import it at startup with
+_EXPERIMENTAL_DAGGER_CACHE_IMPORT_CONFIG. Defaults to the latest trace.`,
+	Example: `dagger trace seed --to type=local,dest=./cache`,
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, _, err := loadTrace(args)
+		if err != nil {
+			return err
+		}
+		seeds := db.CacheSeeds()
+		if len(seeds) == 0 {
+			return errors.New("trace has no results to seed the cache with")
+		}
+		exports, err := client.ParseCacheConfigs(traceSeedTo)
+		if err != nil {
+			return fmt.Errorf("parse --to: %w", err)
+		}
+		params := client.Params{
+			CacheExportConfigs: exports,
+		}
+		return withEngine(cmd.Context(), params, func(ctx context.Context, engineClient *client.Client) error {
+			eg, ctx := errgroup.WithContext(ctx)
+			for _, seed := range seeds {
+				eg.Go(func() error {
+					return engineClient.Dagger().Do(ctx, &dagger.Request{
+						Query: fmt.Sprintf(`query Seed($id: %[1]sID!) { load%[1]sFromID(id: $id) { sync } }`, seed.Type),
+						Variables: map[string]any{
+							"id": seed.ID,
+						},
+					}, &dagger.Response{})
+				})
+			}
+			return eg.Wait()
+		})
+	},
+}
+
+func init() {
+	traceSeedCmd.Flags().StringVar(&traceSeedTo, "to", "", "Cache export config, e.g. type=local,dest=./cache")
+	traceSeedCmd.MarkFlagRequired("to")
+
+	traceSummaryCmd.Flags().StringVar(&traceSummaryFormat, "format", "text", "Output format (text, md)")
+	traceSummaryCmd.Flags().IntVar(&traceSummarySlowest, "slowest", 10, "Number of slowest steps to show")
+	traceSummaryCmd.Flags().IntVar(&traceSummaryLogLines, "log-lines", 20, "Number of log lines to show for each failure")
+	traceSummaryCmd.Flags().IntVar(&traceSummaryFlakyRuns, "flaky-runs", 20, "Number of recent runs to check for flaky steps")
+
+	traceCacheCmd.Flags().StringVar(&traceCacheFormat, "format", "text", "Output format (text, json)")
+	traceCacheCmd.Flags().IntVar(&traceCacheTop, "top", 10, "Number of slowest uncached steps to show")
+
+	traceEffectsCmd.Flags().StringVar(&traceEffectsFormat, "format", "text", "Output format (text, json)")
+
+	traceFailuresCmd.Flags().IntVar(&traceFailuresLogLines, "log-lines", 20, "Number of log lines to include for each failure")
+
+	traceDiffCmd.Flags().StringVar(&traceDiffFormat, "format", "text", "Output format (text, json)")
+	traceDiffCmd.Flags().StringVar(&traceDiffTolerance, "tolerance", "10%", "Change in duration to report, relative to the first trace")
+	traceDiffCmd.Flags().DurationVar(&traceDiffMinDelta, "min-delta", time.Second, "Ignore changes in duration smaller than this")
+
+	traceListCmd.Flags().StringArrayVar(&traceListLabels, "label", nil, "Only list traces with the given label, e.g. branch=main")
+
+	traceExportCmd.Flags().StringVar(&traceExportFormat, "format", "json", "Output format: json, otlp, otlp-proto, or perfetto")
+	traceExportCmd.Flags().StringVarP(&traceExportOutput, "output", "o", "", "Directory to write OTLP files to")
+	traceExportCmd.Flags().StringVar(&traceExportPreset, "preset", "", "Tune the OTLP output for a backend: honeycomb")
+
+	traceQueryCmd.Flags().IntVar(&traceQueryRuns, "runs", 0, "Search this many recent traces instead of a single trace")
+	traceQueryCmd.Flags().BoolVar(&traceQueryFailMatch, "fail-on-match", false, "Exit with an error if any spans match")
+
+	traceHeatmapCmd.Flags().IntVar(&traceHeatmapRuns, "runs", 20, "Number of recent runs to show")
+	traceHeatmapCmd.Flags().IntVar(&traceHeatmapDepth, "depth", 2, "Depth of nested steps to show")
+	traceHeatmapCmd.Flags().StringVar(&traceHeatmapFormat, "format", "text", "Output format (text, html)")
+
+	traceCmd.AddCommand(traceListCmd, traceManifestCmd, traceExportCmd, traceSeedCmd, traceSummaryCmd, traceCacheCmd, traceEffectsCmd, traceFailuresCmd, traceDiffCmd, traceVerifyCmd, traceHeatmapCmd, traceQueryCmd, traceResumeCmd)
+	rootCmd.AddCommand(traceCmd)
+}
+
+func traceStore() *dagui.TraceStore {
+	root := os.Getenv("DAGGER_TRACE_STORE")
+	if root == "" {
+		root = filepath.Join(xdg.StateHome, "dagger", "traces")
+	}
+	store := dagui.NewTraceStore(root)
+	// validated along with the other flags
+	if retention, err := dagui.ParseTraceRetention(traceRetention); err == nil {
+		store.Retention = retention
+	}
+	return store
+}
+
+// loadTrace loads the trace named by the first argument, defaulting to the
+// latest trace.
+func loadTrace(args []string) (*dagui.DB, dagui.TraceMeta, error) {
+	id := "latest"
+	if len(args) > 0 {
+		id = args[0]
+	}
+	return traceStore().Load(id)
+}
+
+// openJournal starts a journal for the run's trace, so that it can be
+// resumed with "dagger trace resume" if the run never gets to record it.
+func openJournal() *dagui.Journal {
+	if !recordTraces {
+		return nil
+	}
+	journal, err := traceStore().OpenJournal()
+	if err != nil {
+		slog.Debug("failed to open trace journal", "error", err)
+		return nil
+	}
+	return journal
+}
+
+// recordTrace saves the trace of the completed run to the trace store, and
+// removes its journal now that it's no longer needed.
+func recordTrace(db *dagui.DB, journal *dagui.Journal) {
+	if journal != nil {
+		defer func() {
+			if err := journal.Remove(); err != nil {
+				slog.Debug("failed to remove trace journal", "error", err)
+			}
+		}()
+	}
+	if !recordTraces {
+		return
+	}
+	if _, err := traceStore().Save(db); err != nil {
+		slog.Debug("failed to record trace", "error", err)
+	}
+}
+
+// loadDurationHistory loads the durations of spans from previously recorded
+// traces, for estimating the time remaining for running spans.
+func loadDurationHistory() *dagui.DurationHistory {
+	if !recordTraces {
+		return nil
+	}
+	hist, err := traceStore().DurationHistory()
+	if err != nil {
+		slog.Debug("failed to load duration history", "error", err)
+		return nil
+	}
+	return hist
+}
+
+// compactTraces removes recorded traces beyond --trace-retention in the
+// background, so that the run isn't held up by it.
+func compactTraces() {
+	if !recordTraces {
+		return
+	}
+	go func() {
+		if err := traceStore().Compact(time.Now()); err != nil {
+			slog.Debug("failed to compact traces", "error", err)
+		}
+	}()
+}
+
+func traceStatus(meta dagui.TraceMeta) string {
+	if meta.Failed {
+		return "failed"
+	}
+	return "ok"
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+var (
+	baselineName      string
+	baselineTolerance string
+	baselineMinDelta  time.Duration
+)
+
+var traceBaselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Manage performance baselines for pipelines",
+	Long: `Manage performance baselines for pipelines.
+
+A baseline records the durations and cache hit ratio of a blessed run. Later
+runs of the same pipeline can be compared against it to catch performance
+regressions.`,
+}
+
+var traceBaselineSetCmd = &cobra.Command{
+	Use:   "set [options] [trace]",
+	Short: "Bless a trace as the baseline for its pipeline",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, _, err := loadTrace(args)
+		if err != nil {
+			return err
+		}
+		metrics := db.Metrics()
+		if baselineName != "" {
+			metrics.Name = baselineName
+		}
+		if err := traceStore().SetBaseline(metrics); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Set baseline for %q to trace %s\n", metrics.Name, metrics.TraceID)
+		return nil
+	},
+}
+
+var traceBaselineCompareCmd = &cobra.Command{
+	Use:   "compare [options] [trace]",
+	Short: "Compare a trace against its pipeline's baseline",
+	Long: `Compare a trace against its pipeline's baseline.
+
+Prints a markdown report suitable for a pull request comment, and exits with
+code 3 if any metric regressed beyond the tolerance.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tolerance, err := parsePercent(baselineTolerance)
+		if err != nil {
+			return fmt.Errorf("invalid --tolerance: %w", err)
+		}
+		db, _, err := loadTrace(args)
+		if err != nil {
+			return err
+		}
+		current := db.Metrics()
+		if baselineName != "" {
+			current.Name = baselineName
+		}
+		baseline, err := traceStore().Baseline(current.Name)
+		if err != nil {
+			return err
+		}
+		cmp := dagui.CompareBaseline(baseline, current, dagui.BaselineTolerance{
+			Ratio:    tolerance,
+			MinDelta: baselineMinDelta,
+		})
+		if err := cmp.WriteMarkdown(cmd.OutOrStdout()); err != nil {
+			return err
+		}
+		if cmp.Regressed() {
+			return ExitError{Code: sloViolationExitCode}
+		}
+		return nil
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{traceBaselineSetCmd, traceBaselineCompareCmd} {
+		cmd.Flags().StringVar(&baselineName, "name", "", "Pipeline name to use instead of the trace's name")
+	}
+	traceBaselineCompareCmd.Flags().StringVar(&baselineTolerance, "tolerance", "10%", "Allowed slowdown relative to the baseline")
+	traceBaselineCompareCmd.Flags().DurationVar(&baselineMinDelta, "min-delta", time.Second, "Ignore slowdowns smaller than this duration")
+
+	traceBaselineCmd.AddCommand(traceBaselineSetCmd, traceBaselineCompareCmd)
+	traceCmd.AddCommand(traceBaselineCmd)
+}
+
+// parsePercent parses a percentage like "10%" into a ratio like 0.1.
+func parsePercent(str string) (float64, error) {
+	pct, err := strconv.ParseFloat(strings.TrimSuffix(str, "%"), 64)
+	if err != nil {
+		return 0, err
+	}
+	return pct / 100, nil
+}
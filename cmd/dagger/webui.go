@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dagger/dagger/dagql/dagui"
+	"github.com/dagger/dagger/dagql/dagui/webui"
+)
+
+var (
+	webUIAddr = os.Getenv("DAGGER_WEB_UI")
+
+	// webUIServer is the web UI for the current run, if enabled.
+	webUIServer *webui.Server
+
+	traceWebListen   string
+	traceServeListen string
+)
+
+var traceWebCmd = &cobra.Command{
+	Use:   "web [options] [trace]",
+	Short: "Explore a trace in a local web UI",
+	Long: `Explore a trace in a local web UI, with a searchable tree of spans, their
+logs and metrics, and a flamegraph of where the time went.
+
+The UI is served until interrupted. Defaults to the latest trace.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, _, err := loadTrace(args)
+		if err != nil {
+			return err
+		}
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+		l, err := net.Listen("tcp", traceWebListen)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Serving web UI at http://%s\n", l.Addr())
+		return serveHTTP(ctx, l, webui.NewServer(db))
+	},
+}
+
+var traceServeCmd = &cobra.Command{
+	Use:   "serve [options]",
+	Short: "Serve the stored traces over a read-only REST API",
+	Long: `Serve the stored traces over a read-only REST API, for dashboards and scripts:
+
+  GET /api/traces                            list traces, most recent first
+  GET /api/traces/{trace}                    a trace's metadata
+  GET /api/traces/{trace}/spans              a trace's spans
+  GET /api/traces/{trace}/spans/{span}/logs  a span's logs, as text
+
+Lists are paginated with the offset and limit query parameters. Responses
+carry an ETag, so polling clients can send If-None-Match to skip unchanged
+data. The trace "latest" refers to the most recent trace.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+		l, err := net.Listen("tcp", traceServeListen)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Serving trace API at http://%s/api/traces\n", l.Addr())
+		return serveHTTP(ctx, l, webui.NewTraceAPI(traceStore()))
+	},
+}
+
+func init() {
+	traceWebCmd.Flags().StringVar(&traceWebListen, "listen", "localhost:0", "Address to serve the web UI on")
+	traceServeCmd.Flags().StringVar(&traceServeListen, "listen", "localhost:8020", "Address to serve the API on")
+	traceCmd.AddCommand(traceWebCmd, traceServeCmd)
+}
+
+// startWebUI serves a live web UI for the run, if enabled, returning a
+// function to stop it.
+func startWebUI(ctx context.Context) (func(), error) {
+	if webUIAddr == "" {
+		return func() {}, nil
+	}
+	l, err := net.Listen("tcp", webUIAddr)
+	if err != nil {
+		return nil, fmt.Errorf("web UI: %w", err)
+	}
+	webUIServer = webui.NewServer(dagui.NewDB())
+	webUIServer.Durations = opts.Durations
+	fmt.Fprintf(os.Stderr, "Serving web UI at http://%s\n", l.Addr())
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serveHTTP(ctx, l, webUIServer)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}, nil
+}
+
+func serveHTTP(ctx context.Context, l net.Listener, handler http.Handler) error {
+	httpSrv := &http.Server{
+		Handler: handler,
+		BaseContext: func(net.Listener) context.Context {
+			// end open streams on shutdown
+			return ctx
+		},
+	}
+	go func() {
+		<-ctx.Done()
+		httpSrv.Close()
+	}()
+	if err := httpSrv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
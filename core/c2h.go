@@ -5,11 +5,16 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sync/atomic"
 
+	"dagger.io/dagger/telemetry"
 	"github.com/dagger/dagger/engine/buildkit"
 	"github.com/dagger/dagger/engine/slog"
 	"github.com/moby/buildkit/session/sshforward"
+	"github.com/opencontainers/go-digest"
 	"github.com/sourcegraph/conc/pool"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type c2hTunnel struct {
@@ -17,6 +22,12 @@ type c2hTunnel struct {
 	ns        buildkit.Namespaced
 	socks     []*Socket
 	sockStore *SocketStore
+
+	// connections is called with the number of connections accepted so far
+	// and the number still open, as they change
+	connections func(total, active int64)
+	totalConns  atomic.Int64
+	activeConns atomic.Int64
 }
 
 func (d *c2hTunnel) Tunnel(ctx context.Context) (rerr error) {
@@ -24,6 +35,7 @@ func (d *c2hTunnel) Tunnel(ctx context.Context) (rerr error) {
 
 	ctx, cancel := context.WithCancelCause(ctx)
 	defer cancel(errors.New("tunnel finished"))
+	d.reportConnections(0, 0)
 	listenerPool := pool.New().WithContext(ctx)
 	proxyConnPool := pool.New().WithContext(ctx)
 	for _, sock := range d.socks {
@@ -83,6 +95,10 @@ func (d *c2hTunnel) Tunnel(ctx context.Context) (rerr error) {
 				}
 
 				proxyConnPool.Go(func(ctx context.Context) error {
+					d.reportConnections(d.totalConns.Add(1), d.activeConns.Add(1))
+					defer func() {
+						d.reportConnections(d.totalConns.Load(), d.activeConns.Add(-1))
+					}()
 					err := sshforward.Copy(ctx, downstreamConn, upstreamClient, upstreamClient.CloseSend)
 					if err != nil {
 						connSlog.Error("failed to copy data", "error", err)
@@ -104,3 +120,34 @@ func (d *c2hTunnel) Tunnel(ctx context.Context) (rerr error) {
 	}
 	return rerr
 }
+
+func (d *c2hTunnel) reportConnections(total, active int64) {
+	if d.connections != nil {
+		d.connections(total, active)
+	}
+}
+
+// tunnelConnections records the connections through a tunnel as metrics of
+// its span, so that they're shown as the tunnel runs.
+func tunnelConnections(ctx context.Context, dig digest.Digest) func(total, active int64) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	opt := metric.WithAttributes(callMetricAttrs(dig, spanContext)...)
+	meter := telemetry.Meter(ctx, InstrumentationLibrary)
+	totalGauge, err := meter.Int64Gauge(telemetry.TunnelConnections)
+	if err != nil {
+		slog.Warn("failed to create tunnel metric", "metric", telemetry.TunnelConnections, "err", err)
+		return nil
+	}
+	activeGauge, err := meter.Int64Gauge(telemetry.TunnelActiveConnections)
+	if err != nil {
+		slog.Warn("failed to create tunnel metric", "metric", telemetry.TunnelActiveConnections, "err", err)
+		return nil
+	}
+	return func(total, active int64) {
+		totalGauge.Record(ctx, total, opt)
+		activeGauge.Record(ctx, active, opt)
+	}
+}
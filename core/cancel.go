@@ -0,0 +1,81 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"dagger.io/dagger/telemetry"
+)
+
+// ErrCanceledByUser is the cause of calls canceled individually, e.g. from
+// the TUI, rather than along with the whole session.
+var ErrCanceledByUser = errors.New("canceled by user")
+
+// CallCancels tracks the running calls of a session by their span, so that
+// a single call can be canceled along with everything it started, without
+// canceling the rest of the session.
+type CallCancels struct {
+	mu      sync.Mutex
+	cancels map[trace.SpanID]context.CancelCauseFunc
+}
+
+func NewCallCancels() *CallCancels {
+	return &CallCancels{
+		cancels: map[trace.SpanID]context.CancelCauseFunc{},
+	}
+}
+
+// Track returns a context for the call running in the given span that is
+// canceled if the span is, along with a function to call once it's done.
+func (cc *CallCancels) Track(ctx context.Context, spanID trace.SpanID) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	cc.mu.Lock()
+	cc.cancels[spanID] = cancel
+	cc.mu.Unlock()
+	return ctx, func() {
+		cc.mu.Lock()
+		delete(cc.cancels, spanID)
+		cc.mu.Unlock()
+	}
+}
+
+// Cancel cancels the call running in the given span, returning false if
+// there is none.
+func (cc *CallCancels) Cancel(spanID trace.SpanID) bool {
+	cc.mu.Lock()
+	cancel, ok := cc.cancels[spanID]
+	cc.mu.Unlock()
+	if ok {
+		cancel(ErrCanceledByUser)
+	}
+	return ok
+}
+
+// CanceledBy returns who or what canceled the context, as one of the
+// telemetry.CanceledBy values, or "" if it's not known, e.g. if the client
+// went away.
+func CanceledBy(ctx context.Context) string {
+	cause := context.Cause(ctx)
+	var timeout *TimeoutError
+	switch {
+	case errors.Is(cause, ErrCanceledByUser):
+		return telemetry.CanceledByUser
+	case errors.As(cause, &timeout):
+		return telemetry.CanceledByTimeout
+	}
+	return ""
+}
+
+type callCancelsKey struct{}
+
+func WithCallCancels(ctx context.Context, cc *CallCancels) context.Context {
+	return context.WithValue(ctx, callCancelsKey{}, cc)
+}
+
+func CallCancelsFromContext(ctx context.Context) *CallCancels {
+	cc, _ := ctx.Value(callCancelsKey{}).(*CallCancels)
+	return cc
+}
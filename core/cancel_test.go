@@ -0,0 +1,34 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"dagger.io/dagger/telemetry"
+)
+
+func TestCallCancels(t *testing.T) {
+	cc := NewCallCancels()
+	ctx := context.Background()
+	running := trace.SpanID{1}
+	other := trace.SpanID{2}
+
+	callCtx, done := cc.Track(ctx, running)
+	otherCtx, otherDone := cc.Track(ctx, other)
+	defer otherDone()
+
+	require.True(t, cc.Cancel(running))
+	require.ErrorIs(t, context.Cause(callCtx), ErrCanceledByUser)
+	require.Equal(t, telemetry.CanceledByUser, CanceledBy(callCtx))
+	// only the canceled call is
+	require.NoError(t, otherCtx.Err())
+	require.Empty(t, CanceledBy(otherCtx))
+
+	// calls can't be canceled once they've returned
+	done()
+	require.False(t, cc.Cancel(running))
+	require.False(t, cc.Cancel(trace.SpanID{3}))
+}
@@ -0,0 +1,351 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/dagger/dagger/dagql"
+	"github.com/dagger/dagger/dagql/call"
+)
+
+type ConfigValueType string
+
+var ConfigValueTypes = dagql.NewEnum[ConfigValueType]()
+
+var (
+	ConfigValueTypeString = ConfigValueTypes.Register("STRING",
+		`Any string.`,
+	)
+	ConfigValueTypeInteger = ConfigValueTypes.Register("INTEGER",
+		`A whole number, e.g. 42.`,
+	)
+	ConfigValueTypeBoolean = ConfigValueTypes.Register("BOOLEAN",
+		`A boolean, e.g. true or false.`,
+	)
+)
+
+func (typ ConfigValueType) Type() *ast.Type {
+	return &ast.Type{
+		NamedType: "ConfigValueType",
+		NonNull:   true,
+	}
+}
+
+func (typ ConfigValueType) TypeDescription() string {
+	return "The type of the value of a configuration key."
+}
+
+func (typ ConfigValueType) Decoder() dagql.InputDecoder {
+	return ConfigValueTypes
+}
+
+func (typ ConfigValueType) ToLiteral() call.Literal {
+	return ConfigValueTypes.Literal(typ)
+}
+
+// parse parses a raw value of the type, returning an error suitable for
+// reporting with the key it was set for.
+func (typ ConfigValueType) parse(raw string) (any, error) {
+	switch typ {
+	case ConfigValueTypeInteger:
+		val, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("expected an integer, got %q", raw)
+		}
+		return val, nil
+	case ConfigValueTypeBoolean:
+		val, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("expected a boolean, got %q", raw)
+		}
+		return val, nil
+	default:
+		return raw, nil
+	}
+}
+
+// Config is the typed configuration of a pipeline. The keys are declared by
+// the module or client using it, and their values loaded from files and
+// environment variables, validated as a whole before any of them are used.
+type Config struct {
+	Keys []*ConfigKey `field:"true" doc:"The keys declared for the configuration, in order."`
+
+	// Sources are where the values are loaded from, in order; later sources
+	// take precedence.
+	Sources []*ConfigSource
+}
+
+func (*Config) Type() *ast.Type {
+	return &ast.Type{
+		NamedType: "Config",
+		NonNull:   true,
+	}
+}
+
+func (*Config) TypeDescription() string {
+	return dagql.FormatDescription(
+		`A typed configuration, declaring its keys and loading their values from files and environment variables.`,
+		`Values are validated against the declared keys before they're used, with errors pointing at the offending keys.`)
+}
+
+func (cfg *Config) Clone() *Config {
+	cp := *cfg
+	cp.Keys = slices.Clone(cfg.Keys)
+	cp.Sources = slices.Clone(cfg.Sources)
+	return &cp
+}
+
+// WithKey declares a key, replacing any previous declaration of it.
+func (cfg *Config) WithKey(key *ConfigKey) (*Config, error) {
+	if key.Name == "" {
+		return nil, errors.New("config key name must not be empty")
+	}
+	if key.DefaultValue != "" {
+		if _, err := key.ValueType.parse(key.DefaultValue); err != nil {
+			return nil, fmt.Errorf("config key %q: default: %w", key.Name, err)
+		}
+	}
+	cfg = cfg.Clone()
+	cfg.Keys = slices.DeleteFunc(cfg.Keys, func(k *ConfigKey) bool {
+		return k.Name == key.Name
+	})
+	cfg.Keys = append(cfg.Keys, key)
+	return cfg, nil
+}
+
+// WithSource loads values from the given source, taking precedence over the
+// values loaded so far.
+func (cfg *Config) WithSource(source *ConfigSource) *Config {
+	cfg = cfg.Clone()
+	cfg.Sources = append(cfg.Sources, source)
+	return cfg
+}
+
+// Key returns the declaration of a key.
+func (cfg *Config) Key(name string) (*ConfigKey, bool) {
+	for _, key := range cfg.Keys {
+		if key.Name == name {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// Resolve validates the values loaded for all the declared keys, returning
+// them by key. Keys that aren't required and aren't set have no value.
+//
+// All the offending keys are reported at once, along with the sources of
+// their values.
+func (cfg *Config) Resolve() (map[string]any, error) {
+	var errs []error
+	values := map[string]any{}
+	for _, key := range cfg.Keys {
+		raw, source, found := cfg.lookup(key)
+		if !found {
+			if key.DefaultValue != "" {
+				raw, source, found = key.DefaultValue, "default", true
+			} else if key.Required {
+				errs = append(errs, fmt.Errorf("config key %q: required, but not set", key.Name))
+				continue
+			} else {
+				continue
+			}
+		}
+		val, err := key.ValueType.parse(raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("config key %q (from %s): %w", key.Name, source, err))
+			continue
+		}
+		values[key.Name] = val
+	}
+	for _, source := range cfg.Sources {
+		if source.Env {
+			// environments are full of unrelated variables
+			continue
+		}
+		for _, name := range source.names() {
+			if _, declared := cfg.Key(name); !declared {
+				errs = append(errs, fmt.Errorf("config key %q (from %s): not declared", name, source.Name))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return values, nil
+}
+
+// Value returns the validated value of a declared key, or nil if it isn't set.
+func (cfg *Config) Value(name string) (any, error) {
+	if _, ok := cfg.Key(name); !ok {
+		return nil, fmt.Errorf("config key %q is not declared", name)
+	}
+	values, err := cfg.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	return values[name], nil
+}
+
+// validateConfigInputs validates the Config arguments of a function call, so
+// that a bad configuration fails the call before the function is invoked,
+// with errors pointing at the offending keys.
+func validateConfigInputs(ctx context.Context, srv *dagql.Server, inputs []CallInput) error {
+	var errs []error
+	for _, input := range inputs {
+		cfg, err := configInput(ctx, srv, input.Value)
+		if err != nil {
+			return fmt.Errorf("failed to load config for arg %q: %w", input.Name, err)
+		}
+		if cfg == nil {
+			continue
+		}
+		if _, err := cfg.Resolve(); err != nil {
+			errs = append(errs, fmt.Errorf("invalid config for arg %q: %w", input.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// configInput returns the Config passed as an argument, if any.
+func configInput(ctx context.Context, srv *dagql.Server, val dagql.Typed) (*Config, error) {
+	switch x := val.(type) {
+	case dagql.Instance[*Config]:
+		return x.Self, nil
+	case dagql.ID[*Config]:
+		inst, err := x.Load(ctx, srv)
+		if err != nil {
+			return nil, err
+		}
+		return inst.Self, nil
+	case dagql.Derefable:
+		inner, ok := x.Deref()
+		if !ok {
+			return nil, nil
+		}
+		return configInput(ctx, srv, inner)
+	}
+	return nil, nil
+}
+
+// lookup returns the raw value of a key from the last source that sets it.
+func (cfg *Config) lookup(key *ConfigKey) (value, source string, found bool) {
+	for i := len(cfg.Sources) - 1; i >= 0; i-- {
+		src := cfg.Sources[i]
+		name := key.Name
+		if src.Env {
+			name = src.Prefix + strcase.ToScreamingSnake(key.Name)
+		}
+		if val, ok := src.Values[name]; ok {
+			if src.Env {
+				return val, src.Name + " " + name, true
+			}
+			return val, src.Name, true
+		}
+	}
+	return "", "", false
+}
+
+// ConfigKey is a key declared for a Config.
+type ConfigKey struct {
+	Name         string          `field:"true" doc:"The name of the key."`
+	ValueType    ConfigValueType `field:"true" doc:"The type of the key's value."`
+	Required     bool            `field:"true" doc:"Whether the key must be set."`
+	DefaultValue string          `field:"true" doc:"The value of the key when it's not set, if any."`
+	Description  string          `field:"true" doc:"A description of the key, if any."`
+}
+
+func (*ConfigKey) Type() *ast.Type {
+	return &ast.Type{
+		NamedType: "ConfigKey",
+		NonNull:   true,
+	}
+}
+
+func (*ConfigKey) TypeDescription() string {
+	return "A key declared for a configuration."
+}
+
+// ConfigSource is a set of raw values loaded for a Config.
+type ConfigSource struct {
+	// Name describes the source in errors, e.g. the name of a file.
+	Name string
+
+	// Values are the raw values, by key, or by variable name if Env is set.
+	Values map[string]string
+
+	// Env is set when the values are environment variables, which are named
+	// after the keys in SCREAMING_SNAKE_CASE, after the Prefix.
+	Env    bool
+	Prefix string
+}
+
+// names returns the names of the values of the source, sorted.
+func (source *ConfigSource) names() []string {
+	names := make([]string, 0, len(source.Values))
+	for name := range source.Values {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ParseConfigFile parses a configuration file, either a JSON object or, if
+// its name doesn't end in .json, a dotenv-style file of KEY=VALUE lines.
+func ParseConfigFile(name string, contents []byte) (*ConfigSource, error) {
+	source := &ConfigSource{
+		Name:   name,
+		Values: map[string]string{},
+	}
+	if path.Ext(name) == ".json" {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(contents, &obj); err != nil {
+			return nil, fmt.Errorf("parse %s: expected a JSON object: %w", name, err)
+		}
+		for key, raw := range obj {
+			var str string
+			if err := json.Unmarshal(raw, &str); err == nil {
+				source.Values[key] = str
+			} else {
+				// numbers and booleans are parsed as declared
+				source.Values[key] = string(raw)
+			}
+		}
+		return source, nil
+	}
+	values, err := ParseEnvFile(name, contents)
+	if err != nil {
+		return nil, err
+	}
+	source.Values = values
+	return source, nil
+}
+
+// NewConfigEnvSource returns a source of values from environment variables in
+// the KEY=VALUE format, e.g. of os.Environ, named after the keys with the
+// given prefix.
+func NewConfigEnvSource(variables []string, prefix string) *ConfigSource {
+	source := &ConfigSource{
+		Name:   "environment",
+		Values: map[string]string{},
+		Env:    true,
+		Prefix: prefix,
+	}
+	for _, variable := range variables {
+		name, value, ok := strings.Cut(variable, "=")
+		if !ok || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		source.Values[name] = value
+	}
+	return source
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/dagql"
+)
+
+func TestConfig(t *testing.T) {
+	cfg := &Config{}
+	for _, key := range []*ConfigKey{
+		{Name: "replicas", ValueType: ConfigValueTypeInteger, Required: true},
+		{Name: "logLevel", ValueType: ConfigValueTypeString, DefaultValue: "info"},
+		{Name: "debug", ValueType: ConfigValueTypeBoolean},
+	} {
+		var err error
+		cfg, err = cfg.WithKey(key)
+		require.NoError(t, err)
+	}
+
+	// required keys must be set
+	_, err := cfg.Resolve()
+	require.ErrorContains(t, err, `config key "replicas": required, but not set`)
+
+	file, err := ParseConfigFile("config.json", []byte(`{"replicas": 3, "debug": true}`))
+	require.NoError(t, err)
+	values, err := cfg.WithSource(file).Resolve()
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"replicas": 3, "logLevel": "info", "debug": true}, values)
+
+	// later sources take precedence, with variables named after the keys
+	env := NewConfigEnvSource([]string{"APP_LOG_LEVEL=debug", "APP_REPLICAS=5", "HOME=/root"}, "APP_")
+	values, err = cfg.WithSource(file).WithSource(env).Resolve()
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"replicas": 5, "logLevel": "debug", "debug": true}, values)
+
+	// all offending keys are reported, with the source of their values
+	dotenv, err := ParseConfigFile(".env", []byte("# comment\nreplicas=many\ndebug=\"yes\"\nverbose=1\n"))
+	require.NoError(t, err)
+	_, err = cfg.WithSource(dotenv).Resolve()
+	require.ErrorContains(t, err, `config key "replicas" (from .env): expected an integer, got "many"`)
+	require.ErrorContains(t, err, `config key "debug" (from .env): expected a boolean, got "yes"`)
+	require.ErrorContains(t, err, `config key "verbose" (from .env): not declared`)
+
+	_, err = cfg.WithSource(env).WithSource(NewConfigEnvSource([]string{"APP_DEBUG=maybe"}, "APP_")).Resolve()
+	require.ErrorContains(t, err, `config key "debug" (from environment APP_DEBUG): expected a boolean, got "maybe"`)
+
+	// defaults are validated as they're declared
+	_, err = cfg.WithKey(&ConfigKey{Name: "port", ValueType: ConfigValueTypeInteger, DefaultValue: "http"})
+	require.ErrorContains(t, err, `config key "port": default: expected an integer`)
+
+	_, err = ParseConfigFile("config.json", []byte(`[1, 2]`))
+	require.ErrorContains(t, err, "expected a JSON object")
+}
+
+func TestValidateConfigInputs(t *testing.T) {
+	ctx := context.Background()
+	cfg, err := (&Config{}).WithKey(&ConfigKey{Name: "replicas", ValueType: ConfigValueTypeInteger, Required: true})
+	require.NoError(t, err)
+
+	// arguments that aren't configs are left alone
+	require.NoError(t, validateConfigInputs(ctx, nil, []CallInput{
+		{Name: "name", Value: dagql.String("test")},
+	}))
+
+	err = validateConfigInputs(ctx, nil, []CallInput{
+		{Name: "config", Value: dagql.Instance[*Config]{Self: cfg}},
+	})
+	require.ErrorContains(t, err, `invalid config for arg "config": config key "replicas": required, but not set`)
+
+	// optional configs are validated when they're set
+	err = validateConfigInputs(ctx, nil, []CallInput{
+		{Name: "config", Value: dagql.NonNull(dagql.Instance[*Config]{Self: cfg})},
+	})
+	require.ErrorContains(t, err, `invalid config for arg "config"`)
+	require.NoError(t, validateConfigInputs(ctx, nil, []CallInput{
+		{Name: "config", Value: dagql.Null[dagql.Instance[*Config]]()},
+	}))
+
+	file, err := ParseConfigFile("config.json", []byte(`{"replicas": 3}`))
+	require.NoError(t, err)
+	require.NoError(t, validateConfigInputs(ctx, nil, []CallInput{
+		{Name: "config", Value: dagql.Instance[*Config]{Self: cfg.WithSource(file)}},
+	}))
+}
@@ -33,6 +33,7 @@ import (
 
 	"github.com/dagger/dagger/dagql"
 	"github.com/dagger/dagger/dagql/call"
+	"github.com/dagger/dagger/engine"
 	"github.com/dagger/dagger/engine/buildkit"
 )
 
@@ -291,12 +292,19 @@ func (container *Container) FromRefString(ctx context.Context, addr string) (*Co
 
 	platform := container.Platform
 
-	refName, err := reference.ParseNormalizedNamed(addr)
+	// add a default :latest if no tag or digest
+	refName, err := engine.NormalizeImageRef(addr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse image address %s: %w", addr, err)
 	}
-	// add a default :latest if no tag or digest, otherwise this is a no-op
-	refName = reference.TagNameOnly(refName)
+
+	offlineRef, err := OfflineImageRef(ctx, container.Query, refName)
+	if err != nil {
+		return nil, err
+	}
+	if offlineRef != nil {
+		return container.FromCanonicalRef(ctx, offlineRef, nil)
+	}
 
 	if refName, isCanonical := refName.(reference.Canonical); isCanonical {
 		return container.FromCanonicalRef(ctx, refName, nil)
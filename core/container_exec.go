@@ -16,6 +16,10 @@ import (
 	"github.com/moby/buildkit/client/llb"
 	"github.com/moby/buildkit/identity"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"dagger.io/dagger/telemetry"
 )
 
 var ErrNoCommand = errors.New("no command has been set")
@@ -55,6 +59,10 @@ type ContainerExecOpts struct {
 	// Skip the init process injected into containers by default so that the
 	// user's process is PID 1
 	NoInit bool `default:"false"`
+
+	// Run the command without network access and with a fixed clock, so that
+	// its result only depends on its inputs
+	Hermetic bool `default:"false"`
 }
 
 func (container *Container) WithExec(ctx context.Context, opts ContainerExecOpts) (*Container, error) { //nolint:gocyclo
@@ -106,6 +114,24 @@ func (container *Container) WithExec(ctx context.Context, opts ContainerExecOpts
 		runOpts = append(runOpts, llb.AddEnv(buildkit.DaggerNoInitEnv, "true"))
 	}
 
+	if opts.Hermetic {
+		if opts.ExperimentalPrivilegedNesting {
+			return nil, fmt.Errorf("hermetic execs can't access the Dagger API")
+		}
+		if len(container.Services) > 0 {
+			return nil, fmt.Errorf("hermetic execs can't reach services")
+		}
+		execMD.Hermetic = true
+		// the network mode is already in the cache key, but the fixed clock
+		// isn't, so include an env var (which will be removed before the exec
+		// actually runs) to keep hermetic results apart from others
+		runOpts = append(runOpts,
+			llb.Network(llb.NetModeNone),
+			llb.AddEnv(buildkit.DaggerHermeticEnv, "true"))
+		trace.SpanFromContext(ctx).SetAttributes(
+			attribute.Bool(telemetry.ExecHermeticAttr, true))
+	}
+
 	mod, err := container.Query.CurrentModule(ctx)
 	if err == nil {
 		// allow the exec to reach services scoped to the module that
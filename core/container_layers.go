@@ -0,0 +1,63 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// ContainerLayer is a layer of a container's root filesystem.
+type ContainerLayer struct {
+	Digest     string `field:"true" doc:"The digest of the compressed layer."`
+	Size       int    `field:"true" doc:"The size of the compressed layer, in bytes."`
+	Command    string `field:"true" doc:"A description of the operation that created the layer, e.g. the command it ran."`
+	WastedSize int    `field:"true" doc:"The total size of files in the layer that are replaced or removed by a later layer, in bytes. These files are shipped with the image, but never seen in the container."`
+}
+
+func (ContainerLayer) Type() *ast.Type {
+	return &ast.Type{
+		NamedType: "ContainerLayer",
+		NonNull:   true,
+	}
+}
+
+func (ContainerLayer) TypeDescription() string {
+	return "A layer of a container's root filesystem, as it would be published."
+}
+
+// Layers returns the layers of the container's root filesystem, bottom first.
+func (container *Container) Layers(ctx context.Context) ([]ContainerLayer, error) {
+	if container.FS == nil {
+		return []ContainerLayer{}, nil
+	}
+	svcs, err := container.Query.Services(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get services: %w", err)
+	}
+	bk, err := container.Query.Buildkit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get buildkit client: %w", err)
+	}
+
+	detach, _, err := svcs.StartBindings(ctx, container.Services)
+	if err != nil {
+		return nil, err
+	}
+	defer detach()
+
+	imageLayers, err := bk.ImageLayers(ctx, container.FS)
+	if err != nil {
+		return nil, err
+	}
+	layers := make([]ContainerLayer, 0, len(imageLayers))
+	for _, layer := range imageLayers {
+		layers = append(layers, ContainerLayer{
+			Digest:     layer.Digest.String(),
+			Size:       int(layer.Size),
+			Command:    layer.Command,
+			WastedSize: int(layer.WastedSize),
+		})
+	}
+	return layers, nil
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"dagger.io/dagger/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dagger/dagger/dagql"
+	"github.com/dagger/dagger/dagql/call"
+	"github.com/dagger/dagger/engine"
+)
+
+// daemon tracks a service started by a daemon function. Its span stays
+// running for as long as the service does, modeling the service's health
+// across the clients that attach to and detach from it.
+type daemon struct {
+	span trace.Span
+}
+
+// StartDaemon starts a service returned by a daemon function, keeping it
+// running after the call returns until it's stopped or its session ends,
+// regardless of which clients are attached to it. If the daemon is already
+// running, the client is attached to it instead.
+func (ss *Services) StartDaemon(ctx context.Context, id *call.ID, svc Startable, name string) (*RunningService, error) {
+	clientMetadata, err := engine.ClientMetadataFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key := ServiceKey{
+		Digest:    id.Digest(),
+		SessionID: clientMetadata.SessionID,
+	}
+	ss.l.Lock()
+	if _, exists := ss.daemons[key]; exists {
+		running := ss.running[key]
+		ss.daemonEvent(ctx, key, "attached")
+		ss.l.Unlock()
+		return running, nil
+	}
+	ss.l.Unlock()
+
+	// the span outlives the call that started it
+	ctx, span := Tracer(ctx).Start(context.WithoutCancel(ctx), "daemon "+name,
+		trace.WithAttributes(attribute.Bool(telemetry.DaemonAttr, true)))
+
+	running, err := ss.Start(ctx, id, svc)
+	if err != nil {
+		telemetry.End(span, func() error { return err })
+		return nil, err
+	}
+
+	ss.l.Lock()
+	if _, exists := ss.daemons[key]; exists {
+		// started concurrently; Start attached us to it, but the daemon
+		// already holds a binding
+		ss.bindings[key]--
+		ss.l.Unlock()
+		span.End()
+		return running, nil
+	}
+	// the daemon keeps the binding from Start, so that it isn't stopped
+	// when the clients using it detach
+	ss.daemons[key] = &daemon{span: span}
+	ss.l.Unlock()
+
+	span.SetAttributes(attribute.String(telemetry.DaemonHealthAttr, telemetry.DaemonHealthy))
+	if running.Wait != nil {
+		go func() {
+			err := running.Wait(context.WithoutCancel(ctx))
+			ss.endDaemon(key, err)
+		}()
+	}
+	return running, nil
+}
+
+// daemonEvent records a client attaching to or detaching from a daemon, if
+// the service is one. The caller must hold the lock.
+func (ss *Services) daemonEvent(ctx context.Context, key ServiceKey, event string) {
+	d, ok := ss.daemons[key]
+	if !ok {
+		return
+	}
+	var attrs []attribute.KeyValue
+	if md, err := engine.ClientMetadataFromContext(ctx); err == nil {
+		attrs = append(attrs, attribute.String(telemetry.DaemonClientAttr, md.ClientID))
+	}
+	d.span.AddEvent(event, trace.WithAttributes(attrs...))
+}
+
+// endDaemon ends the span of a daemon once its service has exited, marking it
+// unhealthy if it exited with an error.
+func (ss *Services) endDaemon(key ServiceKey, err error) {
+	ss.l.Lock()
+	d, ok := ss.daemons[key]
+	delete(ss.daemons, key)
+	ss.l.Unlock()
+	if !ok {
+		return
+	}
+	if err != nil {
+		d.span.SetAttributes(attribute.String(telemetry.DaemonHealthAttr, telemetry.DaemonUnhealthy))
+		d.span.SetStatus(codes.Error, err.Error())
+	} else {
+		d.span.SetAttributes(attribute.String(telemetry.DaemonHealthAttr, telemetry.DaemonStopped))
+	}
+	d.span.End()
+}
+
+// startDaemon starts the service returned by a daemon function.
+func (fn *ModuleFunction) startDaemon(ctx context.Context, res dagql.Typed) error {
+	svc, ok := res.(dagql.Instance[*Service])
+	if !ok {
+		return fmt.Errorf("daemon function %q returned %T, not a Service", fn.metadata.Name, res)
+	}
+	svcs, err := fn.root.Services(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get services: %w", err)
+	}
+	name := fn.metadata.Name
+	if fn.objDef != nil {
+		name = fn.objDef.Name + "." + name
+	}
+	if _, err := svcs.StartDaemon(ctx, svc.ID(), svc.Self, name); err != nil {
+		return fmt.Errorf("failed to start daemon: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package core_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"dagger.io/dagger/telemetry"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/engine"
+)
+
+func TestServicesStartDaemon(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, call := tp.Tracer("test").Start(context.Background(), "call")
+	clientCtx := func(clientID string) context.Context {
+		return engine.ContextWithClientMetadata(ctx, &engine.ClientMetadata{
+			ClientID:  clientID,
+			SessionID: "session",
+		})
+	}
+
+	services := core.NewServices()
+	stub := newStartable("daemon")
+	var stops int32
+	expected := &core.RunningService{
+		Key: core.ServiceKey{
+			Digest:    stub.ID().Digest(),
+			SessionID: "session",
+		},
+		Host: "daemon-host",
+		Stop: func(context.Context, bool) error {
+			atomic.AddInt32(&stops, 1)
+			return nil
+		},
+	}
+	stub.startResults <- startResult{Started: expected}
+
+	running, err := services.StartDaemon(clientCtx("client-1"), stub.ID(), stub, "Mod.serve")
+	require.NoError(t, err)
+	require.Equal(t, expected, running)
+	// the daemon keeps running after the call that started it
+	call.End()
+	require.Empty(t, recorder.Ended()[1:])
+
+	// calling the daemon again attaches to it
+	running, err = services.StartDaemon(clientCtx("client-2"), stub.ID(), stub, "Mod.serve")
+	require.NoError(t, err)
+	require.Equal(t, expected, running)
+	require.Equal(t, 1, stub.Starts())
+
+	// clients detaching don't stop it
+	running, err = services.Start(clientCtx("client-3"), stub.ID(), stub)
+	require.NoError(t, err)
+	services.Detach(clientCtx("client-3"), running)
+	require.Zero(t, atomic.LoadInt32(&stops))
+
+	require.NoError(t, services.Stop(clientCtx("client-1"), stub.ID(), false))
+	require.Equal(t, int32(1), atomic.LoadInt32(&stops))
+
+	ended := recorder.Ended()
+	require.Len(t, ended, 2)
+	span := ended[1]
+	require.Equal(t, "daemon Mod.serve", span.Name())
+	require.Equal(t, call.SpanContext().SpanID(), span.Parent().SpanID())
+	require.Contains(t, span.Attributes(), attribute.Bool(telemetry.DaemonAttr, true))
+	require.Contains(t, span.Attributes(), attribute.String(telemetry.DaemonHealthAttr, telemetry.DaemonStopped))
+	var events []string
+	for _, ev := range span.Events() {
+		require.Len(t, ev.Attributes, 1)
+		require.Equal(t, telemetry.DaemonClientAttr, string(ev.Attributes[0].Key))
+		events = append(events, ev.Name+" "+ev.Attributes[0].Value.AsString())
+	}
+	require.Equal(t, []string{
+		"attached client-2",
+		"attached client-3",
+		"detached client-3",
+	}, events)
+}
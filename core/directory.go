@@ -771,7 +771,7 @@ func (dir *Directory) Export(ctx context.Context, destPath string, merge bool) (
 	}
 	defer detach()
 
-	return bk.LocalDirExport(ctx, defPB, destPath, merge)
+	return bk.LocalDirExport(ctx, defPB, destPath, merge, exportProgress(ctx))
 }
 
 // Root removes any relative path from the directory.
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ParseEnvFile parses a dotenv-style file of KEY=VALUE lines, returning the
+// values by key. Blank lines and # comments are skipped, an "export " prefix
+// is allowed, and quoted values are unquoted.
+func ParseEnvFile(name string, contents []byte) (map[string]string, error) {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("parse %s:%d: expected KEY=VALUE", name, lineNo)
+		}
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", name, err)
+	}
+	return values, nil
+}
+
+// ClassifyEnvFile splits the keys of an env file's values into those matching
+// any of the given glob patterns, e.g. "*_TOKEN", which are to be loaded as
+// secrets, and the rest. Both are sorted.
+func ClassifyEnvFile(values map[string]string, secretPatterns []string) (plain, secrets []string, err error) {
+	for _, pattern := range secretPatterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, nil, fmt.Errorf("invalid secret pattern %q: %w", pattern, err)
+		}
+	}
+	for key := range values {
+		secret := slices.ContainsFunc(secretPatterns, func(pattern string) bool {
+			matched, _ := path.Match(pattern, key)
+			return matched
+		})
+		if secret {
+			secrets = append(secrets, key)
+		} else {
+			plain = append(plain, key)
+		}
+	}
+	slices.Sort(plain)
+	slices.Sort(secrets)
+	return plain, secrets, nil
+}
@@ -0,0 +1,35 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvFile(t *testing.T) {
+	values, err := ParseEnvFile(".env", []byte(`
+# database
+DB_HOST=localhost
+export DB_PASSWORD="s3cr=t"
+GITHUB_TOKEN='ghp_123'
+EMPTY=
+`))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"DB_HOST":      "localhost",
+		"DB_PASSWORD":  "s3cr=t",
+		"GITHUB_TOKEN": "ghp_123",
+		"EMPTY":        "",
+	}, values)
+
+	plain, secrets, err := ClassifyEnvFile(values, []string{"*_TOKEN", "*_PASSWORD"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"DB_HOST", "EMPTY"}, plain)
+	require.Equal(t, []string{"DB_PASSWORD", "GITHUB_TOKEN"}, secrets)
+
+	_, _, err = ClassifyEnvFile(values, []string{"[*"})
+	require.ErrorContains(t, err, `invalid secret pattern "[*"`)
+
+	_, err = ParseEnvFile(".env", []byte("FOO=bar\nnonsense\n"))
+	require.ErrorContains(t, err, "parse .env:2: expected KEY=VALUE")
+}
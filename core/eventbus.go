@@ -0,0 +1,108 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"dagger.io/dagger/telemetry"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SessionEvent is an event published to a session's EventBus.
+type SessionEvent struct {
+	Topic    string `field:"true" doc:"The topic the event was published to."`
+	Payload  string `field:"true" doc:"The payload of the event, if any."`
+	Sequence int    `field:"true" doc:"The position of the event in its topic, starting at 1."`
+}
+
+func (SessionEvent) Type() *ast.Type {
+	return &ast.Type{
+		NamedType: "SessionEvent",
+		NonNull:   true,
+	}
+}
+
+func (SessionEvent) TypeDescription() string {
+	return "An event published to the session's event bus."
+}
+
+// EventBus lets the clients of a session, e.g. the functions of the modules it
+// loads, coordinate by publishing events to topics and waiting for them, e.g.
+// a function waiting for another to publish "db-migrated".
+//
+// Events are kept for the lifetime of the session, so that waiting for an
+// event that was published before the wait began returns it immediately
+// rather than blocking forever.
+type EventBus struct {
+	mu     sync.Mutex
+	topics map[string][]SessionEvent
+	// published is closed and replaced each time an event is published, to
+	// wake up anyone waiting.
+	published chan struct{}
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{
+		topics:    map[string][]SessionEvent{},
+		published: make(chan struct{}),
+	}
+}
+
+// Publish publishes an event to a topic, recording it as an event on the
+// current span.
+func (bus *EventBus) Publish(ctx context.Context, topic, payload string) (SessionEvent, error) {
+	if topic == "" {
+		return SessionEvent{}, errors.New("event topic must not be empty")
+	}
+	bus.mu.Lock()
+	event := SessionEvent{
+		Topic:    topic,
+		Payload:  payload,
+		Sequence: len(bus.topics[topic]) + 1,
+	}
+	bus.topics[topic] = append(bus.topics[topic], event)
+	close(bus.published)
+	bus.published = make(chan struct{})
+	bus.mu.Unlock()
+
+	recordEvent(ctx, "published "+topic, event)
+	return event, nil
+}
+
+// Wait returns the first event published to a topic after the given sequence
+// number, waiting for one to be published if there is none yet. Waiting
+// after 0 returns the first event ever published to the topic.
+func (bus *EventBus) Wait(ctx context.Context, topic string, after int) (SessionEvent, error) {
+	if topic == "" {
+		return SessionEvent{}, errors.New("event topic must not be empty")
+	}
+	for {
+		bus.mu.Lock()
+		events := bus.topics[topic]
+		published := bus.published
+		bus.mu.Unlock()
+
+		if after < len(events) {
+			event := events[max(after, 0)]
+			recordEvent(ctx, "received "+topic, event)
+			return event, nil
+		}
+
+		select {
+		case <-published:
+		case <-ctx.Done():
+			return SessionEvent{}, context.Cause(ctx)
+		}
+	}
+}
+
+func recordEvent(ctx context.Context, name string, event SessionEvent) {
+	trace.SpanFromContext(ctx).AddEvent(name, trace.WithAttributes(
+		attribute.String(telemetry.EventTopicAttr, event.Topic),
+		attribute.String(telemetry.EventPayloadAttr, event.Payload),
+		attribute.Int(telemetry.EventSequenceAttr, event.Sequence),
+	))
+}
@@ -0,0 +1,50 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/core"
+)
+
+func TestEventBus(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	bus := core.NewEventBus()
+
+	// events published before the wait began are returned right away
+	_, err := bus.Publish(ctx, "db-migrated", "v1")
+	require.NoError(t, err)
+	event, err := bus.Wait(ctx, "db-migrated", 0)
+	require.NoError(t, err)
+	require.Equal(t, core.SessionEvent{Topic: "db-migrated", Payload: "v1", Sequence: 1}, event)
+
+	// waiting after the last event blocks until the next one
+	received := make(chan core.SessionEvent)
+	go func() {
+		event, _ := bus.Wait(ctx, "db-migrated", event.Sequence)
+		received <- event
+	}()
+	_, err = bus.Publish(ctx, "other", "")
+	require.NoError(t, err)
+	select {
+	case <-received:
+		t.Fatal("received an event from another topic")
+	case <-time.After(50 * time.Millisecond):
+	}
+	_, err = bus.Publish(ctx, "db-migrated", "v2")
+	require.NoError(t, err)
+	require.Equal(t, core.SessionEvent{Topic: "db-migrated", Payload: "v2", Sequence: 2}, <-received)
+
+	// waits end with their context
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = bus.Wait(ctx, "never", 0)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = bus.Publish(ctx, "", "")
+	require.Error(t, err)
+}
@@ -15,10 +15,14 @@ import (
 	"github.com/opencontainers/go-digest"
 	fstypes "github.com/tonistiigi/fsutil/types"
 	"github.com/vektah/gqlparser/v2/ast"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 
 	"dagger.io/dagger/telemetry"
 	"github.com/dagger/dagger/core/reffs"
+	"github.com/dagger/dagger/dagql"
 	"github.com/dagger/dagger/engine/buildkit"
+	"github.com/dagger/dagger/engine/slog"
 )
 
 // File is a content-addressed file.
@@ -353,7 +357,33 @@ func (file *File) Export(ctx context.Context, dest string, allowParentDirPath bo
 	}
 	defer detach()
 
-	return bk.LocalFileExport(ctx, def.ToPB(), dest, file.File, allowParentDirPath)
+	return bk.LocalFileExport(ctx, def.ToPB(), dest, file.File, allowParentDirPath, exportProgress(ctx))
+}
+
+// exportProgress records the progress of exporting a file or directory to the
+// host as metrics of the current call, so that it's shown as it's sent.
+func exportProgress(ctx context.Context) buildkit.ExportProgress {
+	id := dagql.CurrentID(ctx)
+	if id == nil {
+		return nil
+	}
+	attrs := callMetricAttrs(id.Digest(), trace.SpanContextFromContext(ctx))
+	meter := telemetry.Meter(ctx, InstrumentationLibrary)
+	sentGauge, err := meter.Int64Gauge(telemetry.ExportSentBytes, metric.WithUnit(telemetry.ByteUnitName))
+	if err != nil {
+		slog.Warn("failed to create export metric", "metric", telemetry.ExportSentBytes, "err", err)
+		return nil
+	}
+	totalGauge, err := meter.Int64Gauge(telemetry.ExportTotalBytes, metric.WithUnit(telemetry.ByteUnitName))
+	if err != nil {
+		slog.Warn("failed to create export metric", "metric", telemetry.ExportTotalBytes, "err", err)
+		return nil
+	}
+	opt := metric.WithAttributes(attrs...)
+	return func(sent, total int64) {
+		sentGauge.Record(ctx, sent, opt)
+		totalGauge.Record(ctx, total, opt)
+	}
 }
 
 // bkRef returns the buildkit reference from the solved def.
@@ -180,6 +180,7 @@ func (iface *InterfaceType) Install(ctx context.Context, dag *dagql.Server) erro
 			TypeDef:   iface.typeDef,
 			IfaceType: iface,
 		},
+		NoTimeout: true,
 	})
 
 	dag.InstallObject(class)
@@ -315,6 +316,9 @@ func (iface *InterfaceType) Install(ctx context.Context, dag *dagql.Server) erro
 		})
 	}
 
+	if err := checkBuiltinFields(class, fields); err != nil {
+		return fmt.Errorf("interface %q: %w", ifaceTypeDef.Name, err)
+	}
 	class.Install(fields...)
 	dag.InstallObject(class)
 
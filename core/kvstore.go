@@ -0,0 +1,134 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"dagger.io/dagger/telemetry"
+	"github.com/dagger/dagger/engine"
+)
+
+// KVBackend persists key/value pairs beyond a session, grouped in namespaces.
+type KVBackend interface {
+	Get(namespace, key string) (value string, found bool, err error)
+	Set(namespace, key, value string) error
+	Delete(namespace, key string) error
+}
+
+// KVStore is a small key/value store for the clients of a session, e.g. for
+// modules to stash state like counters or the digests of previous artifacts.
+//
+// Values are kept for the lifetime of the session, or persisted across
+// sessions by the engine. Either way, they're namespaced with KVNamespace, so
+// that modules and projects don't clobber each other's values.
+type KVStore struct {
+	mu     sync.Mutex
+	values map[kvKey]string
+
+	// persisted is where persistent values are kept, if anywhere.
+	persisted KVBackend
+}
+
+type kvKey struct {
+	namespace string
+	key       string
+}
+
+// NewKVStore returns a KVStore persisting values to the given backend, which
+// may be nil if persistence is unavailable.
+func NewKVStore(persisted KVBackend) *KVStore {
+	return &KVStore{
+		values:    map[kvKey]string{},
+		persisted: persisted,
+	}
+}
+
+// Get returns the value of a key, and whether it was found.
+func (kv *KVStore) Get(ctx context.Context, namespace, key string, persistent bool) (_ string, _ bool, rerr error) {
+	if key == "" {
+		return "", false, errors.New("key must not be empty")
+	}
+	_, span := Tracer(ctx).Start(ctx, fmt.Sprintf("kv get %s", key), telemetry.Internal())
+	defer telemetry.End(span, func() error { return rerr })
+	if persistent {
+		if kv.persisted == nil {
+			return "", false, errors.New("persistent values are not supported by this engine")
+		}
+		return kv.persisted.Get(namespace, key)
+	}
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	value, found := kv.values[kvKey{namespace, key}]
+	return value, found, nil
+}
+
+// Set sets the value of a key.
+func (kv *KVStore) Set(ctx context.Context, namespace, key, value string, persistent bool) (rerr error) {
+	if key == "" {
+		return errors.New("key must not be empty")
+	}
+	_, span := Tracer(ctx).Start(ctx, fmt.Sprintf("kv set %s", key), telemetry.Internal())
+	defer telemetry.End(span, func() error { return rerr })
+	if persistent {
+		if kv.persisted == nil {
+			return errors.New("persistent values are not supported by this engine")
+		}
+		return kv.persisted.Set(namespace, key, value)
+	}
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.values[kvKey{namespace, key}] = value
+	return nil
+}
+
+// Delete deletes a key, if it's set.
+func (kv *KVStore) Delete(ctx context.Context, namespace, key string, persistent bool) (rerr error) {
+	if key == "" {
+		return errors.New("key must not be empty")
+	}
+	_, span := Tracer(ctx).Start(ctx, fmt.Sprintf("kv delete %s", key), telemetry.Internal())
+	defer telemetry.End(span, func() error { return rerr })
+	if persistent {
+		if kv.persisted == nil {
+			return errors.New("persistent values are not supported by this engine")
+		}
+		return kv.persisted.Delete(namespace, key)
+	}
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	delete(kv.values, kvKey{namespace, key})
+	return nil
+}
+
+// KVNamespace returns the namespace of the keys stored by a module, or by the
+// main client if mod is nil, on behalf of the given main client.
+//
+// Persisted values are shared by the whole engine, so namespaces are scoped
+// by the client's stable ID and project (its git remote, if any), and by the
+// module's source: its git repo and subpath, or its path in its local context.
+// A module name alone would collide across unrelated projects and users.
+func KVNamespace(client *engine.ClientMetadata, mod *Module) (string, error) {
+	ins := []string{
+		client.ClientStableID,
+		client.Labels[kvProjectLabel],
+	}
+	if mod != nil {
+		src := mod.Source.Self
+		if src == nil {
+			return "", fmt.Errorf("module %q has no source", mod.Name())
+		}
+		symbolic, err := src.Symbolic()
+		if err != nil {
+			return "", err
+		}
+		ins = append(ins, string(src.Kind), symbolic, mod.Name())
+	}
+	// separate the parts, so that they can't run into each other
+	return HashFrom(strings.Join(ins, "\x00")).String(), nil
+}
+
+// kvProjectLabel is the client label identifying the project it runs in.
+const kvProjectLabel = "dagger.io/git.remote"
@@ -0,0 +1,138 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/dagql"
+	"github.com/dagger/dagger/engine"
+)
+
+type memKV map[string]map[string]string
+
+func (kv memKV) Get(namespace, key string) (string, bool, error) {
+	value, found := kv[namespace][key]
+	return value, found, nil
+}
+
+func (kv memKV) Set(namespace, key, value string) error {
+	if kv[namespace] == nil {
+		kv[namespace] = map[string]string{}
+	}
+	kv[namespace][key] = value
+	return nil
+}
+
+func (kv memKV) Delete(namespace, key string) error {
+	delete(kv[namespace], key)
+	return nil
+}
+
+func TestKVStore(t *testing.T) {
+	t.Parallel()
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "call")
+	defer span.End()
+
+	persisted := memKV{}
+	kv := core.NewKVStore(persisted)
+
+	require.NoError(t, kv.Set(ctx, "mod", "build-count", "1", false))
+	value, found, err := kv.Get(ctx, "mod", "build-count", false)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "1", value)
+
+	// keys are scoped to their namespace
+	_, found, err = kv.Get(ctx, "other", "build-count", false)
+	require.NoError(t, err)
+	require.False(t, found)
+
+	// and to the session, unless persisted
+	_, found, err = kv.Get(ctx, "mod", "build-count", true)
+	require.NoError(t, err)
+	require.False(t, found)
+	require.NoError(t, kv.Set(ctx, "mod", "digest", "sha256:abc", true))
+	require.Equal(t, memKV{"mod": {"digest": "sha256:abc"}}, persisted)
+	value, found, err = core.NewKVStore(persisted).Get(ctx, "mod", "digest", true)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "sha256:abc", value)
+
+	require.NoError(t, kv.Delete(ctx, "mod", "build-count", false))
+	_, found, err = kv.Get(ctx, "mod", "build-count", false)
+	require.NoError(t, err)
+	require.False(t, found)
+
+	require.Error(t, kv.Set(ctx, "mod", "", "", false))
+	require.Error(t, core.NewKVStore(nil).Set(ctx, "mod", "key", "", true))
+
+	// reads and writes are traced
+	var names []string
+	for _, span := range recorder.Ended() {
+		names = append(names, span.Name())
+	}
+	require.Contains(t, names, "kv set build-count")
+	require.Contains(t, names, "kv get digest")
+	require.Contains(t, names, "kv delete build-count")
+}
+
+func TestKVNamespace(t *testing.T) {
+	gitModule := func(name, cloneRef, subpath string) *core.Module {
+		return &core.Module{
+			NameField: name,
+			Source: dagql.Instance[*core.ModuleSource]{Self: &core.ModuleSource{
+				Kind:        core.ModuleSourceKindGit,
+				AsGitSource: dagql.NonNull(&core.GitModuleSource{CloneRef: cloneRef, RootSubpath: subpath}),
+			}},
+		}
+	}
+	localModule := func(name, subpath string) *core.Module {
+		return &core.Module{
+			NameField: name,
+			Source: dagql.Instance[*core.ModuleSource]{Self: &core.ModuleSource{
+				Kind:          core.ModuleSourceKindLocal,
+				AsLocalSource: dagql.NonNull(&core.LocalModuleSource{RootSubpath: subpath}),
+			}},
+		}
+	}
+	alice := &engine.ClientMetadata{
+		ClientStableID: "alice",
+		Labels:         map[string]string{"dagger.io/git.remote": "github.com/acme/app"},
+	}
+	namespace := func(client *engine.ClientMetadata, mod *core.Module) string {
+		ns, err := core.KVNamespace(client, mod)
+		require.NoError(t, err)
+		return ns
+	}
+
+	ci := namespace(alice, gitModule("ci", "github.com/acme/ci", "/"))
+	require.Equal(t, ci, namespace(alice, gitModule("ci", "github.com/acme/ci", "/")))
+	for _, other := range []string{
+		// the main client
+		namespace(alice, nil),
+		// a module of the same name from another repo or subpath
+		namespace(alice, gitModule("ci", "github.com/other/ci", "/")),
+		namespace(alice, gitModule("ci", "github.com/acme/ci", "/sub")),
+		namespace(alice, localModule("ci", ".")),
+		// the same module in another project
+		namespace(&engine.ClientMetadata{
+			ClientStableID: "alice",
+			Labels:         map[string]string{"dagger.io/git.remote": "github.com/acme/web"},
+		}, gitModule("ci", "github.com/acme/ci", "/")),
+		// or used by another client
+		namespace(&engine.ClientMetadata{
+			ClientStableID: "bob",
+			Labels:         alice.Labels,
+		}, gitModule("ci", "github.com/acme/ci", "/")),
+	} {
+		require.NotEqual(t, ci, other)
+	}
+	require.NotEqual(t, namespace(alice, nil), namespace(&engine.ClientMetadata{ClientStableID: "bob"}, nil))
+}
@@ -0,0 +1,235 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	fstypes "github.com/tonistiigi/fsutil/types"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/dagger/dagger/engine/buildkit"
+)
+
+// License is a license detected in a directory.
+type License struct {
+	Path   string `field:"true" doc:"The path of the file the license was detected in, relative to the directory."`
+	SpdxID string `field:"true" name:"spdxId" doc:"The SPDX identifier of the license (e.g., \"Apache-2.0\"), or NOASSERTION if it wasn't recognized."`
+	Name   string `field:"true" doc:"The name of the license, or the first line of the file if it wasn't recognized."`
+}
+
+func (License) Type() *ast.Type {
+	return &ast.Type{
+		NamedType: "License",
+		NonNull:   true,
+	}
+}
+
+func (License) TypeDescription() string {
+	return "A license detected in a file."
+}
+
+// LicenseNoAssertion is the SPDX identifier reported for license files whose
+// license wasn't recognized.
+const LicenseNoAssertion = "NOASSERTION"
+
+// maxLicenseFileSize is how much of each license file is read. License texts
+// are well under this, and the parts that identify them come first.
+const maxLicenseFileSize = 64 * 1024
+
+// knownLicense is a license recognized by its title, e.g. "GNU General Public
+// License", followed closely by its version, if any.
+type knownLicense struct {
+	spdxID  string
+	name    string
+	title   string
+	version string
+}
+
+// titledLicenses are recognized by whichever title comes first, since license
+// texts often mention other licenses further down, e.g. the GPL pointing to
+// the LGPL.
+var titledLicenses = []knownLicense{
+	{"Apache-2.0", "Apache License 2.0", "apache license", "version 2.0"},
+	{"AGPL-3.0", "GNU Affero General Public License v3.0", "gnu affero general public license", "version 3"},
+	{"LGPL-3.0", "GNU Lesser General Public License v3.0", "gnu lesser general public license", "version 3"},
+	{"LGPL-2.1", "GNU Lesser General Public License v2.1", "gnu lesser general public license", "version 2.1"},
+	{"GPL-3.0", "GNU General Public License v3.0", "gnu general public license", "version 3"},
+	{"GPL-2.0", "GNU General Public License v2.0", "gnu general public license", "version 2"},
+	{"MPL-2.0", "Mozilla Public License 2.0", "mozilla public license", "version 2.0"},
+	{"EPL-2.0", "Eclipse Public License 2.0", "eclipse public license", "v 2.0"},
+	{"BSL-1.0", "Boost Software License 1.0", "boost software license", "version 1.0"},
+	{"CC0-1.0", "Creative Commons Zero v1.0 Universal", "cc0 1.0 universal", ""},
+	{"Unlicense", "The Unlicense", "this is free and unencumbered software released into the public domain", ""},
+}
+
+// untitledLicenses are recognized by their wording, for licenses that don't
+// usually carry their name.
+var untitledLicenses = []struct {
+	spdxID  string
+	name    string
+	phrases []string
+}{
+	{"BSD-3-Clause", `BSD 3-Clause "New" or "Revised" License`, []string{
+		"redistribution and use in source and binary forms",
+		"neither the name",
+	}},
+	{"BSD-2-Clause", `BSD 2-Clause "Simplified" License`, []string{
+		"redistribution and use in source and binary forms",
+	}},
+	{"MIT", "MIT License", []string{
+		"permission is hereby granted free of charge",
+		"the above copyright notice and this permission notice shall be included",
+	}},
+	{"ISC", "ISC License", []string{
+		"permission to use copy modify and/or distribute this software for any purpose",
+	}},
+}
+
+// titleWindow is how far after a license's title its version is looked for.
+const titleWindow = 200
+
+var (
+	spdxIdentifierPattern = regexp.MustCompile(`SPDX-License-Identifier:\s*([^\s*]+(?:\s+(?:AND|OR|WITH)\s+[^\s*]+)*)`)
+	nonWordPattern        = regexp.MustCompile(`[^a-z0-9./]+`)
+)
+
+// DetectLicense identifies the license in the content of a license file,
+// returning its SPDX identifier and name. Explicit SPDX-License-Identifier
+// tags take precedence over the text of the license.
+func DetectLicense(content string) (spdxID string, name string) {
+	if m := spdxIdentifierPattern.FindStringSubmatch(content); m != nil {
+		return m[1], m[1]
+	}
+
+	// compare words only, so that wrapping and punctuation don't matter
+	text := strings.TrimSpace(nonWordPattern.ReplaceAllString(strings.ToLower(content), " "))
+
+	best := -1
+	for _, license := range titledLicenses {
+		idx := strings.Index(text, license.title)
+		if idx == -1 || (best != -1 && idx >= best) {
+			continue
+		}
+		if license.version != "" {
+			after := text[idx+len(license.title):]
+			window := after[:min(len(after), titleWindow)]
+			// "version 2" must not match "version 2.1"
+			window += " "
+			if !strings.Contains(window, license.version+" ") &&
+				!strings.Contains(window, license.version+". ") {
+				continue
+			}
+		}
+		best, spdxID, name = idx, license.spdxID, license.name
+	}
+	if best != -1 {
+		return spdxID, name
+	}
+
+	for _, license := range untitledLicenses {
+		matched := true
+		for _, phrase := range license.phrases {
+			if !strings.Contains(text, phrase) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return license.spdxID, license.name
+		}
+	}
+
+	firstLine, _, _ := strings.Cut(strings.TrimSpace(content), "\n")
+	return LicenseNoAssertion, strings.TrimSpace(firstLine)
+}
+
+// isLicenseFile returns whether a file name looks like a license file, e.g.
+// LICENSE, LICENSE-MIT, COPYING.txt, or MIT-LICENSE.md.
+func isLicenseFile(name string) bool {
+	upper := strings.ToUpper(name)
+	switch ext := filepath.Ext(upper); ext {
+	case ".TXT", ".MD", ".RST":
+		upper = strings.TrimSuffix(upper, ext)
+	}
+	for _, word := range []string{"LICENSE", "LICENCE", "COPYING", "UNLICENSE"} {
+		if upper == word ||
+			strings.HasPrefix(upper, word+"-") || strings.HasPrefix(upper, word+"_") ||
+			strings.HasSuffix(upper, "-"+word) || strings.HasSuffix(upper, "_"+word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Licenses detects the licenses of the directory and everything in it,
+// including vendored dependencies, from their license files.
+func (dir *Directory) Licenses(ctx context.Context) ([]License, error) {
+	svcs, err := dir.Query.Services(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get services: %w", err)
+	}
+	bk, err := dir.Query.Buildkit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get buildkit client: %w", err)
+	}
+
+	detach, _, err := svcs.StartBindings(ctx, dir.Services)
+	if err != nil {
+		return nil, err
+	}
+	defer detach()
+
+	res, err := bk.Solve(ctx, bkgw.SolveRequest{
+		Definition: dir.LLB,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := res.SingleRef()
+	if err != nil {
+		return nil, err
+	}
+	// empty directory, i.e. llb.Scratch()
+	if ref == nil {
+		return []License{}, nil
+	}
+
+	var paths []string
+	err = ref.WalkDir(ctx, buildkit.WalkDirRequest{
+		Path: dir.Dir,
+		Callback: func(p string, info *fstypes.Stat) error {
+			if os.FileMode(info.Mode).IsRegular() && isLicenseFile(filepath.Base(p)) {
+				paths = append(paths, p)
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	licenses := make([]License, 0, len(paths))
+	for _, p := range paths {
+		content, err := ref.ReadFile(ctx, bkgw.ReadRequest{
+			Filename: path.Join(dir.Dir, p),
+			Range:    &bkgw.FileRange{Length: maxLicenseFileSize},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		spdxID, name := DetectLicense(string(content))
+		licenses = append(licenses, License{
+			Path:   p,
+			SpdxID: spdxID,
+			Name:   name,
+		})
+	}
+	return licenses, nil
+}
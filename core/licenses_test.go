@@ -0,0 +1,64 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectLicense(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		content string
+		spdxID  string
+	}{
+		{"apache", `
+                                 Apache License
+                           Version 2.0, January 2004
+                        http://www.apache.org/licenses/`, "Apache-2.0"},
+		{"gpl2 mentioning lgpl", `
+		    GNU GENERAL PUBLIC LICENSE
+		       Version 2, June 1991
+...
+consider it more useful to permit linking proprietary applications with the
+library.  If this is what you want to do, use the GNU Lesser General
+Public License instead of this License.`, "GPL-2.0"},
+		{"lgpl2.1", `
+		  GNU LESSER GENERAL PUBLIC LICENSE
+		       Version 2.1, February 1999`, "LGPL-2.1"},
+		{"mit", `MIT License
+
+Copyright (c) 2024 Someone
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+...
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.`, "MIT"},
+		{"bsd3", `Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+...
+3. Neither the name of the copyright holder nor the names of its
+   contributors may be used to endorse or promote products derived from
+   this software without specific prior written permission.`, "BSD-3-Clause"},
+		{"spdx tag", "// SPDX-License-Identifier: MIT OR Apache-2.0\n", "MIT OR Apache-2.0"},
+		{"unknown", "Proprietary and confidential.\nAll rights reserved.", LicenseNoAssertion},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spdxID, _ := DetectLicense(tc.content)
+			require.Equal(t, tc.spdxID, spdxID)
+		})
+	}
+
+	_, name := DetectLicense("Proprietary and confidential.\nAll rights reserved.")
+	require.Equal(t, "Proprietary and confidential.", name)
+}
+
+func TestIsLicenseFile(t *testing.T) {
+	for _, name := range []string{"LICENSE", "license.md", "LICENSE-MIT", "COPYING", "LICENCE.txt", "MIT-LICENSE.txt"} {
+		require.True(t, isLicenseFile(name), name)
+	}
+	for _, name := range []string{"license.go", "README.md", "licenses.json"} {
+		require.False(t, isLicenseFile(name), name)
+	}
+}
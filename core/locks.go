@@ -0,0 +1,195 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"dagger.io/dagger/telemetry"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dagger/dagger/dagql"
+	"github.com/dagger/dagger/engine/slog"
+)
+
+// Locks are named synchronization primitives shared by the clients of a
+// session, so that concurrent branches of a pipeline, e.g. functions of
+// different modules, can safely share expensive setup work.
+//
+// Each lock and once-guard is held by the client that took it, and is
+// released when that client exits, so that a function that crashes, or
+// forgets to release it, doesn't block the rest of the session.
+//
+// Time spent waiting on them is recorded as a metric of the waiting call.
+type Locks struct {
+	mu      sync.Mutex
+	mutexes map[string]*keyedMutex
+	onces   map[string]*keyedOnce
+	leases  int
+}
+
+// keyedMutex is a held mutex.
+type keyedMutex struct {
+	lease string
+	owner string
+	// released is closed when the mutex is released.
+	released chan struct{}
+}
+
+// keyedOnce is a claimed once-guard.
+type keyedOnce struct {
+	owner    string
+	finished bool
+	// done is closed when the claimer finishes, or gives up.
+	done chan struct{}
+}
+
+func NewLocks() *Locks {
+	return &Locks{
+		mutexes: map[string]*keyedMutex{},
+		onces:   map[string]*keyedOnce{},
+	}
+}
+
+// Lock acquires the mutex with the given key for the given owner, waiting for
+// it to be released if it's held. It returns a lease that must be passed to
+// Unlock to release it.
+func (locks *Locks) Lock(ctx context.Context, key, owner string) (string, error) {
+	if key == "" {
+		return "", errors.New("lock key must not be empty")
+	}
+	start := time.Now()
+	defer func() { recordWait(ctx, telemetry.LockWaitMicroseconds, time.Since(start)) }()
+	for {
+		locks.mu.Lock()
+		held, ok := locks.mutexes[key]
+		if !ok {
+			locks.leases++
+			lease := strconv.Itoa(locks.leases)
+			locks.mutexes[key] = &keyedMutex{
+				lease:    lease,
+				owner:    owner,
+				released: make(chan struct{}),
+			}
+			locks.mu.Unlock()
+			return lease, nil
+		}
+		locks.mu.Unlock()
+
+		select {
+		case <-held.released:
+		case <-ctx.Done():
+			return "", context.Cause(ctx)
+		}
+	}
+}
+
+// Unlock releases the mutex with the given key, which must be held with the
+// given lease.
+func (locks *Locks) Unlock(key, lease string) error {
+	locks.mu.Lock()
+	defer locks.mu.Unlock()
+	held, ok := locks.mutexes[key]
+	if !ok || held.lease != lease {
+		return fmt.Errorf("lock %q is not held with lease %q", key, lease)
+	}
+	delete(locks.mutexes, key)
+	close(held.released)
+	return nil
+}
+
+// Claim claims the once-guard with the given key for the given owner,
+// returning true if the caller is the first to claim it and should do the
+// guarded work, then call Finish. Otherwise it waits for the work to be
+// finished and returns false.
+//
+// If the claimer gives up, or exits without finishing, one of the waiters
+// claims it instead.
+func (locks *Locks) Claim(ctx context.Context, key, owner string) (bool, error) {
+	if key == "" {
+		return false, errors.New("once key must not be empty")
+	}
+	start := time.Now()
+	defer func() { recordWait(ctx, telemetry.LockWaitMicroseconds, time.Since(start)) }()
+	for {
+		locks.mu.Lock()
+		once, ok := locks.onces[key]
+		if !ok {
+			locks.onces[key] = &keyedOnce{owner: owner, done: make(chan struct{})}
+			locks.mu.Unlock()
+			return true, nil
+		}
+		locks.mu.Unlock()
+
+		select {
+		case <-once.done:
+			if once.finished {
+				return false, nil
+			}
+		case <-ctx.Done():
+			return false, context.Cause(ctx)
+		}
+	}
+}
+
+// Finish finishes the work guarded by the claimed once-guard with the given
+// key, releasing those waiting on it. If the work failed, the guard is given
+// up instead, to be claimed again.
+func (locks *Locks) Finish(key string, failed bool) error {
+	locks.mu.Lock()
+	defer locks.mu.Unlock()
+	once, ok := locks.onces[key]
+	if !ok {
+		return fmt.Errorf("once %q is not claimed", key)
+	}
+	if once.finished {
+		return fmt.Errorf("once %q is already finished", key)
+	}
+	if failed {
+		delete(locks.onces, key)
+	} else {
+		once.finished = true
+	}
+	close(once.done)
+	return nil
+}
+
+// Release releases the mutexes held by the given owner, and gives up the
+// once-guards it claimed but didn't finish, e.g. once it has exited.
+func (locks *Locks) Release(owner string) {
+	locks.mu.Lock()
+	defer locks.mu.Unlock()
+	for key, held := range locks.mutexes {
+		if held.owner == owner {
+			delete(locks.mutexes, key)
+			close(held.released)
+		}
+	}
+	for key, once := range locks.onces {
+		if once.owner == owner && !once.finished {
+			delete(locks.onces, key)
+			close(once.done)
+		}
+	}
+}
+
+// recordWait records the time the current call spent waiting, e.g. on a
+// lock, as the given metric.
+func recordWait(ctx context.Context, name string, wait time.Duration) {
+	id := dagql.CurrentID(ctx)
+	if id == nil {
+		return
+	}
+	gauge, err := telemetry.Meter(ctx, InstrumentationLibrary).
+		Int64Gauge(name, metric.WithUnit(telemetry.MicrosecondUnitName))
+	if err != nil {
+		slog.Warn("failed to create wait metric", "metric", name, "err", err)
+		return
+	}
+	attrs := callMetricAttrs(id.Digest(), trace.SpanContextFromContext(ctx))
+	gauge.Record(ctx, wait.Microseconds(), metric.WithAttributes(attrs...))
+}
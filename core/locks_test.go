@@ -0,0 +1,139 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/core"
+)
+
+func TestLocksMutex(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	locks := core.NewLocks()
+
+	lease, err := locks.Lock(ctx, "npm-cache", "fn")
+	require.NoError(t, err)
+
+	// other keys aren't held
+	other, err := locks.Lock(ctx, "other", "fn")
+	require.NoError(t, err)
+	require.NoError(t, locks.Unlock("other", other))
+
+	// acquiring a held lock waits for it to be released
+	acquired := make(chan string)
+	go func() {
+		lease, _ := locks.Lock(ctx, "npm-cache", "fn")
+		acquired <- lease
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquired a held lock")
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.Error(t, locks.Unlock("npm-cache", "bogus"))
+	require.NoError(t, locks.Unlock("npm-cache", lease))
+	next := <-acquired
+	require.NotEqual(t, lease, next)
+
+	// a released lease can't release the lock again
+	require.Error(t, locks.Unlock("npm-cache", lease))
+
+	// waits end with their context
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = locks.Lock(ctx, "npm-cache", "fn")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestLocksOnce(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	locks := core.NewLocks()
+
+	claimed, err := locks.Claim(ctx, "seed", "fn")
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	// later claims wait for the work to finish
+	waited := make(chan bool)
+	go func() {
+		claimed, _ := locks.Claim(ctx, "seed", "fn")
+		waited <- claimed
+	}()
+	select {
+	case <-waited:
+		t.Fatal("claim returned before the work finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// giving up lets a waiter claim it instead
+	require.NoError(t, locks.Finish("seed", true))
+	require.True(t, <-waited)
+
+	go func() {
+		claimed, _ := locks.Claim(ctx, "seed", "fn")
+		waited <- claimed
+	}()
+	require.NoError(t, locks.Finish("seed", false))
+	require.False(t, <-waited)
+
+	// once finished, claims return right away
+	claimed, err = locks.Claim(ctx, "seed", "fn")
+	require.NoError(t, err)
+	require.False(t, claimed)
+	require.Error(t, locks.Finish("seed", false))
+	require.Error(t, locks.Finish("unclaimed", false))
+}
+
+func TestLocksReleasedOnExit(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	locks := core.NewLocks()
+
+	// a function takes a lock and claims guards, then crashes
+	lease, err := locks.Lock(ctx, "npm-cache", "crashed")
+	require.NoError(t, err)
+	claimed, err := locks.Claim(ctx, "seed", "crashed")
+	require.NoError(t, err)
+	require.True(t, claimed)
+	claimed, err = locks.Claim(ctx, "migrate", "crashed")
+	require.NoError(t, err)
+	require.True(t, claimed)
+	require.NoError(t, locks.Finish("migrate", false))
+	other, err := locks.Lock(ctx, "other", "running")
+	require.NoError(t, err)
+
+	acquired := make(chan error)
+	go func() {
+		_, err := locks.Lock(ctx, "npm-cache", "waiting")
+		acquired <- err
+	}()
+	waited := make(chan bool)
+	go func() {
+		claimed, _ := locks.Claim(ctx, "seed", "waiting")
+		waited <- claimed
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquired a held lock")
+	case <-waited:
+		t.Fatal("claim returned before the work finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	locks.Release("crashed")
+	require.NoError(t, <-acquired)
+	// the waiter takes over the unfinished work
+	require.True(t, <-waited)
+	// finished work stays finished
+	claimed, err = locks.Claim(ctx, "migrate", "waiting")
+	require.NoError(t, err)
+	require.False(t, claimed)
+	// the released lease is no longer valid, and other owners' locks are kept
+	require.Error(t, locks.Unlock("npm-cache", lease))
+	require.NoError(t, locks.Unlock("other", other))
+}
@@ -8,7 +8,9 @@ import (
 	"path/filepath"
 	"strings"
 
+	"dagger.io/dagger/telemetry"
 	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	bkgwpb "github.com/moby/buildkit/frontend/gateway/pb"
 	"github.com/moby/buildkit/identity"
 	bksession "github.com/moby/buildkit/session"
 	bksolver "github.com/moby/buildkit/solver"
@@ -18,6 +20,8 @@ import (
 	"github.com/moby/buildkit/util/bklog"
 	bkworker "github.com/moby/buildkit/worker"
 	"github.com/opencontainers/go-digest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/dagger/dagger/analytics"
@@ -119,6 +123,102 @@ func (fn *ModuleFunction) recordCall(ctx context.Context) {
 	analytics.Ctx(ctx).Capture(ctx, "module_call", props)
 }
 
+// skipIfNotTargeted skips the call if the session is limited to other
+// functions and the call isn't made within one of them, returning the zero
+// value of the function's return type instead.
+//
+// Only calls whose result can be left empty are skipped. Calls returning
+// objects always run, since they build what the targets depend on.
+func (fn *ModuleFunction) skipIfNotTargeted(ctx context.Context) (dagql.Typed, bool, error) {
+	zero, skip, err := fn.notTargeted(ctx)
+	if err != nil || !skip {
+		return nil, false, err
+	}
+	res, err := fn.returnType.ConvertFromSDKResult(ctx, zero)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to convert skipped result: %w", err)
+	}
+	telemetry.Skip(ctx, "not targeted")
+	return res, true, nil
+}
+
+// notTargeted returns whether the call is skipped because the session is
+// limited to other functions, and the zero value it returns instead.
+func (fn *ModuleFunction) notTargeted(ctx context.Context) (zero any, skip bool, err error) {
+	if fn.metadata.Name == "" || fn.objDef == nil {
+		// constructors build the object the targets are called on
+		return nil, false, nil
+	}
+	targets, withinTarget, err := fn.mod.Query.CallTargets(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get call targets: %w", err)
+	}
+	if withinTarget || IsCallTarget(targets, fn.objDef.Name, fn.metadata.Name) {
+		return nil, false, nil
+	}
+	retType := fn.metadata.ReturnType
+	switch {
+	case retType.Optional, retType.Kind == TypeDefKindVoid:
+	case retType.Kind == TypeDefKindString:
+		zero = ""
+	case retType.Kind == TypeDefKindInteger:
+		zero = 0
+	case retType.Kind == TypeDefKindFloat:
+		zero = 0.0
+	case retType.Kind == TypeDefKindBoolean:
+		zero = false
+	case retType.Kind == TypeDefKindList:
+		zero = []any{}
+	default:
+		return nil, false, nil
+	}
+	return zero, true, nil
+}
+
+// CacheKey returns the key to cache a call to the function with, per client.
+// Calls skipped because they aren't targeted are cached apart from the
+// calls that run, so that their zero result isn't returned for a call that
+// should have run, e.g. the same call made later within a target.
+func (fn *ModuleFunction) CacheKey(ctx context.Context, origDgst digest.Digest) (digest.Digest, error) {
+	dgst, err := CachePerClientObject[any](ctx, nil, nil, origDgst)
+	if err != nil {
+		return "", err
+	}
+	_, skip, err := fn.notTargeted(ctx)
+	if err != nil {
+		return "", err
+	}
+	if skip {
+		return HashFrom(dgst.String(), "not targeted"), nil
+	}
+	return dgst, nil
+}
+
+// IsCallTarget returns whether a function is one of the given targets, which
+// are function names, optionally qualified by their object's name, e.g.
+// "test" or "Backend.test". Names are matched regardless of their case, so
+// that kebab-case names from the CLI match too.
+func IsCallTarget(targets []string, objName, fnName string) bool {
+	normalize := func(name string) string {
+		name = strings.ReplaceAll(name, "-", "")
+		name = strings.ReplaceAll(name, "_", "")
+		return strings.ToLower(name)
+	}
+	for _, target := range targets {
+		obj, name, qualified := strings.Cut(target, ".")
+		if !qualified {
+			obj, name = "", target
+		}
+		if normalize(name) != normalize(fnName) {
+			continue
+		}
+		if !qualified || normalize(obj) == normalize(objName) {
+			return true
+		}
+	}
+	return false
+}
+
 // setCallInputs sets the call inputs for the function call.
 //
 // It first load the argument set by the user.
@@ -216,6 +316,24 @@ func (fn *ModuleFunction) Call(ctx context.Context, opts *CallOpts) (t dagql.Typ
 	// Calls without function name are internal and excluded.
 	fn.recordCall(ctx)
 
+	if res, skipped, err := fn.skipIfNotTargeted(ctx); err != nil || skipped {
+		return res, err
+	}
+
+	if key := fn.metadata.RateLimitKey; key != "" {
+		scope, err := RateLimitScope(fn.mod)
+		if err != nil {
+			return nil, err
+		}
+		if err := fn.root.RateLimiters().Wait(ctx, scope, key, fn.metadata.RateLimitRPS); err != nil {
+			return nil, fmt.Errorf("failed to wait for rate limit %q: %w", key, err)
+		}
+	}
+
+	if err := validateConfigInputs(ctx, opts.Server, opts.Inputs); err != nil {
+		return nil, err
+	}
+
 	callInputs, err := fn.setCallInputs(ctx, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to set call inputs: %w", err)
@@ -308,6 +426,10 @@ func (fn *ModuleFunction) Call(ctx context.Context, opts *CallOpts) (t dagql.Typ
 
 	_, err = ctr.Evaluate(ctx)
 	if err != nil {
+		if isCrash(err) {
+			trace.SpanFromContext(ctx).SetAttributes(
+				attribute.String(telemetry.ErrorCategoryAttr, telemetry.ErrorCategoryCrash))
+		}
 		id, ok, extractErr := extractError(ctx, bk, err)
 		if extractErr != nil {
 			// if the module hasn't provided us with a nice error, just return the
@@ -361,6 +483,12 @@ func (fn *ModuleFunction) Call(ctx context.Context, opts *CallOpts) (t dagql.Typ
 		return nil, fmt.Errorf("failed to convert return value: %w", err)
 	}
 
+	if fn.metadata.Daemon {
+		if err := fn.startDaemon(ctx, returnValueTyped); err != nil {
+			return nil, err
+		}
+	}
+
 	// Get the client ID actually used during the function call - this might not
 	// be the same as execMD.ClientID if the function call was cached at the
 	// buildkit level
@@ -390,6 +518,13 @@ func (fn *ModuleFunction) Call(ctx context.Context, opts *CallOpts) (t dagql.Typ
 	return returnValueTyped, nil
 }
 
+// isCrash returns true if the function's runtime exited with
+// telemetry.CrashExitCode, i.e. it crashed rather than returned an error.
+func isCrash(err error) bool {
+	var exitErr *bkgwpb.ExitError
+	return errors.As(err, &exitErr) && exitErr.ExitCode == telemetry.CrashExitCode
+}
+
 func extractError(ctx context.Context, client *buildkit.Client, baseErr error) (dagql.ID[*Error], bool, error) {
 	var id dagql.ID[*Error]
 
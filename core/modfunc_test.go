@@ -0,0 +1,84 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	bkgwpb "github.com/moby/buildkit/frontend/gateway/pb"
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+
+	"dagger.io/dagger/telemetry"
+	"github.com/dagger/dagger/engine"
+)
+
+func TestIsCallTarget(t *testing.T) {
+	targets := []string{"unit-test", "Backend.lint"}
+	require.True(t, IsCallTarget(targets, "Ci", "unitTest"))
+	require.True(t, IsCallTarget(targets, "Backend", "unitTest"))
+	require.True(t, IsCallTarget(targets, "Backend", "lint"))
+	require.False(t, IsCallTarget(targets, "Frontend", "lint"))
+	require.False(t, IsCallTarget(targets, "Ci", "publish"))
+	require.False(t, IsCallTarget(nil, "Ci", "publish"))
+}
+
+// callTargetsServer is a Server limited to call targets, and whether calls
+// are made within one of them.
+type callTargetsServer struct {
+	Server
+	targets      []string
+	withinTarget bool
+}
+
+func (srv *callTargetsServer) CallTargets(context.Context) ([]string, bool, error) {
+	return srv.targets, srv.withinTarget, nil
+}
+
+func TestModuleFunctionCacheKeyNotTargeted(t *testing.T) {
+	srv := &callTargetsServer{targets: []string{"test"}}
+	fn := &ModuleFunction{
+		mod:      &Module{Query: &Query{Server: srv}},
+		objDef:   &ObjectTypeDef{Name: "Ci"},
+		metadata: &Function{Name: "lint", ReturnType: &TypeDef{Kind: TypeDefKindString}},
+	}
+	ctx := engine.ContextWithClientMetadata(context.Background(), &engine.ClientMetadata{ClientID: "client"})
+	dgst := digest.FromString("lint")
+
+	// called from outside of the targets, the call is skipped
+	skipped, err := fn.CacheKey(ctx, dgst)
+	require.NoError(t, err)
+	_, skip, err := fn.notTargeted(ctx)
+	require.NoError(t, err)
+	require.True(t, skip)
+
+	// the same call made within a target runs, and doesn't hit the skipped
+	// call's zero result
+	srv.withinTarget = true
+	targeted, err := fn.CacheKey(ctx, dgst)
+	require.NoError(t, err)
+	require.NotEqual(t, skipped, targeted)
+	perClient, err := CachePerClientObject[any](ctx, nil, nil, dgst)
+	require.NoError(t, err)
+	require.Equal(t, perClient, targeted)
+
+	// calls returning objects always run, so they're cached as usual
+	srv.withinTarget = false
+	fn.metadata.ReturnType = &TypeDef{Kind: TypeDefKindObject}
+	key, err := fn.CacheKey(ctx, dgst)
+	require.NoError(t, err)
+	require.Equal(t, perClient, key)
+}
+
+func TestIsCrash(t *testing.T) {
+	crashed := fmt.Errorf("process did not complete successfully: %w",
+		&bkgwpb.ExitError{ExitCode: telemetry.CrashExitCode})
+	require.True(t, isCrash(crashed))
+
+	// returning an error isn't a crash
+	failed := fmt.Errorf("process did not complete successfully: %w",
+		&bkgwpb.ExitError{ExitCode: 2})
+	require.False(t, isCrash(failed))
+	require.False(t, isCrash(errors.New("failed to exec function")))
+}
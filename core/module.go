@@ -508,6 +508,9 @@ func (mod *Module) validateObjectTypeDef(ctx context.Context, typeDef *TypeDef)
 		if err := mod.validateTypeDef(ctx, fn.ReturnType); err != nil {
 			return err
 		}
+		if fn.Daemon && (fn.ReturnType.Kind != TypeDefKindObject || fn.ReturnType.AsObject.Value.Name != "Service") {
+			return fmt.Errorf("object %q daemon function %q must return a Service", obj.OriginalName, fn.OriginalName)
+		}
 
 		for _, arg := range fn.Args {
 			argType, ok, err := mod.Deps.ModTypeFor(ctx, arg.TypeDef)
@@ -262,7 +262,8 @@ func (obj *ModuleObject) Install(ctx context.Context, dag *dagql.Server) error {
 	}
 
 	class := dagql.NewClass(dagql.ClassOpts[*ModuleObject]{
-		Typed: obj,
+		Typed:     obj,
+		NoTimeout: true,
 	})
 	objDef := obj.TypeDef
 	mod := obj.Module
@@ -279,12 +280,26 @@ func (obj *ModuleObject) Install(ctx context.Context, dag *dagql.Server) error {
 	}
 	fields = append(fields, funs...)
 
+	if err := checkBuiltinFields(class, fields); err != nil {
+		return fmt.Errorf("object %q: %w", objDef.OriginalName, err)
+	}
 	class.Install(fields...)
 	dag.InstallObject(class)
 
 	return nil
 }
 
+// checkBuiltinFields returns an error if any of the fields would replace one
+// that dagql installs on every class, like id.
+func checkBuiltinFields[T dagql.Typed](class dagql.Class[T], fields []dagql.Field[T]) error {
+	for _, field := range fields {
+		if _, ok := class.Field(field.Spec.Name); ok {
+			return fmt.Errorf("%q conflicts with a built-in field of the same name", field.Spec.Name)
+		}
+	}
+	return nil
+}
+
 func (obj *ModuleObject) installConstructor(ctx context.Context, dag *dagql.Server) error {
 	objDef := obj.TypeDef
 	mod := obj.Module
@@ -480,7 +495,9 @@ func objFun(ctx context.Context, mod *Module, objDef *ObjectTypeDef, fun *Functi
 		// We can't *quite* mark them as fully cached across clients in a session, since Call has special
 		// logic for transferring secrets between cached calls (covered by TestModule/TestSecretNested
 		// integ tests).
-		CacheKeyFunc: CachePerClient[*ModuleObject, map[string]dagql.Input],
+		CacheKeyFunc: func(ctx context.Context, _ dagql.Instance[*ModuleObject], _ map[string]dagql.Input, origDgst digest.Digest) (digest.Digest, error) {
+			return modFun.CacheKey(ctx, origDgst)
+		},
 	}, nil
 }
 
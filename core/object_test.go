@@ -0,0 +1,25 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/dagql"
+)
+
+func TestCheckBuiltinFields(t *testing.T) {
+	field := func(name string) dagql.Field[*Container] {
+		return dagql.Field[*Container]{Spec: dagql.FieldSpec{Name: name}}
+	}
+
+	class := dagql.NewClass[*Container]()
+	require.NoError(t, checkBuiltinFields(class, []dagql.Field[*Container]{field("build")}))
+	require.ErrorContains(t, checkBuiltinFields(class, []dagql.Field[*Container]{field("build"), field("withTimeout")}),
+		`"withTimeout" conflicts with a built-in field`)
+
+	// module types don't have withTimeout, so they may define it themselves
+	class = dagql.NewClass(dagql.ClassOpts[*Container]{NoTimeout: true})
+	require.NoError(t, checkBuiltinFields(class, []dagql.Field[*Container]{field("withTimeout")}))
+	require.Error(t, checkBuiltinFields(class, []dagql.Field[*Container]{field("id")}))
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/distribution/reference"
+
+	"github.com/dagger/dagger/engine"
+)
+
+// OfflineError is returned when the engine is running in offline mode and
+// the pipeline needs content that wasn't pre-seeded from a bundle.
+type OfflineError struct {
+	// Kind of the missing content, e.g. "image" or "module".
+	Kind string
+
+	// Refs that were requested but are missing from the bundle.
+	Refs []string
+}
+
+func (err *OfflineError) Error() string {
+	return fmt.Sprintf(
+		"offline mode: %s not found in pre-seeded bundle: %s (add with `dagger bundle create --%s`)",
+		err.Kind,
+		strings.Join(err.Refs, ", "),
+		err.Kind,
+	)
+}
+
+// offlineMetadata returns the metadata of the non-module client that started
+// the current call chain if it requested offline mode, or nil otherwise.
+//
+// Module clients inherit offline mode from whichever host client called them.
+func offlineMetadata(ctx context.Context, query *Query) (*engine.ClientMetadata, error) {
+	md, err := query.NonModuleParentClientMetadata(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client metadata: %w", err)
+	}
+	if !md.Offline {
+		return nil, nil
+	}
+	return md, nil
+}
+
+// OfflineImageRef resolves an image ref against the pre-seeded bundle when
+// offline mode is enabled. It returns nil if offline mode is disabled.
+//
+// refName must be normalized with engine.NormalizeImageRef, the same way the
+// bundle's images are keyed. In offline mode every image must come from the
+// bundle, including digest-pinned refs, so that nothing is resolved against a
+// registry.
+func OfflineImageRef(ctx context.Context, query *Query, refName reference.Named) (reference.Canonical, error) {
+	md, err := offlineMetadata(ctx, query)
+	if err != nil || md == nil {
+		return nil, err
+	}
+	return offlineImageRef(md.OfflineImages, refName)
+}
+
+func offlineImageRef(images map[string]string, refName reference.Named) (reference.Canonical, error) {
+	if canonical, ok := refName.(reference.Canonical); ok {
+		for _, pinned := range images {
+			pinnedRef, err := parseOfflineImageRef(pinned)
+			if err != nil {
+				return nil, err
+			}
+			if pinnedRef.Name() == canonical.Name() && pinnedRef.Digest() == canonical.Digest() {
+				return canonical, nil
+			}
+		}
+		return nil, &OfflineError{Kind: "image", Refs: []string{refName.String()}}
+	}
+	pinned, ok := images[refName.String()]
+	if !ok {
+		return nil, &OfflineError{Kind: "image", Refs: []string{refName.String()}}
+	}
+	return parseOfflineImageRef(pinned)
+}
+
+func parseOfflineImageRef(pinned string) (reference.Canonical, error) {
+	pinnedRef, err := reference.ParseNormalizedNamed(pinned)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundled image ref %q: %w", pinned, err)
+	}
+	canonical, ok := pinnedRef.(reference.Canonical)
+	if !ok {
+		return nil, fmt.Errorf("bundled image ref %q is not pinned to a digest", pinned)
+	}
+	return canonical, nil
+}
+
+// OfflineModuleSource returns the host path of the bundled copy of a remote
+// module source when offline mode is enabled, which must be loaded instead.
+// It returns an empty path if offline mode is disabled.
+func OfflineModuleSource(ctx context.Context, query *Query, refString string) (string, error) {
+	md, err := offlineMetadata(ctx, query)
+	if err != nil || md == nil {
+		return "", err
+	}
+	path, ok := md.OfflineModules[refString]
+	if !ok {
+		return "", &OfflineError{Kind: "module", Refs: []string{refString}}
+	}
+	return path, nil
+}
+
+// JoinOfflineErrors joins errs, merging any OfflineErrors of the same kind so
+// that everything missing from the bundle is reported at once.
+func JoinOfflineErrors(errs ...error) error {
+	var joined []error
+	byKind := map[string]*OfflineError{}
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		var offlineErr *OfflineError
+		if !errors.As(err, &offlineErr) {
+			joined = append(joined, err)
+			continue
+		}
+		if merged, ok := byKind[offlineErr.Kind]; ok {
+			for _, ref := range offlineErr.Refs {
+				if !slices.Contains(merged.Refs, ref) {
+					merged.Refs = append(merged.Refs, ref)
+				}
+			}
+			continue
+		}
+		merged := &OfflineError{Kind: offlineErr.Kind, Refs: slices.Clone(offlineErr.Refs)}
+		byKind[offlineErr.Kind] = merged
+		joined = append(joined, merged)
+	}
+	if len(joined) == 1 {
+		return joined[0]
+	}
+	return errors.Join(joined...)
+}
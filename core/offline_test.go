@@ -0,0 +1,70 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/engine"
+)
+
+func TestOfflineImageRef(t *testing.T) {
+	const (
+		alpine  = "docker.io/library/alpine@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+		unknown = "sha256:fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210"
+	)
+	// bundles key their images by the normalized ref
+	key, err := engine.NormalizeImageRef("alpine:3.20")
+	require.NoError(t, err)
+	images := map[string]string{key.String(): alpine}
+
+	for _, ref := range []string{
+		"alpine:3.20",
+		"docker.io/library/alpine:3.20",
+		"index.docker.io/library/alpine:3.20",
+		// digest-pinned refs must be in the bundle too
+		alpine,
+		"alpine@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+	} {
+		refName, err := engine.NormalizeImageRef(ref)
+		require.NoError(t, err, ref)
+		canonical, err := offlineImageRef(images, refName)
+		require.NoError(t, err, ref)
+		require.Equal(t, alpine, canonical.String(), ref)
+	}
+
+	for _, ref := range []string{
+		"alpine",
+		"alpine:3.21",
+		"busybox:3.20",
+		"alpine@" + unknown,
+		"ghcr.io/library/alpine@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+	} {
+		refName, err := engine.NormalizeImageRef(ref)
+		require.NoError(t, err, ref)
+		_, err = offlineImageRef(images, refName)
+		var offlineErr *OfflineError
+		require.ErrorAs(t, err, &offlineErr, ref)
+		require.Equal(t, []string{refName.String()}, offlineErr.Refs)
+	}
+}
+
+func TestJoinOfflineErrors(t *testing.T) {
+	require.NoError(t, JoinOfflineErrors(nil, nil))
+
+	other := errors.New("boom")
+	err := JoinOfflineErrors(
+		&OfflineError{Kind: "module", Refs: []string{"github.com/acme/a"}},
+		nil,
+		fmt.Errorf("load dependency: %w", &OfflineError{Kind: "module", Refs: []string{"github.com/acme/b", "github.com/acme/a"}}),
+		&OfflineError{Kind: "image", Refs: []string{"alpine:3.20"}},
+		other,
+	)
+	require.ErrorIs(t, err, other)
+	var offlineErr *OfflineError
+	require.ErrorAs(t, err, &offlineErr)
+	require.Equal(t, &OfflineError{Kind: "module", Refs: []string{"github.com/acme/a", "github.com/acme/b"}}, offlineErr)
+	require.ErrorContains(t, err, "offline mode: image not found in pre-seeded bundle: alpine:3.20")
+}
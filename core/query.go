@@ -38,6 +38,11 @@ type Server interface {
 	// If the current client is coming from a function, return the function call metadata
 	CurrentFunctionCall(context.Context) (*FunctionCall, error)
 
+	// The functions that the session is limited to calling, and whether the
+	// current client is running within a call to one of them, in which case
+	// anything it calls runs too. There is no limit if there are no targets.
+	CallTargets(context.Context) (targets []string, withinTarget bool, err error)
+
 	// Return the list of deps being served to the current client
 	CurrentServedDeps(context.Context) (*ModDeps, error)
 
@@ -74,9 +79,21 @@ type Server interface {
 	// The services for the current client's session
 	Services(context.Context) (*Services, error)
 
+	// The event bus for the current client's session
+	Events(context.Context) (*EventBus, error)
+
+	// The locks for the current client's session
+	Locks(context.Context) (*Locks, error)
+
+	// The key/value store for the current client's session
+	KV(context.Context) (*KVStore, error)
+
 	// The default platform for the engine as a whole
 	Platform() Platform
 
+	// The rate limiters for the engine as a whole
+	RateLimiters() *RateLimiters
+
 	// The content store for the engine as a whole
 	OCIStore() content.Store
 
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"dagger.io/dagger/telemetry"
+	"golang.org/x/time/rate"
+)
+
+// RateLimiters throttle calls to functions that hit external services, like
+// registries or third-party APIs, across every session of the engine, so that
+// parallel branches of pipelines don't get them throttled or banned.
+//
+// Rate limit keys are scoped to the module declaring them, so that modules
+// can't throttle each other by picking the same key.
+//
+// Time spent waiting on them is recorded as a metric of the waiting call.
+type RateLimiters struct {
+	mu       sync.Mutex
+	limiters map[string]*keyedLimiter
+
+	// idleTimeout is how long a limiter goes unused before it's forgotten.
+	idleTimeout time.Duration
+}
+
+// keyedLimiter is the limiter of a rate limit key.
+type keyedLimiter struct {
+	*rate.Limiter
+	waiting  int
+	lastUsed time.Time
+}
+
+// rateLimiterIdleTimeout is how long a rate limit goes unused before it's
+// forgotten, along with the rates declared for it.
+const rateLimiterIdleTimeout = 10 * time.Minute
+
+func NewRateLimiters() *RateLimiters {
+	return &RateLimiters{
+		limiters:    map[string]*keyedLimiter{},
+		idleTimeout: rateLimiterIdleTimeout,
+	}
+}
+
+// RateLimitScope returns the scope of the rate limits declared by a module,
+// which is the same for every version of the module's source.
+func RateLimitScope(mod *Module) (string, error) {
+	src := mod.Source.Self
+	if src == nil {
+		return "", fmt.Errorf("module %q has no source", mod.Name())
+	}
+	symbolic, err := src.Symbolic()
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{string(src.Kind), symbolic, mod.Name()}, "\x00"), nil
+}
+
+// Wait waits until the rate limit with the given key in the given scope
+// allows another call, at the given rate of calls per second. If calls
+// declare different rates for the same key, the lowest one applies.
+func (rl *RateLimiters) Wait(ctx context.Context, scope, key string, rps float64) error {
+	if key == "" {
+		return errors.New("rate limit key must not be empty")
+	}
+	if rps <= 0 {
+		return fmt.Errorf("rate limit %q must allow a positive number of calls per second, got %v", key, rps)
+	}
+	now := time.Now()
+	rl.mu.Lock()
+	rl.evictLocked(now)
+	id := scope + "\x00" + key
+	limiter, ok := rl.limiters[id]
+	if !ok {
+		limiter = &keyedLimiter{Limiter: rate.NewLimiter(rate.Limit(rps), 1)}
+		rl.limiters[id] = limiter
+	} else if rate.Limit(rps) < limiter.Limit() {
+		limiter.SetLimitAt(now, rate.Limit(rps))
+	}
+	limiter.waiting++
+	rl.mu.Unlock()
+	defer func() {
+		rl.mu.Lock()
+		limiter.waiting--
+		limiter.lastUsed = time.Now()
+		rl.mu.Unlock()
+	}()
+
+	start := time.Now()
+	defer func() { recordWait(ctx, telemetry.RateLimitWaitMicroseconds, time.Since(start)) }()
+	return limiter.Wait(ctx)
+}
+
+// evictLocked forgets the limiters that nobody is waiting on and that have
+// been idle for long enough that a new one would allow calls just the same.
+func (rl *RateLimiters) evictLocked(now time.Time) {
+	for id, limiter := range rl.limiters {
+		if limiter.waiting > 0 {
+			continue
+		}
+		interval := time.Duration(float64(time.Second) / float64(limiter.Limit()))
+		if now.Sub(limiter.lastUsed) > max(rl.idleTimeout, interval) {
+			delete(rl.limiters, id)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestRateLimiters(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	limiters := NewRateLimiters()
+
+	// the first call goes right through, and the next waits its turn
+	start := time.Now()
+	require.NoError(t, limiters.Wait(ctx, "mod", "registry", 10))
+	require.NoError(t, limiters.Wait(ctx, "mod", "registry", 10))
+	require.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+
+	// other keys, and the same key of other modules, are limited separately
+	start = time.Now()
+	require.NoError(t, limiters.Wait(ctx, "mod", "other", 10))
+	require.NoError(t, limiters.Wait(ctx, "other-mod", "registry", 10))
+	require.Less(t, time.Since(start), 50*time.Millisecond)
+
+	// waits end with their context
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	require.Error(t, limiters.Wait(ctx, "mod", "registry", 0.1))
+
+	require.Error(t, limiters.Wait(ctx, "mod", "", 1))
+	require.Error(t, limiters.Wait(ctx, "mod", "registry", 0))
+}
+
+func TestRateLimitersLowestRate(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	limiters := NewRateLimiters()
+	limit := func() rate.Limit {
+		limiters.mu.Lock()
+		defer limiters.mu.Unlock()
+		return limiters.limiters["mod\x00registry"].Limit()
+	}
+
+	require.NoError(t, limiters.Wait(ctx, "mod", "registry", 1000))
+	require.Equal(t, rate.Limit(1000), limit())
+	require.NoError(t, limiters.Wait(ctx, "mod", "registry", 500))
+	require.Equal(t, rate.Limit(500), limit())
+	// whichever order the calls come in
+	require.NoError(t, limiters.Wait(ctx, "mod", "registry", 1000))
+	require.Equal(t, rate.Limit(500), limit())
+}
+
+func TestRateLimitersEviction(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	limiters := NewRateLimiters()
+	limiters.idleTimeout = time.Millisecond
+
+	require.NoError(t, limiters.Wait(ctx, "mod", "registry", 100))
+	require.NoError(t, limiters.Wait(ctx, "mod", "slow", 0.1))
+	require.Len(t, limiters.limiters, 2)
+
+	// limiters are forgotten once idle, but not before a new one would allow
+	// calls just the same
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, limiters.Wait(ctx, "mod", "other", 100))
+	require.Len(t, limiters.limiters, 2)
+	require.Contains(t, limiters.limiters, "mod\x00slow")
+	require.NotContains(t, limiters.limiters, "mod\x00registry")
+
+	// limiters being waited on are kept
+	limiters.limiters["mod\x00other"].waiting++
+	time.Sleep(20 * time.Millisecond)
+	limiters.mu.Lock()
+	limiters.evictLocked(time.Now())
+	limiters.mu.Unlock()
+	require.Contains(t, limiters.limiters, "mod\x00other")
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opentelemetry.io/otel/trace"
+
+	"dagger.io/dagger/telemetry"
+	"github.com/dagger/dagger/dagql"
+	"github.com/dagger/dagger/dagql/call"
+	"github.com/dagger/dagger/engine/buildkit"
+	"github.com/dagger/dagger/engine/circuit"
+	"github.com/dagger/dagger/engine/slog"
+)
+
+type ErrorCategory string
+
+var ErrorCategoryEnum = dagql.NewEnum[ErrorCategory]()
+
+var (
+	ErrorCategoryExec = ErrorCategoryEnum.Register("EXEC",
+		`A command exited with a non-zero exit code.`,
+	)
+	ErrorCategoryNetwork = ErrorCategoryEnum.Register("NETWORK",
+		`A network request failed, e.g. due to a timeout, a refused connection, or a server error.`,
+	)
+)
+
+func (cat ErrorCategory) Type() *ast.Type {
+	return &ast.Type{
+		NamedType: "ErrorCategory",
+		NonNull:   true,
+	}
+}
+
+func (cat ErrorCategory) TypeDescription() string {
+	return "A category of errors that may be retried."
+}
+
+func (cat ErrorCategory) Decoder() dagql.InputDecoder {
+	return ErrorCategoryEnum
+}
+
+func (cat ErrorCategory) ToLiteral() call.Literal {
+	return ErrorCategoryEnum.Literal(cat)
+}
+
+// CategorizeError returns the category of an error, and false if it doesn't
+// fall into any.
+func CategorizeError(err error) (ErrorCategory, bool) {
+	var execErr *buildkit.ExecError
+	if errors.As(err, &execErr) {
+		return ErrorCategoryExec, true
+	}
+	if circuit.IsUnavailable(err) {
+		return ErrorCategoryNetwork, true
+	}
+	return "", false
+}
+
+// RetryPolicy configures how an operation is retried.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts, including the first.
+	Attempts int
+
+	// Backoff is the delay before the first retry, doubled for each
+	// subsequent one.
+	Backoff time.Duration
+
+	// RetryOn limits retries to errors of the given categories. All errors
+	// are retried if empty.
+	RetryOn []ErrorCategory
+}
+
+// Retryable returns whether the policy retries the given error.
+func (policy RetryPolicy) Retryable(err error) bool {
+	if len(policy.RetryOn) == 0 {
+		return true
+	}
+	cat, ok := CategorizeError(err)
+	if !ok {
+		return false
+	}
+	for _, retryOn := range policy.RetryOn {
+		if retryOn == cat {
+			return true
+		}
+	}
+	return false
+}
+
+// Do runs fn until it succeeds, it fails with an error the policy doesn't
+// retry, or the attempts run out. Each attempt runs in its own span, linked
+// to the span of the attempt before it.
+func (policy RetryPolicy) Do(ctx context.Context, fn func(context.Context) error) error {
+	var prev trace.SpanContext
+	backoff := policy.Backoff
+	for attempt := 1; ; attempt++ {
+		opts := []trace.SpanStartOption{}
+		if prev.IsValid() {
+			opts = append(opts, trace.WithLinks(trace.Link{SpanContext: prev}))
+		}
+		attemptCtx, span := Tracer(ctx).Start(ctx, fmt.Sprintf("attempt %d/%d", attempt, policy.Attempts), opts...)
+		err := fn(attemptCtx)
+		telemetry.End(span, func() error { return err })
+		if err == nil {
+			return nil
+		}
+		if attempt >= policy.Attempts || !policy.Retryable(err) {
+			return err
+		}
+		prev = span.SpanContext()
+		slog.Warn("retrying after failed attempt", "attempt", attempt, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, context.Cause(ctx))
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
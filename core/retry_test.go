@@ -0,0 +1,44 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	ctx := context.Background()
+	otherErr := errors.New("boom")
+	netErr := errors.New(`invalid response status 503`)
+
+	var attempts int
+	failTwice := func(err error) func(context.Context) error {
+		attempts = 0
+		return func(context.Context) error {
+			attempts++
+			if attempts <= 2 {
+				return err
+			}
+			return nil
+		}
+	}
+
+	// all errors are retried by default
+	policy := RetryPolicy{Attempts: 3}
+	require.NoError(t, policy.Do(ctx, failTwice(otherErr)))
+	require.Equal(t, 3, attempts)
+
+	// until the attempts run out
+	policy = RetryPolicy{Attempts: 2}
+	require.ErrorIs(t, policy.Do(ctx, failTwice(netErr)), netErr)
+	require.Equal(t, 2, attempts)
+
+	// errors outside of the given categories fail right away
+	policy = RetryPolicy{Attempts: 3, RetryOn: []ErrorCategory{ErrorCategoryNetwork}}
+	require.NoError(t, policy.Do(ctx, failTwice(netErr)))
+	require.Equal(t, 3, attempts)
+	require.Error(t, policy.Do(ctx, failTwice(otherErr)))
+	require.Equal(t, 1, attempts)
+}
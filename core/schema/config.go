@@ -0,0 +1,185 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/dagql"
+)
+
+type configSchema struct {
+	srv *dagql.Server
+}
+
+var _ SchemaResolvers = &configSchema{}
+
+func (s *configSchema) Install() {
+	core.ConfigValueTypes.Install(s.srv)
+
+	dagql.Fields[*core.Query]{
+		dagql.Func("config", s.config).
+			Doc(`Create a new, empty configuration.`,
+				`Declare its keys with withKey, then load their values with withFile
+				and withEnv, replacing ad-hoc parsing of environment variables.`),
+	}.Install(s.srv)
+
+	dagql.Fields[*core.Config]{
+		dagql.Func("withKey", s.withKey).
+			Doc(`Declares a key of the configuration, replacing any previous declaration of it.`).
+			ArgDoc("name", `The name of the key.`).
+			ArgDoc("valueType", `The type of the key's value.`).
+			ArgDoc("required", `Whether the key must be set, unless it has a default.`).
+			ArgDoc("defaultValue", `The value of the key when it's not set.`).
+			ArgDoc("description", `A description of the key.`),
+
+		dagql.Func("withFile", s.withFile).
+			Doc(`Loads values from a file, taking precedence over the values loaded so far.`,
+				`Files ending in .json must be a JSON object of values by key; other
+				files are read as KEY=VALUE lines, e.g. a .env file. Keys that aren't
+				declared are reported by validation.`).
+			ArgDoc("source", `The file to load values from.`),
+
+		dagql.Func("withEnv", s.withEnv).
+			Doc(`Loads values from environment variables, taking precedence over the values loaded so far.`,
+				`Variables are named after the keys in SCREAMING_SNAKE_CASE, after the
+				prefix, e.g. "APP_LOG_LEVEL" for the key "logLevel" with the prefix
+				"APP_".`).
+			ArgDoc("variables", `The environment variables, in the KEY=VALUE format, e.g. of os.Environ.`).
+			ArgDoc("prefix", `The prefix of the variables' names.`),
+
+		dagql.Func("withValue", s.withValue).
+			Doc(`Sets the value of a key, taking precedence over the values loaded so far.`).
+			ArgDoc("name", `The name of the key.`).
+			ArgDoc("value", `The value of the key.`),
+
+		dagql.Func("validate", s.validate).
+			Doc(`Validates the values of all the declared keys, reporting all the offending keys at once.`,
+				`Configurations passed to a function are validated before the function
+				is invoked; call it to fail fast elsewhere, e.g. in the client.`),
+
+		dagql.Func("string", s.string).
+			Doc(`Returns the value of a key of type STRING, or null if it's not set.`,
+				`Fails if any value of the configuration is invalid.`).
+			ArgDoc("name", `The name of the key.`),
+
+		dagql.Func("integer", s.integer).
+			Doc(`Returns the value of a key of type INTEGER, or null if it's not set.`,
+				`Fails if any value of the configuration is invalid.`).
+			ArgDoc("name", `The name of the key.`),
+
+		dagql.Func("boolean", s.boolean).
+			Doc(`Returns the value of a key of type BOOLEAN, or null if it's not set.`,
+				`Fails if any value of the configuration is invalid.`).
+			ArgDoc("name", `The name of the key.`),
+	}.Install(s.srv)
+
+	dagql.Fields[*core.ConfigKey]{}.Install(s.srv)
+}
+
+func (s *configSchema) config(ctx context.Context, parent *core.Query, args struct{}) (*core.Config, error) {
+	return &core.Config{}, nil
+}
+
+func (s *configSchema) withKey(ctx context.Context, parent *core.Config, args struct {
+	Name         string
+	ValueType    core.ConfigValueType
+	Required     bool   `default:"false"`
+	DefaultValue string `default:""`
+	Description  string `default:""`
+}) (*core.Config, error) {
+	return parent.WithKey(&core.ConfigKey{
+		Name:         args.Name,
+		ValueType:    args.ValueType,
+		Required:     args.Required,
+		DefaultValue: args.DefaultValue,
+		Description:  args.Description,
+	})
+}
+
+func (s *configSchema) withFile(ctx context.Context, parent *core.Config, args struct {
+	Source core.FileID
+}) (*core.Config, error) {
+	file, err := args.Source.Load(ctx, s.srv)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := file.Self.Contents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	source, err := core.ParseConfigFile(path.Base(file.Self.File), contents)
+	if err != nil {
+		return nil, err
+	}
+	return parent.WithSource(source), nil
+}
+
+func (s *configSchema) withEnv(ctx context.Context, parent *core.Config, args struct {
+	Variables []string
+	Prefix    string `default:""`
+}) (*core.Config, error) {
+	return parent.WithSource(core.NewConfigEnvSource(args.Variables, args.Prefix)), nil
+}
+
+func (s *configSchema) withValue(ctx context.Context, parent *core.Config, args struct {
+	Name  string
+	Value string
+}) (*core.Config, error) {
+	return parent.WithSource(&core.ConfigSource{
+		Name:   "withValue",
+		Values: map[string]string{args.Name: args.Value},
+	}), nil
+}
+
+func (s *configSchema) validate(ctx context.Context, parent *core.Config, args struct{}) (dagql.Nullable[core.Void], error) {
+	_, err := parent.Resolve()
+	return dagql.Null[core.Void](), err
+}
+
+type configValueArgs struct {
+	Name string
+}
+
+func (s *configSchema) string(ctx context.Context, parent *core.Config, args configValueArgs) (dagql.Nullable[dagql.String], error) {
+	val, err := configValue[string](parent, args.Name, core.ConfigValueTypeString)
+	if err != nil || val == nil {
+		return dagql.Null[dagql.String](), err
+	}
+	return dagql.NonNull(dagql.NewString(*val)), nil
+}
+
+func (s *configSchema) integer(ctx context.Context, parent *core.Config, args configValueArgs) (dagql.Nullable[dagql.Int], error) {
+	val, err := configValue[int](parent, args.Name, core.ConfigValueTypeInteger)
+	if err != nil || val == nil {
+		return dagql.Null[dagql.Int](), err
+	}
+	return dagql.NonNull(dagql.NewInt(*val)), nil
+}
+
+func (s *configSchema) boolean(ctx context.Context, parent *core.Config, args configValueArgs) (dagql.Nullable[dagql.Boolean], error) {
+	val, err := configValue[bool](parent, args.Name, core.ConfigValueTypeBoolean)
+	if err != nil || val == nil {
+		return dagql.Null[dagql.Boolean](), err
+	}
+	return dagql.NonNull(dagql.NewBoolean(*val)), nil
+}
+
+// configValue returns the value of a key, checking that it's declared with the
+// given type.
+func configValue[T any](cfg *core.Config, name string, typ core.ConfigValueType) (*T, error) {
+	key, ok := cfg.Key(name)
+	if !ok {
+		return nil, fmt.Errorf("config key %q is not declared", name)
+	}
+	if key.ValueType != typ {
+		return nil, fmt.Errorf("config key %q is of type %s, not %s", name, key.ValueType, typ)
+	}
+	val, err := cfg.Value(name)
+	if err != nil || val == nil {
+		return nil, err
+	}
+	typed := val.(T)
+	return &typed, nil
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"dagger.io/dagger/telemetry"
 	"github.com/containerd/platforms"
 	"github.com/distribution/reference"
 	"github.com/moby/buildkit/client/llb"
@@ -19,11 +20,15 @@ import (
 	"github.com/moby/buildkit/exporter/containerimage/exptypes"
 	"github.com/moby/buildkit/frontend/dockerfile/shell"
 	"github.com/moby/buildkit/identity"
+	"github.com/opencontainers/go-digest"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/vektah/gqlparser/v2/ast"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/dagger/dagger/core"
 	"github.com/dagger/dagger/dagql"
+	"github.com/dagger/dagger/engine"
 	"github.com/dagger/dagger/engine/buildkit"
 	"github.com/dagger/dagger/engine/slog"
 )
@@ -49,6 +54,11 @@ func (s *containerSchema) Install() {
 			Doc(`Forces evaluation of the pipeline in the engine.`,
 				`It doesn't run the default command if no exec has been set.`),
 
+		Retrier[*core.Container]().
+			Doc(`Evaluates the pipeline in the engine, retrying failures according to the given policy.`,
+				`Use it after the exec to retry, e.g. a flaky test, instead of
+				retrying in a loop. Each attempt is reported in its own span.`),
+
 		dagql.Func("pipeline", s.pipeline).
 			View(BeforeVersion("v0.13.0")).
 			Deprecated("Explicit pipeline creation is now a no-op").
@@ -152,6 +162,14 @@ func (s *containerSchema) Install() {
 			ArgDoc("name", `The name of the secret variable (e.g., "API_SECRET").`).
 			ArgDoc("secret", `The identifier of the secret value.`),
 
+		dagql.Func("withEnvFile", s.withEnvFile).
+			Doc(`Retrieves this container plus the variables of an env file.`,
+				`Variables whose names match any of the secret patterns are set as
+				secret variables, hidden from telemetry, and the rest as plain
+				environment variables. Only the names of the variables are recorded.`).
+			ArgDoc("source", `The env file, of KEY=VALUE lines (e.g., a .env file).`).
+			ArgDoc("secretPatterns", `Glob patterns of the names of the variables to set as secrets (e.g., "*_TOKEN").`),
+
 		dagql.Func("withoutEnvVariable", s.withoutEnvVariable).
 			Doc(`Retrieves this container minus the given environment variable.`).
 			ArgDoc("name", `The name of the environment variable (e.g., "HOST").`),
@@ -412,7 +430,16 @@ func (s *containerSchema) Install() {
 				`If set, skip the automatic init process injected into containers by default.`,
 				`This should only be used if the user requires that their exec process be the
 				pid 1 process in the container. Otherwise it may result in unexpected behavior.`,
-			),
+			).
+			ArgDoc("hermetic",
+				`Execute the command without network access, in UTC with the C locale,
+				and with its clock fixed to the Unix epoch (or the engine's deterministic
+				epoch) where possible.`,
+				`Fixing the clock is best-effort: it preloads libfaketime, so it only
+				applies if libfaketime is installed in the container (e.g., "apk add
+				libfaketime"), and not to statically linked programs. Otherwise the
+				command runs with the real clock, and its span records that the clock
+				wasn't fixed.`),
 
 		dagql.Func("withExec", s.withExec).
 			View(BeforeVersion("v0.13.0")).
@@ -604,6 +631,12 @@ func (s *containerSchema) Install() {
 		dagql.Func("imageRef", s.imageRef).
 			Doc(`The unique image reference which can only be retrieved immediately after the 'Container.From' call.`),
 
+		dagql.Func("layers", s.layers).
+			Doc(`Retrieves the layers of the container's root filesystem, bottom first.`,
+				`Each layer reports its size and the command that created it, along
+				with how much of it is wasted on files that later layers replace or
+				remove.`),
+
 		dagql.Func("withExposedPort", s.withExposedPort).
 			Doc(`Expose a network port.`,
 				`Exposed ports serve two purposes:`,
@@ -738,12 +771,21 @@ func (s *containerSchema) from(ctx context.Context, parent dagql.Instance[*core.
 	}
 	platform := parent.Self.Platform
 
-	refName, err := reference.ParseNormalizedNamed(args.Address)
+	// add a default :latest if no tag or digest
+	refName, err := engine.NormalizeImageRef(args.Address)
 	if err != nil {
 		return inst, fmt.Errorf("failed to parse image address %s: %w", args.Address, err)
 	}
-	// add a default :latest if no tag or digest, otherwise this is a no-op
-	refName = reference.TagNameOnly(refName)
+
+	// in offline mode, images come from the pre-seeded bundle, never from a
+	// registry
+	offlineRef, err := core.OfflineImageRef(ctx, parent.Self.Query, refName)
+	if err != nil {
+		return inst, err
+	}
+	if offlineRef != nil {
+		refName = offlineRef
+	}
 
 	if refName, isCanonical := refName.(reference.Canonical); isCanonical {
 		ctr, err := parent.Self.FromCanonicalRef(ctx, refName, nil)
@@ -1128,6 +1170,69 @@ func (s *containerSchema) withEnvVariable(ctx context.Context, parent *core.Cont
 	})
 }
 
+type containerWithEnvFileArgs struct {
+	Source         core.FileID
+	SecretPatterns []string `default:"[\"*_TOKEN\", \"*_SECRET\", \"*_PASSWORD\", \"*_KEY\"]"`
+}
+
+func (s *containerSchema) withEnvFile(ctx context.Context, parent *core.Container, args containerWithEnvFileArgs) (_ *core.Container, rerr error) {
+	file, err := args.Source.Load(ctx, s.srv)
+	if err != nil {
+		return nil, err
+	}
+	name := path.Base(file.Self.File)
+	contents, err := file.Self.Contents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	values, err := core.ParseEnvFile(name, contents)
+	if err != nil {
+		return nil, err
+	}
+	plain, secrets, err := core.ClassifyEnvFile(values, args.SecretPatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	// the secrets are set beneath this span, so only their names are shown
+	ctx, span := core.Tracer(ctx).Start(ctx, "load env file "+name,
+		telemetry.Internal(),
+		telemetry.Encapsulate(),
+		trace.WithAttributes(
+			attribute.StringSlice(telemetry.EnvFileVariablesAttr, plain),
+			attribute.StringSlice(telemetry.EnvFileSecretsAttr, secrets),
+		))
+	defer telemetry.End(span, func() error { return rerr })
+
+	ctr, err := parent.UpdateImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
+		for _, key := range plain {
+			cfg.Env = core.AddEnv(cfg.Env, key, values[key])
+		}
+		return cfg
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range secrets {
+		var secret dagql.Instance[*core.Secret]
+		if err := s.srv.Select(ctx, s.srv.Root(), &secret, dagql.Selector{
+			Field: "setSecret",
+			Args: []dagql.NamedInput{
+				// the file's digest keeps secrets of different files apart
+				{Name: "name", Value: dagql.NewString(key + "@" + digest.FromBytes(contents).String())},
+				{Name: "plaintext", Value: dagql.NewString(values[key])},
+			},
+		}); err != nil {
+			return nil, fmt.Errorf("failed to set secret %s: %w", key, err)
+		}
+		ctr, err = ctr.WithSecretVariable(ctx, key, secret.Self)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ctr, nil
+}
+
 type containerWithSystemEnvArgs struct {
 	Name string
 }
@@ -1988,6 +2093,10 @@ func (s *containerSchema) imageRef(ctx context.Context, parent *core.Container,
 	return parent.ImageRefOrErr(ctx)
 }
 
+func (s *containerSchema) layers(ctx context.Context, parent *core.Container, args struct{}) ([]core.ContainerLayer, error) {
+	return parent.Layers(ctx)
+}
+
 type containerWithServiceBindingArgs struct {
 	Alias   string
 	Service core.ServiceID
@@ -52,6 +52,7 @@ func (m *CoreMod) Install(ctx context.Context, dag *dagql.Server) error {
 		&socketSchema{dag},
 		&moduleSchema{dag},
 		&errorSchema{dag},
+		&configSchema{dag},
 		&engineSchema{dag},
 	} {
 		schema.Install()
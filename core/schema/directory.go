@@ -37,6 +37,9 @@ func (s *directorySchema) Install() {
 		dagql.Func("glob", s.glob).
 			Doc(`Returns a list of files and directories that matche the given pattern.`).
 			ArgDoc("pattern", `Pattern to match (e.g., "*.md").`),
+		dagql.Func("licenses", s.licenses).
+			Doc(`Detects the licenses of the directory and everything in it, including
+				vendored dependencies, from license files like LICENSE or COPYING.`),
 		dagql.Func("digest", s.digest).
 			Doc(
 				`Return the directory's digest.
@@ -202,6 +205,10 @@ func (s *directorySchema) glob(ctx context.Context, parent *core.Directory, args
 	return parent.Glob(ctx, args.Pattern)
 }
 
+func (s *directorySchema) licenses(ctx context.Context, parent *core.Directory, args struct{}) ([]core.License, error) {
+	return parent.Licenses(ctx)
+}
+
 func (s *directorySchema) digest(ctx context.Context, parent *core.Directory, args struct{}) (dagql.String, error) {
 	digest, err := parent.Digest(ctx)
 	if err != nil {
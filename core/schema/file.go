@@ -19,6 +19,9 @@ func (s *fileSchema) Install() {
 	dagql.Fields[*core.File]{
 		Syncer[*core.File]().
 			Doc(`Force evaluation in the engine.`),
+		Retrier[*core.File]().
+			Doc(`Force evaluation in the engine, retrying failures according to the given policy.`,
+				`Use it to retry, e.g. a flaky HTTP fetch. Each attempt is reported in its own span.`),
 		dagql.Func("contents", s.contents).
 			Doc(`Retrieves the contents of the file.`),
 		dagql.Func("size", s.size).
@@ -428,9 +428,18 @@ func (s *gitSchema) tags(ctx context.Context, parent *core.GitRepository, args t
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	err = cmd.Run()
+	bk, err := parent.Query.Buildkit(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("git command failed: %w\nstdout: %s\nstderr: %s", err, stdout.String(), stderr.String())
+		return nil, fmt.Errorf("failed to get buildkit client: %w", err)
+	}
+	err = bk.Breakers.Do(ctx, remote.Host, func() error {
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("git command failed: %w\nstdout: %s\nstderr: %s", err, stdout.String(), stderr.String())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	tags := []string{}
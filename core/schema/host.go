@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/containerd/containerd/content"
@@ -186,6 +188,17 @@ func (s *hostSchema) Install() {
 				`An empty set of ports is not valid; an error will be returned.`).
 			ArgDoc("host", `Upstream host to forward traffic to.`),
 
+		dagql.FuncWithCacheKey("reverseTunnel", s.reverseTunnel, core.CachePerClient).
+			Doc(`Creates a service that lets containers call back to a port on the
+			host, e.g. a local development server, through a tunnel managed by
+			the engine.`,
+				`Unless called by the main client, the port must be granted by the
+				client, e.g. with "dagger --allow-host-port". The connections made
+				through the tunnel are counted in the metrics of its span.`).
+			ArgDoc("port", `Port on the host to forward traffic to.`).
+			ArgDoc("host", `Host to forward traffic to, as resolved by the client.`).
+			ArgDoc("protocol", `Transport layer protocol to use for traffic.`),
+
 		// hidden from external clients via the __ prefix
 		dagql.Func("__internalService", s.internalService).
 			Doc(`(Internal-only) "service" but scoped to the exact right buildkit session ID.`),
@@ -470,6 +483,49 @@ func (s *hostSchema) service(ctx context.Context, parent *core.Host, args hostSe
 	return inst, err
 }
 
+type hostReverseTunnelArgs struct {
+	Port     int
+	Host     string               `default:"localhost"`
+	Protocol core.NetworkProtocol `default:"TCP"`
+}
+
+func (s *hostSchema) reverseTunnel(ctx context.Context, parent *core.Host, args hostReverseTunnelArgs) (inst dagql.Instance[*core.Service], err error) {
+	if err := checkHostPortGrant(ctx, parent.Query, args.Host, args.Port); err != nil {
+		return inst, err
+	}
+	return s.service(ctx, parent, hostServiceArgs{
+		Host: args.Host,
+		Ports: []dagql.InputObject[core.PortForward]{{
+			Value: core.PortForward{
+				Backend:  args.Port,
+				Protocol: args.Protocol,
+			},
+		}},
+	})
+}
+
+// checkHostPortGrant returns an error if containers haven't been granted
+// access to a port on the client's host. The main client is exempt, since it
+// already has access to its own host; modules and nested clients need the
+// grants of their nearest non-module parent, e.g. the CLI.
+func checkHostPortGrant(ctx context.Context, query *core.Query, host string, port int) error {
+	if query.RequireMainClient(ctx) == nil {
+		return nil
+	}
+	clientMetadata, err := query.NonModuleParentClientMetadata(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get client metadata: %w", err)
+	}
+	if clientMetadata.HostPortAllowed(host, port) {
+		return nil
+	}
+	grant := strconv.Itoa(port)
+	if host != "localhost" {
+		grant = net.JoinHostPort(host, grant)
+	}
+	return fmt.Errorf("port %d on %s has not been granted to containers; allow it with --allow-host-port %s", port, host, grant)
+}
+
 type hostInternalServiceArgs struct {
 	Socks dagql.ArrayInput[dagql.ID[*core.Socket]]
 }
@@ -277,6 +277,23 @@ func (s *moduleSchema) Install() {
 			Doc(`Returns the function with the given source map.`).
 			ArgDoc("sourceMap", `The source map for the function definition.`),
 
+		dagql.Func("withDaemon", s.functionWithDaemon).
+			Doc(`Returns the function marked as a daemon.`,
+				`A daemon function returns a Service, which is started when the
+				function is called and keeps running after the call returns, until
+				it's stopped or the session ends.`),
+
+		dagql.Func("withRateLimit", s.functionWithRateLimit).
+			Doc(`Returns the function with calls to it rate limited.`,
+				`Calls to functions of the same module sharing a rate limit key wait
+				for each other, across every session of the engine, to hit external
+				services like registries or third-party APIs no faster than they
+				allow. If functions declare different rates for the same key, the
+				lowest one applies. Time spent waiting is recorded as a metric of
+				the call.`).
+			ArgDoc("key", `The key of the rate limit (e.g., "docker-hub").`).
+			ArgDoc("rps", `The number of calls allowed per second, e.g. 0.5 for one every two seconds.`),
+
 		dagql.Func("withArg", s.functionWithArg).
 			Doc(`Returns the function with the provided argument`).
 			ArgDoc("name", `The name of the argument`).
@@ -553,6 +570,23 @@ func (s *moduleSchema) functionWithDescription(ctx context.Context, fn *core.Fun
 	return fn.WithDescription(args.Description), nil
 }
 
+func (s *moduleSchema) functionWithDaemon(ctx context.Context, fn *core.Function, args struct{}) (*core.Function, error) {
+	return fn.WithDaemon(), nil
+}
+
+func (s *moduleSchema) functionWithRateLimit(ctx context.Context, fn *core.Function, args struct {
+	Key string
+	Rps float64
+}) (*core.Function, error) {
+	if args.Key == "" {
+		return nil, fmt.Errorf("rate limit key must not be empty")
+	}
+	if args.Rps <= 0 {
+		return nil, fmt.Errorf("rate limit must allow a positive number of calls per second, got %v", args.Rps)
+	}
+	return fn.WithRateLimit(args.Key, args.Rps), nil
+}
+
 func (s *moduleSchema) functionWithArg(ctx context.Context, fn *core.Function, args struct {
 	Name         string
 	TypeDef      core.TypeDefID
@@ -71,6 +71,15 @@ func (s *moduleSchema) moduleSource(ctx context.Context, query *core.Query, args
 		})
 
 	case core.ModuleSourceKindGit:
+		bundled, err := core.OfflineModuleSource(ctx, query, args.RefString)
+		if err != nil {
+			return nil, err
+		}
+		if bundled != "" {
+			// load the bundled copy from the host as a local module source
+			return s.moduleSource(ctx, query, moduleSourceArgs{RefString: bundled})
+		}
+
 		src.AsGitSource = dagql.NonNull(&core.GitModuleSource{})
 
 		src.AsGitSource.Value.Root = parsed.repoRoot.Root
@@ -157,7 +166,7 @@ func (s *moduleSchema) moduleSource(ctx context.Context, query *core.Query, args
 		}
 
 		var gitRef dagql.Instance[*core.GitRef]
-		err := s.dag.Select(ctx, s.dag.Root(), &gitRef,
+		err = s.dag.Select(ctx, s.dag.Root(), &gitRef,
 			dagql.Selector{
 				Field: "git",
 				Args: []dagql.NamedInput{
@@ -669,6 +678,9 @@ func (s *moduleSchema) moduleSourceDependencies(
 		}
 
 		existingDeps = make([]dagql.Instance[*core.ModuleDependency], len(updatedDeps))
+		// load every dependency before failing, so that offline mode reports
+		// all of the ones missing from the bundle at once
+		depErrs := make([]error, len(updatedDeps))
 		var eg errgroup.Group
 		for i, depCfg := range updatedDeps {
 			eg.Go(func() error {
@@ -683,14 +695,16 @@ func (s *moduleSchema) moduleSourceDependencies(
 					},
 				)
 				if err != nil {
-					return fmt.Errorf("failed to create module source from dependency: %w", err)
+					depErrs[i] = fmt.Errorf("failed to create module source from dependency: %w", err)
+					return nil
 				}
 
-				existingDeps[i], err = resolveDep(ctx, depCfg.Name, depSrc)
-				return err
+				existingDeps[i], depErrs[i] = resolveDep(ctx, depCfg.Name, depSrc)
+				return nil
 			})
 		}
-		if err := eg.Wait(); err != nil {
+		eg.Wait()
+		if err := core.JoinOfflineErrors(depErrs...); err != nil {
 			return nil, fmt.Errorf("failed to load pre-configured dependencies: %w", err)
 		}
 	}
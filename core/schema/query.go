@@ -3,12 +3,14 @@ package schema
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 
 	codegenintrospection "github.com/dagger/dagger/cmd/codegen/introspection"
 	"github.com/dagger/dagger/core"
 	"github.com/dagger/dagger/dagql"
+	"github.com/dagger/dagger/dagql/call"
 	"github.com/dagger/dagger/dagql/introspection"
 	"github.com/dagger/dagger/engine"
 	"github.com/dagger/dagger/engine/sources/blob"
@@ -55,6 +57,7 @@ func (s *querySchema) Install() {
 	core.TypeDefKinds.Install(s.srv)
 	core.ModuleSourceKindEnum.Install(s.srv)
 	core.ReturnTypesEnum.Install(s.srv)
+	core.ErrorCategoryEnum.Install(s.srv)
 
 	dagql.MustInputSpec(PipelineLabel{}).Install(s.srv)
 	dagql.MustInputSpec(core.PortForward{}).Install(s.srv)
@@ -66,6 +69,12 @@ func (s *querySchema) Install() {
 
 	dagql.Fields[Label]{}.Install(s.srv)
 
+	dagql.Fields[core.License]{}.Install(s.srv)
+
+	dagql.Fields[core.ContainerLayer]{}.Install(s.srv)
+
+	dagql.Fields[core.SessionEvent]{}.Install(s.srv)
+
 	dagql.Fields[*core.Query]{
 		dagql.Func("pipeline", s.pipeline).
 			View(BeforeVersion("v0.13.0")).
@@ -77,6 +86,95 @@ func (s *querySchema) Install() {
 
 		dagql.Func("version", s.version).
 			Doc(`Get the current Dagger Engine version.`),
+
+		dagql.Func("decodeID", s.decodeID).
+			Doc(`Decode an object ID into the chain of calls that produced it, as JSON.`).
+			ArgDoc("id", `The ID to decode.`),
+
+		dagql.Func("canonicalID", s.canonicalID).
+			Doc(`Canonicalize an object ID, stripping fields that do not affect its result.`,
+				`IDs which would produce the same result have the same canonical ID,
+				regardless of how they were constructed.`).
+			ArgDoc("id", `The ID to canonicalize.`),
+
+		dagql.Func("rebaseID", s.rebaseID).
+			Doc(`Re-base an object ID onto a different version of a module.`,
+				`Every call in the ID implemented by a module with the same name as the
+				given module is switched over to it, allowing stored IDs to be migrated
+				across module upgrades without re-running the calls that produced them.`).
+			ArgDoc("id", `The ID to re-base.`).
+			ArgDoc("module", `The module version to re-base onto.`),
+
+		dagql.Func("publishEvent", s.publishEvent).
+			Impure("Publishes to the session's event bus.").
+			Doc(`Publish an event to a topic of the session's event bus.`,
+				`Functions of any module in the session can wait for the event with
+				waitForEvent, to coordinate without signaling through files.`).
+			ArgDoc("topic", `The topic to publish to (e.g., "db-migrated").`).
+			ArgDoc("payload", `A payload for the event, if any.`),
+
+		dagql.Func("waitForEvent", s.waitForEvent).
+			Impure("Waits for events published at runtime.").
+			Doc(`Wait for an event to be published to a topic of the session's event bus.`,
+				`Returns the first event published to the topic after the given
+				sequence number, even if it was published before the wait began.`).
+			ArgDoc("topic", `The topic to wait for an event from.`).
+			ArgDoc("after", `Only return an event published after this sequence number, e.g. that of the last event received. By default, the first event published to the topic is returned.`),
+
+		dagql.Func("acquireLock", s.acquireLock).
+			Impure("Acquires a lock shared at runtime.").
+			Doc(`Acquire a named lock shared by the session, waiting for it to be released if it's held.`,
+				`Returns a lease to release the lock with, using releaseLock. The lock
+				is released anyway once the calling function exits. Time spent
+				waiting is recorded as a metric of the call.`).
+			ArgDoc("key", `The name of the lock (e.g., "npm-cache").`),
+
+		dagql.Func("releaseLock", s.releaseLock).
+			Impure("Releases a lock shared at runtime.").
+			Doc(`Release a named lock acquired with acquireLock.`).
+			ArgDoc("key", `The name of the lock.`).
+			ArgDoc("lease", `The lease returned by acquireLock.`),
+
+		dagql.Func("claimOnce", s.claimOnce).
+			Impure("Claims a guard shared at runtime.").
+			Doc(`Claim a named once-per-session guard, for setup work that must only be done once.`,
+				`Returns true to the first caller, which should do the work, then
+				call finishOnce. Other callers wait for the work to be finished and
+				get false. If the calling function exits without finishing the work,
+				a waiting caller claims the guard instead. Time spent waiting is
+				recorded as a metric of the call.`).
+			ArgDoc("key", `The name of the guard (e.g., "seed-database").`),
+
+		dagql.Func("finishOnce", s.finishOnce).
+			Impure("Finishes a guard shared at runtime.").
+			Doc(`Finish the work guarded by a once-per-session guard claimed with claimOnce.`).
+			ArgDoc("key", `The name of the guard.`).
+			ArgDoc("failed", `Give up the guard instead, because the work failed, so that a waiting caller claims it.`),
+
+		dagql.Func("kvGet", s.kvGet).
+			Impure("Reads values stored at runtime.").
+			Doc(`Get the value of a key in the session's key/value store, or null if it's not set.`,
+				`Keys are scoped to the calling module's source, and to the project
+				and client it's called from.`).
+			ArgDoc("key", `The key to get (e.g., "build-count").`).
+			ArgDoc("persistent", `Get the value persisted by the engine across sessions, rather than the session's.`),
+
+		dagql.Func("kvSet", s.kvSet).
+			Impure("Stores values at runtime.").
+			Doc(`Set the value of a key in the session's key/value store.`,
+				`Keys are scoped to the calling module's source, and to the project
+				and client it's called from. Values last for the session,
+				unless persisted by the engine across sessions, e.g. to compare
+				the digests of artifacts with those of the previous run.`).
+			ArgDoc("key", `The key to set.`).
+			ArgDoc("value", `The value to set.`).
+			ArgDoc("persistent", `Persist the value across sessions.`),
+
+		dagql.Func("kvDelete", s.kvDelete).
+			Impure("Deletes values stored at runtime.").
+			Doc(`Delete a key from the session's key/value store, if it's set.`).
+			ArgDoc("key", `The key to delete.`).
+			ArgDoc("persistent", `Delete the value persisted by the engine across sessions, rather than the session's.`),
 	}.Install(s.srv)
 }
 
@@ -94,6 +192,187 @@ func (s *querySchema) version(_ context.Context, _ *core.Query, args struct{}) (
 	return engine.Version, nil
 }
 
+func (s *querySchema) decodeID(_ context.Context, _ *core.Query, args struct {
+	ID string
+}) (core.JSON, error) {
+	var id call.ID
+	if err := id.Decode(args.ID); err != nil {
+		return nil, fmt.Errorf("invalid ID: %w", err)
+	}
+	return json.Marshal(id.Decoded())
+}
+
+func (s *querySchema) canonicalID(_ context.Context, _ *core.Query, args struct {
+	ID string
+}) (string, error) {
+	var id call.ID
+	if err := id.Decode(args.ID); err != nil {
+		return "", fmt.Errorf("invalid ID: %w", err)
+	}
+	return id.Canonical().Encode()
+}
+
+func (s *querySchema) rebaseID(ctx context.Context, _ *core.Query, args struct {
+	ID     string
+	Module core.ModuleID
+}) (string, error) {
+	var id call.ID
+	if err := id.Decode(args.ID); err != nil {
+		return "", fmt.Errorf("invalid ID: %w", err)
+	}
+	mod, err := args.Module.Load(ctx, s.srv)
+	if err != nil {
+		return "", fmt.Errorf("failed to load module: %w", err)
+	}
+	if mod.Self.InstanceID == nil {
+		return "", fmt.Errorf("module %q must be initialized before re-basing onto it", mod.Self.Name())
+	}
+	return id.Rebase(mod.Self.IDModule()).Encode()
+}
+
+func (s *querySchema) publishEvent(ctx context.Context, parent *core.Query, args struct {
+	Topic   string
+	Payload string `default:""`
+}) (core.SessionEvent, error) {
+	bus, err := parent.Events(ctx)
+	if err != nil {
+		return core.SessionEvent{}, fmt.Errorf("failed to get event bus: %w", err)
+	}
+	return bus.Publish(ctx, args.Topic, args.Payload)
+}
+
+func (s *querySchema) waitForEvent(ctx context.Context, parent *core.Query, args struct {
+	Topic string
+	After int `default:"0"`
+}) (core.SessionEvent, error) {
+	bus, err := parent.Events(ctx)
+	if err != nil {
+		return core.SessionEvent{}, fmt.Errorf("failed to get event bus: %w", err)
+	}
+	return bus.Wait(ctx, args.Topic, args.After)
+}
+
+func (s *querySchema) acquireLock(ctx context.Context, parent *core.Query, args struct {
+	Key string
+}) (string, error) {
+	locks, err := parent.Locks(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get locks: %w", err)
+	}
+	clientMetadata, err := engine.ClientMetadataFromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	return locks.Lock(ctx, args.Key, clientMetadata.ClientID)
+}
+
+func (s *querySchema) releaseLock(ctx context.Context, parent *core.Query, args struct {
+	Key   string
+	Lease string
+}) (dagql.Nullable[core.Void], error) {
+	void := dagql.Null[core.Void]()
+	locks, err := parent.Locks(ctx)
+	if err != nil {
+		return void, fmt.Errorf("failed to get locks: %w", err)
+	}
+	return void, locks.Unlock(args.Key, args.Lease)
+}
+
+func (s *querySchema) claimOnce(ctx context.Context, parent *core.Query, args struct {
+	Key string
+}) (dagql.Boolean, error) {
+	locks, err := parent.Locks(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get locks: %w", err)
+	}
+	clientMetadata, err := engine.ClientMetadataFromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	claimed, err := locks.Claim(ctx, args.Key, clientMetadata.ClientID)
+	return dagql.Boolean(claimed), err
+}
+
+func (s *querySchema) finishOnce(ctx context.Context, parent *core.Query, args struct {
+	Key    string
+	Failed bool `default:"false"`
+}) (dagql.Nullable[core.Void], error) {
+	void := dagql.Null[core.Void]()
+	locks, err := parent.Locks(ctx)
+	if err != nil {
+		return void, fmt.Errorf("failed to get locks: %w", err)
+	}
+	return void, locks.Finish(args.Key, args.Failed)
+}
+
+type kvArgs struct {
+	Key        string
+	Persistent bool `default:"false"`
+}
+
+// kvNamespace returns the namespace of the calling module's keys, or of the
+// main client's if it's not called from a module.
+func kvNamespace(ctx context.Context, parent *core.Query) (string, error) {
+	client, err := parent.NonModuleParentClientMetadata(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get client metadata: %w", err)
+	}
+	mod, err := parent.CurrentModule(ctx)
+	if errors.Is(err, core.ErrNoCurrentModule) {
+		return core.KVNamespace(client, nil)
+	}
+	if err != nil {
+		return "", err
+	}
+	return core.KVNamespace(client, mod)
+}
+
+func (s *querySchema) kvGet(ctx context.Context, parent *core.Query, args kvArgs) (dagql.Nullable[dagql.String], error) {
+	none := dagql.Null[dagql.String]()
+	kv, err := parent.KV(ctx)
+	if err != nil {
+		return none, fmt.Errorf("failed to get kv store: %w", err)
+	}
+	namespace, err := kvNamespace(ctx, parent)
+	if err != nil {
+		return none, err
+	}
+	value, found, err := kv.Get(ctx, namespace, args.Key, args.Persistent)
+	if err != nil || !found {
+		return none, err
+	}
+	return dagql.NonNull(dagql.NewString(value)), nil
+}
+
+func (s *querySchema) kvSet(ctx context.Context, parent *core.Query, args struct {
+	kvArgs
+	Value string
+}) (dagql.Nullable[core.Void], error) {
+	void := dagql.Null[core.Void]()
+	kv, err := parent.KV(ctx)
+	if err != nil {
+		return void, fmt.Errorf("failed to get kv store: %w", err)
+	}
+	namespace, err := kvNamespace(ctx, parent)
+	if err != nil {
+		return void, err
+	}
+	return void, kv.Set(ctx, namespace, args.Key, args.Value, args.Persistent)
+}
+
+func (s *querySchema) kvDelete(ctx context.Context, parent *core.Query, args kvArgs) (dagql.Nullable[core.Void], error) {
+	void := dagql.Null[core.Void]()
+	kv, err := parent.KV(ctx)
+	if err != nil {
+		return void, fmt.Errorf("failed to get kv store: %w", err)
+	}
+	namespace, err := kvNamespace(ctx, parent)
+	if err != nil {
+		return void, err
+	}
+	return void, kv.Delete(ctx, namespace, args.Key, args.Persistent)
+}
+
 func (s *querySchema) schemaJSONFile(ctx context.Context, parent dagql.Instance[*core.Query], args struct{}) (inst dagql.Instance[*core.File], rerr error) {
 	data, err := s.srv.Query(ctx, codegenintrospection.Query, nil)
 	if err != nil {
@@ -4,10 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/iancoleman/strcase"
 	"golang.org/x/mod/semver"
 
+	"github.com/dagger/dagger/core"
 	"github.com/dagger/dagger/dagql"
 	"github.com/dagger/dagger/dagql/introspection"
 	"github.com/dagger/dagger/engine/buildkit"
@@ -33,6 +35,41 @@ func Syncer[T Evaluatable]() dagql.Field[T] {
 	})
 }
 
+type retryArgs struct {
+	Attempts int
+	Backoff  string               `default:"1s"`
+	RetryOn  []core.ErrorCategory `default:"[]"`
+}
+
+func Retrier[T Evaluatable]() dagql.Field[T] {
+	return dagql.NodeFunc("withRetry", func(ctx context.Context, self dagql.Instance[T], args retryArgs) (T, error) {
+		var zero T
+		if args.Attempts < 1 {
+			return zero, fmt.Errorf("attempts must be at least 1, got %d", args.Attempts)
+		}
+		backoff, err := time.ParseDuration(args.Backoff)
+		if err != nil {
+			return zero, fmt.Errorf("invalid backoff: %w", err)
+		}
+		policy := core.RetryPolicy{
+			Attempts: args.Attempts,
+			Backoff:  backoff,
+			RetryOn:  args.RetryOn,
+		}
+		err = policy.Do(ctx, func(ctx context.Context) error {
+			_, err := self.Self.Evaluate(ctx)
+			return err
+		})
+		if err != nil {
+			return zero, err
+		}
+		return self.Self, nil
+	}).
+		ArgDoc("attempts", `Maximum number of attempts, including the first.`).
+		ArgDoc("backoff", `Delay before the first retry, doubled for each subsequent one (e.g., "500ms", "2s").`).
+		ArgDoc("retryOn", `Only retry errors of these categories. All errors are retried if empty.`)
+}
+
 func collectInputsSlice[T dagql.Type](inputs []dagql.InputObject[T]) []T {
 	ts := make([]T, len(inputs))
 	for i, input := range inputs {
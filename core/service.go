@@ -706,10 +706,11 @@ func (svc *Service) startReverseTunnel(ctx context.Context, id *call.ID) (runnin
 	}()
 
 	tunnel := &c2hTunnel{
-		bk:        bk,
-		ns:        netNS,
-		socks:     svc.HostSockets,
-		sockStore: sockStore,
+		bk:          bk,
+		ns:          netNS,
+		socks:       svc.HostSockets,
+		sockStore:   sockStore,
+		connections: tunnelConnections(ctx, dig),
 	}
 
 	// NB: decouple from the incoming ctx cancel and add our own
@@ -36,6 +36,7 @@ type Services struct {
 	starting map[ServiceKey]*sync.WaitGroup
 	running  map[ServiceKey]*RunningService
 	bindings map[ServiceKey]int
+	daemons  map[ServiceKey]*daemon
 	l        sync.Mutex
 }
 
@@ -81,6 +82,7 @@ func NewServices() *Services {
 		starting: map[ServiceKey]*sync.WaitGroup{},
 		running:  map[ServiceKey]*RunningService{},
 		bindings: map[ServiceKey]int{},
+		daemons:  map[ServiceKey]*daemon{},
 	}
 }
 
@@ -156,6 +158,7 @@ dance:
 		case isRunning:
 			// already running; increment binding count and return
 			ss.bindings[key]++
+			ss.daemonEvent(ctx, key, "attached")
 			ss.l.Unlock()
 			return running, nil
 		case isStarting:
@@ -323,6 +326,7 @@ func (ss *Services) Detach(ctx context.Context, svc *RunningService) {
 	}
 
 	ss.bindings[svc.Key]--
+	ss.daemonEvent(ctx, svc.Key, "detached")
 
 	if ss.bindings[svc.Key] > 0 {
 		ss.l.Unlock()
@@ -340,6 +344,7 @@ func (ss *Services) Detach(ctx context.Context, svc *RunningService) {
 }
 
 func (ss *Services) stop(ctx context.Context, running *RunningService, force bool) error {
+	ss.endDaemon(running.Key, nil)
 	err := running.Stop(ctx, force)
 	if err != nil {
 		return fmt.Errorf("stop: %w", err)
@@ -354,6 +359,7 @@ func (ss *Services) stop(ctx context.Context, running *RunningService, force boo
 }
 
 func (ss *Services) stopGraceful(ctx context.Context, running *RunningService, timeout time.Duration) error {
+	ss.endDaemon(running.Key, nil)
 	// attempt to gentle stop within a timeout
 	cause := errors.New("service did not terminate")
 	ctx2, _ := context.WithTimeoutCause(ctx, timeout, cause)
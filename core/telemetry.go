@@ -2,13 +2,17 @@ package core
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/dustin/go-humanize"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/proto"
 
 	"dagger.io/dagger/telemetry"
 	"github.com/dagger/dagger/dagql"
@@ -19,6 +23,11 @@ import (
 	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
+// callPayloadWarnBytes is the size of a call's arguments or result beyond
+// which a warning is recorded on its span. Large payloads have to be
+// serialized and transferred on every call, and often slow down sessions.
+const callPayloadWarnBytes = 1024 * 1024
+
 func collectDefs(ctx context.Context, val dagql.Typed) []*pb.Definition {
 	if val, ok := val.(dagql.Wrapper); ok {
 		return collectDefs(ctx, val.Unwrap())
@@ -82,7 +91,26 @@ func AroundFunc(ctx context.Context, self dagql.Object, id *call.ID) (context.Co
 	ctx, span := telemetry.Tracer(ctx, InstrumentationLibrary).
 		Start(ctx, spanName, trace.WithAttributes(attrs...))
 
+	// allow canceling the call on its own, e.g. from the TUI
+	untrack := func() {}
+	if cc := CallCancelsFromContext(ctx); cc != nil {
+		ctx, untrack = cc.Track(ctx, span.SpanContext().SpanID())
+	}
+
+	// bound the call by the timeout given to the object it's called on
+	cancelTimeout := func() {}
+	if timeout, ok := dagql.CallTimeout(id); ok {
+		ctx, cancelTimeout = WithTimeout(ctx, timeout, false)
+	}
+
 	return ctx, func(res dagql.Typed, cached bool, err error) {
+		untrack()
+		defer cancelTimeout()
+
+		// report timeouts as the failure, rather than as a cancelation
+		err = TimeoutCause(ctx, err)
+		_, timedOut := err.(*TimeoutError)
+
 		defer telemetry.End(span, func() error {
 			if err != nil {
 				return errors.New(unwrapError(err))
@@ -96,9 +124,12 @@ func AroundFunc(ctx context.Context, self dagql.Object, id *call.ID) (context.Co
 			span.SetAttributes(attribute.Bool(telemetry.CachedAttr, true))
 		}
 
-		if ctx.Err() != nil {
+		if ctx.Err() != nil && !timedOut {
 			// If the request was canceled, reflect it on the span.
 			span.SetAttributes(attribute.Bool(telemetry.CanceledAttr, true))
+			if by := CanceledBy(ctx); by != "" {
+				span.SetAttributes(attribute.String(telemetry.CanceledByAttr, by))
+			}
 		}
 
 		if err == nil {
@@ -130,6 +161,10 @@ func AroundFunc(ctx context.Context, self dagql.Object, id *call.ID) (context.Co
 			}
 		}
 
+		if payloadSizesEnabled(ctx) {
+			recordPayloadSizes(ctx, span, id, res)
+		}
+
 		// Record LLB op digests installed by this call so that we can know that it
 		// has pending work.
 		//
@@ -167,6 +202,76 @@ func AroundFunc(ctx context.Context, self dagql.Object, id *call.ID) (context.Co
 	}
 }
 
+// recordPayloadSizes records the serialized size of the call's arguments and
+// result as metrics, warning on the span if either is unusually large.
+func recordPayloadSizes(ctx context.Context, span trace.Span, id *call.ID, res dagql.Typed) {
+	var argsSize int64
+	for _, arg := range id.Call().Args {
+		argsSize += int64(proto.Size(arg))
+	}
+	var resultSize int64
+	switch res := res.(type) {
+	case nil:
+	case dagql.Object:
+		if dag, err := res.ID().ToProto(); err == nil {
+			resultSize = int64(proto.Size(dag))
+		}
+	default:
+		if payload, err := json.Marshal(res); err == nil {
+			resultSize = int64(len(payload))
+		}
+	}
+
+	attrs := callMetricAttrs(id.Digest(), span.SpanContext())
+	meter := telemetry.Meter(ctx, InstrumentationLibrary)
+	for name, size := range map[string]int64{
+		telemetry.CallArgsBytes:   argsSize,
+		telemetry.CallResultBytes: resultSize,
+	} {
+		gauge, err := meter.Int64Gauge(name, metric.WithUnit(telemetry.ByteUnitName))
+		if err != nil {
+			slog.Warn("failed to create payload size metric", "metric", name, "err", err)
+			continue
+		}
+		gauge.Record(ctx, size, metric.WithAttributes(attrs...))
+	}
+
+	if argsSize > callPayloadWarnBytes {
+		span.AddEvent(fmt.Sprintf("large arguments: %s", humanize.IBytes(uint64(argsSize))))
+	}
+	if resultSize > callPayloadWarnBytes {
+		span.AddEvent(fmt.Sprintf("large result: %s", humanize.IBytes(uint64(resultSize))))
+	}
+}
+
+// callMetricAttrs returns the attributes that associate a metric with a call
+// and its span, so that it's shown alongside the call.
+func callMetricAttrs(dig digest.Digest, spanContext trace.SpanContext) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String(telemetry.DagDigestAttr, dig.String()),
+	}
+	if spanContext.IsValid() {
+		attrs = append(attrs,
+			attribute.String(telemetry.MetricsSpanIDAttr, spanContext.SpanID().String()),
+			attribute.String(telemetry.MetricsTraceIDAttr, spanContext.TraceID().String()),
+		)
+	}
+	return attrs
+}
+
+type payloadSizesKey struct{}
+
+// WithPayloadSizes enables recording the size of calls' arguments and results
+// as metrics, which is off by default since it serializes every payload.
+func WithPayloadSizes(ctx context.Context) context.Context {
+	return context.WithValue(ctx, payloadSizesKey{}, true)
+}
+
+func payloadSizesEnabled(ctx context.Context) bool {
+	enabled, _ := ctx.Value(payloadSizesKey{}).(bool)
+	return enabled
+}
+
 // isIntrospection detects whether an ID is an introspection query.
 //
 // These queries tend to be very large and are not interesting for users to
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"dagger.io/dagger/telemetry"
+	"github.com/dagger/dagger/dagql"
+	"github.com/dagger/dagger/dagql/call"
+)
+
+func TestAroundFuncPayloadSizes(t *testing.T) {
+	id := call.New().Append(&ast.Type{NamedType: "String", NonNull: true}, "greeting", "", nil, false, 0, "",
+		call.NewArgument("name", call.NewLiteralString("world"), false))
+	recorded := func(ctx context.Context) []string {
+		reader := sdkmetric.NewManualReader()
+		ctx = telemetry.WithMeterProvider(ctx, sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+		_, done := AroundFunc(ctx, nil, id)
+		done(dagql.NewString("hello, world"), false, nil)
+		var metrics metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(ctx, &metrics))
+		var names []string
+		for _, scope := range metrics.ScopeMetrics {
+			for _, m := range scope.Metrics {
+				names = append(names, m.Name)
+			}
+		}
+		return names
+	}
+
+	// payloads aren't measured unless enabled
+	require.Empty(t, recorded(context.Background()))
+	require.ElementsMatch(t,
+		[]string{telemetry.CallArgsBytes, telemetry.CallResultBytes},
+		recorded(WithPayloadSizes(context.Background())))
+}
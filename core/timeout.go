@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"dagger.io/dagger/telemetry"
+)
+
+// TimeoutError is the cause of a context canceled by a timeout, so that
+// timeouts can be reported as such rather than as a bare deadline error.
+type TimeoutError struct {
+	Timeout time.Duration
+
+	// Session is set if the whole session timed out, rather than a single
+	// call.
+	Session bool
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Session {
+		return fmt.Sprintf("session timed out after %s", e.Timeout)
+	}
+	return fmt.Sprintf("timed out after %s", e.Timeout)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// WithTimeout returns a context that is canceled with a TimeoutError once the
+// timeout passes, and records the deadline on the current span so the UI can
+// show the time remaining.
+func WithTimeout(ctx context.Context, timeout time.Duration, session bool) (context.Context, context.CancelFunc) {
+	deadline := time.Now().Add(timeout)
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Int64(telemetry.UIDeadlineAttr, deadline.UnixNano()))
+	return context.WithDeadlineCause(ctx, deadline, &TimeoutError{
+		Timeout: timeout,
+		Session: session,
+	})
+}
+
+// TimeoutCause returns the TimeoutError that canceled the context, if any, for
+// reporting in place of the given error.
+func TimeoutCause(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+	if cause, ok := context.Cause(ctx).(*TimeoutError); ok {
+		return cause
+	}
+	return err
+}
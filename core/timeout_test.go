@@ -0,0 +1,28 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeoutCause(t *testing.T) {
+	ctx, cancel := WithTimeout(context.Background(), time.Millisecond, false)
+	defer cancel()
+	<-ctx.Done()
+
+	err := TimeoutCause(ctx, ctx.Err())
+	var timeoutErr *TimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	require.Equal(t, time.Millisecond, timeoutErr.Timeout)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, "timed out after 1ms", err.Error())
+
+	// other cancelations are left alone
+	ctx, cancelCause := context.WithCancelCause(context.Background())
+	cancelCause(errors.New("interrupted"))
+	require.ErrorIs(t, TimeoutCause(ctx, ctx.Err()), context.Canceled)
+}
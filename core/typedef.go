@@ -25,6 +25,11 @@ type Function struct {
 
 	SourceMap *SourceMap `field:"true" doc:"The location of this function declaration."`
 
+	Daemon bool `field:"true" doc:"Whether the function is a daemon, whose returned service keeps running after the call returns."`
+
+	RateLimitKey string  `field:"true" doc:"The key of the rate limit that calls to the function wait for, if any."`
+	RateLimitRPS float64 `field:"true" name:"rateLimitRps" doc:"The number of calls per second allowed by the function's rate limit, if any."`
+
 	// Below are not in public API
 
 	// OriginalName of the parent object
@@ -125,6 +130,19 @@ func (fn *Function) WithArg(name string, typeDef *TypeDef, desc string, defaultV
 	return fn
 }
 
+func (fn *Function) WithDaemon() *Function {
+	fn = fn.Clone()
+	fn.Daemon = true
+	return fn
+}
+
+func (fn *Function) WithRateLimit(key string, rps float64) *Function {
+	fn = fn.Clone()
+	fn.RateLimitKey = key
+	fn.RateLimitRPS = rps
+	return fn
+}
+
 func (fn *Function) WithSourceMap(sourceMap *SourceMap) *Function {
 	fn = fn.Clone()
 	fn.SourceMap = sourceMap
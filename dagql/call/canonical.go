@@ -0,0 +1,163 @@
+package call
+
+import (
+	"github.com/opencontainers/go-digest"
+	"github.com/zeebo/xxh3"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/dagger/dagger/dagql/call/callpbv1"
+)
+
+// Canonical returns an equivalent ID with its non-semantic fields stripped,
+// so that IDs which would produce the same result also have the same digest
+// regardless of how they were constructed.
+//
+// Module refs are dropped in favor of their pin when the module is pinned,
+// since the same commit may be referenced by many refs. Custom digests, e.g.
+// of calls cached per client, are kept by mixing them into the digest of the
+// canonical call, so that calls with different custom digests stay apart.
+func (id *ID) Canonical() *ID {
+	return id.rewrite(map[*ID]*ID{}, func(mod *Module) *Module {
+		if mod.id == nil {
+			return mod
+		}
+		ref := mod.pb.Ref
+		if mod.pb.Pin != "" {
+			ref = ""
+		}
+		return NewModule(mod.id.Canonical(), mod.pb.Name, ref, mod.pb.Pin)
+	}, false)
+}
+
+// Rebase returns the ID with every call implemented by a module of the same
+// name as mod switched over to mod, e.g. to migrate an ID to a newer version
+// of the module.
+//
+// The digests of all affected calls are recomputed. Custom digests are kept
+// for calls that are otherwise unchanged.
+func (id *ID) Rebase(mod *Module) *ID {
+	return id.rewrite(map[*ID]*ID{}, func(old *Module) *Module {
+		if old.pb.Name != mod.pb.Name {
+			return old
+		}
+		return mod
+	}, true)
+}
+
+// rewrite rebuilds the ID bottom-up, including any IDs passed as arguments,
+// replacing modules with the result of rewriteMod.
+//
+// If keepDigests is true, custom digests are preserved for calls whose
+// receiver, arguments, and module are unchanged.
+func (id *ID) rewrite(memo map[*ID]*ID, rewriteMod func(*Module) *Module, keepDigests bool) *ID {
+	if id == nil {
+		return nil
+	}
+	if rewritten, ok := memo[id]; ok {
+		return rewritten
+	}
+
+	receiver := id.receiver.rewrite(memo, rewriteMod, keepDigests)
+	changed := receiver != id.receiver
+
+	args := make([]*Argument, len(id.args))
+	for i, arg := range id.args {
+		args[i] = arg.rewrite(memo, rewriteMod, keepDigests)
+		changed = changed || args[i] != arg
+	}
+
+	mod := id.module
+	if mod != nil {
+		mod = rewriteMod(mod)
+		changed = changed || mod != id.module
+	}
+
+	if keepDigests && !changed {
+		// nothing to rewrite, so keep the ID and its digest as-is
+		memo[id] = id
+		return id
+	}
+
+	rewritten := receiver.Append(
+		id.pb.Type.ToAST(),
+		id.pb.Field,
+		id.pb.View,
+		mod,
+		id.pb.Tainted,
+		int(id.pb.Nth),
+		"",
+		args...,
+	)
+	if derived := id.derivedDigest(); !keepDigests && derived != id.Digest() {
+		// keep the custom digest as-is if the call is already canonical, and
+		// mix it into the canonical call's digest otherwise
+		customDigest := id.Digest()
+		if rewritten.Digest() != derived {
+			h := xxh3.New()
+			h.WriteString(rewritten.Digest().String())
+			h.WriteString(customDigest.String())
+			customDigest = digest.NewDigest("xxh3", h)
+		}
+		rewritten = rewritten.WithMetadata(customDigest, id.pb.Tainted)
+	}
+	memo[id] = rewritten
+	return rewritten
+}
+
+// derivedDigest returns the digest derived from the call, which differs
+// from its digest if it has a custom digest.
+func (id *ID) derivedDigest() digest.Digest {
+	call := proto.Clone(id.pb).(*callpbv1.Call)
+	call.Digest = ""
+	dgst, err := (&ID{pb: call}).calcDigest()
+	if err != nil {
+		return id.Digest()
+	}
+	return digest.Digest(dgst)
+}
+
+func (arg *Argument) rewrite(memo map[*ID]*ID, rewriteMod func(*Module) *Module, keepDigests bool) *Argument {
+	if arg == nil || arg.value == nil {
+		return arg
+	}
+	value := rewriteLiteral(arg.value, memo, rewriteMod, keepDigests)
+	if value == arg.value {
+		return arg
+	}
+	return NewArgument(arg.pb.Name, value, arg.isSensitive)
+}
+
+func rewriteLiteral(lit Literal, memo map[*ID]*ID, rewriteMod func(*Module) *Module, keepDigests bool) Literal {
+	switch lit := lit.(type) {
+	case *LiteralID:
+		id := lit.id.rewrite(memo, rewriteMod, keepDigests)
+		if id == lit.id {
+			return lit
+		}
+		return NewLiteralID(id)
+	case *LiteralList:
+		values := make([]Literal, len(lit.values))
+		var changed bool
+		for i, val := range lit.values {
+			values[i] = rewriteLiteral(val, memo, rewriteMod, keepDigests)
+			changed = changed || values[i] != val
+		}
+		if !changed {
+			return lit
+		}
+		return NewLiteralList(values...)
+	case *LiteralObject:
+		values := make([]*Argument, len(lit.values))
+		var changed bool
+		for i, val := range lit.values {
+			values[i] = val.rewrite(memo, rewriteMod, keepDigests)
+			changed = changed || values[i] != val
+		}
+		if !changed {
+			return lit
+		}
+		return NewLiteralObject(values...)
+	default:
+		return lit
+	}
+}
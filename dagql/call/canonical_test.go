@@ -0,0 +1,44 @@
+package call
+
+import (
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestCanonicalCustomDigests(t *testing.T) {
+	typ := ast.NonNullNamedType("Foo", nil)
+	call := func(receiver *ID, field string, mod *Module, customDigest digest.Digest) *ID {
+		return receiver.Append(typ, field, "", mod, false, 0, customDigest)
+	}
+
+	perClientA := call(New(), "foo", nil, "xxh3:a")
+	perClientB := call(New(), "foo", nil, "xxh3:b")
+	plain := call(New(), "foo", nil, "")
+
+	// calls with different custom digests don't collapse to one key
+	require.NotEqual(t, perClientA.Canonical().Digest(), perClientB.Canonical().Digest())
+	require.NotEqual(t, perClientA.Canonical().Digest(), plain.Canonical().Digest())
+	// a call that's already canonical keeps its custom digest
+	require.Equal(t, perClientA.Digest(), perClientA.Canonical().Digest())
+	// and so do calls on top of it, which are derived from it
+	require.NotEqual(t,
+		call(perClientA, "bar", nil, "").Canonical().Digest(),
+		call(perClientB, "bar", nil, "").Canonical().Digest())
+
+	// custom digests of calls that aren't canonical are mixed into the
+	// canonical call's digest, so the same call still canonicalizes the same
+	modID := call(New(), "module", nil, "")
+	main := NewModule(modID, "mod", "github.com/example/mod@main", "abc123")
+	tag := NewModule(modID, "mod", "github.com/example/mod@v1.0.0", "abc123")
+	viaMain := call(New(), "foo", main, "xxh3:a").Canonical()
+	viaTag := call(New(), "foo", tag, "xxh3:a").Canonical()
+	require.Equal(t, viaMain.Digest(), viaTag.Digest())
+	require.NotEqual(t, viaMain.Digest(), call(New(), "foo", main, "xxh3:b").Canonical().Digest())
+	require.NotEqual(t, viaMain.Digest(), call(New(), "foo", main, "").Canonical().Digest())
+
+	// canonicalizing is idempotent
+	require.Equal(t, viaMain.Digest(), viaMain.Canonical().Digest())
+}
@@ -0,0 +1,90 @@
+package call
+
+// DecodedCall is a stable, human-readable representation of a single call in
+// an ID's chain, suitable for JSON output and diffing.
+type DecodedCall struct {
+	Field   string         `json:"field"`
+	Args    []DecodedArg   `json:"args,omitempty"`
+	Type    string         `json:"type"`
+	Nth     int64          `json:"nth,omitempty"`
+	View    string         `json:"view,omitempty"`
+	Module  *DecodedModule `json:"module,omitempty"`
+	Tainted bool           `json:"tainted,omitempty"`
+	Digest  string         `json:"digest"`
+}
+
+// DecodedArg is a named argument or input object field.
+type DecodedArg struct {
+	Name  string `json:"name"`
+	Value any    `json:"value"`
+}
+
+// DecodedModule is the module that implements a call.
+type DecodedModule struct {
+	Name string `json:"name"`
+	Ref  string `json:"ref,omitempty"`
+	Pin  string `json:"pin,omitempty"`
+}
+
+// DecodedID is an ID passed as an argument, decoded into its own chain.
+type DecodedID struct {
+	ID []DecodedCall `json:"id"`
+}
+
+// Decoded returns the ID's chain of calls, starting from the root Query.
+func (id *ID) Decoded() []DecodedCall {
+	if id == nil {
+		return nil
+	}
+	chain := id.receiver.Decoded()
+	call := DecodedCall{
+		Field:   id.Field(),
+		Type:    id.Type().ToAST().String(),
+		Nth:     id.Nth(),
+		View:    id.View(),
+		Tainted: id.pb.Tainted,
+		Digest:  id.Digest().String(),
+	}
+	for _, arg := range id.args {
+		if arg.isSensitive {
+			continue
+		}
+		call.Args = append(call.Args, DecodedArg{
+			Name:  arg.Name(),
+			Value: decodedLiteral(arg.Value()),
+		})
+	}
+	if id.module != nil {
+		call.Module = &DecodedModule{
+			Name: id.module.pb.Name,
+			Ref:  id.module.pb.Ref,
+			Pin:  id.module.pb.Pin,
+		}
+	}
+	return append(chain, call)
+}
+
+func decodedLiteral(lit Literal) any {
+	switch lit := lit.(type) {
+	case *LiteralID:
+		return DecodedID{ID: lit.Value().Decoded()}
+	case *LiteralList:
+		vals := make([]any, 0, lit.Len())
+		lit.Range(func(_ int, val Literal) error {
+			vals = append(vals, decodedLiteral(val))
+			return nil
+		})
+		return vals
+	case *LiteralObject:
+		fields := make([]DecodedArg, 0, lit.Len())
+		lit.Range(func(_ int, name string, val Literal) error {
+			fields = append(fields, DecodedArg{Name: name, Value: decodedLiteral(val)})
+			return nil
+		})
+		return fields
+	case *LiteralNull:
+		return nil
+	default:
+		return lit.ToInput()
+	}
+}
@@ -0,0 +1,59 @@
+package call
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestDecoded(t *testing.T) {
+	ctrType := ast.NonNullNamedType("Container", nil)
+	dirType := ast.NonNullNamedType("Directory", nil)
+	src := New().Append(dirType, "directory", "", nil, false, 0, "")
+	ctr := New().Append(ctrType, "container", "", nil, false, 0, "").
+		Append(ctrType, "from", "", nil, false, 0, "",
+			NewArgument("address", NewLiteralString("alpine"), false))
+	mod := NewModule(New().Append(ast.NonNullNamedType("Module", nil), "module", "", nil, false, 0, ""),
+		"ci", "github.com/acme/ci@main", "abc123")
+	built := ctr.Append(ctrType, "build", "", mod, false, 2, "",
+		NewArgument("source", NewLiteralID(src), false),
+		NewArgument("args", NewLiteralList(NewLiteralString("-v"), NewLiteralNull()), false),
+		NewArgument("opts", NewLiteralObject(NewArgument("depth", NewLiteralInt(1), false)), false),
+		NewArgument("token", NewLiteralString("hunter2"), true))
+
+	// decoding works the same on an ID that went over the wire
+	enc, err := built.Encode()
+	require.NoError(t, err)
+	var decoded ID
+	require.NoError(t, decoded.Decode(enc))
+
+	chain := decoded.Decoded()
+	require.Len(t, chain, 3)
+	require.Equal(t, DecodedCall{
+		Field:  "container",
+		Type:   "Container!",
+		Digest: ctr.Receiver().Digest().String(),
+	}, chain[0])
+	require.Equal(t, []DecodedArg{{Name: "address", Value: "alpine"}}, chain[1].Args)
+
+	last := chain[2]
+	require.Equal(t, "build", last.Field)
+	require.Equal(t, int64(2), last.Nth)
+	require.Equal(t, &DecodedModule{Name: "ci", Ref: "github.com/acme/ci@main", Pin: "abc123"}, last.Module)
+	require.Equal(t, built.Digest().String(), last.Digest)
+	// sensitive arguments are left out
+	require.Equal(t, []DecodedArg{
+		{Name: "source", Value: DecodedID{ID: src.Decoded()}},
+		{Name: "args", Value: []any{"-v", nil}},
+		{Name: "opts", Value: []DecodedArg{{Name: "depth", Value: int64(1)}}},
+	}, last.Args)
+
+	payload, err := json.Marshal(chain)
+	require.NoError(t, err)
+	require.NotContains(t, string(payload), "hunter2")
+	require.Contains(t, string(payload), `{"name":"source","value":{"id":[{"field":"directory","type":"Directory!","digest":"`)
+
+	require.Nil(t, (*ID)(nil).Decoded())
+}
@@ -2257,3 +2257,69 @@ func TestCustomDigest(t *testing.T) {
 		assert.Equal(t, s1ID, res.ReturnTheArg.ID)
 	}
 }
+
+func TestWithTimeout(t *testing.T) {
+	srv := dagql.NewServer(Query{})
+	points.Install[Query](srv)
+
+	timeouts := map[string]time.Duration{}
+	srv.Around(func(ctx context.Context, self dagql.Object, id *call.ID) (context.Context, func(dagql.Typed, bool, error)) {
+		if timeout, ok := dagql.CallTimeout(id); ok {
+			timeouts[id.Field()] = timeout
+		}
+		return ctx, dagql.NoopDone
+	})
+
+	gql := client.New(dagql.NewDefaultHandler(srv))
+
+	var res struct {
+		Point struct {
+			WithTimeout struct {
+				ShiftLeft struct {
+					X int
+					Y int
+				}
+			}
+		}
+	}
+	req(t, gql, `query {
+		point(x: 6, y: 7) {
+			withTimeout(duration: "5m") {
+				shiftLeft {
+					x
+					y
+				}
+			}
+		}
+	}`, &res)
+	assert.Equal(t, 5, res.Point.WithTimeout.ShiftLeft.X)
+	assert.Equal(t, 7, res.Point.WithTimeout.ShiftLeft.Y)
+	// only the call made on the result of withTimeout is bound by it
+	assert.DeepEqual(t, map[string]time.Duration{"shiftLeft": 5 * time.Minute}, timeouts)
+
+	reqFail(t, gql, `query { point(x: 6, y: 7) { withTimeout(duration: "soon") { x } } }`, "invalid duration")
+	reqFail(t, gql, `query { point(x: 6, y: 7) { withTimeout(duration: "-1s") { x } } }`, "duration must be positive")
+
+	// classes can opt out, e.g. to define their own withTimeout
+	class := dagql.NewClass(dagql.ClassOpts[*points.Point]{NoTimeout: true})
+	_, ok := class.Field("withTimeout")
+	assert.Assert(t, !ok)
+	_, ok = class.Field("id")
+	assert.Assert(t, ok)
+
+	// a module's withTimeout function doesn't bound the next call
+	pointType := (&points.Point{}).Type()
+	mod := call.NewModule(call.New().Append(&ast.Type{NamedType: "Module", NonNull: true}, "module", "", nil, false, 0, ""),
+		"mod", "github.com/acme/mod", "abc123")
+	withTimeout := func(mod *call.Module) *call.ID {
+		return call.New().Append(pointType, "point", "", nil, false, 0, "").
+			Append(pointType, "withTimeout", "", mod, false, 0, "",
+				call.NewArgument("duration", call.NewLiteralString("5m"), false)).
+			Append(pointType, "shiftLeft", "", nil, false, 0, "")
+	}
+	timeout, ok := dagql.CallTimeout(withTimeout(nil))
+	assert.Assert(t, ok)
+	assert.Equal(t, 5*time.Minute, timeout)
+	_, ok = dagql.CallTimeout(withTimeout(mod))
+	assert.Assert(t, !ok)
+}
@@ -0,0 +1,97 @@
+package dagui
+
+import (
+	"time"
+)
+
+// CallAggregate summarizes sibling spans of the same call, e.g. the hundreds
+// of identical steps of a parallel matrix build, so they can be shown as a
+// single row.
+type CallAggregate struct {
+	// Spans are the spans of the call, in order.
+	Spans []*Span
+
+	// Failed and Running are the number of spans that failed or are still
+	// running.
+	Failed  int
+	Running int
+
+	// Min, Avg and Max are the durations of the spans that completed.
+	Min time.Duration
+	Avg time.Duration
+	Max time.Duration
+}
+
+// Count returns the number of spans of the call.
+func (agg *CallAggregate) Count() int {
+	return len(agg.Spans)
+}
+
+func newCallAggregate(spans []*Span) *CallAggregate {
+	agg := &CallAggregate{Spans: spans}
+	var total time.Duration
+	var completed int
+	for _, span := range spans {
+		if span.IsRunningOrEffectsRunning() {
+			agg.Running++
+			continue
+		}
+		if span.IsFailedOrCausedFailure() {
+			agg.Failed++
+		}
+		dur := span.ActiveDuration(span.EndTime)
+		if completed == 0 || dur < agg.Min {
+			agg.Min = dur
+		}
+		agg.Max = max(agg.Max, dur)
+		total += dur
+		completed++
+	}
+	if completed > 0 {
+		agg.Avg = total / time.Duration(completed)
+	}
+	return agg
+}
+
+// aggregateCalls replaces sibling trees of spans of the same call with a
+// single tree for all of them, whose children are the trees of each span,
+// recursing into the trees' children.
+func aggregateCalls(trees []*TraceTree, parent *TraceTree) []*TraceTree {
+	byCall := map[string][]*TraceTree{}
+	for _, tree := range trees {
+		if digest := tree.Span.CallDigest; digest != "" {
+			byCall[digest] = append(byCall[digest], tree)
+		}
+	}
+	var out []*TraceTree
+	for _, tree := range trees {
+		tree.Children = aggregateCalls(tree.Children, tree)
+		group := byCall[tree.Span.CallDigest]
+		if len(group) < 2 {
+			out = append(out, tree)
+			continue
+		}
+		if group[0] != tree {
+			// already grouped, with the first of the call's spans
+			continue
+		}
+		spans := make([]*Span, len(group))
+		for i, member := range group {
+			spans[i] = member.Span
+		}
+		agg := &TraceTree{
+			Span:      tree.Span,
+			Parent:    parent,
+			Aggregate: newCallAggregate(spans),
+			Children:  group,
+			Final:     tree.Final,
+		}
+		for _, member := range group {
+			member.Parent = agg
+			member.Chained = false
+			agg.IsRunningOrChildRunning = agg.IsRunningOrChildRunning || member.IsRunningOrChildRunning
+		}
+		out = append(out, agg)
+	}
+	return out
+}
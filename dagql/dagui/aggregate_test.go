@@ -0,0 +1,74 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestAggregateCalls(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	traceID := TraceID{TraceID: trace.TraceID{1}}
+	root := SpanID{SpanID: trace.SpanID{1}}
+	snapshots := []SpanSnapshot{{
+		ID:        root,
+		TraceID:   traceID,
+		Name:      "matrix",
+		StartTime: start,
+		EndTime:   start.Add(time.Minute),
+	}}
+	for i, digest := range []string{"xxh3:build", "xxh3:build", "xxh3:test", "xxh3:build"} {
+		snapshot := SpanSnapshot{
+			ID:         SpanID{SpanID: trace.SpanID{byte(i + 2)}},
+			TraceID:    traceID,
+			ParentID:   root,
+			Name:       digest,
+			CallDigest: digest,
+			StartTime:  start,
+			EndTime:    start.Add(time.Duration(i+1) * time.Second),
+		}
+		if i == 1 {
+			snapshot.Status = sdktrace.Status{Code: codes.Error}
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	db := NewDB()
+	db.SetPrimarySpan(root)
+	db.ImportSnapshots(snapshots)
+
+	opts := FrontendOpts{
+		ZoomedSpan:     root,
+		Verbosity:      ShowCompletedVerbosity,
+		AggregateCalls: true,
+	}
+	rows := db.RowsView(opts).Rows(opts)
+	require.Len(t, rows.Order, 2)
+	agg := rows.Order[0].Aggregate
+	require.NotNil(t, agg)
+	require.Equal(t, 3, agg.Count())
+	require.Equal(t, 1, agg.Failed)
+	require.Zero(t, agg.Running)
+	require.Equal(t, time.Second, agg.Min)
+	require.Equal(t, 4*time.Second, agg.Max)
+	require.Equal(t, 7*time.Second/3, agg.Avg)
+	require.Nil(t, rows.Order[1].Aggregate)
+	require.Equal(t, "xxh3:test", rows.Order[1].Span.CallDigest)
+
+	// expanding the row shows each span
+	opts.Verbosity = ExpandCompletedVerbosity
+	rows = db.RowsView(opts).Rows(opts)
+	require.Len(t, rows.Order, 5)
+	for _, row := range rows.Order[1:4] {
+		require.Equal(t, 1, row.Depth)
+		require.Equal(t, "xxh3:build", row.Span.CallDigest)
+	}
+
+	// without aggregation, every span has its own row
+	opts.AggregateCalls = false
+	rows = db.RowsView(opts).Rows(opts)
+	require.Len(t, rows.Order, 4)
+}
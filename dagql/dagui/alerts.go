@@ -0,0 +1,125 @@
+package dagui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// AlertConfig is a set of alert rules to evaluate against completed runs,
+// loaded from a JSON file like:
+//
+//	{
+//	  "rules": [{
+//	    "name": "slow steps",
+//	    "query": "span.duration > 5m && !internal",
+//	    "sinks": [{"type": "exit-code"}, {"type": "webhook", "url": "https://..."}]
+//	  }]
+//	}
+type AlertConfig struct {
+	Rules []*AlertRule `json:"rules"`
+}
+
+// AlertRule raises an alert when any span of a run matches its query.
+type AlertRule struct {
+	Name  string      `json:"name"`
+	Query string      `json:"query"`
+	Sinks []AlertSink `json:"sinks"`
+
+	query *SpanQuery
+}
+
+// AlertSink is where an alert is sent.
+type AlertSink struct {
+	// Type is one of exit-code, webhook, or file.
+	Type string `json:"type"`
+
+	// URL is where the alert is POSTed as JSON, for webhook sinks.
+	URL string `json:"url,omitempty"`
+
+	// Path is the file the alert is appended to as a line of JSON, for file
+	// sinks.
+	Path string `json:"path,omitempty"`
+
+	// Code is the exit code to use, for exit-code sinks. Defaults to a code
+	// chosen by the caller.
+	Code int `json:"code,omitempty"`
+}
+
+const (
+	AlertSinkExitCode = "exit-code"
+	AlertSinkWebhook  = "webhook"
+	AlertSinkFile     = "file"
+)
+
+// Alert is raised by a rule that matched spans of a run.
+type Alert struct {
+	Rule    string      `json:"rule"`
+	Query   string      `json:"query"`
+	TraceID TraceID     `json:"traceId"`
+	Run     string      `json:"run"`
+	Spans   []SpanMatch `json:"spans"`
+
+	Sinks []AlertSink `json:"-"`
+}
+
+// LoadAlertConfig loads alert rules from a JSON file, checking that their
+// queries and sinks are valid.
+func LoadAlertConfig(path string) (*AlertConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg AlertConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for i, rule := range cfg.Rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("alert rule %d: missing name", i+1)
+		}
+		rule.query, err = ParseSpanQuery(rule.Query)
+		if err != nil {
+			return nil, fmt.Errorf("alert rule %q: %w", rule.Name, err)
+		}
+		for _, sink := range rule.Sinks {
+			switch {
+			case sink.Type == AlertSinkExitCode:
+			case sink.Type == AlertSinkWebhook && sink.URL != "":
+			case sink.Type == AlertSinkFile && sink.Path != "":
+			case sink.Type == AlertSinkWebhook:
+				return nil, fmt.Errorf("alert rule %q: webhook sink needs a url", rule.Name)
+			case sink.Type == AlertSinkFile:
+				return nil, fmt.Errorf("alert rule %q: file sink needs a path", rule.Name)
+			default:
+				return nil, fmt.Errorf("alert rule %q: unknown sink type %q", rule.Name, sink.Type)
+			}
+		}
+	}
+	return &cfg, nil
+}
+
+// Evaluate returns the alerts raised by the rules for a completed run.
+func (cfg *AlertConfig) Evaluate(db *DB) []Alert {
+	var alerts []Alert
+	for _, rule := range cfg.Rules {
+		spans := db.Select(rule.query).Order
+		if len(spans) == 0 {
+			continue
+		}
+		alert := Alert{
+			Rule:  rule.Name,
+			Query: rule.Query,
+			Sinks: rule.Sinks,
+		}
+		if primary := db.Spans.Map[db.PrimarySpan]; primary != nil {
+			alert.TraceID = primary.TraceID
+			alert.Run = primary.Name
+		}
+		for _, span := range spans {
+			alert.Spans = append(alert.Spans, NewSpanMatch(span))
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
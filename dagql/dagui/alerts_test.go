@@ -0,0 +1,54 @@
+package dagui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestAlerts(t *testing.T) {
+	dir := t.TempDir()
+	write := func(cfg string) string {
+		path := filepath.Join(dir, "alerts.json")
+		require.NoError(t, os.WriteFile(path, []byte(cfg), 0o600))
+		return path
+	}
+
+	cfg, err := LoadAlertConfig(write(`{"rules": [
+		{"name": "slow", "query": "duration > 5m", "sinks": [{"type": "exit-code"}]},
+		{"name": "failed", "query": "failed", "sinks": [{"type": "file", "path": "alerts.jsonl"}]}
+	]}`))
+	require.NoError(t, err)
+
+	start := time.Now().Add(-time.Hour)
+	root := SpanID{SpanID: trace.SpanID{1}}
+	db := NewDB()
+	db.SetPrimarySpan(root)
+	db.ImportSnapshots([]SpanSnapshot{{
+		ID:        root,
+		TraceID:   TraceID{TraceID: trace.TraceID{1}},
+		Name:      "run",
+		StartTime: start,
+		EndTime:   start.Add(6 * time.Minute),
+	}})
+	alerts := cfg.Evaluate(db)
+	require.Len(t, alerts, 1)
+	require.Equal(t, "slow", alerts[0].Rule)
+	require.Equal(t, "run", alerts[0].Run)
+	require.Len(t, alerts[0].Spans, 1)
+	require.Equal(t, []AlertSink{{Type: AlertSinkExitCode}}, alerts[0].Sinks)
+
+	for _, invalid := range []string{
+		`{"rules": [{"query": "failed"}]}`,
+		`{"rules": [{"name": "bad", "query": "nope"}]}`,
+		`{"rules": [{"name": "bad", "query": "failed", "sinks": [{"type": "webhook"}]}]}`,
+		`{"rules": [{"name": "bad", "query": "failed", "sinks": [{"type": "email"}]}]}`,
+	} {
+		_, err := LoadAlertConfig(write(invalid))
+		require.Error(t, err, invalid)
+	}
+}
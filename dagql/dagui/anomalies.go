@@ -0,0 +1,149 @@
+package dagui
+
+import (
+	"fmt"
+	"time"
+)
+
+// AnomalyKind is a kind of problem with the telemetry received for a span.
+type AnomalyKind string
+
+const (
+	// AnomalyEndBeforeStart is a span that ended before it started. Its times
+	// are corrected; see normalizeTimes.
+	AnomalyEndBeforeStart AnomalyKind = "end-before-start"
+
+	// AnomalyUnknownParent is a span whose parent was never received.
+	AnomalyUnknownParent AnomalyKind = "unknown-parent"
+
+	// AnomalyConflictingSpan is a span received more than once with
+	// conflicting data, e.g. a different trace or start time. The latest data
+	// is kept.
+	AnomalyConflictingSpan AnomalyKind = "conflicting-span"
+
+	// AnomalyClockSkew is a span that started before its parent, which was
+	// timestamped by another process's clock. The times of that clock's spans
+	// are shifted to correct for it; see correctClockSkew.
+	AnomalyClockSkew AnomalyKind = "clock-skew"
+)
+
+// AnomalyKinds lists every kind of anomaly, in the order they're reported.
+var AnomalyKinds = []AnomalyKind{
+	AnomalyEndBeforeStart,
+	AnomalyUnknownParent,
+	AnomalyConflictingSpan,
+	AnomalyClockSkew,
+}
+
+// maxAnomalies is the number of anomalies kept for each kind. Further
+// anomalies are still counted.
+const maxAnomalies = 100
+
+// Anomaly is a problem found with the telemetry received for a span.
+type Anomaly struct {
+	Kind   AnomalyKind
+	Span   SpanID
+	Name   string
+	Detail string
+}
+
+// recordAnomaly counts an anomaly found at ingestion, keeping its details if
+// there's room.
+func (db *DB) recordAnomaly(anomaly Anomaly) {
+	if db.anomalyCounts == nil {
+		db.anomalyCounts = make(map[AnomalyKind]int)
+	}
+	db.anomalyCounts[anomaly.Kind]++
+	if db.anomalyCounts[anomaly.Kind] <= maxAnomalies {
+		db.anomalies = append(db.anomalies, anomaly)
+	}
+}
+
+// validateSpan checks a span's incoming data against its current data,
+// before it's applied, correcting the incoming times if they can't be right.
+func (db *DB) validateSpan(span *Span, incoming *SpanSnapshot) {
+	if !incoming.EndTime.IsZero() && incoming.EndTime.Before(incoming.StartTime) {
+		db.recordAnomaly(Anomaly{
+			Kind:   AnomalyEndBeforeStart,
+			Span:   incoming.ID,
+			Name:   incoming.Name,
+			Detail: fmt.Sprintf("ended %s before it started", incoming.StartTime.Sub(incoming.EndTime)),
+		})
+	}
+	if correction := incoming.normalizeTimes(); correction != "" {
+		incoming.Corrected = correction
+	}
+	if incoming.Corrected != "" {
+		if db.correctedSpans == nil {
+			db.correctedSpans = make(map[SpanID]struct{})
+		}
+		db.correctedSpans[incoming.ID] = struct{}{}
+	} else {
+		delete(db.correctedSpans, incoming.ID)
+	}
+	if span.Received {
+		var conflicts []string
+		if span.TraceID != incoming.TraceID {
+			conflicts = append(conflicts, fmt.Sprintf("trace %s != %s", incoming.TraceID, span.TraceID))
+		}
+		if !span.StartTime.Equal(incoming.StartTime) {
+			conflicts = append(conflicts, fmt.Sprintf("start time %s != %s",
+				incoming.StartTime.Format(time.RFC3339Nano), span.StartTime.Format(time.RFC3339Nano)))
+		}
+		if !span.EndTime.IsZero() && !span.EndTime.Equal(incoming.EndTime) {
+			conflicts = append(conflicts, fmt.Sprintf("end time %s != %s",
+				incoming.EndTime.Format(time.RFC3339Nano), span.EndTime.Format(time.RFC3339Nano)))
+		}
+		for _, conflict := range conflicts {
+			db.recordAnomaly(Anomaly{
+				Kind:   AnomalyConflictingSpan,
+				Span:   span.ID,
+				Name:   incoming.Name,
+				Detail: conflict,
+			})
+		}
+	}
+}
+
+// unknownParents returns the spans whose parent was never received. Parents
+// are often received after their children, since they end later, so these
+// are only anomalies once the trace is complete. The ancestors of the primary
+// span are left out, since they belong to an outer trace that's expected to
+// be missing.
+func (db *DB) unknownParents() []Anomaly {
+	outer := make(map[SpanID]bool)
+	if primary := db.Spans.Map[db.PrimarySpan]; primary != nil {
+		for p := range primary.Parents {
+			outer[p.ID] = true
+		}
+	}
+	var anomalies []Anomaly
+	for _, span := range db.Spans.Order {
+		if !span.Received || span.ParentSpan == nil || span.ParentSpan.Received ||
+			outer[span.ParentID] {
+			continue
+		}
+		anomalies = append(anomalies, Anomaly{
+			Kind:   AnomalyUnknownParent,
+			Span:   span.ID,
+			Name:   span.Name,
+			Detail: fmt.Sprintf("parent %s not received", span.ParentID),
+		})
+	}
+	return anomalies
+}
+
+// Anomalies returns the problems found with the telemetry received so far,
+// along with how many of each kind were found. Only the first anomalies of
+// each kind are returned, but all of them are counted.
+func (db *DB) Anomalies() ([]Anomaly, map[AnomalyKind]int) {
+	anomalies := append([]Anomaly(nil), db.anomalies...)
+	counts := make(map[AnomalyKind]int, len(AnomalyKinds))
+	for kind, n := range db.anomalyCounts {
+		counts[kind] = n
+	}
+	unknown := db.unknownParents()
+	counts[AnomalyUnknownParent] = len(unknown)
+	anomalies = append(anomalies, unknown[:min(len(unknown), maxAnomalies)]...)
+	return anomalies, counts
+}
@@ -0,0 +1,46 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnomalies(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	span := testTrace{start: start}.span
+	backwards := span(2, 1, "backwards", 0, time.Minute)
+	backwards.EndTime = start.Add(-time.Second)
+
+	db := NewDB()
+	db.SetPrimarySpan(testSpanID(1))
+	db.ImportSnapshots([]SpanSnapshot{
+		// the primary span's parent belongs to an outer trace
+		span(1, 9, "run", 0, time.Minute),
+		backwards,
+		span(3, 1, "ok", 0, time.Minute),
+		span(4, 8, "orphan", 0, time.Minute),
+	})
+
+	anomalies, counts := db.Anomalies()
+	require.Equal(t, 1, counts[AnomalyEndBeforeStart])
+	require.Equal(t, 1, counts[AnomalyUnknownParent])
+	require.Equal(t, 0, counts[AnomalyConflictingSpan])
+	require.Len(t, anomalies, 2)
+	require.Equal(t, "backwards", anomalies[0].Name)
+	require.Equal(t, "orphan", anomalies[1].Name)
+
+	// durations are clamped rather than negative
+	require.Equal(t, time.Duration(0), db.Spans.Map[backwards.ID].EndTime.Sub(start))
+
+	// a duplicate span with conflicting data is counted; updates to a running
+	// span aren't
+	conflict := span(3, 1, "ok", 0, time.Minute)
+	conflict.StartTime = start.Add(time.Second)
+	db.ImportSnapshots([]SpanSnapshot{conflict})
+	db.ImportSnapshots([]SpanSnapshot{span(5, 1, "running", 0, 0)})
+	db.ImportSnapshots([]SpanSnapshot{span(5, 1, "running", 0, time.Minute)})
+	_, counts = db.Anomalies()
+	require.Equal(t, 1, counts[AnomalyConflictingSpan])
+}
@@ -0,0 +1,223 @@
+package dagui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const baselinesDir = "baselines"
+
+// TraceMetrics are the performance characteristics of a run that baselines
+// are compared on.
+type TraceMetrics struct {
+	// Name identifies the pipeline, e.g. "dagger call build".
+	Name    string
+	TraceID TraceID
+
+	Total         time.Duration
+	CacheHitRatio float64
+
+	// Steps maps the names of the run's top-level steps to their durations.
+	Steps map[string]time.Duration
+
+	// QuarantinedFailures is the number of quarantined steps that failed,
+	// tracked separately since they don't count as failures.
+	QuarantinedFailures int `json:",omitempty"`
+}
+
+// Metrics computes the metrics of the DB's primary span.
+func (db *DB) Metrics() TraceMetrics {
+	metrics := TraceMetrics{
+		Steps: map[string]time.Duration{},
+	}
+	primary := db.Spans.Map[db.PrimarySpan]
+	if primary == nil {
+		return metrics
+	}
+	metrics.Name = primary.Name
+	metrics.TraceID = primary.TraceID
+	metrics.Total = primary.WallTime()
+	metrics.CacheHitRatio, _ = db.CacheHitRatio()
+	for _, span := range db.TopLevelSpans() {
+		if span.Pause {
+			continue
+		}
+		metrics.Steps[span.Name] = max(metrics.Steps[span.Name], span.WallTime())
+	}
+	for _, span := range db.Spans.Order {
+		if span.Quarantined && span.IsFailedOrCausedFailure() {
+			metrics.QuarantinedFailures++
+		}
+	}
+	return metrics
+}
+
+// TopLevelSpans returns the visible children of the primary span, looking
+// through passthrough spans.
+func (db *DB) TopLevelSpans() []*Span {
+	primary := db.Spans.Map[db.PrimarySpan]
+	if primary == nil {
+		return nil
+	}
+	var spans []*Span
+	var collect func(SpanSet)
+	collect = func(set SpanSet) {
+		for _, span := range set.Order {
+			if span.Passthrough {
+				collect(span.ChildSpans)
+				continue
+			}
+			if span.IsInternal() {
+				continue
+			}
+			spans = append(spans, span)
+		}
+	}
+	collect(primary.ChildSpans)
+	return spans
+}
+
+var unsafeBaselineChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+func (store *TraceStore) baselinePath(name string) string {
+	return filepath.Join(store.Root, baselinesDir, unsafeBaselineChars.ReplaceAllString(name, "_")+".json")
+}
+
+// SetBaseline blesses the metrics as the baseline for their pipeline.
+func (store *TraceStore) SetBaseline(metrics TraceMetrics) error {
+	path := store.baselinePath(metrics.Name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return writeJSONFile(path, metrics)
+}
+
+// Baseline returns the baseline for the named pipeline.
+func (store *TraceStore) Baseline(name string) (TraceMetrics, error) {
+	var metrics TraceMetrics
+	if err := readJSONFile(store.baselinePath(name), &metrics); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return metrics, fmt.Errorf("no baseline set for %q", name)
+		}
+		return metrics, err
+	}
+	return metrics, nil
+}
+
+// BaselineTolerance configures how much a run may deviate from its baseline
+// before it counts as a regression.
+type BaselineTolerance struct {
+	// Ratio is the allowed relative increase in durations, and relative
+	// decrease in cache hit ratio, e.g. 0.1 for 10%.
+	Ratio float64
+
+	// MinDelta is the smallest duration increase considered a regression,
+	// to ignore noise in short steps.
+	MinDelta time.Duration
+}
+
+// MetricComparison compares a single metric against its baseline.
+type MetricComparison struct {
+	Name       string
+	Baseline   string
+	Current    string
+	Change     string
+	Regression bool
+}
+
+// BaselineComparison is the result of comparing a run against its baseline.
+type BaselineComparison struct {
+	Baseline TraceMetrics
+	Current  TraceMetrics
+	Metrics  []MetricComparison
+}
+
+// Regressed reports whether any metric regressed beyond the tolerance.
+func (cmp BaselineComparison) Regressed() bool {
+	for _, m := range cmp.Metrics {
+		if m.Regression {
+			return true
+		}
+	}
+	return false
+}
+
+// CompareBaseline compares the current metrics against the baseline.
+func CompareBaseline(baseline, current TraceMetrics, tolerance BaselineTolerance) BaselineComparison {
+	cmp := BaselineComparison{
+		Baseline: baseline,
+		Current:  current,
+	}
+	compareDuration := func(name string, base, cur time.Duration, hasBase bool) {
+		m := MetricComparison{
+			Name:    name,
+			Current: FormatDuration(cur),
+		}
+		if !hasBase {
+			m.Baseline = "-"
+			m.Change = "new"
+		} else {
+			m.Baseline = FormatDuration(base)
+			delta := cur - base
+			if base > 0 {
+				m.Change = fmt.Sprintf("%+.1f%%", float64(delta)/float64(base)*100)
+			}
+			m.Regression = delta > tolerance.MinDelta &&
+				float64(delta) > float64(base)*tolerance.Ratio
+		}
+		cmp.Metrics = append(cmp.Metrics, m)
+	}
+
+	compareDuration("total", baseline.Total, current.Total, true)
+
+	names := make([]string, 0, len(current.Steps))
+	for name := range current.Steps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		base, ok := baseline.Steps[name]
+		compareDuration("step: "+name, base, current.Steps[name], ok)
+	}
+
+	cmp.Metrics = append(cmp.Metrics, MetricComparison{
+		Name:     "cache hit ratio",
+		Baseline: fmt.Sprintf("%.1f%%", baseline.CacheHitRatio*100),
+		Current:  fmt.Sprintf("%.1f%%", current.CacheHitRatio*100),
+		Change:   fmt.Sprintf("%+.1fpp", (current.CacheHitRatio-baseline.CacheHitRatio)*100),
+		Regression: current.CacheHitRatio <
+			baseline.CacheHitRatio*(1-tolerance.Ratio),
+	})
+	return cmp
+}
+
+// WriteMarkdown renders the comparison as a markdown report, suitable for
+// posting as a pull request comment.
+func (cmp BaselineComparison) WriteMarkdown(w io.Writer) error {
+	status := "✅ No performance regressions"
+	if cmp.Regressed() {
+		status = "❌ Performance regressed"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "### %s: `%s`\n\n", status, cmp.Current.Name)
+	fmt.Fprintf(&sb, "Compared trace `%s` against baseline `%s`.\n\n", cmp.Current.TraceID, cmp.Baseline.TraceID)
+	sb.WriteString("| Metric | Baseline | Current | Change | |\n")
+	sb.WriteString("|---|---:|---:|---:|---|\n")
+	for _, m := range cmp.Metrics {
+		mark := ""
+		if m.Regression {
+			mark = "❌"
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s |\n",
+			strings.ReplaceAll(m.Name, "|", `\|`), m.Baseline, m.Current, m.Change, mark)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,90 @@
+package dagui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceMetrics(t *testing.T) {
+	span := testTrace{start: time.Now().Add(-time.Hour)}.span
+	cached := span(3, 1, "test", 2*time.Second, 3*time.Second)
+	cached.Cached = true
+	db := NewDB()
+	db.SetPrimarySpan(testSpanID(1))
+	db.ImportSnapshots([]SpanSnapshot{
+		span(1, 0, "dagger call build", 0, 10*time.Second),
+		span(2, 1, "build", time.Second, 5*time.Second),
+		cached,
+	})
+
+	metrics := db.Metrics()
+	require.Equal(t, "dagger call build", metrics.Name)
+	require.Equal(t, 10*time.Second, metrics.Total)
+	require.Equal(t, map[string]time.Duration{
+		"build": 4 * time.Second,
+		"test":  time.Second,
+	}, metrics.Steps)
+
+	store := NewTraceStore(t.TempDir())
+	_, err := store.Baseline(metrics.Name)
+	require.ErrorContains(t, err, "no baseline set")
+	require.NoError(t, store.SetBaseline(metrics))
+	baseline, err := store.Baseline(metrics.Name)
+	require.NoError(t, err)
+	require.Equal(t, metrics, baseline)
+}
+
+func TestCompareBaseline(t *testing.T) {
+	baseline := TraceMetrics{
+		Name:          "ci",
+		Total:         10 * time.Second,
+		CacheHitRatio: 0.8,
+		Steps: map[string]time.Duration{
+			"build": 4 * time.Second,
+			"lint":  100 * time.Millisecond,
+		},
+	}
+	tolerance := BaselineTolerance{Ratio: 0.1, MinDelta: time.Second}
+
+	cmp := CompareBaseline(baseline, baseline, tolerance)
+	require.False(t, cmp.Regressed())
+
+	current := TraceMetrics{
+		Name:          "ci",
+		Total:         10500 * time.Millisecond,
+		CacheHitRatio: 0.5,
+		Steps: map[string]time.Duration{
+			"build": 6 * time.Second,
+			// slower by more than the ratio, but within the minimum delta
+			"lint": 500 * time.Millisecond,
+			"test": time.Second,
+		},
+	}
+	cmp = CompareBaseline(baseline, current, tolerance)
+	require.True(t, cmp.Regressed())
+	regressions := map[string]bool{}
+	for _, m := range cmp.Metrics {
+		regressions[m.Name] = m.Regression
+	}
+	require.Equal(t, map[string]bool{
+		"total":           false,
+		"step: build":     true,
+		"step: lint":      false,
+		"step: test":      false,
+		"cache hit ratio": true,
+	}, regressions)
+	require.Equal(t, MetricComparison{
+		Name:     "step: test",
+		Baseline: "-",
+		Current:  FormatDuration(time.Second),
+		Change:   "new",
+	}, cmp.Metrics[3])
+
+	var md strings.Builder
+	require.NoError(t, cmp.WriteMarkdown(&md))
+	require.Contains(t, md.String(), "### ❌ Performance regressed: `ci`")
+	require.Contains(t, md.String(), "| step: build | "+FormatDuration(4*time.Second)+" | "+FormatDuration(6*time.Second)+" | +50.0% | ❌ |")
+}
@@ -0,0 +1,200 @@
+package dagui
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var (
+	boltMetaBucket    = []byte("meta")
+	boltSpansBucket   = []byte("spans")
+	boltLogsBucket    = []byte("logs")
+	boltMetricsBucket = []byte("metrics")
+
+	boltPrimarySpanKey = []byte("primary")
+)
+
+// boltOpenTimeout is how long to wait for another process to release the
+// store's file lock.
+const boltOpenTimeout = time.Second
+
+// BoltStore is a DBStore backed by a bbolt database file.
+//
+// Spans and log tails are keyed by span ID, so that each write replaces the
+// previous state. Metric points are appended under a bucket for each call and
+// metric.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+var _ DBStore = (*BoltStore)(nil)
+
+// OpenBoltStore opens or creates a store at the given path.
+func OpenBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{
+		Timeout: boltOpenTimeout,
+		// the store only needs to survive the process crashing, not the
+		// machine, so don't wait on the disk for every write
+		NoSync: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltMetaBucket, boltSpansBucket, boltLogsBucket, boltMetricsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init %s: %w", path, err)
+	}
+	return &BoltStore{db: db}, nil
+}
+
+func (store *BoltStore) WritePrimarySpan(id SpanID) error {
+	return store.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltMetaBucket).Put(boltPrimarySpanKey, id.SpanID[:])
+	})
+}
+
+func (store *BoltStore) WriteSpans(snapshots []SpanSnapshot) error {
+	return store.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltSpansBucket)
+		for _, snapshot := range snapshots {
+			payload, err := json.Marshal(snapshot)
+			if err != nil {
+				return fmt.Errorf("marshal span %s: %w", snapshot.ID, err)
+			}
+			if err := bucket.Put(snapshot.ID.SpanID[:], payload); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (store *BoltStore) WriteLogTails(tails map[SpanID][]byte) error {
+	return store.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltLogsBucket)
+		for id, tail := range tails {
+			if err := bucket.Put(id.SpanID[:], tail); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// boltMetricPoint is the stored form of a metric data point. Attributes are
+// dropped; the call and metric name are all that are used for display.
+type boltMetricPoint struct {
+	StartTime time.Time
+	Time      time.Time
+	Value     int64
+}
+
+func (store *BoltStore) WriteMetrics(metricsByCall map[string]map[string][]metricdata.DataPoint[int64]) error {
+	return store.db.Update(func(tx *bolt.Tx) error {
+		for callDigest, metrics := range metricsByCall {
+			call, err := tx.Bucket(boltMetricsBucket).CreateBucketIfNotExists([]byte(callDigest))
+			if err != nil {
+				return err
+			}
+			for name, points := range metrics {
+				metric, err := call.CreateBucketIfNotExists([]byte(name))
+				if err != nil {
+					return err
+				}
+				for _, point := range points {
+					seq, err := metric.NextSequence()
+					if err != nil {
+						return err
+					}
+					payload, err := json.Marshal(boltMetricPoint{
+						StartTime: point.StartTime,
+						Time:      point.Time,
+						Value:     point.Value,
+					})
+					if err != nil {
+						return err
+					}
+					if err := metric.Put(binary.BigEndian.AppendUint64(nil, seq), payload); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (store *BoltStore) Read() (*StoredDB, error) {
+	stored := &StoredDB{
+		LogTails:      make(map[SpanID][]byte),
+		MetricsByCall: make(map[string]map[string][]metricdata.DataPoint[int64]),
+	}
+	err := store.db.View(func(tx *bolt.Tx) error {
+		if primary := tx.Bucket(boltMetaBucket).Get(boltPrimarySpanKey); primary != nil {
+			copy(stored.PrimarySpan.SpanID[:], primary)
+		}
+		if err := tx.Bucket(boltSpansBucket).ForEach(func(_, payload []byte) error {
+			var snapshot SpanSnapshot
+			if err := json.Unmarshal(payload, &snapshot); err != nil {
+				return fmt.Errorf("unmarshal span: %w", err)
+			}
+			stored.Spans = append(stored.Spans, snapshot)
+			return nil
+		}); err != nil {
+			return err
+		}
+		if err := tx.Bucket(boltLogsBucket).ForEach(func(key, tail []byte) error {
+			var id SpanID
+			copy(id.SpanID[:], key)
+			stored.LogTails[id] = append([]byte(nil), tail...)
+			return nil
+		}); err != nil {
+			return err
+		}
+		return tx.Bucket(boltMetricsBucket).ForEachBucket(func(callDigest []byte) error {
+			call := tx.Bucket(boltMetricsBucket).Bucket(callDigest)
+			metrics := make(map[string][]metricdata.DataPoint[int64])
+			stored.MetricsByCall[string(callDigest)] = metrics
+			return call.ForEachBucket(func(name []byte) error {
+				return call.Bucket(name).ForEach(func(_, payload []byte) error {
+					var point boltMetricPoint
+					if err := json.Unmarshal(payload, &point); err != nil {
+						return fmt.Errorf("unmarshal metric point: %w", err)
+					}
+					metrics[string(name)] = append(metrics[string(name)], metricdata.DataPoint[int64]{
+						StartTime: point.StartTime,
+						Time:      point.Time,
+						Value:     point.Value,
+					})
+					return nil
+				})
+			})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	// spans are keyed by ID; put them back in the order they started, so
+	// parents are integrated before their children where possible
+	slices.SortStableFunc(stored.Spans, func(a, b SpanSnapshot) int {
+		return a.StartTime.Compare(b.StartTime)
+	})
+	return stored, nil
+}
+
+func (store *BoltStore) Close() error {
+	return store.db.Close()
+}
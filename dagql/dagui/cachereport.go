@@ -0,0 +1,116 @@
+package dagui
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// CacheReport analyzes how a completed run used the cache.
+type CacheReport struct {
+	// Cached and Executed are the number of calls that were cached and that
+	// actually ran.
+	Cached   int `json:"cached"`
+	Executed int `json:"executed"`
+
+	// HitRatio is the ratio of calls that were cached, between 0 and 1.
+	HitRatio float64 `json:"hitRatio"`
+
+	// TimeSaved is the estimated time saved by cached calls, from how long
+	// they took when they last ran.
+	TimeSaved time.Duration `json:"timeSaved"`
+
+	// Estimated is the number of cached calls that have run before, and so
+	// count towards TimeSaved.
+	Estimated int `json:"estimated"`
+
+	// Uncached are the slowest calls that ran, slowest first.
+	Uncached []UncachedStep `json:"uncached"`
+}
+
+// UncachedStep is a call that ran instead of being cached.
+type UncachedStep struct {
+	Name       string        `json:"name"`
+	CallDigest string        `json:"callDigest,omitempty"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// CacheReport analyzes the run's use of the cache, estimating the time saved
+// by cached calls with their durations in the history, and listing up to the
+// given number of slowest uncached calls.
+func (db *DB) CacheReport(hist *DurationHistory, top int) CacheReport {
+	report := CacheReport{
+		Uncached: []UncachedStep{},
+	}
+	for _, span := range db.Spans.Order {
+		if span.CallDigest == "" || span.IsInternal() || span.IsRunning() {
+			continue
+		}
+		if span.IsCached() {
+			report.Cached++
+			// a cached call's children are cached too, if they show up at all,
+			// so only count the time saved by the outermost one
+			if hasCachedAncestor(span) {
+				continue
+			}
+			if dur, ok := hist.Estimate(span); ok {
+				report.TimeSaved += dur
+				report.Estimated++
+			}
+			continue
+		}
+		report.Executed++
+		if span.Passthrough {
+			continue
+		}
+		report.Uncached = append(report.Uncached, UncachedStep{
+			Name:       span.Name,
+			CallDigest: span.CallDigest,
+			Duration:   span.WallTime(),
+		})
+	}
+	if total := report.Cached + report.Executed; total > 0 {
+		report.HitRatio = float64(report.Cached) / float64(total)
+	}
+	slices.SortStableFunc(report.Uncached, func(a, b UncachedStep) int {
+		return cmp.Compare(b.Duration, a.Duration)
+	})
+	if len(report.Uncached) > top {
+		report.Uncached = report.Uncached[:top]
+	}
+	return report
+}
+
+func hasCachedAncestor(span *Span) bool {
+	for parent := span.ParentSpan; parent != nil; parent = parent.ParentSpan {
+		if parent.IsCached() {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteText renders the report as a human-readable table.
+func (report CacheReport) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Cache: %d cached, %d executed (%.1f%% hit ratio)\n",
+		report.Cached, report.Executed, report.HitRatio*100)
+	if report.Estimated > 0 {
+		fmt.Fprintf(&sb, "Time saved: ~%s, estimated from %d of %d cached calls\n",
+			FormatDuration(report.TimeSaved), report.Estimated, report.Cached)
+	}
+	if len(report.Uncached) > 0 {
+		sb.WriteString("\nSlowest uncached steps:\n")
+		tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+		for _, step := range report.Uncached {
+			fmt.Fprintf(tw, "  %s\t  %s\n", FormatDuration(step.Duration), step.Name)
+		}
+		tw.Flush()
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
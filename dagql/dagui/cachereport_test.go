@@ -0,0 +1,49 @@
+package dagui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheReport(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	span := testTrace{start: start}.span
+	cached := func(snapshot SpanSnapshot) SpanSnapshot {
+		snapshot.Cached = true
+		return snapshot
+	}
+
+	db := NewDB()
+	db.ImportSnapshots([]SpanSnapshot{
+		span(1, 0, "", 0, 10*time.Second),
+		cached(span(2, 1, "", 0, time.Second)),
+		cached(span(3, 2, "", 0, time.Second)),
+		cached(span(4, 1, "", time.Second, 2*time.Second)),
+		span(5, 1, "", 2*time.Second, 5*time.Second),
+		span(6, 1, "", 5*time.Second, 10*time.Second),
+	})
+
+	hist := NewDurationHistory()
+	hist.ByCall["digest 2"] = DurationEstimate{Duration: time.Minute}
+	// nested in a cached call, so already accounted for
+	hist.ByCall["digest 3"] = DurationEstimate{Duration: 30 * time.Second}
+
+	report := db.CacheReport(hist, 2)
+	require.Equal(t, 3, report.Cached)
+	require.Equal(t, 3, report.Executed)
+	require.Equal(t, 0.5, report.HitRatio)
+	require.Equal(t, time.Minute, report.TimeSaved)
+	require.Equal(t, 1, report.Estimated)
+	require.Equal(t, []UncachedStep{
+		{Name: "step 1", CallDigest: "digest 1", Duration: 10 * time.Second},
+		{Name: "step 6", CallDigest: "digest 6", Duration: 5 * time.Second},
+	}, report.Uncached)
+
+	var sb strings.Builder
+	require.NoError(t, report.WriteText(&sb))
+	require.Contains(t, sb.String(), "Cache: 3 cached, 3 executed (50.0% hit ratio)")
+	require.Contains(t, sb.String(), "step 6")
+}
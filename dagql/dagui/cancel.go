@@ -0,0 +1,84 @@
+package dagui
+
+import "dagger.io/dagger/telemetry"
+
+// CanceledByFailure is the CancelCause of a span canceled because another one
+// failed, e.g. its parent, which took down the rest of its work. Unlike the
+// telemetry.CanceledBy values, it's inferred by the frontend.
+const CanceledByFailure = "failure"
+
+// CancelCause describes why a span was canceled.
+type CancelCause struct {
+	// By is who or what canceled the span: telemetry.CanceledByUser,
+	// telemetry.CanceledByTimeout, or CanceledByFailure. It's empty if not
+	// known, e.g. if the client went away.
+	By string `json:",omitempty"`
+
+	// From is the name of the span the cancellation propagated from, e.g. the
+	// parent that failed, if not the span itself.
+	From string `json:",omitempty"`
+}
+
+func (cause CancelCause) String() string {
+	switch cause.By {
+	case telemetry.CanceledByUser:
+		return "canceled by user"
+	case telemetry.CanceledByTimeout:
+		return "timed out"
+	case CanceledByFailure:
+		if cause.From != "" {
+			return cause.From + " failed"
+		}
+		return "canceled after a failure"
+	}
+	return "canceled"
+}
+
+// CancelCause returns why the span was canceled, following the cancellation
+// back to where it came from like failures are: through the effects the span
+// installed, and down from its parents.
+func (span *Span) CancelCause() CancelCause {
+	if span.Final {
+		if span.CancelCause_ != nil {
+			return *span.CancelCause_
+		}
+		return CancelCause{}
+	}
+	if !span.IsCanceled() {
+		return CancelCause{}
+	}
+	return span.cancelCause()
+}
+
+func (span *Span) cancelCause() CancelCause {
+	if span.CanceledBy != "" {
+		return CancelCause{By: span.CanceledBy}
+	}
+	for _, effect := range span.EffectIDs {
+		effectSpans := span.db.EffectSpans[effect]
+		if effectSpans == nil {
+			continue
+		}
+		for _, effectSpan := range effectSpans.Order {
+			if !effectSpan.Canceled {
+				continue
+			}
+			if cause := effectSpan.cancelCause(); cause.By != "" {
+				if cause.From == "" {
+					cause.From = effectSpan.Name
+				}
+				return cause
+			}
+		}
+	}
+	for parent := span.ParentSpan; parent != nil; parent = parent.ParentSpan {
+		if parent.CanceledBy != "" {
+			return CancelCause{By: parent.CanceledBy, From: parent.Name}
+		}
+		// canceled parents fail too, so keep looking for where it came from
+		if parent.IsFailed() && !parent.Canceled {
+			return CancelCause{By: CanceledByFailure, From: parent.Name}
+		}
+	}
+	return CancelCause{}
+}
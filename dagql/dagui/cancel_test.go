@@ -0,0 +1,73 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"dagger.io/dagger/telemetry"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestCancelCause(t *testing.T) {
+	span := testTrace{start: time.Now().Add(-time.Hour).Truncate(time.Second)}.span
+	failed := sdktrace.Status{Code: codes.Error}
+
+	root := span(1, 0, "dagger call test", time.Second, time.Minute)
+
+	// a sibling failing takes down the rest of its parent's work
+	test := span(2, 1, "test", 2*time.Second, time.Minute)
+	test.Status = failed
+	unit := span(3, 2, "unit", 3*time.Second, time.Minute)
+	unit.Status = failed
+	lint := span(4, 2, "lint", 4*time.Second, time.Minute)
+	lint.Canceled = true
+	lint.Status = failed
+
+	// the user canceled a call, and the span that installed its effect
+	deploy := span(5, 1, "deploy", 5*time.Second, time.Minute)
+	deploy.Canceled = true
+	deploy.CanceledBy = telemetry.CanceledByUser
+	deploy.Status = failed
+	push := span(6, 5, "push", 6*time.Second, time.Minute)
+	push.Canceled = true
+	push.Status = failed
+	exec := span(7, 1, "exec", 7*time.Second, time.Minute)
+	exec.EffectID = "push"
+	exec.Canceled = true
+	exec.CanceledBy = telemetry.CanceledByTimeout
+	publish := span(8, 1, "publish", 8*time.Second, time.Minute)
+	publish.EffectIDs = []string{"push"}
+
+	// nothing to blame
+	gone := span(9, 1, "gone", 9*time.Second, time.Minute)
+	gone.Canceled = true
+
+	db := NewDB()
+	db.ImportSnapshots([]SpanSnapshot{root, test, unit, lint, deploy, push, exec, publish, gone})
+	get := func(id byte) *Span {
+		return db.Spans.Map[testSpanID(id)]
+	}
+
+	require.Equal(t, CancelCause{}, get(3).CancelCause(), "failed spans aren't canceled")
+	require.Equal(t, CancelCause{By: CanceledByFailure, From: "test"}, get(4).CancelCause())
+	require.Equal(t, "test failed", get(4).CancelCause().String())
+	require.Equal(t, CancelCause{By: telemetry.CanceledByUser}, get(5).CancelCause())
+	require.Equal(t, CancelCause{By: telemetry.CanceledByUser, From: "deploy"}, get(6).CancelCause())
+	require.Equal(t, "canceled by user", get(6).CancelCause().String())
+
+	// cancellation propagates through effects
+	require.True(t, get(8).IsCanceled())
+	require.Equal(t, CancelCause{By: telemetry.CanceledByTimeout, From: "exec"}, get(8).CancelCause())
+	require.Equal(t, "timed out", get(8).CancelCause().String())
+
+	require.True(t, get(9).IsCanceled())
+	require.Equal(t, CancelCause{}, get(9).CancelCause())
+	require.Equal(t, "canceled", get(9).CancelCause().String())
+
+	// the cause is kept in snapshots
+	var final Span
+	final.SpanSnapshot = get(4).Snapshot()
+	require.Equal(t, CancelCause{By: CanceledByFailure, From: "test"}, final.CancelCause())
+}
@@ -0,0 +1,144 @@
+package dagui
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+	"time"
+)
+
+// ChromeTrace is a trace in the Chrome Trace Event format, which can be
+// opened in chrome://tracing or ui.perfetto.dev.
+//
+// See https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU.
+type ChromeTrace struct {
+	TraceEvents     []ChromeTraceEvent `json:"traceEvents"`
+	DisplayTimeUnit string             `json:"displayTimeUnit"`
+}
+
+// ChromeTraceEvent is an event of a ChromeTrace.
+type ChromeTraceEvent struct {
+	Name string `json:"name"`
+	Cat  string `json:"cat,omitempty"`
+	// Ph is the phase of the event: "X" for a complete event, i.e. a span,
+	// or "M" for metadata.
+	Ph  string `json:"ph"`
+	Pid int    `json:"pid"`
+	Tid int    `json:"tid"`
+	// Ts and Dur are in microseconds.
+	Ts   int64          `json:"ts"`
+	Dur  int64          `json:"dur,omitempty"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+// chromeLane is a thread of a ChromeTrace. Viewers expect the complete events
+// of a thread to nest, so spans that overlap their siblings, e.g. parallel
+// steps, are placed on lanes of their own.
+type chromeLane struct {
+	// open are the spans placed on the lane that are still open as of the
+	// last span placed, outermost first.
+	open []*VisibleSpan
+}
+
+// ChromeTrace converts the visible tree of spans to the Chrome Trace Event
+// format, with a complete event for each span, its category being the span's
+// status.
+func (db *DB) ChromeTrace(opts FrontendOpts) *ChromeTrace {
+	tree := db.VisibleTree(opts)
+
+	// running spans are shown as running until the last time seen
+	var latest time.Time
+	var findLatest func([]*VisibleSpan)
+	findLatest = func(spans []*VisibleSpan) {
+		for _, span := range spans {
+			if span.StartTime.After(latest) {
+				latest = span.StartTime
+			}
+			if span.EndTime != nil && span.EndTime.After(latest) {
+				latest = *span.EndTime
+			}
+			findLatest(span.Children)
+		}
+	}
+	findLatest(tree)
+	end := func(span *VisibleSpan) time.Time {
+		if span.EndTime == nil {
+			return latest
+		}
+		return *span.EndTime
+	}
+
+	trace := &ChromeTrace{
+		TraceEvents:     []ChromeTraceEvent{},
+		DisplayTimeUnit: "ms",
+	}
+	var lanes []*chromeLane
+	// place finds a lane where the span nests within its parent, creating
+	// one if there is none.
+	place := func(span, parent *VisibleSpan) int {
+		for i, lane := range lanes {
+			// close the spans that ended before this one started
+			for len(lane.open) > 0 && !end(lane.open[len(lane.open)-1]).After(span.StartTime) {
+				lane.open = lane.open[:len(lane.open)-1]
+			}
+			fits := len(lane.open) == 0
+			if parent != nil {
+				fits = len(lane.open) > 0 && lane.open[len(lane.open)-1] == parent
+			}
+			if fits {
+				lane.open = append(lane.open, span)
+				return i
+			}
+		}
+		lanes = append(lanes, &chromeLane{open: []*VisibleSpan{span}})
+		return len(lanes) - 1
+	}
+	var walk func(spans []*VisibleSpan, parent *VisibleSpan)
+	walk = func(spans []*VisibleSpan, parent *VisibleSpan) {
+		spans = slices.Clone(spans)
+		slices.SortStableFunc(spans, func(a, b *VisibleSpan) int {
+			return a.StartTime.Compare(b.StartTime)
+		})
+		for _, span := range spans {
+			lane := place(span, parent)
+			args := map[string]any{
+				"id": span.ID.String(),
+			}
+			if span.CallDigest != "" {
+				args["call"] = span.CallDigest
+			}
+			if span.Error != "" {
+				args["error"] = span.Error
+			}
+			for name, val := range span.Attributes {
+				args[name] = val
+			}
+			trace.TraceEvents = append(trace.TraceEvents, ChromeTraceEvent{
+				Name: span.Name,
+				Cat:  span.Status,
+				Ph:   "X",
+				Pid:  1,
+				Tid:  lane + 1,
+				Ts:   span.StartTime.UnixMicro(),
+				Dur:  max(end(span).Sub(span.StartTime).Microseconds(), 1),
+				Args: args,
+			})
+			walk(span.Children, span)
+		}
+	}
+	walk(tree, nil)
+
+	trace.TraceEvents = append(trace.TraceEvents, ChromeTraceEvent{
+		Name: "process_name",
+		Ph:   "M",
+		Pid:  1,
+		Args: map[string]any{"name": "dagger"},
+	})
+	return trace
+}
+
+// WriteChromeTrace writes the visible tree of spans in the Chrome Trace Event
+// format. See ChromeTrace.
+func (db *DB) WriteChromeTrace(w io.Writer, opts FrontendOpts) error {
+	return json.NewEncoder(w).Encode(db.ChromeTrace(opts))
+}
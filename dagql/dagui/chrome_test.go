@@ -0,0 +1,52 @@
+package dagui
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestChromeTrace(t *testing.T) {
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	span := testTrace{start: start}.span
+	build := span(2, 1, "build", 0, 4*time.Second)
+	test := span(3, 1, "test", time.Second, 3*time.Second)
+	test.Status = sdktrace.Status{Code: codes.Error, Description: "boom"}
+	db := NewDB()
+	db.SetPrimarySpan(testSpanID(1))
+	db.ImportSnapshots([]SpanSnapshot{
+		span(1, 0, "run", 0, 5*time.Second),
+		build,
+		test,
+		span(4, 2, "compile", 0, 2*time.Second),
+		span(5, 2, "link", 2*time.Second, 4*time.Second),
+	})
+
+	opts := FrontendOpts{ZoomedSpan: testSpanID(1), Verbosity: ExpandCompletedVerbosity}
+	var buf bytes.Buffer
+	require.NoError(t, db.WriteChromeTrace(&buf, opts))
+	var ct ChromeTrace
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &ct))
+	require.Equal(t, "ms", ct.DisplayTimeUnit)
+
+	byName := map[string]ChromeTraceEvent{}
+	for _, ev := range ct.TraceEvents {
+		byName[ev.Name] = ev
+	}
+	require.Equal(t, "X", byName["build"].Ph)
+	require.Equal(t, start.UnixMicro(), byName["build"].Ts)
+	require.Equal(t, (4 * time.Second).Microseconds(), byName["build"].Dur)
+	// children nest on their parent's lane, one after the other
+	require.Equal(t, byName["build"].Tid, byName["compile"].Tid)
+	require.Equal(t, byName["build"].Tid, byName["link"].Tid)
+	// parallel steps get a lane of their own
+	require.NotEqual(t, byName["build"].Tid, byName["test"].Tid)
+	require.Equal(t, "failed", byName["test"].Cat)
+	require.Equal(t, "boom", byName["test"].Args["error"])
+	require.Equal(t, "M", byName["process_name"].Ph)
+}
@@ -0,0 +1,41 @@
+package dagui
+
+import (
+	"dagger.io/dagger/telemetry"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// SpanConnections counts the connections made through a tunnel to the host
+// run by a span, e.g. a reverse tunnel for callbacks to a local server.
+type SpanConnections struct {
+	// Total is the number of connections accepted so far.
+	Total int64 `json:",omitempty"`
+
+	// Active is the number of connections still open.
+	Active int64 `json:",omitempty"`
+}
+
+// recordConnections records a sample of a metric as the connections of the
+// tunnel it was sampled for, if it's a tunnel metric. The metrics are
+// current values, so the latest sample wins.
+func (db *DB) recordConnections(name string, point metricdata.DataPoint[int64]) {
+	if name != telemetry.TunnelConnections && name != telemetry.TunnelActiveConnections {
+		return
+	}
+	span := db.metricSpan(point)
+	if span == nil {
+		return
+	}
+	conns := span.Connections
+	if conns == nil {
+		conns = &SpanConnections{}
+	}
+	switch name {
+	case telemetry.TunnelConnections:
+		conns.Total = point.Value
+	case telemetry.TunnelActiveConnections:
+		conns.Active = point.Value
+	}
+	span.Connections = conns
+	db.update(span)
+}
@@ -0,0 +1,51 @@
+package dagui
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dagger.io/dagger/telemetry"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSpanConnections(t *testing.T) {
+	traceID := TraceID{TraceID: trace.TraceID{1}}
+	tunnel := SpanID{SpanID: trace.SpanID{1}}
+	db := NewDB()
+	db.ImportSnapshots([]SpanSnapshot{{
+		ID:        tunnel,
+		TraceID:   traceID,
+		Name:      "tunnel tcp 3000 -> 3000",
+		StartTime: time.Now(),
+	}})
+
+	attrs := attribute.NewSet(
+		attribute.String(telemetry.DagDigestAttr, "xxh3:tunnel"),
+		attribute.String(telemetry.MetricsSpanIDAttr, tunnel.String()),
+	)
+	gauge := func(name string, values ...int64) metricdata.Metrics {
+		var points []metricdata.DataPoint[int64]
+		for _, val := range values {
+			points = append(points, metricdata.DataPoint[int64]{Attributes: attrs, Value: val})
+		}
+		return metricdata.Metrics{Name: name, Data: metricdata.Gauge[int64]{DataPoints: points}}
+	}
+	require.NoError(t, db.MetricExporter().Export(context.Background(), &metricdata.ResourceMetrics{
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Metrics: []metricdata.Metrics{
+				gauge(telemetry.TunnelConnections, 0, 2, 3),
+				gauge(telemetry.TunnelActiveConnections, 0, 2, 1),
+				gauge(telemetry.MemoryCurrentBytes, 2048),
+			},
+		}},
+	}))
+
+	span := db.Spans.Map[tunnel]
+	require.Equal(t, &SpanConnections{Total: 3, Active: 1}, span.Connections)
+	require.Nil(t, span.Resources)
+	require.Equal(t, span.Connections, span.Snapshot().Connections)
+}
@@ -0,0 +1,72 @@
+package dagui
+
+import "time"
+
+// CriticalPath returns the chain of spans that determined the wall-clock time
+// of the run, ordered by start time, along with the spans they ran under.
+// Speeding up spans off the path wouldn't make the run any faster.
+//
+// The path is found by walking back from the end of the primary span: the
+// child or effect that finished last is what the span was waiting on, so it's
+// on the path, along with its own critical path. The walk then continues
+// with whatever finished last before that span started, until the start of
+// the span is reached.
+func (db *DB) CriticalPath() SpanSet {
+	path := NewSpanSet()
+	primary := db.Spans.Map[db.PrimarySpan]
+	if primary == nil {
+		return path
+	}
+	now := time.Now()
+	var walk func(span *Span, end time.Time)
+	walk = func(span *Span, end time.Time) {
+		path.Add(span)
+		deps := criticalDeps(span)
+		// children can end after their parent, e.g. due to clock skew, so
+		// the first one is clamped to the parent's end; after that, only
+		// spans that finished before the next one started could've held it
+		// up
+		first := true
+		for end.After(span.StartTime) {
+			var next *Span
+			var nextEnd time.Time
+			for _, dep := range deps.Order {
+				if path.Map[dep.ID] != nil || !dep.Received || !dep.StartTime.Before(end) {
+					continue
+				}
+				depEnd := dep.EndTimeOrFallback(now)
+				if depEnd.After(end) {
+					if !first {
+						continue
+					}
+					depEnd = end
+				}
+				if next == nil || depEnd.After(nextEnd) ||
+					(depEnd.Equal(nextEnd) && dep.StartTime.Before(next.StartTime)) {
+					next, nextEnd = dep, depEnd
+				}
+			}
+			if next == nil {
+				return
+			}
+			walk(next, nextEnd)
+			end = next.StartTime
+			first = false
+		}
+	}
+	walk(primary, primary.EndTimeOrFallback(now))
+	return path
+}
+
+// criticalDeps returns the spans that a span waits on: its children,
+// including effects linked to it, and effects it installed.
+func criticalDeps(span *Span) SpanSet {
+	deps := NewSpanSet()
+	for _, child := range span.ChildSpans.Order {
+		deps.Add(child)
+	}
+	for effect := range span.EffectSpans {
+		deps.Add(effect)
+	}
+	return deps
+}
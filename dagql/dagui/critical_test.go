@@ -0,0 +1,32 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCriticalPath(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	span := testTrace{start: start}.span
+
+	db := NewDB()
+	db.SetPrimarySpan(testSpanID(1))
+	db.ImportSnapshots([]SpanSnapshot{
+		span(1, 0, "run", 0, 10*time.Second),
+		span(2, 1, "fetch", 100*time.Millisecond, time.Second),
+		span(3, 1, "lint", 0, 500*time.Millisecond),
+		span(4, 1, "build", time.Second, 9*time.Second),
+		span(5, 4, "compile", 1100*time.Millisecond, 5*time.Second),
+		span(6, 4, "vet", 2*time.Second, 3*time.Second),
+		span(7, 4, "link", 5*time.Second, 9*time.Second),
+		span(8, 1, "publish", 9*time.Second, 10*time.Second),
+	})
+
+	var names []string
+	for _, span := range db.CriticalPath().Order {
+		names = append(names, span.Name)
+	}
+	require.Equal(t, []string{"run", "fetch", "build", "compile", "link", "publish"}, names)
+}
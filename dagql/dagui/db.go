@@ -14,6 +14,7 @@ import (
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 
 	"dagger.io/dagger/telemetry"
 	"github.com/dagger/dagger/dagql/call/callpbv1"
@@ -24,6 +25,9 @@ type DB struct {
 	PrimarySpan SpanID
 	PrimaryLogs map[SpanID][]sdklog.Record
 
+	// LogTails holds the last LogTailSize bytes of each span's logs.
+	LogTails map[SpanID][]byte
+
 	Epoch, End time.Time
 
 	Spans    *OrderedSet[SpanID, *Span]
@@ -31,11 +35,26 @@ type DB struct {
 
 	Resources map[attribute.Distinct]*resource.Resource
 
+	// Labels describe the session, e.g. its branch, PR, and the user who
+	// triggered it, so that its trace can be found later. See
+	// telemetry.SessionLabelPrefix.
+	Labels map[string]string
+
+	// Sources holds the distinct processes that emitted spans, e.g. the CLI
+	// and each engine it connected to, in the order they were first seen.
+	Sources []TraceSource
+
+	// RawSpans holds the telemetry received for each span, for debugging.
+	RawSpans map[SpanID]*RawSpan
+
 	Calls     map[string]*callpbv1.Call
 	Outputs   map[string]map[string]struct{}
 	OutputOf  map[string]map[string]struct{}
 	Intervals map[string]map[time.Time]*Span
 
+	// Pauses holds the spans covering times when the pipeline was paused.
+	Pauses SpanSet
+
 	CauseSpans  map[string]SpanSet
 	EffectSpans map[string]SpanSet
 
@@ -52,31 +71,76 @@ type DB struct {
 	// or status were modified via a child or linked span.
 	updatedSpans SpanSet
 
+	// store persists the DB's data as it's received, if set with SetStore.
+	store DBStore
+
+	// unstoredSpans is the set of spans updated since they were last written
+	// to the store.
+	unstoredSpans SpanSet
+
+	// anomalies holds problems found with the telemetry as it was received,
+	// and anomalyCounts counts them by kind. See Anomalies.
+	anomalies     []Anomaly
+	anomalyCounts map[AnomalyKind]int
+
+	// correctedSpans is the set of spans whose times were corrected. See
+	// CorrectedSpans.
+	correctedSpans map[SpanID]struct{}
+
 	// seenSpans keeps track of which spans have been observed via
 	// UpdatedSnapshots so that we can know whether we need to send them when we
 	// finally see them
 	seenSpans map[SpanID]struct{}
+
+	// logicalTexts caches the normalized text of calls, for deriving their
+	// logical IDs
+	logicalTexts map[string]string
+
+	// retention limits the spans kept in memory, and pruned counts the
+	// statuses of the spans it pruned. See Prune.
+	retention   RetentionPolicy
+	pruned      SpanCounts
+	prunedSpans int
+
+	// subtreeVerbosity overrides the verbosity of subtrees, keyed by call
+	// digest or span ID. See SetSubtreeVerbosity.
+	subtreeVerbosity map[string]int
+
+	// clockSkew holds the estimated offset of the clock of each resource that
+	// exported spans. See correctClockSkew.
+	clockSkew map[attribute.Distinct]time.Duration
+
+	// callCounts caches the counts of the run's calls, which are cleared
+	// whenever a span is integrated or pruned. See SpanCounts.
+	callCounts *callCounts
 }
 
 func NewDB() *DB {
 	return &DB{
 		PrimaryLogs: make(map[SpanID][]sdklog.Record),
+		LogTails:    make(map[SpanID][]byte),
 
 		Spans:     NewSpanSet(),
 		Resources: make(map[attribute.Distinct]*resource.Resource),
+		RawSpans:  make(map[SpanID]*RawSpan),
 
 		Calls:     make(map[string]*callpbv1.Call),
 		OutputOf:  make(map[string]map[string]struct{}),
 		Outputs:   make(map[string]map[string]struct{}),
 		Intervals: make(map[string]map[time.Time]*Span),
+		Pauses:    NewSpanSet(),
 
 		CompletedEffects: make(map[string]bool),
 		FailedEffects:    make(map[string]bool),
 		CauseSpans:       make(map[string]SpanSet),
 		EffectSpans:      make(map[string]SpanSet),
 
-		updatedSpans: NewSpanSet(),
-		seenSpans:    make(map[SpanID]struct{}),
+		updatedSpans:  NewSpanSet(),
+		unstoredSpans: NewSpanSet(),
+		seenSpans:     make(map[SpanID]struct{}),
+		logicalTexts:  make(map[string]string),
+
+		subtreeVerbosity: make(map[string]int),
 	}
 }
 
@@ -135,11 +199,14 @@ func (db *DB) UpdatedSnapshots(filter map[SpanID]bool) []SpanSnapshot {
 func (db *DB) ImportSnapshots(snapshots []SpanSnapshot) {
 	for _, snapshot := range snapshots {
 		span := db.findOrAllocSpan(snapshot.ID)
+		db.validateSpan(span, &snapshot)
 		span.Received = true
 		snapshot.Version += span.Version // don't reset the version
 		span.SpanSnapshot = snapshot
 		db.integrateSpan(span)
 	}
+	db.persistSpans()
+	db.Prune(time.Now())
 }
 
 func (db *DB) update(span *Span) {
@@ -150,6 +217,9 @@ func (db *DB) update(span *Span) {
 	}
 	span.Version++
 	db.updatedSpans.Add(span)
+	if db.store != nil {
+		db.unstoredSpans.Add(span)
+	}
 }
 
 // Matches returns true if the span matches the filter, looking through
@@ -204,6 +274,8 @@ func (db *DB) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) er
 	for _, span := range spans {
 		db.recordOTelSpan(span)
 	}
+	db.persistSpans()
+	db.Prune(time.Now())
 	return nil
 }
 
@@ -216,6 +288,10 @@ type DBLogExporter struct {
 }
 
 func (db DBLogExporter) Export(ctx context.Context, logs []sdklog.Record) error {
+	var tails map[SpanID][]byte
+	if db.store != nil {
+		tails = make(map[SpanID][]byte)
+	}
 	for _, log := range logs {
 		if log.Body().AsString() == "" {
 			// eof; ignore
@@ -226,6 +302,19 @@ func (db DBLogExporter) Export(ctx context.Context, logs []sdklog.Record) error
 			// buffer raw logs so we can replay them later
 			db.PrimaryLogs[spanID] = append(db.PrimaryLogs[spanID], log)
 		}
+		tail := append(db.LogTails[spanID], log.Body().AsString()...)
+		if len(tail) > LogTailSize {
+			tail = tail[len(tail)-LogTailSize:]
+		}
+		db.LogTails[spanID] = tail
+		if tails != nil {
+			tails[spanID] = tail
+		}
+	}
+	if len(tails) > 0 {
+		db.persist(func(store DBStore) error {
+			return store.WriteLogTails(tails)
+		})
 	}
 	return nil
 }
@@ -255,6 +344,10 @@ type DBMetricExporter struct {
 }
 
 func (db DBMetricExporter) Export(ctx context.Context, resourceMetrics *metricdata.ResourceMetrics) error {
+	var newPoints map[string]map[string][]metricdata.DataPoint[int64]
+	if db.store != nil {
+		newPoints = make(map[string]map[string][]metricdata.DataPoint[int64])
+	}
 	for _, scopeMetric := range resourceMetrics.ScopeMetrics {
 		for _, metric := range scopeMetric.Metrics {
 			metricData, ok := metric.Data.(metricdata.Gauge[int64])
@@ -277,9 +370,22 @@ func (db DBMetricExporter) Export(ctx context.Context, resourceMetrics *metricda
 					db.MetricsByCall[callDigest.AsString()] = metricsByName
 				}
 				metricsByName[metric.Name] = append(metricsByName[metric.Name], point)
+				db.recordResources(metric.Name, point)
+				db.recordConnections(metric.Name, point)
+				if newPoints != nil {
+					if newPoints[callDigest.AsString()] == nil {
+						newPoints[callDigest.AsString()] = make(map[string][]metricdata.DataPoint[int64])
+					}
+					newPoints[callDigest.AsString()][metric.Name] = append(newPoints[callDigest.AsString()][metric.Name], point)
+				}
 			}
 		}
 	}
+	if len(newPoints) > 0 {
+		db.persist(func(store DBStore) error {
+			return store.WriteMetrics(newPoints)
+		})
+	}
 
 	return nil
 }
@@ -290,6 +396,9 @@ func (db DBMetricExporter) Export(ctx context.Context, resourceMetrics *metricda
 // to the span it created.
 func (db *DB) SetPrimarySpan(span SpanID) {
 	db.PrimarySpan = span
+	db.persist(func(store DBStore) error {
+		return store.WritePrimarySpan(span)
+	})
 }
 
 func (db *DB) initSpan(spanID SpanID) *Span {
@@ -337,11 +446,33 @@ func (db *DB) recordOTelSpan(span sdktrace.ReadOnlySpan) {
 
 	// create or update the span itself
 	spanData := db.findOrAllocSpan(spanID)
+	incoming := SpanSnapshot{
+		ID:        spanID,
+		TraceID:   TraceID{span.SpanContext().TraceID()},
+		ParentID:  SpanID{span.Parent().SpanID()},
+		Name:      span.Name(),
+		StartTime: span.StartTime(),
+		EndTime:   span.EndTime(),
+	}
+	for _, event := range span.Events() {
+		// only used as hints for correcting the span's times
+		incoming.Events = append(incoming.Events, SpanEvent{Name: event.Name, Time: event.Time})
+	}
+	var clock attribute.Distinct
+	if resource := span.Resource(); resource != nil {
+		clock = resource.Equivalent()
+		db.correctClockSkew(spanData, clock, &incoming)
+	}
+	db.validateSpan(spanData, &incoming)
 	spanData.Received = true
+	spanData.TraceID = incoming.TraceID
 	spanData.ParentID.SpanID = span.Parent().SpanID()
-	spanData.Name = span.Name()
-	spanData.StartTime = span.StartTime()
-	spanData.EndTime = span.EndTime()
+	spanData.Name = incoming.Name
+	spanData.StartTime = incoming.StartTime
+	spanData.EndTime = incoming.EndTime
+	spanData.Corrected = incoming.Corrected
+	spanData.ClockSkew = incoming.ClockSkew
+	spanData.clock = clock
 	spanData.Status = span.Status()
 	spanData.Links = make([]SpanContext, len(span.Links()))
 	for i, link := range span.Links() {
@@ -351,9 +482,44 @@ func (db *DB) recordOTelSpan(span sdktrace.ReadOnlySpan) {
 		}
 	}
 
+	spanData.Events = nil
+	spanData.Exceptions = nil
+	for _, event := range span.Events() {
+		if event.Name == semconv.ExceptionEventName {
+			var exc SpanException
+			for _, attr := range event.Attributes {
+				switch attr.Key {
+				case semconv.ExceptionTypeKey:
+					exc.Type = attr.Value.AsString()
+				case semconv.ExceptionMessageKey:
+					exc.Message = attr.Value.AsString()
+				case semconv.ExceptionStacktraceKey:
+					exc.Stacktrace = attr.Value.AsString()
+				}
+			}
+			spanData.Exceptions = append(spanData.Exceptions, exc)
+			continue
+		}
+		spanData.Events = append(spanData.Events, SpanEvent{
+			Name: event.Name,
+			Time: event.Time.Add(spanData.ClockSkew),
+		})
+	}
+
+	raw := &RawSpan{
+		Attributes: span.Attributes(),
+		Links:      span.Links(),
+		Events:     span.Events(),
+	}
 	if resource := span.Resource(); resource != nil {
+		if _, seen := db.Resources[resource.Equivalent()]; !seen {
+			db.trackLabels(resource)
+		}
 		db.Resources[resource.Equivalent()] = resource
+		raw.Resource = resource.Attributes()
+		spanData.Source = NewTraceSource(resource)
 	}
+	db.RawSpans[spanID] = raw
 
 	// populate snapshot from otel attributes
 	for _, attr := range span.Attributes() {
@@ -533,6 +699,8 @@ func (activity *Activity) mergeIntervals() {
 // integrateSpan takes a possibly newly created span and updates
 // database relationships and state
 func (db *DB) integrateSpan(span *Span) { //nolint: gocyclo
+	db.callCounts = nil
+
 	// track the span's own interval
 	span.Activity.Add(span)
 	db.update(span)
@@ -566,6 +734,12 @@ func (db *DB) integrateSpan(span *Span) { //nolint: gocyclo
 		span.causesViaLinks.Add(linked)
 	}
 
+	if span.Pause {
+		db.Pauses.Add(span)
+	}
+
+	db.trackSource(span)
+
 	// keep track of intervals seen for a digest
 	if span.CallDigest != "" {
 		if db.Intervals[span.CallDigest] == nil {
@@ -639,7 +813,7 @@ func (db *DB) integrateSpan(span *Span) { //nolint: gocyclo
 			db.EffectSpans[span.EffectID] = NewSpanSet()
 		}
 		db.EffectSpans[span.EffectID].Add(span)
-		if span.IsFailed() {
+		if span.IsFailed() && !span.IsFailureTolerated() {
 			db.FailedEffects[span.EffectID] = true
 		}
 		causes := db.CauseSpans[span.EffectID]
@@ -0,0 +1,101 @@
+package dagui
+
+import (
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/dagger/dagger/engine/slog"
+)
+
+// DBStore persists the data received by a DB as it arrives, so that a trace
+// can be rehydrated with RestoreDB if the process recording it crashes or is
+// restarted before the run completes.
+type DBStore interface {
+	// WritePrimarySpan records the trace's primary span.
+	WritePrimarySpan(SpanID) error
+
+	// WriteSpans stores the latest snapshots of spans, replacing any stored
+	// earlier.
+	WriteSpans([]SpanSnapshot) error
+
+	// WriteLogTails stores the tails of spans' logs, replacing any stored
+	// earlier.
+	WriteLogTails(map[SpanID][]byte) error
+
+	// WriteMetrics appends data points received for calls, keyed by call
+	// digest and metric name like DB.MetricsByCall.
+	WriteMetrics(map[string]map[string][]metricdata.DataPoint[int64]) error
+
+	// Read returns everything stored so far.
+	Read() (*StoredDB, error)
+
+	Close() error
+}
+
+// StoredDB is the data read back from a DBStore.
+type StoredDB struct {
+	PrimarySpan   SpanID
+	Spans         []SpanSnapshot
+	LogTails      map[SpanID][]byte
+	MetricsByCall map[string]map[string][]metricdata.DataPoint[int64]
+}
+
+// SetStore starts persisting the DB's data to the store, first writing
+// everything received so far.
+func (db *DB) SetStore(store DBStore) error {
+	if db.PrimarySpan.IsValid() {
+		if err := store.WritePrimarySpan(db.PrimarySpan); err != nil {
+			return err
+		}
+	}
+	if err := store.WriteSpans(snapshotSpans(db.Spans.Order, func(*Span) bool { return true })); err != nil {
+		return err
+	}
+	if err := store.WriteLogTails(db.LogTails); err != nil {
+		return err
+	}
+	if err := store.WriteMetrics(db.MetricsByCall); err != nil {
+		return err
+	}
+	db.store = store
+	db.unstoredSpans = NewSpanSet()
+	return nil
+}
+
+// persist writes to the store, if there is one. Failing to persist doesn't
+// interrupt the run; the store is best-effort.
+func (db *DB) persist(write func(DBStore) error) {
+	if db.store == nil {
+		return
+	}
+	if err := write(db.store); err != nil {
+		slog.Debug("failed to persist trace data", "error", err)
+	}
+}
+
+// persistSpans writes the spans updated since they were last persisted.
+func (db *DB) persistSpans() {
+	if db.store == nil || len(db.unstoredSpans.Order) == 0 {
+		return
+	}
+	snapshots := snapshotSpans(db.unstoredSpans.Order, func(*Span) bool { return true })
+	db.unstoredSpans = NewSpanSet()
+	db.persist(func(store DBStore) error {
+		return store.WriteSpans(snapshots)
+	})
+}
+
+// RestoreDB rehydrates a DB from everything written to a store.
+func RestoreDB(store DBStore) (*DB, error) {
+	stored, err := store.Read()
+	if err != nil {
+		return nil, err
+	}
+	db := NewDB()
+	db.SetPrimarySpan(stored.PrimarySpan)
+	db.ImportSnapshots(stored.Spans)
+	for id, tail := range stored.LogTails {
+		db.LogTails[id] = tail
+	}
+	db.MetricsByCall = stored.MetricsByCall
+	return db, nil
+}
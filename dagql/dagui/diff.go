@@ -0,0 +1,156 @@
+package dagui
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// TraceDiff lists the calls whose behavior changed between two runs, matched
+// by their call digests.
+type TraceDiff struct {
+	// Matched is the number of calls made by both runs.
+	Matched int `json:"matched"`
+
+	// Uncached are calls that were cached in the first run, but not the
+	// second.
+	Uncached []StepDiff `json:"uncached"`
+
+	// Slower and Faster are calls that ran in both runs, and whose duration
+	// changed beyond the tolerance, by decreasing change.
+	Slower []StepDiff `json:"slower"`
+	Faster []StepDiff `json:"faster"`
+
+	// Failed are calls that failed in the second run, but not the first,
+	// including calls the first run didn't make.
+	Failed []StepDiff `json:"failed"`
+}
+
+// StepDiff is a call that changed between two runs.
+type StepDiff struct {
+	Name       string        `json:"name"`
+	CallDigest string        `json:"callDigest"`
+	Before     time.Duration `json:"before"`
+	After      time.Duration `json:"after"`
+}
+
+// Changed reports whether any call changed between the runs.
+func (diff TraceDiff) Changed() bool {
+	return len(diff.Uncached) > 0 ||
+		len(diff.Slower) > 0 ||
+		len(diff.Faster) > 0 ||
+		len(diff.Failed) > 0
+}
+
+// DiffDBs compares the calls made by two runs, reporting those that stopped
+// being cached, got slower or faster beyond the tolerance, or newly failed.
+func DiffDBs(a, b *DB, tolerance BaselineTolerance) TraceDiff {
+	diff := TraceDiff{
+		Uncached: []StepDiff{},
+		Slower:   []StepDiff{},
+		Faster:   []StepDiff{},
+		Failed:   []StepDiff{},
+	}
+	before := a.spansByCall()
+	for _, after := range b.spansByCall().Order {
+		step := StepDiff{
+			Name:       after.Name,
+			CallDigest: after.CallDigest,
+			After:      after.WallTime(),
+		}
+		prev, matched := before.Map[after.CallDigest]
+		if matched {
+			diff.Matched++
+			step.Before = prev.WallTime()
+		}
+		if after.IsFailed() {
+			if !matched || !prev.IsFailed() {
+				diff.Failed = append(diff.Failed, step)
+			}
+			continue
+		}
+		if !matched || prev.IsFailed() {
+			continue
+		}
+		switch {
+		case prev.IsCached() && !after.IsCached():
+			diff.Uncached = append(diff.Uncached, step)
+		case prev.IsCached() || after.IsCached():
+			// durations of cached calls don't say how long they take to run
+		case exceedsTolerance(step.Before, step.After, tolerance):
+			diff.Slower = append(diff.Slower, step)
+		case exceedsTolerance(step.After, step.Before, tolerance):
+			diff.Faster = append(diff.Faster, step)
+		}
+	}
+	byChange := func(a, b StepDiff) int {
+		return cmp.Compare((b.After - b.Before).Abs(), (a.After - a.Before).Abs())
+	}
+	slices.SortStableFunc(diff.Slower, byChange)
+	slices.SortStableFunc(diff.Faster, byChange)
+	return diff
+}
+
+// exceedsTolerance reports whether a duration increased from base to cur
+// beyond the tolerance.
+func exceedsTolerance(base, cur time.Duration, tolerance BaselineTolerance) bool {
+	delta := cur - base
+	return delta > tolerance.MinDelta &&
+		float64(delta) > float64(base)*tolerance.Ratio
+}
+
+func isDiffable(span *Span) bool {
+	return span.CallDigest != "" &&
+		!span.IsInternal() &&
+		!span.Passthrough &&
+		!span.IsRunningOrEffectsRunning()
+}
+
+// spansByCall returns the first completed span of each call, in order, so
+// that calls made many times are only compared once.
+func (db *DB) spansByCall() *OrderedSet[string, *Span] {
+	spans := NewOrderedSet(func(span *Span) string {
+		return span.CallDigest
+	})
+	for _, span := range db.Spans.Order {
+		if isDiffable(span) {
+			spans.Add(span)
+		}
+	}
+	return spans
+}
+
+// WriteText renders the diff as human-readable tables.
+func (diff TraceDiff) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Compared %d calls made by both runs.\n", diff.Matched)
+	if !diff.Changed() {
+		sb.WriteString("No changes.\n")
+	}
+	section := func(title string, steps []StepDiff, row func(StepDiff) string) {
+		if len(steps) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "\n%s:\n", title)
+		tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		for _, step := range steps {
+			fmt.Fprintf(tw, "  %s\t%s\n", row(step), step.Name)
+		}
+		tw.Flush()
+	}
+	durations := func(step StepDiff) string {
+		return fmt.Sprintf("%s → %s", FormatDuration(step.Before), FormatDuration(step.After))
+	}
+	section("Newly failed", diff.Failed, func(StepDiff) string { return "✘" })
+	section("No longer cached", diff.Uncached, func(step StepDiff) string {
+		return "ran for " + FormatDuration(step.After)
+	})
+	section("Slower", diff.Slower, durations)
+	section("Faster", diff.Faster, durations)
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,91 @@
+package dagui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestDiffDBs(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	type step struct {
+		name   string
+		cached bool
+		failed bool
+		dur    time.Duration
+	}
+	run := func(traceN byte, steps ...step) *DB {
+		traceID := TraceID{TraceID: trace.TraceID{traceN}}
+		var snapshots []SpanSnapshot
+		for i, s := range steps {
+			snapshot := SpanSnapshot{
+				ID:         SpanID{SpanID: trace.SpanID{traceN, byte(i + 1)}},
+				TraceID:    traceID,
+				Name:       s.name,
+				CallDigest: "digest " + s.name,
+				Cached:     s.cached,
+				StartTime:  start.Add(time.Duration(i) * time.Minute),
+				EndTime:    start.Add(time.Duration(i)*time.Minute + s.dur),
+			}
+			if s.failed {
+				snapshot.Status = sdktrace.Status{Code: codes.Error}
+			}
+			snapshots = append(snapshots, snapshot)
+		}
+		db := NewDB()
+		db.ImportSnapshots(snapshots)
+		return db
+	}
+
+	a := run(1,
+		step{name: "unchanged", dur: 10 * time.Second},
+		step{name: "uncached", cached: true, dur: time.Millisecond},
+		step{name: "slower", dur: 10 * time.Second},
+		step{name: "faster", dur: 10 * time.Second},
+		step{name: "noise", dur: 100 * time.Millisecond},
+		step{name: "broke", dur: time.Second},
+		step{name: "still broken", failed: true, dur: time.Second},
+		step{name: "gone", dur: time.Second},
+	)
+	b := run(2,
+		step{name: "unchanged", dur: 10500 * time.Millisecond},
+		step{name: "uncached", dur: 20 * time.Second},
+		step{name: "slower", dur: 30 * time.Second},
+		step{name: "faster", dur: 2 * time.Second},
+		step{name: "noise", dur: 900 * time.Millisecond},
+		step{name: "broke", failed: true, dur: time.Second},
+		step{name: "still broken", failed: true, dur: time.Second},
+		step{name: "new", failed: true, dur: time.Second},
+		// only the first span of a call is compared
+		step{name: "slower", dur: time.Minute},
+	)
+
+	diff := DiffDBs(a, b, BaselineTolerance{Ratio: 0.1, MinDelta: time.Second})
+	require.Equal(t, 7, diff.Matched)
+	require.Equal(t, []StepDiff{
+		{Name: "uncached", CallDigest: "digest uncached", Before: time.Millisecond, After: 20 * time.Second},
+	}, diff.Uncached)
+	require.Equal(t, []StepDiff{
+		{Name: "slower", CallDigest: "digest slower", Before: 10 * time.Second, After: 30 * time.Second},
+	}, diff.Slower)
+	require.Equal(t, []StepDiff{
+		{Name: "faster", CallDigest: "digest faster", Before: 10 * time.Second, After: 2 * time.Second},
+	}, diff.Faster)
+	require.Equal(t, []StepDiff{
+		{Name: "broke", CallDigest: "digest broke", Before: time.Second, After: time.Second},
+		{Name: "new", CallDigest: "digest new", After: time.Second},
+	}, diff.Failed)
+	require.True(t, diff.Changed())
+
+	var sb strings.Builder
+	require.NoError(t, diff.WriteText(&sb))
+	require.Contains(t, sb.String(), "Compared 7 calls made by both runs.")
+	require.Contains(t, sb.String(), "10.0s → 30.0s  slower")
+
+	require.False(t, DiffDBs(a, a, BaselineTolerance{}).Changed())
+}
@@ -223,7 +223,7 @@ func (dag *dotDag) writeTo(out io.Writer) {
 		}
 		label := buf.String()
 
-		duration := vtx.span.Activity.Duration(time.Now())
+		duration := vtx.span.ActiveDuration(time.Now())
 		label += fmt.Sprintf("\n%s", duration)
 
 		thicc := false
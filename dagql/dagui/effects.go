@@ -0,0 +1,186 @@
+package dagui
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// EffectGraph shows how the effects of a run were installed and satisfied,
+// i.e. which spans installed each effect, e.g. a call returning a lazily
+// evaluated container, and which spans ran it, e.g. the exec. Spans stay
+// pending until their effects start, so this is how to find out why one is
+// stuck pending.
+type EffectGraph struct {
+	// Counts are the number of effects in each state.
+	Counts map[EffectState]int `json:"counts"`
+
+	// Effects are all the effects, pending ones first.
+	Effects []EffectNode `json:"effects"`
+}
+
+// EffectState is the state of an effect.
+type EffectState string
+
+const (
+	// EffectPending is an effect that was installed but hasn't started, nor
+	// been reported as completed. Spans that installed it are shown as
+	// pending.
+	EffectPending EffectState = "pending"
+	// EffectRunning is an effect that's running.
+	EffectRunning EffectState = "running"
+	// EffectFailed is an effect that failed.
+	EffectFailed EffectState = "failed"
+	// EffectCompleted is an effect that completed, whether or not its spans
+	// were seen, e.g. if it was cached deep down.
+	EffectCompleted EffectState = "completed"
+)
+
+var effectStateOrder = []EffectState{
+	EffectPending,
+	EffectRunning,
+	EffectFailed,
+	EffectCompleted,
+}
+
+// EffectNode is an effect of an EffectGraph.
+type EffectNode struct {
+	ID    string      `json:"id"`
+	State EffectState `json:"state"`
+
+	// InstalledBy are the spans that installed the effect.
+	InstalledBy []EffectSpan `json:"installedBy"`
+
+	// SatisfiedBy are the spans that ran the effect.
+	SatisfiedBy []EffectSpan `json:"satisfiedBy"`
+
+	// Reported is whether a span reported the effect as completed.
+	Reported bool `json:"reported"`
+}
+
+// EffectSpan is a span installing or satisfying an effect.
+type EffectSpan struct {
+	ID        SpanID    `json:"id"`
+	Name      string    `json:"name"`
+	StartTime time.Time `json:"startTime"`
+	Status    string    `json:"status"`
+}
+
+func newEffectSpans(set SpanSet) []EffectSpan {
+	spans := []EffectSpan{}
+	if set == nil {
+		return spans
+	}
+	for _, span := range set.Order {
+		spans = append(spans, EffectSpan{
+			ID:        span.ID,
+			Name:      span.Name,
+			StartTime: span.StartTime,
+			Status:    spanStatus(span),
+		})
+	}
+	return spans
+}
+
+// EffectGraph collects the effects installed and satisfied by the spans of
+// the run.
+func (db *DB) EffectGraph() EffectGraph {
+	ids := map[string]struct{}{}
+	for id := range db.CauseSpans {
+		ids[id] = struct{}{}
+	}
+	for id := range db.EffectSpans {
+		ids[id] = struct{}{}
+	}
+	for id := range db.CompletedEffects {
+		ids[id] = struct{}{}
+	}
+
+	graph := EffectGraph{
+		Counts:  map[EffectState]int{},
+		Effects: []EffectNode{},
+	}
+	for _, id := range slices.Sorted(maps.Keys(ids)) {
+		node := EffectNode{
+			ID:          id,
+			InstalledBy: newEffectSpans(db.CauseSpans[id]),
+			SatisfiedBy: newEffectSpans(db.EffectSpans[id]),
+			Reported:    db.CompletedEffects[id],
+		}
+		var running bool
+		if set := db.EffectSpans[id]; set != nil {
+			running = slices.ContainsFunc(set.Order, (*Span).IsRunning)
+		}
+		switch {
+		case db.FailedEffects[id]:
+			node.State = EffectFailed
+		case running:
+			node.State = EffectRunning
+		case node.Reported || len(node.SatisfiedBy) > 0:
+			node.State = EffectCompleted
+		default:
+			node.State = EffectPending
+		}
+		graph.Counts[node.State]++
+		graph.Effects = append(graph.Effects, node)
+	}
+	slices.SortStableFunc(graph.Effects, func(a, b EffectNode) int {
+		if c := cmp.Compare(
+			slices.Index(effectStateOrder, a.State),
+			slices.Index(effectStateOrder, b.State),
+		); c != 0 {
+			return c
+		}
+		return firstStart(a.InstalledBy).Compare(firstStart(b.InstalledBy))
+	})
+	return graph
+}
+
+func firstStart(spans []EffectSpan) time.Time {
+	var first time.Time
+	for _, span := range spans {
+		if first.IsZero() || span.StartTime.Before(first) {
+			first = span.StartTime
+		}
+	}
+	return first
+}
+
+// WriteText renders the graph as human-readable text, listing the effects
+// that didn't complete, and summarizing the ones that did.
+func (graph EffectGraph) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	counts := make([]string, len(effectStateOrder))
+	for i, state := range effectStateOrder {
+		counts[i] = fmt.Sprintf("%d %s", graph.Counts[state], state)
+	}
+	fmt.Fprintf(&sb, "Effects: %s\n", strings.Join(counts, ", "))
+	writeSpans := func(label string, spans []EffectSpan) {
+		if len(spans) == 0 {
+			fmt.Fprintf(&sb, "    %s: none\n", label)
+			return
+		}
+		for i, span := range spans {
+			if i == 0 {
+				fmt.Fprintf(&sb, "    %s: ", label)
+			} else {
+				fmt.Fprintf(&sb, "    %s  ", strings.Repeat(" ", len(label)))
+			}
+			fmt.Fprintf(&sb, "%s (%s, %s)\n", span.Name, span.ID, span.Status)
+		}
+	}
+	for _, effect := range graph.Effects {
+		if effect.State == EffectCompleted {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n  %s %s\n", effect.State, effect.ID)
+		writeSpans("installed by", effect.InstalledBy)
+		writeSpans("satisfied by", effect.SatisfiedBy)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
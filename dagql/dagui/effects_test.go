@@ -0,0 +1,61 @@
+package dagui
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestEffectGraph(t *testing.T) {
+	span := testTrace{start: time.Now().Add(-time.Hour).Truncate(time.Second)}.span
+	withExec := span(1, 0, "withExec", 0, time.Second)
+	withExec.EffectIDs = []string{"ran", "stuck"}
+	ran := span(2, 0, "exec go build", time.Second, 2*time.Second)
+	ran.EffectID = "ran"
+	withFile := span(3, 0, "withFile", 2*time.Second, 3*time.Second)
+	withFile.EffectIDs = []string{"cached"}
+	withFile.EffectsCompleted = []string{"cached"}
+	failed := span(4, 0, "exec go test", 3*time.Second, 4*time.Second)
+	failed.EffectID = "failed"
+	failed.Status = sdktrace.Status{Code: codes.Error}
+
+	db := NewDB()
+	db.ImportSnapshots([]SpanSnapshot{withExec, ran, withFile, failed})
+
+	graph := db.EffectGraph()
+	require.Equal(t, map[EffectState]int{
+		EffectPending:   1,
+		EffectFailed:    1,
+		EffectCompleted: 2,
+	}, graph.Counts)
+
+	// pending effects come first, naming the spans waiting on them
+	stuck := graph.Effects[0]
+	require.Equal(t, "stuck", stuck.ID)
+	require.Equal(t, EffectPending, stuck.State)
+	require.Len(t, stuck.InstalledBy, 1)
+	require.Equal(t, "withExec", stuck.InstalledBy[0].Name)
+	require.Empty(t, stuck.SatisfiedBy)
+
+	byID := map[string]EffectNode{}
+	for _, effect := range graph.Effects {
+		byID[effect.ID] = effect
+	}
+	require.Equal(t, EffectCompleted, byID["ran"].State)
+	require.Equal(t, "exec go build", byID["ran"].SatisfiedBy[0].Name)
+	// completed effects may never have been seen, e.g. if deeply cached
+	require.Equal(t, EffectCompleted, byID["cached"].State)
+	require.True(t, byID["cached"].Reported)
+	require.Equal(t, EffectFailed, byID["failed"].State)
+
+	var buf bytes.Buffer
+	require.NoError(t, graph.WriteText(&buf))
+	require.Contains(t, buf.String(), "Effects: 1 pending, 0 running, 1 failed, 2 completed")
+	require.Contains(t, buf.String(), "pending stuck\n    installed by: withExec")
+	require.Contains(t, buf.String(), "satisfied by: none")
+	require.NotContains(t, buf.String(), "completed ran")
+}
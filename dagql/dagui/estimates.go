@@ -0,0 +1,309 @@
+package dagui
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const traceDurationsFilename = "durations.json"
+
+// durationHistoryMaxAge is how long an entry is kept in the duration history
+// without being seen again.
+const durationHistoryMaxAge = 30 * 24 * time.Hour
+
+// durationHistoryMaxEntries is how many entries are kept in each of the
+// duration history's maps, dropping the least recently seen.
+const durationHistoryMaxEntries = 5000
+
+// DurationHistory records how long spans took in previous runs, so that the
+// time remaining for running spans can be estimated.
+type DurationHistory struct {
+	// ByCall maps call digests to their durations.
+	ByCall map[string]DurationEstimate
+
+	// ByLogical maps the logical IDs of calls to their durations, as a
+	// fallback for when a call's digest changes between runs, e.g. due to
+	// changed inputs.
+	ByLogical map[string]DurationEstimate
+
+	// ByName maps span names to their durations, as a last resort for spans
+	// that aren't calls.
+	ByName map[string]DurationEstimate
+
+	// ByEffect maps effect IDs to their durations, for estimating effects
+	// that have not started yet and so have no span.
+	ByEffect map[string]DurationEstimate
+}
+
+// DurationEstimate is the expected duration of a span.
+type DurationEstimate struct {
+	Duration time.Duration
+
+	// Seen is when the span last ran.
+	Seen time.Time
+}
+
+func NewDurationHistory() *DurationHistory {
+	return &DurationHistory{
+		ByCall:    map[string]DurationEstimate{},
+		ByLogical: map[string]DurationEstimate{},
+		ByName:    map[string]DurationEstimate{},
+		ByEffect:  map[string]DurationEstimate{},
+	}
+}
+
+// Record updates the history with the durations of the DB's completed spans.
+//
+// Cached and failed spans are skipped, since they say little about how long
+// the span takes to actually run.
+func (hist *DurationHistory) Record(db *DB) {
+	for _, span := range db.Spans.Order {
+		if span.IsRunningOrEffectsRunning() ||
+			span.IsCached() ||
+			span.IsFailedOrCausedFailure() ||
+			span.IsCanceled() {
+			continue
+		}
+		dur := span.ActiveDuration(span.EndTime)
+		seen := span.EndTime
+		if span.CallDigest != "" {
+			hist.ByCall[span.CallDigest] = hist.ByCall[span.CallDigest].update(dur, seen)
+		}
+		if id := span.logicalID(); id != "" {
+			hist.ByLogical[id] = hist.ByLogical[id].update(dur, seen)
+		}
+		hist.ByName[span.Name] = hist.ByName[span.Name].update(dur, seen)
+		if span.EffectID != "" {
+			hist.ByEffect[span.EffectID] = hist.ByEffect[span.EffectID].update(dur, seen)
+		}
+	}
+}
+
+func (est DurationEstimate) update(dur time.Duration, seen time.Time) DurationEstimate {
+	if est.Duration > 0 {
+		// weigh previous runs equally with the latest run, so that the
+		// estimate adapts quickly without being thrown off by one outlier
+		dur = (est.Duration + dur) / 2
+	}
+	return DurationEstimate{
+		Duration: dur,
+		Seen:     seen,
+	}
+}
+
+// Estimate returns the expected duration of the span, if it has run before.
+func (hist *DurationHistory) Estimate(span *Span) (time.Duration, bool) {
+	if hist == nil {
+		return 0, false
+	}
+	if span.CallDigest != "" {
+		if est, ok := hist.ByCall[span.CallDigest]; ok {
+			return est.Duration, true
+		}
+	}
+	if id := span.logicalID(); id != "" {
+		if est, ok := hist.ByLogical[id]; ok {
+			return est.Duration, true
+		}
+	}
+	if est, ok := hist.ByName[span.Name]; ok {
+		return est.Duration, true
+	}
+	return 0, false
+}
+
+// ETA estimates the time remaining for a running span, along with its
+// progress as a fraction between 0 and 1.
+//
+// A span can't complete before its running children, so the estimate is
+// extended to cover the child that is expected to take the longest.
+func (hist *DurationHistory) ETA(span *Span, now time.Time) (time.Duration, float64, bool) {
+	total, ok := hist.Estimate(span)
+	if !ok {
+		return 0, 0, false
+	}
+	elapsed := span.ActiveDuration(now)
+	remaining := total - elapsed
+	for _, child := range span.ChildSpans.Order {
+		if !child.IsRunningOrEffectsRunning() {
+			continue
+		}
+		if childRemaining, _, ok := hist.ETA(child, now); ok {
+			remaining = max(remaining, childRemaining)
+		}
+	}
+	remaining = max(remaining, 0)
+	progress := float64(elapsed) / float64(elapsed+remaining)
+	// never claim to be done while still running
+	progress = min(progress, 0.99)
+	return remaining, progress, true
+}
+
+func (hist *DurationHistory) prune(now time.Time) {
+	for _, entries := range []map[string]DurationEstimate{hist.ByCall, hist.ByLogical, hist.ByName, hist.ByEffect} {
+		for key, est := range entries {
+			if now.Sub(est.Seen) > durationHistoryMaxAge {
+				delete(entries, key)
+			}
+		}
+		if len(entries) <= durationHistoryMaxEntries {
+			continue
+		}
+		keys := make([]string, 0, len(entries))
+		for key := range entries {
+			keys = append(keys, key)
+		}
+		slices.SortFunc(keys, func(a, b string) int {
+			return entries[b].Seen.Compare(entries[a].Seen)
+		})
+		for _, key := range keys[durationHistoryMaxEntries:] {
+			delete(entries, key)
+		}
+	}
+}
+
+// RunEstimate estimates the work remaining for a run.
+type RunEstimate struct {
+	// Remaining is the estimated time until the run completes.
+	Remaining time.Duration `json:"remaining"`
+
+	// Progress is the run's estimated progress, between 0 and 1.
+	Progress float64 `json:"progress"`
+
+	// RemainingWork is the total estimated time of all running and pending
+	// steps, regardless of whether they run in parallel.
+	RemainingWork time.Duration `json:"remainingWork"`
+
+	// Running and Pending are the number of steps running and waiting to run.
+	Running int `json:"running"`
+	Pending int `json:"pending"`
+
+	// Unknown is the number of running or pending steps with no history,
+	// which are left out of the estimate.
+	Unknown int `json:"unknown"`
+}
+
+// RunETA estimates the time remaining for the whole run, combining the
+// running and pending steps, including effects that have not started yet,
+// with their durations from previous runs.
+//
+// The time remaining is the longer of the run's own estimate and its longest
+// running step followed by its longest pending step, assuming pending steps
+// wait on running ones.
+func (db *DB) RunETA(hist *DurationHistory, now time.Time) (RunEstimate, bool) {
+	var est RunEstimate
+	primary := db.Spans.Map[db.PrimarySpan]
+	if hist == nil || primary == nil || !primary.IsRunningOrEffectsRunning() {
+		return est, false
+	}
+	var longestRunning, longestPending time.Duration
+	for _, span := range db.Spans.Order {
+		if span.ID == primary.ID || span.Passthrough || span.IsInternal() {
+			continue
+		}
+		switch {
+		case span.IsRunning():
+			est.Running++
+			if hasRunningChild(span) {
+				// accounted for by its children
+				continue
+			}
+			remaining, _, ok := hist.ETA(span, now)
+			if !ok {
+				est.Unknown++
+				continue
+			}
+			est.RemainingWork += remaining
+			longestRunning = max(longestRunning, remaining)
+		case span.IsPending():
+			est.Pending++
+			dur, ok := hist.pendingEstimate(db, span)
+			if !ok {
+				est.Unknown++
+				continue
+			}
+			est.RemainingWork += dur
+			longestPending = max(longestPending, dur)
+		}
+	}
+	est.Remaining = longestRunning + longestPending
+	if remaining, _, ok := hist.ETA(primary, now); ok {
+		est.Remaining = max(est.Remaining, remaining)
+	}
+	if est.Remaining == 0 && est.Unknown > 0 {
+		// nothing to go on
+		return est, false
+	}
+	elapsed := primary.ActiveDuration(now)
+	est.Progress = min(float64(elapsed)/float64(elapsed+est.Remaining), 0.99)
+	return est, true
+}
+
+// pendingEstimate estimates how long a pending span will take, falling back
+// to the durations of the effects it is waiting on.
+func (hist *DurationHistory) pendingEstimate(db *DB, span *Span) (time.Duration, bool) {
+	if dur, ok := hist.Estimate(span); ok {
+		return dur, true
+	}
+	var total time.Duration
+	var found bool
+	for _, effect := range span.EffectIDs {
+		if db.CompletedEffects[effect] {
+			continue
+		}
+		if est, ok := hist.ByEffect[effect]; ok {
+			total += est.Duration
+			found = true
+		}
+	}
+	return total, found
+}
+
+func hasRunningChild(span *Span) bool {
+	for _, child := range span.ChildSpans.Order {
+		if child.IsRunning() {
+			return true
+		}
+	}
+	return false
+}
+
+// DurationHistory returns the durations recorded from previously saved
+// traces.
+func (store *TraceStore) DurationHistory() (*DurationHistory, error) {
+	hist := NewDurationHistory()
+	if err := readJSONFile(filepath.Join(store.Root, traceDurationsFilename), hist); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return hist, nil
+		}
+		return nil, err
+	}
+	if hist.ByCall == nil {
+		hist.ByCall = map[string]DurationEstimate{}
+	}
+	if hist.ByLogical == nil {
+		hist.ByLogical = map[string]DurationEstimate{}
+	}
+	if hist.ByName == nil {
+		hist.ByName = map[string]DurationEstimate{}
+	}
+	if hist.ByEffect == nil {
+		hist.ByEffect = map[string]DurationEstimate{}
+	}
+	return hist, nil
+}
+
+// recordDurations adds the DB's span durations to the store's history.
+func (store *TraceStore) recordDurations(db *DB) error {
+	hist, err := store.DurationHistory()
+	if err != nil {
+		// start over rather than failing forever on a corrupt history
+		hist = NewDurationHistory()
+	}
+	hist.Record(db)
+	hist.prune(time.Now())
+	return writeJSONFile(filepath.Join(store.Root, traceDurationsFilename), hist)
+}
@@ -0,0 +1,29 @@
+package dagui
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDurationHistoryPrune(t *testing.T) {
+	now := time.Now()
+	hist := NewDurationHistory()
+	hist.ByName["stale"] = DurationEstimate{Duration: time.Second, Seen: now.Add(-durationHistoryMaxAge - time.Hour)}
+	for i := range durationHistoryMaxEntries + 10 {
+		hist.ByCall[strconv.Itoa(i)] = DurationEstimate{
+			Duration: time.Second,
+			Seen:     now.Add(-time.Duration(i) * time.Minute),
+		}
+	}
+	hist.prune(now)
+
+	require.Empty(t, hist.ByName)
+	require.Len(t, hist.ByCall, durationHistoryMaxEntries)
+	// the least recently seen entries are dropped
+	require.Contains(t, hist.ByCall, "0")
+	require.Contains(t, hist.ByCall, strconv.Itoa(durationHistoryMaxEntries-1))
+	require.NotContains(t, hist.ByCall, strconv.Itoa(durationHistoryMaxEntries))
+}
@@ -0,0 +1,86 @@
+package dagui
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSpanEvents(t *testing.T) {
+	ctx := context.Background()
+	start := time.Now().Add(-time.Hour)
+	stub := tracetest.SpanStub{
+		Name: "exec",
+		SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: trace.TraceID{1},
+			SpanID:  testSpanID(1).SpanID,
+		}),
+		StartTime: start,
+		Events: []sdktrace.Event{
+			{Name: "pulling image", Time: start.Add(time.Second)},
+		},
+	}
+
+	db := NewDB()
+	require.NoError(t, db.ExportSpans(ctx, []sdktrace.ReadOnlySpan{stub.Snapshot()}))
+	require.Equal(t, []SpanEvent{
+		{Name: "pulling image", Time: start.Add(time.Second)},
+	}, db.Spans.Map[testSpanID(1)].Events)
+
+	// updates to the span carry all of its events so far
+	stub.Events = append(stub.Events, sdktrace.Event{Name: "running", Time: start.Add(2 * time.Second)})
+	stub.EndTime = start.Add(3 * time.Second)
+	require.NoError(t, db.ExportSpans(ctx, []sdktrace.ReadOnlySpan{stub.Snapshot()}))
+	require.Equal(t, []SpanEvent{
+		{Name: "pulling image", Time: start.Add(time.Second)},
+		{Name: "running", Time: start.Add(2 * time.Second)},
+	}, db.Spans.Map[testSpanID(1)].Events)
+
+	// events survive snapshots, e.g. for stored traces
+	imported := NewDB()
+	imported.ImportSnapshots([]SpanSnapshot{db.Spans.Map[testSpanID(1)].Snapshot()})
+	require.Len(t, imported.Spans.Map[testSpanID(1)].Events, 2)
+}
+
+func TestSpanExceptions(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	stub := tracetest.SpanStub{
+		Name: "build",
+		SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: trace.TraceID{1},
+			SpanID:  testSpanID(1).SpanID,
+		}),
+		StartTime: start,
+		EndTime:   start.Add(time.Second),
+		Events: []sdktrace.Event{
+			{Name: "started", Time: start},
+			{
+				Name: semconv.ExceptionEventName,
+				Time: start.Add(time.Second),
+				Attributes: []attribute.KeyValue{
+					semconv.ExceptionType("ValueError"),
+					semconv.ExceptionMessage("bad input"),
+					semconv.ExceptionStacktrace(`File "/src/main.py", line 12, in build`),
+				},
+			},
+		},
+	}
+
+	db := NewDB()
+	require.NoError(t, db.ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{stub.Snapshot()}))
+	span := db.Spans.Map[testSpanID(1)]
+	// exceptions are kept apart from the span's other events
+	require.Equal(t, []SpanEvent{{Name: "started", Time: start}}, span.Events)
+	require.Equal(t, []SpanException{{
+		Type:       "ValueError",
+		Message:    "bad input",
+		Stacktrace: `File "/src/main.py", line 12, in build`,
+	}}, span.Exceptions)
+}
@@ -0,0 +1,107 @@
+package dagui
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// VisibleSpan is a span as the frontend would show it, with hidden spans
+// omitted and passthrough spans replaced by their children.
+type VisibleSpan struct {
+	ID        SpanID     `json:"id"`
+	Name      string     `json:"name"`
+	StartTime time.Time  `json:"startTime"`
+	EndTime   *time.Time `json:"endTime,omitempty"`
+	Status    string     `json:"status"`
+	Error     string     `json:"error,omitempty"`
+
+	// CallDigest is the digest of the call the span represents, if any.
+	CallDigest string `json:"callDigest,omitempty"`
+
+	// LogicalID identifies the call across runs, for matching steps even as
+	// their inputs change. See DB.LogicalID.
+	LogicalID string `json:"logicalId,omitempty"`
+
+	// Chained is true if the span is a call against the result of the span
+	// before it, e.g. from(...) -> withExec(...).
+	Chained bool `json:"chained,omitempty"`
+
+	// Attributes are the attributes that modules set on the span. See
+	// telemetry.UserAttrPrefix.
+	Attributes map[string]any `json:"attributes,omitempty"`
+
+	// Annotations are the annotations that modules attached to the span. See
+	// telemetry.AnnotationAttrPrefix.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	Children []*VisibleSpan `json:"children,omitempty"`
+}
+
+// VisibleTree returns the tree of spans the frontend would show with the
+// given options, applying the same rules for hidden, passthrough, and
+// encapsulated spans.
+func (db *DB) VisibleTree(opts FrontendOpts) []*VisibleSpan {
+	view := db.RowsView(opts)
+	spans := make([]*VisibleSpan, 0, len(view.Body))
+	for _, tree := range view.Body {
+		spans = append(spans, db.visibleSpan(tree, opts))
+	}
+	return spans
+}
+
+func (db *DB) visibleSpan(tree *TraceTree, opts FrontendOpts) *VisibleSpan {
+	span := tree.Span
+	visible := &VisibleSpan{
+		ID:         span.ID,
+		Name:       span.Name,
+		StartTime:  span.StartTime,
+		Status:     spanStatus(span),
+		CallDigest: span.CallDigest,
+		LogicalID:  span.logicalID(),
+		Chained:    tree.Chained,
+		Attributes: span.UserAttributeValues(),
+
+		Annotations: span.Annotations,
+	}
+	if name, ok := opts.SpanNames.Name(db, span); ok {
+		visible.Name = name
+	}
+	if !span.IsRunning() {
+		end := span.EndTime
+		visible.EndTime = &end
+	}
+	if span.IsFailed() {
+		visible.Error = span.Status.Description
+	}
+	for _, child := range tree.Children {
+		visible.Children = append(visible.Children, db.visibleSpan(child, opts))
+	}
+	return visible
+}
+
+func spanStatus(span *Span) string {
+	switch {
+	case span.IsRunning():
+		return "running"
+	case span.IsPending():
+		return "pending"
+	case span.IsCanceled():
+		return "canceled"
+	case span.IsFailed():
+		return "failed"
+	case span.IsSkipped():
+		return "skipped"
+	case span.IsCached():
+		return "cached"
+	default:
+		return "ok"
+	}
+}
+
+// WriteVisibleTree writes the visible tree of spans as JSON.
+func (db *DB) WriteVisibleTree(w io.Writer, opts FrontendOpts) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(db.VisibleTree(opts))
+}
@@ -0,0 +1,101 @@
+package dagui
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestVisibleTree(t *testing.T) {
+	span := testTrace{start: time.Now().Add(-time.Hour)}.span
+	build := span(2, 1, "build", time.Second, 5*time.Second)
+	build.Encapsulate = true
+	internal := span(4, 1, "internal", 2*time.Second, 3*time.Second)
+	internal.Internal = true
+	wrapper := span(5, 1, "wrapper", 5*time.Second, 8*time.Second)
+	wrapper.Passthrough = true
+	ignored := span(7, 1, "id", 7*time.Second, 8*time.Second)
+	ignored.Ignore = true
+	lint := span(8, 1, "lint", 8*time.Second, 9*time.Second)
+	lint.Status = sdktrace.Status{Code: codes.Error, Description: "exit code: 1"}
+	failedInternal := span(9, 1, "internal failure", 9*time.Second, 10*time.Second)
+	failedInternal.Internal = true
+	failedInternal.Status = sdktrace.Status{Code: codes.Error, Description: "boom"}
+
+	db := NewDB()
+	db.SetPrimarySpan(testSpanID(1))
+	db.ImportSnapshots([]SpanSnapshot{
+		span(1, 0, "run", 0, 10*time.Second),
+		build,
+		span(3, 2, "setup", time.Second, 2*time.Second),
+		internal,
+		wrapper,
+		span(6, 5, "test", 5*time.Second, 8*time.Second),
+		ignored,
+		lint,
+		failedInternal,
+	})
+
+	names := func(spans []*VisibleSpan) []string {
+		var names []string
+		var walk func(spans []*VisibleSpan, depth int)
+		walk = func(spans []*VisibleSpan, depth int) {
+			for _, span := range spans {
+				names = append(names, strings.Repeat("  ", depth)+span.Name)
+				walk(span.Children, depth+1)
+			}
+		}
+		walk(spans, 0)
+		return names
+	}
+
+	// internal and encapsulated spans are hidden unless they failed, ignored
+	// spans are always hidden, and passthrough spans are replaced by their
+	// children
+	require.Equal(t, []string{
+		"run",
+		"  build",
+		"  test",
+		"  lint",
+		"  internal failure",
+	}, names(db.VisibleTree(FrontendOpts{Verbosity: ShowCompletedVerbosity})))
+
+	require.Equal(t, []string{
+		"run",
+		"  build",
+		"    setup",
+		"  internal",
+		"  test",
+		"  lint",
+		"  internal failure",
+	}, names(db.VisibleTree(FrontendOpts{Verbosity: ShowInternalVerbosity})))
+
+	// debugging reveals everything except passthrough spans
+	require.Equal(t, []string{
+		"run",
+		"  build",
+		"    setup",
+		"  internal",
+		"  test",
+		"  id",
+		"  lint",
+		"  internal failure",
+	}, names(db.VisibleTree(FrontendOpts{Debug: true})))
+
+	var buf strings.Builder
+	require.NoError(t, db.WriteVisibleTree(&buf, FrontendOpts{Verbosity: ShowCompletedVerbosity}))
+	var exported []*VisibleSpan
+	require.NoError(t, json.Unmarshal([]byte(buf.String()), &exported))
+	require.Len(t, exported, 1)
+	children := exported[0].Children
+	require.Len(t, children, 4)
+	require.Equal(t, "ok", children[0].Status)
+	require.Empty(t, children[0].Children)
+	require.Equal(t, "failed", children[2].Status)
+	require.Equal(t, "exit code: 1", children[2].Error)
+}
@@ -0,0 +1,210 @@
+package dagui
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dagger/dagger/dagql/call/callpbv1"
+)
+
+// Failures collects every distinct root cause of the run's failure, following
+// each failed span's errors down to the steps that actually failed. Unlike
+// Summary, it also follows failed effects and links, which matters for runs
+// that keep going after the first failure, where many steps may fail
+// independently. Steps allowed or expected to fail are left out.
+func (db *DB) Failures(logLines int) []StepFailure {
+	var failures []StepFailure
+	for _, cause := range db.rootCauses() {
+		failures = append(failures, StepFailure{
+			Name:       cause.span.Name,
+			CallDigest: cause.span.CallDigest,
+			Error:      cause.span.Status.Description,
+			LogTail:    db.LogTailLines(cause.span, logLines),
+		})
+	}
+	return failures
+}
+
+// rootCause is a step that failed on its own, along with the spans its
+// failure propagated through, outermost first.
+type rootCause struct {
+	span *Span
+	via  []*Span
+}
+
+func (db *DB) rootCauses() []rootCause {
+	primary := db.Spans.Map[db.PrimarySpan]
+	if primary == nil {
+		return nil
+	}
+	var causes []rootCause
+	seen := map[SpanID]bool{}
+	var collect func(*Span, []*Span)
+	collect = func(span *Span, via []*Span) {
+		for _, failed := range span.Errors().Order {
+			if seen[failed.ID] || failed.IsFailureTolerated() {
+				continue
+			}
+			seen[failed.ID] = true
+			path := via
+			if failed != span {
+				// failed through a link or effect
+				path = append(slices.Clip(via), span)
+			}
+			if hasFailedChild(failed) {
+				path = append(slices.Clip(path), failed)
+				for _, child := range failed.ChildSpans.Order {
+					if child.IsFailedOrCausedFailure() {
+						collect(child, path)
+					}
+				}
+				continue
+			}
+			causes = append(causes, rootCause{span: failed, via: path})
+		}
+	}
+	collect(primary, nil)
+	return causes
+}
+
+// FailureReport is a machine-readable report of a run's failures, e.g. for
+// CI to turn into annotations.
+type FailureReport struct {
+	Name     string         `json:"name"`
+	TraceID  TraceID        `json:"traceId"`
+	Failed   bool           `json:"failed"`
+	Failures []FailureCause `json:"failures"`
+}
+
+// FailureCause is a step that failed on its own, causing the run to fail.
+type FailureCause struct {
+	Name       string `json:"name"`
+	SpanID     SpanID `json:"spanId"`
+	CallDigest string `json:"callDigest,omitempty"`
+	Error      string `json:"error"`
+
+	// Category is the kind of failure, e.g. "crash". See ErrorCategoryAttr.
+	Category string `json:"category,omitempty"`
+
+	// ExitCode is the exit code of the failed command, if any.
+	ExitCode *int `json:"exitCode,omitempty"`
+
+	// Logs is the tail of the step's output.
+	Logs string `json:"logs,omitempty"`
+
+	// Via are the names of the steps the failure propagated through to fail
+	// the run, outermost first.
+	Via []string `json:"via,omitempty"`
+
+	// Chain is the chain of API calls that led to the failed call, from the
+	// first call to the failed one.
+	Chain []ChainCall `json:"chain,omitempty"`
+}
+
+// ChainCall is a call in the chain that led to a failure.
+type ChainCall struct {
+	// Operation is the API called, e.g. "Container.withExec".
+	Operation string         `json:"operation"`
+	Module    string         `json:"module,omitempty"`
+	Args      map[string]any `json:"args,omitempty"`
+	Digest    string         `json:"digest"`
+}
+
+// exitCodePattern matches the exit code in the error of a failed exec.
+var exitCodePattern = regexp.MustCompile(`exit code:? (\d+)`)
+
+// FailureReport collects the root causes of the run's failure like Failures
+// does, with the call chain, exit code, and last log lines of each.
+func (db *DB) FailureReport(logLines int) FailureReport {
+	report := FailureReport{
+		Failures: []FailureCause{},
+	}
+	if primary := db.Spans.Map[db.PrimarySpan]; primary != nil {
+		report.Name = primary.Name
+		report.TraceID = primary.TraceID
+		report.Failed = primary.IsFailedOrCausedFailure()
+	}
+	for _, cause := range db.rootCauses() {
+		span := cause.span
+		failure := FailureCause{
+			Name:       span.Name,
+			SpanID:     span.ID,
+			CallDigest: span.CallDigest,
+			Error:      span.Status.Description,
+			Category:   span.ErrorCategory,
+			Logs:       db.LogTailLines(span, logLines),
+			Chain:      db.callChain(span),
+		}
+		if m := exitCodePattern.FindStringSubmatch(span.Status.Description); m != nil {
+			if code, err := strconv.Atoi(m[1]); err == nil {
+				failure.ExitCode = &code
+			}
+		}
+		for _, via := range cause.via {
+			failure.Via = append(failure.Via, via.Name)
+		}
+		report.Failures = append(report.Failures, failure)
+	}
+	return report
+}
+
+// callChain returns the chain of calls leading to the span's call, or that
+// of its nearest ancestor that made a call, e.g. for an exec.
+func (db *DB) callChain(span *Span) []ChainCall {
+	for span != nil && span.Call == nil {
+		span = span.ParentSpan
+	}
+	if span == nil {
+		return nil
+	}
+	var chain []ChainCall
+	for call := span.Call; call != nil; call = db.Calls[call.ReceiverDigest] {
+		chain = append(chain, db.chainCall(call))
+		if call.ReceiverDigest == "" {
+			break
+		}
+	}
+	slices.Reverse(chain)
+	return chain
+}
+
+func (db *DB) chainCall(call *callpbv1.Call) ChainCall {
+	link := ChainCall{
+		Operation: db.callOperation(call),
+		Digest:    call.Digest,
+	}
+	if call.Module != nil {
+		link.Module = call.Module.Name
+	}
+	if len(call.Args) > 0 {
+		link.Args = make(map[string]any, len(call.Args))
+		for _, arg := range call.Args {
+			link.Args[arg.GetName()] = literalValue(arg.GetValue())
+		}
+	}
+	return link
+}
+
+// WriteFailureReport renders a consolidated report of a run's failures as
+// plain text.
+func WriteFailureReport(w io.Writer, failures []StepFailure) error {
+	if len(failures) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	if len(failures) == 1 {
+		sb.WriteString("1 step failed:\n")
+	} else {
+		fmt.Fprintf(&sb, "%d steps failed:\n", len(failures))
+	}
+	for i, failure := range failures {
+		fmt.Fprintf(&sb, "\n%d. %s%s\n", i+1, failure.Name, flakySuffix(failure.Flaky))
+		writeFailureText(&sb, failure)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
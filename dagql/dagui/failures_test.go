@@ -0,0 +1,113 @@
+package dagui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/dagger/dagger/dagql/call/callpbv1"
+)
+
+func TestFailures(t *testing.T) {
+	span := testTrace{start: time.Now().Add(-time.Hour)}.span
+	failed := func(snapshot SpanSnapshot, errMsg string) SpanSnapshot {
+		snapshot.Status = sdktrace.Status{Code: codes.Error, Description: errMsg}
+		return snapshot
+	}
+
+	db := NewDB()
+	db.SetPrimarySpan(testSpanID(1))
+	db.ImportSnapshots([]SpanSnapshot{
+		failed(span(1, 0, "run", time.Second, time.Minute), "2 checks failed"),
+		failed(span(2, 1, "check", 2*time.Second, time.Minute), "2 checks failed"),
+		failed(span(3, 2, "test", 3*time.Second, time.Minute), "exit code 1"),
+		span(4, 2, "lint", 4*time.Second, time.Minute),
+		failed(span(5, 2, "build", 5*time.Second, time.Minute), "exit code 2"),
+	})
+
+	failures := db.Failures(10)
+	require.Equal(t, []StepFailure{
+		{Name: "test", CallDigest: "test", Error: "exit code 1"},
+		{Name: "build", CallDigest: "build", Error: "exit code 2"},
+	}, failures)
+
+	var report strings.Builder
+	require.NoError(t, WriteFailureReport(&report, failures))
+	require.Equal(t, `2 steps failed:
+
+1. test
+  exit code 1
+
+2. build
+  exit code 2
+`, report.String())
+}
+
+func TestFailureReport(t *testing.T) {
+	span := testTrace{start: time.Now().Add(-time.Hour)}.span
+	call := func(digest, recv, field, typ string, args ...*callpbv1.Argument) *callpbv1.Call {
+		call := &callpbv1.Call{
+			Digest:         digest,
+			ReceiverDigest: recv,
+			Field:          field,
+			Type:           &callpbv1.Type{NamedType: typ},
+			Args:           args,
+		}
+		return call
+	}
+	calling := func(snapshot SpanSnapshot, call *callpbv1.Call) SpanSnapshot {
+		payload, err := call.Encode()
+		require.NoError(t, err)
+		snapshot.CallDigest = call.Digest
+		snapshot.CallPayload = payload
+		return snapshot
+	}
+
+	ctr := call("ctr", "", "container", "Container")
+	from := call("from", "ctr", "from", "Container", &callpbv1.Argument{
+		Name:  "address",
+		Value: &callpbv1.Literal{Value: &callpbv1.Literal_String_{String_: "golang"}},
+	})
+	exec := call("exec", "from", "withExec", "Container")
+
+	run := span(1, 0, "run", time.Second, time.Minute)
+	run.EffectIDs = []string{"effect"}
+	execSpan := span(5, 4, "exec go test", 5*time.Second, time.Minute)
+	execSpan.Status = sdktrace.Status{
+		Code:        codes.Error,
+		Description: `process "go test" did not complete successfully: exit code: 1`,
+	}
+	execSpan.EffectID = "effect"
+
+	db := NewDB()
+	db.SetPrimarySpan(testSpanID(1))
+	db.ImportSnapshots([]SpanSnapshot{
+		run,
+		calling(span(2, 1, "container", 2*time.Second, time.Minute), ctr),
+		calling(span(3, 1, "from", 3*time.Second, time.Minute), from),
+		calling(span(4, 1, "withExec", 4*time.Second, time.Minute), exec),
+		execSpan,
+	})
+	db.LogTails[execSpan.ID] = []byte("--- FAIL: TestFoo\n")
+
+	report := db.FailureReport(10)
+	require.True(t, report.Failed)
+	require.Equal(t, "run", report.Name)
+	require.Len(t, report.Failures, 1)
+
+	failure := report.Failures[0]
+	require.Equal(t, "exec go test", failure.Name)
+	require.Equal(t, []string{"run"}, failure.Via)
+	require.NotNil(t, failure.ExitCode)
+	require.Equal(t, 1, *failure.ExitCode)
+	require.Equal(t, "--- FAIL: TestFoo", failure.Logs)
+	require.Equal(t, []ChainCall{
+		{Operation: "Query.container", Digest: "ctr"},
+		{Operation: "Container.from", Digest: "from", Args: map[string]any{"address": "golang"}},
+		{Operation: "Container.withExec", Digest: "exec"},
+	}, failure.Chain)
+}
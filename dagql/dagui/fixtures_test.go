@@ -0,0 +1,48 @@
+package dagui
+
+import (
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// testTrace builds the spans of a trace for tests, timed relative to its
+// start.
+type testTrace struct {
+	start time.Time
+	// id is the trace's ID, or 1 if unset. Span IDs are shared by all traces,
+	// so spans of different traces need different numbers.
+	id byte
+}
+
+// testSpanID returns the ID of the nth span of a testTrace.
+func testSpanID(n byte) SpanID {
+	return SpanID{SpanID: trace.SpanID{n}}
+}
+
+// span returns the nth span of the trace, a child of the parent span unless
+// it's 0, running from from until to, or still running if to is 0.
+//
+// It's named and called after the given name, or "step n" and "digest n" if
+// the name is empty.
+func (tr testTrace) span(n, parent byte, name string, from, to time.Duration) SpanSnapshot {
+	snapshot := SpanSnapshot{
+		ID:         testSpanID(n),
+		TraceID:    TraceID{TraceID: trace.TraceID{max(tr.id, 1)}},
+		Name:       name,
+		CallDigest: name,
+		StartTime:  tr.start.Add(from),
+	}
+	if name == "" {
+		snapshot.Name = fmt.Sprintf("step %d", n)
+		snapshot.CallDigest = fmt.Sprintf("digest %d", n)
+	}
+	if to > 0 {
+		snapshot.EndTime = tr.start.Add(to)
+	}
+	if parent != 0 {
+		snapshot.ParentID = testSpanID(parent)
+	}
+	return snapshot
+}
@@ -0,0 +1,119 @@
+package dagui
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// FailedOnlyInQuarantine returns true if every failure at the root of the
+// run's failure is of a quarantined step, and no step was skipped, in which
+// case the run is reported as passing unless quarantined failures are
+// configured to fail.
+func (db *DB) FailedOnlyInQuarantine(mode QuarantineMode) bool {
+	return len(db.quarantinedFailures(mode)) > 0
+}
+
+// IsQuarantinedError returns true if err, the error a run ended with, is
+// the failure of its quarantined steps alone: only quarantined steps failed,
+// nothing was skipped, and err reports one of their failures rather than
+// something else going wrong.
+func (db *DB) IsQuarantinedError(err error, mode QuarantineMode) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	msg := err.Error()
+	for _, span := range db.quarantinedFailures(mode) {
+		if desc := span.Status.Description; desc != "" && strings.Contains(msg, desc) {
+			return true
+		}
+	}
+	return false
+}
+
+// quarantinedFailures returns the spans at the root of the run's failure if
+// they are all quarantined and no step was skipped, or nil otherwise.
+func (db *DB) quarantinedFailures(mode QuarantineMode) []*Span {
+	var quarantined []*Span
+	for _, span := range db.Spans.Order {
+		if span.IsSkipped() {
+			return nil
+		}
+		if !span.IsFailed() || hasFailedChild(span) || span.IsFailureTolerated() {
+			continue
+		}
+		if !span.IsQuarantinedFailure(mode) {
+			return nil
+		}
+		quarantined = append(quarantined, span)
+	}
+	return quarantined
+}
+
+// FlakyStep is a step that both passed and failed across recent runs with
+// unchanged inputs.
+type FlakyStep struct {
+	// CallDigest identifies the call, and so its inputs.
+	CallDigest string
+	Name       string
+
+	Passes   int
+	Failures int
+}
+
+// FlakySteps finds the steps that both passed and failed with the same
+// inputs, i.e. the same call digest, in the last n traces.
+//
+// Only steps that failed themselves are considered, rather than steps that
+// failed because of one of their children, and cached runs are ignored since
+// they didn't actually run.
+func (store *TraceStore) FlakySteps(n int) ([]FlakyStep, error) {
+	metas, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	metas = metas[:min(n, len(metas))]
+	steps := map[string]*FlakyStep{}
+	for _, meta := range metas {
+		db, _, err := store.Load(meta.TraceID.String())
+		if err != nil {
+			return nil, err
+		}
+		for _, span := range db.Spans.Order {
+			if span.CallDigest == "" ||
+				span.IsRunningOrEffectsRunning() ||
+				span.IsCached() ||
+				span.IsCanceled() {
+				continue
+			}
+			step := steps[span.CallDigest]
+			if step == nil {
+				step = &FlakyStep{
+					CallDigest: span.CallDigest,
+					Name:       span.Name,
+				}
+				steps[span.CallDigest] = step
+			}
+			switch {
+			case span.IsFailed() && !hasFailedChild(span):
+				step.Failures++
+			case !span.IsFailedOrCausedFailure():
+				step.Passes++
+			}
+		}
+	}
+	var flaky []FlakyStep
+	for _, step := range steps {
+		if step.Passes > 0 && step.Failures > 0 {
+			flaky = append(flaky, *step)
+		}
+	}
+	sort.Slice(flaky, func(i, j int) bool {
+		if flaky[i].Failures != flaky[j].Failures {
+			return flaky[i].Failures > flaky[j].Failures
+		}
+		return flaky[i].Name < flaky[j].Name
+	})
+	return flaky, nil
+}
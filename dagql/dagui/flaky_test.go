@@ -0,0 +1,123 @@
+package dagui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestFlakySteps(t *testing.T) {
+	store := NewTraceStore(t.TempDir())
+	start := time.Now().Add(-time.Hour)
+	save := func(run byte, failed ...string) {
+		traceID := TraceID{TraceID: trace.TraceID{run}}
+		root := SpanID{SpanID: trace.SpanID{run, 1}}
+		snapshots := []SpanSnapshot{{
+			ID:        root,
+			TraceID:   traceID,
+			Name:      "run",
+			StartTime: start.Add(time.Duration(run) * time.Minute),
+			EndTime:   start.Add(time.Duration(run)*time.Minute + time.Second),
+		}}
+		for i, dgst := range []string{"test", "lint"} {
+			span := SpanSnapshot{
+				ID:         SpanID{SpanID: trace.SpanID{run, byte(i + 2)}},
+				TraceID:    traceID,
+				ParentID:   root,
+				Name:       dgst,
+				CallDigest: dgst,
+				StartTime:  snapshots[0].StartTime,
+				EndTime:    snapshots[0].EndTime,
+			}
+			for _, f := range failed {
+				if f == dgst {
+					span.Status = sdktrace.Status{Code: codes.Error}
+				}
+			}
+			snapshots = append(snapshots, span)
+		}
+		db := NewDB()
+		db.SetPrimarySpan(root)
+		db.ImportSnapshots(snapshots)
+		_, err := store.Save(db)
+		require.NoError(t, err)
+	}
+	save(1, "test", "lint")
+	save(2)
+	save(3, "test", "lint")
+	save(4, "test")
+
+	flaky, err := store.FlakySteps(10)
+	require.NoError(t, err)
+	require.Equal(t, []FlakyStep{
+		{CallDigest: "test", Name: "test", Passes: 1, Failures: 3},
+		{CallDigest: "lint", Name: "lint", Passes: 2, Failures: 2},
+	}, flaky)
+
+	// test didn't pass in the last two runs, so it's just failing
+	flaky, err = store.FlakySteps(2)
+	require.NoError(t, err)
+	require.Equal(t, []FlakyStep{
+		{CallDigest: "lint", Name: "lint", Passes: 1, Failures: 1},
+	}, flaky)
+}
+
+func TestFailedOnlyInQuarantine(t *testing.T) {
+	span := testTrace{start: time.Now().Add(-time.Hour)}.span
+	failed := func(snapshot SpanSnapshot, desc string) SpanSnapshot {
+		snapshot.Status = sdktrace.Status{Code: codes.Error, Description: desc}
+		return snapshot
+	}
+	load := func(extra ...SpanSnapshot) *DB {
+		flaky := span(3, 1, "flaky", 3*time.Second, time.Minute)
+		flaky.Quarantined = true
+		db := NewDB()
+		db.SetPrimarySpan(testSpanID(1))
+		db.ImportSnapshots(append([]SpanSnapshot{
+			failed(span(1, 0, "run", 0, time.Minute), "exit code: 1"),
+			span(2, 1, "build", 2*time.Second, time.Minute),
+			failed(flaky, "exit code: 1"),
+			// the failure's root cause is within the quarantined step
+			failed(span(4, 3, "exec", 4*time.Second, time.Minute), "exit code: 1"),
+		}, extra...))
+		return db
+	}
+	quarantinedErr := errors.New("input: container.withExec process \"go test\" did not complete successfully: exit code: 1")
+
+	db := load()
+	require.True(t, db.FailedOnlyInQuarantine(QuarantineWarn))
+	require.False(t, db.FailedOnlyInQuarantine(QuarantineFail))
+	require.True(t, db.IsQuarantinedError(quarantinedErr, QuarantineWarn))
+	require.False(t, db.IsQuarantinedError(quarantinedErr, QuarantineFail))
+
+	// errors that aren't the quarantined failure still fail the run
+	require.False(t, db.IsQuarantinedError(nil, QuarantineWarn))
+	require.False(t, db.IsQuarantinedError(errors.New("failed to connect to engine"), QuarantineWarn))
+	require.False(t, db.IsQuarantinedError(fmt.Errorf("run: %w", context.Canceled), QuarantineWarn))
+
+	// something else failed
+	db = load(failed(span(5, 2, "compile", 5*time.Second, time.Minute), "exit code: 2"))
+	require.False(t, db.FailedOnlyInQuarantine(QuarantineWarn))
+	require.False(t, db.IsQuarantinedError(quarantinedErr, QuarantineWarn))
+
+	// something else was skipped
+	skip := span(5, 2, "skip", 5*time.Second, 6*time.Second)
+	skip.Skip = "no changes"
+	db = load(skip)
+	require.False(t, db.FailedOnlyInQuarantine(QuarantineWarn))
+	require.False(t, db.IsQuarantinedError(quarantinedErr, QuarantineWarn))
+
+	// nothing failed at all
+	db = NewDB()
+	db.SetPrimarySpan(testSpanID(1))
+	db.ImportSnapshots([]SpanSnapshot{span(1, 0, "run", 0, time.Minute)})
+	require.False(t, db.FailedOnlyInQuarantine(QuarantineWarn))
+	require.False(t, db.IsQuarantinedError(quarantinedErr, QuarantineWarn))
+}
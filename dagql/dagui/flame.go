@@ -0,0 +1,115 @@
+package dagui
+
+import (
+	"slices"
+	"time"
+)
+
+// FlameNode is a span in a flamegraph, sized by the time spent in it and
+// everything under it.
+type FlameNode struct {
+	Span     *Span
+	Parent   *FlameNode
+	Children []*FlameNode
+
+	// Self is the time spent in the span that isn't accounted for by its
+	// children in the graph, including any hidden ones.
+	Self time.Duration
+
+	// Total is Self plus the Total of each child. Children running in
+	// parallel each count in full, so like CPU time, Total can exceed the
+	// wall-clock time of the span.
+	Total time.Duration
+}
+
+// NewFlamegraph returns a flamegraph rooted at span, with the given trees as
+// its children, e.g. the Body of a RowsView zoomed on span. The span may be
+// nil, in which case the root just groups the trees.
+func NewFlamegraph(span *Span, trees []*TraceTree, now time.Time) *FlameNode {
+	totals := map[SpanID]time.Duration{}
+	var total func(*Span) time.Duration
+	total = func(span *Span) time.Duration {
+		if t, ok := totals[span.ID]; ok {
+			return t
+		}
+		t := span.SelfDuration(now)
+		for _, child := range span.ChildSpans.Order {
+			t += total(child)
+		}
+		totals[span.ID] = t
+		return t
+	}
+	var build func(parent *FlameNode, span *Span, trees []*TraceTree) *FlameNode
+	build = func(parent *FlameNode, span *Span, trees []*TraceTree) *FlameNode {
+		node := &FlameNode{Span: span, Parent: parent}
+		var children time.Duration
+		for _, tree := range trees {
+			child := build(node, tree.Span, tree.Children)
+			node.Children = append(node.Children, child)
+			children += child.Total
+		}
+		node.Total = children
+		if span != nil {
+			// trees may lift spans out of hidden parents, but never out of
+			// the span itself, so this only guards against clock skew
+			node.Total = max(total(span), children)
+		}
+		node.Self = node.Total - children
+		return node
+	}
+	return build(nil, span, trees)
+}
+
+// Find returns the node for the given span, or nil if it's not in the graph.
+func (node *FlameNode) Find(id SpanID) *FlameNode {
+	if node.Span != nil && node.Span.ID == id {
+		return node
+	}
+	for _, child := range node.Children {
+		if found := child.Find(id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// SelfDuration returns how long the span ran without any of its children
+// running, or until now if it's still running.
+func (span *Span) SelfDuration(now time.Time) time.Duration {
+	start, end := span.StartTime, span.EndTime
+	if span.IsRunning() {
+		end = now
+	}
+	if !end.After(start) {
+		return 0
+	}
+	var busy []Interval
+	for _, child := range span.ChildSpans.Order {
+		for ival := range child.Activity.Intervals(now) {
+			if ival.Start.Before(start) {
+				ival.Start = start
+			}
+			if ival.End.After(end) {
+				ival.End = end
+			}
+			if ival.End.After(ival.Start) {
+				busy = append(busy, ival)
+			}
+		}
+	}
+	slices.SortFunc(busy, func(a, b Interval) int {
+		return a.Start.Compare(b.Start)
+	})
+	self := end.Sub(start)
+	var cursor time.Time
+	for _, ival := range busy {
+		if ival.Start.Before(cursor) {
+			ival.Start = cursor
+		}
+		if ival.End.After(ival.Start) {
+			self -= ival.End.Sub(ival.Start)
+			cursor = ival.End
+		}
+	}
+	return self
+}
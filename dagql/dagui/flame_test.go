@@ -0,0 +1,44 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlamegraph(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	span := testTrace{start: start}.span
+
+	db := NewDB()
+	db.ImportSnapshots([]SpanSnapshot{
+		span(1, 0, "run", 0, 10*time.Second),
+		span(2, 1, "build", time.Second, 4*time.Second),
+		span(3, 2, "compile", time.Second+time.Millisecond, 2*time.Second),
+		span(4, 1, "test", 2*time.Second, 6*time.Second),
+	})
+	run, build, compile, test := db.Spans.Map[testSpanID(1)], db.Spans.Map[testSpanID(2)], db.Spans.Map[testSpanID(3)], db.Spans.Map[testSpanID(4)]
+	now := time.Now()
+
+	// build and test overlap, so run only ran on its own for 5s
+	require.Equal(t, 5*time.Second, run.SelfDuration(now))
+	require.Equal(t, 2*time.Second+time.Millisecond, build.SelfDuration(now))
+
+	root := NewFlamegraph(run, []*TraceTree{
+		{Span: build, Children: []*TraceTree{{Span: compile}}},
+		{Span: test},
+	}, now)
+	require.Equal(t, 12*time.Second, root.Total)
+	require.Equal(t, 5*time.Second, root.Self)
+	require.Len(t, root.Children, 2)
+	require.Equal(t, 3*time.Second, root.Children[0].Total)
+	require.Equal(t, 4*time.Second, root.Children[1].Total)
+	require.Same(t, root.Children[0], root.Find(testSpanID(3)).Parent)
+
+	// hidden children are counted as time spent in their parent
+	root = NewFlamegraph(run, []*TraceTree{{Span: build}, {Span: test}}, now)
+	require.Equal(t, 12*time.Second, root.Total)
+	require.Equal(t, 3*time.Second, root.Children[0].Self)
+	require.Nil(t, root.Find(testSpanID(3)))
+}
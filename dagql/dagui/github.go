@@ -0,0 +1,142 @@
+package dagui
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// githubLogLines is the number of log lines shown for each failed step.
+const githubLogLines = 50
+
+// githubLocationPattern matches a source location at the start of a line of
+// output, as printed by compilers and linters, e.g. "main.go:12:5: ...".
+// Only relative paths are matched, since absolute ones are paths in a
+// container that don't match the checked out repository.
+var githubLocationPattern = regexp.MustCompile(`(?m)^([\w.\-][\w./\-]*\.\w+):(\d+)(?::(\d+))?:\s*(.+)$`)
+
+// WriteGitHubAnnotations reports the run's failures as GitHub Actions
+// workflow commands, so that they're shown as annotations in the checks of a
+// pull request. Each root cause of the failure is reported as an error,
+// after a collapsed group of its logs. Failures of quarantined steps and
+// unexpected passes are reported as warnings, depending on the quarantine
+// mode.
+//
+// Errors point at the first source location found in the step's error or
+// logs, if any, e.g. of a compiler error.
+func (db *DB) WriteGitHubAnnotations(w io.Writer, quarantine QuarantineMode) error {
+	var sb strings.Builder
+	report := db.FailureReport(githubLogLines)
+	for _, failure := range report.Failures {
+		span := db.Spans.Map[failure.SpanID]
+		if span != nil && span.IsQuarantinedFailure(quarantine) {
+			// reported as a warning below
+			continue
+		}
+		fmt.Fprintf(&sb, "::group::%s\n", escapeGitHubData(failure.Name+" failed"))
+		// stop processing commands, so that the step's output can't inject any
+		token, err := githubStopToken()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&sb, "::stop-commands::%s\n", token)
+		for _, step := range failure.Via {
+			fmt.Fprintf(&sb, "via: %s\n", step)
+		}
+		if span != nil {
+			_, reasons := span.FailedReason()
+			for _, reason := range reasons {
+				fmt.Fprintf(&sb, "reason: %s\n", reason)
+			}
+		}
+		if failure.Logs != "" {
+			sb.WriteString(failure.Logs)
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "::%s::\n", token)
+		sb.WriteString("::endgroup::\n")
+
+		props := []string{"title", failure.Name}
+		if file, line, col, ok := githubLocation(failure.Error + "\n" + failure.Logs); ok {
+			props = append(props, "file", file, "line", line)
+			if col != "" {
+				props = append(props, "col", col)
+			}
+		}
+		msg := failure.Error
+		if len(failure.Via) > 0 {
+			msg += "\n\nin " + strings.Join(failure.Via, " > ") + " > " + failure.Name
+		}
+		writeGitHubCommand(&sb, "error", msg, props...)
+	}
+
+	summary := db.Summary(0, githubLogLines, quarantine)
+	for _, warning := range summary.Warnings {
+		writeGitHubCommand(&sb, "warning", warning.Error,
+			"title", warning.Name+" failed (quarantined)")
+	}
+	for _, name := range summary.UnexpectedPasses {
+		writeGitHubCommand(&sb, "warning", name+" was expected to fail, but passed",
+			"title", name+" passed unexpectedly")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// githubStopToken returns a random token to pause processing of workflow
+// commands with.
+func githubStopToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// githubLocation finds the first source location in a step's output.
+func githubLocation(output string) (file, line, col string, ok bool) {
+	m := githubLocationPattern.FindStringSubmatch(output)
+	if m == nil {
+		return "", "", "", false
+	}
+	return m[1], m[2], m[3], true
+}
+
+// writeGitHubCommand writes a workflow command with the given properties, in
+// key, value pairs.
+func writeGitHubCommand(sb *strings.Builder, command, msg string, props ...string) {
+	sb.WriteString("::" + command)
+	for i := 0; i+1 < len(props); i += 2 {
+		if i == 0 {
+			sb.WriteString(" ")
+		} else {
+			sb.WriteString(",")
+		}
+		sb.WriteString(props[i] + "=" + escapeGitHubProperty(props[i+1]))
+	}
+	sb.WriteString("::" + escapeGitHubData(msg) + "\n")
+}
+
+// escapeGitHubData escapes the message of a workflow command, so that it
+// stays on a single line.
+func escapeGitHubData(str string) string {
+	return strings.NewReplacer(
+		"%", "%25",
+		"\r", "%0D",
+		"\n", "%0A",
+	).Replace(str)
+}
+
+// escapeGitHubProperty escapes the value of a workflow command property.
+func escapeGitHubProperty(str string) string {
+	return strings.NewReplacer(
+		"%", "%25",
+		"\r", "%0D",
+		"\n", "%0A",
+		":", "%3A",
+		",", "%2C",
+	).Replace(str)
+}
@@ -0,0 +1,62 @@
+package dagui
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestWriteGitHubAnnotations(t *testing.T) {
+	span := testTrace{start: time.Now().Add(-time.Hour)}.span
+	failed := func(snapshot SpanSnapshot, errMsg string) SpanSnapshot {
+		snapshot.Status = sdktrace.Status{Code: codes.Error, Description: errMsg}
+		return snapshot
+	}
+	build := failed(span(3, 2, "build", 3*time.Second, time.Minute), "exit code: 1")
+	flaky := failed(span(4, 2, "flaky, test", 4*time.Second, time.Minute), "timed out")
+	flaky.Quarantined = true
+	xfail := span(5, 2, "xfail", 5*time.Second, time.Minute)
+	xfail.FailureExpected = true
+
+	db := NewDB()
+	db.SetPrimarySpan(testSpanID(1))
+	db.ImportSnapshots([]SpanSnapshot{
+		failed(span(1, 0, "run", time.Second, time.Minute), "check failed"),
+		failed(span(2, 1, "check", 2*time.Second, time.Minute), "check failed"),
+		build,
+		flaky,
+		xfail,
+	})
+	db.LogTails[build.ID] = []byte("# example\n./main.go:12:5: undefined: foo\n::error::injected\n")
+
+	var out strings.Builder
+	require.NoError(t, db.WriteGitHubAnnotations(&out, QuarantineWarn))
+	// the stop token is random
+	stop := regexp.MustCompile(`::stop-commands::(\w+)\n`).FindStringSubmatch(out.String())
+	require.NotNil(t, stop)
+	require.Equal(t, `::group::build failed
+::stop-commands::TOKEN
+via: run
+via: check
+reason: span itself errored
+# example
+./main.go:12:5: undefined: foo
+::error::injected
+::TOKEN::
+::endgroup::
+::error title=build,file=./main.go,line=12,col=5::exit code: 1%0A%0Ain run > check > build
+::warning title=flaky%2C test failed (quarantined)::timed out
+::warning title=xfail passed unexpectedly::xfail was expected to fail, but passed
+`, strings.ReplaceAll(out.String(), stop[1], "TOKEN"))
+
+	// quarantined failures are errors when they fail the run
+	out.Reset()
+	require.NoError(t, db.WriteGitHubAnnotations(&out, QuarantineFail))
+	require.Contains(t, out.String(), "::error title=flaky%2C test::timed out")
+	require.NotContains(t, out.String(), "::warning title=flaky")
+}
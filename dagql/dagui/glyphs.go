@@ -0,0 +1,118 @@
+package dagui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// StatusGlyphs are the symbols shown next to each step to indicate its
+// status.
+type StatusGlyphs struct {
+	// Name is the name of the preset the glyphs are based on. It is empty for
+	// the default glyphs when no preset was configured.
+	Name string
+
+	Running string
+	Pending string
+	Success string
+	Failure string
+	Cached  string
+	Skipped string
+}
+
+// GlyphPresets are the built-in sets of status glyphs.
+var GlyphPresets = map[string]StatusGlyphs{
+	"unicode": {
+		Running: "●",
+		Pending: "○",
+		Success: "✔",
+		Failure: "✘",
+		Cached:  "$", // cache money
+		Skipped: "∅",
+	},
+	"ascii": {
+		Running: "*",
+		Pending: ".",
+		Success: "+",
+		Failure: "x",
+		Cached:  "$",
+		Skipped: "-",
+	},
+	"nerd": {
+		Running: "\uf192", // nf-fa-dot_circle_o
+		Pending: "\uf10c", // nf-fa-circle_o
+		Success: "\uf00c", // nf-fa-check
+		Failure: "\uf00d", // nf-fa-times
+		Cached:  "\uf1c0", // nf-fa-database
+		Skipped: "\uf05e", // nf-fa-ban
+	},
+	"emoji": {
+		Running: "🔄",
+		Pending: "⏳",
+		Success: "✅",
+		Failure: "❌",
+		Cached:  "💰",
+		Skipped: "⏭️",
+	},
+}
+
+// DefaultGlyphs are the glyphs used when none are configured.
+var DefaultGlyphs = GlyphPresets["unicode"]
+
+// ParseStatusGlyphs parses a glyph preset name, optionally followed by
+// comma-separated overrides for individual statuses, e.g.
+// "ascii,cached=c,running=>".
+func ParseStatusGlyphs(spec string) (StatusGlyphs, error) {
+	name, overrides, _ := strings.Cut(spec, ",")
+	glyphs, ok := GlyphPresets[name]
+	if !ok {
+		presets := make([]string, 0, len(GlyphPresets))
+		for preset := range GlyphPresets {
+			presets = append(presets, preset)
+		}
+		sort.Strings(presets)
+		return StatusGlyphs{}, fmt.Errorf("unknown glyph preset %q: must be one of %s", name, strings.Join(presets, ", "))
+	}
+	glyphs.Name = name
+	if overrides == "" {
+		return glyphs, nil
+	}
+	for _, override := range strings.Split(overrides, ",") {
+		status, glyph, ok := strings.Cut(override, "=")
+		if !ok || glyph == "" {
+			return StatusGlyphs{}, fmt.Errorf("invalid glyph override %q: expected status=glyph", override)
+		}
+		switch status {
+		case "running":
+			glyphs.Running = glyph
+		case "pending":
+			glyphs.Pending = glyph
+		case "success":
+			glyphs.Success = glyph
+		case "failure":
+			glyphs.Failure = glyph
+		case "cached":
+			glyphs.Cached = glyph
+		case "skipped":
+			glyphs.Skipped = glyph
+		default:
+			return StatusGlyphs{}, fmt.Errorf("invalid glyph override %q: unknown status %q", override, status)
+		}
+	}
+	return glyphs, nil
+}
+
+// Configured reports whether glyphs were explicitly configured, rather than
+// left as the zero value.
+func (glyphs StatusGlyphs) Configured() bool {
+	return glyphs.Name != ""
+}
+
+// OrDefault returns the glyphs, or DefaultGlyphs if none were configured.
+func (glyphs StatusGlyphs) OrDefault() StatusGlyphs {
+	if !glyphs.Configured() {
+		return DefaultGlyphs
+	}
+	return glyphs
+}
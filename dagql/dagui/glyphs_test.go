@@ -0,0 +1,34 @@
+package dagui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStatusGlyphs(t *testing.T) {
+	glyphs, err := ParseStatusGlyphs("ascii")
+	require.NoError(t, err)
+	require.Equal(t, "ascii", glyphs.Name)
+	require.Equal(t, "+", glyphs.Success)
+
+	glyphs, err = ParseStatusGlyphs("unicode,cached=c,running=>")
+	require.NoError(t, err)
+	require.Equal(t, "c", glyphs.Cached)
+	require.Equal(t, ">", glyphs.Running)
+	require.Equal(t, DefaultGlyphs.Success, glyphs.Success)
+
+	for _, spec := range []string{
+		"",
+		"fancy",
+		"ascii,cached",
+		"ascii,bogus=x",
+	} {
+		t.Run(spec, func(t *testing.T) {
+			_, err := ParseStatusGlyphs(spec)
+			require.Error(t, err)
+		})
+	}
+
+	require.Equal(t, DefaultGlyphs, StatusGlyphs{}.OrDefault())
+}
@@ -0,0 +1,197 @@
+package dagui
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"time"
+)
+
+// StepHistory is the durations of each step across recent runs.
+type StepHistory struct {
+	// Runs are the runs the history covers, oldest first.
+	Runs []TraceMeta
+
+	// Steps are the steps seen in the runs, in the order they appear in the
+	// latest run, followed by steps that no longer run.
+	Steps []StepRuns
+}
+
+// StepRuns is the history of a single step.
+type StepRuns struct {
+	// ID is the step's logical ID, or its name if it is not a call.
+	ID    string
+	Name  string
+	Depth int
+
+	// Runs holds the step's run in each of the history's runs, in the same
+	// order.
+	Runs []StepRun
+}
+
+// StepRun is a step's run within a single trace.
+type StepRun struct {
+	Ran      bool
+	Cached   bool
+	Duration time.Duration
+}
+
+// HeatLevels is the number of levels Heat rates durations on.
+const HeatLevels = 5
+
+// Heat rates a step's duration in a run relative to its median duration
+// across runs, from 0 for much faster to HeatLevels-1 for much slower. It
+// returns false for runs where the step didn't run, or was cached.
+func (step StepRuns) Heat(run int) (int, bool) {
+	r := step.Runs[run]
+	if !r.Ran || r.Cached {
+		return 0, false
+	}
+	median := step.median()
+	if median <= 0 {
+		return HeatLevels / 2, true
+	}
+	ratio := float64(r.Duration) / float64(median)
+	switch {
+	case ratio < 0.75:
+		return 0, true
+	case ratio < 0.95:
+		return 1, true
+	case ratio < 1.1:
+		return 2, true
+	case ratio < 1.5:
+		return 3, true
+	default:
+		return 4, true
+	}
+}
+
+func (step StepRuns) median() time.Duration {
+	var durs []time.Duration
+	for _, r := range step.Runs {
+		if r.Ran && !r.Cached {
+			durs = append(durs, r.Duration)
+		}
+	}
+	if len(durs) == 0 {
+		return 0
+	}
+	slices.Sort(durs)
+	return durs[len(durs)/2]
+}
+
+// StepHistory collects the durations of the visible steps of the last n
+// traces, down to the given depth, matching steps across runs by their
+// logical ID.
+func (store *TraceStore) StepHistory(n, depth int, opts FrontendOpts) (*StepHistory, error) {
+	metas, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	metas = metas[:min(n, len(metas))]
+	hist := &StepHistory{}
+	steps := map[string]*StepRuns{}
+	var order []string
+	// visit the latest run first, so that steps are listed in its order
+	for i, meta := range metas {
+		db, _, err := store.Load(meta.TraceID.String())
+		if err != nil {
+			return nil, err
+		}
+		run := len(metas) - 1 - i
+		var walk func([]*VisibleSpan, int)
+		walk = func(spans []*VisibleSpan, d int) {
+			for _, span := range spans {
+				id := span.LogicalID
+				if id == "" {
+					id = span.Name
+				}
+				step := steps[id]
+				if step == nil {
+					step = &StepRuns{
+						ID:    id,
+						Name:  span.Name,
+						Depth: d,
+						Runs:  make([]StepRun, len(metas)),
+					}
+					steps[id] = step
+					order = append(order, id)
+				}
+				r := &step.Runs[run]
+				r.Ran = true
+				r.Cached = r.Cached || span.Status == "cached"
+				if span.EndTime != nil {
+					// a step may run more than once, e.g. in a loop; keep the
+					// slowest
+					r.Duration = max(r.Duration, span.EndTime.Sub(span.StartTime))
+				}
+				if d+1 < depth {
+					walk(span.Children, d+1)
+				}
+			}
+		}
+		walk(db.VisibleTree(opts), 0)
+	}
+	for _, id := range order {
+		hist.Steps = append(hist.Steps, *steps[id])
+	}
+	slices.Reverse(metas)
+	hist.Runs = metas
+	return hist, nil
+}
+
+// HeatColors are the colors of each heat level, from fast to slow.
+var HeatColors = [HeatLevels]string{"#1a9850", "#91cf60", "#d9ef8b", "#fc8d59", "#d73027"}
+
+var heatmapHTML = template.Must(template.New("heatmap").Funcs(template.FuncMap{
+	"heat": func(step StepRuns, run int) template.CSS {
+		level, ok := step.Heat(run)
+		if !ok {
+			return ""
+		}
+		return template.CSS("background:" + HeatColors[level])
+	},
+	"title": func(step StepRuns, run int) string {
+		r := step.Runs[run]
+		switch {
+		case !r.Ran:
+			return "did not run"
+		case r.Cached:
+			return "cached"
+		default:
+			return r.Duration.Round(time.Millisecond).String()
+		}
+	},
+	"indent": func(depth int) template.CSS {
+		return template.CSS(fmt.Sprintf("padding-left:%dem", depth*2))
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Step durations</title>
+<style>
+  body { font: 13px ui-monospace, Menlo, Consolas, monospace; }
+  table { border-collapse: collapse; }
+  td { padding: 0 0.5em; white-space: nowrap; }
+  td.run { width: 1.2em; height: 1.2em; padding: 0; border: 1px solid #fff; }
+  td.cached { background: #ccc; }
+</style>
+</head>
+<body>
+<table>
+<tr><th></th>{{range .Runs}}<th title="{{.Name}} ({{.StartTime.Format "2006-01-02 15:04"}})"></th>{{end}}</tr>
+{{range $step := .Steps}}<tr>
+  <td style="{{indent $step.Depth}}">{{$step.Name}}</td>
+  {{range $i, $run := $step.Runs}}<td class="run{{if $run.Cached}} cached{{end}}" style="{{heat $step $i}}" title="{{title $step $i}}"></td>{{end}}
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// WriteHTML writes the history as a standalone HTML page.
+func (hist *StepHistory) WriteHTML(w io.Writer) error {
+	return heatmapHTML.Execute(w, hist)
+}
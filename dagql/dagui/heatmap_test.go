@@ -0,0 +1,117 @@
+package dagui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestStepHeat(t *testing.T) {
+	ran := func(dur time.Duration) StepRun { return StepRun{Ran: true, Duration: dur} }
+	step := StepRuns{Runs: []StepRun{
+		ran(10 * time.Second),
+		ran(7 * time.Second),
+		ran(9 * time.Second),
+		ran(12 * time.Second),
+		ran(20 * time.Second),
+		{Ran: true, Cached: true},
+		{},
+	}}
+
+	var levels []int
+	for i := range step.Runs {
+		level, ok := step.Heat(i)
+		if !ok {
+			level = -1
+		}
+		levels = append(levels, level)
+	}
+	// relative to the 10s median, ignoring cached runs and runs it wasn't in
+	require.Equal(t, []int{2, 0, 1, 3, 4, -1, -1}, levels)
+
+	// without a duration to compare to, runs are usual
+	level, ok := StepRuns{Runs: []StepRun{ran(0)}}.Heat(0)
+	require.True(t, ok)
+	require.Equal(t, HeatLevels/2, level)
+}
+
+func TestStepHistory(t *testing.T) {
+	now := time.Now()
+	store := NewTraceStore(t.TempDir())
+	// save runs oldest first, each with its own trace
+	for i, durs := range []map[string]time.Duration{
+		{"build": 10 * time.Second, "lint": 2 * time.Second},
+		{"build": 0, "lint": 3 * time.Second},
+		{"test": 5 * time.Second, "build": 30 * time.Second},
+	} {
+		tr := testTrace{start: now.Add(time.Duration(i-3) * time.Hour)}
+		traceID := TraceID{TraceID: trace.TraceID{byte(i + 1)}}
+		snapshots := []SpanSnapshot{tr.span(1, 0, "run", 0, time.Minute)}
+		for n, name := range []string{"test", "build", "lint"} {
+			dur, ok := durs[name]
+			if !ok {
+				continue
+			}
+			from := time.Duration(n+1) * time.Second
+			span := tr.span(byte(n+2), 1, name, from, from+dur)
+			// a cached step takes no time
+			span.Cached = dur == 0
+			if span.Cached {
+				span.EndTime = span.StartTime
+			}
+			snapshots = append(snapshots, span)
+		}
+		for i := range snapshots {
+			snapshots[i].TraceID = traceID
+		}
+		db := NewDB()
+		db.SetPrimarySpan(testSpanID(1))
+		db.ImportSnapshots(snapshots)
+		_, err := store.Save(db)
+		require.NoError(t, err)
+	}
+
+	hist, err := store.StepHistory(10, 2, FrontendOpts{})
+	require.NoError(t, err)
+	require.Len(t, hist.Runs, 3)
+	require.True(t, hist.Runs[0].StartTime.Before(hist.Runs[2].StartTime), "runs are oldest first")
+
+	// steps are listed in the order of the latest run, then those that no
+	// longer run, down to the given depth
+	var names []string
+	for _, step := range hist.Steps {
+		names = append(names, step.Name)
+		require.Len(t, step.Runs, 3)
+	}
+	require.Equal(t, []string{"run", "test", "build", "lint"}, names)
+	require.Equal(t, 1, hist.Steps[1].Depth)
+
+	require.Equal(t, []StepRun{
+		{},
+		{},
+		{Ran: true, Duration: 5 * time.Second},
+	}, hist.Steps[1].Runs)
+	require.Equal(t, []StepRun{
+		{Ran: true, Duration: 10 * time.Second},
+		{Ran: true, Cached: true},
+		{Ran: true, Duration: 30 * time.Second},
+	}, hist.Steps[2].Runs)
+	require.Equal(t, []StepRun{
+		{Ran: true, Duration: 2 * time.Second},
+		{Ran: true, Duration: 3 * time.Second},
+		{},
+	}, hist.Steps[3].Runs)
+
+	// the history is limited to the latest runs
+	hist, err = store.StepHistory(1, 2, FrontendOpts{})
+	require.NoError(t, err)
+	require.Len(t, hist.Runs, 1)
+	require.Len(t, hist.Steps, 3)
+
+	var html strings.Builder
+	require.NoError(t, hist.WriteHTML(&html))
+	require.Contains(t, html.String(), "background:"+HeatColors[HeatLevels/2])
+}
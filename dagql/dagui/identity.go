@@ -0,0 +1,139 @@
+package dagui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/dagger/dagger/dagql/call/callpbv1"
+)
+
+// hashPatterns match content hashes that are expected to change between runs
+// of the same pipeline, e.g. commit SHAs and content digests.
+var hashPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bsha(256|384|512):[0-9a-f]{32,}\b`),
+	regexp.MustCompile(`\b[0-9a-f]{64}\b`),
+	regexp.MustCompile(`\b[0-9a-f]{40}\b`),
+}
+
+// shortHashPattern matches an abbreviated commit SHA, which is only masked
+// when it is the whole argument, since shorter hex strings are common.
+var shortHashPattern = regexp.MustCompile(`^[0-9a-f]*[a-f][0-9a-f]*$`)
+
+// LogicalID returns a stable identity for a call, for matching the same step
+// across runs whose inputs have changed.
+//
+// Unlike the call's digest, it is derived from the names of the module,
+// type, and function and the call's arguments, with object arguments and the
+// receiver replaced by their own logical IDs, and content hashes like commit
+// SHAs masked.
+func (db *DB) LogicalID(call *callpbv1.Call) string {
+	text, _ := db.logicalText(call)
+	return digest.FromString(text).String()
+}
+
+// logicalText returns the normalized text that a call's logical ID is derived
+// from, and whether it is complete, i.e. every call it references is known.
+func (db *DB) logicalText(call *callpbv1.Call) (string, bool) {
+	if text, ok := db.logicalTexts[call.Digest]; ok {
+		return text, true
+	}
+	complete := true
+	var sb strings.Builder
+	if call.ReceiverDigest != "" {
+		recv, ok := db.Calls[call.ReceiverDigest]
+		if ok {
+			text, ok := db.logicalText(recv)
+			complete = complete && ok
+			sb.WriteString(text)
+		} else {
+			complete = false
+			sb.WriteString("?")
+		}
+		sb.WriteString(".")
+	}
+	if call.Module != nil {
+		sb.WriteString(call.Module.Name)
+		sb.WriteString(":")
+	}
+	sb.WriteString(call.Type.ToAST().Name())
+	sb.WriteString(".")
+	sb.WriteString(call.Field)
+	sb.WriteString("(")
+	for i, arg := range call.Args {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(arg.GetName())
+		sb.WriteString("=")
+		text, ok := db.logicalLiteral(arg.GetValue())
+		complete = complete && ok
+		sb.WriteString(text)
+	}
+	sb.WriteString(")")
+	if call.Nth != 0 {
+		fmt.Fprintf(&sb, "[%d]", call.Nth)
+	}
+	text := sb.String()
+	if complete && call.Digest != "" {
+		// calls referenced later may not be known yet, so only remember
+		// complete results
+		db.logicalTexts[call.Digest] = text
+	}
+	return text, complete
+}
+
+func (db *DB) logicalLiteral(lit *callpbv1.Literal) (string, bool) {
+	switch val := lit.GetValue().(type) {
+	case *callpbv1.Literal_CallDigest:
+		call, ok := db.Calls[val.CallDigest]
+		if !ok {
+			return "@?", false
+		}
+		text, ok := db.logicalText(call)
+		return "@" + digest.FromString(text).Encoded(), ok
+	case *callpbv1.Literal_String_:
+		return fmt.Sprintf("%q", maskHashes(val.String_)), true
+	case *callpbv1.Literal_List:
+		complete := true
+		strs := make([]string, len(val.List.GetValues()))
+		for i, elem := range val.List.GetValues() {
+			var ok bool
+			strs[i], ok = db.logicalLiteral(elem)
+			complete = complete && ok
+		}
+		return "[" + strings.Join(strs, ",") + "]", complete
+	case *callpbv1.Literal_Object:
+		complete := true
+		strs := make([]string, len(val.Object.GetValues()))
+		for i, field := range val.Object.GetValues() {
+			text, ok := db.logicalLiteral(field.GetValue())
+			complete = complete && ok
+			strs[i] = field.GetName() + ":" + text
+		}
+		return "{" + strings.Join(strs, ",") + "}", complete
+	default:
+		return fmt.Sprint(literalValue(lit)), true
+	}
+}
+
+func maskHashes(str string) string {
+	if len(str) >= 7 && shortHashPattern.MatchString(str) {
+		return "<hash>"
+	}
+	for _, pattern := range hashPatterns {
+		str = pattern.ReplaceAllString(str, "<hash>")
+	}
+	return str
+}
+
+// logicalID returns the span's logical ID, falling back to the one it was
+// imported with.
+func (span *Span) logicalID() string {
+	if span.Call == nil || span.db == nil {
+		return span.LogicalID
+	}
+	return span.db.LogicalID(span.Call)
+}
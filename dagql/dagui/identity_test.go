@@ -0,0 +1,48 @@
+package dagui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/dagql/call/callpbv1"
+)
+
+func TestLogicalID(t *testing.T) {
+	db := NewDB()
+	addCall := func(dgst, recv, field string, args ...*callpbv1.Argument) *callpbv1.Call {
+		call := &callpbv1.Call{
+			Digest:         dgst,
+			ReceiverDigest: recv,
+			Field:          field,
+			Type:           &callpbv1.Type{NamedType: "Container"},
+			Args:           args,
+		}
+		db.Calls[dgst] = call
+		return call
+	}
+	stringArg := func(name, val string) *callpbv1.Argument {
+		return &callpbv1.Argument{
+			Name:  name,
+			Value: &callpbv1.Literal{Value: &callpbv1.Literal_String_{String_: val}},
+		}
+	}
+
+	// two runs building different commits of the same source
+	addCall("a1", "", "git", stringArg("url", "https://github.com/dagger/dagger@0123456789abcdef0123456789abcdef01234567"))
+	run1 := addCall("a2", "a1", "withExec", stringArg("tag", "v1"))
+	addCall("b1", "", "git", stringArg("url", "https://github.com/dagger/dagger@fedcba9876543210fedcba9876543210fedcba98"))
+	run2 := addCall("b2", "b1", "withExec", stringArg("tag", "v1"))
+	require.Equal(t, db.LogicalID(run1), db.LogicalID(run2))
+
+	// short SHAs are masked when they are the whole argument
+	short1 := addCall("c1", "", "withLabel", stringArg("commit", "0123abc"))
+	short2 := addCall("c2", "", "withLabel", stringArg("commit", "4567def"))
+	require.Equal(t, db.LogicalID(short1), db.LogicalID(short2))
+
+	// but meaningful differences are kept
+	other := addCall("b3", "b1", "withExec", stringArg("tag", "v2"))
+	require.NotEqual(t, db.LogicalID(run1), db.LogicalID(other))
+	sibling := addCall("b4", "b1", "withEntrypoint", stringArg("tag", "v1"))
+	require.NotEqual(t, db.LogicalID(run2), db.LogicalID(sibling))
+}
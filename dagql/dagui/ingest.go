@@ -0,0 +1,183 @@
+package dagui
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// IngestQueue buffers the telemetry exported to a DB, so that exporting never
+// waits on readers of the DB, e.g. a frontend rendering a frame. Readers Flush
+// the queue between reads, under the same lock as the reads, so a read works
+// on a stable DB that nothing mutates underneath it.
+//
+// Telemetry is flushed in the order it was exported, so logs exported after
+// their span are still applied after it. Consecutive exports of the same kind
+// are coalesced into one batch, and a span exported again before it's
+// flushed only keeps its latest state, so the queue grows with the distinct
+// spans exported rather than with the number of exports.
+type IngestQueue struct {
+	spans   sdktrace.SpanExporter
+	logs    sdklog.Exporter
+	metrics sdkmetric.Exporter
+
+	mu      sync.Mutex
+	batches []ingestBatch
+}
+
+type ingestBatch struct {
+	spans   []sdktrace.ReadOnlySpan
+	logs    []sdklog.Record
+	metrics *metricdata.ResourceMetrics
+
+	// spanIndex maps the batch's spans to their index in spans
+	spanIndex map[trace.SpanID]int
+}
+
+// coalesce merges the next batch into this one if they're of the same kind,
+// returning false otherwise.
+func (b *ingestBatch) coalesce(next ingestBatch) bool {
+	switch {
+	case b.spans != nil && next.spans != nil:
+		b.addSpans(next.spans)
+	case b.logs != nil && next.logs != nil:
+		b.logs = append(b.logs, next.logs...)
+	case b.metrics != nil && next.metrics != nil && b.metrics.Resource == next.metrics.Resource:
+		b.metrics.ScopeMetrics = append(b.metrics.ScopeMetrics, next.metrics.ScopeMetrics...)
+	default:
+		return false
+	}
+	return true
+}
+
+// addSpans adds spans to the batch, replacing the earlier state of spans
+// already in it.
+func (b *ingestBatch) addSpans(spans []sdktrace.ReadOnlySpan) {
+	if b.spanIndex == nil {
+		b.spanIndex = map[trace.SpanID]int{}
+	}
+	for _, span := range spans {
+		id := span.SpanContext().SpanID()
+		if i, ok := b.spanIndex[id]; ok {
+			b.spans[i] = span
+			continue
+		}
+		b.spanIndex[id] = len(b.spans)
+		b.spans = append(b.spans, span)
+	}
+}
+
+// NewIngestQueue creates a queue that flushes to the given exporters, which
+// typically write to a DB without locking it, as Flush is called under the
+// readers' lock.
+func NewIngestQueue(spans sdktrace.SpanExporter, logs sdklog.Exporter, metrics sdkmetric.Exporter) *IngestQueue {
+	return &IngestQueue{
+		spans:   spans,
+		logs:    logs,
+		metrics: metrics,
+	}
+}
+
+func (q *IngestQueue) push(batch ingestBatch) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if n := len(q.batches); n > 0 && q.batches[n-1].coalesce(batch) {
+		return
+	}
+	q.batches = append(q.batches, batch)
+}
+
+// ExportSpans queues spans. Spans are read-only, so they're kept as-is.
+func (q *IngestQueue) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	batch := ingestBatch{spans: make([]sdktrace.ReadOnlySpan, 0, len(spans))}
+	batch.addSpans(spans)
+	q.push(batch)
+	return nil
+}
+
+// ExportLogs queues log records, copying them as the caller may reuse them.
+func (q *IngestQueue) ExportLogs(ctx context.Context, logs []sdklog.Record) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	cp := make([]sdklog.Record, len(logs))
+	for i, log := range logs {
+		cp[i] = log.Clone()
+	}
+	q.push(ingestBatch{logs: cp})
+	return nil
+}
+
+// ExportMetrics queues metrics, copying them as the caller may reuse them.
+// Only int64 gauges are kept, since they're the only metrics a DB records.
+func (q *IngestQueue) ExportMetrics(ctx context.Context, resourceMetrics *metricdata.ResourceMetrics) error {
+	cp := &metricdata.ResourceMetrics{Resource: resourceMetrics.Resource}
+	for _, scopeMetrics := range resourceMetrics.ScopeMetrics {
+		scopeCp := metricdata.ScopeMetrics{Scope: scopeMetrics.Scope}
+		for _, metric := range scopeMetrics.Metrics {
+			gauge, ok := metric.Data.(metricdata.Gauge[int64])
+			if !ok {
+				continue
+			}
+			points := slices.Clone(gauge.DataPoints)
+			for i := range points {
+				points[i].Exemplars = slices.Clone(points[i].Exemplars)
+			}
+			metric.Data = metricdata.Gauge[int64]{DataPoints: points}
+			scopeCp.Metrics = append(scopeCp.Metrics, metric)
+		}
+		if len(scopeCp.Metrics) > 0 {
+			cp.ScopeMetrics = append(cp.ScopeMetrics, scopeCp)
+		}
+	}
+	if len(cp.ScopeMetrics) == 0 {
+		return nil
+	}
+	q.push(ingestBatch{metrics: cp})
+	return nil
+}
+
+// Len returns the number of batches waiting to be flushed.
+func (q *IngestQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.batches)
+}
+
+// Flush applies the queued telemetry to the exporters, returning how many
+// batches were applied, so callers can skip recomputing their view of the DB
+// when nothing changed. Telemetry exported while flushing waits for the next
+// Flush.
+func (q *IngestQueue) Flush(ctx context.Context) (int, error) {
+	q.mu.Lock()
+	batches := q.batches
+	q.batches = nil
+	q.mu.Unlock()
+
+	var errs []error
+	for _, batch := range batches {
+		var err error
+		switch {
+		case batch.spans != nil:
+			err = q.spans.ExportSpans(ctx, batch.spans)
+		case batch.logs != nil:
+			err = q.logs.Export(ctx, batch.logs)
+		case batch.metrics != nil:
+			err = q.metrics.Export(ctx, batch.metrics)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return len(batches), errors.Join(errs...)
+}
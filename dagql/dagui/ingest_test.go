@@ -0,0 +1,119 @@
+package dagui
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"dagger.io/dagger/telemetry"
+)
+
+func TestIngestQueue(t *testing.T) {
+	ctx := context.Background()
+	start := time.Now().Add(-time.Hour)
+	spanID := SpanID{SpanID: trace.SpanID{1}}
+	span := tracetest.SpanStub{
+		Name: "build",
+		SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: trace.TraceID{1},
+			SpanID:  spanID.SpanID,
+		}),
+		StartTime: start,
+		EndTime:   start.Add(time.Second),
+	}.Snapshot()
+
+	db := NewDB()
+	q := NewIngestQueue(db, db.LogExporter(), db.MetricExporter())
+
+	// nothing reaches the DB until it's flushed
+	require.NoError(t, q.ExportSpans(ctx, []sdktrace.ReadOnlySpan{span}))
+	var rec sdklog.Record
+	rec.SetSpanID(spanID.SpanID)
+	rec.SetBody(log.StringValue("building\n"))
+	logs := []sdklog.Record{rec}
+	require.NoError(t, q.ExportLogs(ctx, logs))
+	// the caller may reuse its records once exported
+	logs[0].SetBody(log.StringValue("reused\n"))
+	metrics := &metricdata.ResourceMetrics{ScopeMetrics: []metricdata.ScopeMetrics{{
+		Metrics: []metricdata.Metrics{{
+			Name: "disk.bytes",
+			Data: metricdata.Gauge[int64]{DataPoints: []metricdata.DataPoint[int64]{{
+				Attributes: attribute.NewSet(attribute.String(telemetry.DagDigestAttr, "sha256:abc")),
+				Value:      42,
+			}}},
+		}, {
+			Name: "ignored",
+			Data: metricdata.Sum[float64]{},
+		}},
+	}}}
+	require.NoError(t, q.ExportMetrics(ctx, metrics))
+	metrics.ScopeMetrics[0].Metrics[0].Data.(metricdata.Gauge[int64]).DataPoints[0].Value = 0
+	require.Equal(t, 3, q.Len())
+	require.Empty(t, db.Spans.Order)
+	require.Empty(t, db.LogTails)
+
+	n, err := q.Flush(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Zero(t, q.Len())
+	require.Len(t, db.Spans.Order, 1)
+	require.Equal(t, "building\n", string(db.LogTails[spanID]))
+	points := db.MetricsByCall["sha256:abc"]["disk.bytes"]
+	require.Len(t, points, 1)
+	require.Equal(t, int64(42), points[0].Value)
+
+	// flushing an empty queue applies nothing
+	n, err = q.Flush(ctx)
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestIngestQueueCoalesce(t *testing.T) {
+	ctx := context.Background()
+	start := time.Now().Add(-time.Hour)
+	stub := func(n byte, name string, end time.Duration) sdktrace.ReadOnlySpan {
+		return tracetest.SpanStub{
+			Name: name,
+			SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID: trace.TraceID{1},
+				SpanID:  trace.SpanID{n},
+			}),
+			StartTime: start,
+			EndTime:   start.Add(end),
+		}.Snapshot()
+	}
+	var rec sdklog.Record
+	rec.SetSpanID(trace.SpanID{1})
+	rec.SetBody(log.StringValue("building\n"))
+
+	db := NewDB()
+	q := NewIngestQueue(db, db.LogExporter(), db.MetricExporter())
+	require.NoError(t, q.ExportSpans(ctx, []sdktrace.ReadOnlySpan{stub(1, "build", 0)}))
+	require.NoError(t, q.ExportSpans(ctx, []sdktrace.ReadOnlySpan{stub(2, "test", 0)}))
+	// a span exported again replaces its queued state
+	require.NoError(t, q.ExportSpans(ctx, []sdktrace.ReadOnlySpan{stub(1, "build", time.Second)}))
+	require.NoError(t, q.ExportLogs(ctx, []sdklog.Record{rec}))
+	require.NoError(t, q.ExportLogs(ctx, []sdklog.Record{rec}))
+	require.NoError(t, q.ExportSpans(ctx, []sdktrace.ReadOnlySpan{stub(3, "lint", 0)}))
+
+	// spans, logs, then spans again, to keep logs after their span
+	require.Equal(t, 3, q.Len())
+	require.Len(t, q.batches[0].spans, 2)
+	require.Len(t, q.batches[1].logs, 2)
+
+	n, err := q.Flush(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Len(t, db.Spans.Order, 3)
+	require.Equal(t, start.Add(time.Second), db.Spans.Map[SpanID{SpanID: trace.SpanID{1}}].EndTime)
+	require.Equal(t, "building\nbuilding\n", string(db.LogTails[SpanID{SpanID: trace.SpanID{1}}]))
+}
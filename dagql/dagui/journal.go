@@ -0,0 +1,139 @@
+package dagui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// journalsDir holds the journals of runs in progress, relative to the store's
+// root. A journal is removed once its trace is saved, so any left behind are
+// from runs that didn't complete, e.g. because the CLI crashed.
+const journalsDir = "journals"
+
+// Journal is a DBStore that a run writes its trace to as it goes, so that the
+// trace can be resumed if the run never gets to save it.
+type Journal struct {
+	*BoltStore
+
+	Path string
+}
+
+// OpenJournal creates a journal for a run that's starting.
+func (store *TraceStore) OpenJournal() (*Journal, error) {
+	dir := filepath.Join(store.Root, journalsDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%d-%d.db", time.Now().UnixNano(), os.Getpid()))
+	bolt, err := OpenBoltStore(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Journal{BoltStore: bolt, Path: path}, nil
+}
+
+// Remove closes and deletes the journal, once its trace has been saved.
+func (journal *Journal) Remove() error {
+	if err := journal.Close(); err != nil {
+		return err
+	}
+	return os.Remove(journal.Path)
+}
+
+// JournalMeta describes a journal left behind by a run that didn't complete.
+type JournalMeta struct {
+	Path     string
+	TraceID  TraceID
+	Name     string
+	Modified time.Time
+}
+
+// Journals returns the journals left behind by runs that didn't complete,
+// most recently modified first. Journals of runs still in progress are locked
+// and left out.
+func (store *TraceStore) Journals() ([]JournalMeta, error) {
+	entries, err := os.ReadDir(filepath.Join(store.Root, journalsDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var metas []JournalMeta
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".db") {
+			continue
+		}
+		path := filepath.Join(store.Root, journalsDir, entry.Name())
+		db, err := readJournal(path)
+		if err != nil {
+			// in use or corrupt; skip
+			continue
+		}
+		primary := db.Spans.Map[db.PrimarySpan]
+		if primary == nil {
+			// nothing worth resuming
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		metas = append(metas, JournalMeta{
+			Path:     path,
+			TraceID:  primary.TraceID,
+			Name:     primary.Name,
+			Modified: info.ModTime(),
+		})
+	}
+	sort.Slice(metas, func(i, j int) bool {
+		return metas[i].Modified.After(metas[j].Modified)
+	})
+	return metas, nil
+}
+
+// Resume rehydrates the trace of a run that didn't complete from its journal,
+// saving it to the store alongside completed traces and removing the journal.
+// The special ID "latest" refers to the most recently modified journal.
+func (store *TraceStore) Resume(id string) (*DB, TraceMeta, error) {
+	journals, err := store.Journals()
+	if err != nil {
+		return nil, TraceMeta{}, err
+	}
+	var found *JournalMeta
+	for i, journal := range journals {
+		if id == "latest" || journal.TraceID.String() == id {
+			found = &journals[i]
+			break
+		}
+	}
+	if found == nil {
+		if id == "latest" {
+			return nil, TraceMeta{}, errors.New("no journals to resume")
+		}
+		return nil, TraceMeta{}, fmt.Errorf("no journal for trace %s", id)
+	}
+	db, err := readJournal(found.Path)
+	if err != nil {
+		return nil, TraceMeta{}, err
+	}
+	meta, err := store.Save(db)
+	if err != nil {
+		return nil, meta, err
+	}
+	return db, meta, os.Remove(found.Path)
+}
+
+func readJournal(path string) (*DB, error) {
+	bolt, err := OpenBoltStore(path)
+	if err != nil {
+		return nil, err
+	}
+	defer bolt.Close()
+	return RestoreDB(bolt)
+}
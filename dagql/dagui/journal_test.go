@@ -0,0 +1,74 @@
+package dagui
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestJournalResume(t *testing.T) {
+	store := NewTraceStore(t.TempDir())
+	journal, err := store.OpenJournal()
+	require.NoError(t, err)
+
+	traceID := TraceID{TraceID: trace.TraceID{1}}
+	root := SpanID{SpanID: trace.SpanID{1}}
+	child := SpanID{SpanID: trace.SpanID{2}}
+	start := time.Now().Add(-time.Minute)
+
+	db := NewDB()
+	// spans received before the store is set are written too
+	db.ImportSnapshots([]SpanSnapshot{{
+		ID:        root,
+		TraceID:   traceID,
+		Name:      "run",
+		StartTime: start,
+	}})
+	require.NoError(t, db.SetStore(journal))
+	db.SetPrimarySpan(root)
+	db.ImportSnapshots([]SpanSnapshot{{
+		ID:        child,
+		TraceID:   traceID,
+		ParentID:  root,
+		Name:      "build",
+		StartTime: start.Add(time.Second),
+		EndTime:   start.Add(2 * time.Second),
+	}})
+	var rec sdklog.Record
+	rec.SetSpanID(child.SpanID)
+	rec.SetBody(log.StringValue("building\n"))
+	require.NoError(t, db.LogExporter().Export(context.Background(), []sdklog.Record{rec}))
+
+	// the run crashes, leaving its journal behind
+	require.NoError(t, journal.Close())
+
+	journals, err := store.Journals()
+	require.NoError(t, err)
+	require.Len(t, journals, 1)
+	require.Equal(t, traceID, journals[0].TraceID)
+	require.Equal(t, "run", journals[0].Name)
+
+	resumed, meta, err := store.Resume("latest")
+	require.NoError(t, err)
+	require.Equal(t, traceID, meta.TraceID)
+	require.Equal(t, root, resumed.PrimarySpan)
+	require.Len(t, resumed.Spans.Order, 2)
+	require.Equal(t, "build", resumed.Spans.Map[child].Name)
+	require.Equal(t, root, resumed.Spans.Map[child].ParentSpan.ID)
+	require.Equal(t, "building\n", string(resumed.LogTails[child]))
+
+	// the journal is recorded as a regular trace
+	journals, err = store.Journals()
+	require.NoError(t, err)
+	require.Empty(t, journals)
+	_, _, err = store.Load(traceID.String())
+	require.NoError(t, err)
+
+	_, _, err = store.Resume("latest")
+	require.Error(t, err)
+}
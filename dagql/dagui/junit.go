@@ -0,0 +1,162 @@
+package dagui
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// junitLogLines is the number of log lines included with a failed test.
+const junitLogLines = 50
+
+// JUnitReport is a report of a run's test cases in JUnit XML format, for CI
+// systems that ingest it.
+type JUnitReport struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr,omitempty"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     junitSeconds `xml:"time,attr"`
+	Suites   []*JUnitSuite
+}
+
+// JUnitSuite is a suite of test cases, e.g. a package.
+type JUnitSuite struct {
+	XMLName  xml.Name     `xml:"testsuite"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     junitSeconds `xml:"time,attr"`
+	Cases    []JUnitCase
+}
+
+// JUnitCase is the outcome of a test case. At most one of Failure, Error,
+// and Skipped is set; none are for a passing test.
+type JUnitCase struct {
+	XMLName   xml.Name      `xml:"testcase"`
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      junitSeconds  `xml:"time,attr"`
+	Failure   *JUnitProblem `xml:"failure,omitempty"`
+	Error     *JUnitProblem `xml:"error,omitempty"`
+	Skipped   *JUnitProblem `xml:"skipped,omitempty"`
+}
+
+// JUnitProblem describes why a test case didn't pass.
+type JUnitProblem struct {
+	Message string `xml:"message,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// junitSeconds is a duration, written in seconds as JUnit expects.
+type junitSeconds time.Duration
+
+func (secs junitSeconds) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: name, Value: fmt.Sprintf("%.3f", time.Duration(secs).Seconds())}, nil
+}
+
+// JUnitReport reports the outcome of each span marked as a test case with
+// telemetry.TestCaseAttr, grouped by suite. Failed tests include the tail of
+// their logs. Like TAP, steps allowed or expected to fail are reported as
+// skipped, and so are failures of quarantined steps, depending on the
+// quarantine mode, while unexpected passes pass. Tests that were canceled or
+// didn't complete are reported as errors.
+func (db *DB) JUnitReport(quarantine QuarantineMode) JUnitReport {
+	var report JUnitReport
+	if primary := db.Spans.Map[db.PrimarySpan]; primary != nil {
+		report.Name = primary.Name
+	}
+	suites := map[string]*JUnitSuite{}
+	for _, span := range db.Spans.Order {
+		if !span.TestCase {
+			continue
+		}
+		suiteName := span.TestSuite
+		if suiteName == "" && span.ParentSpan != nil {
+			suiteName = span.ParentSpan.Name
+		}
+		suite, ok := suites[suiteName]
+		if !ok {
+			suite = &JUnitSuite{Name: suiteName}
+			suites[suiteName] = suite
+			report.Suites = append(report.Suites, suite)
+		}
+		tc := db.junitCase(span, quarantine)
+		tc.ClassName = suiteName
+		suite.Cases = append(suite.Cases, tc)
+		suite.Tests++
+		suite.Time += tc.Time
+		switch {
+		case tc.Failure != nil:
+			suite.Failures++
+		case tc.Error != nil:
+			suite.Errors++
+		case tc.Skipped != nil:
+			suite.Skipped++
+		}
+	}
+	for _, suite := range report.Suites {
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Skipped += suite.Skipped
+		report.Time += suite.Time
+	}
+	return report
+}
+
+func (db *DB) junitCase(span *Span, quarantine QuarantineMode) JUnitCase {
+	tc := JUnitCase{
+		Name: span.Name,
+		Time: junitSeconds(span.WallTime()),
+	}
+	failure := func() *JUnitProblem {
+		return &JUnitProblem{
+			Message: span.Status.Description,
+			Text:    db.LogTailLines(span, junitLogLines),
+		}
+	}
+	switch {
+	case span.IsRunningOrEffectsRunning():
+		tc.Error = &JUnitProblem{Message: "did not complete"}
+	case span.IsCanceled():
+		msg := "canceled"
+		if cause := span.CancelCause(); cause.By != "" {
+			msg = cause.String()
+		}
+		tc.Error = &JUnitProblem{Message: msg}
+	case span.IsSkipped():
+		_, reason := span.SkipReason()
+		tc.Skipped = &JUnitProblem{Message: reason}
+	case span.IsQuarantinedFailure(quarantine):
+		tc.Skipped = failure()
+		tc.Skipped.Message = strings.TrimSuffix("quarantined: "+tc.Skipped.Message, ": ")
+	case span.IsToleratedFailure() && span.FailureExpected:
+		tc.Skipped = &JUnitProblem{Message: "expected failure"}
+	case span.IsToleratedFailure():
+		tc.Skipped = &JUnitProblem{Message: "allowed failure"}
+	case span.IsFailedOrCausedFailure():
+		tc.Failure = failure()
+	}
+	return tc
+}
+
+// WriteXML writes the report as JUnit XML.
+func (report JUnitReport) WriteXML(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,70 @@
+package dagui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestJUnitReport(t *testing.T) {
+	span := testTrace{start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}.span
+	failed := func(snapshot SpanSnapshot, errMsg string) SpanSnapshot {
+		snapshot.Status = sdktrace.Status{Code: codes.Error, Description: errMsg}
+		return snapshot
+	}
+	test := func(n byte, name string, dur time.Duration) SpanSnapshot {
+		from := time.Duration(n) * time.Second
+		snapshot := span(n, 2, name, from, from+dur)
+		snapshot.TestCase = true
+		return snapshot
+	}
+
+	pass := test(3, "TestPass", 1500*time.Millisecond)
+	fail := failed(test(4, "TestFail", time.Second), "exit code: 1")
+	flaky := failed(test(5, "TestFlaky", time.Second), "timed out")
+	flaky.Quarantined = true
+	other := test(6, "lint", 250*time.Millisecond)
+	other.TestSuite = "checks"
+	notTest := span(7, 2, "setup", 7*time.Second, 8*time.Second)
+
+	db := NewDB()
+	db.SetPrimarySpan(testSpanID(1))
+	db.ImportSnapshots([]SpanSnapshot{
+		failed(span(1, 0, "run", time.Second, time.Second+time.Minute), "exit code: 1"),
+		failed(span(2, 1, "go test ./...", 2*time.Second, 2*time.Second+time.Minute), "exit code: 1"),
+		pass,
+		fail,
+		flaky,
+		other,
+		notTest,
+	})
+	db.LogTails[fail.ID] = []byte("--- FAIL: TestFail\n    want 1 < 2\n")
+
+	var out strings.Builder
+	require.NoError(t, db.JUnitReport(QuarantineWarn).WriteXML(&out))
+	require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="run" tests="4" failures="1" errors="0" skipped="1" time="3.750">
+  <testsuite name="go test ./..." tests="3" failures="1" errors="0" skipped="1" time="3.500">
+    <testcase name="TestPass" classname="go test ./..." time="1.500"></testcase>
+    <testcase name="TestFail" classname="go test ./..." time="1.000">
+      <failure message="exit code: 1">--- FAIL: TestFail&#xA;    want 1 &lt; 2</failure>
+    </testcase>
+    <testcase name="TestFlaky" classname="go test ./..." time="1.000">
+      <skipped message="quarantined: timed out"></skipped>
+    </testcase>
+  </testsuite>
+  <testsuite name="checks" tests="1" failures="0" errors="0" skipped="0" time="0.250">
+    <testcase name="lint" classname="checks" time="0.250"></testcase>
+  </testsuite>
+</testsuites>
+`, out.String())
+
+	// quarantined failures count when they fail the run
+	report := db.JUnitReport(QuarantineFail)
+	require.Equal(t, 2, report.Failures)
+	require.Zero(t, report.Skipped)
+}
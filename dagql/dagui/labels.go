@@ -0,0 +1,90 @@
+package dagui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"dagger.io/dagger/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// wellKnownLabels derives session labels from the labels the CLI detects from
+// the VCS and CI environment, in order of preference.
+var wellKnownLabels = []struct {
+	name string
+	keys []string
+}{
+	{"branch", []string{"dagger.io/vcs.change.branch", "dagger.io/git.branch"}},
+	{"pr", []string{"dagger.io/vcs.change.number"}},
+	{"user", []string{"dagger.io/vcs.triggerer.login", "dagger.io/git.author.name"}},
+}
+
+// trackLabels records the session labels set on a resource, keeping the
+// first value seen for each, since the client that started the session
+// reports first.
+func (db *DB) trackLabels(res *resource.Resource) {
+	set := res.Set()
+	for _, known := range wellKnownLabels {
+		if _, ok := db.Labels[known.name]; ok {
+			continue
+		}
+		for _, key := range known.keys {
+			if val, ok := set.Value(attribute.Key(key)); ok && val.Emit() != "" {
+				db.setLabel(known.name, val.Emit())
+				break
+			}
+		}
+	}
+	for _, kv := range res.Attributes() {
+		name, ok := strings.CutPrefix(string(kv.Key), telemetry.SessionLabelPrefix)
+		if !ok || name == "" {
+			continue
+		}
+		if _, ok := db.Labels[name]; !ok {
+			db.setLabel(name, kv.Value.Emit())
+		}
+	}
+}
+
+func (db *DB) setLabel(name, val string) {
+	if db.Labels == nil {
+		db.Labels = map[string]string{}
+	}
+	db.Labels[name] = val
+}
+
+// FormatLabels renders labels as space-separated name=value pairs, sorted by
+// name.
+func FormatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for _, name := range slices.Sorted(maps.Keys(labels)) {
+		pairs = append(pairs, name+"="+labels[name])
+	}
+	return strings.Join(pairs, " ")
+}
+
+// ParseLabels parses name=value pairs, e.g. from --label flags.
+func ParseLabels(pairs []string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, pair := range pairs {
+		name, val, ok := strings.Cut(pair, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid label %q: expected name=value", pair)
+		}
+		labels[name] = val
+	}
+	return labels, nil
+}
+
+// MatchesLabels reports whether the labels include all of the given ones.
+func MatchesLabels(labels, filter map[string]string) bool {
+	for name, val := range filter {
+		if got, ok := labels[name]; !ok || got != val {
+			return false
+		}
+	}
+	return true
+}
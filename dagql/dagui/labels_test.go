@@ -0,0 +1,75 @@
+package dagui
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dagger.io/dagger/telemetry"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSessionLabels(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	span := func(id byte, res *resource.Resource) sdktrace.ReadOnlySpan {
+		return tracetest.SpanStub{
+			Name: "span",
+			SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID: trace.TraceID{1},
+				SpanID:  trace.SpanID{id},
+			}),
+			StartTime: start.Add(time.Duration(id) * time.Second),
+			EndTime:   start.Add(time.Minute),
+			Resource:  res,
+		}.Snapshot()
+	}
+	cli := resource.NewSchemaless(
+		attribute.String("service.name", "dagger-cli"),
+		attribute.String("dagger.io/git.branch", "feature"),
+		attribute.String("dagger.io/vcs.change.branch", "fix-bug"),
+		attribute.String("dagger.io/vcs.change.number", "123"),
+		attribute.String("dagger.io/git.author.name", "Alex"),
+		attribute.String(telemetry.SessionLabelPrefix+"env", "ci"),
+	)
+	engine := resource.NewSchemaless(
+		attribute.String("service.name", "dagger-engine"),
+		attribute.String(telemetry.SessionLabelPrefix+"env", "dev"),
+	)
+	db := NewDB()
+	require.NoError(t, db.ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{
+		span(1, cli),
+		span(2, engine),
+	}))
+	want := map[string]string{
+		"branch": "fix-bug",
+		"pr":     "123",
+		"user":   "Alex",
+		"env":    "ci",
+	}
+	require.Equal(t, want, db.Labels)
+	require.Equal(t, "branch=fix-bug env=ci pr=123 user=Alex", FormatLabels(db.Labels))
+
+	// labels are stored with the trace
+	store := NewTraceStore(t.TempDir())
+	meta, err := store.Save(db)
+	require.NoError(t, err)
+	metas, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, metas, 1)
+	require.Equal(t, want, metas[0].Labels)
+	loaded, _, err := store.Load(meta.TraceID.String())
+	require.NoError(t, err)
+	require.Equal(t, want, loaded.Labels)
+
+	filter, err := ParseLabels([]string{"branch=fix-bug", "pr=123"})
+	require.NoError(t, err)
+	require.True(t, MatchesLabels(metas[0].Labels, filter))
+	require.False(t, MatchesLabels(metas[0].Labels, map[string]string{"pr": "124"}))
+	_, err = ParseLabels([]string{"bogus"})
+	require.Error(t, err)
+}
@@ -0,0 +1,71 @@
+package dagui
+
+import (
+	"slices"
+	"strings"
+)
+
+// Matrix is the grid of results of a matrix of runs, with a row for each
+// combination of values of all but the last axis, and a column for each value
+// of the last axis.
+type Matrix struct {
+	Axes []MatrixAxis
+	Rows []MatrixRow
+}
+
+// MatrixAxis is an axis of a matrix along with the values seen for it.
+type MatrixAxis struct {
+	Name   string
+	Values []string
+}
+
+// MatrixRow is a row of a matrix.
+type MatrixRow struct {
+	// Labels are the row's values for all but the last axis.
+	Labels []string
+
+	// Cells are the spans of the row's cells, one for each value of the last
+	// axis, or nil if there is no such cell.
+	Cells []*Span
+}
+
+// Matrix returns the matrix grouped by the span, or nil if it isn't one.
+func (span *Span) Matrix() *Matrix {
+	if len(span.MatrixAxes) == 0 {
+		return nil
+	}
+	matrix := &Matrix{}
+	for _, name := range span.MatrixAxes {
+		matrix.Axes = append(matrix.Axes, MatrixAxis{Name: name})
+	}
+	last := len(matrix.Axes) - 1
+	rows := map[string]int{}
+	cells := map[string]map[string]*Span{}
+	for _, child := range span.ChildSpans.Order {
+		if len(child.MatrixCell) != len(matrix.Axes) {
+			continue
+		}
+		values := make([]string, len(matrix.Axes))
+		for i, label := range child.MatrixCell {
+			_, val, _ := strings.Cut(label, "=")
+			values[i] = val
+			if !slices.Contains(matrix.Axes[i].Values, val) {
+				matrix.Axes[i].Values = append(matrix.Axes[i].Values, val)
+			}
+		}
+		key := strings.Join(values[:last], "\x00")
+		if _, ok := rows[key]; !ok {
+			rows[key] = len(matrix.Rows)
+			matrix.Rows = append(matrix.Rows, MatrixRow{Labels: values[:last]})
+			cells[key] = map[string]*Span{}
+		}
+		cells[key][values[last]] = child
+	}
+	for i, row := range matrix.Rows {
+		cols := cells[strings.Join(row.Labels, "\x00")]
+		for _, val := range matrix.Axes[last].Values {
+			matrix.Rows[i].Cells = append(matrix.Rows[i].Cells, cols[val])
+		}
+	}
+	return matrix
+}
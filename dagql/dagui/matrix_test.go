@@ -0,0 +1,62 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMatrix(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	traceID := TraceID{TraceID: trace.TraceID{1}}
+	root := SpanID{SpanID: trace.SpanID{1}}
+	snapshots := []SpanSnapshot{{
+		ID:         root,
+		TraceID:    traceID,
+		Name:       "test",
+		MatrixAxes: []string{"go", "os"},
+		StartTime:  start,
+		EndTime:    start.Add(time.Minute),
+	}}
+	for i, cell := range [][]string{
+		{"go=1.22", "os=linux"},
+		{"go=1.22", "os=darwin"},
+		{"go=1.23", "os=linux"},
+	} {
+		snapshot := SpanSnapshot{
+			ID:         SpanID{SpanID: trace.SpanID{byte(i + 2)}},
+			TraceID:    traceID,
+			ParentID:   root,
+			Name:       cell[0] + ", " + cell[1],
+			MatrixCell: cell,
+			StartTime:  start.Add(time.Duration(i+1) * time.Second),
+			EndTime:    start.Add(time.Minute),
+		}
+		if i == 1 {
+			snapshot.Status = sdktrace.Status{Code: codes.Error}
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	db := NewDB()
+	db.ImportSnapshots(snapshots)
+
+	matrix := db.Spans.Map[root].Matrix()
+	require.NotNil(t, matrix)
+	require.Equal(t, []MatrixAxis{
+		{Name: "go", Values: []string{"1.22", "1.23"}},
+		{Name: "os", Values: []string{"linux", "darwin"}},
+	}, matrix.Axes)
+	require.Len(t, matrix.Rows, 2)
+	require.Equal(t, []string{"1.22"}, matrix.Rows[0].Labels)
+	require.False(t, matrix.Rows[0].Cells[0].IsFailed())
+	require.True(t, matrix.Rows[0].Cells[1].IsFailed())
+	require.Equal(t, []string{"1.23"}, matrix.Rows[1].Labels)
+	require.NotNil(t, matrix.Rows[1].Cells[0])
+	require.Nil(t, matrix.Rows[1].Cells[1])
+
+	require.Nil(t, matrix.Rows[1].Cells[0].Matrix())
+}
@@ -0,0 +1,216 @@
+// Package metrics serves Prometheus metrics derived from the spans of a run,
+// so that CI systems can scrape a pipeline's health without an OTLP pipeline.
+//
+// Metrics are written in the Prometheus text exposition format, computed from
+// the DB on each scrape.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/dagger/dagger/dagql/dagui"
+	"github.com/dagger/dagger/engine/slog"
+)
+
+// Server serves metrics for the spans in a DB.
+type Server struct {
+	db *dagui.DB
+
+	// TraceStore, if set, has its disk usage reported along with the run's
+	// metrics.
+	TraceStore *dagui.TraceStore
+
+	// ingest queues exported spans, so that exporting never waits on a
+	// scrape; they're flushed to the DB while holding mu
+	ingest *dagui.IngestQueue
+
+	// held while reading or updating the DB
+	mu sync.Mutex
+
+	// durations of completed steps by operation, accumulated so that they
+	// keep counting up even as spans are pruned from the DB
+	durations map[string]*duration
+	counted   map[dagui.SpanID]bool
+
+	mux *http.ServeMux
+}
+
+type duration struct {
+	sum   float64
+	count int
+}
+
+var _ http.Handler = (*Server)(nil)
+
+// NewServer serves metrics for the spans in the DB, which must not be updated
+// other than through the server's exporter.
+func NewServer(db *dagui.DB) *Server {
+	s := &Server{
+		db:        db,
+		durations: map[string]*duration{},
+		counted:   map[dagui.SpanID]bool{},
+		mux:       http.NewServeMux(),
+	}
+	// only spans are exported to the server
+	s.ingest = dagui.NewIngestQueue(db, nil, nil)
+	s.mux.HandleFunc("GET /metrics", s.serveMetrics)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// SpanExporter returns an exporter that updates the metrics live.
+func (s *Server) SpanExporter() sdktrace.SpanExporter {
+	return serverSpanExporter{s}
+}
+
+type serverSpanExporter struct {
+	*Server
+}
+
+func (s serverSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	return s.ingest.ExportSpans(ctx, spans)
+}
+
+func (s serverSpanExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+func (s *Server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.WriteMetrics(w)
+}
+
+// WriteMetrics writes the current metrics in the Prometheus text format.
+func (s *Server) WriteMetrics(w io.Writer) error {
+	s.mu.Lock()
+	if _, err := s.ingest.Flush(context.Background()); err != nil {
+		slog.Warn("failed to ingest spans", "error", err)
+	}
+	counts := s.db.SpanCounts()
+	s.countDurations()
+	durations := make(map[string]duration, len(s.durations))
+	for op, d := range s.durations {
+		durations[op] = *d
+	}
+	s.mu.Unlock()
+
+	m := &writer{w: w}
+	m.metric("dagger_steps_running", "gauge", "Steps currently running.")
+	m.sample("dagger_steps_running", nil, float64(counts.Running))
+	m.metric("dagger_steps_pending", "gauge", "Steps waiting on other steps or resources.")
+	m.sample("dagger_steps_pending", nil, float64(counts.Pending))
+
+	m.metric("dagger_steps_completed_total", "counter", "Steps completed, by outcome.")
+	for _, outcome := range []struct {
+		name  string
+		count int
+	}{
+		// Done includes cached steps
+		{"succeeded", counts.Done - counts.Cached},
+		{"cached", counts.Cached},
+		{"failed", counts.Failed},
+		{"skipped", counts.Skipped},
+	} {
+		m.sample("dagger_steps_completed_total", []string{"outcome", outcome.name}, float64(outcome.count))
+	}
+
+	m.metric("dagger_cache_hit_ratio", "gauge", "Ratio of successful steps that were cached.")
+	var ratio float64
+	if counts.Done > 0 {
+		ratio = float64(counts.Cached) / float64(counts.Done)
+	}
+	m.sample("dagger_cache_hit_ratio", nil, ratio)
+
+	m.metric("dagger_step_duration_seconds", "summary", "Duration of completed steps, by operation.")
+	for _, op := range slices.Sorted(maps.Keys(durations)) {
+		d := durations[op]
+		m.sample("dagger_step_duration_seconds_sum", []string{"operation", op}, d.sum)
+		m.sample("dagger_step_duration_seconds_count", []string{"operation", op}, float64(d.count))
+	}
+
+	if s.TraceStore != nil {
+		usage, err := s.TraceStore.Usage()
+		if err != nil {
+			return err
+		}
+		m.metric("dagger_trace_store_traces", "gauge", "Traces recorded in the local trace store.")
+		m.sample("dagger_trace_store_traces", nil, float64(usage.Traces))
+		m.metric("dagger_trace_store_bytes", "gauge", "Disk space used by the local trace store.")
+		m.sample("dagger_trace_store_bytes", nil, float64(usage.Bytes))
+	}
+	return m.err
+}
+
+// countDurations adds the durations of steps that completed since the last
+// call.
+func (s *Server) countDurations() {
+	for _, span := range s.db.Spans.Order {
+		if s.counted[span.ID] ||
+			span.Call == nil ||
+			span.IsInternal() ||
+			span.IsRunningOrEffectsRunning() ||
+			span.IsPending() ||
+			span.EndTime.Before(span.StartTime) {
+			continue
+		}
+		s.counted[span.ID] = true
+		op := s.db.OperationName(span)
+		d := s.durations[op]
+		if d == nil {
+			d = &duration{}
+			s.durations[op] = d
+		}
+		d.sum += span.EndTime.Sub(span.StartTime).Seconds()
+		d.count++
+	}
+}
+
+// writer writes metrics in the text exposition format, keeping the first
+// error.
+type writer struct {
+	w   io.Writer
+	err error
+}
+
+func (m *writer) metric(name, typ, help string) {
+	m.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes a sample with the given label name/value pairs.
+func (m *writer) sample(name string, labels []string, value float64) {
+	var sb strings.Builder
+	sb.WriteString(name)
+	if len(labels) > 0 {
+		sb.WriteString("{")
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			fmt.Fprintf(&sb, `%s="%s"`, labels[i], labelEscaper.Replace(labels[i+1]))
+		}
+		sb.WriteString("}")
+	}
+	m.printf("%s %v\n", sb.String(), value)
+}
+
+func (m *writer) printf(format string, args ...any) {
+	if m.err != nil {
+		return
+	}
+	_, m.err = fmt.Fprintf(m.w, format, args...)
+}
+
+// labelEscaper escapes label values as the text exposition format expects.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
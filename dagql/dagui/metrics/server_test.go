@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dagger.io/dagger/telemetry"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dagger/dagger/dagql/call/callpbv1"
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+func TestServeMetrics(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	traceID := dagui.TraceID{TraceID: trace.TraceID{1}}
+	id := func(n byte) dagui.SpanID { return dagui.SpanID{SpanID: trace.SpanID{n}} }
+	span := func(n byte, recv, field string, from, to time.Duration) dagui.SpanSnapshot {
+		call := &callpbv1.Call{
+			Digest:         string(rune('a' + n)),
+			ReceiverDigest: recv,
+			Field:          field,
+			Type:           &callpbv1.Type{NamedType: "Container"},
+		}
+		payload, err := call.Encode()
+		require.NoError(t, err)
+		snapshot := dagui.SpanSnapshot{
+			ID:          id(n),
+			TraceID:     traceID,
+			Name:        field,
+			CallDigest:  call.Digest,
+			CallPayload: payload,
+			StartTime:   start.Add(from),
+		}
+		if to > 0 {
+			snapshot.EndTime = start.Add(to)
+		}
+		return snapshot
+	}
+
+	db := dagui.NewDB()
+	failed := span(3, "b", "withExec", 3*time.Second, 4*time.Second)
+	failed.Status = sdktrace.Status{Code: codes.Error}
+	db.ImportSnapshots([]dagui.SpanSnapshot{
+		span(1, "", "container", 0, time.Second),
+		span(2, "b", "withExec", time.Second, 3*time.Second),
+		failed,
+		span(4, "b", "withExec", 4*time.Second, 0),
+	})
+	srv := NewServer(db)
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, 200, rec.Code)
+	body := rec.Body.String()
+	for _, line := range []string{
+		`dagger_steps_running 1`,
+		`dagger_steps_completed_total{outcome="succeeded"} 2`,
+		`dagger_steps_completed_total{outcome="failed"} 1`,
+		`dagger_cache_hit_ratio 0`,
+		`dagger_step_duration_seconds_sum{operation="Query.container"} 1`,
+		`dagger_step_duration_seconds_sum{operation="Container.withExec"} 3`,
+		`dagger_step_duration_seconds_count{operation="Container.withExec"} 2`,
+	} {
+		require.Contains(t, strings.Split(body, "\n"), line)
+	}
+
+	// durations keep counting up as steps complete
+	done := span(4, "b", "withExec", 4*time.Second, 8*time.Second)
+	done.Version = 1
+	db.ImportSnapshots([]dagui.SpanSnapshot{done})
+	var sb strings.Builder
+	require.NoError(t, srv.WriteMetrics(&sb))
+	require.Contains(t, strings.Split(sb.String(), "\n"), `dagger_step_duration_seconds_count{operation="Container.withExec"} 3`)
+}
+
+func TestServeTraceStoreMetrics(t *testing.T) {
+	root := dagui.SpanID{SpanID: trace.SpanID{1}}
+	db := dagui.NewDB()
+	db.SetPrimarySpan(root)
+	db.ImportSnapshots([]dagui.SpanSnapshot{{
+		ID:        root,
+		TraceID:   dagui.TraceID{TraceID: trace.TraceID{1}},
+		Name:      "run",
+		StartTime: time.Now().Add(-time.Minute),
+		EndTime:   time.Now(),
+	}})
+	store := dagui.NewTraceStore(t.TempDir())
+	_, err := store.Save(db)
+	require.NoError(t, err)
+	usage, err := store.Usage()
+	require.NoError(t, err)
+
+	srv := NewServer(dagui.NewDB())
+	srv.TraceStore = store
+	var out strings.Builder
+	require.NoError(t, srv.WriteMetrics(&out))
+	require.Contains(t, out.String(), "dagger_trace_store_traces 1\n")
+	require.Contains(t, out.String(), fmt.Sprintf("dagger_trace_store_bytes %v\n", float64(usage.Bytes)))
+}
+
+func TestExportDuringScrape(t *testing.T) {
+	srv := NewServer(dagui.NewDB())
+	start := time.Now().Add(-time.Hour)
+	call := &callpbv1.Call{
+		Digest: "a",
+		Field:  "container",
+		Type:   &callpbv1.Type{NamedType: "Container"},
+	}
+	payload, err := call.Encode()
+	require.NoError(t, err)
+	span := tracetest.SpanStub{
+		Name: "container",
+		SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: trace.TraceID{1},
+			SpanID:  trace.SpanID{1},
+		}),
+		StartTime: start,
+		EndTime:   start.Add(time.Second),
+		Attributes: []attribute.KeyValue{
+			attribute.String(telemetry.DagDigestAttr, call.Digest),
+			attribute.String(telemetry.DagCallAttr, payload),
+		},
+	}.Snapshot()
+
+	// exporting doesn't wait for a scrape in progress
+	srv.mu.Lock()
+	exported := make(chan error)
+	go func() {
+		exported <- srv.SpanExporter().ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{span})
+	}()
+	select {
+	case err := <-exported:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("export blocked on a scrape")
+	}
+	srv.mu.Unlock()
+
+	// and shows up in the next one
+	var sb strings.Builder
+	require.NoError(t, srv.WriteMetrics(&sb))
+	require.Contains(t, strings.Split(sb.String(), "\n"), `dagger_steps_completed_total{outcome="succeeded"} 1`)
+}
@@ -0,0 +1,154 @@
+package dagui
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/dagger/dagger/dagql/call/callpbv1"
+)
+
+// SpanNames customizes the titles of spans for calls to particular functions,
+// keyed by "Type.function" or just "function".
+//
+// Templates are written as:
+//
+//	withExec={{join .Args.args " "}}
+//	Container.from=pull {{.Args.address}}
+type SpanNames map[string]*template.Template
+
+// SpanNameData is the data available to span name templates.
+type SpanNameData struct {
+	// Name is the span's default name.
+	Name string
+	// Type is the type the function was called on, e.g. Container.
+	Type string
+	// Function is the name of the function, e.g. withExec.
+	Function string
+	// Module is the name of the module implementing the function, if any.
+	Module string
+	// Args are the function's arguments. Strings, numbers, and booleans are
+	// passed as-is, lists as slices, input objects as maps, and objects by
+	// their call digest.
+	Args map[string]any
+}
+
+var spanNameFuncs = template.FuncMap{
+	"join": func(vals any, sep string) string {
+		list, ok := vals.([]any)
+		if !ok {
+			return fmt.Sprint(vals)
+		}
+		strs := make([]string, len(list))
+		for i, val := range list {
+			strs[i] = fmt.Sprint(val)
+		}
+		return strings.Join(strs, sep)
+	},
+	"trunc": func(n int, val any) string {
+		str := fmt.Sprint(val)
+		if len(str) > n {
+			return str[:n] + "…"
+		}
+		return str
+	},
+}
+
+// Set parses and adds a template written as key=template.
+func (names SpanNames) Set(str string) error {
+	key, text, ok := strings.Cut(str, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("invalid span name %q: expected function=template", str)
+	}
+	tmpl, err := template.New(key).Funcs(spanNameFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid span name %q: %w", str, err)
+	}
+	names[key] = tmpl
+	return nil
+}
+
+// OperationName returns the name of the API the span called, e.g.
+// "Container.withExec", or an empty string if it isn't a call.
+func (db *DB) OperationName(span *Span) string {
+	if span.Call == nil {
+		return ""
+	}
+	return db.callOperation(span.Call)
+}
+
+func (db *DB) callOperation(call *callpbv1.Call) string {
+	return db.receiverType(call) + "." + call.Field
+}
+
+func (db *DB) receiverType(call *callpbv1.Call) string {
+	if call.ReceiverDigest == "" {
+		return "Query"
+	}
+	return db.MustCall(call.ReceiverDigest).Type.NamedType
+}
+
+// Name returns the custom name for the span, if a template applies to it.
+func (names SpanNames) Name(db *DB, span *Span) (string, bool) {
+	if len(names) == 0 || span.Call == nil {
+		return "", false
+	}
+	call := span.Call
+	typeName := db.receiverType(call)
+	tmpl, ok := names[typeName+"."+call.Field]
+	if !ok {
+		tmpl, ok = names[call.Field]
+	}
+	if !ok {
+		return "", false
+	}
+	data := SpanNameData{
+		Name:     span.Name,
+		Type:     typeName,
+		Function: call.Field,
+		Args:     make(map[string]any, len(call.Args)),
+	}
+	if call.Module != nil {
+		data.Module = call.Module.Name
+	}
+	for _, arg := range call.Args {
+		data.Args[arg.GetName()] = literalValue(arg.GetValue())
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		// fall back to the default rendering
+		return "", false
+	}
+	return sb.String(), true
+}
+
+func literalValue(lit *callpbv1.Literal) any {
+	switch val := lit.GetValue().(type) {
+	case *callpbv1.Literal_Bool:
+		return val.Bool
+	case *callpbv1.Literal_Int:
+		return val.Int
+	case *callpbv1.Literal_Float:
+		return val.Float
+	case *callpbv1.Literal_String_:
+		return val.String_
+	case *callpbv1.Literal_Enum:
+		return val.Enum
+	case *callpbv1.Literal_CallDigest:
+		return val.CallDigest
+	case *callpbv1.Literal_List:
+		vals := make([]any, len(val.List.GetValues()))
+		for i, elem := range val.List.GetValues() {
+			vals[i] = literalValue(elem)
+		}
+		return vals
+	case *callpbv1.Literal_Object:
+		fields := make(map[string]any, len(val.Object.GetValues()))
+		for _, field := range val.Object.GetValues() {
+			fields[field.GetName()] = literalValue(field.GetValue())
+		}
+		return fields
+	default:
+		return nil
+	}
+}
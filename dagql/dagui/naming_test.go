@@ -0,0 +1,124 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/dagger/dagger/dagql/call"
+)
+
+func TestSpanNamesSet(t *testing.T) {
+	names := SpanNames{}
+	require.NoError(t, names.Set("Container.from=pull {{.Args.address}}"))
+	require.NoError(t, names.Set("withExec={{join .Args.args \" \"}}"))
+	// templates may contain '='
+	require.NoError(t, names.Set("build=a=b"))
+	require.Len(t, names, 3)
+
+	for _, bad := range []string{
+		"withExec",
+		"={{.Name}}",
+		"withExec={{.Name",
+		"withExec={{nope .Name}}",
+	} {
+		require.Error(t, names.Set(bad), bad)
+	}
+	require.Len(t, names, 3)
+}
+
+func TestSpanNamesName(t *testing.T) {
+	ctrType := &ast.Type{NamedType: "Container", NonNull: true}
+	dirType := &ast.Type{NamedType: "Directory", NonNull: true}
+	src := call.New().Append(dirType, "directory", "", nil, false, 0, "")
+	ctr := call.New().Append(ctrType, "container", "", nil, false, 0, "")
+	from := ctr.Append(ctrType, "from", "", nil, false, 0, "",
+		call.NewArgument("address", call.NewLiteralString("alpine"), false))
+	mod := call.NewModule(call.New().Append(&ast.Type{NamedType: "Module", NonNull: true}, "module", "", nil, false, 0, ""),
+		"ci", "github.com/acme/ci@main", "abc123")
+	exec := from.Append(ctrType, "withExec", "", mod, false, 0, "",
+		call.NewArgument("args", call.NewLiteralList(call.NewLiteralString("go"), call.NewLiteralString("test")), false),
+		call.NewArgument("opts", call.NewLiteralObject(call.NewArgument("depth", call.NewLiteralInt(2), false)), false),
+		call.NewArgument("source", call.NewLiteralID(src), false))
+
+	tr := testTrace{start: time.Now().Add(-time.Hour)}
+	snapshots := []SpanSnapshot{tr.span(1, 0, "run", 0, time.Minute)}
+	for i, id := range []*call.ID{src, ctr, from, exec} {
+		payload, err := id.Call().Encode()
+		require.NoError(t, err)
+		snapshot := tr.span(byte(i+2), 1, id.Field(), 0, time.Second)
+		snapshot.CallDigest = string(id.Digest())
+		snapshot.CallPayload = payload
+		snapshots = append(snapshots, snapshot)
+	}
+	db := NewDB()
+	db.SetPrimarySpan(testSpanID(1))
+	db.ImportSnapshots(snapshots)
+	ctrSpan := db.Spans.Map[testSpanID(3)]
+	fromSpan := db.Spans.Map[testSpanID(4)]
+	execSpan := db.Spans.Map[testSpanID(5)]
+
+	name := func(tmpl string, span *Span) (string, bool) {
+		names := SpanNames{}
+		require.NoError(t, names.Set(tmpl))
+		return names.Name(db, span)
+	}
+
+	for tmpl, expected := range map[string]string{
+		"withExec={{.Name}} {{.Type}}.{{.Function}} from {{.Module}}":   "withExec Container.withExec from ci",
+		"withExec={{join .Args.args \" \"}}":                            "go test",
+		"withExec=depth {{.Args.opts.depth}}":                           "depth 2",
+		"withExec={{trunc 3 (join .Args.args \" \")}}":                  "go …",
+		"withExec={{eq .Args.source \"" + string(src.Digest()) + "\"}}": "true",
+	} {
+		actual, ok := name(tmpl, execSpan)
+		require.True(t, ok, tmpl)
+		require.Equal(t, expected, actual, tmpl)
+	}
+
+	// calls made on Query are keyed by "Query.function"
+	actual, ok := name("Query.container=new container", ctrSpan)
+	require.True(t, ok)
+	require.Equal(t, "new container", actual)
+
+	// "Type.function" takes precedence over "function"
+	names := SpanNames{}
+	require.NoError(t, names.Set("from=any from"))
+	require.NoError(t, names.Set("Container.from=pull {{.Args.address}}"))
+	actual, ok = names.Name(db, fromSpan)
+	require.True(t, ok)
+	require.Equal(t, "pull alpine", actual)
+
+	// no template for the function, or no call at all
+	_, ok = names.Name(db, execSpan)
+	require.False(t, ok)
+	_, ok = names.Name(db, db.Spans.Map[testSpanID(1)])
+	require.False(t, ok)
+	_, ok = SpanNames(nil).Name(db, fromSpan)
+	require.False(t, ok)
+
+	// a template that fails to execute falls back to the default name
+	_, ok = name("withExec={{index .Args.args 5}}", execSpan)
+	require.False(t, ok)
+	names = SpanNames{}
+	require.NoError(t, names.Set("withExec={{index .Args.args 5}}"))
+	require.NoError(t, names.Set("Container.from=pull {{.Args.address}}"))
+	rows := db.VisibleTree(FrontendOpts{
+		ZoomedSpan: testSpanID(1),
+		Verbosity:  ShowCompletedVerbosity,
+		SpanNames:  names,
+	})
+	var visible []string
+	var collect func([]*VisibleSpan)
+	collect = func(spans []*VisibleSpan) {
+		for _, span := range spans {
+			visible = append(visible, span.Name)
+			collect(span.Children)
+		}
+	}
+	collect(rows)
+	require.Contains(t, visible, "pull alpine")
+	require.Contains(t, visible, "withExec")
+}
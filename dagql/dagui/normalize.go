@@ -0,0 +1,70 @@
+package dagui
+
+import "time"
+
+// Corrections describe how a span's times were normalized at ingestion.
+//
+// Spans with bogus times are common in truncated exports, e.g. a span whose
+// end was recorded by a different clock, or whose start was lost. Rather than
+// rendering negative durations, their times are corrected as well as the
+// span's own events allow, since those are recorded in order by the same
+// process, and the span is flagged so the correction isn't mistaken for real
+// data.
+const (
+	// CorrectionStartFromEvents is a span with no start time, whose start
+	// was taken from its earliest event.
+	CorrectionStartFromEvents = "start-from-events"
+
+	// CorrectionStartFromEnd is a span with no start time and no events,
+	// whose start was set to its end, so that it has no duration.
+	CorrectionStartFromEnd = "start-from-end"
+
+	// CorrectionEndFromEvents is a span that ended before it started, whose
+	// end was taken from its latest event.
+	CorrectionEndFromEvents = "end-from-events"
+
+	// CorrectionEndFromStart is a span that ended before it started and has
+	// no later events, whose end was clamped to its start, so that it has no
+	// duration.
+	CorrectionEndFromStart = "end-from-start"
+)
+
+// normalizeTimes corrects a snapshot's start and end times if they can't be
+// right, returning the correction made, if any.
+func (snapshot *SpanSnapshot) normalizeTimes() string {
+	if snapshot.EndTime.IsZero() {
+		// still running; nothing to go on yet
+		return ""
+	}
+	var first, last time.Time
+	for _, event := range snapshot.Events {
+		if first.IsZero() || event.Time.Before(first) {
+			first = event.Time
+		}
+		if event.Time.After(last) {
+			last = event.Time
+		}
+	}
+	switch {
+	case snapshot.StartTime.IsZero() && !first.IsZero() && !first.After(snapshot.EndTime):
+		snapshot.StartTime = first
+		return CorrectionStartFromEvents
+	case snapshot.StartTime.IsZero():
+		snapshot.StartTime = snapshot.EndTime
+		return CorrectionStartFromEnd
+	case !snapshot.EndTime.Before(snapshot.StartTime):
+		return ""
+	case last.After(snapshot.StartTime):
+		snapshot.EndTime = last
+		return CorrectionEndFromEvents
+	default:
+		snapshot.EndTime = snapshot.StartTime
+		return CorrectionEndFromStart
+	}
+}
+
+// CorrectedSpans returns the number of spans whose times were corrected at
+// ingestion. See SpanSnapshot.Corrected.
+func (db *DB) CorrectedSpans() int {
+	return len(db.correctedSpans)
+}
@@ -0,0 +1,61 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNormalizeTimes(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	for _, tc := range []struct {
+		name       string
+		start, end time.Time
+		events     []time.Time
+		correction string
+		wantStart  time.Time
+		wantEnd    time.Time
+	}{
+		{"valid", start, start.Add(time.Minute), nil, "", start, start.Add(time.Minute)},
+		{"running", start, time.Time{}, nil, "", start, time.Time{}},
+		{"zero duration", start, start, nil, "", start, start},
+		{"end before start", start, start.Add(-time.Minute), nil,
+			CorrectionEndFromStart, start, start},
+		{"end before start with events", start, start.Add(-time.Minute),
+			[]time.Time{start.Add(time.Second), start.Add(2 * time.Second)},
+			CorrectionEndFromEvents, start, start.Add(2 * time.Second)},
+		{"missing start with events", time.Time{}, start.Add(time.Minute),
+			[]time.Time{start.Add(time.Second), start},
+			CorrectionStartFromEvents, start, start.Add(time.Minute)},
+		{"missing start", time.Time{}, start, nil,
+			CorrectionStartFromEnd, start, start},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			snapshot := SpanSnapshot{StartTime: tc.start, EndTime: tc.end}
+			for _, ev := range tc.events {
+				snapshot.Events = append(snapshot.Events, SpanEvent{Name: "ev", Time: ev})
+			}
+			require.Equal(t, tc.correction, snapshot.normalizeTimes())
+			require.Equal(t, tc.wantStart, snapshot.StartTime)
+			require.Equal(t, tc.wantEnd, snapshot.EndTime)
+		})
+	}
+
+	db := NewDB()
+	db.ImportSnapshots([]SpanSnapshot{{
+		ID:        SpanID{SpanID: trace.SpanID{1}},
+		Name:      "backwards",
+		StartTime: start,
+		EndTime:   start.Add(-time.Minute),
+	}, {
+		ID:        SpanID{SpanID: trace.SpanID{2}},
+		Name:      "fine",
+		StartTime: start,
+		EndTime:   start.Add(time.Minute),
+	}})
+	require.Equal(t, 1, db.CorrectedSpans())
+	require.Equal(t, CorrectionEndFromStart, db.Spans.Map[SpanID{SpanID: trace.SpanID{1}}].Corrected)
+	require.Equal(t, "0.0s", FormatDuration(-time.Second))
+}
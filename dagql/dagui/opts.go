@@ -1,6 +1,8 @@
 package dagui
 
 import (
+	"context"
+	"fmt"
 	"slices"
 	"time"
 )
@@ -30,6 +32,14 @@ type FrontendOpts struct {
 	// Run a custom function on exit.
 	CustomExit func()
 
+	// CancelSpan cancels the call running in a span, leaving the rest of the
+	// run going. It is nil if not supported.
+	CancelSpan func(context.Context, SpanID) error
+
+	// PauseRun pauses or resumes the run, holding back new execs while
+	// letting running ones finish. It is nil if not supported.
+	PauseRun func(ctx context.Context, paused bool) error
+
 	// DotOutputFilePath is the path to write the DOT output to after execution, if any
 	DotOutputFilePath string
 
@@ -45,6 +55,89 @@ type FrontendOpts struct {
 
 	// FocusedSpan is the currently selected span, i.e. the cursor position.
 	FocusedSpan SpanID
+
+	// RevealedSpans are shown even if they would otherwise be hidden, e.g.
+	// because they're encapsulated, and expanded if they have a revealed
+	// child. See Reveal.
+	RevealedSpans map[SpanID]bool
+
+	// SpanNames customizes the titles of spans for particular functions.
+	SpanNames SpanNames
+
+	// Glyphs are the symbols used to show the status of each step.
+	Glyphs StatusGlyphs
+
+	// Durations are the durations of spans in previous runs, used to show an
+	// estimate of the time remaining for running spans.
+	Durations *DurationHistory
+
+	// Store persists the telemetry received by the frontend as it arrives, so
+	// the trace can be resumed if the frontend doesn't complete.
+	Store DBStore
+
+	// TerminalProgress reports the run's overall progress to the terminal
+	// emulator, e.g. to show in its tab or taskbar.
+	TerminalProgress bool
+
+	// TerminalBell rings the terminal's bell once the run completes, e.g. for
+	// tmux to flag the window. It requires TerminalProgress.
+	TerminalBell bool
+
+	// Quarantine configures how failures of quarantined steps are reported.
+	Quarantine QuarantineMode
+
+	// Retention limits the spans kept in memory, for long-running sessions.
+	Retention RetentionPolicy
+
+	// CacheReport prints an analysis of the run's use of the cache once it
+	// completes.
+	CacheReport bool
+
+	// DebugEffects prints the effects installed by the run, and which spans
+	// satisfied them, once it completes, to debug spans stuck pending.
+	DebugEffects bool
+
+	// Deadline is when the run times out, if it has a timeout.
+	Deadline time.Time
+
+	// SlowThresholds are how long spans may run before they're highlighted
+	// as slow.
+	SlowThresholds SlowThresholds
+
+	// OnSlowSpan is called once for each span that runs longer than its
+	// threshold, e.g. to send a notification. It's called in its own
+	// goroutine.
+	OnSlowSpan func(SlowSpan)
+
+	// AggregateCalls shows sibling spans of the same call, e.g. the identical
+	// steps of a parallel matrix build, as a single row summarizing them,
+	// which expands to a row for each.
+	AggregateCalls bool
+}
+
+// QuarantineMode configures how failures of quarantined steps are reported.
+type QuarantineMode string
+
+const (
+	// QuarantineWarn reports failures of quarantined steps as warnings. It
+	// is the default.
+	QuarantineWarn QuarantineMode = "warn"
+
+	// QuarantineFail reports failures of quarantined steps like any other.
+	QuarantineFail QuarantineMode = "fail"
+)
+
+// ParseQuarantineMode parses a quarantine mode, defaulting to
+// QuarantineWarn.
+func ParseQuarantineMode(str string) (QuarantineMode, error) {
+	switch mode := QuarantineMode(str); mode {
+	case "":
+		return QuarantineWarn, nil
+	case QuarantineWarn, QuarantineFail:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid quarantine mode %q: must be %s or %s", str, QuarantineWarn, QuarantineFail)
+	}
 }
 
 const (
@@ -58,6 +151,15 @@ const (
 	ShowMetricsVerbosity      = 3
 )
 
+// Reveal reveals the span and its ancestors, so that it's shown even if it's
+// hidden or within a collapsed subtree, replacing any spans revealed before.
+func (opts *FrontendOpts) Reveal(span *Span) {
+	opts.RevealedSpans = map[SpanID]bool{}
+	for ; span != nil; span = span.ParentSpan {
+		opts.RevealedSpans[span.ID] = true
+	}
+}
+
 func (opts FrontendOpts) ShouldShow(db *DB, span *Span) bool {
 	if opts.Debug {
 		// debug reveals all
@@ -67,6 +169,9 @@ func (opts FrontendOpts) ShouldShow(db *DB, span *Span) bool {
 		// prevent focused span from disappearing
 		return true
 	}
+	if opts.RevealedSpans[span.ID] {
+		return true
+	}
 	if span.Ignore {
 		// absolutely 100% boring spans, like 'id' and 'sync'
 		//
@@ -110,7 +215,7 @@ func (opts FrontendOpts) ShouldShow(db *DB, span *Span) bool {
 	// }
 	if opts.GCThreshold > 0 &&
 		time.Since(span.EndTime) > opts.GCThreshold &&
-		opts.Verbosity < ShowCompletedVerbosity {
+		span.Verbosity(opts) < ShowCompletedVerbosity {
 		// stop showing steps that ended after a given threshold
 		return false
 	}
@@ -0,0 +1,435 @@
+package dagui
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"dagger.io/dagger/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	otlpcommonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	otlplogsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	otlpmetricsv1 "go.opentelemetry.io/proto/otlp/metrics/v1"
+	otlpresourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	otlptracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// otlpScope is the instrumentation scope of exported telemetry. The original
+// scopes aren't kept.
+var otlpScope = &otlpcommonv1.InstrumentationScope{Name: "dagger.io/dagui"}
+
+// OTLPExport is the telemetry of a DB as OTLP export requests, as sent to an
+// OTLP collector.
+type OTLPExport struct {
+	Traces  *coltracepb.ExportTraceServiceRequest
+	Logs    *collogspb.ExportLogsServiceRequest
+	Metrics *colmetricspb.ExportMetricsServiceRequest
+}
+
+// OTLP converts the DB's telemetry back into OTLP, so that it can be imported
+// into other tools after the fact.
+//
+// Spans received directly keep their raw attributes and resource. Spans
+// loaded from snapshots, e.g. from a TraceStore, have theirs rebuilt from the
+// fields of the snapshot, so attributes that dagui doesn't track are lost.
+// Only the tail of each span's logs is kept, as a single log record.
+func (db *DB) OTLP() *OTLPExport {
+	export := &OTLPExport{
+		Traces:  &coltracepb.ExportTraceServiceRequest{},
+		Logs:    &collogspb.ExportLogsServiceRequest{},
+		Metrics: &colmetricspb.ExportMetricsServiceRequest{},
+	}
+
+	// group spans by the resource they were emitted with, in order of first
+	// appearance
+	var resources []*otlptracev1.ResourceSpans
+	byResource := map[string]*otlptracev1.ResourceSpans{}
+	for _, span := range db.Spans.Order {
+		if !span.Received {
+			continue
+		}
+		var res []attribute.KeyValue
+		if raw := db.RawSpans[span.ID]; raw != nil {
+			res = raw.Resource
+		} else if span.Source != nil {
+			res = span.Source.attributes()
+		}
+		set := attribute.NewSet(res...)
+		key := set.Encoded(attribute.DefaultEncoder())
+		rs, found := byResource[key]
+		if !found {
+			rs = &otlptracev1.ResourceSpans{
+				Resource:   &otlpresourcev1.Resource{Attributes: telemetry.KeyValues(res)},
+				ScopeSpans: []*otlptracev1.ScopeSpans{{Scope: otlpScope}},
+			}
+			byResource[key] = rs
+			resources = append(resources, rs)
+		}
+		rs.ScopeSpans[0].Spans = append(rs.ScopeSpans[0].Spans, db.spanToOTLP(span))
+	}
+	export.Traces.ResourceSpans = resources
+
+	var logs []*otlplogsv1.LogRecord
+	for _, span := range db.Spans.Order {
+		tail := db.LogTails[span.ID]
+		if len(tail) == 0 {
+			continue
+		}
+		at := span.EndTime
+		if at.IsZero() {
+			at = span.StartTime
+		}
+		logs = append(logs, &otlplogsv1.LogRecord{
+			TimeUnixNano:         uint64(at.UnixNano()),
+			ObservedTimeUnixNano: uint64(at.UnixNano()),
+			Body:                 &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_StringValue{StringValue: string(tail)}},
+			TraceId:              span.TraceID.TraceID[:],
+			SpanId:               span.ID.SpanID[:],
+		})
+	}
+	if len(logs) > 0 {
+		export.Logs.ResourceLogs = []*otlplogsv1.ResourceLogs{{
+			Resource: &otlpresourcev1.Resource{},
+			ScopeLogs: []*otlplogsv1.ScopeLogs{{
+				Scope:      otlpScope,
+				LogRecords: logs,
+			}},
+		}}
+	}
+
+	var metrics []*otlpmetricsv1.Metric
+	digests := make([]string, 0, len(db.MetricsByCall))
+	for digest := range db.MetricsByCall {
+		digests = append(digests, digest)
+	}
+	sort.Strings(digests)
+	for _, digest := range digests {
+		byName := db.MetricsByCall[digest]
+		names := make([]string, 0, len(byName))
+		for name := range byName {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			gauge := &otlpmetricsv1.Gauge{}
+			for _, point := range byName[name] {
+				gauge.DataPoints = append(gauge.DataPoints, &otlpmetricsv1.NumberDataPoint{
+					Attributes:        telemetry.KeyValues([]attribute.KeyValue{attribute.String(telemetry.DagDigestAttr, digest)}),
+					StartTimeUnixNano: uint64(point.StartTime.UnixNano()),
+					TimeUnixNano:      uint64(point.Time.UnixNano()),
+					Value:             &otlpmetricsv1.NumberDataPoint_AsInt{AsInt: point.Value},
+				})
+			}
+			metrics = append(metrics, &otlpmetricsv1.Metric{
+				Name: name,
+				Data: &otlpmetricsv1.Metric_Gauge{Gauge: gauge},
+			})
+		}
+	}
+	if len(metrics) > 0 {
+		export.Metrics.ResourceMetrics = []*otlpmetricsv1.ResourceMetrics{{
+			Resource: &otlpresourcev1.Resource{},
+			ScopeMetrics: []*otlpmetricsv1.ScopeMetrics{{
+				Scope:   otlpScope,
+				Metrics: metrics,
+			}},
+		}}
+	}
+
+	return export
+}
+
+func (db *DB) spanToOTLP(span *Span) *otlptracev1.Span {
+	pb := &otlptracev1.Span{
+		TraceId:           span.TraceID.TraceID[:],
+		SpanId:            span.ID.SpanID[:],
+		Name:              span.Name,
+		Kind:              otlptracev1.Span_SPAN_KIND_INTERNAL,
+		StartTimeUnixNano: uint64(span.StartTime.UnixNano()),
+		Status:            &otlptracev1.Status{Message: span.Status.Description},
+	}
+	if !span.EndTime.IsZero() {
+		pb.EndTimeUnixNano = uint64(span.EndTime.UnixNano())
+	}
+	if span.ParentID.IsValid() {
+		pb.ParentSpanId = span.ParentID.SpanID[:]
+	}
+	switch span.Status.Code {
+	case codes.Ok:
+		pb.Status.Code = otlptracev1.Status_STATUS_CODE_OK
+	case codes.Error:
+		pb.Status.Code = otlptracev1.Status_STATUS_CODE_ERROR
+	}
+	for _, link := range span.Links {
+		pb.Links = append(pb.Links, &otlptracev1.Span_Link{
+			TraceId: link.TraceID.TraceID[:],
+			SpanId:  link.SpanID.SpanID[:],
+		})
+	}
+	if raw := db.RawSpans[span.ID]; raw != nil {
+		pb.Attributes = telemetry.KeyValues(raw.Attributes)
+		pb.Events = telemetry.SpanEventsToPB(raw.Events)
+		return pb
+	}
+	pb.Attributes = telemetry.KeyValues(span.otlpAttributes())
+	for _, event := range span.Events {
+		pb.Events = append(pb.Events, &otlptracev1.Span_Event{
+			Name:         event.Name,
+			TimeUnixNano: uint64(event.Time.UnixNano()),
+		})
+	}
+	for _, exc := range span.Exceptions {
+		attrs := []attribute.KeyValue{
+			semconv.ExceptionType(exc.Type),
+			semconv.ExceptionMessage(exc.Message),
+		}
+		if exc.Stacktrace != "" {
+			attrs = append(attrs, semconv.ExceptionStacktrace(exc.Stacktrace))
+		}
+		pb.Events = append(pb.Events, &otlptracev1.Span_Event{
+			Name:         semconv.ExceptionEventName,
+			TimeUnixNano: pb.EndTimeUnixNano,
+			Attributes:   telemetry.KeyValues(attrs),
+		})
+	}
+	return pb
+}
+
+// otlpAttributes rebuilds the attributes that the span's snapshot was
+// parsed from. See ProcessAttribute.
+func (span *Span) otlpAttributes() []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	str := func(key, val string) {
+		if val != "" {
+			attrs = append(attrs, attribute.String(key, val))
+		}
+	}
+	flag := func(key string, val bool) {
+		if val {
+			attrs = append(attrs, attribute.Bool(key, true))
+		}
+	}
+	strs := func(key string, vals []string) {
+		if len(vals) > 0 {
+			attrs = append(attrs, attribute.StringSlice(key, vals))
+		}
+	}
+	str(telemetry.DagDigestAttr, span.CallDigest)
+	str(telemetry.DagCallAttr, span.CallPayload)
+	str(telemetry.DagOutputAttr, span.Output)
+	strs(telemetry.DagInputsAttr, span.Inputs)
+	flag(telemetry.CachedAttr, span.Cached)
+	flag(telemetry.CanceledAttr, span.Canceled)
+	str(telemetry.CanceledByAttr, span.CanceledBy)
+	str(telemetry.ErrorCategoryAttr, span.ErrorCategory)
+	flag(telemetry.UIInternalAttr, span.Internal)
+	flag(telemetry.UIEncapsulateAttr, span.Encapsulate)
+	flag(telemetry.UIEncapsulatedAttr, span.Encapsulated)
+	flag(telemetry.UIPassthroughAttr, span.Passthrough)
+	flag(telemetry.UIQuarantineAttr, span.Quarantined)
+	flag(telemetry.UIFailureAllowedAttr, span.FailureAllowed)
+	flag(telemetry.UIFailureExpectedAttr, span.FailureExpected)
+	flag(telemetry.UIPauseAttr, span.Pause)
+	str(telemetry.UISkipAttr, span.Skip)
+	strs(telemetry.UIMatrixAxesAttr, span.MatrixAxes)
+	strs(telemetry.UIMatrixCellAttr, span.MatrixCell)
+	if !span.Deadline.IsZero() {
+		attrs = append(attrs, attribute.Int64(telemetry.UIDeadlineAttr, span.Deadline.UnixNano()))
+	}
+	str(telemetry.EffectIDAttr, span.EffectID)
+	strs(telemetry.EffectIDsAttr, span.EffectIDs)
+	strs(telemetry.EffectsCompletedAttr, span.EffectsCompleted)
+	strs(telemetry.PromptedArgsAttr, span.PromptedArgs)
+	flag(telemetry.TestCaseAttr, span.TestCase)
+	str(telemetry.TestSuiteAttr, span.TestSuite)
+	for _, name := range sortedKeys(span.Attributes) {
+		if kv, ok := anyAttribute(name, span.Attributes[name]); ok {
+			attrs = append(attrs, kv)
+		}
+	}
+	for _, name := range sortedKeys(span.UserAttributes) {
+		if _, retained := span.Attributes[telemetry.UserAttrPrefix+name]; retained {
+			continue
+		}
+		if kv, ok := anyAttribute(telemetry.UserAttrPrefix+name, span.UserAttributes[name].Value); ok {
+			attrs = append(attrs, kv)
+		}
+	}
+	for _, name := range sortedKeys(span.Annotations) {
+		str(telemetry.AnnotationAttrPrefix+name, span.Annotations[name])
+	}
+	return attrs
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// anyAttribute converts a value as returned by attribute.Value.AsInterface
+// back into an attribute.
+func anyAttribute(key string, val any) (attribute.KeyValue, bool) {
+	switch v := val.(type) {
+	case string:
+		return attribute.String(key, v), true
+	case bool:
+		return attribute.Bool(key, v), true
+	case int64:
+		return attribute.Int64(key, v), true
+	case float64:
+		return attribute.Float64(key, v), true
+	case []string:
+		return attribute.StringSlice(key, v), true
+	case []bool:
+		return attribute.BoolSlice(key, v), true
+	case []int64:
+		return attribute.Int64Slice(key, v), true
+	case []float64:
+		return attribute.Float64Slice(key, v), true
+	default:
+		return attribute.KeyValue{}, false
+	}
+}
+
+// attributes returns the resource attributes that the source was read from.
+func (src TraceSource) attributes() []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if src.ServiceName != "" {
+		attrs = append(attrs, semconv.ServiceName(src.ServiceName))
+	}
+	if src.ServiceVersion != "" {
+		attrs = append(attrs, semconv.ServiceVersion(src.ServiceVersion))
+	}
+	if src.HostName != "" {
+		attrs = append(attrs, semconv.HostName(src.HostName))
+	}
+	if src.EngineName != "" {
+		attrs = append(attrs, attribute.String(EngineNameAttr, src.EngineName))
+	}
+	return attrs
+}
+
+// OTLP file formats written by WriteFiles.
+const (
+	OTLPFormatJSON  = "json"
+	OTLPFormatProto = "proto"
+)
+
+// WriteFiles writes the traces, logs, and metrics to traces, logs, and
+// metrics files in the directory, as OTLP JSON (with the .json extension) or
+// protobuf (.pb). Kinds of telemetry with no data are skipped.
+//
+// JSON files follow the OTLP/JSON encoding, with hex trace and span IDs, so
+// they can be read by e.g. the OpenTelemetry Collector's otlpjsonfile
+// receiver.
+func (export *OTLPExport) WriteFiles(dir, format string) ([]string, error) {
+	if format != OTLPFormatJSON && format != OTLPFormatProto {
+		return nil, fmt.Errorf("unknown OTLP format %q", format)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	var written []string
+	for _, file := range []struct {
+		name  string
+		msg   proto.Message
+		empty bool
+	}{
+		{"traces", export.Traces, len(export.Traces.ResourceSpans) == 0},
+		{"logs", export.Logs, len(export.Logs.ResourceLogs) == 0},
+		{"metrics", export.Metrics, len(export.Metrics.ResourceMetrics) == 0},
+	} {
+		if file.empty {
+			continue
+		}
+		var payload []byte
+		var err error
+		var path string
+		if format == OTLPFormatJSON {
+			payload, err = otlpJSON(file.msg)
+			path = filepath.Join(dir, file.name+".json")
+		} else {
+			payload, err = proto.Marshal(file.msg)
+			path = filepath.Join(dir, file.name+".pb")
+		}
+		if err != nil {
+			return written, fmt.Errorf("marshal %s: %w", file.name, err)
+		}
+		if err := os.WriteFile(path, payload, 0o644); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// otlpIDFields are the fields holding trace and span IDs, which OTLP/JSON
+// encodes as hex rather than the base64 used for other bytes.
+var otlpIDFields = map[string]bool{
+	"traceId":      true,
+	"spanId":       true,
+	"parentSpanId": true,
+}
+
+// otlpJSON marshals an OTLP message as OTLP/JSON, followed by a newline so
+// that requests can be concatenated as JSON lines.
+func otlpJSON(msg proto.Message) ([]byte, error) {
+	payload, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := json.Unmarshal(payload, &doc); err != nil {
+		return nil, err
+	}
+	if err := hexIDs(doc); err != nil {
+		return nil, err
+	}
+	payload, err = json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return append(payload, '\n'), nil
+}
+
+func hexIDs(doc any) error {
+	switch v := doc.(type) {
+	case map[string]any:
+		for key, val := range v {
+			if str, ok := val.(string); ok && otlpIDFields[key] {
+				id, err := base64.StdEncoding.DecodeString(str)
+				if err != nil {
+					return fmt.Errorf("decode %s: %w", key, err)
+				}
+				v[key] = strings.ToLower(hex.EncodeToString(id))
+				continue
+			}
+			if err := hexIDs(val); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, val := range v {
+			if err := hexIDs(val); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
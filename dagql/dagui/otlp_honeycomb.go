@@ -0,0 +1,204 @@
+package dagui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"dagger.io/dagger/telemetry"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	otlpcommonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+)
+
+// OTLP export presets, tuning the telemetry for a particular backend.
+const (
+	OTLPPresetHoneycomb = "honeycomb"
+)
+
+// Columns derived by the Honeycomb preset.
+const (
+	// HoneycombOperationColumn is the API a span called, e.g.
+	// "Container.withExec".
+	HoneycombOperationColumn = "dagger.operation"
+
+	// HoneycombModuleColumn is the name of the module that a span called.
+	HoneycombModuleColumn = "dagger.module"
+
+	// HoneycombCacheStateColumn is whether a call was cached or executed.
+	HoneycombCacheStateColumn = "dagger.cache_state"
+
+	// HoneycombFailureCategoryColumn is why a span failed: an error
+	// category like "crash", or one of "canceled", "timeout", "dependency",
+	// or "error".
+	HoneycombFailureCategoryColumn = "dagger.failure_category"
+)
+
+// OTLPPreset converts the DB's telemetry into OTLP like OTLP does, tuned for
+// the given preset, if any.
+func (db *DB) OTLPPreset(preset string) (*OTLPExport, error) {
+	switch preset {
+	case "":
+		return db.OTLP(), nil
+	case OTLPPresetHoneycomb:
+		return db.HoneycombOTLP(), nil
+	default:
+		return nil, fmt.Errorf("unknown OTLP preset %q", preset)
+	}
+}
+
+// HoneycombOTLP converts the DB's telemetry into OTLP tuned for Honeycomb,
+// so that it can be queried without a transformation pipeline:
+//
+//   - Array attributes are flattened to comma-separated strings, and the
+//     encoded call payload is dropped in favor of columns derived from it.
+//   - Columns are derived for the operation and module called, the cache
+//     state, and the failure category of each span.
+//   - The service name, which Honeycomb uses as the dataset, is derived from
+//     the run's module, so each module's runs land in the same dataset.
+func (db *DB) HoneycombOTLP() *OTLPExport {
+	export := db.OTLP()
+	dataset := HoneycombDataset(db.moduleName())
+	for _, rs := range export.Traces.ResourceSpans {
+		rs.Resource.Attributes = setServiceName(rs.Resource.Attributes, dataset)
+		for _, ss := range rs.ScopeSpans {
+			for _, pb := range ss.Spans {
+				var id SpanID
+				copy(id.SpanID[:], pb.SpanId)
+				pb.Attributes = db.honeycombAttributes(db.Spans.Map[id], pb.Attributes)
+			}
+		}
+	}
+	return export
+}
+
+var unsafeDatasetChars = regexp.MustCompile(`[^a-z0-9._~-]+`)
+
+// HoneycombDataset returns the name of the dataset for runs of the given
+// module, or of runs that don't call a module.
+func HoneycombDataset(module string) string {
+	module = strings.Trim(unsafeDatasetChars.ReplaceAllString(strings.ToLower(module), "-"), "-")
+	if module == "" {
+		return "dagger"
+	}
+	return "dagger-" + module
+}
+
+// moduleName returns the name of the first module the run called.
+func (db *DB) moduleName() string {
+	for _, span := range db.Spans.Order {
+		if span.Call != nil && span.Call.Module != nil {
+			return span.Call.Module.Name
+		}
+	}
+	return ""
+}
+
+func setServiceName(attrs []*otlpcommonv1.KeyValue, name string) []*otlpcommonv1.KeyValue {
+	attrs = removeAttribute(attrs, string(semconv.ServiceNameKey))
+	return append(attrs, stringKeyValue(string(semconv.ServiceNameKey), name))
+}
+
+func (db *DB) honeycombAttributes(span *Span, attrs []*otlpcommonv1.KeyValue) []*otlpcommonv1.KeyValue {
+	attrs = removeAttribute(attrs, telemetry.DagCallAttr)
+	for _, kv := range attrs {
+		kv.Value = flattenValue(kv.Value)
+	}
+	if span == nil {
+		return attrs
+	}
+	column := func(key, val string) {
+		if val != "" {
+			attrs = append(removeAttribute(attrs, key), stringKeyValue(key, val))
+		}
+	}
+	if span.Call != nil {
+		column(HoneycombOperationColumn, db.OperationName(span))
+		if span.Call.Module != nil {
+			column(HoneycombModuleColumn, span.Call.Module.Name)
+		}
+	}
+	column(HoneycombCacheStateColumn, cacheState(span))
+	column(HoneycombFailureCategoryColumn, failureCategory(span))
+	return attrs
+}
+
+func cacheState(span *Span) string {
+	switch {
+	case span.CallDigest == "":
+		return ""
+	case span.IsCached():
+		return "cached"
+	case span.IsRunningOrEffectsRunning():
+		return "running"
+	case span.IsPending():
+		return "pending"
+	default:
+		return "executed"
+	}
+}
+
+func failureCategory(span *Span) string {
+	switch {
+	case !span.IsFailedOrCausedFailure():
+		return ""
+	case span.ErrorCategory != "":
+		return span.ErrorCategory
+	case span.IsCanceled():
+		return "canceled"
+	case !span.Deadline.IsZero() && !span.EndTime.Before(span.Deadline):
+		return "timeout"
+	case !span.IsFailed():
+		// failed because of a child, link, or effect
+		return "dependency"
+	default:
+		return "error"
+	}
+}
+
+func removeAttribute(attrs []*otlpcommonv1.KeyValue, key string) []*otlpcommonv1.KeyValue {
+	kept := attrs[:0]
+	for _, kv := range attrs {
+		if kv.Key != key {
+			kept = append(kept, kv)
+		}
+	}
+	return kept
+}
+
+func stringKeyValue(key, val string) *otlpcommonv1.KeyValue {
+	return &otlpcommonv1.KeyValue{
+		Key:   key,
+		Value: &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_StringValue{StringValue: val}},
+	}
+}
+
+// flattenValue converts arrays to comma-separated strings, which Honeycomb
+// can filter and group by.
+func flattenValue(val *otlpcommonv1.AnyValue) *otlpcommonv1.AnyValue {
+	arr := val.GetArrayValue()
+	if arr == nil {
+		return val
+	}
+	strs := make([]string, 0, len(arr.Values))
+	for _, v := range arr.Values {
+		strs = append(strs, anyValueString(v))
+	}
+	return &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_StringValue{StringValue: strings.Join(strs, ",")}}
+}
+
+func anyValueString(val *otlpcommonv1.AnyValue) string {
+	switch v := val.GetValue().(type) {
+	case *otlpcommonv1.AnyValue_StringValue:
+		return v.StringValue
+	case *otlpcommonv1.AnyValue_BoolValue:
+		return fmt.Sprint(v.BoolValue)
+	case *otlpcommonv1.AnyValue_IntValue:
+		return fmt.Sprint(v.IntValue)
+	case *otlpcommonv1.AnyValue_DoubleValue:
+		return fmt.Sprint(v.DoubleValue)
+	case *otlpcommonv1.AnyValue_ArrayValue:
+		return anyValueString(flattenValue(val))
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,105 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"dagger.io/dagger/telemetry"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	otlpcommonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+
+	"github.com/dagger/dagger/dagql/call/callpbv1"
+)
+
+func TestHoneycombOTLP(t *testing.T) {
+	traceID := TraceID{TraceID: trace.TraceID{0xab, 1}}
+	root := SpanID{SpanID: trace.SpanID{0xcd, 1}}
+	build := SpanID{SpanID: trace.SpanID{0xcd, 2}}
+	test := SpanID{SpanID: trace.SpanID{0xcd, 3}}
+	start := time.Unix(1700000000, 0)
+
+	call := &callpbv1.Call{
+		Digest: "sha256:build",
+		Field:  "build",
+		Type:   &callpbv1.Type{NamedType: "Container"},
+		Module: &callpbv1.Module{Name: "My_App"},
+	}
+	payload, err := call.Encode()
+	require.NoError(t, err)
+
+	db := NewDB()
+	db.ImportSnapshots([]SpanSnapshot{{
+		ID:        root,
+		TraceID:   traceID,
+		Name:      "run",
+		StartTime: start,
+		EndTime:   start.Add(time.Minute),
+		Status:    sdktrace.Status{Code: codes.Error},
+		Source:    &TraceSource{ServiceName: "dagger-cli"},
+	}, {
+		ID:          build,
+		TraceID:     traceID,
+		ParentID:    root,
+		Name:        "build",
+		StartTime:   start.Add(time.Second),
+		EndTime:     start.Add(2 * time.Second),
+		CallDigest:  call.Digest,
+		CallPayload: payload,
+		Cached:      true,
+		Inputs:      []string{"sha256:a", "sha256:b"},
+	}, {
+		ID:            test,
+		TraceID:       traceID,
+		ParentID:      root,
+		Name:          "test",
+		StartTime:     start.Add(2 * time.Second),
+		EndTime:       start.Add(3 * time.Second),
+		CallDigest:    "sha256:test",
+		Status:        sdktrace.Status{Code: codes.Error},
+		ErrorCategory: telemetry.ErrorCategoryCrash,
+	}})
+
+	export, err := db.OTLPPreset(OTLPPresetHoneycomb)
+	require.NoError(t, err)
+
+	spans := map[string]map[string]string{}
+	for _, rs := range export.Traces.ResourceSpans {
+		require.Equal(t, "dagger-my_app", attributeValues(rs.Resource.Attributes)["service.name"])
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				spans[span.Name] = attributeValues(span.Attributes)
+			}
+		}
+	}
+	require.Equal(t, map[string]string{
+		telemetry.DagDigestAttr:   "sha256:build",
+		telemetry.DagInputsAttr:   "sha256:a,sha256:b",
+		telemetry.CachedAttr:      "true",
+		HoneycombOperationColumn:  "Query.build",
+		HoneycombModuleColumn:     "My_App",
+		HoneycombCacheStateColumn: "cached",
+	}, spans["build"])
+	require.Equal(t, "crash", spans["test"][HoneycombFailureCategoryColumn])
+	require.Equal(t, "executed", spans["test"][HoneycombCacheStateColumn])
+	require.Equal(t, "error", spans["run"][HoneycombFailureCategoryColumn])
+	require.NotContains(t, spans["run"], HoneycombCacheStateColumn)
+
+	_, err = db.OTLPPreset("bogus")
+	require.Error(t, err)
+}
+
+func TestHoneycombDataset(t *testing.T) {
+	require.Equal(t, "dagger", HoneycombDataset(""))
+	require.Equal(t, "dagger-my-app", HoneycombDataset("My App!"))
+}
+
+func attributeValues(attrs []*otlpcommonv1.KeyValue) map[string]string {
+	values := map[string]string{}
+	for _, kv := range attrs {
+		values[kv.Key] = anyValueString(kv.Value)
+	}
+	return values
+}
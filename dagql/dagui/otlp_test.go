@@ -0,0 +1,106 @@
+package dagui
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"dagger.io/dagger/telemetry"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestOTLPRoundTrip(t *testing.T) {
+	traceID := TraceID{TraceID: trace.TraceID{0xab, 1}}
+	root := SpanID{SpanID: trace.SpanID{0xcd, 1}}
+	child := SpanID{SpanID: trace.SpanID{0xcd, 2}}
+	start := time.Unix(1700000000, 0)
+	engine := &TraceSource{ServiceName: "dagger-engine", EngineName: "engine-1"}
+
+	// snapshots, as loaded from a TraceStore, with no raw attributes
+	db := NewDB()
+	db.ImportSnapshots([]SpanSnapshot{{
+		ID:        root,
+		TraceID:   traceID,
+		Name:      "run",
+		StartTime: start,
+		EndTime:   start.Add(time.Minute),
+	}, {
+		ID:         child,
+		TraceID:    traceID,
+		ParentID:   root,
+		Name:       "build",
+		StartTime:  start.Add(time.Second),
+		EndTime:    start.Add(2 * time.Second),
+		CallDigest: "sha256:build",
+		Cached:     true,
+		Source:     engine,
+		UserAttributes: map[string]UserAttribute{
+			"team": {Type: "string", Value: "ci"},
+		},
+	}})
+	db.LogTails[child] = []byte("building\n")
+	db.MetricsByCall = map[string]map[string][]metricdata.DataPoint[int64]{
+		"sha256:build": {
+			telemetry.IOStatDiskReadBytes: {{Time: start.Add(2 * time.Second), Value: 42}},
+		},
+	}
+
+	export := db.OTLP()
+	require.Len(t, export.Traces.ResourceSpans, 2)
+	require.Len(t, export.Logs.ResourceLogs, 1)
+	require.Len(t, export.Metrics.ResourceMetrics, 1)
+
+	dir := t.TempDir()
+	paths, err := export.WriteFiles(dir, OTLPFormatProto)
+	require.NoError(t, err)
+	require.Len(t, paths, 3)
+
+	payload, err := os.ReadFile(filepath.Join(dir, "traces.pb"))
+	require.NoError(t, err)
+	var req coltracepb.ExportTraceServiceRequest
+	require.NoError(t, proto.Unmarshal(payload, &req))
+
+	imported := NewDB()
+	require.NoError(t, imported.ExportSpans(context.Background(), telemetry.SpansFromPB(req.ResourceSpans)))
+	span := imported.Spans.Map[child]
+	require.NotNil(t, span)
+	require.Equal(t, "build", span.Name)
+	require.Equal(t, root, span.ParentID)
+	require.Equal(t, "sha256:build", span.CallDigest)
+	require.True(t, span.Cached)
+	require.Equal(t, "ci", span.UserAttributes["team"].Value)
+	require.Equal(t, engine, span.Source)
+	require.Equal(t, time.Second, span.EndTime.Sub(span.StartTime))
+
+	// OTLP/JSON encodes IDs as hex
+	_, err = export.WriteFiles(dir, OTLPFormatJSON)
+	require.NoError(t, err)
+	payload, err = os.ReadFile(filepath.Join(dir, "traces.json"))
+	require.NoError(t, err)
+	var doc struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID      string `json:"traceId"`
+					SpanID       string `json:"spanId"`
+					ParentSpanID string `json:"parentSpanId"`
+					Kind         int    `json:"kind"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	require.NoError(t, json.Unmarshal(payload, &doc))
+	spans := doc.ResourceSpans[1].ScopeSpans[0].Spans
+	require.Len(t, spans, 1)
+	require.Equal(t, traceID.String(), spans[0].TraceID)
+	require.Equal(t, child.String(), spans[0].SpanID)
+	require.Equal(t, root.String(), spans[0].ParentSpanID)
+	require.Equal(t, 1, spans[0].Kind)
+}
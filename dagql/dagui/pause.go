@@ -0,0 +1,70 @@
+package dagui
+
+import "time"
+
+// IsPaused returns whether the pipeline is currently paused.
+func (db *DB) IsPaused() bool {
+	for _, pause := range db.Pauses.Order {
+		if pause.IsRunning() {
+			return true
+		}
+	}
+	return false
+}
+
+// PausedDuring returns how much of the given interval the pipeline spent
+// paused, treating pauses that haven't ended yet as lasting until now.
+func (db *DB) PausedDuring(ival Interval, now time.Time) time.Duration {
+	var paused time.Duration
+	for _, pause := range db.Pauses.Order {
+		end := pause.EndTime
+		if pause.IsRunning() {
+			end = now
+		}
+		start := maxTime(pause.StartTime, ival.Start)
+		end = minTime(end, ival.End)
+		if end.After(start) {
+			paused += end.Sub(start)
+		}
+	}
+	return paused
+}
+
+// ActiveDuration returns how long the span has been active as of now, not
+// counting any time spent paused.
+func (span *Span) ActiveDuration(now time.Time) time.Duration {
+	if span.Pause || span.db == nil {
+		return span.Activity.Duration(now)
+	}
+	var dur time.Duration
+	for ival := range span.Activity.Intervals(now) {
+		dur += ival.End.Sub(ival.Start) - span.db.PausedDuring(ival, now)
+	}
+	return dur
+}
+
+// WallTime returns how long the span took from start to end, or until now if
+// it's still running, not counting any time spent paused.
+func (span *Span) WallTime() time.Duration {
+	now := time.Now()
+	ival := Interval{Start: span.StartTime, End: span.EndTimeOrFallback(now)}
+	dur := ival.End.Sub(ival.Start)
+	if span.Pause || span.db == nil {
+		return dur
+	}
+	return dur - span.db.PausedDuring(ival, now)
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
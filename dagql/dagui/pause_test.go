@@ -0,0 +1,61 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPauseAwareClock(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	traceID := TraceID{TraceID: trace.TraceID{1}}
+	root := SpanID{SpanID: trace.SpanID{1}}
+	step := SpanID{SpanID: trace.SpanID{2}}
+	pause := SpanID{SpanID: trace.SpanID{3}}
+
+	db := NewDB()
+	db.SetPrimarySpan(root)
+	db.ImportSnapshots([]SpanSnapshot{{
+		ID:        root,
+		TraceID:   traceID,
+		Name:      "run",
+		StartTime: start,
+		EndTime:   start.Add(10 * time.Minute),
+	}, {
+		ID:        step,
+		TraceID:   traceID,
+		ParentID:  root,
+		Name:      "build",
+		StartTime: start.Add(time.Minute),
+		EndTime:   start.Add(5 * time.Minute),
+	}, {
+		ID:        pause,
+		TraceID:   traceID,
+		ParentID:  root,
+		Name:      "paused",
+		Pause:     true,
+		StartTime: start.Add(4 * time.Minute),
+		EndTime:   start.Add(7 * time.Minute),
+	}})
+	require.False(t, db.IsPaused())
+
+	now := start.Add(10 * time.Minute)
+	require.Equal(t, 7*time.Minute, db.Spans.Map[root].ActiveDuration(now))
+	require.Equal(t, 3*time.Minute, db.Spans.Map[step].ActiveDuration(now))
+	require.Equal(t, 3*time.Minute, db.Spans.Map[pause].ActiveDuration(now))
+	require.Equal(t, 7*time.Minute, db.Spans.Map[root].WallTime())
+
+	// pauses that haven't ended count until now
+	db.ImportSnapshots([]SpanSnapshot{{
+		ID:        SpanID{SpanID: trace.SpanID{4}},
+		TraceID:   traceID,
+		ParentID:  root,
+		Name:      "paused",
+		Pause:     true,
+		StartTime: start.Add(8 * time.Minute),
+	}})
+	require.True(t, db.IsPaused())
+	require.Equal(t, 2*time.Minute, db.PausedDuring(Interval{Start: start.Add(8 * time.Minute), End: now}, now))
+}
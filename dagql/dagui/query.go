@@ -0,0 +1,483 @@
+package dagui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SpanQuery is a compiled query over the spans of a trace, e.g.
+//
+//	span.duration > 30s && span.cached == false && name =~ "test"
+//	status=error AND duration>30s AND name~"apt-get"
+//
+// Queries compare span fields against literals with ==, !=, <, <=, >, >=, =~
+// (regexp match), and !~, and combine them with &&, ||, !, and parentheses.
+// = and ~ are short for == and =~, and AND, OR, and NOT for &&, ||, and !.
+// Strings may be left unquoted if they're a single word. Boolean fields may
+// also be used on their own. See SpanQueryFields for the fields that may be
+// queried.
+type SpanQuery struct {
+	src   string
+	match func(*Span) bool
+}
+
+// SpanQueryFields are the fields that queries can refer to, along with their
+// types. Fields may be prefixed with "span.", e.g. span.name.
+var SpanQueryFields = map[string]string{
+	"name":     "string",
+	"id":       "string",
+	"parent":   "string",
+	"trace":    "string",
+	"call":     "string",
+	"status":   "string",
+	"error":    "string",
+	"duration": "duration",
+	"cached":   "bool",
+	"failed":   "bool",
+	"canceled": "bool",
+	"skipped":  "bool",
+	"internal": "bool",
+	"running":  "bool",
+}
+
+// spanField returns the value of the named field of the span.
+func spanField(span *Span, name string) any {
+	switch name {
+	case "name":
+		return span.Name
+	case "id":
+		return span.ID.String()
+	case "parent":
+		return span.ParentID.String()
+	case "trace":
+		return span.TraceID.String()
+	case "call":
+		return span.CallDigest
+	case "status":
+		return spanStatus(span)
+	case "error":
+		return span.Status.Description
+	case "duration":
+		return span.WallTime()
+	case "cached":
+		return span.IsCached()
+	case "failed":
+		return span.IsFailedOrCausedFailure()
+	case "canceled":
+		return span.IsCanceled()
+	case "skipped":
+		return span.IsSkipped()
+	case "internal":
+		return span.IsInternal()
+	case "running":
+		return span.IsRunningOrEffectsRunning()
+	default:
+		return nil
+	}
+}
+
+// ParseSpanQuery compiles a query.
+func ParseSpanQuery(src string) (*SpanQuery, error) {
+	toks, err := lexSpanQuery(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{toks: toks}
+	match, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %s at offset %d", tok, tok.pos)
+	}
+	return &SpanQuery{src: src, match: match}, nil
+}
+
+func (q *SpanQuery) String() string {
+	return q.src
+}
+
+// Match returns whether the span matches the query.
+func (q *SpanQuery) Match(span *Span) bool {
+	return q.match(span)
+}
+
+// Query returns the spans matching a query, e.g. status=error. See
+// SpanQuery for the syntax.
+func (db *DB) Query(expr string) (SpanSet, error) {
+	q, err := ParseSpanQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	return db.Select(q), nil
+}
+
+// Select returns the spans matching a compiled query.
+func (db *DB) Select(q *SpanQuery) SpanSet {
+	spans := NewSpanSet()
+	for _, span := range db.Spans.Order {
+		if q.Match(span) {
+			spans.Add(span)
+		}
+	}
+	return spans
+}
+
+// SpanMatch is a span matching a query, as output for scripts.
+type SpanMatch struct {
+	TraceID    TraceID    `json:"traceId"`
+	ID         SpanID     `json:"id"`
+	ParentID   SpanID     `json:"parentId"`
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	CallDigest string     `json:"callDigest,omitempty"`
+	StartTime  time.Time  `json:"startTime"`
+	EndTime    *time.Time `json:"endTime,omitempty"`
+	DurationMS int64      `json:"durationMs"`
+	Cached     bool       `json:"cached,omitempty"`
+
+	// Attributes are the attributes that modules set on the span.
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// NewSpanMatch returns the output for a span that matched a query.
+func NewSpanMatch(span *Span) SpanMatch {
+	match := SpanMatch{
+		TraceID:    span.TraceID,
+		ID:         span.ID,
+		ParentID:   span.ParentID,
+		Name:       span.Name,
+		Status:     spanStatus(span),
+		CallDigest: span.CallDigest,
+		StartTime:  span.StartTime,
+		DurationMS: span.WallTime().Milliseconds(),
+		Cached:     span.IsCached(),
+		Attributes: span.UserAttributeValues(),
+	}
+	if span.IsFailed() {
+		match.Error = span.Status.Description
+	}
+	if !span.IsRunning() {
+		end := span.EndTime
+		match.EndTime = &end
+	}
+	return match
+}
+
+// WriteSpanMatches writes the spans matching a query as a JSON array.
+func WriteSpanMatches(w io.Writer, spans []*Span) error {
+	matches := make([]SpanMatch, len(spans))
+	for i, span := range spans {
+		matches[i] = NewSpanMatch(span)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(matches)
+}
+
+type queryTokenKind int
+
+const (
+	tokEOF queryTokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokDuration
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type queryToken struct {
+	kind queryTokenKind
+	text string
+	pos  int
+}
+
+func (tok queryToken) String() string {
+	if tok.kind == tokEOF {
+		return "end of query"
+	}
+	return strconv.Quote(tok.text)
+}
+
+var queryOps = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "!", "=", "~"}
+
+// queryOpAliases maps shorthand operators and keywords to the operators they
+// stand for.
+var queryOpAliases = map[string]string{
+	"=":   "==",
+	"~":   "=~",
+	"AND": "&&",
+	"OR":  "||",
+	"NOT": "!",
+}
+
+// queryStatusAliases maps alternative names for statuses, e.g. OTel's, to the
+// status they stand for.
+var queryStatusAliases = map[string]string{
+	"error": "failed",
+}
+
+func lexSpanQuery(src string) ([]queryToken, error) {
+	var toks []queryToken
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			toks = append(toks, queryToken{tokLParen, "(", i})
+			i++
+		case c == ')':
+			toks = append(toks, queryToken{tokRParen, ")", i})
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(src) && src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(src) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			str, err := strconv.Unquote(src[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at offset %d: %w", i, err)
+			}
+			toks = append(toks, queryToken{tokString, str, i})
+			i = end + 1
+		case unicode.IsDigit(c):
+			end := i
+			for end < len(src) && (unicode.IsDigit(rune(src[end])) || src[end] == '.') {
+				end++
+			}
+			kind := tokNumber
+			for end < len(src) && unicode.IsLetter(rune(src[end])) {
+				kind = tokDuration
+				end++
+			}
+			// durations like 1m30s alternate between digits and units
+			for kind == tokDuration && end < len(src) && unicode.IsDigit(rune(src[end])) {
+				for end < len(src) && (unicode.IsDigit(rune(src[end])) || src[end] == '.') {
+					end++
+				}
+				for end < len(src) && unicode.IsLetter(rune(src[end])) {
+					end++
+				}
+			}
+			toks = append(toks, queryToken{kind, src[i:end], i})
+			i = end
+		case unicode.IsLetter(c) || c == '_':
+			end := i
+			for end < len(src) && (unicode.IsLetter(rune(src[end])) || unicode.IsDigit(rune(src[end])) || src[end] == '_' || src[end] == '.') {
+				end++
+			}
+			if op, ok := queryOpAliases[strings.ToUpper(src[i:end])]; ok {
+				toks = append(toks, queryToken{tokOp, op, i})
+			} else {
+				toks = append(toks, queryToken{tokIdent, src[i:end], i})
+			}
+			i = end
+		default:
+			var op string
+			for _, candidate := range queryOps {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at offset %d", c, i)
+			}
+			pos := i
+			i += len(op)
+			if alias, ok := queryOpAliases[op]; ok {
+				op = alias
+			}
+			toks = append(toks, queryToken{tokOp, op, pos})
+		}
+	}
+	return append(toks, queryToken{kind: tokEOF, pos: len(src)}), nil
+}
+
+type queryParser struct {
+	toks []queryToken
+	pos  int
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.toks[p.pos]
+}
+
+func (p *queryParser) next() queryToken {
+	tok := p.toks[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *queryParser) parseOr() (func(*Span) bool, error) {
+	lhs, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "||" {
+		p.next()
+		rhs, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := lhs
+		lhs = func(span *Span) bool { return l(span) || rhs(span) }
+	}
+	return lhs, nil
+}
+
+func (p *queryParser) parseAnd() (func(*Span) bool, error) {
+	lhs, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "&&" {
+		p.next()
+		rhs, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := lhs
+		lhs = func(span *Span) bool { return l(span) && rhs(span) }
+	}
+	return lhs, nil
+}
+
+func (p *queryParser) parseUnary() (func(*Span) bool, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokOp && tok.text == "!":
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(span *Span) bool { return !inner(span) }, nil
+	case tok.kind == tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, fmt.Errorf("expected \")\" at offset %d, got %s", closing.pos, closing)
+		}
+		return inner, nil
+	case tok.kind == tokIdent:
+		return p.parseComparison(tok)
+	default:
+		return nil, fmt.Errorf("unexpected %s at offset %d", tok, tok.pos)
+	}
+}
+
+func (p *queryParser) parseComparison(ident queryToken) (func(*Span) bool, error) {
+	field := strings.TrimPrefix(ident.text, "span.")
+	typ, ok := SpanQueryFields[field]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q at offset %d", ident.text, ident.pos)
+	}
+	op := p.peek()
+	if op.kind != tokOp || op.text == "&&" || op.text == "||" || op.text == "!" {
+		if typ != "bool" {
+			return nil, fmt.Errorf("expected comparison after %s field %q at offset %d", typ, ident.text, op.pos)
+		}
+		return func(span *Span) bool { return spanField(span, field).(bool) }, nil
+	}
+	p.next()
+	lit := p.next()
+	switch typ {
+	case "string":
+		if lit.kind != tokString && lit.kind != tokIdent {
+			return nil, fmt.Errorf("expected string to compare %q with at offset %d, got %s", ident.text, lit.pos, lit)
+		}
+		if alias, ok := queryStatusAliases[lit.text]; ok && field == "status" {
+			lit.text = alias
+		}
+		return compareStrings(field, op, lit)
+	case "duration":
+		var dur time.Duration
+		switch lit.kind {
+		case tokDuration:
+			var err error
+			dur, err = time.ParseDuration(lit.text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid duration at offset %d: %w", lit.pos, err)
+			}
+		case tokNumber:
+			if lit.text != "0" {
+				return nil, fmt.Errorf("missing unit in duration %s at offset %d", lit, lit.pos)
+			}
+		default:
+			return nil, fmt.Errorf("expected duration to compare %q with at offset %d, got %s", ident.text, lit.pos, lit)
+		}
+		return compareDurations(field, op, dur)
+	default:
+		if lit.kind != tokIdent || (lit.text != "true" && lit.text != "false") {
+			return nil, fmt.Errorf("expected true or false to compare %q with at offset %d, got %s", ident.text, lit.pos, lit)
+		}
+		want := lit.text == "true"
+		switch op.text {
+		case "==":
+			return func(span *Span) bool { return spanField(span, field).(bool) == want }, nil
+		case "!=":
+			return func(span *Span) bool { return spanField(span, field).(bool) != want }, nil
+		default:
+			return nil, fmt.Errorf("operator %s can't be used with bool field %q at offset %d", op.text, ident.text, op.pos)
+		}
+	}
+}
+
+func compareStrings(field string, op, lit queryToken) (func(*Span) bool, error) {
+	get := func(span *Span) string { return spanField(span, field).(string) }
+	switch op.text {
+	case "==":
+		return func(span *Span) bool { return get(span) == lit.text }, nil
+	case "!=":
+		return func(span *Span) bool { return get(span) != lit.text }, nil
+	case "=~", "!~":
+		re, err := regexp.Compile(lit.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regexp at offset %d: %w", lit.pos, err)
+		}
+		want := op.text == "=~"
+		return func(span *Span) bool { return re.MatchString(get(span)) == want }, nil
+	default:
+		return nil, fmt.Errorf("operator %s can't be used with string field %q at offset %d", op.text, field, op.pos)
+	}
+}
+
+func compareDurations(field string, op queryToken, want time.Duration) (func(*Span) bool, error) {
+	get := func(span *Span) time.Duration { return spanField(span, field).(time.Duration) }
+	switch op.text {
+	case "==":
+		return func(span *Span) bool { return get(span) == want }, nil
+	case "!=":
+		return func(span *Span) bool { return get(span) != want }, nil
+	case "<":
+		return func(span *Span) bool { return get(span) < want }, nil
+	case "<=":
+		return func(span *Span) bool { return get(span) <= want }, nil
+	case ">":
+		return func(span *Span) bool { return get(span) > want }, nil
+	case ">=":
+		return func(span *Span) bool { return get(span) >= want }, nil
+	default:
+		return nil, fmt.Errorf("operator %s can't be used with field %q at offset %d", op.text, field, op.pos)
+	}
+}
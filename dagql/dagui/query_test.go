@@ -0,0 +1,62 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestSpanQuery(t *testing.T) {
+	span := testTrace{start: time.Now().Add(-time.Hour)}.span
+	unit := span(2, 1, "unit test", 2*time.Second, 2*time.Second+45*time.Second)
+	lint := span(3, 1, "lint", 3*time.Second, 3*time.Second+2*time.Minute)
+	lint.Status = sdktrace.Status{Code: codes.Error, Description: "exit code 1"}
+	cached := span(4, 1, "integration test", 4*time.Second, 4*time.Second+time.Minute)
+	cached.Cached = true
+	db := NewDB()
+	db.ImportSnapshots([]SpanSnapshot{span(1, 0, "run", time.Second, time.Second+5*time.Minute), unit, lint, cached})
+
+	for _, tc := range []struct {
+		query string
+		names []string
+	}{
+		{`span.duration > 30s && span.cached == false && name =~ "test"`, []string{"unit test"}},
+		{`name =~ "test" && !cached`, []string{"unit test"}},
+		{`failed || span.duration >= 1m30s`, []string{"run", "lint"}},
+		{`(cached || failed) && error != ""`, []string{"lint"}},
+		{`status == "cached"`, []string{"integration test"}},
+		{`name !~ "^(run|lint)$" && duration < 1m`, []string{"unit test"}},
+		{`parent == "0100000000000000"`, []string{"unit test", "lint", "integration test"}},
+		{`status=error AND duration>30s AND name~"li"`, []string{"lint"}},
+		{`NOT failed and name ~ test OR status = cached`, []string{"unit test", "integration test"}},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			spans, err := db.Query(tc.query)
+			require.NoError(t, err)
+			var names []string
+			for _, span := range spans.Order {
+				names = append(names, span.Name)
+			}
+			require.Equal(t, tc.names, names)
+		})
+	}
+
+	for _, invalid := range []string{
+		`nope == "x"`,
+		`duration > 30`,
+		`name > "a"`,
+		`cached == "yes"`,
+		`name =~ "("`,
+		`(failed`,
+		`failed cached`,
+		`name == "unterminated`,
+		`failed AND`,
+		`name = 3`,
+	} {
+		_, err := ParseSpanQuery(invalid)
+		require.Error(t, err, invalid)
+	}
+}
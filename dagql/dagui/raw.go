@@ -0,0 +1,16 @@
+package dagui
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// RawSpan is the telemetry last received for a span, as it was before its
+// attributes were folded into the span's snapshot. It's kept for debugging
+// the telemetry emitted by modules and exporters.
+type RawSpan struct {
+	Attributes []attribute.KeyValue
+	Resource   []attribute.KeyValue
+	Links      []sdktrace.Link
+	Events     []sdktrace.Event
+}
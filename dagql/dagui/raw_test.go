@@ -0,0 +1,50 @@
+package dagui
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dagger.io/dagger/telemetry"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRawSpans(t *testing.T) {
+	start := time.Now()
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{1},
+	})
+	stub := tracetest.SpanStub{
+		Name:        "build",
+		SpanContext: spanCtx,
+		StartTime:   start,
+		EndTime:     start.Add(time.Second),
+		Attributes: []attribute.KeyValue{
+			attribute.Bool(telemetry.UIInternalAttr, true),
+			attribute.String("custom.attr", "value"),
+		},
+		Events: []sdktrace.Event{{
+			Name:       "checkpoint",
+			Time:       start,
+			Attributes: []attribute.KeyValue{attribute.Int("n", 1)},
+		}},
+		Resource: resource.NewSchemaless(attribute.String("service.name", "my-module")),
+	}
+	db := NewDB()
+	require.NoError(t, db.ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{stub.Snapshot()}))
+
+	id := SpanID{SpanID: trace.SpanID{1}}
+	require.True(t, db.Spans.Map[id].Internal)
+	raw := db.RawSpans[id]
+	require.NotNil(t, raw)
+	require.Equal(t, stub.Attributes, raw.Attributes)
+	require.Equal(t, []attribute.KeyValue{attribute.String("service.name", "my-module")}, raw.Resource)
+	require.Len(t, raw.Events, 1)
+	require.Equal(t, "checkpoint", raw.Events[0].Name)
+}
@@ -0,0 +1,164 @@
+package dagui
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"dagger.io/dagger/telemetry"
+)
+
+// ReportFormatVersion is the version of the format written by
+// RunReport.WriteText. It's bumped whenever a change could break a script
+// that parses reports.
+const ReportFormatVersion = 1
+
+// ReportResult is the outcome of a run, as seen by automation.
+type ReportResult string
+
+const (
+	// ReportPass is a run that succeeded.
+	ReportPass ReportResult = "pass"
+
+	// ReportFail is a run that failed because of the code being run.
+	ReportFail ReportResult = "fail"
+
+	// ReportSkip is a run that couldn't tell whether the code works, e.g.
+	// because the engine was unreachable or an external dependency was down.
+	ReportSkip ReportResult = "skip"
+
+	// ReportAbort is a run that was interrupted.
+	ReportAbort ReportResult = "abort"
+)
+
+// ExitCode returns the exit code for the result, following the contract of
+// git bisect run: 0 for good, 1 for bad, 125 for commits that can't be
+// tested, and above 127 to stop bisecting altogether.
+func (result ReportResult) ExitCode() int {
+	switch result {
+	case ReportPass:
+		return 0
+	case ReportSkip:
+		return 125
+	case ReportAbort:
+		return 130
+	default:
+		return 1
+	}
+}
+
+// RunReport is a summary of a completed run that only holds what's
+// deterministic for a given commit, so that the reports of two runs of the
+// same code can be compared byte for byte. Timings, trace IDs, cache hits,
+// and logs are left out.
+type RunReport struct {
+	Name   string
+	Result ReportResult
+
+	// Reason explains results that aren't down to the run's steps, e.g. an
+	// interrupted run.
+	Reason string
+
+	Failures         []ReportStep
+	Warnings         []ReportStep
+	Skipped          []ReportStep
+	UnexpectedPasses []ReportStep
+}
+
+// ReportStep is a step listed in a report, with the detail relevant to its
+// listing, e.g. its error.
+type ReportStep struct {
+	Name   string
+	Detail string
+}
+
+// Report builds the run's report. The result is determined from the trace
+// alone: a run whose only failures were short-circuited calls to external
+// dependencies is skipped, since it says nothing about the code.
+func (db *DB) Report(quarantine QuarantineMode) RunReport {
+	report := RunReport{Result: ReportPass}
+	primary := db.Spans.Map[db.PrimarySpan]
+	if primary == nil {
+		report.Result = ReportSkip
+		report.Reason = "no trace recorded"
+		return report
+	}
+	report.Name = primary.Name
+	untestable := true
+	for _, span := range db.Spans.Order {
+		if span.ID == primary.ID || span.IsInternal() || span.Passthrough {
+			continue
+		}
+		switch {
+		case span.IsSkipped():
+			_, reason := span.SkipReason()
+			report.Skipped = append(report.Skipped, ReportStep{Name: span.Name, Detail: reason})
+		case span.IsUnexpectedPass():
+			report.UnexpectedPasses = append(report.UnexpectedPasses, ReportStep{Name: span.Name})
+		case span.IsFailed() && !hasFailedChild(span) && !span.IsFailureTolerated():
+			step := ReportStep{Name: span.Name, Detail: span.Status.Description}
+			if span.IsQuarantinedFailure(quarantine) {
+				report.Warnings = append(report.Warnings, step)
+				continue
+			}
+			report.Failures = append(report.Failures, step)
+			if span.ErrorCategory != telemetry.ErrorCategoryCircuitOpen {
+				untestable = false
+			}
+		}
+	}
+	for _, steps := range [][]ReportStep{report.Failures, report.Warnings, report.Skipped, report.UnexpectedPasses} {
+		sort.Slice(steps, func(i, j int) bool {
+			if steps[i].Name != steps[j].Name {
+				return steps[i].Name < steps[j].Name
+			}
+			return steps[i].Detail < steps[j].Detail
+		})
+	}
+	switch {
+	case len(report.Failures) > 0 && untestable:
+		report.Result = ReportSkip
+		report.Reason = "external dependencies unavailable"
+	case len(report.Failures) > 0 || primary.IsFailedOrCausedFailure():
+		report.Result = ReportFail
+	}
+	return report
+}
+
+// reportLine collapses text onto a single line, so that it can't be mistaken
+// for another field of the report.
+func reportLine(str string) string {
+	return strings.Join(strings.Fields(str), " ")
+}
+
+// WriteText renders the report as lines of "key: value" pairs in a fixed
+// order, starting with the format version. Steps are sorted by name, so the
+// output only changes when the outcome of the run does.
+func (report RunReport) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "format: %d\n", ReportFormatVersion)
+	fmt.Fprintf(&sb, "result: %s\n", report.Result)
+	fmt.Fprintf(&sb, "exit-code: %d\n", report.Result.ExitCode())
+	if report.Reason != "" {
+		fmt.Fprintf(&sb, "reason: %s\n", reportLine(report.Reason))
+	}
+	if report.Name != "" {
+		fmt.Fprintf(&sb, "name: %s\n", reportLine(report.Name))
+	}
+	writeSteps := func(key string, steps []ReportStep) {
+		for _, step := range steps {
+			if step.Detail == "" {
+				fmt.Fprintf(&sb, "%s: %s\n", key, reportLine(step.Name))
+			} else {
+				fmt.Fprintf(&sb, "%s: %s: %s\n", key, reportLine(step.Name), reportLine(step.Detail))
+			}
+		}
+	}
+	writeSteps("failed", report.Failures)
+	writeSteps("warning", report.Warnings)
+	writeSteps("skipped", report.Skipped)
+	writeSteps("unexpected-pass", report.UnexpectedPasses)
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
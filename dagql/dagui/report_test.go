@@ -0,0 +1,64 @@
+package dagui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"dagger.io/dagger/telemetry"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestReport(t *testing.T) {
+	failed := func(snapshot SpanSnapshot, errMsg, category string) SpanSnapshot {
+		snapshot.Status = sdktrace.Status{Code: codes.Error, Description: errMsg}
+		snapshot.ErrorCategory = category
+		return snapshot
+	}
+	report := func(snapshots ...SpanSnapshot) (RunReport, string) {
+		db := NewDB()
+		db.SetPrimarySpan(testSpanID(1))
+		db.ImportSnapshots(snapshots)
+		report := db.Report(QuarantineWarn)
+		var out strings.Builder
+		require.NoError(t, report.WriteText(&out))
+		return report, out.String()
+	}
+
+	// reports of the same outcome are identical, regardless of timing
+	now := testTrace{start: time.Now()}
+	result, out := report(
+		failed(now.span(1, 0, "dagger call test", time.Second, time.Minute), "2 steps failed", ""),
+		failed(now.span(3, 1, "test", 3*time.Second, time.Minute), "exit code 1:\nassertion failed", ""),
+		failed(now.span(2, 1, "build", 2*time.Second, time.Minute), "exit code 2", ""),
+	)
+	require.Equal(t, ReportFail, result.Result)
+	require.Equal(t, `format: 1
+result: fail
+exit-code: 1
+name: dagger call test
+failed: build: exit code 2
+failed: test: exit code 1: assertion failed
+`, out)
+	earlier := testTrace{start: now.start.Add(-time.Hour)}
+	_, again := report(
+		failed(earlier.span(1, 0, "dagger call test", time.Second, time.Minute), "2 steps failed", ""),
+		failed(earlier.span(2, 1, "test", 2*time.Second, time.Minute), "exit code 1:\nassertion failed", ""),
+		failed(earlier.span(3, 1, "build", 3*time.Second, time.Minute), "exit code 2", ""),
+	)
+	require.Equal(t, out, again)
+
+	// failures of external dependencies say nothing about the code
+	skipped, _ := report(
+		failed(now.span(1, 0, "dagger call test", time.Second, time.Minute), "1 step failed", ""),
+		failed(now.span(2, 1, "pull", 2*time.Second, time.Minute), "circuit open", telemetry.ErrorCategoryCircuitOpen),
+	)
+	require.Equal(t, ReportSkip, skipped.Result)
+	require.Equal(t, 125, skipped.Result.ExitCode())
+
+	passed, out := report(now.span(1, 0, "dagger call test", time.Second, time.Minute))
+	require.Equal(t, ReportPass, passed.Result)
+	require.Equal(t, "format: 1\nresult: pass\nexit-code: 0\nname: dagger call test\n", out)
+}
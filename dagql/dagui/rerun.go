@@ -0,0 +1,167 @@
+package dagui
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/dagger/dagger/dagql/call/callpbv1"
+)
+
+// RerunPlan is how to resume a stored run from one of its calls.
+type RerunPlan struct {
+	// From is the span of the call to resume from.
+	From *Span
+
+	// Modules are the encoded IDs of the modules that the calls need served.
+	Modules []string
+
+	// Calls are the encoded IDs of the calls to evaluate again, in the order
+	// they were first made: From, followed by the run's final calls that
+	// depended on it. Everything else they depend on is expected to be cached.
+	Calls []string
+}
+
+// PlanRerun plans resuming the run from the given span ID or call digest,
+// defaulting to the first call that failed.
+func (db *DB) PlanRerun(from string) (*RerunPlan, error) {
+	fromSpan, err := db.rerunSpan(from)
+	if err != nil {
+		return nil, err
+	}
+	plan := &RerunPlan{From: fromSpan}
+	digests := []string{fromSpan.CallDigest}
+
+	consumed := map[string]bool{}
+	for _, call := range db.Calls {
+		consumed[call.ReceiverDigest] = true
+		for _, arg := range call.Args {
+			literalCallDigests(arg.GetValue(), consumed)
+		}
+	}
+
+	planned := map[string]bool{fromSpan.CallDigest: true}
+	for _, span := range db.Spans.Order {
+		dig := span.CallDigest
+		if dig == "" || planned[dig] || consumed[dig] || !isTopLevelCall(span) {
+			continue
+		}
+		calls := map[string]*callpbv1.Call{}
+		if !db.gatherCalls(dig, calls) {
+			continue
+		}
+		// calls that build on the result, or that made it within a function
+		if _, ok := calls[fromSpan.CallDigest]; ok || isAncestor(span, fromSpan) {
+			planned[dig] = true
+			digests = append(digests, dig)
+		}
+	}
+
+	modules := map[string]bool{}
+	for _, dig := range digests {
+		id, err := db.EncodeID(dig)
+		if err != nil {
+			return nil, err
+		}
+		plan.Calls = append(plan.Calls, id)
+		calls := map[string]*callpbv1.Call{}
+		db.gatherCalls(dig, calls)
+		for _, dep := range calls {
+			modDig := dep.GetModule().GetCallDigest()
+			if modDig == "" || modules[modDig] {
+				continue
+			}
+			modules[modDig] = true
+			id, err := db.EncodeID(modDig)
+			if err != nil {
+				return nil, fmt.Errorf("module %s: %w", dep.GetModule().GetName(), err)
+			}
+			plan.Modules = append(plan.Modules, id)
+		}
+	}
+	return plan, nil
+}
+
+// EncodeID encodes the ID of the given call from the calls recorded in the
+// trace, failing if any of the calls it references weren't recorded.
+func (db *DB) EncodeID(dig string) (string, error) {
+	dag := &callpbv1.DAG{
+		RootDigest:    dig,
+		CallsByDigest: map[string]*callpbv1.Call{},
+	}
+	if !db.gatherCalls(dig, dag.CallsByDigest) {
+		return "", fmt.Errorf("trace is missing calls made by %s", dig)
+	}
+	payload, err := proto.Marshal(dag)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(payload), nil
+}
+
+// rerunSpan finds the span of the call to rerun from.
+func (db *DB) rerunSpan(from string) (*Span, error) {
+	if from == "" {
+		for _, span := range db.Spans.Order {
+			if span.CallDigest != "" && span.IsFailed() && !hasFailedChildCall(span) {
+				return span, nil
+			}
+		}
+		return nil, fmt.Errorf("trace has no failed calls to rerun from")
+	}
+	if spanID, err := trace.SpanIDFromHex(from); err == nil {
+		span := db.Spans.Map[SpanID{SpanID: spanID}]
+		if span == nil {
+			return nil, fmt.Errorf("span %s not found in trace", from)
+		}
+		for span != nil && span.CallDigest == "" {
+			span = span.ParentSpan
+		}
+		if span == nil {
+			return nil, fmt.Errorf("span %s is not part of a call", from)
+		}
+		return span, nil
+	}
+	if _, ok := db.Calls[from]; ok {
+		if span := db.MostInterestingSpan(from); span != nil {
+			return span, nil
+		}
+	}
+	return nil, fmt.Errorf("no span or call %q in trace", from)
+}
+
+// hasFailedChildCall returns whether any of the span's descendants is a call
+// that failed.
+func hasFailedChildCall(span *Span) bool {
+	for _, child := range span.ChildSpans.Order {
+		if child.CallDigest != "" && child.IsFailed() {
+			return true
+		}
+		if hasFailedChildCall(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTopLevelCall returns whether the span is a call made directly by the
+// client, rather than from within another call, e.g. a function.
+func isTopLevelCall(span *Span) bool {
+	for parent := span.ParentSpan; parent != nil; parent = parent.ParentSpan {
+		if parent.CallDigest != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func isAncestor(ancestor, span *Span) bool {
+	for parent := span.ParentSpan; parent != nil; parent = parent.ParentSpan {
+		if parent == ancestor {
+			return true
+		}
+	}
+	return false
+}
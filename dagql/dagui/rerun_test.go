@@ -0,0 +1,87 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dagger/dagger/dagql/call"
+)
+
+func TestPlanRerun(t *testing.T) {
+	ctrType := &ast.Type{NamedType: "Container", NonNull: true}
+	strType := &ast.Type{NamedType: "String", NonNull: true}
+	ctr := call.New().Append(ctrType, "container", "", nil, false, 0, "")
+	from := ctr.Append(ctrType, "from", "", nil, false, 0, "",
+		call.NewArgument("address", call.NewLiteralString("alpine"), false))
+	exec := from.Append(ctrType, "withExec", "", nil, false, 0, "",
+		call.NewArgument("args", call.NewLiteralList(call.NewLiteralString("false")), false))
+	stdout := exec.Append(strType, "stdout", "", nil, false, 0, "")
+	other := from.Append(ctrType, "withWorkdir", "", nil, false, 0, "",
+		call.NewArgument("path", call.NewLiteralString("/src"), false))
+
+	start := time.Now().Add(-time.Hour)
+	traceID := TraceID{TraceID: trace.TraceID{1}}
+	root := SpanID{SpanID: trace.SpanID{1}}
+	snapshots := []SpanSnapshot{{
+		ID:        root,
+		TraceID:   traceID,
+		Name:      "run",
+		StartTime: start,
+		EndTime:   start.Add(time.Minute),
+	}}
+	for i, id := range []*call.ID{ctr, from, exec, stdout, other} {
+		payload, err := id.Call().Encode()
+		require.NoError(t, err)
+		snapshot := SpanSnapshot{
+			ID:          SpanID{SpanID: trace.SpanID{byte(i + 2)}},
+			TraceID:     traceID,
+			ParentID:    root,
+			Name:        id.Field(),
+			CallDigest:  string(id.Digest()),
+			CallPayload: payload,
+			StartTime:   start.Add(time.Duration(i+1) * time.Second),
+			EndTime:     start.Add(time.Minute),
+		}
+		if id == exec || id == stdout {
+			snapshot.Status = sdktrace.Status{Code: codes.Error}
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	db := NewDB()
+	db.SetPrimarySpan(root)
+	db.ImportSnapshots(snapshots)
+
+	decode := func(ids []string) []string {
+		var fields []string
+		for _, enc := range ids {
+			var id call.ID
+			require.NoError(t, id.Decode(enc))
+			fields = append(fields, id.Field())
+		}
+		return fields
+	}
+
+	// defaults to the first failed call, followed by the calls built on it
+	plan, err := db.PlanRerun("")
+	require.NoError(t, err)
+	require.Equal(t, "withExec", plan.From.Name)
+	require.Equal(t, []string{"withExec", "stdout"}, decode(plan.Calls))
+	require.Empty(t, plan.Modules)
+
+	// calls can be selected by span ID or digest
+	plan, err = db.PlanRerun(SpanID{SpanID: trace.SpanID{3}}.String())
+	require.NoError(t, err)
+	require.Equal(t, []string{"from", "stdout", "withWorkdir"}, decode(plan.Calls))
+	plan, err = db.PlanRerun(string(stdout.Digest()))
+	require.NoError(t, err)
+	require.Equal(t, []string{"stdout"}, decode(plan.Calls))
+
+	_, err = db.PlanRerun("nope")
+	require.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package dagui
+
+import (
+	"time"
+
+	"dagger.io/dagger/telemetry"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanResources is the resource usage of the container run by a span, e.g.
+// an exec, as sampled by the engine over the life of the container.
+type SpanResources struct {
+	// CPU is the CPU time used, across all cores.
+	CPU time.Duration `json:",omitempty"`
+
+	// PeakMemoryBytes is the most memory used at once.
+	PeakMemoryBytes int64 `json:",omitempty"`
+
+	DiskReadBytes  int64 `json:",omitempty"`
+	DiskWriteBytes int64 `json:",omitempty"`
+
+	NetworkRxBytes int64 `json:",omitempty"`
+	NetworkTxBytes int64 `json:",omitempty"`
+}
+
+// record records a sample of a metric, returning false if the metric isn't a
+// resource. The metrics are all totals for the life of the container, so the
+// latest sample wins.
+func (res *SpanResources) record(name string, value int64) bool {
+	switch name {
+	case telemetry.CPUStatUsage:
+		res.CPU = time.Duration(value) * time.Microsecond
+	case telemetry.MemoryPeakBytes:
+		res.PeakMemoryBytes = max(res.PeakMemoryBytes, value)
+	case telemetry.IOStatDiskReadBytes:
+		res.DiskReadBytes = value
+	case telemetry.IOStatDiskWriteBytes:
+		res.DiskWriteBytes = value
+	case telemetry.NetstatRxBytes:
+		res.NetworkRxBytes = value
+	case telemetry.NetstatTxBytes:
+		res.NetworkTxBytes = value
+	default:
+		return false
+	}
+	return true
+}
+
+// recordResources records a sample of a metric as the resource usage of the
+// span it was sampled for, if any.
+func (db *DB) recordResources(name string, point metricdata.DataPoint[int64]) {
+	span := db.metricSpan(point)
+	if span == nil {
+		return
+	}
+	res := span.Resources
+	if res == nil {
+		res = &SpanResources{}
+	}
+	if !res.record(name, point.Value) {
+		return
+	}
+	span.Resources = res
+	db.update(span)
+}
+
+// metricSpan returns the span a metric was sampled for, if any.
+func (db *DB) metricSpan(point metricdata.DataPoint[int64]) *Span {
+	val, ok := point.Attributes.Value(telemetry.MetricsSpanIDAttr)
+	if !ok {
+		return nil
+	}
+	spanID, err := trace.SpanIDFromHex(val.AsString())
+	if err != nil {
+		return nil
+	}
+	// spans are exported as they start, before any samples are taken, so a
+	// missing span is one we don't know about
+	return db.Spans.Map[SpanID{SpanID: spanID}]
+}
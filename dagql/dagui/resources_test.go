@@ -0,0 +1,63 @@
+package dagui
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dagger.io/dagger/telemetry"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSpanResources(t *testing.T) {
+	traceID := TraceID{TraceID: trace.TraceID{1}}
+	exec := SpanID{SpanID: trace.SpanID{1}}
+	db := NewDB()
+	db.ImportSnapshots([]SpanSnapshot{{
+		ID:        exec,
+		TraceID:   traceID,
+		Name:      "exec go build",
+		StartTime: time.Now(),
+	}})
+	version := db.Spans.Map[exec].Version
+
+	attrs := attribute.NewSet(
+		attribute.String(telemetry.DagDigestAttr, "xxh3:exec"),
+		attribute.String(telemetry.MetricsSpanIDAttr, exec.String()),
+	)
+	gauge := func(name string, values ...int64) metricdata.Metrics {
+		var points []metricdata.DataPoint[int64]
+		for _, val := range values {
+			points = append(points, metricdata.DataPoint[int64]{Attributes: attrs, Value: val})
+		}
+		return metricdata.Metrics{Name: name, Data: metricdata.Gauge[int64]{DataPoints: points}}
+	}
+	require.NoError(t, db.MetricExporter().Export(context.Background(), &metricdata.ResourceMetrics{
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Metrics: []metricdata.Metrics{
+				gauge(telemetry.CPUStatUsage, 500_000, 1_500_000),
+				gauge(telemetry.MemoryPeakBytes, 1024, 4096),
+				gauge(telemetry.MemoryCurrentBytes, 2048),
+				gauge(telemetry.IOStatDiskReadBytes, 10),
+				gauge(telemetry.IOStatDiskWriteBytes, 20),
+				gauge(telemetry.NetstatRxBytes, 30),
+				gauge(telemetry.NetstatTxBytes, 40),
+			},
+		}},
+	}))
+
+	span := db.Spans.Map[exec]
+	require.Equal(t, &SpanResources{
+		CPU:             1500 * time.Millisecond,
+		PeakMemoryBytes: 4096,
+		DiskReadBytes:   10,
+		DiskWriteBytes:  20,
+		NetworkRxBytes:  30,
+		NetworkTxBytes:  40,
+	}, span.Resources)
+	require.Greater(t, span.Version, version)
+	require.Equal(t, span.Resources, span.Snapshot().Resources)
+}
@@ -0,0 +1,237 @@
+package dagui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetentionPolicy limits how much telemetry the DB keeps in memory, for
+// sessions that run long enough for it to matter. Completed subtrees are
+// pruned as a whole, oldest first, while their time stays accounted for in
+// the Activity of the spans above them and their statuses in SpanCounts.
+type RetentionPolicy struct {
+	// MaxSpans is the number of spans above which completed subtrees are
+	// pruned. Zero means no limit.
+	MaxSpans int
+
+	// MaxAge is how long completed subtrees are kept after they end. Zero
+	// means no limit.
+	MaxAge time.Duration
+
+	// KeepFailed keeps subtrees containing failures regardless of the
+	// limits, so that they can still be looked into.
+	KeepFailed bool
+}
+
+// Enabled returns whether the policy prunes anything at all.
+func (policy RetentionPolicy) Enabled() bool {
+	return policy.MaxSpans > 0 || policy.MaxAge > 0
+}
+
+// ParseRetentionPolicy parses a retention policy from a comma-separated list
+// of limits, e.g. "spans=10000,age=1h,keep-failed".
+func ParseRetentionPolicy(spec string) (RetentionPolicy, error) {
+	var policy RetentionPolicy
+	if spec == "" {
+		return policy, nil
+	}
+	for _, limit := range strings.Split(spec, ",") {
+		key, value, _ := strings.Cut(limit, "=")
+		var err error
+		switch key {
+		case "spans":
+			policy.MaxSpans, err = strconv.Atoi(value)
+			if err == nil && policy.MaxSpans < 0 {
+				err = fmt.Errorf("must not be negative")
+			}
+		case "age":
+			policy.MaxAge, err = time.ParseDuration(value)
+			if err == nil && policy.MaxAge < 0 {
+				err = fmt.Errorf("must not be negative")
+			}
+		case "keep-failed":
+			policy.KeepFailed = true
+			if value != "" {
+				policy.KeepFailed, err = strconv.ParseBool(value)
+			}
+		default:
+			return RetentionPolicy{}, fmt.Errorf("invalid retention limit %q: must be spans=N, age=DURATION, or keep-failed", limit)
+		}
+		if err != nil {
+			return RetentionPolicy{}, fmt.Errorf("invalid retention limit %q: %w", limit, err)
+		}
+	}
+	return policy, nil
+}
+
+// SetRetention sets the policy for pruning spans as telemetry is received.
+func (db *DB) SetRetention(policy RetentionPolicy) {
+	db.retention = policy
+}
+
+// PrunedSpans returns the number of spans pruned by the retention policy.
+func (db *DB) PrunedSpans() int {
+	return db.prunedSpans
+}
+
+// Prune prunes completed subtrees according to the retention policy,
+// returning the number of spans pruned.
+func (db *DB) Prune(now time.Time) int {
+	if !db.retention.Enabled() {
+		return 0
+	}
+	// make sure nothing is lost from the store
+	db.persistSpans()
+
+	candidates := db.prunableSubtrees()
+	slices.SortFunc(candidates, func(a, b *Span) int {
+		return a.Activity.EndTimeOrFallback(now).Compare(b.Activity.EndTimeOrFallback(now))
+	})
+
+	removed := map[SpanID]bool{}
+	for _, span := range candidates {
+		expired := db.retention.MaxAge > 0 &&
+			now.Sub(span.Activity.EndTimeOrFallback(now)) > db.retention.MaxAge
+		over := db.retention.MaxSpans > 0 &&
+			len(db.Spans.Order)-len(removed) > db.retention.MaxSpans
+		if !expired && !over {
+			// candidates are oldest first, so nothing else is due either
+			break
+		}
+		db.pruneSubtree(span, removed)
+	}
+	if len(removed) == 0 {
+		return 0
+	}
+	// remove from the big sets in one pass, rather than one span at a time
+	for _, set := range []SpanSet{db.Spans, db.updatedSpans, db.unstoredSpans} {
+		set.Order = slices.DeleteFunc(set.Order, func(span *Span) bool {
+			return removed[span.ID]
+		})
+		for id := range removed {
+			delete(set.Map, id)
+		}
+	}
+	db.prunedSpans += len(removed)
+	db.callCounts = nil
+	return len(removed)
+}
+
+// prunableSubtrees returns the largest subtrees that can be pruned, i.e.
+// completed spans whose parent can't be pruned itself, like the primary span
+// of a session that's still running.
+func (db *DB) prunableSubtrees() []*Span {
+	// the primary span and everything above it is always kept
+	protected := map[SpanID]bool{}
+	for span := db.Spans.Map[db.PrimarySpan]; span != nil; span = span.ParentSpan {
+		protected[span.ID] = true
+	}
+	if db.RootSpan != nil {
+		protected[db.RootSpan.ID] = true
+	}
+
+	prunable := map[SpanID]bool{}
+	var check func(*Span) bool
+	check = func(span *Span) bool {
+		if ok, checked := prunable[span.ID]; checked {
+			return ok
+		}
+		ok := span.Received &&
+			!span.IsRunningOrEffectsRunning() &&
+			!(db.retention.KeepFailed && span.IsFailedOrCausedFailure())
+		for _, child := range span.ChildSpans.Order {
+			// check every child, so they're all recorded
+			if !check(child) {
+				ok = false
+			}
+		}
+		prunable[span.ID] = ok
+		return ok
+	}
+
+	var candidates []*Span
+	var collect func(*Span)
+	collect = func(span *Span) {
+		if !protected[span.ID] && check(span) {
+			candidates = append(candidates, span)
+			return
+		}
+		for _, child := range span.ChildSpans.Order {
+			if child.ParentSpan == span {
+				collect(child)
+			}
+		}
+	}
+	for _, span := range db.Spans.Order {
+		if span.ParentSpan == nil {
+			collect(span)
+		}
+	}
+	return candidates
+}
+
+// pruneSubtree removes a span and everything under it, keeping track of
+// what was removed in the span's parent and in the pruned counts.
+func (db *DB) pruneSubtree(span *Span, removed map[SpanID]bool) {
+	if parent := span.ParentSpan; parent != nil {
+		parent.prunedChildren += countChildren(NewSpanSet(span))
+		db.update(parent)
+	}
+	var remove func(*Span)
+	remove = func(span *Span) {
+		// children linked from elsewhere are pruned along with their own
+		// parent instead
+		for _, child := range slices.Clone(span.ChildSpans.Order) {
+			if child.ParentSpan == span {
+				remove(child)
+			}
+		}
+		db.removeSpan(span)
+		removed[span.ID] = true
+	}
+	remove(span)
+}
+
+// removeSpan removes a span from the spans related to it, and from the DB's
+// indexes other than its span sets.
+func (db *DB) removeSpan(span *Span) {
+	db.pruned.add(span)
+
+	if parent := span.ParentSpan; parent != nil {
+		parent.ChildSpans.Remove(span)
+		parent.RunningSpans.Remove(span)
+	}
+	for _, cause := range span.causesViaLinks.Order {
+		cause.ChildSpans.Remove(span)
+		cause.effectsViaLinks.Remove(span)
+	}
+	for _, effect := range span.effectsViaLinks.Order {
+		effect.causesViaLinks.Remove(span)
+	}
+	if span.EffectID != "" {
+		if effects := db.EffectSpans[span.EffectID]; effects != nil {
+			effects.Remove(span)
+		}
+	}
+	for _, id := range span.EffectIDs {
+		if causes := db.CauseSpans[id]; causes != nil {
+			causes.Remove(span)
+		}
+	}
+	if intervals := db.Intervals[span.CallDigest]; intervals[span.StartTime] == span {
+		delete(intervals, span.StartTime)
+		if len(intervals) == 0 {
+			delete(db.Intervals, span.CallDigest)
+		}
+	}
+	db.Pauses.Remove(span)
+
+	delete(db.RawSpans, span.ID)
+	delete(db.LogTails, span.ID)
+	delete(db.PrimaryLogs, span.ID)
+	delete(db.seenSpans, span.ID)
+	delete(db.correctedSpans, span.ID)
+}
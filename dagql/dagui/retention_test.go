@@ -0,0 +1,86 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestParseRetentionPolicy(t *testing.T) {
+	policy, err := ParseRetentionPolicy("spans=100,age=1h,keep-failed")
+	require.NoError(t, err)
+	require.Equal(t, RetentionPolicy{MaxSpans: 100, MaxAge: time.Hour, KeepFailed: true}, policy)
+
+	policy, err = ParseRetentionPolicy("")
+	require.NoError(t, err)
+	require.False(t, policy.Enabled())
+
+	for _, spec := range []string{"spans=lots", "age=-1h", "keep-failed=maybe", "size=1G"} {
+		_, err := ParseRetentionPolicy(spec)
+		require.Error(t, err, spec)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Now()
+	start := now.Add(-time.Hour)
+	span := testTrace{start: start}.span
+	failed := func(snapshot SpanSnapshot) SpanSnapshot {
+		snapshot.Status = sdktrace.Status{Code: codes.Error}
+		return snapshot
+	}
+
+	db := NewDB()
+	db.SetPrimarySpan(testSpanID(1))
+	db.SetRetention(RetentionPolicy{MaxAge: 10 * time.Minute, KeepFailed: true})
+	db.ImportSnapshots([]SpanSnapshot{
+		// the session, still running
+		span(1, 0, "", 0, 0),
+		// done long ago
+		span(2, 1, "", time.Minute, 5*time.Minute),
+		span(3, 2, "", 2*time.Minute, 4*time.Minute),
+		// failed long ago
+		failed(span(4, 1, "", 5*time.Minute, 10*time.Minute)),
+		// done recently
+		span(5, 1, "", 55*time.Minute, 56*time.Minute),
+		// still running
+		span(6, 1, "", 58*time.Minute, 0),
+		span(7, 6, "", 58*time.Minute, 0),
+	})
+	counts := db.SpanCounts()
+	activity := db.Spans.Map[testSpanID(1)].Activity
+
+	// spans get pruned as they're imported
+	require.Equal(t, 2, db.PrunedSpans())
+	require.Equal(t, 0, db.Prune(now))
+
+	var kept []SpanID
+	for _, span := range db.Spans.Order {
+		kept = append(kept, span.ID)
+	}
+	require.ElementsMatch(t, []SpanID{testSpanID(1), testSpanID(4), testSpanID(5), testSpanID(6), testSpanID(7)}, kept)
+	require.NotContains(t, db.Spans.Map[testSpanID(1)].ChildSpans.Map, testSpanID(2))
+
+	// what was pruned is still accounted for
+	require.Equal(t, 4, db.Spans.Map[testSpanID(1)].Snapshot().ChildCount)
+	require.Equal(t, activity, db.Spans.Map[testSpanID(1)].Activity)
+	require.Equal(t, 3, counts.Done)
+	require.Equal(t, 1, counts.Failed)
+
+	// over the span limit, the oldest eligible subtree goes first
+	db.SetRetention(RetentionPolicy{MaxSpans: 4})
+	require.Equal(t, 1, db.Prune(now))
+	require.NotContains(t, db.Spans.Map, testSpanID(4))
+	require.Contains(t, db.Spans.Map, testSpanID(5))
+	require.Equal(t, counts, db.SpanCounts())
+
+	// the primary span and running spans are never pruned
+	db.SetRetention(RetentionPolicy{MaxSpans: 1})
+	require.Equal(t, 1, db.Prune(now))
+	require.Contains(t, db.Spans.Map, testSpanID(1))
+	require.Contains(t, db.Spans.Map, testSpanID(6))
+	require.Contains(t, db.Spans.Map, testSpanID(7))
+}
@@ -0,0 +1,59 @@
+package dagui
+
+// Roots returns the top-level invocations of the session, in the order they
+// started: the primary span, followed by the roots of any other traces the
+// session received, e.g. the calls of each client of a long-lived `dagger
+// listen`.
+func (db *DB) Roots() []*Span {
+	var roots []*Span
+	primary := db.Spans.Map[db.PrimarySpan]
+	if primary != nil {
+		roots = append(roots, primary)
+	}
+	for _, span := range db.Spans.Order {
+		if span == primary || !span.Received {
+			continue
+		}
+		if span.ParentSpan != nil && span.ParentSpan.Received {
+			continue
+		}
+		if primary != nil && span.TraceID == primary.TraceID {
+			// parents of spans in the primary trace can arrive late, so
+			// don't mistake them for separate invocations
+			continue
+		}
+		roots = append(roots, span)
+	}
+	return roots
+}
+
+// IsMultiRoot reports whether the session has more than one top-level
+// invocation.
+func (db *DB) IsMultiRoot() bool {
+	return len(db.Roots()) > 1
+}
+
+// Root returns the top-level span of the invocation the span belongs to: its
+// furthest ancestor that was received, or the span itself.
+func (span *Span) Root() *Span {
+	root := span
+	for root.ParentSpan != nil && root.ParentSpan.Received {
+		root = root.ParentSpan
+	}
+	return root
+}
+
+// RootCounts counts the steps of a single top-level invocation by status, so
+// that each invocation's progress can be reported independently.
+func (db *DB) RootCounts(root *Span) SpanCounts {
+	var counts SpanCounts
+	var walk func(*Span)
+	walk = func(span *Span) {
+		counts.add(span)
+		for _, child := range span.ChildSpans.Order {
+			walk(child)
+		}
+	}
+	walk(root)
+	return counts
+}
@@ -0,0 +1,50 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoots(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	// each client gets a trace of its own
+	session := testTrace{start: start, id: 1}
+	completed := testTrace{start: start.Add(time.Minute), id: 2}
+	client := testTrace{start: start.Add(2 * time.Minute), id: 3}
+
+	db := NewDB()
+	db.ImportSnapshots([]SpanSnapshot{
+		// the session itself, e.g. dagger listen
+		session.span(1, 0, "", time.Second, 0),
+		// a client that completed, leaving a step behind
+		completed.span(2, 0, "", time.Second, 2*time.Second),
+		completed.span(3, 2, "", 2*time.Second, 0),
+		// a client that's still running
+		client.span(4, 0, "", time.Second, 0),
+		client.span(5, 4, "", 2*time.Second, 0),
+		client.span(6, 4, "", 3*time.Second, 4*time.Second),
+	})
+
+	roots := db.Roots()
+	require.Len(t, roots, 3)
+	require.Equal(t, db.PrimarySpan, roots[0].ID)
+	require.True(t, db.IsMultiRoot())
+
+	clientRoot := roots[2]
+	require.Equal(t, clientRoot, db.Spans.Map[testSpanID(6)].Root())
+	require.Equal(t, SpanCounts{Running: 2, Done: 1}, db.RootCounts(clientRoot))
+
+	// a completed invocation doesn't cancel the steps of the others
+	require.True(t, db.Spans.Map[testSpanID(3)].IsCanceled())
+	require.False(t, db.Spans.Map[testSpanID(5)].IsCanceled())
+
+	view := db.RowsView(FrontendOpts{ZoomedSpan: db.PrimarySpan, Verbosity: ShowCompletedVerbosity})
+	var body []*Span
+	for _, tree := range view.Body {
+		body = append(body, tree.Span)
+	}
+	require.Equal(t, roots[1:], body)
+	require.Len(t, view.BySpan[clientRoot.ID].Children, 2)
+}
@@ -0,0 +1,108 @@
+package dagui
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dagger/dagger/dagql/call/callpbv1"
+)
+
+// Search returns the spans beneath the given span that match the query, in
+// order, including those that are hidden by default, e.g. because they're
+// encapsulated.
+//
+// A span matches if the query fuzzy-matches its name or the API it called,
+// e.g. "Container.withExec", or is contained in one of its call's arguments
+// or annotations. Matching is case-insensitive.
+func (db *DB) Search(under *Span, query string) []*Span {
+	query = strings.TrimSpace(query)
+	if query == "" || under == nil {
+		return nil
+	}
+	var matches []*Span
+	var walk func(*Span)
+	walk = func(span *Span) {
+		for _, child := range span.ChildSpans.Order {
+			if child.Received && !child.Passthrough && db.MatchesSearch(child, query) {
+				matches = append(matches, child)
+			}
+			walk(child)
+		}
+	}
+	walk(under)
+	return matches
+}
+
+// MatchesSearch returns whether the span matches the query. See Search.
+func (db *DB) MatchesSearch(span *Span, query string) bool {
+	if FuzzyMatch(query, span.Name) {
+		return true
+	}
+	if span.Call != nil && FuzzyMatch(query, db.callOperation(span.Call)) {
+		return true
+	}
+	query = strings.ToLower(query)
+	for _, val := range span.Annotations {
+		if strings.Contains(strings.ToLower(val), query) {
+			return true
+		}
+	}
+	if span.Call == nil {
+		return false
+	}
+	for _, arg := range span.Call.Args {
+		if argMatches(arg.GetValue(), query) {
+			return true
+		}
+	}
+	return false
+}
+
+func argMatches(lit *callpbv1.Literal, query string) bool {
+	switch val := lit.GetValue().(type) {
+	case *callpbv1.Literal_List:
+		for _, elem := range val.List.GetValues() {
+			if argMatches(elem, query) {
+				return true
+			}
+		}
+		return false
+	case *callpbv1.Literal_Object:
+		for _, field := range val.Object.GetValues() {
+			if argMatches(field.GetValue(), query) {
+				return true
+			}
+		}
+		return false
+	case *callpbv1.Literal_CallDigest:
+		// digests would match all sorts of short queries
+		return false
+	default:
+		return strings.Contains(strings.ToLower(fmt.Sprint(literalValue(lit))), query)
+	}
+}
+
+// FuzzyMatch returns whether the characters of the query appear in the text
+// in order, ignoring case and spaces in the query, e.g. "wexec" matches
+// "withExec".
+func FuzzyMatch(query, text string) bool {
+	for _, q := range query {
+		if unicode.IsSpace(q) {
+			continue
+		}
+		q = unicode.ToLower(q)
+		for {
+			r, size := utf8.DecodeRuneInString(text)
+			if size == 0 {
+				return false
+			}
+			text = text[size:]
+			if unicode.ToLower(r) == q {
+				break
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/dagql/call/callpbv1"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	require.True(t, FuzzyMatch("wexec", "withExec"))
+	require.True(t, FuzzyMatch("Container.withexec", "Container.withExec"))
+	require.True(t, FuzzyMatch("go test", "exec gotest"))
+	require.True(t, FuzzyMatch("", "anything"))
+	require.False(t, FuzzyMatch("execw", "withExec"))
+	require.False(t, FuzzyMatch("withExecs", "withExec"))
+}
+
+func TestSearch(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	span := testTrace{start: start}.span
+	call := &callpbv1.Call{
+		Digest: "exec",
+		Field:  "withExec",
+		Type:   &callpbv1.Type{NamedType: "Container"},
+		Args: []*callpbv1.Argument{{
+			Name: "args",
+			Value: &callpbv1.Literal{Value: &callpbv1.Literal_List{List: &callpbv1.List{
+				Values: []*callpbv1.Literal{
+					{Value: &callpbv1.Literal_String_{String_: "go"}},
+					{Value: &callpbv1.Literal_String_{String_: "vet"}},
+				},
+			}}},
+		}},
+	}
+	payload, err := call.Encode()
+	require.NoError(t, err)
+	exec := span(3, 2, "exec", 3*time.Second, time.Minute)
+	exec.CallDigest = call.Digest
+	exec.CallPayload = payload
+	lint := span(2, 1, "lint", 2*time.Second, time.Minute)
+	lint.Encapsulate = true
+
+	db := NewDB()
+	db.SetPrimarySpan(testSpanID(1))
+	db.ImportSnapshots([]SpanSnapshot{
+		span(1, 0, "run", time.Second, time.Minute),
+		lint,
+		exec,
+		span(4, 3, "go vet ./...", 4*time.Second, time.Minute),
+		span(5, 1, "test", 5*time.Second, time.Minute),
+	})
+	primary := db.Spans.Map[testSpanID(1)]
+
+	names := func(spans []*Span) []string {
+		var names []string
+		for _, span := range spans {
+			names = append(names, span.Name)
+		}
+		return names
+	}
+	require.Equal(t, []string{"exec", "go vet ./..."}, names(db.Search(primary, "vet")))
+	require.Equal(t, []string{"exec"}, names(db.Search(primary, "wexec")))
+	require.Equal(t, []string{"test"}, names(db.Search(primary, "TEST")))
+	require.Empty(t, db.Search(primary, " "))
+
+	// the encapsulated match is hidden and collapsed, until revealed
+	opts := FrontendOpts{ZoomedSpan: testSpanID(1)}
+	rows := db.RowsView(opts).Rows(opts)
+	require.Nil(t, rows.BySpan[testSpanID(4)])
+	opts.Reveal(db.Spans.Map[testSpanID(4)])
+	rows = db.RowsView(opts).Rows(opts)
+	require.NotNil(t, rows.BySpan[testSpanID(4)])
+	require.NotNil(t, rows.BySpan[testSpanID(3)])
+}
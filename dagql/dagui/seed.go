@@ -0,0 +1,119 @@
+package dagui
+
+import (
+	"sort"
+
+	"github.com/dagger/dagger/dagql/call/callpbv1"
+)
+
+// seedableTypes are the types whose results can be forced with a sync.
+var seedableTypes = map[string]bool{
+	"Container": true,
+	"Directory": true,
+	"File":      true,
+}
+
+// CacheSeed is a result from a trace that can be re-evaluated to reproduce
+// the cache entries that the original run relied on.
+type CacheSeed struct {
+	// Type is the GraphQL type of the result, e.g. Container.
+	Type string `json:"type"`
+
+	// ID is the encoded ID of the result.
+	ID string `json:"id"`
+}
+
+// CacheSeeds returns the final Container, Directory, and File results of the
+// trace which completed successfully. Re-evaluating them evaluates everything
+// they depended on, and nothing else.
+//
+// Results whose full call graph was not captured in the trace are skipped.
+func (db *DB) CacheSeeds() []CacheSeed {
+	consumed := map[string]bool{}
+	for _, call := range db.Calls {
+		consumed[call.ReceiverDigest] = true
+		for _, arg := range call.Args {
+			literalCallDigests(arg.GetValue(), consumed)
+		}
+	}
+
+	var seeds []CacheSeed
+	for dig, call := range db.Calls {
+		if consumed[dig] || call.Type.GetElem() != nil || !seedableTypes[call.Type.GetNamedType()] {
+			continue
+		}
+		if !db.callSucceeded(dig) {
+			continue
+		}
+		id, err := db.EncodeID(dig)
+		if err != nil {
+			continue
+		}
+		seeds = append(seeds, CacheSeed{
+			Type: call.Type.GetNamedType(),
+			ID:   id,
+		})
+	}
+	sort.Slice(seeds, func(i, j int) bool {
+		return seeds[i].ID < seeds[j].ID
+	})
+	return seeds
+}
+
+// callSucceeded reports whether the call completed without error at least
+// once in the trace.
+func (db *DB) callSucceeded(dig string) bool {
+	for _, span := range db.Intervals[dig] {
+		if !span.IsRunning() && !span.IsFailed() {
+			return true
+		}
+	}
+	return false
+}
+
+// gatherCalls collects the call and everything it references, returning
+// false if any of them are missing.
+func (db *DB) gatherCalls(dig string, calls map[string]*callpbv1.Call) bool {
+	if dig == "" {
+		return true
+	}
+	if _, ok := calls[dig]; ok {
+		return true
+	}
+	call, ok := db.Calls[dig]
+	if !ok {
+		return false
+	}
+	calls[dig] = call
+	if !db.gatherCalls(call.ReceiverDigest, calls) {
+		return false
+	}
+	if !db.gatherCalls(call.GetModule().GetCallDigest(), calls) {
+		return false
+	}
+	refs := map[string]bool{}
+	for _, arg := range call.Args {
+		literalCallDigests(arg.GetValue(), refs)
+	}
+	for ref := range refs {
+		if !db.gatherCalls(ref, calls) {
+			return false
+		}
+	}
+	return true
+}
+
+func literalCallDigests(lit *callpbv1.Literal, digests map[string]bool) {
+	switch x := lit.GetValue().(type) {
+	case *callpbv1.Literal_CallDigest:
+		digests[x.CallDigest] = true
+	case *callpbv1.Literal_List:
+		for _, val := range x.List.GetValues() {
+			literalCallDigests(val, digests)
+		}
+	case *callpbv1.Literal_Object:
+		for _, field := range x.Object.GetValues() {
+			literalCallDigests(field.GetValue(), digests)
+		}
+	}
+}
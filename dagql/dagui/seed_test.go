@@ -0,0 +1,68 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/dagger/dagger/dagql/call"
+)
+
+func TestCacheSeeds(t *testing.T) {
+	ctrType := &ast.Type{NamedType: "Container", NonNull: true}
+	dirType := &ast.Type{NamedType: "Directory", NonNull: true}
+	strType := &ast.Type{NamedType: "String", NonNull: true}
+	ctr := call.New().Append(ctrType, "container", "", nil, false, 0, "")
+	from := ctr.Append(ctrType, "from", "", nil, false, 0, "",
+		call.NewArgument("address", call.NewLiteralString("alpine"), false))
+	rootfs := from.Append(dirType, "rootfs", "", nil, false, 0, "")
+	src := call.New().Append(dirType, "directory", "", nil, false, 0, "")
+	mounted := from.Append(ctrType, "withDirectory", "", nil, false, 0, "",
+		call.NewArgument("path", call.NewLiteralString("/src"), false),
+		call.NewArgument("directory", call.NewLiteralID(src), false))
+	exec := from.Append(ctrType, "withExec", "", nil, false, 0, "",
+		call.NewArgument("args", call.NewLiteralList(call.NewLiteralString("false")), false))
+	stdout := from.Append(strType, "stdout", "", nil, false, 0, "")
+
+	tr := testTrace{start: time.Now().Add(-time.Hour)}
+	snapshots := []SpanSnapshot{tr.span(1, 0, "run", 0, time.Minute)}
+	for i, id := range []*call.ID{ctr, from, rootfs, src, mounted, exec, stdout} {
+		payload, err := id.Call().Encode()
+		require.NoError(t, err)
+		n := byte(i + 2)
+		snapshot := tr.span(n, 1, id.Field(), time.Duration(n)*time.Second, time.Minute)
+		snapshot.CallDigest = string(id.Digest())
+		snapshot.CallPayload = payload
+		if id == exec {
+			snapshot.Status = sdktrace.Status{Code: codes.Error}
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	db := NewDB()
+	db.SetPrimarySpan(testSpanID(1))
+	db.ImportSnapshots(snapshots)
+
+	// only the final results are seeds: the source directory is consumed by
+	// withDirectory, the failed exec never produced a result, and strings
+	// can't be synced
+	fields := map[string]string{}
+	for _, seed := range db.CacheSeeds() {
+		var id call.ID
+		require.NoError(t, id.Decode(seed.ID))
+		fields[id.Field()] = seed.Type
+	}
+	require.Equal(t, map[string]string{
+		"rootfs":        "Directory",
+		"withDirectory": "Container",
+	}, fields)
+
+	// seeds that would need calls missing from the trace are skipped
+	delete(db.Calls, string(src.Digest()))
+	seeds := db.CacheSeeds()
+	require.Len(t, seeds, 1)
+	require.Equal(t, "Directory", seeds[0].Type)
+}
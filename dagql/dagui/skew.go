@@ -0,0 +1,92 @@
+package dagui
+
+import (
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Clock skew between processes.
+//
+// Spans from different processes, e.g. the CLI and each engine it's connected
+// to, are timestamped by different clocks, so a child can appear to start
+// before its parent when the clocks disagree. Since a span can't start before
+// its parent, that's evidence of skew: each process's clock is given an
+// offset, estimated from such spans as they're received, which shifts the
+// times of its spans to line up with their parents'.
+//
+// Spans within a process share its clock, so only spans whose parent came
+// from another process are checked.
+
+// correctClockSkew shifts an incoming span's times by the offset of the clock
+// it was timestamped with, first updating the offset if the span's times
+// conflict with those of its parent or children from other clocks. Spans that
+// were already received keep the offset they were first shifted by, so that
+// updates to them stay consistent.
+func (db *DB) correctClockSkew(span *Span, clock attribute.Distinct, incoming *SpanSnapshot) {
+	offset := span.ClockSkew
+	if !span.Received {
+		offset = db.estimateClockSkew(span, clock, incoming)
+	}
+	if offset == 0 {
+		return
+	}
+	incoming.ClockSkew = offset
+	if !incoming.StartTime.IsZero() {
+		incoming.StartTime = incoming.StartTime.Add(offset)
+	}
+	if !incoming.EndTime.IsZero() {
+		incoming.EndTime = incoming.EndTime.Add(offset)
+	}
+	for i := range incoming.Events {
+		incoming.Events[i].Time = incoming.Events[i].Time.Add(offset)
+	}
+}
+
+// estimateClockSkew returns the offset of a clock, updated to account for the
+// incoming span's times relative to its children and parent from other
+// clocks.
+func (db *DB) estimateClockSkew(span *Span, clock attribute.Distinct, incoming *SpanSnapshot) time.Duration {
+	if db.clockSkew == nil {
+		db.clockSkew = make(map[attribute.Distinct]time.Duration)
+	}
+	offset := db.clockSkew[clock]
+	if incoming.StartTime.IsZero() {
+		return offset
+	}
+	var skewed time.Duration
+
+	// parents are often received after their children, since they end later,
+	// so children from other clocks may already be here
+	for _, child := range span.ChildSpans.Order {
+		if !child.Received || child.clock == clock || child.StartTime.IsZero() {
+			continue
+		}
+		if ahead := incoming.StartTime.Add(offset).Sub(child.StartTime); ahead > 0 {
+			offset -= ahead
+			skewed -= ahead
+		}
+	}
+
+	// a span can't start before its parent; this takes priority over the
+	// children, which may be skewed themselves
+	if parent := db.Spans.Map[incoming.ParentID]; parent != nil &&
+		parent.Received && parent.clock != clock && !parent.StartTime.IsZero() {
+		if behind := parent.StartTime.Sub(incoming.StartTime.Add(offset)); behind > 0 {
+			offset += behind
+			skewed += behind
+		}
+	}
+
+	if skewed != 0 {
+		db.clockSkew[clock] = offset
+		db.recordAnomaly(Anomaly{
+			Kind:   AnomalyClockSkew,
+			Span:   incoming.ID,
+			Name:   incoming.Name,
+			Detail: fmt.Sprintf("clock skewed by %s relative to its parent or children; offset now %s", skewed, offset),
+		})
+	}
+	return offset
+}
@@ -0,0 +1,98 @@
+package dagui
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestClockSkew(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	cli := resource.NewSchemaless(attribute.String("service.name", "dagger-cli"))
+	engine := resource.NewSchemaless(attribute.String("service.name", "dagger-engine"))
+	// the engine's clock is 5s behind the CLI's
+	const skew = 5 * time.Second
+	span := func(id, parent byte, res *resource.Resource, from, to time.Duration) sdktrace.ReadOnlySpan {
+		stub := tracetest.SpanStub{
+			Name: "span",
+			SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID: trace.TraceID{1},
+				SpanID:  trace.SpanID{id},
+			}),
+			StartTime: start.Add(from),
+			EndTime:   start.Add(to),
+			Resource:  res,
+			Events:    []sdktrace.Event{{Name: "event", Time: start.Add(from)}},
+		}
+		if parent != 0 {
+			stub.Parent = trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID: trace.TraceID{1},
+				SpanID:  trace.SpanID{parent},
+			})
+		}
+		if res == engine {
+			stub.StartTime = stub.StartTime.Add(-skew)
+			stub.EndTime = stub.EndTime.Add(-skew)
+			stub.Events[0].Time = stub.Events[0].Time.Add(-skew)
+		}
+		return stub.Snapshot()
+	}
+	get := func(db *DB, id byte) *Span {
+		return db.Spans.Map[SpanID{SpanID: trace.SpanID{id}}]
+	}
+
+	t.Run("parent first", func(t *testing.T) {
+		db := NewDB()
+		require.NoError(t, db.ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{
+			span(1, 0, cli, 0, time.Minute),
+			span(2, 1, engine, time.Second, 30*time.Second),
+			// same clock as its parent, so left alone, but shifted with it
+			span(3, 2, engine, 2*time.Second, 3*time.Second),
+		}))
+		require.Equal(t, start, get(db, 2).StartTime)
+		require.Equal(t, skew-time.Second, get(db, 2).ClockSkew)
+		require.Equal(t, start, get(db, 2).Events[0].Time)
+		require.Equal(t, start.Add(time.Second), get(db, 3).StartTime)
+		require.Zero(t, get(db, 1).ClockSkew)
+		_, counts := db.Anomalies()
+		require.Equal(t, 1, counts[AnomalyClockSkew])
+
+		// updates to a received span keep its offset
+		require.NoError(t, db.ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{
+			span(2, 1, engine, time.Second, 30*time.Second),
+		}))
+		require.Equal(t, start, get(db, 2).StartTime)
+		_, counts = db.Anomalies()
+		require.Zero(t, counts[AnomalyConflictingSpan])
+	})
+
+	t.Run("children first", func(t *testing.T) {
+		db := NewDB()
+		require.NoError(t, db.ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{
+			span(2, 1, engine, time.Second, 30*time.Second),
+			span(1, 0, cli, 0, time.Minute),
+		}))
+		parent, child := get(db, 1), get(db, 2)
+		require.False(t, child.StartTime.Before(parent.StartTime))
+		require.Equal(t, -(skew - time.Second), parent.ClockSkew)
+	})
+
+	t.Run("no skew", func(t *testing.T) {
+		db := NewDB()
+		require.NoError(t, db.ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{
+			span(1, 0, cli, 0, time.Minute),
+			span(2, 1, cli, -time.Second, 30*time.Second),
+		}))
+		// spans from the same clock are taken at their word
+		require.Equal(t, start.Add(-time.Second), get(db, 2).StartTime)
+		_, counts := db.Anomalies()
+		require.Zero(t, counts[AnomalyClockSkew])
+	})
+}
@@ -0,0 +1,54 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSkip(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	traceID := TraceID{TraceID: trace.TraceID{1}}
+	root := SpanID{SpanID: trace.SpanID{1}}
+	check := SpanID{SpanID: trace.SpanID{2}}
+	db := NewDB()
+	db.SetPrimarySpan(root)
+	db.ImportSnapshots([]SpanSnapshot{{
+		ID:        root,
+		TraceID:   traceID,
+		Name:      "run",
+		StartTime: start,
+		EndTime:   start.Add(time.Minute),
+	}, {
+		ID:         check,
+		TraceID:    traceID,
+		ParentID:   root,
+		Name:       "integration",
+		CallDigest: "sha256:check",
+		StartTime:  start.Add(time.Second),
+		EndTime:    start.Add(2 * time.Second),
+	}, {
+		ID:        SpanID{SpanID: trace.SpanID{3}},
+		TraceID:   traceID,
+		ParentID:  check,
+		Name:      "skip",
+		Internal:  true,
+		Skip:      "no docker socket",
+		StartTime: start.Add(time.Second),
+		EndTime:   start.Add(time.Second),
+	}})
+
+	span := db.Spans.Map[check]
+	require.True(t, span.IsSkipped())
+	skipped, reason := span.SkipReason()
+	require.True(t, skipped)
+	require.Equal(t, "no docker socket", reason)
+	require.False(t, db.Spans.Map[root].IsSkipped())
+
+	require.Equal(t, SpanCounts{Skipped: 1}, db.SpanCounts())
+	summary := db.Summary(0, 10, QuarantineWarn)
+	require.False(t, summary.Failed)
+	require.Equal(t, []StepSkip{{Name: "integration", Reason: "no docker socket"}}, summary.Skipped)
+}
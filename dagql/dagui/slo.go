@@ -0,0 +1,150 @@
+package dagui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SLOKind is the property of a run that an SLO constrains.
+type SLOKind string
+
+const (
+	// SLOTotal constrains the duration of the whole run.
+	SLOTotal SLOKind = "total"
+	// SLOStep constrains the duration of every span with a given name.
+	SLOStep SLOKind = "step"
+	// SLOCache constrains the fraction of calls that were cached.
+	SLOCache SLOKind = "cache"
+)
+
+// SLO is a performance objective evaluated against a run's spans once the
+// run completes.
+//
+// SLOs are written as:
+//
+//	total<10m
+//	step:<span name><2m
+//	cache>80%
+type SLO struct {
+	Kind SLOKind
+
+	// Step is the span name constrained by a SLOStep.
+	Step string
+
+	// Max is the maximum duration for SLOTotal and SLOStep.
+	Max time.Duration
+
+	// MinRatio is the minimum cache hit ratio, between 0 and 1, for SLOCache.
+	MinRatio float64
+
+	raw string
+}
+
+func (slo SLO) String() string {
+	return slo.raw
+}
+
+// ParseSLO parses an SLO from its string form.
+func ParseSLO(str string) (SLO, error) {
+	slo := SLO{raw: str}
+	switch {
+	case strings.HasPrefix(str, string(SLOCache)):
+		rest, ok := strings.CutPrefix(strings.TrimPrefix(str, string(SLOCache)), ">")
+		if !ok {
+			return SLO{}, fmt.Errorf("invalid SLO %q: expected cache>N%%", str)
+		}
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(rest, "%"), 64)
+		if err != nil || pct < 0 || pct > 100 {
+			return SLO{}, fmt.Errorf("invalid SLO %q: expected a percentage between 0 and 100", str)
+		}
+		slo.Kind = SLOCache
+		slo.MinRatio = pct / 100
+		return slo, nil
+	case strings.HasPrefix(str, string(SLOTotal)+"<"):
+		slo.Kind = SLOTotal
+	case strings.HasPrefix(str, string(SLOStep)+":"):
+		slo.Kind = SLOStep
+	default:
+		return SLO{}, fmt.Errorf("invalid SLO %q: must start with total<, step:, or cache>", str)
+	}
+	// split on the last < so that step names may contain one
+	idx := strings.LastIndex(str, "<")
+	if idx == -1 {
+		return SLO{}, fmt.Errorf("invalid SLO %q: expected <duration", str)
+	}
+	limit, err := time.ParseDuration(str[idx+1:])
+	if err != nil {
+		return SLO{}, fmt.Errorf("invalid SLO %q: %w", str, err)
+	}
+	slo.Max = limit
+	if slo.Kind == SLOStep {
+		slo.Step = str[len(SLOStep)+1 : idx]
+		if slo.Step == "" {
+			return SLO{}, fmt.Errorf("invalid SLO %q: missing step name", str)
+		}
+	}
+	return slo, nil
+}
+
+// SLOResult is the outcome of evaluating an SLO.
+type SLOResult struct {
+	SLO SLO
+
+	// OK is true if the SLO was met.
+	OK bool
+
+	// Actual is a human-readable description of the observed value.
+	Actual string
+}
+
+// Evaluate checks the SLO against the spans in the DB.
+func (slo SLO) Evaluate(db *DB) SLOResult {
+	res := SLOResult{SLO: slo}
+	switch slo.Kind {
+	case SLOTotal:
+		primary := db.PrimarySpan
+		span := db.Spans.Map[primary]
+		if span == nil {
+			res.Actual = "no primary span"
+			return res
+		}
+		dur := span.EndTimeOrNow().Sub(span.StartTime)
+		res.OK = dur < slo.Max
+		res.Actual = FormatDuration(dur)
+	case SLOStep:
+		var longest time.Duration
+		var found bool
+		for _, span := range db.Spans.Order {
+			if span.Name != slo.Step {
+				continue
+			}
+			found = true
+			longest = max(longest, span.EndTimeOrNow().Sub(span.StartTime))
+		}
+		if !found {
+			// steps may legitimately be skipped
+			res.OK = true
+			res.Actual = "not run"
+			return res
+		}
+		res.OK = longest < slo.Max
+		res.Actual = FormatDuration(longest)
+	case SLOCache:
+		ratio, total := db.CacheHitRatio()
+		res.OK = total == 0 || ratio > slo.MinRatio
+		res.Actual = fmt.Sprintf("%.1f%% of %d calls", ratio*100, total)
+	}
+	return res
+}
+
+// CacheHitRatio returns the fraction of completed, non-internal calls that
+// were cached, along with the number of calls considered.
+func (db *DB) CacheHitRatio() (float64, int) {
+	counts := db.countCalls()
+	if counts.completed == 0 {
+		return 0, 0
+	}
+	return float64(counts.cached) / float64(counts.completed), counts.completed
+}
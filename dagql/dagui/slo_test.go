@@ -0,0 +1,82 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestParseSLO(t *testing.T) {
+	for _, tc := range []struct {
+		str  string
+		want SLO
+	}{
+		{"total<10m", SLO{Kind: SLOTotal, Max: 10 * time.Minute}},
+		{"step:build<2m", SLO{Kind: SLOStep, Step: "build", Max: 2 * time.Minute}},
+		{"step:a<b<30s", SLO{Kind: SLOStep, Step: "a<b", Max: 30 * time.Second}},
+		{"cache>80%", SLO{Kind: SLOCache, MinRatio: 0.8}},
+	} {
+		t.Run(tc.str, func(t *testing.T) {
+			slo, err := ParseSLO(tc.str)
+			require.NoError(t, err)
+			tc.want.raw = tc.str
+			require.Equal(t, tc.want, slo)
+		})
+	}
+
+	for _, str := range []string{
+		"total>10m",
+		"step:<2m",
+		"step:build<fast",
+		"cache<80%",
+		"cache>180%",
+		"speed<1s",
+	} {
+		t.Run(str, func(t *testing.T) {
+			_, err := ParseSLO(str)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestCallCounts(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	traceID := TraceID{TraceID: trace.TraceID{1}}
+	call := func(id byte, cached, running bool) SpanSnapshot {
+		snapshot := SpanSnapshot{
+			ID:         SpanID{SpanID: trace.SpanID{id}},
+			TraceID:    traceID,
+			Name:       "call",
+			CallDigest: string(rune('a' + id)),
+			StartTime:  start,
+			Cached:     cached,
+		}
+		if !running {
+			snapshot.EndTime = start.Add(time.Second)
+		}
+		return snapshot
+	}
+
+	db := NewDB()
+	db.ImportSnapshots([]SpanSnapshot{call(1, true, false), call(2, false, false)})
+	require.Equal(t, SpanCounts{Cached: 1, Done: 2}, db.SpanCounts())
+	ratio, calls := db.CacheHitRatio()
+	require.Equal(t, 0.5, ratio)
+	require.Equal(t, 2, calls)
+
+	// the counts are cached until spans change
+	require.NotNil(t, db.callCounts)
+	db.ImportSnapshots([]SpanSnapshot{call(3, false, true)})
+	require.Equal(t, SpanCounts{Running: 1, Cached: 1, Done: 2}, db.SpanCounts())
+	ratio, calls = db.CacheHitRatio()
+	require.Equal(t, 0.5, ratio)
+	require.Equal(t, 2, calls)
+
+	db.ImportSnapshots([]SpanSnapshot{call(3, true, false)})
+	require.Equal(t, SpanCounts{Cached: 2, Done: 3}, db.SpanCounts())
+	ratio, calls = db.CacheHitRatio()
+	require.InDelta(t, 2.0/3.0, ratio, 0.001)
+	require.Equal(t, 3, calls)
+}
@@ -0,0 +1,154 @@
+package dagui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SlowThreshold is how long spans may run before they're considered slow.
+//
+// Thresholds are written as:
+//
+//	5m
+//	<span name pattern>=5m
+//
+// where the first applies to every span, and the second to spans whose name
+// matches the pattern, in which * matches any text, e.g. "withExec*=10m".
+type SlowThreshold struct {
+	// Pattern is the pattern span names must match, or empty to match all.
+	Pattern string
+
+	// Max is how long a matching span may run before it's slow.
+	Max time.Duration
+
+	raw string
+}
+
+func (th SlowThreshold) String() string {
+	return th.raw
+}
+
+// ParseSlowThreshold parses a threshold from its string form.
+func ParseSlowThreshold(str string) (SlowThreshold, error) {
+	th := SlowThreshold{raw: str}
+	dur := str
+	// split on the last = so that patterns may contain one
+	if idx := strings.LastIndex(str, "="); idx != -1 {
+		th.Pattern, dur = str[:idx], str[idx+1:]
+		if th.Pattern == "" {
+			return SlowThreshold{}, fmt.Errorf("invalid slow threshold %q: empty pattern", str)
+		}
+	}
+	limit, err := time.ParseDuration(dur)
+	if err != nil {
+		return SlowThreshold{}, fmt.Errorf("invalid slow threshold %q: %w", str, err)
+	}
+	if limit <= 0 {
+		return SlowThreshold{}, fmt.Errorf("invalid slow threshold %q: must be positive", str)
+	}
+	th.Max = limit
+	return th, nil
+}
+
+// SlowThresholds are the thresholds configured for a run.
+type SlowThresholds []SlowThreshold
+
+// For returns the threshold for spans with the given name: that of the first
+// pattern it matches, or else the last one for all spans.
+func (ths SlowThresholds) For(name string) (time.Duration, bool) {
+	var global time.Duration
+	for _, th := range ths {
+		if th.Pattern == "" {
+			global = th.Max
+		} else if matchGlob(th.Pattern, name) {
+			return th.Max, true
+		}
+	}
+	return global, global > 0
+}
+
+// IsSlow returns whether the span is running and has been for longer than
+// its threshold, returning the threshold.
+func (ths SlowThresholds) IsSlow(span *Span, now time.Time) (time.Duration, bool) {
+	if len(ths) == 0 || !span.IsRunning() {
+		return 0, false
+	}
+	limit, ok := ths.For(span.Name)
+	if !ok || span.ActiveDuration(now) <= limit {
+		return 0, false
+	}
+	return limit, true
+}
+
+// matchGlob returns whether str matches the pattern, in which * matches any
+// text, including none.
+func matchGlob(pattern, str string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == str
+	}
+	rest, ok := strings.CutPrefix(str, parts[0])
+	if !ok {
+		return false
+	}
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(rest, part)
+		if idx == -1 {
+			return false
+		}
+		rest = rest[idx+len(part):]
+	}
+	return len(rest) >= len(last) && strings.HasSuffix(rest, last)
+}
+
+// SlowSpan describes a span that became slow, to notify about it.
+type SlowSpan struct {
+	ID        SpanID
+	Name      string
+	Duration  time.Duration
+	Threshold time.Duration
+}
+
+// slowCheckInterval is how often SlowWatcher looks for slow spans, since it
+// goes through all of them.
+const slowCheckInterval = time.Second
+
+// SlowWatcher finds the spans that became slow since it last looked, so that
+// each slow span is reported once.
+type SlowWatcher struct {
+	lastCheck time.Time
+	reported  map[SpanID]bool
+}
+
+// Check returns the spans that became slow under the given thresholds since
+// the last check. It looks at most once per second, returning nothing in
+// between.
+func (w *SlowWatcher) Check(db *DB, ths SlowThresholds, now time.Time) []SlowSpan {
+	if len(ths) == 0 || now.Sub(w.lastCheck) < slowCheckInterval {
+		return nil
+	}
+	w.lastCheck = now
+	if w.reported == nil {
+		w.reported = map[SpanID]bool{}
+	}
+	var slow []SlowSpan
+	for _, span := range db.Spans.Order {
+		if w.reported[span.ID] {
+			continue
+		}
+		limit, ok := ths.IsSlow(span, now)
+		if !ok {
+			continue
+		}
+		w.reported[span.ID] = true
+		slow = append(slow, SlowSpan{
+			ID:        span.ID,
+			Name:      span.Name,
+			Duration:  span.ActiveDuration(now),
+			Threshold: limit,
+		})
+	}
+	return slow
+}
@@ -0,0 +1,105 @@
+package dagui
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestParseSlowThreshold(t *testing.T) {
+	for _, tc := range []struct {
+		str  string
+		want SlowThreshold
+	}{
+		{"5m", SlowThreshold{Max: 5 * time.Minute}},
+		{"withExec*=10m", SlowThreshold{Pattern: "withExec*", Max: 10 * time.Minute}},
+		{"a=b=30s", SlowThreshold{Pattern: "a=b", Max: 30 * time.Second}},
+	} {
+		t.Run(tc.str, func(t *testing.T) {
+			th, err := ParseSlowThreshold(tc.str)
+			require.NoError(t, err)
+			tc.want.raw = tc.str
+			require.Equal(t, tc.want, th)
+		})
+	}
+
+	for _, str := range []string{
+		"",
+		"=5m",
+		"build=fast",
+		"build=0s",
+	} {
+		t.Run(str, func(t *testing.T) {
+			_, err := ParseSlowThreshold(str)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestSlowThresholdsFor(t *testing.T) {
+	var ths SlowThresholds
+	for _, str := range []string{"withExec*=10m", "5m", "*test*=20m"} {
+		th, err := ParseSlowThreshold(str)
+		require.NoError(t, err)
+		ths = append(ths, th)
+	}
+	for name, want := range map[string]time.Duration{
+		"withExec go build":      10 * time.Minute,
+		"withExec go test ./...": 10 * time.Minute,
+		"go test":                20 * time.Minute,
+		"test":                   20 * time.Minute,
+		"build":                  5 * time.Minute,
+		"container.from(alpine)": 5 * time.Minute,
+	} {
+		limit, ok := ths.For(name)
+		require.True(t, ok, name)
+		require.Equal(t, want, limit, name)
+	}
+
+	_, ok := SlowThresholds{ths[0]}.For("build")
+	require.False(t, ok)
+}
+
+func TestSlowWatcher(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	stub := func(id byte, name string, start, end time.Time) sdktrace.ReadOnlySpan {
+		return tracetest.SpanStub{
+			Name: name,
+			SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID: trace.TraceID{1},
+				SpanID:  trace.SpanID{id},
+			}),
+			StartTime: start,
+			EndTime:   end,
+		}.Snapshot()
+	}
+	db := NewDB()
+	require.NoError(t, db.ExportSpans(ctx, []sdktrace.ReadOnlySpan{
+		stub(1, "build", now.Add(-time.Hour), time.Time{}),
+		stub(2, "test", now.Add(-time.Hour), now.Add(-time.Minute)),
+		stub(3, "lint", now.Add(-time.Second), time.Time{}),
+	}))
+	th, err := ParseSlowThreshold("1m")
+	require.NoError(t, err)
+	ths := SlowThresholds{th}
+
+	// only running spans past their threshold are slow
+	var w SlowWatcher
+	slow := w.Check(db, ths, now)
+	require.Len(t, slow, 1)
+	require.Equal(t, "build", slow[0].Name)
+	require.Equal(t, time.Minute, slow[0].Threshold)
+	require.GreaterOrEqual(t, slow[0].Duration, time.Hour)
+
+	// spans are reported once, as they become slow
+	slow = w.Check(db, ths, now.Add(2*time.Minute))
+	require.Len(t, slow, 1)
+	require.Equal(t, "lint", slow[0].Name)
+	require.Empty(t, w.Check(db, ths, now.Add(3*time.Minute)))
+}
@@ -0,0 +1,116 @@
+package dagui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// EngineNameAttr is the resource attribute the engine sets to its name.
+const EngineNameAttr = "dagger.io/engine.name"
+
+// TraceSource identifies the process that emitted a span, e.g. the CLI or one
+// of the engines it's connected to, from the OTel resource it exported the
+// span with.
+type TraceSource struct {
+	ServiceName    string `json:"serviceName,omitempty"`
+	ServiceVersion string `json:"serviceVersion,omitempty"`
+	HostName       string `json:"hostName,omitempty"`
+	EngineName     string `json:"engineName,omitempty"`
+}
+
+// NewTraceSource returns the source described by a resource, or nil if it
+// doesn't identify one.
+func NewTraceSource(res *resource.Resource) *TraceSource {
+	var src TraceSource
+	for _, kv := range res.Attributes() {
+		switch kv.Key {
+		case semconv.ServiceNameKey:
+			src.ServiceName = kv.Value.Emit()
+		case semconv.ServiceVersionKey:
+			src.ServiceVersion = kv.Value.Emit()
+		case semconv.HostNameKey:
+			src.HostName = kv.Value.Emit()
+		case EngineNameAttr:
+			src.EngineName = kv.Value.Emit()
+		}
+	}
+	if src == (TraceSource{}) {
+		return nil
+	}
+	return &src
+}
+
+// String returns a one-line description, e.g. dagger-engine v0.18.0 on
+// builder-1.
+func (src TraceSource) String() string {
+	var b strings.Builder
+	b.WriteString(src.ServiceName)
+	if b.Len() == 0 {
+		b.WriteString("unknown service")
+	}
+	if src.ServiceVersion != "" {
+		b.WriteString(" " + src.ServiceVersion)
+	}
+	host := src.EngineName
+	if host == "" {
+		host = src.HostName
+	}
+	if host != "" {
+		fmt.Fprintf(&b, " on %s", host)
+	}
+	return b.String()
+}
+
+// trackSource records the span's source, if it's one we haven't seen before.
+func (db *DB) trackSource(span *Span) {
+	if span.Source == nil || slices.Contains(db.Sources, *span.Source) {
+		return
+	}
+	db.Sources = append(db.Sources, *span.Source)
+}
+
+// IsEngine returns whether the source is an engine, as opposed to e.g. the
+// CLI or a module's SDK.
+func (src TraceSource) IsEngine() bool {
+	return src.EngineName != ""
+}
+
+// engineSources returns the sources that are engines.
+func (db *DB) engineSources() []TraceSource {
+	var engines []TraceSource
+	for _, src := range db.Sources {
+		if src.IsEngine() {
+			engines = append(engines, src)
+		}
+	}
+	return engines
+}
+
+// groupBySource orders the top-level rows of a trace spanning multiple engines
+// so that the rows of each engine are together, in the order the engines were
+// seen, and marks the first row of each group with its source. Rows from
+// other sources, like the CLI, stay at the top.
+func (db *DB) groupBySource(body []*TraceTree) {
+	engines := db.engineSources()
+	if len(engines) < 2 {
+		return
+	}
+	index := func(tree *TraceTree) int {
+		if tree.Span.Source == nil {
+			return -1
+		}
+		return slices.Index(engines, *tree.Span.Source)
+	}
+	slices.SortStableFunc(body, func(a, b *TraceTree) int {
+		return index(a) - index(b)
+	})
+	for i, tree := range body {
+		if idx := index(tree); idx >= 0 && (i == 0 || index(body[i-1]) != idx) {
+			tree.Source = tree.Span.Source
+		}
+	}
+}
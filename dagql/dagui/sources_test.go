@@ -0,0 +1,70 @@
+package dagui
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceSources(t *testing.T) {
+	start := time.Now()
+	engine := func(name string) *resource.Resource {
+		return resource.NewSchemaless(
+			attribute.String("service.name", "dagger-engine"),
+			attribute.String("service.version", "v0.18.0"),
+			attribute.String("host.name", name+".local"),
+			attribute.String(EngineNameAttr, name),
+		)
+	}
+	span := func(id byte, name string, res *resource.Resource) sdktrace.ReadOnlySpan {
+		return tracetest.SpanStub{
+			Name: name,
+			SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID: trace.TraceID{1},
+				SpanID:  trace.SpanID{id},
+			}),
+			StartTime: start.Add(time.Duration(id) * time.Second),
+			EndTime:   start.Add(time.Minute),
+			Resource:  res,
+		}.Snapshot()
+	}
+	db := NewDB()
+	cli := resource.NewSchemaless(attribute.String("service.name", "dagger-cli"))
+	require.NoError(t, db.ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{
+		span(1, "a1", engine("a")),
+		span(2, "b1", engine("b")),
+		span(3, "a2", engine("a")),
+		span(4, "cli", cli),
+	}))
+
+	require.Len(t, db.Sources, 3)
+	require.Equal(t, "dagger-engine v0.18.0 on a", db.Sources[0].String())
+	require.Equal(t, "b.local", db.Sources[1].HostName)
+
+	view := db.RowsView(FrontendOpts{})
+	var names []string
+	var headers []string
+	for _, tree := range view.Body {
+		names = append(names, tree.Span.Name)
+		if tree.Source != nil {
+			headers = append(headers, tree.Source.EngineName)
+		}
+	}
+	require.Equal(t, []string{"cli", "a1", "a2", "b1"}, names)
+	require.Equal(t, []string{"a", "b"}, headers)
+
+	// a single engine isn't grouped
+	db = NewDB()
+	require.NoError(t, db.ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{
+		span(1, "a1", engine("a")),
+		span(2, "cli", cli),
+	}))
+	require.Nil(t, db.RowsView(FrontendOpts{}).Body[0].Source)
+}
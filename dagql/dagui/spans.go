@@ -3,9 +3,13 @@ package dagui
 import (
 	"fmt"
 	"math"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"dagger.io/dagger/telemetry"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 
@@ -36,16 +40,31 @@ type Span struct {
 	// just allocated due to a span parent or other relationship.
 	Received bool
 
+	// prunedChildren is the number of children pruned by the DB's retention
+	// policy, which still count towards ChildCount.
+	prunedChildren int
+
+	// clock identifies the resource the span was exported with, whose clock
+	// timestamped it. See correctClockSkew.
+	clock attribute.Distinct
+
 	db *DB
 }
 
 // Snapshot returns a snapshot of the span's current state.
 func (span *Span) Snapshot() SpanSnapshot {
-	span.ChildCount = countChildren(span.ChildSpans)
+	span.ChildCount = countChildren(span.ChildSpans) + span.prunedChildren
 	span.Failed_, span.FailedReason_ = span.FailedReason()
 	span.Cached_, span.CachedReason_ = span.CachedReason()
 	span.Pending_, span.PendingReason_ = span.PendingReason()
 	span.Canceled_, span.CanceledReason_ = span.CanceledReason()
+	span.CancelCause_ = nil
+	if cause := span.CancelCause(); cause != (CancelCause{}) {
+		span.CancelCause_ = &cause
+	}
+	if span.Call != nil && span.db != nil {
+		span.LogicalID = span.db.LogicalID(span.Call)
+	}
 	snapshot := span.SpanSnapshot
 	snapshot.Final = true // NOTE: applied to copy
 	return snapshot
@@ -73,6 +92,7 @@ type SpanSnapshot struct {
 	Final bool
 
 	ID        SpanID
+	TraceID   TraceID
 	Name      string
 	StartTime time.Time
 	EndTime   time.Time
@@ -84,20 +104,35 @@ type SpanSnapshot struct {
 
 	Status sdktrace.Status `json:",omitempty"`
 
+	// Events are key moments that occurred during the span.
+	Events []SpanEvent `json:",omitempty"`
+
+	// Exceptions are errors recorded by the span, typically thrown by an SDK
+	// runtime.
+	Exceptions []SpanException `json:",omitempty"`
+
 	// statuses derived from span and its effects
-	Failed_         bool     `json:",omitempty"`
-	FailedReason_   []string `json:",omitempty"`
-	Cached_         bool     `json:",omitempty"`
-	CachedReason_   []string `json:",omitempty"`
-	Pending_        bool     `json:",omitempty"`
-	PendingReason_  []string `json:",omitempty"`
-	Canceled_       bool     `json:",omitempty"`
-	CanceledReason_ []string `json:",omitempty"`
+	Failed_         bool         `json:",omitempty"`
+	FailedReason_   []string     `json:",omitempty"`
+	Cached_         bool         `json:",omitempty"`
+	CachedReason_   []string     `json:",omitempty"`
+	Pending_        bool         `json:",omitempty"`
+	PendingReason_  []string     `json:",omitempty"`
+	Canceled_       bool         `json:",omitempty"`
+	CanceledReason_ []string     `json:",omitempty"`
+	CancelCause_    *CancelCause `json:",omitempty"`
 
 	// statuses reported by the span via attributes
 	Canceled bool `json:",omitempty"`
 	Cached   bool `json:",omitempty"`
 
+	// CanceledBy is who or what canceled the span, as reported by it, e.g.
+	// telemetry.CanceledByUser. See CancelCause.
+	CanceledBy string `json:",omitempty"`
+
+	// ErrorCategory distinguishes kinds of failures, e.g. runtime crashes.
+	ErrorCategory string `json:",omitempty"`
+
 	// UI preferences reported by the span, or applied to it (sync=>passthrough)
 	Internal     bool `json:",omitempty"`
 	Encapsulate  bool `json:",omitempty"`
@@ -106,6 +141,46 @@ type SpanSnapshot struct {
 	Passthrough  bool `json:",omitempty"`
 	Ignore       bool `json:",omitempty"`
 
+	// Quarantined is set for known-flaky steps, whose failures are reported
+	// as warnings.
+	Quarantined bool `json:",omitempty"`
+
+	// FailureAllowed and FailureExpected are set for steps that may or are
+	// expected to fail, whose failures don't propagate.
+	FailureAllowed  bool `json:",omitempty"`
+	FailureExpected bool `json:",omitempty"`
+
+	// Deadline is when the span's work times out, if it has a timeout.
+	Deadline time.Time `json:",omitempty"`
+
+	// Pause is set for spans covering a time when the pipeline was paused.
+	Pause bool `json:",omitempty"`
+
+	// Daemon is set for spans tracking the service of a daemon function,
+	// which run for as long as the service does, outliving the call that
+	// started them. DaemonHealth is the service's health, e.g.
+	// telemetry.DaemonHealthy.
+	Daemon       bool   `json:",omitempty"`
+	DaemonHealth string `json:",omitempty"`
+
+	// Skip is the reason given for skipping the parent span, set on the
+	// spans recording the skip.
+	Skip string `json:",omitempty"`
+
+	// TestCase is set for spans of test cases, and TestSuite is the name of
+	// the suite they belong to, if set. See DB.JUnitReport.
+	TestCase  bool   `json:",omitempty"`
+	TestSuite string `json:",omitempty"`
+
+	// PromptedArgs are the arguments of a "dagger call" that the user was
+	// prompted for, as "<function> --<flag>", set on the run's span.
+	PromptedArgs []string `json:",omitempty"`
+
+	// MatrixAxes are the axis names of the matrix whose cells are this
+	// span's children, and MatrixCell are the name=value labels of a cell.
+	MatrixAxes []string `json:",omitempty"`
+	MatrixCell []string `json:",omitempty"`
+
 	Inputs []string `json:",omitempty"`
 	Output string   `json:",omitempty"`
 
@@ -116,8 +191,61 @@ type SpanSnapshot struct {
 	CallDigest  string `json:",omitempty"`
 	CallPayload string `json:",omitempty"`
 
+	// LogicalID identifies the call across runs, even as its inputs change.
+	// See DB.LogicalID.
+	LogicalID string `json:",omitempty"`
+
 	ChildCount int  `json:",omitempty"`
 	HasLogs    bool `json:",omitempty"`
+
+	// Attributes holds the values of any attributes registered with
+	// RetainAttribute.
+	Attributes map[string]any `json:",omitempty"`
+
+	// UserAttributes holds the attributes that modules set in the
+	// telemetry.UserAttrPrefix namespace, keyed by their name without the
+	// prefix.
+	UserAttributes map[string]UserAttribute `json:",omitempty"`
+
+	// Annotations holds the annotations that modules attached to the span in
+	// the telemetry.AnnotationAttrPrefix namespace, keyed by their name
+	// without the prefix.
+	Annotations map[string]string `json:",omitempty"`
+
+	// Resources is the resource usage of the container run by the span, if
+	// any, from the metrics sampled for it.
+	Resources *SpanResources `json:",omitempty"`
+
+	// Connections counts the connections through the tunnel run by the span,
+	// if any, from the metrics sampled for it.
+	Connections *SpanConnections `json:",omitempty"`
+
+	// Source identifies the process that emitted the span, from the OTel
+	// resource it was exported with.
+	Source *TraceSource `json:",omitempty"`
+
+	// Corrected is set if the span's times couldn't be right as received and
+	// were corrected, e.g. CorrectionEndFromStart.
+	Corrected string `json:",omitempty"`
+
+	// ClockSkew is the offset the span's times were shifted by to correct for
+	// the skew of the clock that timestamped them. See correctClockSkew.
+	ClockSkew time.Duration `json:",omitempty"`
+}
+
+// SpanEvent is a timestamped event recorded within a span, e.g. a phase of a
+// long-running exec.
+type SpanEvent struct {
+	Name string
+	Time time.Time
+}
+
+// SpanException is an exception recorded within a span, following the OTel
+// semantic conventions for exceptions.
+type SpanException struct {
+	Type       string
+	Message    string
+	Stacktrace string `json:",omitempty"`
 }
 
 func (snapshot *SpanSnapshot) ProcessAttribute(name string, val any) {
@@ -143,6 +271,12 @@ func (snapshot *SpanSnapshot) ProcessAttribute(name string, val any) {
 	case telemetry.CanceledAttr:
 		snapshot.Canceled = val.(bool)
 
+	case telemetry.CanceledByAttr:
+		snapshot.CanceledBy = val.(string)
+
+	case telemetry.ErrorCategoryAttr:
+		snapshot.ErrorCategory = val.(string)
+
 	case telemetry.UIEncapsulateAttr:
 		snapshot.Encapsulate = val.(bool)
 
@@ -155,6 +289,42 @@ func (snapshot *SpanSnapshot) ProcessAttribute(name string, val any) {
 	case telemetry.UIPassthroughAttr:
 		snapshot.Passthrough = val.(bool)
 
+	case telemetry.UIQuarantineAttr:
+		snapshot.Quarantined = val.(bool)
+
+	case telemetry.UIFailureAllowedAttr:
+		snapshot.FailureAllowed = val.(bool)
+
+	case telemetry.UIFailureExpectedAttr:
+		snapshot.FailureExpected = val.(bool)
+
+	case telemetry.UIDeadlineAttr:
+		snapshot.Deadline = time.Unix(0, val.(int64))
+
+	case telemetry.UIPauseAttr:
+		snapshot.Pause = val.(bool)
+
+	case telemetry.UISkipAttr:
+		snapshot.Skip = val.(string)
+
+	case telemetry.TestCaseAttr:
+		snapshot.TestCase = val.(bool)
+
+	case telemetry.TestSuiteAttr:
+		snapshot.TestSuite = val.(string)
+
+	case telemetry.DaemonAttr:
+		snapshot.Daemon = val.(bool)
+
+	case telemetry.DaemonHealthAttr:
+		snapshot.DaemonHealth = val.(string)
+
+	case telemetry.UIMatrixAxesAttr:
+		snapshot.MatrixAxes = sliceOf[string](val)
+
+	case telemetry.UIMatrixCellAttr:
+		snapshot.MatrixCell = sliceOf[string](val)
+
 	case telemetry.DagInputsAttr:
 		snapshot.Inputs = sliceOf[string](val)
 
@@ -164,6 +334,9 @@ func (snapshot *SpanSnapshot) ProcessAttribute(name string, val any) {
 	case telemetry.EffectsCompletedAttr:
 		snapshot.EffectsCompleted = sliceOf[string](val)
 
+	case telemetry.PromptedArgsAttr:
+		snapshot.PromptedArgs = sliceOf[string](val)
+
 	case telemetry.DagOutputAttr:
 		snapshot.Output = val.(string)
 
@@ -174,9 +347,58 @@ func (snapshot *SpanSnapshot) ProcessAttribute(name string, val any) {
 		// encapsulate these by default; we only maybe want to see these if their
 		// parent failed, since some happy paths might involve _expected_ failures
 		snapshot.Encapsulated = true
+
+	default:
+		if strings.HasPrefix(name, telemetry.AnnotationAttrPrefix) {
+			annotation, value, err := parseAnnotation(name, val)
+			if err != nil {
+				slog.Debug("dropping invalid annotation", "name", name, "err", err)
+				return
+			}
+			if snapshot.Annotations == nil {
+				snapshot.Annotations = map[string]string{}
+			}
+			snapshot.Annotations[annotation] = value
+		}
+		if strings.HasPrefix(name, telemetry.UserAttrPrefix) {
+			userName, attr, err := parseUserAttribute(name, val)
+			if err != nil {
+				slog.Debug("dropping invalid user attribute", "name", name, "err", err)
+				return
+			}
+			if snapshot.UserAttributes == nil {
+				snapshot.UserAttributes = map[string]UserAttribute{}
+			}
+			snapshot.UserAttributes[userName] = attr
+		}
+		if isRetainedAttribute(name) {
+			if snapshot.Attributes == nil {
+				snapshot.Attributes = map[string]any{}
+			}
+			snapshot.Attributes[name] = val
+		}
 	}
 }
 
+var (
+	retainedAttrs   = map[string]bool{}
+	retainedAttrsMu sync.RWMutex
+)
+
+// RetainAttribute configures spans to keep the value of the given attribute in
+// their Attributes, for frontends that render it.
+func RetainAttribute(name string) {
+	retainedAttrsMu.Lock()
+	retainedAttrs[name] = true
+	retainedAttrsMu.Unlock()
+}
+
+func isRetainedAttribute(name string) bool {
+	retainedAttrsMu.RLock()
+	defer retainedAttrsMu.RUnlock()
+	return retainedAttrs[name]
+}
+
 func sliceOf[T any](val any) []T {
 	if direct, ok := val.([]T); ok {
 		return direct
@@ -215,7 +437,7 @@ func (span *Span) PropagateStatusToParentsAndLinks() {
 			changed = causal.RunningSpans.Remove(span)
 		}
 
-		if span.IsFailed() {
+		if span.IsFailed() && !span.IsFailureTolerated() {
 			causal.FailedLinks.Add(span)
 		}
 
@@ -286,6 +508,47 @@ func (span *Span) Errors() SpanSet {
 	return errs
 }
 
+// IsQuarantinedFailure returns true if the span failed but it, or one of its
+// parents, is quarantined, in which case the failure is reported as a warning
+// unless configured otherwise.
+func (span *Span) IsQuarantinedFailure(mode QuarantineMode) bool {
+	if mode == QuarantineFail || !span.IsFailedOrCausedFailure() {
+		return false
+	}
+	for s := span; s != nil; s = s.ParentSpan {
+		if s.Quarantined {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFailureTolerated returns true if the span, or one of its parents, is
+// allowed or expected to fail, in which case its failure doesn't propagate.
+func (span *Span) IsFailureTolerated() bool {
+	for s := span; s != nil; s = s.ParentSpan {
+		if s.FailureAllowed || s.FailureExpected {
+			return true
+		}
+	}
+	return false
+}
+
+// IsToleratedFailure returns true if the span failed but is allowed or
+// expected to.
+func (span *Span) IsToleratedFailure() bool {
+	return span.IsFailedOrCausedFailure() && span.IsFailureTolerated()
+}
+
+// IsUnexpectedPass returns true if the span was expected to fail, but
+// completed successfully.
+func (span *Span) IsUnexpectedPass() bool {
+	return span.FailureExpected &&
+		!span.IsRunningOrEffectsRunning() &&
+		!span.IsFailedOrCausedFailure() &&
+		!span.Canceled
+}
+
 func (span *Span) IsFailedOrCausedFailure() bool {
 	if span.Final {
 		return span.Failed_
@@ -356,14 +619,15 @@ func (span *Span) VisibleParent(opts FrontendOpts) *Span {
 }
 
 func (span *Span) Hidden(opts FrontendOpts) bool {
-	if span.IsInternal() && opts.Verbosity < ShowInternalVerbosity {
+	verbosity := span.Verbosity(opts)
+	if span.IsInternal() && verbosity < ShowInternalVerbosity {
 		// internal spans are hidden by default
 		return true
 	}
 	if span.ParentSpan != nil &&
 		(span.Encapsulated || span.ParentSpan.Encapsulate) &&
 		!span.ParentSpan.IsFailed() &&
-		opts.Verbosity < ShowEncapsulatedVerbosity {
+		verbosity < ShowEncapsulatedVerbosity {
 		// encapsulated steps are hidden (even on error) unless their parent errors
 		return true
 	}
@@ -544,6 +808,23 @@ func (span *Span) IsInternal() bool {
 	return span.Internal
 }
 
+// SkipReason returns whether the span was skipped and why, as recorded by one
+// of its children.
+func (span *Span) SkipReason() (bool, string) {
+	for _, child := range span.ChildSpans.Order {
+		if child.Skip != "" {
+			return true, child.Skip
+		}
+	}
+	return false, ""
+}
+
+// IsSkipped returns true if the span completed, but was skipped.
+func (span *Span) IsSkipped() bool {
+	skipped, _ := span.SkipReason()
+	return skipped && !span.IsRunningOrEffectsRunning() && !span.IsFailedOrCausedFailure()
+}
+
 func (span *Span) IsCanceled() bool {
 	canceled, _ := span.CanceledReason()
 	return canceled
@@ -557,8 +838,24 @@ func (span *Span) CanceledReason() (bool, []string) {
 	if span.Canceled {
 		reasons = append(reasons, "span says it is canceled")
 	}
-	if span.db.RootSpan != nil &&
-		!span.db.RootSpan.IsRunning() &&
+	for _, effect := range span.EffectIDs {
+		if effectSpans := span.db.EffectSpans[effect]; effectSpans != nil &&
+			slices.ContainsFunc(effectSpans.Order, func(s *Span) bool { return s.Canceled }) {
+			reasons = append(reasons, "span installed canceled effect: "+effect)
+		}
+	}
+	if span.IsRunningOrEffectsRunning() {
+		for parent := span.ParentSpan; parent != nil; parent = parent.ParentSpan {
+			if parent.Canceled && !parent.IsRunning() {
+				reasons = append(reasons, "parent span was canceled: "+parent.Name)
+				break
+			}
+		}
+	}
+	// only infer cancellation from the span's own invocation, since a
+	// session may serve several at once, and daemons are meant to outlive it
+	if root := span.Root(); !span.Daemon && !root.ParentID.IsValid() &&
+		!root.IsRunning() &&
 		span.IsRunningOrEffectsRunning() {
 		reasons = append(reasons, "root span completed, but this span span was still running")
 	}
@@ -595,9 +892,9 @@ func (span *Span) Classes() []string {
 }
 
 func FormatDuration(d time.Duration) string {
-	if d < 0 {
-		return "INVALID_DURATION"
-	}
+	// spans are normalized as they're received, so this is only reached by
+	// skew between clocks, e.g. a span that started after the local time
+	d = max(d, 0)
 
 	days := int64(d.Hours()) / 24
 	hours := int64(d.Hours()) % 24
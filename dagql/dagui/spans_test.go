@@ -0,0 +1,26 @@
+package dagui
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetainAttribute(t *testing.T) {
+	var snapshot SpanSnapshot
+	snapshot.ProcessAttribute("test.retained.report", "3 passed")
+	require.Nil(t, snapshot.Attributes)
+
+	RetainAttribute("test.retained.report")
+	snapshot.ProcessAttribute("test.retained.report", "3 passed")
+	snapshot.ProcessAttribute("test.retained.other", "x")
+	require.Equal(t, map[string]any{"test.retained.report": "3 passed"}, snapshot.Attributes)
+
+	// retained attributes are sent along with snapshots
+	payload, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	var decoded SpanSnapshot
+	require.NoError(t, json.Unmarshal(payload, &decoded))
+	require.Equal(t, snapshot.Attributes, decoded.Attributes)
+}
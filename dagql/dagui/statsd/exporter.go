@@ -0,0 +1,227 @@
+// Package statsd sends metrics derived from the spans of a run to a statsd
+// server, with DogStatsD tags, so that teams using Datadog can chart their
+// pipelines without deploying an OpenTelemetry collector.
+//
+// Each step sends its duration as it completes, and the run as a whole sends
+// its duration, outcome and cache usage once it finishes.
+package statsd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+// maxPacketSize keeps packets within the MTU of most networks, as statsd
+// clients usually do.
+const maxPacketSize = 1432
+
+// Exporter sends metrics for the spans in a DB.
+type Exporter struct {
+	db   *dagui.DB
+	w    io.Writer
+	tags []string
+
+	started time.Time
+
+	// held while reading or updating the DB
+	mu   sync.Mutex
+	sent map[dagui.SpanID]bool
+}
+
+// NewExporter sends metrics for the spans in the DB to w, one packet per
+// write, with the given tags added to every metric. The DB must not be updated
+// other than through the exporter.
+func NewExporter(db *dagui.DB, w io.Writer, tags []string) *Exporter {
+	return &Exporter{
+		db:      db,
+		w:       w,
+		tags:    tags,
+		started: time.Now(),
+		sent:    map[dagui.SpanID]bool{},
+	}
+}
+
+// Dial connects to a statsd server at the given address, either host:port,
+// udp://host:port, or unix:///path/to/socket for a Unix datagram socket.
+func Dial(addr string) (net.Conn, error) {
+	network := "udp"
+	if strings.Contains(addr, "://") {
+		u, err := url.Parse(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid statsd address %q: %w", addr, err)
+		}
+		switch u.Scheme {
+		case "udp":
+			addr = u.Host
+		case "unix":
+			network = "unixgram"
+			addr = u.Path
+		default:
+			return nil, fmt.Errorf("invalid statsd address %q: unsupported scheme %q", addr, u.Scheme)
+		}
+	}
+	return net.Dial(network, addr)
+}
+
+// SpanExporter returns an exporter that sends the metrics of steps as they
+// complete.
+func (e *Exporter) SpanExporter() sdktrace.SpanExporter {
+	return spanExporter{e}
+}
+
+type spanExporter struct {
+	*Exporter
+}
+
+func (e spanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.db.ExportSpans(ctx, spans); err != nil {
+		return err
+	}
+	e.flushSteps()
+	return nil
+}
+
+func (e spanExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+// Flush sends the metrics of steps that completed since the last flush.
+func (e *Exporter) Flush() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.flushSteps()
+}
+
+func (e *Exporter) flushSteps() {
+	p := e.packets()
+	for _, span := range e.db.Spans.Order {
+		if e.sent[span.ID] ||
+			span.Call == nil ||
+			span.IsInternal() ||
+			span.IsRunningOrEffectsRunning() ||
+			span.IsPending() ||
+			span.EndTime.Before(span.StartTime) {
+			continue
+		}
+		e.sent[span.ID] = true
+		p.timing("dagger.step.duration", span.EndTime.Sub(span.StartTime),
+			"operation:"+e.db.OperationName(span),
+			"outcome:"+outcome(span))
+	}
+	p.flush()
+}
+
+// Finish sends the metrics of the run as a whole, given the error it failed
+// with, if any.
+func (e *Exporter) Finish(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.flushSteps()
+
+	counts := e.db.SpanCounts()
+	runOutcome := "outcome:succeeded"
+	if err != nil {
+		runOutcome = "outcome:failed"
+	}
+	p := e.packets()
+	p.timing("dagger.run.duration", time.Since(e.started), runOutcome)
+	p.metric("dagger.run.count", "1", "c", runOutcome)
+	for _, steps := range []struct {
+		outcome string
+		count   int
+	}{
+		// Done includes cached steps
+		{"succeeded", counts.Done - counts.Cached},
+		{"cached", counts.Cached},
+		{"failed", counts.Failed},
+		{"skipped", counts.Skipped},
+	} {
+		p.metric("dagger.run.steps", fmt.Sprint(steps.count), "g", "outcome:"+steps.outcome)
+	}
+	var ratio float64
+	if counts.Done > 0 {
+		ratio = float64(counts.Cached) / float64(counts.Done)
+	}
+	p.metric("dagger.run.cache_hit_ratio", fmt.Sprint(ratio), "g")
+	p.flush()
+}
+
+func outcome(span *dagui.Span) string {
+	switch {
+	case span.IsFailed():
+		return "failed"
+	case span.IsSkipped():
+		return "skipped"
+	case span.IsCached():
+		return "cached"
+	default:
+		return "succeeded"
+	}
+}
+
+func (e *Exporter) packets() *packets {
+	return &packets{w: e.w, tags: e.tags}
+}
+
+// packets batches metrics into as few packets as fit them. Errors are
+// ignored, since statsd is lossy by design and metrics must never fail a run.
+type packets struct {
+	w    io.Writer
+	tags []string
+	buf  []byte
+}
+
+func (p *packets) timing(name string, d time.Duration, tags ...string) {
+	p.metric(name, fmt.Sprint(d.Milliseconds()), "ms", tags...)
+}
+
+// metric adds a metric in the DogStatsD format, e.g.
+// "dagger.run.count:1|c|#outcome:failed".
+func (p *packets) metric(name, value, typ string, tags ...string) {
+	line := name + ":" + value + "|" + typ
+	if tags := append(tags, p.tags...); len(tags) > 0 {
+		line += "|#" + strings.Join(tags, ",")
+	}
+	if len(p.buf) > 0 && len(p.buf)+1+len(line) > maxPacketSize {
+		p.flush()
+	}
+	if len(p.buf) > 0 {
+		p.buf = append(p.buf, '\n')
+	}
+	p.buf = append(p.buf, line...)
+}
+
+func (p *packets) flush() {
+	if len(p.buf) == 0 {
+		return
+	}
+	_, _ = p.w.Write(p.buf)
+	p.buf = p.buf[:0]
+}
+
+// Tags sanitizes tags for DogStatsD, which reserves the characters that
+// separate metrics and tags.
+func Tags(tags []string) []string {
+	sanitized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = tagReplacer.Replace(strings.TrimSpace(tag))
+		if tag != "" {
+			sanitized = append(sanitized, tag)
+		}
+	}
+	return sanitized
+}
+
+var tagReplacer = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_")
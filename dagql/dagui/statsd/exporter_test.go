@@ -0,0 +1,117 @@
+package statsd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dagger/dagger/dagql/call/callpbv1"
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+type packetRecorder struct {
+	packets []string
+}
+
+func (r *packetRecorder) Write(p []byte) (int, error) {
+	r.packets = append(r.packets, string(p))
+	return len(p), nil
+}
+
+func (r *packetRecorder) lines() []string {
+	var lines []string
+	for _, p := range r.packets {
+		lines = append(lines, strings.Split(p, "\n")...)
+	}
+	return lines
+}
+
+func TestExporter(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	traceID := dagui.TraceID{TraceID: trace.TraceID{1}}
+	id := func(n byte) dagui.SpanID { return dagui.SpanID{SpanID: trace.SpanID{n}} }
+	span := func(n byte, recv, field string, from, to time.Duration) dagui.SpanSnapshot {
+		call := &callpbv1.Call{
+			Digest:         string(rune('a' + n)),
+			ReceiverDigest: recv,
+			Field:          field,
+			Type:           &callpbv1.Type{NamedType: "Container"},
+		}
+		payload, err := call.Encode()
+		require.NoError(t, err)
+		snapshot := dagui.SpanSnapshot{
+			ID:          id(n),
+			TraceID:     traceID,
+			Name:        field,
+			CallDigest:  call.Digest,
+			CallPayload: payload,
+			StartTime:   start.Add(from),
+		}
+		if to > 0 {
+			snapshot.EndTime = start.Add(to)
+		}
+		return snapshot
+	}
+
+	db := dagui.NewDB()
+	failed := span(3, "b", "withExec", 3*time.Second, 4*time.Second)
+	failed.Status = sdktrace.Status{Code: codes.Error}
+	db.ImportSnapshots([]dagui.SpanSnapshot{
+		span(1, "", "container", 0, time.Second),
+		span(2, "b", "withExec", time.Second, 3*time.Second),
+		failed,
+		span(4, "b", "withExec", 4*time.Second, 0),
+	})
+	rec := &packetRecorder{}
+	exp := NewExporter(db, rec, Tags([]string{"env:ci", " repo:a,b "}))
+
+	exp.Flush()
+	require.Len(t, rec.packets, 1)
+	require.Equal(t, []string{
+		"dagger.step.duration:1000|ms|#operation:Query.container,outcome:succeeded,env:ci,repo:a_b",
+		"dagger.step.duration:2000|ms|#operation:Container.withExec,outcome:succeeded,env:ci,repo:a_b",
+		"dagger.step.duration:1000|ms|#operation:Container.withExec,outcome:failed,env:ci,repo:a_b",
+	}, rec.lines())
+
+	// steps are only sent once, as they complete
+	rec.packets = nil
+	exp.Flush()
+	require.Empty(t, rec.packets)
+	done := span(4, "b", "withExec", 4*time.Second, 8*time.Second)
+	done.Version = 1
+	db.ImportSnapshots([]dagui.SpanSnapshot{done})
+
+	exp.Finish(errors.New("boom"))
+	lines := rec.lines()
+	require.Equal(t, "dagger.step.duration:4000|ms|#operation:Container.withExec,outcome:succeeded,env:ci,repo:a_b", lines[0])
+	require.True(t, strings.HasPrefix(lines[1], "dagger.run.duration:"))
+	require.True(t, strings.HasSuffix(lines[1], "|ms|#outcome:failed,env:ci,repo:a_b"))
+	require.Equal(t, []string{
+		"dagger.run.count:1|c|#outcome:failed,env:ci,repo:a_b",
+		"dagger.run.steps:3|g|#outcome:succeeded,env:ci,repo:a_b",
+		"dagger.run.steps:0|g|#outcome:cached,env:ci,repo:a_b",
+		"dagger.run.steps:1|g|#outcome:failed,env:ci,repo:a_b",
+		"dagger.run.steps:0|g|#outcome:skipped,env:ci,repo:a_b",
+		"dagger.run.cache_hit_ratio:0|g|#env:ci,repo:a_b",
+	}, lines[2:])
+}
+
+func TestPacketSize(t *testing.T) {
+	rec := &packetRecorder{}
+	p := &packets{w: rec}
+	for range 100 {
+		p.metric("dagger.step.duration", "1000", "ms", "operation:"+strings.Repeat("x", 50))
+	}
+	p.flush()
+	require.Greater(t, len(rec.packets), 1)
+	for _, packet := range rec.packets {
+		require.LessOrEqual(t, len(packet), maxPacketSize)
+	}
+	require.Len(t, rec.lines(), 100)
+}
@@ -0,0 +1,435 @@
+package dagui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opencontainers/go-digest"
+)
+
+// DefaultTraceStoreMaxTraces is the number of traces kept by a TraceStore
+// before the oldest ones are removed, unless its retention says otherwise.
+const DefaultTraceStoreMaxTraces = 50
+
+const (
+	traceMetaFilename = "meta.json"
+	traceLogsFilename = "logs.json.zst"
+)
+
+// traceSpanBlockSize is the number of spans stored in each block. Blocks are
+// compressed separately, so that a range of spans can be read without
+// decompressing the whole trace.
+const traceSpanBlockSize = 4096
+
+func traceSpanBlockFilename(block int) string {
+	return fmt.Sprintf("spans-%05d.json.zst", block)
+}
+
+// spanLogTail is the stored form of a span's log tail.
+type spanLogTail struct {
+	Span SpanID
+	Tail string
+}
+
+// TraceStore persists completed traces to disk so that they can be inspected,
+// compared, and mined for history after the run that produced them is gone.
+//
+// Each trace is stored in its own directory, named after its trace ID.
+type TraceStore struct {
+	Root string
+
+	// Retention limits the traces kept. Traces outside of it are removed by
+	// Compact.
+	Retention TraceRetention
+}
+
+func NewTraceStore(root string) *TraceStore {
+	return &TraceStore{
+		Root: root,
+		Retention: TraceRetention{
+			MaxTraces: DefaultTraceStoreMaxTraces,
+		},
+	}
+}
+
+// TraceMeta summarizes a stored trace.
+type TraceMeta struct {
+	TraceID     TraceID
+	PrimarySpan SpanID
+	Name        string
+	StartTime   time.Time
+	EndTime     time.Time
+	Failed      bool
+	Spans       int
+
+	// Labels describe the session, e.g. its branch or PR.
+	Labels map[string]string `json:",omitempty"`
+
+	// PromptedArgs are the arguments the user was prompted for, rather than
+	// passing them on the command line, so the command line alone doesn't
+	// reproduce the run.
+	PromptedArgs []string `json:",omitempty"`
+
+	// SpanBlockSize is the number of spans in each of the trace's blocks.
+	SpanBlockSize int `json:",omitempty"`
+
+	// Digest is a hash over the trace's stored spans and logs, for verifying
+	// that they haven't been modified since they were saved. It is the hash
+	// of FileDigests.
+	Digest digest.Digest `json:",omitempty"`
+
+	// FileDigests are the digests of each of the trace's span blocks in
+	// order, followed by its logs.
+	FileDigests []digest.Digest `json:",omitempty"`
+}
+
+func (meta TraceMeta) Duration() time.Duration {
+	return meta.EndTime.Sub(meta.StartTime)
+}
+
+// Save writes the DB's spans to the store, keyed by the primary span's trace.
+func (store *TraceStore) Save(db *DB) (TraceMeta, error) {
+	primary := db.Spans.Map[db.PrimarySpan]
+	if primary == nil {
+		return TraceMeta{}, errors.New("no primary span to save")
+	}
+	meta := TraceMeta{
+		TraceID:     primary.TraceID,
+		PrimarySpan: primary.ID,
+		Name:        primary.Name,
+		StartTime:   primary.StartTime,
+		EndTime:     primary.EndTime,
+		Failed:      primary.IsFailedOrCausedFailure(),
+		Spans:       len(db.Spans.Order),
+		Labels:      db.Labels,
+
+		PromptedArgs: primary.PromptedArgs,
+
+		SpanBlockSize: traceSpanBlockSize,
+	}
+	if !meta.TraceID.IsValid() {
+		return TraceMeta{}, errors.New("primary span has no trace ID")
+	}
+	snapshots := make([]SpanSnapshot, 0, len(db.Spans.Order))
+	for _, span := range db.Spans.Order {
+		snapshots = append(snapshots, span.Snapshot())
+	}
+	dir := store.dir(meta.TraceID.String())
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return TraceMeta{}, err
+	}
+	for block := 0; block*meta.SpanBlockSize < len(snapshots); block++ {
+		start := block * meta.SpanBlockSize
+		end := min(start+meta.SpanBlockSize, len(snapshots))
+		dgst, err := writeZstdJSONFile(filepath.Join(dir, traceSpanBlockFilename(block)), snapshots[start:end])
+		if err != nil {
+			return TraceMeta{}, err
+		}
+		meta.FileDigests = append(meta.FileDigests, dgst)
+	}
+	logs := make([]spanLogTail, 0, len(db.LogTails))
+	for id, tail := range db.LogTails {
+		logs = append(logs, spanLogTail{Span: id, Tail: string(tail)})
+	}
+	dgst, err := writeZstdJSONFile(filepath.Join(dir, traceLogsFilename), logs)
+	if err != nil {
+		return TraceMeta{}, err
+	}
+	meta.FileDigests = append(meta.FileDigests, dgst)
+	meta.Digest = rootDigest(meta.FileDigests)
+	// write meta last; a trace without meta is treated as incomplete
+	if err := writeJSONFile(filepath.Join(dir, traceMetaFilename), meta); err != nil {
+		return TraceMeta{}, err
+	}
+	if err := store.recordDurations(db); err != nil {
+		return TraceMeta{}, err
+	}
+	return meta, nil
+}
+
+// List returns the metadata of all stored traces, most recent first.
+func (store *TraceStore) List() ([]TraceMeta, error) {
+	entries, err := os.ReadDir(store.Root)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var metas []TraceMeta
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		var meta TraceMeta
+		if err := readJSONFile(filepath.Join(store.dir(entry.Name()), traceMetaFilename), &meta); err != nil {
+			// incomplete or corrupt; skip
+			continue
+		}
+		metas = append(metas, meta)
+	}
+	sort.Slice(metas, func(i, j int) bool {
+		return metas[i].StartTime.After(metas[j].StartTime)
+	})
+	return metas, nil
+}
+
+// Meta returns the metadata for a stored trace. The special ID "latest"
+// refers to the most recently started trace.
+func (store *TraceStore) Meta(id string) (TraceMeta, error) {
+	if id == "latest" {
+		metas, err := store.List()
+		if err != nil {
+			return TraceMeta{}, err
+		}
+		if len(metas) == 0 {
+			return TraceMeta{}, errors.New("no traces stored")
+		}
+		return metas[0], nil
+	}
+	var meta TraceMeta
+	if err := readJSONFile(filepath.Join(store.dir(id), traceMetaFilename), &meta); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return TraceMeta{}, fmt.Errorf("trace %s not found", id)
+		}
+		return TraceMeta{}, err
+	}
+	return meta, nil
+}
+
+// TraceLoadOpts limits how much of a stored trace LoadWith reads.
+type TraceLoadOpts struct {
+	// SkipLogs leaves the spans' log tails out of the DB, sparing reading
+	// them for callers that only look at spans.
+	SkipLogs bool
+}
+
+// Load reads a stored trace back into a new DB.
+func (store *TraceStore) Load(id string) (*DB, TraceMeta, error) {
+	return store.LoadWith(id, TraceLoadOpts{})
+}
+
+// LoadWith reads the parts of a stored trace selected by opts into a new DB.
+func (store *TraceStore) LoadWith(id string, opts TraceLoadOpts) (*DB, TraceMeta, error) {
+	meta, err := store.Meta(id)
+	if err != nil {
+		return nil, meta, err
+	}
+	snapshots, err := store.Spans(meta, 0, meta.Spans)
+	if err != nil {
+		return nil, meta, err
+	}
+	var logs []spanLogTail
+	if !opts.SkipLogs {
+		logs, err = store.logs(meta)
+		if err != nil {
+			return nil, meta, err
+		}
+	}
+	db := NewDB()
+	db.SetPrimarySpan(meta.PrimarySpan)
+	db.Labels = meta.Labels
+	db.ImportSnapshots(snapshots)
+	for _, log := range logs {
+		db.LogTails[log.Span] = []byte(log.Tail)
+	}
+	return db, meta, nil
+}
+
+// LogTail reads the tail of a stored span's logs without loading the rest of
+// the trace. It reports false if the trace has no such span.
+func (store *TraceStore) LogTail(meta TraceMeta, spanID SpanID) ([]byte, bool, error) {
+	logs, err := store.logs(meta)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, log := range logs {
+		if log.Span == spanID {
+			return []byte(log.Tail), true, nil
+		}
+	}
+	// no logs; check that the span exists, a block at a time
+	for offset := 0; offset < meta.Spans; offset += meta.SpanBlockSize {
+		snapshots, err := store.Spans(meta, offset, meta.SpanBlockSize)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, snapshot := range snapshots {
+			if snapshot.ID == spanID {
+				return nil, true, nil
+			}
+		}
+	}
+	return nil, false, nil
+}
+
+// Spans reads a range of a stored trace's spans, decompressing only the
+// blocks that hold them. Ranges past the end of the trace are truncated.
+func (store *TraceStore) Spans(meta TraceMeta, offset, limit int) ([]SpanSnapshot, error) {
+	if err := checkSpanBlocks(meta); err != nil {
+		return nil, err
+	}
+	dir := store.dir(meta.TraceID.String())
+	offset = min(offset, meta.Spans)
+	end := offset + min(limit, meta.Spans-offset)
+	snapshots := make([]SpanSnapshot, 0, max(end-offset, 0))
+	for block := offset / meta.SpanBlockSize; block*meta.SpanBlockSize < end; block++ {
+		var blockSpans []SpanSnapshot
+		if err := readZstdJSONFile(filepath.Join(dir, traceSpanBlockFilename(block)), &blockSpans); err != nil {
+			return nil, err
+		}
+		blockStart := block * meta.SpanBlockSize
+		from := max(offset-blockStart, 0)
+		to := min(end-blockStart, len(blockSpans))
+		if from < to {
+			snapshots = append(snapshots, blockSpans[from:to]...)
+		}
+	}
+	return snapshots, nil
+}
+
+func (store *TraceStore) logs(meta TraceMeta) ([]spanLogTail, error) {
+	dir := store.dir(meta.TraceID.String())
+	var logs []spanLogTail
+	err := readZstdJSONFile(filepath.Join(dir, traceLogsFilename), &logs)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// Verify checks that a stored trace's spans and logs match its digest.
+func (store *TraceStore) Verify(meta TraceMeta) error {
+	if meta.Digest == "" {
+		return fmt.Errorf("trace %s has no digest to verify", meta.TraceID)
+	}
+	if rootDigest(meta.FileDigests) != meta.Digest {
+		return fmt.Errorf("trace %s: file digests do not match %s", meta.TraceID, meta.Digest)
+	}
+	if err := checkSpanBlocks(meta); err != nil {
+		return err
+	}
+	var files []string
+	for block := 0; block*meta.SpanBlockSize < meta.Spans; block++ {
+		files = append(files, traceSpanBlockFilename(block))
+	}
+	files = append(files, traceLogsFilename)
+	if len(files) != len(meta.FileDigests) {
+		return fmt.Errorf("trace %s: expected %d files, have %d digests", meta.TraceID, len(files), len(meta.FileDigests))
+	}
+	dir := store.dir(meta.TraceID.String())
+	for i, name := range files {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("trace %s: %w", meta.TraceID, err)
+		}
+		dgst, err := digest.FromReader(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("trace %s: %s: %w", meta.TraceID, name, err)
+		}
+		if dgst != meta.FileDigests[i] {
+			return fmt.Errorf("trace %s: %s has been modified: expected %s, got %s", meta.TraceID, name, meta.FileDigests[i], dgst)
+		}
+	}
+	return nil
+}
+
+// checkSpanBlocks returns an error for traces that weren't stored in span
+// blocks, which were saved by older versions and are no longer readable.
+func checkSpanBlocks(meta TraceMeta) error {
+	if meta.SpanBlockSize <= 0 {
+		return fmt.Errorf("trace %s was stored in an unsupported format; record it again", meta.TraceID)
+	}
+	return nil
+}
+
+// rootDigest combines the digests of a trace's files into one.
+func rootDigest(files []digest.Digest) digest.Digest {
+	strs := make([]string, len(files))
+	for i, dgst := range files {
+		strs[i] = dgst.String()
+	}
+	return digest.FromString(strings.Join(strs, "\n"))
+}
+
+// Modified returns when a stored trace was last saved.
+func (store *TraceStore) Modified(meta TraceMeta) (time.Time, error) {
+	info, err := os.Stat(filepath.Join(store.dir(meta.TraceID.String()), traceMetaFilename))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+func (store *TraceStore) dir(id string) string {
+	return filepath.Join(store.Root, id)
+}
+
+func writeJSONFile(path string, v any) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(v); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func readJSONFile(path string, v any) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewDecoder(f).Decode(v)
+}
+
+// writeZstdJSONFile writes a compressed JSON file, returning the digest of
+// its contents as written.
+func writeZstdJSONFile(path string, v any) (digest.Digest, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	digester := digest.Canonical.Digester()
+	zw, err := zstd.NewWriter(io.MultiWriter(f, digester.Hash()))
+	if err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := json.NewEncoder(zw).Encode(v); err != nil {
+		zw.Close()
+		f.Close()
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return "", err
+	}
+	return digester.Digest(), f.Close()
+}
+
+func readZstdJSONFile(path string, v any) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zr, err := zstd.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	return json.NewDecoder(zr).Decode(v)
+}
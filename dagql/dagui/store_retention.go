@@ -0,0 +1,178 @@
+package dagui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceIncompleteMaxAge is how long a trace directory without metadata is
+// kept, so that traces still being saved aren't mistaken for ones left
+// behind by an interrupted save.
+const traceIncompleteMaxAge = time.Hour
+
+// TraceRetention limits the traces kept by a TraceStore. Zero limits are
+// unlimited.
+type TraceRetention struct {
+	// MaxTraces is the number of traces to keep, most recent first.
+	MaxTraces int
+
+	// MaxAge is how long to keep a trace after it started.
+	MaxAge time.Duration
+
+	// MaxBytes is the total disk space to use for traces, keeping the most
+	// recent ones that fit.
+	MaxBytes int64
+}
+
+// ParseTraceRetention parses the retention of a TraceStore from a
+// comma-separated list of limits, e.g. "traces=100,age=720h,size=1GB".
+// Limits that aren't given keep their default.
+func ParseTraceRetention(spec string) (TraceRetention, error) {
+	retention := TraceRetention{
+		MaxTraces: DefaultTraceStoreMaxTraces,
+	}
+	if spec == "" {
+		return retention, nil
+	}
+	for _, limit := range strings.Split(spec, ",") {
+		key, value, _ := strings.Cut(limit, "=")
+		var err error
+		switch key {
+		case "traces":
+			retention.MaxTraces, err = strconv.Atoi(value)
+			if err == nil && retention.MaxTraces < 0 {
+				err = fmt.Errorf("must not be negative")
+			}
+		case "age":
+			retention.MaxAge, err = time.ParseDuration(value)
+			if err == nil && retention.MaxAge < 0 {
+				err = fmt.Errorf("must not be negative")
+			}
+		case "size":
+			var size uint64
+			size, err = humanize.ParseBytes(value)
+			retention.MaxBytes = int64(size)
+		default:
+			return TraceRetention{}, fmt.Errorf("invalid trace retention limit %q: must be traces=N, age=DURATION, or size=BYTES", limit)
+		}
+		if err != nil {
+			return TraceRetention{}, fmt.Errorf("invalid trace retention limit %q: %w", limit, err)
+		}
+	}
+	return retention, nil
+}
+
+// TraceStoreUsage summarizes the disk used by a TraceStore's traces.
+type TraceStoreUsage struct {
+	Traces int
+	Bytes  int64
+}
+
+// Usage returns the number of stored traces and the disk space they use.
+func (store *TraceStore) Usage() (TraceStoreUsage, error) {
+	metas, err := store.List()
+	if err != nil {
+		return TraceStoreUsage{}, err
+	}
+	usage := TraceStoreUsage{Traces: len(metas)}
+	for _, meta := range metas {
+		size, err := dirSize(store.dir(meta.TraceID.String()))
+		if err != nil {
+			return TraceStoreUsage{}, err
+		}
+		usage.Bytes += size
+	}
+	return usage, nil
+}
+
+// Compact removes the traces that fall outside of the store's retention,
+// along with traces left incomplete by an interrupted save. It's meant to
+// run in the background, e.g. while a run is in progress.
+func (store *TraceStore) Compact(now time.Time) error {
+	metas, err := store.List()
+	if err != nil {
+		return err
+	}
+	var errs error
+	var kept int
+	var keptBytes int64
+	for _, meta := range metas {
+		dir := store.dir(meta.TraceID.String())
+		size, err := dirSize(dir)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		retention := store.Retention
+		if (retention.MaxTraces <= 0 || kept < retention.MaxTraces) &&
+			(retention.MaxAge <= 0 || now.Sub(meta.StartTime) <= retention.MaxAge) &&
+			(retention.MaxBytes <= 0 || keptBytes+size <= retention.MaxBytes) {
+			kept++
+			keptBytes += size
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+	return errors.Join(errs, store.removeIncomplete(now))
+}
+
+// removeIncomplete removes trace directories that never got their metadata
+// written.
+func (store *TraceStore) removeIncomplete(now time.Time) error {
+	entries, err := os.ReadDir(store.Root)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	var errs error
+	for _, entry := range entries {
+		if _, err := trace.TraceIDFromHex(entry.Name()); err != nil || !entry.IsDir() {
+			// not a trace, e.g. journals or baselines
+			continue
+		}
+		dir := store.dir(entry.Name())
+		if _, err := os.Stat(filepath.Join(dir, traceMetaFilename)); !errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) < traceIncompleteMaxAge {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+	return errs
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
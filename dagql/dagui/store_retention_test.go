@@ -0,0 +1,98 @@
+package dagui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestParseTraceRetention(t *testing.T) {
+	retention, err := ParseTraceRetention("age=24h,size=1MB")
+	require.NoError(t, err)
+	require.Equal(t, TraceRetention{
+		MaxTraces: DefaultTraceStoreMaxTraces,
+		MaxAge:    24 * time.Hour,
+		MaxBytes:  1000 * 1000,
+	}, retention)
+
+	retention, err = ParseTraceRetention("traces=0")
+	require.NoError(t, err)
+	require.Zero(t, retention.MaxTraces)
+
+	for _, spec := range []string{"traces=lots", "traces=-1", "age=-1h", "size=huge", "spans=10"} {
+		_, err := ParseTraceRetention(spec)
+		require.Error(t, err, spec)
+	}
+}
+
+func TestTraceStoreCompact(t *testing.T) {
+	now := time.Now()
+	store := NewTraceStore(t.TempDir())
+	save := func(n byte, age time.Duration) TraceMeta {
+		root := SpanID{SpanID: trace.SpanID{n}}
+		db := NewDB()
+		db.SetPrimarySpan(root)
+		db.ImportSnapshots([]SpanSnapshot{{
+			ID:        root,
+			TraceID:   TraceID{TraceID: trace.TraceID{n}},
+			Name:      "run",
+			StartTime: now.Add(-age),
+			EndTime:   now.Add(-age + time.Second),
+		}})
+		meta, err := store.Save(db)
+		require.NoError(t, err)
+		return meta
+	}
+	ids := func() []TraceID {
+		metas, err := store.List()
+		require.NoError(t, err)
+		var ids []TraceID
+		for _, meta := range metas {
+			ids = append(ids, meta.TraceID)
+		}
+		return ids
+	}
+	recent := save(1, time.Minute)
+	older := save(2, time.Hour)
+	oldest := save(3, 48*time.Hour)
+
+	usage, err := store.Usage()
+	require.NoError(t, err)
+	require.Equal(t, 3, usage.Traces)
+	require.Positive(t, usage.Bytes)
+
+	// saving never removes traces; compacting does
+	store.Retention = TraceRetention{MaxAge: 24 * time.Hour}
+	require.Equal(t, []TraceID{recent.TraceID, older.TraceID, oldest.TraceID}, ids())
+	require.NoError(t, store.Compact(now))
+	require.Equal(t, []TraceID{recent.TraceID, older.TraceID}, ids())
+
+	// the most recent traces that fit are kept
+	traceSize, err := dirSize(store.dir(recent.TraceID.String()))
+	require.NoError(t, err)
+	store.Retention = TraceRetention{MaxBytes: traceSize + 1}
+	require.NoError(t, store.Compact(now))
+	require.Equal(t, []TraceID{recent.TraceID}, ids())
+
+	store.Retention = TraceRetention{MaxTraces: 1}
+	save(4, 0)
+	require.NoError(t, store.Compact(now))
+	require.Len(t, ids(), 1)
+
+	// traces left without metadata are removed once they can't still be
+	// being saved
+	incomplete := store.dir(TraceID{TraceID: trace.TraceID{5}}.String())
+	require.NoError(t, os.MkdirAll(incomplete, 0o755))
+	journals := filepath.Join(store.Root, journalsDir)
+	require.NoError(t, os.MkdirAll(journals, 0o755))
+	require.NoError(t, store.Compact(now))
+	require.DirExists(t, incomplete)
+	require.NoError(t, store.Compact(now.Add(2*traceIncompleteMaxAge)))
+	require.NoDirExists(t, incomplete)
+	// other directories are left alone
+	require.DirExists(t, journals)
+}
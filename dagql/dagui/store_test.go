@@ -0,0 +1,109 @@
+package dagui
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceStoreSpanBlocks(t *testing.T) {
+	traceID := TraceID{TraceID: trace.TraceID{1}}
+	spanID := func(i int) SpanID {
+		var id trace.SpanID
+		binary.BigEndian.PutUint64(id[:], uint64(i+1))
+		return SpanID{SpanID: id}
+	}
+	start := time.Now().Add(-time.Hour)
+	total := traceSpanBlockSize*2 + 10
+	snapshots := make([]SpanSnapshot, total)
+	for i := range snapshots {
+		snapshots[i] = SpanSnapshot{
+			ID:        spanID(i),
+			TraceID:   traceID,
+			Name:      "span",
+			StartTime: start.Add(time.Duration(i) * time.Millisecond),
+			EndTime:   start.Add(time.Hour),
+		}
+		if i > 0 {
+			snapshots[i].ParentID = spanID(0)
+		}
+	}
+	db := NewDB()
+	db.SetPrimarySpan(spanID(0))
+	db.ImportSnapshots(snapshots)
+	db.LogTails[spanID(0)] = []byte("hello\n")
+
+	store := NewTraceStore(t.TempDir())
+	meta, err := store.Save(db)
+	require.NoError(t, err)
+	require.Equal(t, total, meta.Spans)
+
+	// a range straddling two blocks
+	spans, err := store.Spans(meta, traceSpanBlockSize-5, 10)
+	require.NoError(t, err)
+	require.Len(t, spans, 10)
+	for i, span := range spans {
+		require.Equal(t, db.Spans.Order[traceSpanBlockSize-5+i].ID, span.ID)
+	}
+
+	// a range past the end
+	spans, err = store.Spans(meta, total-3, 10)
+	require.NoError(t, err)
+	require.Len(t, spans, 3)
+
+	// ranges that would overflow
+	spans, err = store.Spans(meta, math.MaxInt, math.MaxInt)
+	require.NoError(t, err)
+	require.Empty(t, spans)
+	spans, err = store.Spans(meta, total-3, math.MaxInt)
+	require.NoError(t, err)
+	require.Len(t, spans, 3)
+
+	loaded, _, err := store.Load(meta.TraceID.String())
+	require.NoError(t, err)
+	require.Len(t, loaded.Spans.Order, total)
+	require.Equal(t, "hello\n", string(loaded.LogTails[spanID(0)]))
+
+	// logs can be left out, or read on their own
+	loaded, _, err = store.LoadWith(meta.TraceID.String(), TraceLoadOpts{SkipLogs: true})
+	require.NoError(t, err)
+	require.Len(t, loaded.Spans.Order, total)
+	require.Empty(t, loaded.LogTails)
+	tail, ok, err := store.LogTail(meta, spanID(0))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "hello\n", string(tail))
+	// a span in the last block without logs
+	tail, ok, err = store.LogTail(meta, spanID(total-1))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Empty(t, tail)
+	_, ok, err = store.LogTail(meta, spanID(total))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, store.Verify(meta))
+	block := filepath.Join(store.dir(meta.TraceID.String()), traceSpanBlockFilename(1))
+	require.NoError(t, os.WriteFile(block, []byte("tampered"), 0o644))
+	require.ErrorContains(t, store.Verify(meta), traceSpanBlockFilename(1))
+}
+
+func TestTraceStoreUnsupportedFormat(t *testing.T) {
+	store := NewTraceStore(t.TempDir())
+	meta := TraceMeta{
+		TraceID: TraceID{TraceID: trace.TraceID{1}},
+		Spans:   1,
+	}
+	dir := store.dir(meta.TraceID.String())
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, writeJSONFile(filepath.Join(dir, traceMetaFilename), meta))
+
+	_, _, err := store.Load(meta.TraceID.String())
+	require.ErrorContains(t, err, "unsupported format")
+}
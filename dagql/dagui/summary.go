@@ -0,0 +1,366 @@
+package dagui
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LogTailSize is the number of bytes of logs retained for each span, for
+// showing alongside failures after the run completes.
+const LogTailSize = 8 * 1024
+
+// RunSummary summarizes a completed run.
+type RunSummary struct {
+	Name     string
+	TraceID  TraceID
+	Failed   bool
+	Duration time.Duration
+
+	// Slowest are the slowest calls made during the run, slowest first.
+	Slowest []StepTiming
+
+	Calls         int
+	CacheHitRatio float64
+
+	// Failures are the root causes of the run's failure.
+	Failures []StepFailure
+
+	// Warnings are failures of quarantined steps, reported as warnings.
+	Warnings []StepFailure
+
+	// UnexpectedPasses are the names of steps expected to fail that passed.
+	UnexpectedPasses []string
+
+	// Skipped are the steps that were skipped, which don't count as passing
+	// or failing.
+	Skipped []StepSkip
+
+	// CorrectedSpans is the number of spans whose times were corrected as
+	// they were received, so their durations aren't to be trusted.
+	CorrectedSpans int
+}
+
+// StepSkip is a skipped step along with the reason it was skipped.
+type StepSkip struct {
+	Name   string
+	Reason string
+}
+
+// StepTiming is the duration of a single step.
+type StepTiming struct {
+	Name     string
+	Duration time.Duration
+	Cached   bool
+}
+
+// StepFailure is a failed step along with the tail of its logs.
+type StepFailure struct {
+	Name       string
+	CallDigest string
+	Error      string
+	LogTail    string
+
+	// Flaky is set for steps known to fail intermittently. See MarkFlaky.
+	Flaky bool
+}
+
+// Summary summarizes the run, listing up to the given number of slowest
+// steps and trimming failure logs to the given number of lines. Failures of
+// quarantined steps are listed as warnings, depending on the quarantine mode.
+// Failures of steps allowed or expected to fail are left out, while steps
+// expected to fail that passed are listed as unexpected passes.
+func (db *DB) Summary(slowest, logLines int, quarantine QuarantineMode) RunSummary {
+	var summary RunSummary
+	primary := db.Spans.Map[db.PrimarySpan]
+	if primary == nil {
+		return summary
+	}
+	summary.Name = primary.Name
+	summary.TraceID = primary.TraceID
+	summary.Failed = primary.IsFailedOrCausedFailure()
+	summary.Duration = primary.WallTime()
+	summary.CacheHitRatio, summary.Calls = db.CacheHitRatio()
+	summary.CorrectedSpans = db.CorrectedSpans()
+
+	for _, span := range db.Spans.Order {
+		if span.ID == primary.ID || span.IsInternal() || span.Passthrough {
+			continue
+		}
+		if span.Call != nil {
+			summary.Slowest = append(summary.Slowest, StepTiming{
+				Name:     span.Name,
+				Duration: span.WallTime(),
+				Cached:   span.IsCached(),
+			})
+		}
+		if span.IsSkipped() {
+			_, reason := span.SkipReason()
+			summary.Skipped = append(summary.Skipped, StepSkip{Name: span.Name, Reason: reason})
+		}
+		if span.IsUnexpectedPass() {
+			summary.UnexpectedPasses = append(summary.UnexpectedPasses, span.Name)
+		}
+		if span.IsFailed() && !hasFailedChild(span) && !span.IsFailureTolerated() {
+			failure := StepFailure{
+				Name:       span.Name,
+				CallDigest: span.CallDigest,
+				Error:      span.Status.Description,
+				LogTail:    db.LogTailLines(span, logLines),
+			}
+			if span.IsQuarantinedFailure(quarantine) {
+				summary.Warnings = append(summary.Warnings, failure)
+			} else {
+				summary.Failures = append(summary.Failures, failure)
+			}
+		}
+	}
+	sort.SliceStable(summary.Slowest, func(i, j int) bool {
+		return summary.Slowest[i].Duration > summary.Slowest[j].Duration
+	})
+	if len(summary.Slowest) > slowest {
+		summary.Slowest = summary.Slowest[:slowest]
+	}
+	return summary
+}
+
+// MarkFlaky flags the failures of steps known to be flaky.
+func (summary *RunSummary) MarkFlaky(flaky []FlakyStep) {
+	digests := make(map[string]bool, len(flaky))
+	for _, step := range flaky {
+		digests[step.CallDigest] = true
+	}
+	for _, failures := range [][]StepFailure{summary.Failures, summary.Warnings} {
+		for i, failure := range failures {
+			if failure.CallDigest != "" && digests[failure.CallDigest] {
+				failures[i].Flaky = true
+			}
+		}
+	}
+}
+
+// SpanCounts counts the run's calls by status.
+type SpanCounts struct {
+	Running int
+	Pending int
+	Cached  int
+	Failed  int
+	Skipped int
+	Done    int
+}
+
+// SpanCounts counts the non-internal calls made during the run by status,
+// including any pruned by the retention policy.
+func (db *DB) SpanCounts() SpanCounts {
+	return db.countCalls().spans
+}
+
+// callCounts counts the run's calls, for SpanCounts and CacheHitRatio.
+type callCounts struct {
+	spans SpanCounts
+
+	// completed is the number of calls that aren't running, and cached the
+	// number of those that were cached.
+	completed int
+	cached    int
+}
+
+// countCalls counts the run's calls, caching the counts until the DB changes
+// so that e.g. the TUI can show them every frame without going through
+// every span.
+func (db *DB) countCalls() callCounts {
+	if db.callCounts != nil {
+		return *db.callCounts
+	}
+	counts := callCounts{spans: db.pruned}
+	for _, span := range db.Spans.Order {
+		counts.spans.add(span)
+		if span.CallDigest == "" || span.IsInternal() || span.IsRunning() {
+			continue
+		}
+		counts.completed++
+		if span.IsCached() {
+			counts.cached++
+		}
+	}
+	db.callCounts = &counts
+	return counts
+}
+
+func (counts *SpanCounts) add(span *Span) {
+	if span.CallDigest == "" || span.IsInternal() {
+		return
+	}
+	switch {
+	case span.IsRunningOrEffectsRunning():
+		counts.Running++
+	case span.IsPending():
+		counts.Pending++
+	case span.IsFailed():
+		counts.Failed++
+	case span.IsSkipped():
+		counts.Skipped++
+	case span.IsCached():
+		counts.Cached++
+		counts.Done++
+	default:
+		counts.Done++
+	}
+}
+
+func hasFailedChild(span *Span) bool {
+	for _, child := range span.ChildSpans.Order {
+		if child.IsFailed() {
+			return true
+		}
+	}
+	return false
+}
+
+// LogTailLines returns the last n lines of the span's logs, or of its first
+// descendant that has any logs.
+func (db *DB) LogTailLines(span *Span, n int) string {
+	return lastLines(db.logTail(span), n)
+}
+
+func (db *DB) logTail(span *Span) string {
+	if tail := db.LogTails[span.ID]; len(tail) > 0 {
+		return string(tail)
+	}
+	for _, child := range span.ChildSpans.Order {
+		if tail := db.logTail(child); tail != "" {
+			return tail
+		}
+	}
+	return ""
+}
+
+func lastLines(str string, n int) string {
+	lines := strings.Split(strings.TrimRight(str, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WriteText renders the summary as plain text.
+func (summary RunSummary) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s in %s\n", summary.Name, summary.status(), FormatDuration(summary.Duration))
+	fmt.Fprintf(&sb, "Trace: %s\n", summary.TraceID)
+	fmt.Fprintf(&sb, "Cache: %.1f%% of %d calls cached\n", summary.CacheHitRatio*100, summary.Calls)
+	if len(summary.Slowest) > 0 {
+		sb.WriteString("\nSlowest steps:\n")
+		for _, step := range summary.Slowest {
+			fmt.Fprintf(&sb, "  %8s  %s%s\n", FormatDuration(step.Duration), step.Name, cachedSuffix(step.Cached))
+		}
+	}
+	for _, failure := range summary.Failures {
+		fmt.Fprintf(&sb, "\nFailed: %s%s\n", failure.Name, flakySuffix(failure.Flaky))
+		writeFailureText(&sb, failure)
+	}
+	for _, warning := range summary.Warnings {
+		fmt.Fprintf(&sb, "\nWarning: %s failed, but is quarantined%s\n", warning.Name, flakySuffix(warning.Flaky))
+		writeFailureText(&sb, warning)
+	}
+	for _, name := range summary.UnexpectedPasses {
+		fmt.Fprintf(&sb, "\nWarning: %s passed, but was expected to fail\n", name)
+	}
+	if len(summary.Skipped) > 0 {
+		sb.WriteString("\nSkipped steps:\n")
+		for _, step := range summary.Skipped {
+			fmt.Fprintf(&sb, "  %s: %s\n", step.Name, step.Reason)
+		}
+	}
+	if summary.CorrectedSpans > 0 {
+		fmt.Fprintf(&sb, "\nNote: %d spans had invalid times and were corrected\n", summary.CorrectedSpans)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteMarkdown renders the summary as GitHub-flavored markdown, suitable for
+// posting as a pull request comment.
+func (summary RunSummary) WriteMarkdown(w io.Writer) error {
+	var sb strings.Builder
+	icon := "✅"
+	if summary.Failed {
+		icon = "❌"
+	}
+	fmt.Fprintf(&sb, "### %s `%s` %s in %s\n\n", icon, summary.Name, summary.status(), FormatDuration(summary.Duration))
+	fmt.Fprintf(&sb, "Trace `%s` · %.1f%% of %d calls cached\n", summary.TraceID, summary.CacheHitRatio*100, summary.Calls)
+	for _, failure := range summary.Failures {
+		fmt.Fprintf(&sb, "\n#### ❌ `%s`%s\n\n", failure.Name, flakySuffix(failure.Flaky))
+		writeFailureMarkdown(&sb, failure)
+	}
+	for _, warning := range summary.Warnings {
+		fmt.Fprintf(&sb, "\n#### ⚠️ `%s` (quarantined)%s\n\n", warning.Name, flakySuffix(warning.Flaky))
+		writeFailureMarkdown(&sb, warning)
+	}
+	for _, name := range summary.UnexpectedPasses {
+		fmt.Fprintf(&sb, "\n#### ⚠️ `%s` passed, but was expected to fail\n", name)
+	}
+	if len(summary.Skipped) > 0 {
+		fmt.Fprintf(&sb, "\n<details><summary>%d skipped</summary>\n\n", len(summary.Skipped))
+		for _, step := range summary.Skipped {
+			fmt.Fprintf(&sb, "- `%s`: %s\n", step.Name, step.Reason)
+		}
+		sb.WriteString("\n</details>\n")
+	}
+	if len(summary.Slowest) > 0 {
+		sb.WriteString("\n<details><summary>Slowest steps</summary>\n\n")
+		sb.WriteString("| Step | Duration | |\n")
+		sb.WriteString("|---|---:|---|\n")
+		for _, step := range summary.Slowest {
+			fmt.Fprintf(&sb, "| `%s` | %s | %s |\n",
+				strings.ReplaceAll(step.Name, "|", `\|`), FormatDuration(step.Duration), strings.TrimSpace(cachedSuffix(step.Cached)))
+		}
+		sb.WriteString("\n</details>\n")
+	}
+	if summary.CorrectedSpans > 0 {
+		fmt.Fprintf(&sb, "\n> [!NOTE]\n> %d spans had invalid times and were corrected.\n", summary.CorrectedSpans)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (summary RunSummary) status() string {
+	if summary.Failed {
+		return "failed"
+	}
+	return "succeeded"
+}
+
+func writeFailureText(sb *strings.Builder, failure StepFailure) {
+	if failure.Error != "" {
+		fmt.Fprintf(sb, "  %s\n", failure.Error)
+	}
+	if failure.LogTail != "" {
+		sb.WriteString(failure.LogTail + "\n")
+	}
+}
+
+func writeFailureMarkdown(sb *strings.Builder, failure StepFailure) {
+	if failure.Error != "" {
+		fmt.Fprintf(sb, "%s\n\n", failure.Error)
+	}
+	if failure.LogTail != "" {
+		fmt.Fprintf(sb, "<details><summary>Logs</summary>\n\n```\n%s\n```\n\n</details>\n", failure.LogTail)
+	}
+}
+
+func flakySuffix(flaky bool) string {
+	if flaky {
+		return " (flaky)"
+	}
+	return ""
+}
+
+func cachedSuffix(cached bool) string {
+	if cached {
+		return " CACHED"
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package dagui
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/dagger/dagger/dagql/call"
+)
+
+func TestSummary(t *testing.T) {
+	span := testTrace{start: time.Now().Add(-time.Hour)}.span
+	step := func(n, parent byte, field string, from, to time.Duration) SpanSnapshot {
+		id := call.New().Append(&ast.Type{NamedType: "Container", NonNull: true}, field, "", nil, false, 0, "")
+		payload, err := id.Call().Encode()
+		require.NoError(t, err)
+		snapshot := span(n, parent, field, from, to)
+		snapshot.CallDigest = string(id.Digest())
+		snapshot.CallPayload = payload
+		return snapshot
+	}
+	failed := func(snapshot SpanSnapshot, err string) SpanSnapshot {
+		snapshot.Status = sdktrace.Status{Code: codes.Error, Description: err}
+		return snapshot
+	}
+	cached := step(4, 1, "lint", 0, time.Millisecond)
+	cached.Cached = true
+
+	db := NewDB()
+	db.SetPrimarySpan(testSpanID(1))
+	db.ImportSnapshots([]SpanSnapshot{
+		failed(span(1, 0, "dagger call ci", 0, 10*time.Second), "exit code: 1"),
+		step(2, 1, "build", 0, 3*time.Second),
+		failed(step(3, 1, "test", 3*time.Second, 9*time.Second), "exit code: 1"),
+		cached,
+		// the root cause of the failure
+		failed(span(5, 3, "go test ./...", 3*time.Second, 9*time.Second), "exit code: 1"),
+	})
+
+	// logs are kept up to the tail size
+	var rec sdklog.Record
+	rec.SetSpanID(testSpanID(5).SpanID)
+	rec.SetBody(log.StringValue(strings.Repeat("x", LogTailSize) + "\n--- FAIL: TestFoo\nFAIL\n"))
+	require.NoError(t, db.LogExporter().Export(context.Background(), []sdklog.Record{rec}))
+	require.Len(t, db.LogTails[testSpanID(5)], LogTailSize)
+
+	summary := db.Summary(2, 2, QuarantineWarn)
+	require.Equal(t, "dagger call ci", summary.Name)
+	require.True(t, summary.Failed)
+	require.Equal(t, 10*time.Second, summary.Duration)
+	require.Equal(t, []StepTiming{
+		{Name: "test", Duration: 6 * time.Second},
+		{Name: "build", Duration: 3 * time.Second},
+	}, summary.Slowest)
+	require.Equal(t, []StepFailure{{
+		Name:       "go test ./...",
+		CallDigest: "go test ./...",
+		Error:      "exit code: 1",
+		LogTail:    "--- FAIL: TestFoo\nFAIL",
+	}}, summary.Failures)
+
+	var text strings.Builder
+	require.NoError(t, summary.WriteText(&text))
+	require.Contains(t, text.String(), "dagger call ci failed in "+FormatDuration(10*time.Second))
+	require.Contains(t, text.String(), "\nFailed: go test ./...\n  exit code: 1\n--- FAIL: TestFoo\nFAIL\n")
+
+	var md strings.Builder
+	require.NoError(t, summary.WriteMarkdown(&md))
+	require.Contains(t, md.String(), "### ❌ `dagger call ci` failed in ")
+	require.Contains(t, md.String(), "#### ❌ `go test ./...`\n\nexit code: 1\n\n<details><summary>Logs</summary>\n\n```\n--- FAIL: TestFoo\nFAIL\n```")
+	require.Contains(t, md.String(), "| `test` | "+FormatDuration(6*time.Second)+" |  |")
+}
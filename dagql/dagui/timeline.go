@@ -0,0 +1,92 @@
+package dagui
+
+import (
+	"slices"
+	"time"
+)
+
+// Timeline lays spans out on a wall-clock time axis, Gantt-style, so that
+// parallelism and stalls in the pipeline are visible.
+type Timeline struct {
+	// Start and End are the bounds of the time axis.
+	Start, End time.Time
+
+	// Bars are the spans on the timeline, in the order of the rows they were
+	// built from, so that children are grouped beneath their parents.
+	Bars []TimelineBar
+}
+
+// TimelineBar is a span placed on a timeline.
+type TimelineBar struct {
+	Span  *Span
+	Depth int
+
+	// Start and End are when the span ran, with End being the time the
+	// timeline was built if the span is still running.
+	Start, End time.Time
+
+	// Leaf is set if none of the span's children are on the timeline.
+	Leaf bool
+}
+
+// NewTimeline returns a timeline of the given rows, e.g. the Rows of a
+// RowsView, treating spans that are still running as ending now.
+func NewTimeline(rows []*TraceRow, now time.Time) *Timeline {
+	tl := &Timeline{}
+	for i, row := range rows {
+		span := row.Span
+		if span.StartTime.IsZero() {
+			// not started yet, e.g. pending
+			continue
+		}
+		bar := TimelineBar{
+			Span:  span,
+			Depth: row.Depth,
+			Start: span.StartTime,
+			End:   span.EndTimeOrFallback(now),
+			Leaf:  i == len(rows)-1 || rows[i+1].Depth <= row.Depth,
+		}
+		if bar.End.Before(bar.Start) {
+			bar.End = bar.Start
+		}
+		if tl.Start.IsZero() || bar.Start.Before(tl.Start) {
+			tl.Start = bar.Start
+		}
+		if bar.End.After(tl.End) {
+			tl.End = bar.End
+		}
+		tl.Bars = append(tl.Bars, bar)
+	}
+	return tl
+}
+
+// Duration returns the length of the time axis.
+func (tl *Timeline) Duration() time.Duration {
+	return tl.End.Sub(tl.Start)
+}
+
+// Stalls returns the periods of at least minGap during which none of the
+// leaf spans on the timeline were running, i.e. when the pipeline made no
+// visible progress.
+func (tl *Timeline) Stalls(minGap time.Duration) []Interval {
+	var busy []Interval
+	for _, bar := range tl.Bars {
+		if bar.Leaf {
+			busy = append(busy, Interval{Start: bar.Start, End: bar.End})
+		}
+	}
+	slices.SortFunc(busy, func(a, b Interval) int {
+		return a.Start.Compare(b.Start)
+	})
+	var stalls []Interval
+	cursor := tl.Start
+	for _, ival := range busy {
+		if gap := ival.Start.Sub(cursor); gap > 0 && gap >= minGap {
+			stalls = append(stalls, Interval{Start: cursor, End: ival.Start})
+		}
+		if ival.End.After(cursor) {
+			cursor = ival.End
+		}
+	}
+	return stalls
+}
@@ -0,0 +1,46 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeline(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	span := testTrace{start: start}.span
+
+	db := NewDB()
+	db.ImportSnapshots([]SpanSnapshot{
+		span(1, 0, "", 0, 20*time.Second),
+		span(2, 1, "", time.Second, 4*time.Second),
+		span(3, 1, "", 2*time.Second, 5*time.Second),
+		// nothing runs from 5s to 12s
+		span(4, 1, "", 12*time.Second, 0),
+	})
+	now := start.Add(30 * time.Second)
+	rows := []*TraceRow{
+		{Span: db.Spans.Map[testSpanID(1)]},
+		{Span: db.Spans.Map[testSpanID(2)], Depth: 1},
+		{Span: db.Spans.Map[testSpanID(3)], Depth: 1},
+		{Span: db.Spans.Map[testSpanID(4)], Depth: 1},
+	}
+
+	tl := NewTimeline(rows, now)
+	require.Equal(t, start, tl.Start)
+	// the running span is drawn until now
+	require.Equal(t, now, tl.End)
+	require.Len(t, tl.Bars, 4)
+	require.False(t, tl.Bars[0].Leaf)
+	require.True(t, tl.Bars[1].Leaf)
+	require.Equal(t, now, tl.Bars[3].End)
+
+	require.Equal(t, []Interval{
+		{Start: start, End: start.Add(time.Second)},
+		{Start: start.Add(5 * time.Second), End: start.Add(12 * time.Second)},
+	}, tl.Stalls(time.Second))
+	require.Equal(t, []Interval{
+		{Start: start.Add(5 * time.Second), End: start.Add(12 * time.Second)},
+	}, tl.Stalls(2*time.Second))
+}
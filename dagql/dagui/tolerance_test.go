@@ -0,0 +1,52 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestFailureTolerance(t *testing.T) {
+	span := testTrace{start: time.Now().Add(-time.Hour)}.span
+	failed := func(snapshot SpanSnapshot) SpanSnapshot {
+		snapshot.Status = sdktrace.Status{Code: codes.Error, Description: "exit code 1"}
+		return snapshot
+	}
+	allowed := failed(span(2, 1, "lint", 2*time.Second, time.Minute))
+	allowed.FailureAllowed = true
+	xfail := failed(span(3, 1, "known bug", 3*time.Second, time.Minute))
+	xfail.FailureExpected = true
+	xpass := span(4, 1, "fixed bug", 4*time.Second, time.Minute)
+	xpass.FailureExpected = true
+	allowedEffect := failed(span(5, 2, "exec", 5*time.Second, time.Minute))
+	allowedEffect.EffectID = "effect"
+	consumer := span(6, 1, "publish", 6*time.Second, time.Minute)
+	consumer.EffectIDs = []string{"effect"}
+
+	db := NewDB()
+	db.SetPrimarySpan(testSpanID(1))
+	db.ImportSnapshots([]SpanSnapshot{
+		span(1, 0, "run", time.Second, time.Minute),
+		allowed, xfail, xpass, allowedEffect, consumer,
+	})
+
+	lint := db.Spans.Map[allowed.ID]
+	require.True(t, lint.IsToleratedFailure())
+	require.True(t, db.Spans.Map[xfail.ID].IsToleratedFailure())
+	require.True(t, db.Spans.Map[allowedEffect.ID].IsToleratedFailure())
+	require.True(t, db.Spans.Map[xpass.ID].IsUnexpectedPass())
+
+	// tolerated failures don't fail the steps that depend on them
+	require.False(t, db.Spans.Map[consumer.ID].IsFailedOrCausedFailure())
+	require.False(t, db.Spans.Map[testSpanID(1)].IsFailedOrCausedFailure())
+
+	summary := db.Summary(0, 10, QuarantineWarn)
+	require.False(t, summary.Failed)
+	require.Empty(t, summary.Failures)
+	require.Empty(t, summary.Warnings)
+	require.Equal(t, []string{"fixed bug"}, summary.UnexpectedPasses)
+	require.Empty(t, db.Failures(10))
+}
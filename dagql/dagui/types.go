@@ -1,6 +1,7 @@
 package dagui
 
 import (
+	"slices"
 	"time"
 
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
@@ -20,6 +21,15 @@ type TraceTree struct {
 
 	Parent *TraceTree
 
+	// Source is set on the first top-level row emitted by each source, when
+	// the trace has more than one.
+	Source *TraceSource
+
+	// Aggregate is set on trees standing for several spans of the same call,
+	// whose children are the trees of each span. See
+	// FrontendOpts.AggregateCalls.
+	Aggregate *CallAggregate
+
 	IsRunningOrChildRunning bool
 	Chained                 bool
 	Final                   bool
@@ -39,6 +49,8 @@ type TraceRow struct {
 	Previous                *TraceRow
 	Parent                  *Span
 	HasChildren             bool
+	Source                  *TraceSource
+	Aggregate               *CallAggregate
 }
 
 type RowsView struct {
@@ -57,6 +69,13 @@ func (db *DB) RowsView(opts FrontendOpts) *RowsView {
 	var spans []*Span
 	if view.Zoomed != nil {
 		spans = view.Zoomed.ChildSpans.Order
+		if view.Zoomed.ID == db.PrimarySpan {
+			// show the other invocations of the session alongside the
+			// primary one, each as its own subtree
+			if roots := db.Roots(); len(roots) > 1 {
+				spans = append(slices.Clone(spans), roots[1:]...)
+			}
+		}
 	} else {
 		spans = db.Spans.Order
 	}
@@ -68,6 +87,10 @@ func (db *DB) RowsView(opts FrontendOpts) *RowsView {
 		}
 		view.BySpan[tree.Span.ID] = tree
 	})
+	if opts.AggregateCalls {
+		view.Body = aggregateCalls(view.Body, nil)
+	}
+	db.groupBySource(view.Body)
 	return view
 }
 
@@ -152,13 +175,30 @@ func (lv *RowsView) Rows(opts FrontendOpts) *Rows {
 			IsRunningOrChildRunning: tree.IsRunningOrChildRunning,
 			Parent:                  parent,
 			HasChildren:             len(tree.Children) > 0,
+			Source:                  tree.Source,
+			Aggregate:               tree.Aggregate,
 		}
 		if len(rows.Order) > 0 {
 			row.Previous = rows.Order[len(rows.Order)-1]
 		}
 		rows.Order = append(rows.Order, row)
 		rows.BySpan[tree.Span.ID] = row
-		if tree.IsRunningOrChildRunning || tree.Span.IsFailedOrCausedFailure() || opts.Verbosity >= ExpandCompletedVerbosity {
+		if tree.Aggregate != nil {
+			// expanding every span of the call would defeat the purpose, so
+			// only do it on request
+			if tree.Span.Verbosity(opts) >= ExpandCompletedVerbosity ||
+				hasRevealedChild(tree, opts) {
+				// the row stands for its children, so it isn't their parent
+				for _, child := range tree.Children {
+					walk(child, parent, depth+1)
+				}
+			}
+			return
+		}
+		if tree.IsRunningOrChildRunning ||
+			tree.Span.IsFailedOrCausedFailure() ||
+			tree.Span.Verbosity(opts) >= ExpandCompletedVerbosity ||
+			hasRevealedChild(tree, opts) {
 			for _, child := range tree.Children {
 				walk(child, row.Span, depth+1)
 			}
@@ -171,6 +211,15 @@ func (lv *RowsView) Rows(opts FrontendOpts) *Rows {
 	return rows
 }
 
+func hasRevealedChild(tree *TraceTree, opts FrontendOpts) bool {
+	for _, child := range tree.Children {
+		if opts.RevealedSpans[child.Span.ID] {
+			return true
+		}
+	}
+	return false
+}
+
 func (row *TraceTree) Depth() int {
 	if row.Parent == nil {
 		return 0
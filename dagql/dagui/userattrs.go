@@ -0,0 +1,133 @@
+package dagui
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"dagger.io/dagger/telemetry"
+)
+
+// UserAttribute is the value of an attribute that a module set on a span in
+// the telemetry.UserAttrPrefix namespace, along with its type so that it
+// survives being stored as JSON.
+type UserAttribute struct {
+	// Type is one of bool, int, float, or string, or a slice of them, e.g.
+	// string[].
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+var userAttrNameRe = regexp.MustCompile(`^[a-z0-9_]+(\.[a-z0-9_]+)*$`)
+
+// parseUserAttribute validates an attribute in the user namespace, returning
+// its name without the prefix.
+func parseUserAttribute(name string, val any) (string, UserAttribute, error) {
+	name = strings.TrimPrefix(name, telemetry.UserAttrPrefix)
+	if !userAttrNameRe.MatchString(name) {
+		return "", UserAttribute{}, fmt.Errorf("invalid name %q", name)
+	}
+	var attr UserAttribute
+	switch v := val.(type) {
+	case bool:
+		attr = UserAttribute{Type: "bool", Value: v}
+	case int64:
+		attr = UserAttribute{Type: "int", Value: v}
+	case float64:
+		attr = UserAttribute{Type: "float", Value: v}
+	case string:
+		if len(v) > telemetry.UserAttrMaxLen {
+			return "", UserAttribute{}, fmt.Errorf("value of %q exceeds %d bytes", name, telemetry.UserAttrMaxLen)
+		}
+		attr = UserAttribute{Type: "string", Value: v}
+	case []bool:
+		attr = UserAttribute{Type: "bool[]", Value: v}
+	case []int64:
+		attr = UserAttribute{Type: "int[]", Value: v}
+	case []float64:
+		attr = UserAttribute{Type: "float[]", Value: v}
+	case []string:
+		for _, s := range v {
+			if len(s) > telemetry.UserAttrMaxLen {
+				return "", UserAttribute{}, fmt.Errorf("value of %q exceeds %d bytes", name, telemetry.UserAttrMaxLen)
+			}
+		}
+		attr = UserAttribute{Type: "string[]", Value: v}
+	default:
+		return "", UserAttribute{}, fmt.Errorf("unsupported type %T for %q", val, name)
+	}
+	return name, attr, nil
+}
+
+var _ json.Unmarshaler = (*UserAttribute)(nil)
+
+// UnmarshalJSON restores the value with its original type, e.g. int64 rather
+// than float64 for ints.
+func (attr *UserAttribute) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	var dest any
+	switch raw.Type {
+	case "bool":
+		dest = new(bool)
+	case "int":
+		dest = new(int64)
+	case "float":
+		dest = new(float64)
+	case "string":
+		dest = new(string)
+	case "bool[]":
+		dest = new([]bool)
+	case "int[]":
+		dest = new([]int64)
+	case "float[]":
+		dest = new([]float64)
+	case "string[]":
+		dest = new([]string)
+	default:
+		return fmt.Errorf("unknown user attribute type %q", raw.Type)
+	}
+	if err := json.Unmarshal(raw.Value, dest); err != nil {
+		return fmt.Errorf("unmarshal %s user attribute: %w", raw.Type, err)
+	}
+	attr.Type = raw.Type
+	attr.Value = reflect.ValueOf(dest).Elem().Interface()
+	return nil
+}
+
+// parseAnnotation validates an attribute in the annotation namespace,
+// returning its name without the prefix.
+func parseAnnotation(name string, val any) (string, string, error) {
+	name = strings.TrimPrefix(name, telemetry.AnnotationAttrPrefix)
+	if !userAttrNameRe.MatchString(name) {
+		return "", "", fmt.Errorf("invalid name %q", name)
+	}
+	str, ok := val.(string)
+	if !ok {
+		return "", "", fmt.Errorf("unsupported type %T for %q", val, name)
+	}
+	if len(str) > telemetry.AnnotationMaxLen {
+		return "", "", fmt.Errorf("value of %q exceeds %d bytes", name, telemetry.AnnotationMaxLen)
+	}
+	return name, str, nil
+}
+
+// UserAttributeValues returns the values of the attributes that modules set
+// on the span, keyed by their name without the namespace prefix.
+func (span *Span) UserAttributeValues() map[string]any {
+	if len(span.UserAttributes) == 0 {
+		return nil
+	}
+	vals := make(map[string]any, len(span.UserAttributes))
+	for name, attr := range span.UserAttributes {
+		vals[name] = attr.Value
+	}
+	return vals
+}
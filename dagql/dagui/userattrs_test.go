@@ -0,0 +1,62 @@
+package dagui
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"dagger.io/dagger/telemetry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserAttributes(t *testing.T) {
+	var snapshot SpanSnapshot
+	snapshot.ProcessAttribute(telemetry.UserAttrPrefix+"team", "infra")
+	snapshot.ProcessAttribute(telemetry.UserAttrPrefix+"shard.index", int64(3))
+	snapshot.ProcessAttribute(telemetry.UserAttrPrefix+"tags", []string{"a", "b"})
+	// invalid names and oversized values are dropped
+	snapshot.ProcessAttribute(telemetry.UserAttrPrefix+"Team", "infra")
+	snapshot.ProcessAttribute(telemetry.UserAttrPrefix+"bad..name", "x")
+	snapshot.ProcessAttribute(telemetry.UserAttrPrefix+"big", strings.Repeat("x", telemetry.UserAttrMaxLen+1))
+	// other unknown attributes are still dropped
+	snapshot.ProcessAttribute("custom.attr", "x")
+
+	expected := map[string]UserAttribute{
+		"team":        {Type: "string", Value: "infra"},
+		"shard.index": {Type: "int", Value: int64(3)},
+		"tags":        {Type: "string[]", Value: []string{"a", "b"}},
+	}
+	require.Equal(t, expected, snapshot.UserAttributes)
+	require.Nil(t, snapshot.Attributes)
+
+	// types survive a round trip through JSON
+	payload, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	var decoded SpanSnapshot
+	require.NoError(t, json.Unmarshal(payload, &decoded))
+	require.Equal(t, expected, decoded.UserAttributes)
+}
+
+func TestAnnotations(t *testing.T) {
+	var snapshot SpanSnapshot
+	snapshot.ProcessAttribute(telemetry.AnnotationAttrPrefix+"ticket", "ENG-123")
+	snapshot.ProcessAttribute(telemetry.AnnotationAttrPrefix+"env.name", "staging")
+	// invalid names, non-strings, and oversized values are dropped
+	snapshot.ProcessAttribute(telemetry.AnnotationAttrPrefix+"Ticket", "ENG-123")
+	snapshot.ProcessAttribute(telemetry.AnnotationAttrPrefix+"count", int64(3))
+	snapshot.ProcessAttribute(telemetry.AnnotationAttrPrefix+"big", strings.Repeat("x", telemetry.AnnotationMaxLen+1))
+
+	require.Equal(t, map[string]string{
+		"ticket":   "ENG-123",
+		"env.name": "staging",
+	}, snapshot.Annotations)
+	require.Nil(t, snapshot.UserAttributes)
+
+	db := NewDB()
+	snapshot.ID = SpanID{SpanID: [8]byte{1}}
+	snapshot.Name = "deploy"
+	db.ImportSnapshots([]SpanSnapshot{snapshot})
+	span := db.Spans.Map[snapshot.ID]
+	require.True(t, db.MatchesSearch(span, "eng-1"))
+	require.False(t, db.MatchesSearch(span, "prod"))
+}
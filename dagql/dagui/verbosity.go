@@ -0,0 +1,44 @@
+package dagui
+
+// SetSubtreeVerbosity overrides the verbosity of a span and its descendants,
+// e.g. to reveal the internal and encapsulated children of one span without
+// revealing those of every other span.
+//
+// Overrides are keyed by the span's call digest if it has one, so that they
+// also apply to other spans for the same call, e.g. when it's retried.
+func (db *DB) SetSubtreeVerbosity(span *Span, verbosity int) {
+	db.subtreeVerbosity[verbosityKey(span)] = verbosity
+}
+
+// ClearSubtreeVerbosity removes a span's verbosity override, if any.
+func (db *DB) ClearSubtreeVerbosity(span *Span) {
+	delete(db.subtreeVerbosity, verbosityKey(span))
+}
+
+// SubtreeVerbosity returns the verbosity override set for the span itself,
+// if any.
+func (db *DB) SubtreeVerbosity(span *Span) (int, bool) {
+	verbosity, ok := db.subtreeVerbosity[verbosityKey(span)]
+	return verbosity, ok
+}
+
+// Verbosity returns the level of detail to show for a span: the override of
+// the span or its nearest ancestor that has one, or the global verbosity.
+func (span *Span) Verbosity(opts FrontendOpts) int {
+	if span.db == nil || len(span.db.subtreeVerbosity) == 0 {
+		return opts.Verbosity
+	}
+	for s := span; s != nil; s = s.ParentSpan {
+		if verbosity, ok := span.db.SubtreeVerbosity(s); ok {
+			return verbosity
+		}
+	}
+	return opts.Verbosity
+}
+
+func verbosityKey(span *Span) string {
+	if span.CallDigest != "" {
+		return span.CallDigest
+	}
+	return span.ID.String()
+}
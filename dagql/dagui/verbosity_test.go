@@ -0,0 +1,43 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubtreeVerbosity(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	span := testTrace{start: start}.span
+	revealed := span(1, 0, "revealed", time.Second, time.Second+time.Millisecond)
+	revealed.Encapsulate = true
+	other := span(2, 0, "other", 2*time.Second, 2*time.Second+time.Millisecond)
+	other.Encapsulate = true
+	db := NewDB()
+	db.ImportSnapshots([]SpanSnapshot{
+		revealed,
+		span(3, 1, "revealed child", 3*time.Second, 3*time.Second+time.Millisecond),
+		other,
+		span(4, 2, "other child", 4*time.Second, 4*time.Second+time.Millisecond),
+	})
+	opts := FrontendOpts{Verbosity: ShowCompletedVerbosity}
+
+	require.True(t, db.Spans.Map[testSpanID(3)].Hidden(opts))
+	require.True(t, db.Spans.Map[testSpanID(4)].Hidden(opts))
+
+	db.SetSubtreeVerbosity(db.Spans.Map[testSpanID(1)], ShowEncapsulatedVerbosity)
+	require.False(t, db.Spans.Map[testSpanID(3)].Hidden(opts))
+	require.True(t, db.Spans.Map[testSpanID(4)].Hidden(opts))
+	require.Equal(t, ShowEncapsulatedVerbosity, db.Spans.Map[testSpanID(3)].Verbosity(opts))
+	require.Equal(t, ShowCompletedVerbosity, db.Spans.Map[testSpanID(4)].Verbosity(opts))
+
+	// overrides are keyed by call, so they apply to other spans of the call
+	retry := span(5, 0, "revealed", 5*time.Second, 5*time.Second+time.Millisecond)
+	db.ImportSnapshots([]SpanSnapshot{retry})
+	_, ok := db.SubtreeVerbosity(db.Spans.Map[testSpanID(5)])
+	require.True(t, ok)
+
+	db.ClearSubtreeVerbosity(db.Spans.Map[testSpanID(1)])
+	require.True(t, db.Spans.Map[testSpanID(3)].Hidden(opts))
+}
@@ -0,0 +1,51 @@
+package dagui
+
+import (
+	"slices"
+
+	"github.com/dagger/dagger/dagql/call/callpbv1"
+)
+
+// WarmupManifest lists the external content that a pipeline needed, so that
+// the engine can fetch it ahead of a later run.
+type WarmupManifest struct {
+	// Images are the container image refs passed to Container.from.
+	Images []string `json:"images"`
+
+	// Modules are the module source refs passed to moduleSource.
+	Modules []string `json:"modules"`
+}
+
+// WarmupManifest derives a manifest from the calls seen in the trace.
+func (db *DB) WarmupManifest() *WarmupManifest {
+	manifest := &WarmupManifest{
+		Images:  []string{},
+		Modules: []string{},
+	}
+	for _, call := range db.Calls {
+		switch {
+		case call.Field == "from" && call.Type.GetNamedType() == "Container":
+			if ref := stringArg(call, "address"); ref != "" {
+				manifest.Images = append(manifest.Images, ref)
+			}
+		case call.Field == "moduleSource" && call.ReceiverDigest == "":
+			if ref := stringArg(call, "refString"); ref != "" {
+				manifest.Modules = append(manifest.Modules, ref)
+			}
+		}
+	}
+	slices.Sort(manifest.Images)
+	manifest.Images = slices.Compact(manifest.Images)
+	slices.Sort(manifest.Modules)
+	manifest.Modules = slices.Compact(manifest.Modules)
+	return manifest
+}
+
+func stringArg(call *callpbv1.Call, name string) string {
+	for _, arg := range call.Args {
+		if arg.GetName() == name {
+			return arg.GetValue().GetString_()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package dagui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/dagger/dagger/dagql/call"
+)
+
+func TestWarmupManifest(t *testing.T) {
+	ctrType := &ast.Type{NamedType: "Container", NonNull: true}
+	srcType := &ast.Type{NamedType: "ModuleSource", NonNull: true}
+	from := func(address string) *call.ID {
+		return call.New().Append(ctrType, "container", "", nil, false, 0, "").
+			Append(ctrType, "from", "", nil, false, 0, "",
+				call.NewArgument("address", call.NewLiteralString(address), false))
+	}
+	moduleSource := func(ref string) *call.ID {
+		return call.New().Append(srcType, "moduleSource", "", nil, false, 0, "",
+			call.NewArgument("refString", call.NewLiteralString(ref), false))
+	}
+	ids := []*call.ID{
+		from("golang:1.23"),
+		from("alpine:3.20"),
+		// the same image pulled twice is listed once
+		from("alpine:3.20").Append(ctrType, "withExec", "", nil, false, 0, "",
+			call.NewArgument("args", call.NewLiteralList(call.NewLiteralString("true")), false)),
+		moduleSource("github.com/acme/ci@v1.0.0"),
+		moduleSource("./ci"),
+		// only top-level moduleSource calls are module refs
+		from("alpine:3.20").Append(srcType, "moduleSource", "", nil, false, 0, "",
+			call.NewArgument("refString", call.NewLiteralString("ignored"), false)),
+	}
+
+	tr := testTrace{start: time.Now().Add(-time.Hour)}
+	snapshots := []SpanSnapshot{tr.span(1, 0, "run", 0, time.Minute)}
+	for i, id := range ids {
+		payload, err := id.Call().Encode()
+		require.NoError(t, err)
+		snapshot := tr.span(byte(i+2), 1, id.Field(), 0, time.Second)
+		snapshot.CallDigest = string(id.Digest())
+		snapshot.CallPayload = payload
+		snapshots = append(snapshots, snapshot)
+	}
+	db := NewDB()
+	db.SetPrimarySpan(testSpanID(1))
+	db.ImportSnapshots(snapshots)
+
+	require.Equal(t, &WarmupManifest{
+		Images:  []string{"alpine:3.20", "golang:1.23"},
+		Modules: []string{"./ci", "github.com/acme/ci@v1.0.0"},
+	}, db.WarmupManifest())
+
+	// an empty trace needs nothing
+	require.Equal(t, &WarmupManifest{Images: []string{}, Modules: []string{}}, NewDB().WarmupManifest())
+}
@@ -0,0 +1,250 @@
+package webui
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// TraceAPI serves read-only REST endpoints for the traces in a store:
+//
+//	GET /api/traces                            list traces, most recent first
+//	GET /api/traces/{trace}                    a trace's metadata
+//	GET /api/traces/{trace}/spans              a trace's spans
+//	GET /api/traces/{trace}/spans/{span}/logs  a span's logs, as text
+//
+// Lists are paginated with the offset and limit query parameters, and
+// responses carry an ETag so that clients can poll cheaply with
+// If-None-Match.
+type TraceAPI struct {
+	store *dagui.TraceStore
+	mux   *http.ServeMux
+}
+
+var _ http.Handler = (*TraceAPI)(nil)
+
+func NewTraceAPI(store *dagui.TraceStore) *TraceAPI {
+	api := &TraceAPI{
+		store: store,
+		mux:   http.NewServeMux(),
+	}
+	api.mux.HandleFunc("GET /api/traces", api.serveTraces)
+	api.mux.HandleFunc("GET /api/traces/{trace}", api.serveTrace)
+	api.mux.HandleFunc("GET /api/traces/{trace}/spans", api.serveSpans)
+	api.mux.HandleFunc("GET /api/traces/{trace}/spans/{span}/logs", api.serveLogs)
+	return api
+}
+
+func (api *TraceAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	api.mux.ServeHTTP(w, r)
+}
+
+// Page is a page of a paginated list.
+type Page[T any] struct {
+	Items []T `json:"items"`
+
+	// Total is the number of items across all pages.
+	Total int `json:"total"`
+
+	// Next is the URL of the next page, if any.
+	Next string `json:"next,omitempty"`
+}
+
+func (api *TraceAPI) serveTraces(w http.ResponseWriter, r *http.Request) {
+	metas, err := api.store.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	page, err := paginate(r, metas)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	payload, err := json.Marshal(page)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// the listing changes as traces come and go, so tag it by its content
+	sum := sha256.Sum256(payload)
+	if notModified(w, r, hex.EncodeToString(sum[:16])) {
+		return
+	}
+	writeJSON(w, payload)
+}
+
+func (api *TraceAPI) serveTrace(w http.ResponseWriter, r *http.Request) {
+	meta, ok := api.meta(w, r)
+	if !ok {
+		return
+	}
+	payload, err := json.Marshal(meta)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, payload)
+}
+
+func (api *TraceAPI) serveSpans(w http.ResponseWriter, r *http.Request) {
+	meta, ok := api.meta(w, r)
+	if !ok {
+		return
+	}
+	offset, limit, err := pageRange(r, meta.Spans)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// only read the spans on the page, since traces can be huge
+	snapshots, err := api.store.Spans(meta, offset, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	payload, err := json.Marshal(newPage(r, snapshots, meta.Spans, offset, limit))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, payload)
+}
+
+func (api *TraceAPI) serveLogs(w http.ResponseWriter, r *http.Request) {
+	meta, ok := api.meta(w, r)
+	if !ok {
+		return
+	}
+	spanID, err := parseSpanID(r.PathValue("span"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tail, ok, err := api.store.LogTail(meta, spanID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, fmt.Sprintf("span %s not found", spanID), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(tail)
+}
+
+// meta looks up the requested trace, responding with an error or a Not
+// Modified status if the request is already handled.
+//
+// Stored traces don't change once saved, so they are tagged by when they were
+// saved, which spares loading them to answer conditional requests.
+func (api *TraceAPI) meta(w http.ResponseWriter, r *http.Request) (dagui.TraceMeta, bool) {
+	meta, err := api.store.Meta(r.PathValue("trace"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return meta, false
+	}
+	modified, err := api.store.Modified(meta)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return meta, false
+	}
+	etag := meta.TraceID.String() + "-" + strconv.FormatInt(modified.UnixNano(), 36)
+	if notModified(w, r, etag) {
+		return meta, false
+	}
+	w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	return meta, true
+}
+
+// notModified sets the response's ETag, and responds with Not Modified if it
+// matches the request's If-None-Match.
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	etag = strconv.Quote(etag)
+	w.Header().Set("ETag", etag)
+	// traces are local data, but may be polled often
+	w.Header().Set("Cache-Control", "no-cache")
+	if match := r.Header.Get("If-None-Match"); match == etag || match == "*" {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+func paginate[T any](r *http.Request, items []T) (Page[T], error) {
+	total := len(items)
+	offset, limit, err := pageRange(r, total)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	items = items[offset:min(offset+limit, total)]
+	return newPage(r, items, total, offset, limit), nil
+}
+
+// pageRange returns the range of items requested by the offset and limit
+// query parameters, out of total items. The offset is clamped to the total
+// and the limit to the maximum page size, so that adding them can't overflow.
+func pageRange(r *http.Request, total int) (offset, limit int, err error) {
+	query := r.URL.Query()
+	offset, err = intParam(query, "offset", 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	limit, err = intParam(query, "limit", defaultPageSize)
+	if err != nil {
+		return 0, 0, err
+	}
+	return min(offset, total), min(max(limit, 1), maxPageSize), nil
+}
+
+// newPage returns a page of items from the given range of a list.
+func newPage[T any](r *http.Request, items []T, total, offset, limit int) Page[T] {
+	page := Page[T]{
+		Items: append(make([]T, 0, len(items)), items...),
+		Total: total,
+	}
+	if offset+limit < total {
+		next := *r.URL
+		query := next.Query()
+		query.Set("offset", strconv.Itoa(offset+limit))
+		query.Set("limit", strconv.Itoa(limit))
+		next.RawQuery = query.Encode()
+		page.Next = next.RequestURI()
+	}
+	return page
+}
+
+func intParam(query url.Values, name string, def int) (int, error) {
+	str := query.Get(name)
+	if str == "" {
+		return def, nil
+	}
+	val, err := strconv.Atoi(str)
+	if err != nil || val < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative integer", name, str)
+	}
+	return val, nil
+}
+
+func parseSpanID(str string) (dagui.SpanID, error) {
+	var spanID dagui.SpanID
+	err := spanID.UnmarshalJSON([]byte(strconv.Quote(str)))
+	return spanID, err
+}
+
+func writeJSON(w http.ResponseWriter, payload []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(payload)
+}
@@ -0,0 +1,112 @@
+package webui
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+func TestTraceAPI(t *testing.T) {
+	traceID := dagui.TraceID{TraceID: trace.TraceID{1}}
+	root := dagui.SpanID{SpanID: trace.SpanID{1}}
+	start := time.Now().Add(-time.Minute)
+	snapshots := []dagui.SpanSnapshot{{
+		ID:        root,
+		TraceID:   traceID,
+		Name:      "root",
+		StartTime: start,
+		EndTime:   start.Add(time.Minute),
+	}}
+	for i := byte(2); i <= 6; i++ {
+		snapshots = append(snapshots, dagui.SpanSnapshot{
+			ID:        dagui.SpanID{SpanID: trace.SpanID{i}},
+			TraceID:   traceID,
+			ParentID:  root,
+			Name:      "child",
+			StartTime: start,
+			EndTime:   start.Add(time.Second),
+		})
+	}
+	db := dagui.NewDB()
+	db.SetPrimarySpan(root)
+	db.ImportSnapshots(snapshots)
+	db.LogTails[root] = []byte("hello\n")
+	store := dagui.NewTraceStore(t.TempDir())
+	_, err := store.Save(db)
+	require.NoError(t, err)
+
+	api := NewTraceAPI(store)
+	get := func(path, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("pagination", func(t *testing.T) {
+		var seen int
+		next := "/api/traces/latest/spans?limit=4"
+		for next != "" {
+			rec := get(next, "")
+			require.Equal(t, http.StatusOK, rec.Code)
+			var page Page[dagui.SpanSnapshot]
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+			require.Equal(t, len(snapshots), page.Total)
+			seen += len(page.Items)
+			next = page.Next
+		}
+		require.Equal(t, len(snapshots), seen)
+	})
+
+	t.Run("huge offset and limit", func(t *testing.T) {
+		huge := strconv.Itoa(math.MaxInt)
+		for _, path := range []string{
+			"/api/traces/latest/spans?offset=" + huge + "&limit=" + huge,
+			"/api/traces/latest/spans?offset=2&limit=" + huge,
+			"/api/traces?offset=" + huge + "&limit=" + huge,
+		} {
+			rec := get(path, "")
+			require.Equal(t, http.StatusOK, rec.Code, path)
+		}
+		var page Page[dagui.SpanSnapshot]
+		rec := get("/api/traces/latest/spans?offset="+huge+"&limit="+huge, "")
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		require.Empty(t, page.Items)
+		require.Empty(t, page.Next)
+		rec = get("/api/traces/latest/spans?offset=2&limit="+huge, "")
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		require.Len(t, page.Items, len(snapshots)-2)
+		require.Empty(t, page.Next)
+	})
+
+	t.Run("etag", func(t *testing.T) {
+		rec := get("/api/traces/"+traceID.String()+"/spans", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		etag := rec.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+		rec = get("/api/traces/"+traceID.String()+"/spans", etag)
+		require.Equal(t, http.StatusNotModified, rec.Code)
+		require.Empty(t, rec.Body.Bytes())
+	})
+
+	t.Run("logs", func(t *testing.T) {
+		rec := get("/api/traces/latest/spans/"+root.String()+"/logs", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "hello\n", rec.Body.String())
+
+		rec = get("/api/traces/latest/spans/"+dagui.SpanID{SpanID: trace.SpanID{9}}.String()+"/logs", "")
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,308 @@
+// Package webui serves a local web UI for exploring traces, which scales to
+// much larger traces than a terminal can reasonably show.
+//
+// The UI follows the live tree of spans and their logs over a WebSocket, see
+// StreamProtocol, and charts each span's metrics, which it polls. A stream of
+// span snapshots is also served over server-sent events: each client first
+// receives every span, followed by batches of the spans that changed since.
+// The estimated time remaining for the run is served for external dashboards.
+package webui
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/dagger/dagger/dagql/dagui"
+	"github.com/dagger/dagger/engine/slog"
+)
+
+//go:embed index.html
+var indexHTML []byte
+
+// streamInterval is how often changed spans are sent to clients.
+const streamInterval = 250 * time.Millisecond
+
+// Server serves the web UI for the spans in a DB.
+type Server struct {
+	db *dagui.DB
+
+	// ingest queues exported telemetry, so that exporting never waits on
+	// readers; they flush it to the DB while holding mu
+	ingest *dagui.IngestQueue
+
+	// held while reading or updating the DB
+	mu sync.Mutex
+
+	// logTotals is the number of bytes logged to each span, of which the DB
+	// keeps the tail, for streaming logs from an offset.
+	logTotals map[dagui.SpanID]int
+
+	// Durations are the durations of previous runs, which the run's time
+	// remaining is estimated from. It must be set before serving.
+	Durations *dagui.DurationHistory
+
+	mux *http.ServeMux
+}
+
+var _ http.Handler = (*Server)(nil)
+
+// NewServer serves the spans in the DB, which must not be updated other than
+// through the server's exporters.
+func NewServer(db *dagui.DB) *Server {
+	s := &Server{
+		db:        db,
+		logTotals: map[dagui.SpanID]int{},
+		mux:       http.NewServeMux(),
+	}
+	for spanID, tail := range db.LogTails {
+		s.logTotals[spanID] = len(tail)
+	}
+	s.ingest = dagui.NewIngestQueue(db, serverLogSink{s}, db.MetricExporter())
+	s.mux.HandleFunc("GET /{$}", s.serveIndex)
+	s.mux.HandleFunc("GET /api/snapshots", s.serveSnapshots)
+	s.mux.HandleFunc("GET /api/logs/{span}", s.serveLogs)
+	s.mux.HandleFunc("GET /api/metrics/{span}", s.serveMetrics)
+	s.mux.HandleFunc("GET /api/stream", s.serveStream)
+	s.mux.HandleFunc("GET /api/eta", s.serveETA)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// SpanExporter returns an exporter that updates the served spans live.
+func (s *Server) SpanExporter() sdktrace.SpanExporter {
+	return serverSpanExporter{s}
+}
+
+// LogExporter returns an exporter that updates the served logs live.
+func (s *Server) LogExporter() sdklog.Exporter {
+	return serverLogExporter{s}
+}
+
+// MetricExporter returns an exporter that updates the served metrics live.
+func (s *Server) MetricExporter() sdkmetric.Exporter {
+	return serverMetricExporter{s}
+}
+
+type serverSpanExporter struct {
+	*Server
+}
+
+func (s serverSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	return s.ingest.ExportSpans(ctx, spans)
+}
+
+func (s serverSpanExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+type serverLogExporter struct {
+	*Server
+}
+
+func (s serverLogExporter) Export(ctx context.Context, logs []sdklog.Record) error {
+	return s.ingest.ExportLogs(ctx, logs)
+}
+
+func (s serverLogExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+func (s serverLogExporter) ForceFlush(context.Context) error {
+	return nil
+}
+
+// serverLogSink writes logs flushed from the ingest queue, under mu.
+type serverLogSink struct {
+	*Server
+}
+
+func (s serverLogSink) Export(ctx context.Context, logs []sdklog.Record) error {
+	for _, log := range logs {
+		s.logTotals[dagui.SpanID{SpanID: log.SpanID()}] += len(log.Body().AsString())
+	}
+	return s.db.LogExporter().Export(ctx, logs)
+}
+
+func (s serverLogSink) Shutdown(context.Context) error {
+	return nil
+}
+
+func (s serverLogSink) ForceFlush(context.Context) error {
+	return nil
+}
+
+// flushLocked applies the telemetry exported since the last read to the DB.
+func (s *Server) flushLocked() {
+	if _, err := s.ingest.Flush(context.Background()); err != nil {
+		slog.Warn("failed to ingest telemetry", "error", err)
+	}
+}
+
+type serverMetricExporter struct {
+	*Server
+}
+
+func (s serverMetricExporter) Export(ctx context.Context, resourceMetrics *metricdata.ResourceMetrics) error {
+	return s.ingest.ExportMetrics(ctx, resourceMetrics)
+}
+
+func (s serverMetricExporter) Temporality(ik sdkmetric.InstrumentKind) metricdata.Temporality {
+	return s.db.Temporality(ik)
+}
+
+func (s serverMetricExporter) Aggregation(ik sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return s.db.Aggregation(ik)
+}
+
+func (s serverMetricExporter) ForceFlush(context.Context) error {
+	return nil
+}
+
+func (s serverMetricExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+func (s *Server) serveIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(indexHTML)
+}
+
+// snapshotMessage is a batch of span snapshots sent to the client.
+type snapshotMessage struct {
+	PrimarySpan dagui.SpanID
+	Spans       []dagui.SpanSnapshot
+}
+
+func (s *Server) serveSnapshots(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	// versions of the spans the client has seen
+	sent := map[dagui.SpanID]int{}
+	ticker := time.NewTicker(streamInterval)
+	defer ticker.Stop()
+	for {
+		msg := s.changedSince(sent)
+		if len(msg.Spans) > 0 {
+			payload, err := json.Marshal(msg)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: snapshots\ndata: %s\n\n", payload); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) changedSince(sent map[dagui.SpanID]int) snapshotMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushLocked()
+	msg := snapshotMessage{
+		PrimarySpan: s.db.PrimarySpan,
+	}
+	for _, span := range s.db.Spans.Order {
+		if !span.Received {
+			continue
+		}
+		if version, ok := sent[span.ID]; ok && version == span.Version {
+			continue
+		}
+		sent[span.ID] = span.Version
+		msg.Spans = append(msg.Spans, span.Snapshot())
+	}
+	return msg
+}
+
+func (s *Server) serveLogs(w http.ResponseWriter, r *http.Request) {
+	spanID, err := parseSpanID(r.PathValue("span"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.flushLocked()
+	tail := string(s.db.LogTails[spanID])
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, tail)
+}
+
+// MetricPoint is a value of a metric at a point in time.
+type MetricPoint struct {
+	Time  time.Time `json:"time"`
+	Value int64     `json:"value"`
+}
+
+// serveMetrics serves the metrics recorded for a span's call, keyed by metric
+// name, oldest point first.
+func (s *Server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	spanID, err := parseSpanID(r.PathValue("span"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	metrics := map[string][]MetricPoint{}
+	s.mu.Lock()
+	s.flushLocked()
+	if span := s.db.Spans.Map[spanID]; span != nil && span.CallDigest != "" {
+		for name, points := range s.db.MetricsByCall[span.CallDigest] {
+			for _, point := range points {
+				metrics[name] = append(metrics[name], MetricPoint{
+					Time:  point.Time,
+					Value: point.Value,
+				})
+			}
+		}
+	}
+	s.mu.Unlock()
+	payload, err := json.Marshal(metrics)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, payload)
+}
+
+// serveETA serves the run's estimated time remaining, or null if the run
+// isn't running or there's no history to estimate it from.
+func (s *Server) serveETA(w http.ResponseWriter, r *http.Request) {
+	var eta *dagui.RunEstimate
+	s.mu.Lock()
+	s.flushLocked()
+	if est, ok := s.db.RunETA(s.Durations, time.Now()); ok {
+		eta = &est
+	}
+	s.mu.Unlock()
+	payload, err := json.Marshal(eta)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, payload)
+}
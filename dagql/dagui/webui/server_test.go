@@ -0,0 +1,224 @@
+package webui
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+func TestServeSnapshots(t *testing.T) {
+	traceID := dagui.TraceID{TraceID: trace.TraceID{1}}
+	root := dagui.SpanID{SpanID: trace.SpanID{1}}
+	start := time.Now().Add(-time.Minute)
+	db := dagui.NewDB()
+	db.SetPrimarySpan(root)
+	db.ImportSnapshots([]dagui.SpanSnapshot{{
+		ID:        root,
+		TraceID:   traceID,
+		Name:      "root",
+		StartTime: start,
+	}})
+	srv := NewServer(db)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	require.Equal(t, indexHTML, rec.Body.Bytes())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/snapshots", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	events := bufio.NewReader(resp.Body)
+	next := func() snapshotMessage {
+		event, err := events.ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, "event: snapshots\n", event)
+		data, err := events.ReadString('\n')
+		require.NoError(t, err)
+		_, err = events.ReadString('\n')
+		require.NoError(t, err)
+		var msg snapshotMessage
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(data, "data: ")), &msg))
+		require.Equal(t, root, msg.PrimarySpan)
+		return msg
+	}
+
+	// clients first receive every span
+	msg := next()
+	require.Len(t, msg.Spans, 1)
+	require.Equal(t, "root", msg.Spans[0].Name)
+
+	// followed by only the spans that changed, here the new child and the
+	// parent that gained it
+	child := tracetest.SpanStub{
+		Name: "child",
+		SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: traceID.TraceID,
+			SpanID:  trace.SpanID{2},
+		}),
+		Parent: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: traceID.TraceID,
+			SpanID:  root.SpanID,
+		}),
+		StartTime: start,
+		EndTime:   start.Add(time.Second),
+	}
+	require.NoError(t, srv.SpanExporter().ExportSpans(ctx, []sdktrace.ReadOnlySpan{child.Snapshot()}))
+	msg = next()
+	changed := map[string]dagui.SpanSnapshot{}
+	for _, span := range msg.Spans {
+		changed[span.Name] = span
+	}
+	require.Len(t, changed, 2)
+	require.Equal(t, root, changed["child"].ParentID)
+	require.Equal(t, 1, changed["root"].ChildCount)
+}
+
+func TestServeLogs(t *testing.T) {
+	root := dagui.SpanID{SpanID: trace.SpanID{1}}
+	db := dagui.NewDB()
+	db.ImportSnapshots([]dagui.SpanSnapshot{{
+		ID:        root,
+		TraceID:   dagui.TraceID{TraceID: trace.TraceID{1}},
+		Name:      "withExec",
+		StartTime: time.Now(),
+	}})
+	srv := NewServer(db)
+	for _, body := range []string{"hello\n", "world\n"} {
+		var rec sdklog.Record
+		rec.SetSpanID(root.SpanID)
+		rec.SetBody(log.StringValue(body))
+		require.NoError(t, srv.LogExporter().Export(context.Background(), []sdklog.Record{rec}))
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/api/logs/" + root.String())
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "hello\nworld\n", rec.Body.String())
+
+	// spans without logs have none
+	rec = get("/api/logs/" + dagui.SpanID{SpanID: trace.SpanID{2}}.String())
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Empty(t, rec.Body.String())
+
+	rec = get("/api/logs/bogus")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestServeMetrics(t *testing.T) {
+	traceID := dagui.TraceID{TraceID: trace.TraceID{1}}
+	root := dagui.SpanID{SpanID: trace.SpanID{1}}
+	start := time.Now().Add(-time.Minute).UTC()
+	db := dagui.NewDB()
+	db.ImportSnapshots([]dagui.SpanSnapshot{{
+		ID:         root,
+		TraceID:    traceID,
+		Name:       "withExec",
+		StartTime:  start,
+		CallDigest: "xxh3:abc",
+	}})
+	db.MetricsByCall = map[string]map[string][]metricdata.DataPoint[int64]{
+		"xxh3:abc": {
+			"dagger.io/metrics.memory.current": {
+				{Time: start, Value: 1024},
+				{Time: start.Add(time.Second), Value: 2048},
+			},
+		},
+	}
+	srv := NewServer(db)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/api/metrics/" + root.String())
+	require.Equal(t, http.StatusOK, rec.Code)
+	var metrics map[string][]MetricPoint
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &metrics))
+	require.Equal(t, map[string][]MetricPoint{
+		"dagger.io/metrics.memory.current": {
+			{Time: start, Value: 1024},
+			{Time: start.Add(time.Second), Value: 2048},
+		},
+	}, metrics)
+
+	// spans without metrics have none
+	rec = get("/api/metrics/" + dagui.SpanID{SpanID: trace.SpanID{2}}.String())
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, "{}", rec.Body.String())
+
+	rec = get("/api/metrics/bogus")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestServeETA(t *testing.T) {
+	traceID := dagui.TraceID{TraceID: trace.TraceID{1}}
+	root := dagui.SpanID{SpanID: trace.SpanID{1}}
+	start := time.Now().Add(-time.Minute)
+	db := dagui.NewDB()
+	db.ImportSnapshots([]dagui.SpanSnapshot{{
+		ID:        root,
+		TraceID:   traceID,
+		Name:      "run",
+		StartTime: start,
+	}, {
+		ID:        dagui.SpanID{SpanID: trace.SpanID{2}},
+		TraceID:   traceID,
+		ParentID:  root,
+		Name:      "build",
+		StartTime: start,
+	}})
+	srv := NewServer(db)
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/eta", nil))
+		return rec
+	}
+
+	// nothing to estimate from
+	rec := get()
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, "null", rec.Body.String())
+
+	srv.Durations = dagui.NewDurationHistory()
+	srv.Durations.ByName["run"] = dagui.DurationEstimate{Duration: 10 * time.Minute}
+	srv.Durations.ByName["build"] = dagui.DurationEstimate{Duration: 5 * time.Minute}
+	rec = get()
+	require.Equal(t, http.StatusOK, rec.Code)
+	var eta dagui.RunEstimate
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &eta))
+	require.Equal(t, 1, eta.Running)
+	require.Equal(t, 0, eta.Pending)
+	require.Equal(t, 0, eta.Unknown)
+	// the run takes longer than its build
+	require.InDelta(t, 9*time.Minute, eta.Remaining, float64(10*time.Second))
+	require.InDelta(t, 0.1, eta.Progress, 0.01)
+}
@@ -0,0 +1,210 @@
+package webui
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+// StreamProtocol is the version of the protocol spoken over the stream
+// endpoint. It's bumped for changes that older clients can't ignore.
+//
+// The stream lets external UIs, like browser dashboards and IDE plugins,
+// render the same live tree as the TUI. Messages are JSON objects over a
+// WebSocket, each with a "protocol" and a "type":
+//
+//   - The client starts by sending a "subscribe" message with the versions of
+//     the spans it already has and how many bytes of each span's logs it has
+//     seen, keyed by span ID. Both are empty for a new client; a client
+//     reconnecting after a dropped connection sends what it had, to catch up
+//     on only what it missed.
+//
+//   - The server then sends a "spans" message with the snapshots of the spans
+//     whose Version differs from the client's, and a "logs" message with the
+//     logs written since the client's offsets, followed by more of each as the
+//     spans change and logs are written.
+//
+//   - If the server can't speak the client's protocol, it sends an "error"
+//     message and closes the connection.
+const StreamProtocol = 1
+
+// Types of stream messages.
+const (
+	StreamSubscribe = "subscribe"
+	StreamSpans     = "spans"
+	StreamLogs      = "logs"
+	StreamError     = "error"
+)
+
+// StreamRequest is the message a client subscribes to the stream with.
+type StreamRequest struct {
+	Protocol int    `json:"protocol"`
+	Type     string `json:"type"`
+
+	// Versions are the versions of the spans the client has, keyed by span
+	// ID.
+	Versions map[string]int `json:"versions,omitempty"`
+
+	// LogOffsets are the number of bytes of each span's logs the client has
+	// seen, keyed by span ID.
+	LogOffsets map[string]int `json:"logOffsets,omitempty"`
+}
+
+// StreamMessage is a message sent by the server.
+type StreamMessage struct {
+	Protocol int    `json:"protocol"`
+	Type     string `json:"type"`
+
+	// PrimarySpan is the span the tree is rooted at, set on spans messages.
+	PrimarySpan dagui.SpanID `json:"primarySpan"`
+
+	// Spans are snapshots of spans that changed, set on spans messages.
+	Spans []dagui.SpanSnapshot `json:"spans,omitempty"`
+
+	// Logs are the logs written to each span since the client last saw
+	// them, keyed by span ID, set on logs messages. Only the last
+	// dagui.LogTailSize bytes of each span's logs are kept, so a client that
+	// falls further behind skips ahead, and its offset jumps accordingly.
+	Logs map[string]StreamLogChunk `json:"logs,omitempty"`
+
+	// Error describes what went wrong, set on error messages.
+	Error string `json:"error,omitempty"`
+}
+
+// StreamLogChunk is logs written to a span.
+type StreamLogChunk struct {
+	// Offset is the position of the data in the span's logs, in bytes.
+	Offset int `json:"offset"`
+
+	// Data is the logs written from the offset on.
+	Data string `json:"data"`
+}
+
+// streamSubscribeTimeout is how long clients have to subscribe after
+// connecting.
+const streamSubscribeTimeout = 10 * time.Second
+
+// streamUpgrader only accepts connections from pages served by the web UI
+// itself, so that other sites can't read the trace and its logs.
+var streamUpgrader = websocket.Upgrader{}
+
+func (s *Server) serveStream(w http.ResponseWriter, r *http.Request) {
+	conn, err := streamUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has already responded
+		return
+	}
+	defer conn.Close()
+
+	var req StreamRequest
+	conn.SetReadDeadline(time.Now().Add(streamSubscribeTimeout))
+	if err := conn.ReadJSON(&req); err != nil {
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+	if req.Type != StreamSubscribe || req.Protocol != StreamProtocol {
+		conn.WriteJSON(StreamMessage{
+			Protocol: StreamProtocol,
+			Type:     StreamError,
+			Error: fmt.Sprintf("expected a %s message with protocol %d, got %s with protocol %d",
+				StreamSubscribe, StreamProtocol, req.Type, req.Protocol),
+		})
+		return
+	}
+	versions, err := parseSpanKeys(req.Versions)
+	if err != nil {
+		conn.WriteJSON(StreamMessage{Protocol: StreamProtocol, Type: StreamError, Error: err.Error()})
+		return
+	}
+	offsets, err := parseSpanKeys(req.LogOffsets)
+	if err != nil {
+		conn.WriteJSON(StreamMessage{Protocol: StreamProtocol, Type: StreamError, Error: err.Error()})
+		return
+	}
+
+	// nothing more is expected from the client, but reading is needed to
+	// notice when it goes away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(streamInterval)
+	defer ticker.Stop()
+	for {
+		spans := s.changedSince(versions)
+		if len(spans.Spans) > 0 {
+			if err := conn.WriteJSON(StreamMessage{
+				Protocol:    StreamProtocol,
+				Type:        StreamSpans,
+				PrimarySpan: spans.PrimarySpan,
+				Spans:       spans.Spans,
+			}); err != nil {
+				return
+			}
+		}
+		if logs := s.logsSince(offsets); len(logs) > 0 {
+			if err := conn.WriteJSON(StreamMessage{
+				Protocol: StreamProtocol,
+				Type:     StreamLogs,
+				Logs:     logs,
+			}); err != nil {
+				return
+			}
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-closed:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// logsSince returns the logs written since the given offsets, advancing them.
+func (s *Server) logsSince(offsets map[dagui.SpanID]int) map[string]StreamLogChunk {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushLocked()
+	var logs map[string]StreamLogChunk
+	for spanID, total := range s.logTotals {
+		offset := offsets[spanID]
+		if offset >= total {
+			continue
+		}
+		tail := s.db.LogTails[spanID]
+		// the tail holds the last len(tail) bytes of the logs
+		start := max(offset, total-len(tail))
+		if logs == nil {
+			logs = map[string]StreamLogChunk{}
+		}
+		logs[spanID.String()] = StreamLogChunk{
+			Offset: start,
+			Data:   string(tail[len(tail)-(total-start):]),
+		}
+		offsets[spanID] = total
+	}
+	return logs
+}
+
+func parseSpanKeys(vals map[string]int) (map[dagui.SpanID]int, error) {
+	parsed := make(map[dagui.SpanID]int, len(vals))
+	for key, val := range vals {
+		spanID, err := parseSpanID(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid span ID %q: %w", key, err)
+		}
+		parsed[spanID] = val
+	}
+	return parsed, nil
+}
@@ -0,0 +1,124 @@
+package webui
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+func TestStream(t *testing.T) {
+	traceID := dagui.TraceID{TraceID: trace.TraceID{1}}
+	root := dagui.SpanID{SpanID: trace.SpanID{1}}
+	child := dagui.SpanID{SpanID: trace.SpanID{2}}
+	start := time.Now().Add(-time.Minute)
+	db := dagui.NewDB()
+	db.SetPrimarySpan(root)
+	db.ImportSnapshots([]dagui.SpanSnapshot{{
+		ID:        root,
+		TraceID:   traceID,
+		Name:      "root",
+		StartTime: start,
+	}, {
+		ID:        child,
+		TraceID:   traceID,
+		ParentID:  root,
+		Name:      "child",
+		StartTime: start,
+		EndTime:   start.Add(time.Second),
+	}})
+	srv := NewServer(db)
+	writeLogs := func(spanID dagui.SpanID, body string) {
+		var rec sdklog.Record
+		rec.SetSpanID(spanID.SpanID)
+		rec.SetBody(log.StringValue(body))
+		require.NoError(t, srv.LogExporter().Export(context.Background(), []sdklog.Record{rec}))
+	}
+	writeLogs(child, "hello\n")
+
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	dial := func(t *testing.T, req StreamRequest) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/api/stream", nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		require.NoError(t, conn.WriteJSON(req))
+		return conn
+	}
+	read := func(t *testing.T, conn *websocket.Conn) StreamMessage {
+		var msg StreamMessage
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		require.NoError(t, conn.ReadJSON(&msg))
+		require.Equal(t, StreamProtocol, msg.Protocol)
+		return msg
+	}
+
+	t.Run("cross-origin", func(t *testing.T) {
+		header := http.Header{"Origin": []string{"https://example.com"}}
+		_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/api/stream", header)
+		require.ErrorIs(t, err, websocket.ErrBadHandshake)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		header = http.Header{"Origin": []string{ts.URL}}
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/api/stream", header)
+		require.NoError(t, err)
+		conn.Close()
+	})
+
+	t.Run("subscribe", func(t *testing.T) {
+		conn := dial(t, StreamRequest{Protocol: StreamProtocol, Type: StreamSubscribe})
+		msg := read(t, conn)
+		require.Equal(t, StreamSpans, msg.Type)
+		require.Equal(t, root, msg.PrimarySpan)
+		require.Len(t, msg.Spans, 2)
+		msg = read(t, conn)
+		require.Equal(t, StreamLogs, msg.Type)
+		require.Equal(t, map[string]StreamLogChunk{
+			child.String(): {Offset: 0, Data: "hello\n"},
+		}, msg.Logs)
+
+		// later logs are sent as they're written
+		writeLogs(child, "world\n")
+		msg = read(t, conn)
+		require.Equal(t, StreamLogs, msg.Type)
+		require.Equal(t, map[string]StreamLogChunk{
+			child.String(): {Offset: 6, Data: "world\n"},
+		}, msg.Logs)
+	})
+
+	t.Run("reconnect", func(t *testing.T) {
+		versions := map[string]int{}
+		for _, span := range db.Spans.Order {
+			versions[span.ID.String()] = span.Snapshot().Version
+		}
+		conn := dial(t, StreamRequest{
+			Protocol:   StreamProtocol,
+			Type:       StreamSubscribe,
+			Versions:   versions,
+			LogOffsets: map[string]int{child.String(): 6},
+		})
+		// only what was missed is sent
+		msg := read(t, conn)
+		require.Equal(t, StreamLogs, msg.Type)
+		require.Equal(t, map[string]StreamLogChunk{
+			child.String(): {Offset: 6, Data: "world\n"},
+		}, msg.Logs)
+	})
+
+	t.Run("protocol mismatch", func(t *testing.T) {
+		conn := dial(t, StreamRequest{Protocol: StreamProtocol + 1, Type: StreamSubscribe})
+		msg := read(t, conn)
+		require.Equal(t, StreamError, msg.Type)
+		require.NotEmpty(t, msg.Error)
+	})
+}
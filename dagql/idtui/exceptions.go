@@ -0,0 +1,97 @@
+package idtui
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/muesli/termenv"
+
+	"github.com/dagger/dagger/dagql/call/callpbv1"
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+// moduleSourceRoot is where module runtimes mount the module's context
+// directory.
+const moduleSourceRoot = "/src/"
+
+var (
+	// File "/src/main.py", line 12, in build
+	pythonFrameRe = regexp.MustCompile(`File "([^"]+)", line (\d+)`)
+	// at build (/src/index.ts:12:5), /src/main.go:12 +0x1d
+	pathLineFrameRe = regexp.MustCompile(`(/[^\s():]+):(\d+)`)
+)
+
+// renderExceptions renders the exceptions recorded by the span, highlighting
+// stack frames in the module's own source and linking them to the module
+// source when it is hosted in a known forge.
+func (r *renderer) renderExceptions(out *termenv.Output, span *dagui.Span, prefix string, depth int) {
+	var module *callpbv1.Module
+	if span.Call != nil {
+		module = span.Call.Module
+	}
+	for _, exc := range span.Exceptions {
+		fmt.Fprint(out, prefix)
+		r.indent(out, depth)
+		header := exc.Message
+		if exc.Type != "" {
+			header = exc.Type + ": " + exc.Message
+		}
+		fmt.Fprintln(out, out.String(header).Foreground(termenv.ANSIRed).Bold())
+		for _, line := range strings.Split(strings.TrimRight(exc.Stacktrace, "\n"), "\n") {
+			if line == "" {
+				continue
+			}
+			fmt.Fprint(out, prefix)
+			r.indent(out, depth)
+			fmt.Fprintln(out, renderStackFrame(out, line, module))
+		}
+	}
+}
+
+func renderStackFrame(out *termenv.Output, line string, module *callpbv1.Module) string {
+	match := pythonFrameRe.FindStringSubmatchIndex(line)
+	if match == nil {
+		match = pathLineFrameRe.FindStringSubmatchIndex(line)
+	}
+	if match == nil {
+		return out.String(line).Faint().String()
+	}
+	file := line[match[2]:match[3]]
+	lineNo := line[match[4]:match[5]]
+	if !strings.HasPrefix(file, moduleSourceRoot) {
+		// frames outside of the module's source are usually library noise
+		return out.String(line).Faint().String()
+	}
+	// highlight the file and line, keeping the surrounding text as-is
+	loc := out.String(file).Foreground(termenv.ANSICyan).String() +
+		line[match[3]:match[4]] +
+		out.String(lineNo).Foreground(termenv.ANSICyan).String()
+	if url := moduleSourceURL(module, strings.TrimPrefix(file, moduleSourceRoot), lineNo); url != "" {
+		loc = out.Hyperlink(url, loc)
+	}
+	return line[:match[2]] + loc + line[match[5]:]
+}
+
+// moduleSourceURL links a file in a module's context directory to its source
+// at the module's pinned commit, for modules hosted on GitHub or GitLab.
+func moduleSourceURL(module *callpbv1.Module, file string, line string) string {
+	if module == nil || module.Pin == "" {
+		return ""
+	}
+	ref, _, _ := strings.Cut(module.Ref, "@")
+	parts := strings.SplitN(ref, "/", 4)
+	if len(parts) < 3 {
+		return ""
+	}
+	repo := strings.TrimSuffix(strings.Join(parts[:3], "/"), ".git")
+	switch parts[0] {
+	case "github.com":
+		return fmt.Sprintf("https://%s/blob/%s#L%s", repo, path.Join(module.Pin, file), line)
+	case "gitlab.com":
+		return fmt.Sprintf("https://%s/-/blob/%s#L%s", repo, path.Join(module.Pin, file), line)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,69 @@
+package idtui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muesli/termenv"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dagger/dagger/dagql/call/callpbv1"
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+func TestModuleSourceURL(t *testing.T) {
+	pinned := func(ref string) *callpbv1.Module {
+		return &callpbv1.Module{Ref: ref, Pin: "abc123"}
+	}
+	require.Equal(t, "https://github.com/acme/ci/blob/abc123/src/main.py#L12",
+		moduleSourceURL(pinned("github.com/acme/ci.git/ci@v1"), "src/main.py", "12"))
+	require.Equal(t, "https://gitlab.com/acme/ci/-/blob/abc123/index.ts#L3",
+		moduleSourceURL(pinned("gitlab.com/acme/ci"), "index.ts", "3"))
+	// no link for unknown forges, local modules, or unpinned modules
+	require.Empty(t, moduleSourceURL(pinned("example.com/acme/ci"), "main.go", "1"))
+	require.Empty(t, moduleSourceURL(pinned("./ci"), "main.go", "1"))
+	require.Empty(t, moduleSourceURL(&callpbv1.Module{Ref: "github.com/acme/ci"}, "main.go", "1"))
+	require.Empty(t, moduleSourceURL(nil, "main.go", "1"))
+}
+
+func TestRenderExceptions(t *testing.T) {
+	start := time.Now().Add(-time.Minute)
+	db := dagui.NewDB()
+	db.ImportSnapshots([]dagui.SpanSnapshot{{
+		ID:        dagui.SpanID{SpanID: trace.SpanID{1}},
+		TraceID:   dagui.TraceID{TraceID: trace.TraceID{1}},
+		Name:      "build",
+		StartTime: start,
+		EndTime:   start.Add(time.Second),
+		Exceptions: []dagui.SpanException{{
+			Type:    "ValueError",
+			Message: "bad input",
+			Stacktrace: "Traceback (most recent call last):\n" +
+				`  File "/usr/lib/python3/site-packages/dagger/mod.py", line 80, in call` + "\n" +
+				`  File "/src/main.py", line 12, in build` + "\n",
+		}},
+	}})
+	span := db.Spans.Map[dagui.SpanID{SpanID: trace.SpanID{1}}]
+
+	var buf strings.Builder
+	out := termenv.NewOutput(&buf, termenv.WithProfile(termenv.Ascii))
+	newRenderer(db, 0, dagui.FrontendOpts{}).renderExceptions(out, span, "", 0)
+	require.Equal(t, []string{
+		"ValueError: bad input",
+		"Traceback (most recent call last):",
+		// library frames are left as-is
+		`  File "/usr/lib/python3/site-packages/dagger/mod.py", line 80, in call`,
+		`  File "/src/main.py", line 12, in build`,
+	}, strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"))
+
+	// frames in the module's source link to it
+	module := &callpbv1.Module{Ref: "github.com/acme/ci", Pin: "abc123"}
+	frame := renderStackFrame(out, `  File "/src/main.py", line 12, in build`, module)
+	require.Equal(t,
+		`  File "`+out.Hyperlink("https://github.com/acme/ci/blob/abc123/main.py#L12", `/src/main.py", line 12`)+`, in build`,
+		frame)
+	frame = renderStackFrame(out, "    at build (/src/index.ts:7:5)", module)
+	require.Contains(t, frame, out.Hyperlink("https://github.com/acme/ci/blob/abc123/index.ts#L7", "/src/index.ts:7"))
+}
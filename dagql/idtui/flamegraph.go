@@ -0,0 +1,208 @@
+package idtui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/muesli/termenv"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+// flameBar is a node of the flamegraph laid out on screen.
+type flameBar struct {
+	node *dagui.FlameNode
+	x, w int
+}
+
+// flameRoot returns the flamegraph of the current view, or of the subtree
+// zoomed into within the flamegraph.
+func (fe *frontendPretty) flameRoot(now time.Time) *dagui.FlameNode {
+	if tree := fe.rowsView.BySpan[fe.flameZoomed]; tree != nil {
+		return dagui.NewFlamegraph(tree.Span, tree.Children, now)
+	}
+	return dagui.NewFlamegraph(fe.rowsView.Zoomed, fe.rowsView.Body, now)
+}
+
+// flameFocus returns the focused node of the flamegraph, falling back to its
+// root if the focused span isn't in it.
+func (fe *frontendPretty) flameFocus(root *dagui.FlameNode) *dagui.FlameNode {
+	if focused := root.Find(fe.flameFocused); focused != nil {
+		return focused
+	}
+	return root
+}
+
+// renderFlamegraph renders the view as an icicle-style flamegraph: the root
+// spans the full width, and each span's children are laid out below it, with
+// widths proportional to the time spent under them.
+func (fe *frontendPretty) renderFlamegraph(out *termenv.Output, r *renderer, height int, prefix string) {
+	if fe.rowsView == nil || height < 2 {
+		return
+	}
+	root := fe.flameRoot(r.now)
+	focused := fe.flameFocus(root)
+	width := fe.window.Width - len(prefix)
+
+	// leave room for the summary of the focused span
+	depthLimit := height - 1
+	var levels [][]flameBar
+	var layout func(node *dagui.FlameNode, depth, x, w int)
+	layout = func(node *dagui.FlameNode, depth, x, w int) {
+		if w < 1 || depth >= depthLimit {
+			return
+		}
+		if len(levels) <= depth {
+			levels = append(levels, nil)
+		}
+		levels[depth] = append(levels[depth], flameBar{node: node, x: x, w: w})
+		if node.Total <= 0 {
+			return
+		}
+		// place each child by its cumulative offset so rounding errors
+		// don't add up across siblings
+		var before time.Duration
+		for _, child := range node.Children {
+			from := x + int(int64(w)*int64(before)/int64(node.Total))
+			before += child.Total
+			to := x + int(int64(w)*int64(before)/int64(node.Total))
+			layout(child, depth+1, from, to-from)
+		}
+	}
+	layout(root, 0, 0, width)
+
+	lines := []string{prefix + fe.flameSummary(out, focused)}
+	for _, bars := range levels {
+		line := new(strings.Builder)
+		col := 0
+		for i, bar := range bars {
+			line.WriteString(strings.Repeat(" ", bar.x-col))
+			line.WriteString(fe.renderFlameBar(out, bar, i, bar.node == focused))
+			col = bar.x + bar.w
+		}
+		lines = append(lines, prefix+line.String())
+	}
+	fmt.Fprint(out, strings.Join(lines, "\n"))
+}
+
+// flameSummary describes the focused node, since its bar may be too narrow
+// to fit its name.
+func (fe *frontendPretty) flameSummary(out *termenv.Output, node *dagui.FlameNode) string {
+	summary := new(strings.Builder)
+	fmt.Fprint(summary, out.String(flameLabel(node)).Bold())
+	fmt.Fprint(summary, out.String(fmt.Sprintf("  total %s  self %s",
+		dagui.FormatDuration(node.Total),
+		dagui.FormatDuration(node.Self))).Faint())
+	return summary.String()
+}
+
+func (fe *frontendPretty) renderFlameBar(out *termenv.Output, bar flameBar, idx int, focused bool) string {
+	label := []rune(" " + flameLabel(bar.node) + " " + dagui.FormatDuration(bar.node.Total))
+	if len(label) > bar.w {
+		label = label[:bar.w]
+	}
+	text := string(label) + strings.Repeat(" ", bar.w-len(label))
+	style := out.String(text).
+		Foreground(termenv.ANSIBlack).
+		Background(flameColor(bar.node, idx))
+	if focused {
+		style = style.Bold().Reverse()
+	} else if span := bar.node.Span; span != nil &&
+		fe.criticalPath != nil && fe.criticalPath.Map[span.ID] == nil {
+		style = style.Faint()
+	}
+	return style.String()
+}
+
+func flameLabel(node *dagui.FlameNode) string {
+	if node.Span == nil {
+		return "total"
+	}
+	return node.Span.Name
+}
+
+// flameColor returns the color of a bar, highlighting failed, running, and
+// cached spans, and alternating otherwise so that siblings stand apart.
+func flameColor(node *dagui.FlameNode, idx int) termenv.Color {
+	span := node.Span
+	switch {
+	case span == nil:
+		return termenv.ANSIBrightBlack
+	case span.IsFailedOrCausedFailure():
+		return termenv.ANSIRed
+	case span.IsRunningOrEffectsRunning():
+		return termenv.ANSIYellow
+	case span.Cached:
+		return termenv.ANSIBlue
+	case idx%2 == 0:
+		return termenv.ANSIGreen
+	default:
+		return termenv.ANSIBrightGreen
+	}
+}
+
+// flameKey handles navigation within the flamegraph, returning false for keys
+// that it doesn't handle.
+func (fe *frontendPretty) flameKey(key string) bool {
+	root := fe.flameRoot(time.Now())
+	focused := fe.flameFocus(root)
+	switch key {
+	case "up", "k":
+		if focused.Parent != nil {
+			fe.flameFocusOn(focused.Parent)
+		} else if tree := fe.rowsView.BySpan[fe.flameZoomed]; tree != nil {
+			// at the top of a zoomed subtree; zoom out a level
+			fe.flameZoomed = dagui.SpanID{}
+			if tree.Parent != nil {
+				fe.flameZoomed = tree.Parent.Span.ID
+			}
+		}
+	case "down", "j":
+		// follow the child that took the most time
+		var next *dagui.FlameNode
+		for _, child := range focused.Children {
+			if next == nil || child.Total > next.Total {
+				next = child
+			}
+		}
+		fe.flameFocusOn(next)
+	case "left", "h", "right", "l":
+		if focused.Parent == nil {
+			return true
+		}
+		siblings := focused.Parent.Children
+		for i, sibling := range siblings {
+			if sibling != focused {
+				continue
+			}
+			if key == "left" || key == "h" {
+				if i > 0 {
+					fe.flameFocusOn(siblings[i-1])
+				}
+			} else if i < len(siblings)-1 {
+				fe.flameFocusOn(siblings[i+1])
+			}
+			break
+		}
+	case "enter":
+		if focused.Span != nil && len(focused.Children) > 0 {
+			fe.flameZoomed = focused.Span.ID
+		}
+	case "esc":
+		if !fe.flameZoomed.IsValid() {
+			return false
+		}
+		fe.flameZoomed = dagui.SpanID{}
+	default:
+		return false
+	}
+	return true
+}
+
+func (fe *frontendPretty) flameFocusOn(node *dagui.FlameNode) {
+	if node == nil || node.Span == nil {
+		return
+	}
+	fe.flameFocused = node.Span.ID
+}
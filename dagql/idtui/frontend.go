@@ -67,6 +67,14 @@ type Frontend interface {
 	SetCustomExit(fn func())
 	SetVerbosity(n int)
 
+	// SetCancelSpan tells the frontend how to cancel individual spans, once
+	// connected to an engine.
+	SetCancelSpan(fn func(context.Context, dagui.SpanID) error)
+
+	// SetPauseRun tells the frontend how to pause and resume the run, once
+	// connected to an engine.
+	SetPauseRun(fn func(ctx context.Context, paused bool) error)
+
 	// SetPrimary tells the frontend which span should be treated like the focal
 	// point of the command. Its output will be displayed at the end, and its
 	// children will be promoted to the "top-level" of the TUI.
@@ -76,6 +84,10 @@ type Frontend interface {
 	// the spans beneath the primary span.
 	RevealAllSpans()
 
+	// DB returns the database of telemetry received by the frontend. It is
+	// only safe to use once Run has returned.
+	DB() *dagui.DB
+
 	// Can consume otel spans, logs and metrics.
 	SpanExporter() sdktrace.SpanExporter
 	LogExporter() sdklog.Exporter
@@ -263,8 +275,15 @@ func (r *renderer) renderCall(
 
 	if span != nil {
 		r.renderDuration(out, span)
+		r.renderExportProgress(out, span)
+		r.renderConnections(out, span)
 		r.renderMetrics(out, span)
 		r.renderCached(out, span)
+		r.renderErrorCategory(out, span)
+		r.renderFailureTolerance(out, span)
+		r.renderSkipReason(out, span)
+		r.renderCancelCause(out, span)
+		r.renderDaemonHealth(out, span)
 	}
 
 	return nil
@@ -294,8 +313,15 @@ func (r *renderer) renderSpan(
 		// TODO: when a span has child spans that have progress, do 2-d progress
 		// fe.renderVertexTasks(out, span, depth)
 		r.renderDuration(out, span)
+		r.renderExportProgress(out, span)
+		r.renderConnections(out, span)
 		r.renderMetrics(out, span)
 		r.renderCached(out, span)
+		r.renderErrorCategory(out, span)
+		r.renderFailureTolerance(out, span)
+		r.renderSkipReason(out, span)
+		r.renderCancelCause(out, span)
+		r.renderDaemonHealth(out, span)
 	}
 
 	return nil
@@ -345,29 +371,7 @@ func (r *renderer) renderLiteral(out *termenv.Output, lit *callpbv1.Literal) {
 }
 
 func (r *renderer) renderStatus(out *termenv.Output, span *dagui.Span, focused bool) {
-	var symbol string
-	var color termenv.Color
-	switch {
-	case span.IsRunningOrEffectsRunning():
-		symbol = DotFilled
-		color = termenv.ANSIYellow
-	case span.IsCached():
-		symbol = IconCached
-		color = termenv.ANSIBlue
-	case span.Canceled:
-		symbol = IconSkipped
-		color = termenv.ANSIBrightBlack
-	case span.IsFailedOrCausedFailure():
-		symbol = IconFailure
-		color = termenv.ANSIRed
-	case span.IsPending():
-		symbol = DotEmpty
-		color = termenv.ANSIBrightBlack
-	default:
-		symbol = IconSuccess
-		color = termenv.ANSIGreen
-	}
-
+	symbol, color := r.statusGlyph(span)
 	style := out.String(symbol).Foreground(color)
 	if focused {
 		style = style.Reverse()
@@ -381,15 +385,165 @@ func (r *renderer) renderStatus(out *termenv.Output, span *dagui.Span, focused b
 	}
 }
 
+// statusGlyph returns the configured glyph for the span's status, along with
+// its color.
+func (r *renderer) statusGlyph(span *dagui.Span) (string, termenv.Color) {
+	glyphs := r.Glyphs.OrDefault()
+	switch {
+	case span.IsCanceled():
+		// checked first, since spans left running when their run ended are
+		// canceled
+		return glyphs.Skipped, termenv.ANSIBrightBlack
+	case span.IsRunningOrEffectsRunning():
+		return glyphs.Running, termenv.ANSIYellow
+	case span.IsCached():
+		return glyphs.Cached, termenv.ANSIBlue
+	case span.IsSkipped():
+		return glyphs.Skipped, termenv.ANSIBrightBlack
+	case span.IsQuarantinedFailure(r.Quarantine):
+		return glyphs.Failure, termenv.ANSIYellow
+	case span.IsToleratedFailure():
+		return glyphs.Failure, termenv.ANSIMagenta
+	case span.IsUnexpectedPass():
+		return glyphs.Success, termenv.ANSIYellow
+	case span.IsFailedOrCausedFailure():
+		return glyphs.Failure, termenv.ANSIRed
+	case span.IsPending():
+		return glyphs.Pending, termenv.ANSIBrightBlack
+	default:
+		return glyphs.Success, termenv.ANSIGreen
+	}
+}
+
 func (r *renderer) renderDuration(out *termenv.Output, span *dagui.Span) {
 	fmt.Fprint(out, " ")
-	duration := out.String(dagui.FormatDuration(span.Activity.Duration(r.now)))
-	if span.IsRunningOrEffectsRunning() {
+	duration := out.String(dagui.FormatDuration(span.ActiveDuration(r.now)))
+	threshold, slow := r.SlowThresholds.IsSlow(span, r.now)
+	switch {
+	case slow:
+		duration = duration.Foreground(termenv.ANSIRed).Bold()
+	case span.IsRunningOrEffectsRunning():
 		duration = duration.Foreground(termenv.ANSIYellow)
-	} else {
+	default:
 		duration = duration.Faint()
 	}
 	fmt.Fprint(out, duration)
+	if slow {
+		fmt.Fprint(out, out.String(fmt.Sprintf(" SLOW (over %s)", dagui.FormatDuration(threshold))).Foreground(termenv.ANSIRed))
+	}
+	if span.Corrected != "" {
+		// the duration isn't what was received, so don't pass it off as real
+		fmt.Fprint(out, out.String("*").Foreground(termenv.ANSIYellow))
+	}
+	if span.IsRunningOrEffectsRunning() {
+		r.renderETA(out, span)
+		r.renderDeadline(out, span.Deadline)
+	}
+}
+
+// renderDeadline renders the time remaining before a running span times out.
+func (r *renderer) renderDeadline(out *termenv.Output, deadline time.Time) {
+	if deadline.IsZero() {
+		return
+	}
+	remaining := max(deadline.Sub(r.now), 0)
+	color := termenv.ANSIYellow
+	if remaining < time.Minute {
+		color = termenv.ANSIRed
+	}
+	fmt.Fprint(out, out.String(fmt.Sprintf(" times out in %s", dagui.FormatDuration(remaining))).Foreground(color))
+}
+
+// renderETA renders the estimated time remaining for a running span, based on
+// how long it took in previous runs.
+func (r *renderer) renderETA(out *termenv.Output, span *dagui.Span) {
+	remaining, progress, ok := r.Durations.ETA(span, r.now)
+	if !ok {
+		return
+	}
+	eta := fmt.Sprintf(" ~%s left (%d%%)", dagui.FormatDuration(remaining), int(progress*100))
+	fmt.Fprint(out, out.String(eta).Faint())
+}
+
+// renderMatrix renders the pass/fail grid of a matrix span's cells, with a
+// row for each combination of all but the last axis.
+// renderAggregate renders a summary of the spans of a call grouped into a
+// single row.
+func (r *renderer) renderAggregate(out *termenv.Output, agg *dagui.CallAggregate, prefix string, depth int) {
+	fmt.Fprint(out, prefix)
+	r.indent(out, depth+1)
+	summary := fmt.Sprintf("×%d", agg.Count())
+	if completed := agg.Count() - agg.Running; completed > 0 {
+		summary += fmt.Sprintf(" min %s avg %s max %s",
+			dagui.FormatDuration(agg.Min),
+			dagui.FormatDuration(agg.Avg),
+			dagui.FormatDuration(agg.Max))
+	}
+	fmt.Fprint(out, out.String(summary).Faint())
+	if agg.Running > 0 {
+		fmt.Fprint(out, out.String(fmt.Sprintf(" %d running", agg.Running)).Foreground(termenv.ANSIYellow))
+	}
+	if agg.Failed > 0 {
+		fmt.Fprint(out, out.String(fmt.Sprintf(" %d failed", agg.Failed)).Foreground(termenv.ANSIRed))
+	}
+	fmt.Fprintln(out)
+}
+
+func (r *renderer) renderMatrix(out *termenv.Output, span *dagui.Span, prefix string, depth int) {
+	matrix := span.Matrix()
+	if matrix == nil || len(matrix.Rows) == 0 {
+		return
+	}
+	cols := matrix.Axes[len(matrix.Axes)-1].Values
+	var rowAxes []string
+	for _, axis := range matrix.Axes[:len(matrix.Axes)-1] {
+		rowAxes = append(rowAxes, axis.Name)
+	}
+	header := strings.Join(rowAxes, " ")
+	width := lipgloss.Width(header)
+	labels := make([]string, len(matrix.Rows))
+	for i, row := range matrix.Rows {
+		labels[i] = strings.Join(row.Labels, " ")
+		width = max(width, lipgloss.Width(labels[i]))
+	}
+	pad := func(str string, width int) string {
+		return str + strings.Repeat(" ", max(width-lipgloss.Width(str), 0))
+	}
+
+	fmt.Fprint(out, prefix)
+	r.indent(out, depth)
+	fmt.Fprint(out, out.String(pad(header, width)).Faint())
+	for _, col := range cols {
+		fmt.Fprintf(out, " %s", out.String(col).Faint())
+	}
+	fmt.Fprintln(out)
+	for i, row := range matrix.Rows {
+		fmt.Fprint(out, prefix)
+		r.indent(out, depth)
+		fmt.Fprint(out, pad(labels[i], width))
+		for j, cell := range row.Cells {
+			glyph, color := " ", termenv.Color(termenv.ANSIBrightBlack)
+			if cell != nil {
+				glyph, color = r.statusGlyph(cell)
+			}
+			fmt.Fprintf(out, " %s", out.String(pad(glyph, lipgloss.Width(cols[j]))).Foreground(color))
+		}
+		fmt.Fprintln(out)
+	}
+}
+
+// renderEvents renders a line for each of the span's events, marking the time
+// at which it occurred relative to the start of the span.
+func (r *renderer) renderEvents(out *termenv.Output, span *dagui.Span, prefix string, depth int) {
+	for _, event := range span.Events {
+		fmt.Fprint(out, prefix)
+		r.indent(out, depth)
+		fmt.Fprintf(out, "%s %s %s\n",
+			out.String(DiamondFilled).Foreground(termenv.ANSIMagenta),
+			out.String(fmt.Sprintf("[%s]", dagui.FormatDuration(event.Time.Sub(span.StartTime)))).Faint(),
+			event.Name,
+		)
+	}
 }
 
 func (r *renderer) renderCached(out *termenv.Output, span *dagui.Span) {
@@ -399,6 +553,113 @@ func (r *renderer) renderCached(out *termenv.Output, span *dagui.Span) {
 	}
 }
 
+func (r *renderer) renderErrorCategory(out *termenv.Output, span *dagui.Span) {
+	switch span.ErrorCategory {
+	case telemetry.ErrorCategoryCrash:
+		fmt.Fprintf(out, " %s", out.String("CRASHED").
+			Foreground(termenv.ANSIRed).Bold())
+	case telemetry.ErrorCategoryCircuitOpen:
+		fmt.Fprintf(out, " %s", out.String("CIRCUIT OPEN").
+			Foreground(termenv.ANSIYellow).Bold())
+	case telemetry.ErrorCategorySecretLeak:
+		fmt.Fprintf(out, " %s", out.String("SECRETS FOUND").
+			Foreground(termenv.ANSIRed).Bold())
+	}
+}
+
+// renderFailureTolerance labels the outcome of steps allowed or expected to
+// fail, like a test framework would.
+func (r *renderer) renderFailureTolerance(out *termenv.Output, span *dagui.Span) {
+	var label string
+	var color termenv.Color
+	switch {
+	case span.IsUnexpectedPass():
+		label, color = "XPASS", termenv.ANSIYellow
+	case span.FailureExpected && span.IsFailedOrCausedFailure():
+		label, color = "XFAIL", termenv.ANSIMagenta
+	case span.FailureAllowed && span.IsFailedOrCausedFailure():
+		label, color = "ALLOWED", termenv.ANSIMagenta
+	default:
+		return
+	}
+	fmt.Fprintf(out, " %s", out.String(label).Foreground(color).Bold())
+}
+
+// renderSkipReason renders why a skipped span was skipped.
+func (r *renderer) renderSkipReason(out *termenv.Output, span *dagui.Span) {
+	if !span.IsSkipped() {
+		return
+	}
+	_, reason := span.SkipReason()
+	fmt.Fprintf(out, " %s %s", out.String("SKIPPED").Foreground(termenv.ANSIBrightBlack).Bold(),
+		out.String(reason).Faint())
+}
+
+// renderCancelCause renders why a canceled span was canceled.
+func (r *renderer) renderCancelCause(out *termenv.Output, span *dagui.Span) {
+	if !span.IsCanceled() {
+		return
+	}
+	fmt.Fprintf(out, " %s", out.String("CANCELED").Foreground(termenv.ANSIBrightBlack).Bold())
+	if cause := span.CancelCause(); cause.By != "" {
+		fmt.Fprintf(out, " %s", out.String(cause.String()).Faint())
+	}
+}
+
+// renderDaemonHealth renders the health of a daemon's service.
+func (r *renderer) renderDaemonHealth(out *termenv.Output, span *dagui.Span) {
+	if !span.Daemon || span.DaemonHealth == "" {
+		return
+	}
+	color := termenv.ANSIGreen
+	switch span.DaemonHealth {
+	case telemetry.DaemonUnhealthy:
+		color = termenv.ANSIRed
+	case telemetry.DaemonStopped:
+		color = termenv.ANSIBrightBlack
+	}
+	fmt.Fprintf(out, " %s", out.String(strings.ToUpper(span.DaemonHealth)).Foreground(color).Bold())
+}
+
+// renderExportProgress renders how much of a file exported to the host was
+// sent, or its size once sent.
+func (r *renderer) renderExportProgress(out *termenv.Output, span *dagui.Span) {
+	if span.CallDigest == "" {
+		return
+	}
+	metricsByName := r.db.MetricsByCall[span.CallDigest]
+	sent, total := metricsByName[telemetry.ExportSentBytes], metricsByName[telemetry.ExportTotalBytes]
+	if len(sent) == 0 || len(total) == 0 {
+		return
+	}
+	sentBytes, totalBytes := sent[len(sent)-1].Value, total[len(total)-1].Value
+	if span.IsRunning() && sentBytes < totalBytes {
+		progress := fmt.Sprintf(" sent %s of %s (%d%%)", humanizeBytes(sentBytes), humanizeBytes(totalBytes), sentBytes*100/totalBytes)
+		fmt.Fprint(out, out.String(progress).Foreground(termenv.ANSIYellow))
+		return
+	}
+	fmt.Fprint(out, out.String(" "+humanizeBytes(totalBytes)).Faint())
+}
+
+// renderConnections renders how many connections were made through a tunnel
+// to the host, and how many are still open.
+func (r *renderer) renderConnections(out *termenv.Output, span *dagui.Span) {
+	conns := span.Connections
+	if conns == nil {
+		return
+	}
+	label := fmt.Sprintf(" %d connections", conns.Total)
+	if conns.Total == 1 {
+		label = " 1 connection"
+	}
+	if span.IsRunning() && conns.Active > 0 {
+		label += fmt.Sprintf(", %d open", conns.Active)
+		fmt.Fprint(out, out.String(label).Foreground(termenv.ANSIYellow))
+		return
+	}
+	fmt.Fprint(out, out.String(label).Faint())
+}
+
 func (r renderer) renderMetrics(out *termenv.Output, span *dagui.Span) {
 	if r.Verbosity < dagui.ShowMetricsVerbosity {
 		return
@@ -425,6 +686,14 @@ func (r renderer) renderMetrics(out *termenv.Output, span *dagui.Span) {
 	r.renderMetric(out, metricsByName, telemetry.MemoryCurrentBytes, "Memory Bytes (current)", humanizeBytes)
 	r.renderMetric(out, metricsByName, telemetry.MemoryPeakBytes, "Memory Bytes (peak)", humanizeBytes)
 
+	// Payload Sizes
+	r.renderMetricIfNonzero(out, metricsByName, telemetry.CallArgsBytes, "Args Size", humanizeBytes)
+	r.renderMetricIfNonzero(out, metricsByName, telemetry.CallResultBytes, "Result Size", humanizeBytes)
+
+	// Waits
+	r.renderMetricIfNonzero(out, metricsByName, telemetry.LockWaitMicroseconds, "Lock Wait", durationString)
+	r.renderMetricIfNonzero(out, metricsByName, telemetry.RateLimitWaitMicroseconds, "Rate Limit Wait", durationString)
+
 	// Network Stats
 	r.renderNetworkMetric(out, metricsByName, telemetry.NetstatRxBytes, telemetry.NetstatRxDropped, telemetry.NetstatRxPackets, "Network Rx")
 	r.renderNetworkMetric(out, metricsByName, telemetry.NetstatTxBytes, telemetry.NetstatTxDropped, telemetry.NetstatTxPackets, "Network Tx")
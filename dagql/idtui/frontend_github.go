@@ -0,0 +1,33 @@
+package idtui
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+// frontendGitHub displays progress like frontendPlain, and once the run
+// completes, reports its failures as GitHub Actions workflow commands, so
+// that they're shown as annotations in the checks of a pull request.
+type frontendGitHub struct {
+	*frontendPlain
+
+	out io.Writer
+}
+
+func NewGitHub() Frontend {
+	return &frontendGitHub{
+		frontendPlain: NewPlain().(*frontendPlain),
+		out:           os.Stderr,
+	}
+}
+
+func (fe *frontendGitHub) Run(ctx context.Context, opts dagui.FrontendOpts, run func(context.Context) error) error {
+	runErr := fe.frontendPlain.Run(ctx, opts, run)
+	if err := fe.db.WriteGitHubAnnotations(fe.out, opts.Quarantine); err != nil {
+		return err
+	}
+	return runErr
+}
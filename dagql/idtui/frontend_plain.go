@@ -68,6 +68,9 @@ type frontendPlain struct {
 	// ticker keeps a constant frame rate
 	ticker *time.Ticker
 
+	// slowSpans finds spans running longer than their threshold
+	slowSpans dagui.SlowWatcher
+
 	// done is closed during shutdown
 	done     chan struct{}
 	doneOnce sync.Once
@@ -173,6 +176,12 @@ func (fe *frontendPlain) Run(ctx context.Context, opts dagui.FrontendOpts, run f
 		opts.TooFastThreshold = 100 * time.Millisecond
 	}
 	fe.FrontendOpts = opts
+	if opts.Store != nil {
+		if err := fe.db.SetStore(opts.Store); err != nil {
+			slog.Debug("failed to set up trace store", "error", err)
+		}
+	}
+	fe.db.SetRetention(opts.Retention)
 
 	if !fe.Silent {
 		go func() {
@@ -210,6 +219,18 @@ func (fe *frontendPlain) SetCustomExit(fn func()) {
 	fe.mu.Unlock()
 }
 
+func (fe *frontendPlain) SetCancelSpan(fn func(context.Context, dagui.SpanID) error) {
+	fe.mu.Lock()
+	fe.Opts().CancelSpan = fn
+	fe.mu.Unlock()
+}
+
+func (fe *frontendPlain) SetPauseRun(fn func(ctx context.Context, paused bool) error) {
+	fe.mu.Lock()
+	fe.Opts().PauseRun = fn
+	fe.mu.Unlock()
+}
+
 func (fe *frontendPlain) SetVerbosity(n int) {
 	fe.mu.Lock()
 	fe.Opts().Verbosity = n
@@ -218,7 +239,7 @@ func (fe *frontendPlain) SetVerbosity(n int) {
 
 func (fe *frontendPlain) SetPrimary(spanID dagui.SpanID) {
 	fe.mu.Lock()
-	fe.db.PrimarySpan = spanID
+	fe.db.SetPrimarySpan(spanID)
 	fe.mu.Unlock()
 }
 
@@ -240,6 +261,10 @@ func (fe *frontendPlain) Shutdown(ctx context.Context) error {
 	return fe.db.Shutdown(ctx)
 }
 
+func (fe *frontendPlain) DB() *dagui.DB {
+	return fe.db
+}
+
 func (fe *frontendPlain) SpanExporter() sdktrace.SpanExporter {
 	return plainSpanExporter{fe}
 }
@@ -374,9 +399,31 @@ func (fe PlainFrontendMetricExporter) ForceFlush(context.Context) error {
 func (fe *frontendPlain) render() {
 	fe.mu.Lock()
 	fe.renderProgress()
+	fe.renderSlowSpans()
 	fe.mu.Unlock()
 }
 
+// renderSlowSpans prints a warning for each span that became slow, and calls
+// OnSlowSpan for it.
+func (fe *frontendPlain) renderSlowSpans() {
+	for _, slow := range fe.slowSpans.Check(fe.db, fe.SlowThresholds, time.Now()) {
+		if fe.OnSlowSpan != nil {
+			go fe.OnSlowSpan(slow)
+		}
+		span := fe.db.Spans.Map[slow.ID]
+		dt, ok := fe.data[slow.ID]
+		if span == nil || !ok || !dt.started {
+			continue
+		}
+		fmt.Fprint(fe.output, fe.stepPrefix(span, dt))
+		fmt.Fprintln(fe.output, fe.output.String(fmt.Sprintf("! slow: running for %s, over %s",
+			dagui.FormatDuration(slow.Duration), dagui.FormatDuration(slow.Threshold))).Foreground(termenv.ANSIYellow))
+	}
+}
+
+// cacheReportTop is the number of slowest uncached steps in the cache report.
+const cacheReportTop = 10
+
 func (fe *frontendPlain) finalRender() {
 	fe.mu.Lock()
 	defer fe.mu.Unlock()
@@ -394,6 +441,15 @@ func (fe *frontendPlain) finalRender() {
 		fmt.Fprintln(os.Stderr, "\n"+fe.msgPreFinalRender.String()+"\n")
 	}
 	renderPrimaryOutput(fe.db)
+
+	if fe.CacheReport {
+		fmt.Fprintln(os.Stderr)
+		fe.db.CacheReport(fe.Durations, cacheReportTop).WriteText(os.Stderr)
+	}
+	if fe.DebugEffects {
+		fmt.Fprintln(os.Stderr)
+		fe.db.EffectGraph().WriteText(os.Stderr)
+	}
 }
 
 func (fe *frontendPlain) renderProgress() {
@@ -498,7 +554,9 @@ func (fe *frontendPlain) renderStep(span *dagui.Span, depth int, done bool) {
 	r := newRenderer(fe.db, plainMaxLiteralLen, fe.FrontendOpts)
 
 	prefix := fe.stepPrefix(span, spanDt)
-	if span.Call != nil {
+	if name, ok := r.customTitle(fe.output, span); ok {
+		r.renderSpan(fe.output, nil, name, prefix, depth, false)
+	} else if span.Call != nil {
 		call := &callpbv1.Call{
 			Field:          span.Call.Field,
 			Args:           span.Call.Args,
@@ -514,6 +572,11 @@ func (fe *frontendPlain) renderStep(span *dagui.Span, depth int, done bool) {
 		r.renderSpan(fe.output, nil, span.Name, prefix, depth, false)
 	}
 	if done {
+		if fe.Glyphs.Configured() {
+			// plain output is often parsed, so only show glyphs when asked to
+			symbol, color := r.statusGlyph(span)
+			fmt.Fprint(fe.output, " "+fe.output.String(symbol).Foreground(color).String())
+		}
 		if span.IsFailedOrCausedFailure() {
 			fmt.Fprint(fe.output, fe.output.String(" ERROR").Foreground(termenv.ANSIYellow))
 		} else if span.IsCached() {
@@ -521,7 +584,7 @@ func (fe *frontendPlain) renderStep(span *dagui.Span, depth int, done bool) {
 		} else {
 			fmt.Fprint(fe.output, fe.output.String(" DONE").Foreground(termenv.ANSIGreen))
 		}
-		duration := dagui.FormatDuration(span.Activity.Duration(time.Now()))
+		duration := dagui.FormatDuration(span.ActiveDuration(time.Now()))
 		fmt.Fprint(fe.output, fe.output.String(fmt.Sprintf(" [%s]", duration)).Foreground(termenv.ANSIBrightBlack))
 		r.renderMetrics(fe.output, span)
 
@@ -537,6 +600,13 @@ func (fe *frontendPlain) renderStep(span *dagui.Span, depth int, done bool) {
 		}
 	}
 	fmt.Fprintln(fe.output)
+	if done {
+		r.renderMatrix(fe.output, span, prefix, depth)
+		r.renderEvents(fe.output, span, prefix, depth)
+		if span.IsFailed() {
+			r.renderExceptions(fe.output, span, prefix, depth)
+		}
+	}
 }
 
 func (fe *frontendPlain) renderLogs(row *dagui.TraceTree, depth int) {
@@ -6,15 +6,19 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/muesli/termenv"
 	"github.com/pkg/browser"
+	"go.opentelemetry.io/otel/attribute"
 	sdklog "go.opentelemetry.io/otel/sdk/log"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
@@ -32,6 +36,9 @@ type frontendPretty struct {
 	// don't show live progress; just print a full report at the end
 	reportOnly bool
 
+	// embedded in another program as a Model, which handles quitting
+	embedded bool
+
 	// updated by Run
 	program     *tea.Program
 	run         func(context.Context) error
@@ -49,6 +56,17 @@ type frontendPretty struct {
 	backgrounded bool
 	autoFocus    bool
 	debugged     dagui.SpanID
+	rawSpan      dagui.SpanID
+	diagnostics  bool
+	showCritical bool
+	criticalPath dagui.SpanSet // recomputed each frame while showCritical
+	flamegraph   bool
+	flameZoomed  dagui.SpanID // subtree zoomed into within the flamegraph
+	flameFocused dagui.SpanID
+	timeline     bool          // showing the Gantt-style timeline instead of the tree
+	searching    bool          // typing a search query, started with "/"
+	searchQuery  string        // kept after searching to jump between matches
+	searchHits   dagui.SpanSet // recomputed each frame while searchQuery is set
 	focusedIdx   int
 	rowsView     *dagui.RowsView
 	rows         *dagui.Rows
@@ -66,10 +84,18 @@ type frontendPretty struct {
 	viewOut    *termenv.Output
 	browserBuf *strings.Builder // logs if browser fails
 	stdin      io.Reader        // used by backgroundMsg for running terminal
+	termProg   *termProgress    // set if reporting progress to the terminal
 
 	// held to synchronize tea.Model with updates
 	mu sync.Mutex
 
+	// buffers exported telemetry until the next update, so exporting doesn't
+	// wait on rendering and each frame renders a stable DB
+	ingest *dagui.IngestQueue
+
+	// finds spans running longer than their threshold, for OnSlowSpan
+	slowSpans dagui.SlowWatcher
+
 	// messages to print before the final render
 	msgPreFinalRender strings.Builder
 }
@@ -87,7 +113,7 @@ func NewReporter() Frontend {
 func NewWithDB(db *dagui.DB) *frontendPretty {
 	profile := ColorProfile()
 	view := new(strings.Builder)
-	return &frontendPretty{
+	fe := &frontendPretty{
 		db:        db,
 		logs:      newPrettyLogs(),
 		autoFocus: true,
@@ -104,6 +130,8 @@ func NewWithDB(db *dagui.DB) *frontendPretty {
 		viewOut:    NewOutput(view, termenv.WithProfile(profile)),
 		browserBuf: new(strings.Builder),
 	}
+	fe.ingest = dagui.NewIngestQueue(db, prettyLogSink{fe}, db.MetricExporter())
+	return fe
 }
 
 func (fe *frontendPretty) ConnectedToEngine(ctx context.Context, name string, version string, clientID string) {
@@ -156,6 +184,12 @@ func (fe *frontendPretty) Run(ctx context.Context, opts dagui.FrontendOpts, run
 		opts.GCThreshold = 1 * time.Second
 	}
 	fe.FrontendOpts = opts
+	if opts.Store != nil {
+		if err := fe.db.SetStore(opts.Store); err != nil {
+			slog.Debug("failed to set up trace store", "error", err)
+		}
+	}
+	fe.db.SetRetention(opts.Retention)
 
 	if fe.reportOnly {
 		fe.err = run(ctx)
@@ -185,6 +219,18 @@ func (fe *frontendPretty) SetCustomExit(fn func()) {
 	fe.mu.Unlock()
 }
 
+func (fe *frontendPretty) SetCancelSpan(fn func(context.Context, dagui.SpanID) error) {
+	fe.mu.Lock()
+	fe.Opts().CancelSpan = fn
+	fe.mu.Unlock()
+}
+
+func (fe *frontendPretty) SetPauseRun(fn func(ctx context.Context, paused bool) error) {
+	fe.mu.Lock()
+	fe.Opts().PauseRun = fn
+	fe.mu.Unlock()
+}
+
 func (fe *frontendPretty) SetVerbosity(n int) {
 	fe.mu.Lock()
 	fe.Opts().Verbosity = n
@@ -236,6 +282,14 @@ func (fe *frontendPretty) runWithTUI(ctx context.Context, run func(context.Conte
 		opts = append(opts, tea.WithOutput(out))
 	}
 
+	if fe.TerminalProgress {
+		if out == nil {
+			out = os.Stdout
+		}
+		fe.termProg = newTermProgress(out, fe.TerminalBell)
+		defer fe.termProg.finish()
+	}
+
 	// keep program state so we can send messages to it
 	fe.program = tea.NewProgram(fe, opts...)
 
@@ -270,7 +324,7 @@ func (fe *frontendPretty) renderErrorLogs(out *termenv.Output, r *renderer) bool
 	var anyHasLogs bool
 	dagui.WalkTree(errTree, func(row *dagui.TraceTree, _ int) bool {
 		logs := fe.logs.Logs[row.Span.ID]
-		if logs != nil && logs.UsedHeight() > 0 {
+		if logs != nil && logs.UsedHeight() > 0 || len(row.Span.Exceptions) > 0 {
 			anyHasLogs = true
 			return true
 		}
@@ -282,10 +336,14 @@ func (fe *frontendPretty) renderErrorLogs(out *termenv.Output, r *renderer) bool
 	}
 	dagui.WalkTree(errTree, func(tree *dagui.TraceTree, _ int) bool {
 		logs := fe.logs.Logs[tree.Span.ID]
-		if logs != nil && logs.UsedHeight() > 0 {
+		hasLogs := logs != nil && logs.UsedHeight() > 0
+		if hasLogs || len(tree.Span.Exceptions) > 0 {
 			fmt.Fprintln(out)
 			fe.renderStep(out, r, tree.Span, tree.Chained, 0, "")
-			fe.renderLogs(out, r, logs, -1, logs.UsedHeight(), "")
+			if hasLogs {
+				fe.renderLogs(out, r, logs, -1, logs.UsedHeight(), "")
+			}
+			r.renderExceptions(out, tree.Span, "", 0)
 			fe.renderStepError(out, r, tree.Span, 0, "")
 		}
 		return false
@@ -299,6 +357,9 @@ func (fe *frontendPretty) FinalRender(w io.Writer) error {
 	fe.mu.Lock()
 	defer fe.mu.Unlock()
 
+	// Apply any telemetry received since the last frame.
+	fe.flushLocked()
+
 	// Render the full trace.
 	fe.ZoomedSpan = fe.db.PrimarySpan
 	if fe.reportOnly && fe.Verbosity < dagui.ExpandCompletedVerbosity {
@@ -313,6 +374,14 @@ func (fe *frontendPretty) FinalRender(w io.Writer) error {
 
 	out := NewOutput(w, termenv.WithProfile(fe.profile))
 
+	if fe.DebugEffects {
+		// print it last, even if the run failed or was interrupted
+		defer func() {
+			fmt.Fprintln(os.Stderr)
+			fe.db.EffectGraph().WriteText(os.Stderr)
+		}()
+	}
+
 	if fe.Debug || fe.Verbosity >= dagui.ShowCompletedVerbosity || fe.err != nil {
 		fe.renderProgress(out, r, true, fe.window.Height, "")
 
@@ -338,6 +407,10 @@ func (fe *frontendPretty) FinalRender(w io.Writer) error {
 	return renderPrimaryOutput(fe.db)
 }
 
+func (fe *frontendPretty) DB() *dagui.DB {
+	return fe.db
+}
+
 func (fe *frontendPretty) SpanExporter() sdktrace.SpanExporter {
 	return FrontendSpanExporter{fe}
 }
@@ -347,11 +420,11 @@ type FrontendSpanExporter struct {
 }
 
 func (fe FrontendSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
-	fe.mu.Lock()
-	defer fe.mu.Unlock()
-	defer fe.recalculateViewLocked() // recalculate view *after* updating the db
 	slog.Debug("frontend exporting spans", "spans", len(spans))
-	return fe.db.ExportSpans(ctx, spans)
+	if err := fe.ingest.ExportSpans(ctx, spans); err != nil {
+		return err
+	}
+	return fe.flushIfNoTUI()
 }
 
 func (fe *frontendPretty) Shutdown(ctx context.Context) error {
@@ -370,14 +443,48 @@ type prettyLogExporter struct {
 }
 
 func (fe prettyLogExporter) Export(ctx context.Context, logs []sdklog.Record) error {
-	fe.mu.Lock()
-	defer fe.mu.Unlock()
+	if err := fe.ingest.ExportLogs(ctx, logs); err != nil {
+		return err
+	}
+	return fe.flushIfNoTUI()
+}
+
+// prettyLogSink writes logs flushed from the ingest queue, under fe.mu.
+type prettyLogSink struct {
+	*frontendPretty
+}
+
+func (fe prettyLogSink) Export(ctx context.Context, logs []sdklog.Record) error {
 	if err := fe.db.LogExporter().Export(ctx, logs); err != nil {
 		return err
 	}
 	return fe.logs.Export(ctx, logs)
 }
 
+// flushIfNoTUI applies exported telemetry right away when there's no TUI
+// updating to flush it, i.e. when only reporting at the end.
+func (fe *frontendPretty) flushIfNoTUI() error {
+	if !fe.reportOnly {
+		return nil
+	}
+	fe.mu.Lock()
+	defer fe.mu.Unlock()
+	fe.flushLocked()
+	return nil
+}
+
+// flushLocked applies the telemetry exported since the last flush to the DB,
+// recalculating the view if anything changed.
+func (fe *frontendPretty) flushLocked() {
+	n, err := fe.ingest.Flush(context.Background())
+	if err != nil {
+		slog.Warn("failed to ingest telemetry", "error", err)
+	}
+	if n > 0 {
+		fe.recalculateViewLocked() // recalculate view *after* updating the db
+	}
+}
+
 type eofMsg struct{}
 
 func (fe *frontendPretty) ForceFlush(context.Context) error {
@@ -400,9 +507,10 @@ type FrontendMetricExporter struct {
 }
 
 func (fe FrontendMetricExporter) Export(ctx context.Context, resourceMetrics *metricdata.ResourceMetrics) error {
-	fe.mu.Lock()
-	defer fe.mu.Unlock()
-	return fe.db.MetricExporter().Export(ctx, resourceMetrics)
+	if err := fe.ingest.ExportMetrics(ctx, resourceMetrics); err != nil {
+		return err
+	}
+	return fe.flushIfNoTUI()
 }
 
 func (fe FrontendMetricExporter) Temporality(ik sdkmetric.InstrumentKind) metricdata.Temporality {
@@ -450,6 +558,15 @@ func (fe *frontendPretty) renderKeymap(out *termenv.Output, style lipgloss.Style
 		quitMsg = "quit"
 	}
 
+	if fe.searching {
+		prompt := style.Bold(true).Render("/"+fe.searchQuery+"█") +
+			style.Render("  "+fe.searchStatus()+"  ") +
+			style.Bold(true).Render("enter") + style.Render(": done  ") +
+			style.Bold(true).Render("esc") + style.Render(": cancel")
+		fmt.Fprint(out, prompt)
+		return lipgloss.Width(prompt)
+	}
+
 	var showedKey bool
 	// Blank line prior to keymap
 	for _, key := range []keyHelp{
@@ -458,10 +575,23 @@ func (fe *frontendPretty) renderKeymap(out *termenv.Output, style lipgloss.Style
 		{"first", []string{"home"}, true},
 		{"last", []string{"end", " "}, true},
 		{"zoom", []string{"enter"}, true},
-		{"unzoom", []string{"esc"}, fe.ZoomedSpan.IsValid() &&
-			fe.ZoomedSpan != fe.db.PrimarySpan},
+		{"search", []string{"/"}, fe.searchQuery == ""},
+		{fmt.Sprintf("next match (%s)", fe.searchStatus()), []string{"n"}, fe.searchQuery != ""},
+		{"previous", []string{"N"}, fe.searchQuery != ""},
+		{"clear search", []string{"esc"}, fe.searchQuery != ""},
+		{"raw", []string{"r"}, true},
+		{fe.diagnosticsLabel(), []string{"d"}, fe.diagnostics || fe.anomalyCount() > 0},
+		{"critical path", []string{"c"}, true},
+		{fe.flamegraphLabel(), []string{"f"}, true},
+		{fe.timelineLabel(), []string{"t"}, true},
+		{"cancel", []string{"x"}, fe.canCancelFocused()},
+		{fe.pauseLabel(), []string{"p"}, fe.PauseRun != nil},
+		{fe.subtreeVerbosityLabel(), []string{"v"}, fe.FocusedSpan.IsValid()},
+		{fe.aggregateLabel(), []string{"a"}, true},
+		{"unzoom", []string{"esc"}, fe.searchQuery == "" && (fe.flameZoomed.IsValid() ||
+			(fe.ZoomedSpan.IsValid() && fe.ZoomedSpan != fe.db.PrimarySpan))},
 		{fmt.Sprintf("verbosity=%d", fe.Verbosity), []string{"+/-", "+", "-"}, true},
-		{quitMsg, []string{"q", "ctrl+c"}, true},
+		{quitMsg, []string{"q", "ctrl+c"}, !fe.embedded},
 	} {
 		if !key.show {
 			continue
@@ -493,6 +623,17 @@ func (fe *frontendPretty) Render(out *termenv.Output) error {
 
 	r := newRenderer(fe.db, fe.window.Width, fe.FrontendOpts)
 
+	fe.criticalPath = nil
+	if fe.showCritical {
+		fe.criticalPath = fe.db.CriticalPath()
+	}
+	fe.searchHits = nil
+	if fe.searchQuery != "" {
+		fe.searchHits = fe.searchMatches()
+	}
+
+	progHeight -= fe.renderHeader(out, r)
+
 	var progPrefix string
 	if fe.rowsView != nil && fe.rowsView.Zoomed != nil && fe.rowsView.Zoomed.ID != fe.db.PrimarySpan {
 		fe.renderStep(out, r, fe.rowsView.Zoomed, false, 0, "")
@@ -506,12 +647,19 @@ func (fe *frontendPretty) Render(out *termenv.Output) error {
 	fmt.Fprint(countOut, KeymapStyle.Render(strings.Repeat(HorizBar, 1)))
 	fmt.Fprint(countOut, KeymapStyle.Render(" "))
 	fe.renderKeymap(countOut, KeymapStyle)
+	if est, ok := fe.db.RunETA(fe.Durations, r.now); ok {
+		fmt.Fprint(countOut, KeymapStyle.Render(fmt.Sprintf("  eta: ~%s (%d%%)",
+			dagui.FormatDuration(est.Remaining), int(est.Progress*100))))
+	}
 	fmt.Fprint(countOut, KeymapStyle.Render(" "))
 	if rest := fe.window.Width - lipgloss.Width(below.String()); rest > 0 {
 		fmt.Fprint(countOut, KeymapStyle.Render(strings.Repeat(HorizBar, rest)))
 	}
 
-	if logs := fe.logs.Logs[fe.ZoomedSpan]; logs != nil && logs.UsedHeight() > 0 {
+	if fe.diagnostics {
+		fmt.Fprintln(below)
+		fe.renderDiagnostics(countOut, fe.window.Height/3)
+	} else if logs := fe.logs.Logs[fe.ZoomedSpan]; logs != nil && logs.UsedHeight() > 0 {
 		fmt.Fprintln(below)
 		fe.renderLogs(countOut, r, logs, -1, fe.window.Height/3, progPrefix)
 	}
@@ -519,13 +667,90 @@ func (fe *frontendPretty) Render(out *termenv.Output) error {
 	belowOut := strings.TrimRight(below.String(), "\n")
 	progHeight -= lipgloss.Height(belowOut)
 
-	fe.renderProgress(out, r, false, progHeight, progPrefix)
+	if fe.flamegraph {
+		fe.renderFlamegraph(out, r, progHeight, progPrefix)
+	} else if fe.timeline {
+		fe.renderTimeline(out, r, progHeight, progPrefix)
+	} else {
+		fe.renderProgress(out, r, false, progHeight, progPrefix)
+	}
 	fmt.Fprintln(out)
 
 	fmt.Fprint(out, belowOut)
 	return nil
 }
 
+// renderHeader renders a line summarizing the run as a whole, followed by a
+// line for each other invocation in a session that has several, returning the
+// number of lines rendered.
+func (fe *frontendPretty) renderHeader(out *termenv.Output, r *renderer) int {
+	primary := fe.db.Spans.Map[fe.db.PrimarySpan]
+	if primary == nil {
+		return 0
+	}
+	roots := fe.db.Roots()
+	counts := fe.db.SpanCounts()
+	if len(roots) > 1 {
+		counts = fe.db.RootCounts(primary)
+	}
+	ratio, calls := fe.db.CacheHitRatio()
+
+	header := new(strings.Builder)
+	hdrOut := NewOutput(header, termenv.WithProfile(fe.profile))
+	fmt.Fprint(hdrOut, hdrOut.String(primary.Name).Bold())
+	fmt.Fprint(hdrOut, " ")
+	fmt.Fprint(hdrOut, hdrOut.String(dagui.FormatDuration(primary.ActiveDuration(r.now))).Faint())
+	if primary.IsRunningOrEffectsRunning() {
+		r.renderDeadline(hdrOut, r.Deadline)
+	}
+	if fe.db.IsPaused() {
+		fmt.Fprint(hdrOut, " ")
+		fmt.Fprint(hdrOut, hdrOut.String("PAUSED").Foreground(termenv.ANSIYellow).Bold())
+	}
+	fe.renderHeaderCounts(hdrOut, r, counts)
+	if calls > 0 {
+		fmt.Fprint(hdrOut, hdrOut.String(fmt.Sprintf("  %.0f%% cache hits", ratio*100)).Faint())
+	}
+	if len(fe.db.Labels) > 0 {
+		fmt.Fprint(hdrOut, hdrOut.String("  "+dagui.FormatLabels(fe.db.Labels)).Faint())
+	}
+	// truncate rather than wrap, so each entry is always one line
+	fmt.Fprintln(out, lipgloss.NewStyle().MaxWidth(fe.window.Width).Render(header.String()))
+
+	for _, root := range roots[1:] {
+		header.Reset()
+		fmt.Fprint(hdrOut, "  ")
+		r.renderStatus(hdrOut, root, false)
+		fmt.Fprint(hdrOut, hdrOut.String(root.Name).Bold())
+		r.renderDuration(hdrOut, root)
+		fe.renderHeaderCounts(hdrOut, r, fe.db.RootCounts(root))
+		fmt.Fprintln(out, lipgloss.NewStyle().MaxWidth(fe.window.Width).Render(header.String()))
+	}
+	return len(roots)
+}
+
+// renderHeaderCounts renders the number of steps in each notable state.
+func (fe *frontendPretty) renderHeaderCounts(out *termenv.Output, r *renderer, counts dagui.SpanCounts) {
+	glyphs := r.Glyphs.OrDefault()
+	for _, count := range []struct {
+		n     int
+		glyph string
+		label string
+		color termenv.Color
+	}{
+		{counts.Running, glyphs.Running, "running", termenv.ANSIYellow},
+		{counts.Cached, glyphs.Cached, "cached", termenv.ANSIBlue},
+		{counts.Failed, glyphs.Failure, "failed", termenv.ANSIRed},
+		{counts.Skipped, glyphs.Skipped, "skipped", termenv.ANSIBrightBlack},
+	} {
+		if count.n == 0 {
+			continue
+		}
+		fmt.Fprint(out, "  ")
+		fmt.Fprint(out, out.String(fmt.Sprintf("%s %d %s", count.glyph, count.n, count.label)).Foreground(count.color))
+	}
+}
+
 func (fe *frontendPretty) recalculateViewLocked() {
 	fe.rowsView = fe.db.RowsView(fe.FrontendOpts)
 	fe.rows = fe.rowsView.Rows(fe.FrontendOpts)
@@ -552,6 +777,18 @@ func (fe *frontendPretty) recalculateViewLocked() {
 
 func (fe *frontendPretty) renderedRowLines(r *renderer, row *dagui.TraceRow, prefix string) []string {
 	buf := new(strings.Builder)
+	if fe.criticalPath != nil && fe.criticalPath.Map[row.Span.ID] == nil {
+		// dim rows off the critical path, rendering them without color so
+		// their own styles don't override it
+		out := NewOutput(buf, termenv.WithProfile(termenv.Ascii))
+		fe.renderRow(out, r, row, prefix)
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		dimOut := NewOutput(io.Discard, termenv.WithProfile(fe.profile))
+		for i, line := range lines {
+			lines[i] = dimOut.String(line).Faint().String()
+		}
+		return lines
+	}
 	out := NewOutput(buf, termenv.WithProfile(fe.profile))
 	fe.renderRow(out, r, row, prefix)
 	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
@@ -712,6 +949,9 @@ func (fe *frontendPretty) View() string {
 		// print nothing; make way for the pristine output in the final render
 		return ""
 	}
+	if fe.termProg != nil {
+		return fe.termProg.embed(fe.view.String())
+	}
 	return fe.view.String()
 }
 
@@ -729,6 +969,10 @@ type backgroundDoneMsg struct {
 }
 
 func (fe *frontendPretty) update(msg tea.Msg) (*frontendPretty, tea.Cmd) { //nolint: gocyclo
+	// apply telemetry exported since the last update, so the update and the
+	// frame it renders see the DB as of this point
+	fe.flushLocked()
+
 	switch msg := msg.(type) {
 	case doneMsg: // run finished
 		slog.Debug("run finished", "err", msg.err)
@@ -799,6 +1043,13 @@ func (fe *frontendPretty) update(msg tea.Msg) (*frontendPretty, tea.Cmd) { //nol
 		lastKey := fe.pressedKey
 		fe.pressedKey = msg.String()
 		fe.pressedKeyAt = time.Now()
+		if fe.flamegraph && fe.flameKey(msg.String()) {
+			return fe, nil
+		}
+		if fe.searching {
+			fe.searchKey(msg)
+			return fe, nil
+		}
 		switch msg.String() {
 		case "q", "ctrl+c":
 			if fe.CustomExit != nil {
@@ -823,7 +1074,9 @@ func (fe *frontendPretty) update(msg tea.Msg) (*frontendPretty, tea.Cmd) { //nol
 			fe.interrupt(errors.New("interrupted"))
 			return fe, nil // tea.Quit is deferred until we receive doneMsg
 		case "ctrl+\\": // SIGQUIT
-			fe.program.ReleaseTerminal()
+			if fe.program != nil {
+				fe.program.ReleaseTerminal()
+			}
 			sigquit()
 			return fe, nil
 		case "down", "j":
@@ -847,9 +1100,23 @@ func (fe *frontendPretty) update(msg tea.Msg) (*frontendPretty, tea.Cmd) { //nol
 			fe.pressedKeyAt = time.Now()
 			return fe, nil
 		case "esc":
+			if fe.searchQuery != "" {
+				fe.clearSearch()
+				return fe, nil
+			}
 			fe.ZoomedSpan = fe.db.PrimarySpan
 			fe.recalculateViewLocked()
 			return fe, nil
+		case "/":
+			fe.searching = true
+			fe.searchQuery = ""
+			return fe, nil
+		case "n":
+			fe.jumpToMatch(1)
+			return fe, nil
+		case "N":
+			fe.jumpToMatch(-1)
+			return fe, nil
 		case "+", "=":
 			fe.FrontendOpts.Verbosity++
 			fe.recalculateViewLocked()
@@ -884,6 +1151,41 @@ func (fe *frontendPretty) update(msg tea.Msg) (*frontendPretty, tea.Cmd) { //nol
 		case "?":
 			fe.debugged = fe.FocusedSpan
 			return fe, nil
+		case "r":
+			if fe.rawSpan == fe.FocusedSpan {
+				fe.rawSpan = dagui.SpanID{}
+			} else {
+				fe.rawSpan = fe.FocusedSpan
+			}
+			return fe, nil
+		case "d":
+			fe.diagnostics = !fe.diagnostics
+			return fe, nil
+		case "c":
+			fe.showCritical = !fe.showCritical
+			return fe, nil
+		case "f":
+			fe.flamegraph = !fe.flamegraph
+			fe.flameZoomed = dagui.SpanID{}
+			fe.flameFocused = fe.FocusedSpan
+			fe.timeline = false
+			return fe, nil
+		case "t":
+			fe.timeline = !fe.timeline
+			fe.flamegraph = false
+			return fe, nil
+		case "x":
+			return fe, fe.cancelFocused()
+		case "p":
+			return fe, fe.togglePause()
+		case "v":
+			fe.toggleSubtreeVerbosity()
+			fe.recalculateViewLocked()
+			return fe, nil
+		case "a":
+			fe.AggregateCalls = !fe.AggregateCalls
+			fe.recalculateViewLocked()
+			return fe, nil
 		case "enter":
 			fe.ZoomedSpan = fe.FocusedSpan
 			fe.recalculateViewLocked()
@@ -908,6 +1210,10 @@ func (fe *frontendPretty) update(msg tea.Msg) (*frontendPretty, tea.Cmd) { //nol
 
 	case frameMsg:
 		fe.renderLocked()
+		fe.notifySlowSpansLocked()
+		if fe.termProg != nil {
+			fe.termProg.update(fe.db, fe.Durations)
+		}
 		// NB: take care not to forward Frame downstream, since that will result
 		// in runaway ticks. instead inner components should send a SetFpsMsg to
 		// adjust the outermost layer.
@@ -918,6 +1224,201 @@ func (fe *frontendPretty) update(msg tea.Msg) (*frontendPretty, tea.Cmd) { //nol
 	}
 }
 
+// notifySlowSpansLocked calls OnSlowSpan for each span that became slow.
+func (fe *frontendPretty) notifySlowSpansLocked() {
+	if fe.OnSlowSpan == nil {
+		return
+	}
+	for _, slow := range fe.slowSpans.Check(fe.db, fe.SlowThresholds, time.Now()) {
+		go fe.OnSlowSpan(slow)
+	}
+}
+
+// canCancelFocused returns whether the focused span can be canceled on its
+// own, i.e. its call is running and it isn't the whole run.
+//
+// Calls can only be canceled until they return, so a span whose effects are
+// still running after it ended can't be.
+func (fe *frontendPretty) canCancelFocused() bool {
+	if fe.CancelSpan == nil || fe.FocusedSpan == fe.db.PrimarySpan {
+		return false
+	}
+	span := fe.db.Spans.Map[fe.FocusedSpan]
+	return span != nil && span.IsRunning()
+}
+
+// cancelFocused cancels the call running in the focused span, along with its
+// children and the effects it started, leaving the rest of the run going.
+func (fe *frontendPretty) cancelFocused() tea.Cmd {
+	if !fe.canCancelFocused() {
+		return nil
+	}
+	cancelSpan := fe.CancelSpan
+	spanID := fe.FocusedSpan
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := cancelSpan(ctx, spanID); err != nil {
+			slog.Warn("failed to cancel span", "span", spanID, "err", err)
+		}
+		return nil
+	}
+}
+
+// flamegraphLabel returns the keymap label for switching between the tree
+// and the flamegraph.
+func (fe *frontendPretty) flamegraphLabel() string {
+	if fe.flamegraph {
+		return "tree"
+	}
+	return "flamegraph"
+}
+
+// aggregateLabel returns the keymap label for grouping spans of the same
+// call.
+func (fe *frontendPretty) aggregateLabel() string {
+	if fe.AggregateCalls {
+		return "ungroup calls"
+	}
+	return "group calls"
+}
+
+// subtreeVerbosityLabel returns the keymap label for showing or hiding the
+// details beneath the focused span.
+func (fe *frontendPretty) subtreeVerbosityLabel() string {
+	if span := fe.db.Spans.Map[fe.FocusedSpan]; span != nil {
+		if _, ok := fe.db.SubtreeVerbosity(span); ok {
+			return "hide details"
+		}
+	}
+	return "show details"
+}
+
+// toggleSubtreeVerbosity reveals the internal and encapsulated steps beneath
+// the focused span, or hides them again, leaving the rest of the tree as-is.
+func (fe *frontendPretty) toggleSubtreeVerbosity() {
+	span := fe.db.Spans.Map[fe.FocusedSpan]
+	if span == nil {
+		return
+	}
+	if _, ok := fe.db.SubtreeVerbosity(span); ok {
+		fe.db.ClearSubtreeVerbosity(span)
+		return
+	}
+	fe.db.SetSubtreeVerbosity(span, max(span.Verbosity(fe.FrontendOpts), dagui.ShowEncapsulatedVerbosity))
+}
+
+// pauseLabel returns the keymap label for pausing or resuming the run.
+func (fe *frontendPretty) pauseLabel() string {
+	if fe.db.IsPaused() {
+		return "resume"
+	}
+	return "pause"
+}
+
+// togglePause pauses the run, holding back new execs while letting running
+// ones finish, or resumes it if it's paused.
+func (fe *frontendPretty) togglePause() tea.Cmd {
+	if fe.PauseRun == nil {
+		return nil
+	}
+	pauseRun := fe.PauseRun
+	paused := !fe.db.IsPaused()
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := pauseRun(ctx, paused); err != nil {
+			slog.Warn("failed to pause or resume run", "paused", paused, "err", err)
+		}
+		return nil
+	}
+}
+
+// searchKey edits the search query, jumping to the first match as it's
+// typed.
+func (fe *frontendPretty) searchKey(msg tea.KeyMsg) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		fe.searching = false
+		return
+	case tea.KeyEsc, tea.KeyCtrlC:
+		fe.clearSearch()
+		return
+	case tea.KeyBackspace:
+		if fe.searchQuery == "" {
+			return
+		}
+		_, size := utf8.DecodeLastRuneInString(fe.searchQuery)
+		fe.searchQuery = fe.searchQuery[:len(fe.searchQuery)-size]
+	case tea.KeyRunes, tea.KeySpace:
+		fe.searchQuery += string(msg.Runes)
+	default:
+		return
+	}
+	if matches := fe.searchMatches().Order; len(matches) > 0 {
+		fe.jumpTo(matches[0])
+	}
+}
+
+func (fe *frontendPretty) clearSearch() {
+	fe.searching = false
+	fe.searchQuery = ""
+	fe.searchHits = nil
+	fe.RevealedSpans = nil
+	fe.recalculateViewLocked()
+}
+
+// searchMatches returns the spans matching the search query, in order.
+func (fe *frontendPretty) searchMatches() dagui.SpanSet {
+	return dagui.NewSpanSet(fe.db.Search(fe.db.Spans.Map[fe.db.PrimarySpan], fe.searchQuery)...)
+}
+
+// jumpToMatch focuses the next or previous span matching the search query,
+// wrapping around.
+func (fe *frontendPretty) jumpToMatch(delta int) {
+	matches := fe.searchMatches().Order
+	if len(matches) == 0 {
+		return
+	}
+	idx := slices.IndexFunc(matches, func(span *dagui.Span) bool {
+		return span.ID == fe.FocusedSpan
+	})
+	switch {
+	case idx == -1 && delta < 0:
+		idx = len(matches) - 1
+	case idx == -1:
+		idx = 0
+	default:
+		idx = (idx + delta + len(matches)) % len(matches)
+	}
+	fe.jumpTo(matches[idx])
+}
+
+// jumpTo focuses the span, revealing it if it's hidden or collapsed.
+func (fe *frontendPretty) jumpTo(match *dagui.Span) {
+	if zoomed := fe.db.Spans.Map[fe.ZoomedSpan]; zoomed == nil || !match.HasParent(zoomed) {
+		fe.ZoomedSpan = fe.db.PrimarySpan
+	}
+	fe.Reveal(match)
+	fe.autoFocus = false
+	fe.FocusedSpan = match.ID
+	fe.recalculateViewLocked()
+}
+
+// searchStatus returns the position of the focused span among the matches.
+func (fe *frontendPretty) searchStatus() string {
+	if fe.searchHits == nil || len(fe.searchHits.Order) == 0 {
+		return "no matches"
+	}
+	idx := slices.IndexFunc(fe.searchHits.Order, func(span *dagui.Span) bool {
+		return span.ID == fe.FocusedSpan
+	})
+	if idx == -1 {
+		return fmt.Sprintf("%d matches", len(fe.searchHits.Order))
+	}
+	return fmt.Sprintf("%d/%d", idx+1, len(fe.searchHits.Order))
+}
+
 func (fe *frontendPretty) goStart() {
 	fe.autoFocus = false
 	if len(fe.rows.Order) > 0 {
@@ -1008,13 +1509,64 @@ func (fe *frontendPretty) renderRow(out *termenv.Output, r *renderer, row *dagui
 		r.indent(out, row.Depth)
 		fmt.Fprintln(out)
 	}
+	if row.Source != nil {
+		fmt.Fprint(out, prefix)
+		r.indent(out, row.Depth)
+		fmt.Fprintln(out, out.String("▸ "+row.Source.String()).Bold())
+	}
 	fe.renderStep(out, r, row.Span, row.Chained, row.Depth, prefix)
+	if row.Aggregate != nil {
+		// the row stands for every span of the call, so don't show the
+		// details of just one
+		r.renderAggregate(out, row.Aggregate, prefix, row.Depth)
+		return
+	}
+	r.renderMatrix(out, row.Span, prefix, row.Depth)
+	fe.renderStepEvents(out, r, row, prefix)
 	fe.renderStepLogs(out, r, row, prefix)
 	fe.renderStepError(out, r, row.Span, row.Depth, prefix)
 }
 
+// renderAnnotations renders the annotations that modules attached to the
+// span, one per line, beneath it.
+func (fe *frontendPretty) renderAnnotations(out *termenv.Output, r *renderer, span *dagui.Span, depth int, prefix string) {
+	for _, name := range slices.Sorted(maps.Keys(span.Annotations)) {
+		fmt.Fprint(out, prefix)
+		r.indent(out, depth+1)
+		fmt.Fprint(out, out.String(name+": ").Faint())
+		fmt.Fprintln(out, span.Annotations[name])
+	}
+}
+
+// renderResources renders a summary of the resources used by the container
+// the span ran, e.g. an exec's, beneath it.
+func (fe *frontendPretty) renderResources(out *termenv.Output, r *renderer, span *dagui.Span, depth int, prefix string) {
+	res := span.Resources
+	if res == nil {
+		return
+	}
+	parts := []string{
+		"cpu " + dagui.FormatDuration(res.CPU),
+		"peak memory " + humanizeBytes(res.PeakMemoryBytes),
+		"disk read " + humanizeBytes(res.DiskReadBytes),
+		"write " + humanizeBytes(res.DiskWriteBytes),
+		"network rx " + humanizeBytes(res.NetworkRxBytes),
+		"tx " + humanizeBytes(res.NetworkTxBytes),
+	}
+	fmt.Fprint(out, prefix)
+	r.indent(out, depth+1)
+	fmt.Fprint(out, out.String("resources: ").Faint())
+	fmt.Fprintln(out, strings.Join(parts, ", "))
+}
+
+func (fe *frontendPretty) renderStepEvents(out *termenv.Output, r *renderer, row *dagui.TraceRow, prefix string) {
+	if row.IsRunningOrChildRunning || row.Span.IsFailedOrCausedFailure() || row.Span.Verbosity(fe.FrontendOpts) >= dagui.ExpandCompletedVerbosity {
+		r.renderEvents(out, row.Span, prefix, row.Depth)
+	}
+}
+
 func (fe *frontendPretty) renderStepLogs(out *termenv.Output, r *renderer, row *dagui.TraceRow, prefix string) {
-	if row.IsRunningOrChildRunning || row.Span.IsFailedOrCausedFailure() || fe.Verbosity >= dagui.ExpandCompletedVerbosity {
+	if row.IsRunningOrChildRunning || row.Span.IsFailedOrCausedFailure() || row.Span.Verbosity(fe.FrontendOpts) >= dagui.ExpandCompletedVerbosity {
 		if logs := fe.logs.Logs[row.Span.ID]; logs != nil {
 			fe.renderLogs(out, r,
 				logs,
@@ -1048,7 +1600,11 @@ func (fe *frontendPretty) renderStep(out *termenv.Output, r *renderer, span *dag
 	isFocused := span.ID == fe.FocusedSpan
 
 	id := span.Call
-	if id != nil {
+	if name, ok := r.customTitle(out, span); ok {
+		if err := r.renderSpan(out, span, name, prefix, depth, isFocused); err != nil {
+			return err
+		}
+	} else if id != nil {
 		if err := r.renderCall(out, span, id, prefix, chained, depth, false, span.Internal, isFocused); err != nil {
 			return err
 		}
@@ -1057,8 +1613,21 @@ func (fe *frontendPretty) renderStep(out *termenv.Output, r *renderer, span *dag
 			return err
 		}
 	}
+	if fe.searchHits != nil && fe.searchHits.Map[span.ID] != nil {
+		fmt.Fprint(out, " ")
+		fmt.Fprint(out, out.String("◂ match").Foreground(termenv.ANSIYellow).Bold())
+	}
 	fmt.Fprintln(out)
 
+	if isFocused {
+		fe.renderAnnotations(out, r, span, depth, prefix)
+		fe.renderResources(out, r, span, depth, prefix)
+	}
+
+	if span.ID == fe.rawSpan {
+		fe.renderRawSpan(out, r, span, depth, prefix)
+	}
+
 	if span.ID == fe.debugged {
 		r.indent(out, depth+1)
 		fmt.Fprintf(out, prefix+"? version: %d\n", span.Version)
@@ -1072,10 +1641,34 @@ func (fe *frontendPretty) renderStep(out *termenv.Output, r *renderer, span *dag
 		fmt.Fprintf(out, prefix+"? internal: %v\n", span.Internal)
 		r.indent(out, depth+1)
 		fmt.Fprintf(out, prefix+"? canceled: %v\n", span.Canceled)
+		if canceled, reasons := span.CanceledReason(); canceled {
+			r.indent(out, depth+1)
+			fmt.Fprintf(out, prefix+"? cancel cause: %s (%s)\n", span.CancelCause(), strings.Join(reasons, "; "))
+		}
 		r.indent(out, depth+1)
 		fmt.Fprintf(out, prefix+"? passthrough: %v\n", span.Passthrough)
 		r.indent(out, depth+1)
 		fmt.Fprintf(out, prefix+"? ignore: %v\n", span.Ignore)
+		if span.Source != nil {
+			r.indent(out, depth+1)
+			fmt.Fprintf(out, prefix+"? source: %s\n", span.Source)
+		}
+		if span.Corrected != "" {
+			r.indent(out, depth+1)
+			fmt.Fprintf(out, prefix+"? corrected: %s\n", span.Corrected)
+		}
+		if span.ClockSkew != 0 {
+			r.indent(out, depth+1)
+			fmt.Fprintf(out, prefix+"? clock skew: %s\n", span.ClockSkew)
+		}
+		for _, name := range slices.Sorted(maps.Keys(span.UserAttributes)) {
+			r.indent(out, depth+1)
+			fmt.Fprintf(out, prefix+"? user.%s: %v\n", name, span.UserAttributes[name].Value)
+		}
+		for _, name := range slices.Sorted(maps.Keys(span.Annotations)) {
+			r.indent(out, depth+1)
+			fmt.Fprintf(out, prefix+"? annotation.%s: %s\n", name, span.Annotations[name])
+		}
 		pending, reasons := span.PendingReason()
 		r.indent(out, depth+1)
 		fmt.Fprintf(out, prefix+"? pending: %v\n", pending)
@@ -1120,6 +1713,91 @@ func (fe *frontendPretty) renderStep(out *termenv.Output, r *renderer, span *dag
 	return nil
 }
 
+// rawValueMax is the number of bytes of each attribute value shown in the
+// raw view, since some, like call payloads, can be very large.
+const rawValueMax = 200
+
+func (fe *frontendPretty) anomalyCount() int {
+	_, counts := fe.db.Anomalies()
+	n := fe.db.CorrectedSpans()
+	for _, count := range counts {
+		n += count
+	}
+	return n
+}
+
+func (fe *frontendPretty) diagnosticsLabel() string {
+	if n := fe.anomalyCount(); n > 0 {
+		return fmt.Sprintf("diagnostics (%d)", n)
+	}
+	return "diagnostics"
+}
+
+// renderDiagnostics renders the anomalies found with the telemetry received,
+// e.g. spans that ended before they started, in place of the logs.
+func (fe *frontendPretty) renderDiagnostics(out *termenv.Output, height int) {
+	anomalies, counts := fe.db.Anomalies()
+	lines := []string{
+		out.String(fmt.Sprintf("corrected-times: %d", fe.db.CorrectedSpans())).Bold().String(),
+	}
+	for _, kind := range dagui.AnomalyKinds {
+		lines = append(lines, out.String(fmt.Sprintf("%s: %d", kind, counts[kind])).Bold().String())
+		for _, anomaly := range anomalies {
+			if anomaly.Kind != kind {
+				continue
+			}
+			lines = append(lines, out.String(fmt.Sprintf("  %s %s: %s", anomaly.Span, anomaly.Name, anomaly.Detail)).Faint().String())
+		}
+	}
+	if height > 0 && len(lines) > height {
+		lines = append(lines[:height-1], out.String(fmt.Sprintf("… %d more", len(lines)-height+1)).Faint().String())
+	}
+	fmt.Fprint(out, strings.Join(lines, "\n"))
+}
+
+// renderRawSpan renders the telemetry received for the span as-is, for
+// debugging the telemetry emitted by modules and exporters.
+func (fe *frontendPretty) renderRawSpan(out *termenv.Output, r *renderer, span *dagui.Span, depth int, prefix string) {
+	line := func(indent int, format string, args ...any) {
+		fmt.Fprint(out, prefix)
+		r.indent(out, depth+1+indent)
+		fmt.Fprintln(out, out.String(fmt.Sprintf(format, args...)).Faint())
+	}
+	attrs := func(indent int, kvs []attribute.KeyValue) {
+		for _, kv := range kvs {
+			val := kv.Value.Emit()
+			if len(val) > rawValueMax {
+				val = fmt.Sprintf("%s… (%d bytes)", val[:rawValueMax], len(val))
+			}
+			line(indent, "%s = %s", kv.Key, val)
+		}
+	}
+	raw := fe.db.RawSpans[span.ID]
+	if raw == nil {
+		line(0, "no raw telemetry received for this span")
+		return
+	}
+	line(0, "span %s trace %s parent %s", span.ID, span.TraceID, span.ParentID)
+	line(0, "attributes:")
+	attrs(1, raw.Attributes)
+	line(0, "resource:")
+	attrs(1, raw.Resource)
+	if len(raw.Links) > 0 {
+		line(0, "links:")
+		for _, link := range raw.Links {
+			line(1, "span %s trace %s", link.SpanContext.SpanID(), link.SpanContext.TraceID())
+			attrs(2, link.Attributes)
+		}
+	}
+	if len(raw.Events) > 0 {
+		line(0, "events:")
+		for _, event := range raw.Events {
+			line(1, "%s at %s", event.Name, event.Time.Format(time.RFC3339Nano))
+			attrs(2, event.Attributes)
+		}
+	}
+}
+
 func (fe *frontendPretty) renderLogs(out *termenv.Output, r *renderer, logs *Vterm, depth int, height int, prefix string) {
 	pipe := out.String(VertBoldBar).Foreground(termenv.ANSIBrightBlack)
 	if depth == -1 {
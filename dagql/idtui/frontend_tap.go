@@ -0,0 +1,116 @@
+package idtui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+// tapLogLines is the number of log lines included in a failed test's
+// diagnostics.
+const tapLogLines = 20
+
+// frontendTAP reports each top-level span as a test in Test Anything
+// Protocol format once the run completes. Progress is not displayed. Like
+// the other frontends, it writes to stderr, leaving stdout to the command.
+type frontendTAP struct {
+	*frontendPlain
+}
+
+func NewTAP() Frontend {
+	return &frontendTAP{
+		frontendPlain: NewPlain().(*frontendPlain),
+	}
+}
+
+func (fe *frontendTAP) Run(ctx context.Context, opts dagui.FrontendOpts, run func(context.Context) error) error {
+	opts.Silent = true
+	runErr := fe.frontendPlain.Run(ctx, opts, run)
+	if err := writeTAP(fe.output, fe.db, opts.Quarantine); err != nil {
+		return err
+	}
+	return runErr
+}
+
+// tapDiagnostics is the YAML diagnostic block following each test point.
+type tapDiagnostics struct {
+	DurationMS int64  `yaml:"duration_ms"`
+	Cached     bool   `yaml:"cached,omitempty"`
+	Message    string `yaml:"message,omitempty"`
+	Logs       string `yaml:"logs,omitempty"`
+}
+
+func writeTAP(w io.Writer, db *dagui.DB, quarantine dagui.QuarantineMode) error {
+	spans := db.TopLevelSpans()
+	var sb strings.Builder
+	sb.WriteString("TAP version 14\n")
+	fmt.Fprintf(&sb, "1..%d\n", len(spans))
+	for i, span := range spans {
+		status := "ok"
+		if span.IsFailedOrCausedFailure() {
+			status = "not ok"
+		}
+		directive := ""
+		switch {
+		case span.IsQuarantinedFailure(quarantine):
+			// harnesses do not count failures of TODO tests
+			directive = " # TODO quarantined"
+		case span.IsToleratedFailure() && span.FailureExpected:
+			directive = " # TODO expected failure"
+		case span.IsToleratedFailure():
+			directive = " # TODO allowed failure"
+		case span.IsSkipped():
+			_, reason := span.SkipReason()
+			directive = " # SKIP " + tapDescription(reason)
+		case span.IsUnexpectedPass():
+			// harnesses report passing TODO tests as bonus
+			directive = " # TODO unexpected pass"
+		}
+		fmt.Fprintf(&sb, "%s %d - %s%s\n", status, i+1, tapDescription(span.Name), directive)
+		diag := tapDiagnostics{
+			DurationMS: span.EndTimeOrNow().Sub(span.StartTime).Milliseconds(),
+			Cached:     span.IsCached(),
+		}
+		if status == "not ok" {
+			diag.Message = span.Status.Description
+			diag.Logs = db.LogTailLines(span, tapLogLines)
+		}
+		if err := writeTAPDiagnostics(&sb, diag); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeTAPDiagnostics writes diag as a YAML block indented under its test
+// point.
+func writeTAPDiagnostics(sb *strings.Builder, diag tapDiagnostics) error {
+	var buf strings.Builder
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(diag); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	sb.WriteString("  ---\n")
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		sb.WriteString("  " + line + "\n")
+	}
+	sb.WriteString("  ...\n")
+	return nil
+}
+
+// tapDescription makes a span name safe to use as a test description, which
+// must be a single line and must not contain a directive.
+func tapDescription(name string) string {
+	name = strings.ReplaceAll(name, "\n", " ")
+	return strings.ReplaceAll(name, "#", `\#`)
+}
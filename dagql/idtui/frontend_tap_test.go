@@ -0,0 +1,69 @@
+package idtui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+func TestWriteTAP(t *testing.T) {
+	traceID := dagui.TraceID{TraceID: trace.TraceID{1}}
+	root := dagui.SpanID{SpanID: trace.SpanID{1}}
+	start := time.Now().Add(-time.Minute)
+	step := func(id byte, name string, status sdktrace.Status) dagui.SpanSnapshot {
+		return dagui.SpanSnapshot{
+			ID:        dagui.SpanID{SpanID: trace.SpanID{id}},
+			TraceID:   traceID,
+			ParentID:  root,
+			Name:      name,
+			StartTime: start.Add(time.Duration(id) * time.Second),
+			EndTime:   start.Add(time.Duration(id)*time.Second + 1500*time.Millisecond),
+			Status:    status,
+		}
+	}
+	db := dagui.NewDB()
+	db.SetPrimarySpan(root)
+	db.ImportSnapshots([]dagui.SpanSnapshot{
+		{
+			ID:        root,
+			TraceID:   traceID,
+			Name:      "run",
+			StartTime: start,
+			EndTime:   start.Add(5 * time.Second),
+		},
+		step(2, "build", sdktrace.Status{}),
+		step(3, "test #1\nagain", sdktrace.Status{
+			Code:        codes.Error,
+			Description: "exit code: 1\n\"assert\" failed: a: b",
+		}),
+	})
+	db.LogTails[dagui.SpanID{SpanID: trace.SpanID{3}}] = []byte("first\n  indented\nlast\n")
+
+	var out strings.Builder
+	require.NoError(t, writeTAP(&out, db, dagui.QuarantineFail))
+	require.Equal(t, `TAP version 14
+1..2
+ok 1 - build
+  ---
+  duration_ms: 1500
+  ...
+not ok 2 - test \#1 again
+  ---
+  duration_ms: 1500
+  message: |-
+    exit code: 1
+    "assert" failed: a: b
+  logs: |-
+    first
+      indented
+    last
+  ...
+`, out.String())
+}
@@ -0,0 +1,58 @@
+package idtui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/muesli/termenv"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+// heatColors are the colors of each heat level, from much faster than usual
+// to much slower. Usual durations are kept neutral so that changes stand out.
+var heatColors = [dagui.HeatLevels]termenv.Color{
+	termenv.ANSIBrightGreen,
+	termenv.ANSIGreen,
+	termenv.ANSIBrightBlack,
+	termenv.ANSIYellow,
+	termenv.ANSIRed,
+}
+
+// RenderHeatmap writes a strip for each step in the history, with one cell
+// per run colored by how its duration compares to the step's usual duration,
+// followed by the step's name and its duration in the latest run.
+func RenderHeatmap(w io.Writer, hist *dagui.StepHistory, glyphs dagui.StatusGlyphs) error {
+	out := NewOutput(w)
+	cached := glyphs.OrDefault().Cached
+	faint := func(str string) string {
+		return out.String(str).Faint().String()
+	}
+	fmt.Fprintf(out, "%s %s%s %s%s %s%s %s\n",
+		faint(fmt.Sprintf("%d runs, oldest first:", len(hist.Runs))),
+		out.String("█").Foreground(heatColors[0]), faint(" faster"),
+		out.String("█").Foreground(heatColors[dagui.HeatLevels-1]), faint(" slower"),
+		faint(cached), faint(" cached"),
+		faint("· not run"),
+	)
+	for _, step := range hist.Steps {
+		var strip strings.Builder
+		for i, run := range step.Runs {
+			switch level, ok := step.Heat(i); {
+			case ok:
+				strip.WriteString(out.String("█").Foreground(heatColors[level]).String())
+			case run.Cached:
+				strip.WriteString(faint(cached))
+			default:
+				strip.WriteString(faint("·"))
+			}
+		}
+		latest := ""
+		if last := step.Runs[len(step.Runs)-1]; last.Ran && !last.Cached {
+			latest = " " + faint(dagui.FormatDuration(last.Duration))
+		}
+		fmt.Fprintf(out, "%s %s%s%s\n", strip.String(), strings.Repeat("  ", step.Depth), step.Name, latest)
+	}
+	return nil
+}
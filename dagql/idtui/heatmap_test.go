@@ -0,0 +1,37 @@
+package idtui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+func TestRenderHeatmap(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	ran := func(dur time.Duration) dagui.StepRun { return dagui.StepRun{Ran: true, Duration: dur} }
+	hist := &dagui.StepHistory{
+		Runs: make([]dagui.TraceMeta, 3),
+		Steps: []dagui.StepRuns{
+			{Name: "run", Runs: []dagui.StepRun{ran(time.Minute), ran(time.Minute), ran(time.Minute)}},
+			{Name: "build", Depth: 1, Runs: []dagui.StepRun{ran(10 * time.Second), {Ran: true, Cached: true}, ran(30 * time.Second)}},
+			{Name: "lint", Depth: 1, Runs: []dagui.StepRun{ran(2 * time.Second), ran(3 * time.Second), {}}},
+		},
+	}
+
+	var out strings.Builder
+	require.NoError(t, RenderHeatmap(&out, hist, dagui.StatusGlyphs{}))
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	require.Len(t, lines, 4)
+	cached := dagui.DefaultGlyphs.Cached
+	require.Equal(t, "3 runs, oldest first: █ faster █ slower "+cached+" cached · not run", lines[0])
+	// one cell per run, then the name, indented by depth, and the latest
+	// duration unless it was cached or didn't run
+	require.Equal(t, "███ run "+dagui.FormatDuration(time.Minute), lines[1])
+	require.Equal(t, "█"+cached+"█   build "+dagui.FormatDuration(30*time.Second), lines[2])
+	require.Equal(t, "██·   lint", lines[3])
+}
@@ -0,0 +1,108 @@
+package idtui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+// Model is a Bubble Tea component showing a live, navigable tree of spans,
+// for tools that embed Dagger to show its progress within their own TUI.
+//
+// Feed it spans either with ImportSnapshots or by installing its SpanExporter
+// and LogExporter in an OpenTelemetry pipeline. Forward it all messages, size
+// it with SetSize, and place its View wherever it belongs.
+//
+// Unlike the CLI's frontend, it never quits the program or interrupts the
+// work being shown; those keys are left to the embedding model.
+type Model struct {
+	fe *frontendPretty
+}
+
+var _ tea.Model = (*Model)(nil)
+
+// NewModel creates a Model that renders spans with the given options.
+func NewModel(opts dagui.FrontendOpts) *Model {
+	fe := NewWithDB(dagui.NewDB())
+	fe.FrontendOpts = opts
+	fe.embedded = true
+	return &Model{fe: fe}
+}
+
+// DB returns the database of spans backing the model.
+func (m *Model) DB() *dagui.DB {
+	return m.fe.db
+}
+
+// ImportSnapshots adds or updates spans, e.g. as received from a remote
+// dagui.DB.
+func (m *Model) ImportSnapshots(snapshots []dagui.SpanSnapshot) {
+	m.fe.mu.Lock()
+	defer m.fe.mu.Unlock()
+	m.fe.db.ImportSnapshots(snapshots)
+	m.fe.recalculateViewLocked()
+}
+
+// SetPrimary sets the span whose children are shown, typically the root span
+// of the work being shown.
+func (m *Model) SetPrimary(span dagui.SpanID) {
+	m.fe.SetPrimary(span)
+}
+
+// SpanExporter returns an exporter that feeds spans to the model.
+func (m *Model) SpanExporter() sdktrace.SpanExporter {
+	return m.fe.SpanExporter()
+}
+
+// LogExporter returns an exporter that feeds span logs to the model.
+func (m *Model) LogExporter() sdklog.Exporter {
+	return m.fe.LogExporter()
+}
+
+// SetSize sets the size of the model's view.
+func (m *Model) SetSize(width, height int) {
+	m.fe.mu.Lock()
+	defer m.fe.mu.Unlock()
+	m.fe.setWindowSizeLocked(tea.WindowSizeMsg{Width: width, Height: height})
+}
+
+// Init starts rendering frames.
+func (m *Model) Init() tea.Cmd {
+	return frame(m.fe.fps)
+}
+
+// Update handles frames, resizing, and navigation.
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m.fe.mu.Lock()
+	defer m.fe.mu.Unlock()
+	switch msg := msg.(type) {
+	case frameMsg, tea.WindowSizeMsg, tea.MouseMsg:
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c", "ctrl+\\":
+			// leave these to the embedding model
+			return m, nil
+		case "x", "w":
+			// these act on the focused span's run, which needs a program
+			// running it
+			if m.fe.program == nil || !m.fe.FocusedSpan.IsValid() {
+				return m, nil
+			}
+		case "p":
+			if m.fe.program == nil {
+				return m, nil
+			}
+		}
+	default:
+		return m, nil
+	}
+	_, cmd := m.fe.update(msg)
+	return m, cmd
+}
+
+// View renders the model as of its latest frame.
+func (m *Model) View() string {
+	return m.fe.View()
+}
@@ -0,0 +1,87 @@
+package idtui
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+func TestModelKeys(t *testing.T) {
+	traceID := dagui.TraceID{TraceID: trace.TraceID{1}}
+	root := dagui.SpanID{SpanID: trace.SpanID{1}}
+	start := time.Now().Add(-time.Minute)
+	snapshots := []dagui.SpanSnapshot{{
+		ID:        root,
+		TraceID:   traceID,
+		Name:      "root",
+		StartTime: start,
+	}}
+	for i := byte(2); i <= 3; i++ {
+		snapshots = append(snapshots, dagui.SpanSnapshot{
+			ID:        dagui.SpanID{SpanID: trace.SpanID{i}},
+			TraceID:   traceID,
+			ParentID:  root,
+			Name:      "child",
+			StartTime: start.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	m := NewModel(dagui.FrontendOpts{})
+	m.SetPrimary(root)
+	m.ImportSnapshots(snapshots)
+	m.SetSize(80, 24)
+	key := func(k string) tea.Cmd {
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		return cmd
+	}
+
+	// without a program running the spans, keys acting on it do nothing, even
+	// if hooks for them are set
+	var canceled, paused bool
+	m.fe.CancelSpan = func(context.Context, dagui.SpanID) error {
+		canceled = true
+		return nil
+	}
+	m.fe.PauseRun = func(context.Context, bool) error {
+		paused = true
+		return nil
+	}
+	m.fe.cloudURL = "https://dagger.cloud/traces/1"
+	for _, k := range []string{"x", "p", "w"} {
+		require.Nil(t, key(k), k)
+	}
+	// nor with nothing focused
+	m.fe.program = tea.NewProgram(nil)
+	m.fe.FocusedSpan = dagui.SpanID{}
+	for _, k := range []string{"x", "w"} {
+		require.Nil(t, key(k), k)
+	}
+	require.False(t, canceled)
+	require.False(t, paused)
+
+	// with both, the focused span's call can be canceled
+	m.fe.FocusedSpan = snapshots[1].ID
+	cmd := key("x")
+	require.NotNil(t, cmd)
+	cmd()
+	require.True(t, canceled)
+
+	// quitting is left to the embedding model
+	require.Nil(t, key("q"))
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	require.Nil(t, cmd)
+
+	// navigation moves the focus
+	require.Len(t, m.fe.rows.Order, 2)
+	m.fe.focus(m.fe.rows.Order[0])
+	key("j")
+	require.Equal(t, m.fe.rows.Order[1].Span.ID, m.fe.FocusedSpan)
+	key("k")
+	require.Equal(t, m.fe.rows.Order[0].Span.ID, m.fe.FocusedSpan)
+}
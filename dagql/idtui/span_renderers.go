@@ -0,0 +1,55 @@
+package idtui
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/muesli/termenv"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+// SpanRenderFunc renders the title of a span carrying a particular attribute,
+// given the attribute's value. The span's status, duration, and other details
+// are rendered around it as usual.
+type SpanRenderFunc func(out *termenv.Output, span *dagui.Span, value any) string
+
+var (
+	spanRenderers   = map[string]SpanRenderFunc{}
+	spanRenderersMu sync.RWMutex
+)
+
+// RegisterSpanRenderer renders spans carrying the given attribute with fn,
+// rather than as a generic call, e.g. to show test counts for a span with a
+// "test.report" attribute.
+//
+// Renderers should be registered before any spans are received, typically
+// from an init function.
+func RegisterSpanRenderer(attr string, fn SpanRenderFunc) {
+	spanRenderersMu.Lock()
+	spanRenderers[attr] = fn
+	spanRenderersMu.Unlock()
+	dagui.RetainAttribute(attr)
+}
+
+// customTitle returns the title of the span rendered by a registered span
+// renderer or a configured span name template, if any apply.
+func (r *renderer) customTitle(out *termenv.Output, span *dagui.Span) (string, bool) {
+	if len(span.Attributes) > 0 {
+		spanRenderersMu.RLock()
+		defer spanRenderersMu.RUnlock()
+		// check in a stable order so that spans with more than one rendered
+		// attribute don't flip-flop between frames
+		attrs := make([]string, 0, len(span.Attributes))
+		for attr := range span.Attributes {
+			attrs = append(attrs, attr)
+		}
+		sort.Strings(attrs)
+		for _, attr := range attrs {
+			if fn, ok := spanRenderers[attr]; ok {
+				return fn(out, span, span.Attributes[attr]), true
+			}
+		}
+	}
+	return r.SpanNames.Name(r.db, span)
+}
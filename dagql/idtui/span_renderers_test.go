@@ -0,0 +1,49 @@
+package idtui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/muesli/termenv"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+func TestSpanRenderers(t *testing.T) {
+	RegisterSpanRenderer("test.renderers.report", func(out *termenv.Output, span *dagui.Span, value any) string {
+		return fmt.Sprintf("%s: %v", span.Name, value)
+	})
+	RegisterSpanRenderer("test.renderers.push", func(out *termenv.Output, span *dagui.Span, value any) string {
+		return fmt.Sprintf("pushed %v", value)
+	})
+
+	var snapshot dagui.SpanSnapshot
+	snapshot.ID = dagui.SpanID{SpanID: trace.SpanID{1}}
+	snapshot.TraceID = dagui.TraceID{TraceID: trace.TraceID{1}}
+	snapshot.Name = "go test"
+	// registering a renderer retains its attribute
+	snapshot.ProcessAttribute("test.renderers.report", "3 passed")
+	snapshot.ProcessAttribute("test.renderers.push", "alpine:latest")
+	db := dagui.NewDB()
+	db.ImportSnapshots([]dagui.SpanSnapshot{
+		snapshot,
+		{
+			ID:      dagui.SpanID{SpanID: trace.SpanID{2}},
+			TraceID: dagui.TraceID{TraceID: trace.TraceID{1}},
+			Name:    "build",
+		},
+	})
+
+	r := newRenderer(db, 0, dagui.FrontendOpts{})
+	out := termenv.NewOutput(&strings.Builder{}, termenv.WithProfile(termenv.Ascii))
+	// with more than one renderer, the first attribute by name wins
+	title, ok := r.customTitle(out, db.Spans.Map[dagui.SpanID{SpanID: trace.SpanID{1}}])
+	require.True(t, ok)
+	require.Equal(t, "pushed alpine:latest", title)
+
+	_, ok = r.customTitle(out, db.Spans.Map[dagui.SpanID{SpanID: trace.SpanID{2}}])
+	require.False(t, ok)
+}
@@ -12,6 +12,7 @@ const (
 	CornerTopLeft       = "╭"
 	CornerTopRight      = "╮"
 	CrossBar            = "┼"
+	DiamondFilled       = "◆"
 	DotEmpty            = "○"
 	DotFilled           = "●"
 	HorizBar            = "─"
@@ -0,0 +1,97 @@
+package idtui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+// termProgressState is the state of the ConEmu-style progress indicator
+// supported by Windows Terminal, iTerm2, and others via OSC 9;4.
+type termProgressState int
+
+const (
+	termProgressRemove        termProgressState = 0
+	termProgressNormal        termProgressState = 1
+	termProgressError         termProgressState = 2
+	termProgressIndeterminate termProgressState = 3
+)
+
+// termProgress reports the overall progress of the run to the terminal
+// emulator, e.g. to show in the tab or taskbar, and optionally rings the bell
+// once the run completes.
+//
+// While the TUI is running, the progress is sent as part of its view, so that
+// it's written by the TUI's renderer rather than racing with it.
+type termProgress struct {
+	out  io.Writer
+	tmux bool
+	bell bool
+
+	// the sequence reporting the current progress
+	seq string
+}
+
+func newTermProgress(out io.Writer, bell bool) *termProgress {
+	return &termProgress{
+		out:  out,
+		tmux: os.Getenv("TMUX") != "",
+		bell: bell,
+	}
+}
+
+// update computes the current progress of the run.
+func (tp *termProgress) update(db *dagui.DB, hist *dagui.DurationHistory) {
+	primary := db.Spans.Map[db.PrimarySpan]
+	if primary == nil {
+		return
+	}
+	state := termProgressIndeterminate
+	var pct int
+	if est, ok := db.RunETA(hist, time.Now()); ok {
+		state = termProgressNormal
+		pct = int(est.Progress * 100)
+	}
+	if primary.IsFailedOrCausedFailure() {
+		// keep showing progress, but in red
+		state = termProgressError
+	}
+	tp.seq = tp.sequence(state, pct)
+}
+
+// embed adds the current progress to the start of the view's last line. The
+// sequence takes up no space, and the renderer only writes lines that
+// changed, so it's sent to the terminal whenever the progress changes. The
+// last line is used since the renderer drops lines from the top of views
+// taller than the terminal.
+func (tp *termProgress) embed(view string) string {
+	if tp.seq == "" {
+		return view
+	}
+	i := strings.LastIndex(view, "\n") + 1
+	return view[:i] + tp.seq + view[i:]
+}
+
+// finish clears the progress indicator and, if enabled, rings the bell, which
+// tmux shows as a flag on the window if it isn't the active one. It must only
+// be called once the TUI has stopped.
+func (tp *termProgress) finish() {
+	seq := tp.sequence(termProgressRemove, 0)
+	if tp.bell {
+		seq += "\a"
+	}
+	fmt.Fprint(tp.out, seq)
+}
+
+func (tp *termProgress) sequence(state termProgressState, pct int) string {
+	seq := fmt.Sprintf("\x1b]9;4;%d;%d\x07", state, pct)
+	if tp.tmux {
+		// pass the sequence through to the outer terminal
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return seq
+}
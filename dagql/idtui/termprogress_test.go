@@ -0,0 +1,63 @@
+package idtui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+func TestTermProgress(t *testing.T) {
+	root := dagui.SpanID{SpanID: trace.SpanID{1}}
+	db := dagui.NewDB()
+	db.ImportSnapshots([]dagui.SpanSnapshot{{
+		ID:        root,
+		TraceID:   dagui.TraceID{TraceID: trace.TraceID{1}},
+		Name:      "run",
+		StartTime: time.Now().Add(-time.Minute),
+	}})
+	hist := dagui.NewDurationHistory()
+	hist.ByName["run"] = dagui.DurationEstimate{Duration: 4 * time.Minute}
+
+	var out strings.Builder
+	tp := newTermProgress(&out, false)
+	tp.tmux = false
+	require.Equal(t, "a\nb", tp.embed("a\nb"))
+
+	tp.update(db, hist)
+	require.Equal(t, "\x1b]9;4;1;25\x07", tp.seq)
+	// the progress goes on the last line, and takes up no space
+	view := tp.embed("a\nb")
+	require.Equal(t, "a\n"+tp.seq+"b", view)
+	require.Equal(t, 1, lipgloss.Width(view[2:]))
+	// nothing is written until the TUI is done
+	require.Empty(t, out.String())
+
+	db.ImportSnapshots([]dagui.SpanSnapshot{{
+		ID:        root,
+		TraceID:   dagui.TraceID{TraceID: trace.TraceID{1}},
+		Name:      "run",
+		StartTime: time.Now().Add(-time.Minute),
+		Status:    sdktrace.Status{Code: codes.Error},
+	}})
+	tp.update(db, nil)
+	require.Equal(t, "\x1b]9;4;2;0\x07", tp.seq)
+
+	tp.finish()
+	require.Equal(t, "\x1b]9;4;0;0\x07", out.String())
+
+	// the bell is opt-in
+	out.Reset()
+	tp = newTermProgress(&out, true)
+	tp.tmux = true
+	tp.finish()
+	require.Equal(t, "\x1bPtmux;\x1b\x1b]9;4;0;0\x07\x1b\\\a", out.String())
+	require.Zero(t, lipgloss.Width(tp.sequence(termProgressNormal, 50)))
+}
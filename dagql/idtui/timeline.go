@@ -0,0 +1,119 @@
+package idtui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/muesli/termenv"
+
+	"github.com/dagger/dagger/dagql/dagui"
+)
+
+// timelineMinStall is the shortest period without progress that the
+// timeline highlights as a stall.
+const timelineMinStall = time.Second
+
+// renderTimeline renders the view as a Gantt chart: each row of the tree is
+// labeled on the left, with a bar on a shared wall-clock time axis showing
+// when it ran. Periods when nothing made progress are shaded as stalls.
+func (fe *frontendPretty) renderTimeline(out *termenv.Output, r *renderer, height int, prefix string) {
+	if fe.rows == nil || len(fe.rows.Order) == 0 || height < 2 {
+		return
+	}
+	tl := dagui.NewTimeline(fe.rows.Order, r.now)
+	width := fe.window.Width - len(prefix)
+	labelWidth := min(max(width/4, 12), 40)
+	barWidth := width - labelWidth - 1
+	if barWidth < 10 {
+		return
+	}
+
+	// map times to columns of the bar area
+	col := func(t time.Time) int {
+		if tl.Duration() <= 0 {
+			return 0
+		}
+		c := int(int64(barWidth) * int64(t.Sub(tl.Start)) / int64(tl.Duration()))
+		return min(max(c, 0), barWidth-1)
+	}
+	stalled := make([]bool, barWidth)
+	for _, stall := range tl.Stalls(timelineMinStall) {
+		for c := col(stall.Start); c <= col(stall.End) && c < barWidth; c++ {
+			stalled[c] = true
+		}
+	}
+	bars := make(map[dagui.SpanID]dagui.TimelineBar, len(tl.Bars))
+	for _, bar := range tl.Bars {
+		bars[bar.Span.ID] = bar
+	}
+
+	lines := []string{prefix + strings.Repeat(" ", labelWidth+1) + fe.timelineAxis(out, tl, barWidth)}
+
+	// show the rows surrounding the focused row
+	rows := fe.rows.Order
+	visible := height - 1
+	from := 0
+	if fe.focusedIdx >= 0 {
+		from = max(fe.focusedIdx-visible/2, 0)
+	}
+	from = max(min(from, len(rows)-visible), 0)
+	for i := from; i < len(rows) && i < from+visible; i++ {
+		row := rows[i]
+		line := new(strings.Builder)
+		line.WriteString(prefix)
+		label := []rune(strings.Repeat("  ", row.Depth) + row.Span.Name)
+		if len(label) > labelWidth {
+			label = label[:labelWidth]
+		}
+		labelStr := out.String(string(label) + strings.Repeat(" ", labelWidth-len(label)))
+		if row.Span.ID == fe.FocusedSpan {
+			labelStr = labelStr.Bold().Reverse()
+		}
+		line.WriteString(labelStr.String())
+		line.WriteString(" ")
+		bar, ok := bars[row.Span.ID]
+		start, end := -1, -1
+		if ok {
+			start, end = col(bar.Start), col(bar.End)
+		}
+		_, color := r.statusGlyph(row.Span)
+		for c := range barWidth {
+			switch {
+			case c >= start && c <= end:
+				line.WriteString(out.String("█").Foreground(color).String())
+			case stalled[c]:
+				line.WriteString(out.String("░").Faint().String())
+			default:
+				line.WriteString(" ")
+			}
+		}
+		lines = append(lines, line.String())
+	}
+	fmt.Fprint(out, strings.Join(lines, "\n"))
+}
+
+// timelineAxis renders the time axis with ticks labeled by the time elapsed
+// since the start of the timeline.
+func (fe *frontendPretty) timelineAxis(out *termenv.Output, tl *dagui.Timeline, width int) string {
+	axis := []rune(strings.Repeat("─", width))
+	const tickSpacing = 12
+	for c := 0; c < width; c += tickSpacing {
+		offset := time.Duration(int64(tl.Duration()) * int64(c) / int64(width))
+		label := []rune("┬" + dagui.FormatDuration(offset))
+		if c+len(label) > width {
+			break
+		}
+		copy(axis[c:], label)
+	}
+	return out.String(string(axis)).Faint().String()
+}
+
+// timelineLabel returns the keymap label for switching between the tree and
+// the timeline.
+func (fe *frontendPretty) timelineLabel() string {
+	if fe.timeline {
+		return "tree"
+	}
+	return "timeline"
+}
@@ -33,6 +33,10 @@ type ClassOpts[T Typed] struct {
 	// NoIDs disables the default "id" field and disables the IDType method.
 	NoIDs bool
 
+	// NoTimeout disables the default "withTimeout" field, e.g. for types
+	// defined by modules, which are free to define their own.
+	NoTimeout bool
+
 	// Typed contains the Typed value whose Type() determines the class's type.
 	//
 	// In the simple case, we can just use a zero-value, but it is also allowed
@@ -64,6 +68,9 @@ func NewClass[T Typed](opts_ ...ClassOpts[T]) Class[T] {
 				},
 			},
 		)
+		if !opts.NoTimeout {
+			class.Install(timeoutFieldFor(class))
+		}
 		class.idable = true
 	}
 	return class
@@ -450,7 +457,7 @@ func (r Instance[T]) call(
 
 		innerVal, innerErr = r.Class.Call(ctx, r, newID.Field(), newID.View(), inputArgs)
 		if innerErr != nil {
-			return nil, innerErr
+			return nil, deadlineCause(ctx, innerErr)
 		}
 
 		if n, ok := innerVal.(Derefable); ok {
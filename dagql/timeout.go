@@ -0,0 +1,89 @@
+package dagql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dagger/dagger/dagql/call"
+)
+
+// timeoutField is the name of the field installed on objects with IDs, unless
+// their class opts out with NoTimeout, to bound the next call made on it.
+const timeoutField = "withTimeout"
+
+func timeoutFieldFor[T Typed](class Class[T]) Field[T] {
+	return Field[T]{
+		Spec: FieldSpec{
+			Name: timeoutField,
+			Description: fmt.Sprintf("Returns the %s unchanged, failing the next call made on it if it doesn't complete within the given duration.",
+				class.TypeName()),
+			Type: class.Typed(),
+			Args: []InputSpec{
+				{
+					Name:        "duration",
+					Description: `How long to wait for the next call to complete (e.g., "30s", "5m").`,
+					Type:        String(""),
+				},
+			},
+		},
+		Func: func(ctx context.Context, self Instance[T], args map[string]Input) (Typed, error) {
+			if _, err := parseTimeout(string(args["duration"].(String))); err != nil {
+				return nil, err
+			}
+			// return the bare value so that it's wrapped in this call's ID,
+			// which the next call is bound by
+			return self.Self, nil
+		},
+	}
+}
+
+// CallTimeout returns the duration given to withTimeout if the receiver of
+// the call is the result of withTimeout.
+func CallTimeout(id *call.ID) (time.Duration, bool) {
+	receiver := id.Receiver()
+	// functions of the same name implemented by modules aren't timeouts
+	if receiver == nil || receiver.Field() != timeoutField || receiver.Module() != nil {
+		return 0, false
+	}
+	for _, arg := range receiver.Args() {
+		if arg.Name() != "duration" {
+			continue
+		}
+		lit, ok := arg.Value().(*call.LiteralString)
+		if !ok {
+			return 0, false
+		}
+		timeout, err := parseTimeout(lit.Value())
+		if err != nil {
+			return 0, false
+		}
+		return timeout, true
+	}
+	return 0, false
+}
+
+// deadlineCause returns the cause of the context's deadline in place of err,
+// so that e.g. a timed out call reports its timeout rather than a bare
+// "context deadline exceeded".
+func deadlineCause(ctx context.Context, err error) error {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	if cause := context.Cause(ctx); cause != nil && cause != ctx.Err() {
+		return cause
+	}
+	return err
+}
+
+func parseTimeout(duration string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(duration)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration: %w", err)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("duration must be positive, got %s", timeout)
+	}
+	return timeout, nil
+}
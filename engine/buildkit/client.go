@@ -8,6 +8,7 @@ import (
 	"net"
 	"sync"
 
+	"github.com/distribution/reference"
 	bkcache "github.com/moby/buildkit/cache"
 	bkcacheconfig "github.com/moby/buildkit/cache/config"
 	"github.com/moby/buildkit/cache/remotecache"
@@ -30,6 +31,8 @@ import (
 	"google.golang.org/grpc/metadata"
 
 	"github.com/dagger/dagger/engine"
+	"github.com/dagger/dagger/engine/circuit"
+	"github.com/dagger/dagger/engine/secretscan"
 	"github.com/dagger/dagger/engine/session"
 )
 
@@ -66,6 +69,26 @@ type Opts struct {
 
 	Interactive        bool
 	InteractiveCommand []string
+
+	// KeepGoing solves independent branches of a build on their own, so that
+	// a failure in one doesn't cancel the others.
+	KeepGoing bool
+
+	// Breakers short-circuit calls to external dependencies that are failing
+	// repeatedly.
+	Breakers *circuit.Breakers
+
+	// SecretScanner, if set, stops exports and publishes of files and images
+	// that contain potential secrets.
+	SecretScanner *secretscan.Scanner
+
+	// Deterministic, if set, normalizes exported directories and images so
+	// that builds are reproducible.
+	Deterministic *DeterministicOpts
+
+	// ExportConflict is what to do when an export to the host overlaps with
+	// one in progress.
+	ExportConflict engine.ExportConflictPolicy
 }
 
 type ResolveCacheExporterFunc func(ctx context.Context, g bksession.Group) (remotecache.Exporter, error)
@@ -81,6 +104,8 @@ type Client struct {
 
 	ops   map[digest.Digest]opCtx
 	opsmu sync.RWMutex
+
+	hostExports hostExports
 }
 
 type opCtx struct {
@@ -169,6 +194,11 @@ func (c *Client) Solve(ctx context.Context, req bkgw.SolveRequest) (_ *Result, r
 	if v := ctx.Value("secret-translator"); v != nil {
 		gw.secretTranslator = v.(func(string) (string, error))
 	}
+	if c.KeepGoing && req.Evaluate && req.Definition != nil && req.Frontend == "" {
+		if err := c.solveBranches(ctx, gw, req); err != nil {
+			return nil, err
+		}
+	}
 	llbRes, err := gw.Solve(ctx, req, c.ID())
 	if err != nil {
 		return nil, WrapError(ctx, err, c)
@@ -210,8 +240,21 @@ func (c *Client) ResolveImageConfig(ctx context.Context, ref string, opt sourcer
 	defer cancel(errors.New("resolve image config done"))
 	ctx = withOutgoingContext(ctx)
 
+	var registry string
+	if named, err := reference.ParseNormalizedNamed(ref); err == nil {
+		registry = reference.Domain(named)
+	}
+	var (
+		resolved string
+		dgst     digest.Digest
+		config   []byte
+	)
 	imr := sourceresolver.NewImageMetaResolver(c.LLBBridge)
-	return imr.ResolveImageConfig(ctx, ref, opt)
+	err = c.Breakers.Do(ctx, registry, func() (err error) {
+		resolved, dgst, config, err = imr.ResolveImageConfig(ctx, ref, opt)
+		return err
+	})
+	return resolved, dgst, config, err
 }
 
 func (c *Client) ResolveSourceMetadata(ctx context.Context, op *bksolverpb.SourceOp, opt sourceresolver.Opt) (*sourceresolver.MetaResponse, error) {
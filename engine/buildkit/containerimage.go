@@ -38,10 +38,18 @@ func (c *Client) PublishContainerImage(
 	}
 	defer cancel(errors.New("publish container image done"))
 
+	inputByPlatform = c.Deterministic.normalizeImages(inputByPlatform)
+	opts = c.Deterministic.imageExporterOpts(opts)
 	combinedResult, err := c.getContainerResult(ctx, inputByPlatform)
 	if err != nil {
 		return nil, err
 	}
+	if err := c.verifyReproducible(ctx, imageDefinitions(inputByPlatform), combinedResult); err != nil {
+		return nil, err
+	}
+	if err := c.scanForSecrets(ctx, combinedResult); err != nil {
+		return nil, err
+	}
 
 	exporter, err := c.Worker.Exporter(bkclient.ExporterImage, c.SessionManager)
 	if err != nil {
@@ -81,10 +89,18 @@ func (c *Client) ExportContainerImage(
 		return nil, fmt.Errorf("path %q escapes workdir; use an absolute path instead", destPath)
 	}
 
+	inputByPlatform = c.Deterministic.normalizeImages(inputByPlatform)
+	opts = c.Deterministic.imageExporterOpts(opts)
 	combinedResult, err := c.getContainerResult(ctx, inputByPlatform)
 	if err != nil {
 		return nil, err
 	}
+	if err := c.verifyReproducible(ctx, imageDefinitions(inputByPlatform), combinedResult); err != nil {
+		return nil, err
+	}
+	if err := c.scanForSecrets(ctx, combinedResult); err != nil {
+		return nil, err
+	}
 
 	exporterName := bkclient.ExporterDocker
 	if len(combinedResult.Refs) > 1 {
@@ -106,6 +122,12 @@ func (c *Client) ExportContainerImage(
 		return nil, fmt.Errorf("failed to get requester session ID from client metadata: %w", err)
 	}
 
+	finishExport, err := c.startHostExport(ctx, destPath)
+	if err != nil {
+		return nil, err
+	}
+	defer finishExport()
+
 	ctx = engine.LocalExportOpts{
 		Path:         destPath,
 		IsFileStream: true,
@@ -136,10 +158,15 @@ func (c *Client) ContainerImageToTarball(
 	}
 	defer cancel(errors.New("container image to tarball done"))
 
+	inputByPlatform = c.Deterministic.normalizeImages(inputByPlatform)
+	opts = c.Deterministic.imageExporterOpts(opts)
 	combinedResult, err := c.getContainerResult(ctx, inputByPlatform)
 	if err != nil {
 		return nil, err
 	}
+	if err := c.verifyReproducible(ctx, imageDefinitions(inputByPlatform), combinedResult); err != nil {
+		return nil, err
+	}
 
 	exporterName := bkclient.ExporterDocker
 	if len(combinedResult.Refs) > 1 {
@@ -185,6 +212,19 @@ func (c *Client) ContainerImageToTarball(
 	return localDef.ToPB(), nil
 }
 
+// imageDefinitions returns the definitions of the filesystems of an image,
+// keyed like the refs of its combined result.
+func imageDefinitions(inputByPlatform map[string]ContainerExport) map[string]*bksolverpb.Definition {
+	defs := make(map[string]*bksolverpb.Definition, len(inputByPlatform))
+	for platform, input := range inputByPlatform {
+		if len(inputByPlatform) == 1 {
+			platform = ""
+		}
+		defs[platform] = input.Definition
+	}
+	return defs
+}
+
 func (c *Client) getContainerResult(
 	ctx context.Context,
 	inputByPlatform map[string]ContainerExport,
@@ -0,0 +1,210 @@
+package buildkit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	bkcache "github.com/moby/buildkit/cache"
+	"github.com/moby/buildkit/cache/contenthash"
+	"github.com/moby/buildkit/client/llb"
+	"github.com/moby/buildkit/exporter/containerimage/exptypes"
+	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	bksession "github.com/moby/buildkit/session"
+	bksolverpb "github.com/moby/buildkit/solver/pb"
+	solverresult "github.com/moby/buildkit/solver/result"
+	"github.com/opencontainers/go-digest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"dagger.io/dagger/telemetry"
+)
+
+// DeterministicOpts configures normalization of the directories and images
+// leaving the engine, so that building the same thing twice produces the same
+// bytes.
+type DeterministicOpts struct {
+	// Epoch is the time that file mtimes and image creation times are set to.
+	Epoch time.Time
+
+	// Verify builds every output a second time, without cache, and compares
+	// the digests of both builds.
+	Verify bool
+}
+
+// Rebuild is the outcome of building an output twice to check that it's
+// reproducible.
+type Rebuild struct {
+	// Platform is the platform of the image built, if any.
+	Platform string `json:"platform,omitempty"`
+	First    string `json:"first"`
+	Second   string `json:"second"`
+}
+
+// NormalizeEnv pins the timezone and locale in env, replacing any set
+// explicitly.
+func NormalizeEnv(env []string) []string {
+	normalized := make([]string, 0, len(env)+2)
+	for _, kv := range env {
+		switch k, _, _ := strings.Cut(kv, "="); k {
+		case "TZ", "LC_ALL":
+			continue
+		}
+		normalized = append(normalized, kv)
+	}
+	return append(normalized, "TZ=UTC", "LC_ALL=C")
+}
+
+// normalizeDir returns a definition of the same filesystem as def, with every
+// file's mtime set to the epoch and owned by root.
+func (opts *DeterministicOpts) normalizeDir(ctx context.Context, def *bksolverpb.Definition) (*bksolverpb.Definition, error) {
+	if opts == nil || def == nil || def.Def == nil {
+		return def, nil
+	}
+	defOp, err := llb.NewDefinitionOp(def)
+	if err != nil {
+		return nil, err
+	}
+	st := llb.Scratch().File(
+		llb.Copy(llb.NewState(defOp), "/", "/", &llb.CopyInfo{
+			CopyDirContentsOnly: true,
+			CreatedTime:         &opts.Epoch,
+		}, llb.WithUIDGID(0, 0)),
+		llb.WithCustomName("normalize files for reproducible output"),
+		WithTracePropagation(ctx),
+	)
+	normalized, err := st.Marshal(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to normalize files: %w", err)
+	}
+	return normalized.ToPB(), nil
+}
+
+// imageExporterOpts returns opts with timestamps in the image's layers and
+// config rewritten to the epoch.
+func (opts *DeterministicOpts) imageExporterOpts(exporterOpts map[string]string) map[string]string {
+	if opts == nil {
+		return exporterOpts
+	}
+	exporterOpts = maps.Clone(exporterOpts)
+	if exporterOpts == nil {
+		exporterOpts = map[string]string{}
+	}
+	exporterOpts[string(exptypes.OptKeyRewriteTimestamp)] = "true"
+	exporterOpts[string(exptypes.OptKeySourceDateEpoch)] = strconv.FormatInt(opts.Epoch.Unix(), 10)
+	return exporterOpts
+}
+
+// normalizeImages pins the timezone and locale in the config of each image.
+func (opts *DeterministicOpts) normalizeImages(inputByPlatform map[string]ContainerExport) map[string]ContainerExport {
+	if opts == nil {
+		return inputByPlatform
+	}
+	normalized := make(map[string]ContainerExport, len(inputByPlatform))
+	for platform, input := range inputByPlatform {
+		input.Config.Env = NormalizeEnv(input.Config.Env)
+		normalized[platform] = input
+	}
+	return normalized
+}
+
+// verifyReproducible builds each definition again without cache, comparing
+// the checksum of the new result with that of the result already built, and
+// records the outcome on the current span. Definitions and results are keyed
+// by platform, or the empty string for a single result.
+func (c *Client) verifyReproducible(
+	ctx context.Context,
+	defs map[string]*bksolverpb.Definition,
+	res *solverresult.Result[bkcache.ImmutableRef],
+) error {
+	if c.Deterministic == nil || !c.Deterministic.Verify {
+		return nil
+	}
+	refs := map[string]bkcache.ImmutableRef{}
+	if res.Ref != nil {
+		refs[""] = res.Ref
+	}
+	for platform, ref := range res.Refs {
+		refs[platform] = ref
+	}
+
+	var rebuilds []Rebuild
+	reproducible := true
+	for _, platform := range slices.Sorted(maps.Keys(defs)) {
+		first, err := c.checksumRef(ctx, refs[platform])
+		if err != nil {
+			return err
+		}
+		second, err := c.rebuildChecksum(ctx, defs[platform])
+		if err != nil {
+			return fmt.Errorf("failed to rebuild without cache: %w", err)
+		}
+		rebuilds = append(rebuilds, Rebuild{
+			Platform: platform,
+			First:    first.String(),
+			Second:   second.String(),
+		})
+		if first != second {
+			reproducible = false
+		}
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.Bool(telemetry.ReproducibleAttr, reproducible),
+	}
+	if payload, err := json.Marshal(rebuilds); err == nil {
+		attrs = append(attrs, attribute.String(telemetry.ReproducibleRebuildsAttr, string(payload)))
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attrs...)
+	return nil
+}
+
+func (c *Client) rebuildChecksum(ctx context.Context, def *bksolverpb.Definition) (digest.Digest, error) {
+	res, err := c.Solve(ctx, bkgw.SolveRequest{
+		Definition: withoutCache(def),
+		Evaluate:   true,
+	})
+	if err != nil {
+		return "", err
+	}
+	cacheRes, err := ConvertToWorkerCacheResult(ctx, res)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert result: %w", err)
+	}
+	ref, err := cacheRes.SingleRef()
+	if err != nil {
+		return "", err
+	}
+	return c.checksumRef(ctx, ref)
+}
+
+// checksumRef returns the checksum of a filesystem's contents, which covers
+// paths, contents, modes and ownership, but not timestamps.
+func (c *Client) checksumRef(ctx context.Context, ref bkcache.ImmutableRef) (digest.Digest, error) {
+	dgst, err := contenthash.Checksum(ctx, ref, "/", contenthash.ChecksumOpts{}, bksession.NewGroup(c.ID()))
+	if err != nil {
+		return "", fmt.Errorf("failed to checksum result: %w", err)
+	}
+	return dgst, nil
+}
+
+// withoutCache returns a copy of def with every op set to ignore the cache.
+func withoutCache(def *bksolverpb.Definition) *bksolverpb.Definition {
+	uncached := &bksolverpb.Definition{
+		Def:      def.Def,
+		Source:   def.Source,
+		Metadata: make(map[digest.Digest]bksolverpb.OpMetadata, len(def.Def)),
+	}
+	for _, dt := range def.Def {
+		dgst := digest.FromBytes(dt)
+		md := def.Metadata[dgst]
+		md.IgnoreCache = true
+		uncached.Metadata[dgst] = md
+	}
+	return uncached
+}
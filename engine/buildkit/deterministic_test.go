@@ -0,0 +1,48 @@
+package buildkit
+
+import (
+	"testing"
+	"time"
+
+	bksolverpb "github.com/moby/buildkit/solver/pb"
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeEnv(t *testing.T) {
+	require.Equal(t,
+		[]string{"PATH=/bin", "LANG=fr_FR.UTF-8", "TZ=UTC", "LC_ALL=C"},
+		NormalizeEnv([]string{"PATH=/bin", "TZ=Europe/Paris", "LANG=fr_FR.UTF-8", "LC_ALL=fr_FR.UTF-8"}),
+	)
+}
+
+func TestImageExporterOpts(t *testing.T) {
+	opts := &DeterministicOpts{Epoch: time.Unix(1700000000, 0)}
+	exporterOpts := map[string]string{"name": "registry.example.com/app"}
+	require.Equal(t, map[string]string{
+		"name":              "registry.example.com/app",
+		"rewrite-timestamp": "true",
+		"source-date-epoch": "1700000000",
+	}, opts.imageExporterOpts(exporterOpts))
+	require.Len(t, exporterOpts, 1)
+
+	var disabled *DeterministicOpts
+	require.Equal(t, exporterOpts, disabled.imageExporterOpts(exporterOpts))
+}
+
+func TestWithoutCache(t *testing.T) {
+	ops := [][]byte{[]byte("op1"), []byte("op2")}
+	def := &bksolverpb.Definition{
+		Def: ops,
+		Metadata: map[digest.Digest]bksolverpb.OpMetadata{
+			digest.FromBytes(ops[0]): {Description: map[string]string{"llb.customname": "build"}},
+		},
+	}
+	uncached := withoutCache(def)
+	require.Len(t, uncached.Metadata, 2)
+	for _, dt := range ops {
+		require.True(t, uncached.Metadata[digest.FromBytes(dt)].IgnoreCache)
+	}
+	require.Equal(t, "build", uncached.Metadata[digest.FromBytes(ops[0])].Description["llb.customname"])
+	require.False(t, def.Metadata[digest.FromBytes(ops[0])].IgnoreCache)
+}
@@ -102,6 +102,9 @@ type ExecutionMetadata struct {
 
 	// If true, skip injecting dagger-init into the container.
 	NoInit bool
+
+	// If true, run without network access and with a fixed clock.
+	Hermetic bool
 }
 
 const executionMetadataKey = "dagger.executionMetadata"
@@ -156,6 +159,13 @@ func (w *Worker) Run(
 		return nil, err
 	}
 
+	// don't start new execs while the session is paused
+	if w.execMD != nil && w.sessionHandler != nil {
+		if err := w.sessionHandler.WaitIfPaused(ctx, w.execMD.SessionID); err != nil {
+			return nil, err
+		}
+	}
+
 	state := newExecState(id, &procInfo, rootMount, mounts, started)
 	return nil, w.run(ctx, state,
 		w.setupNetwork,
@@ -163,12 +173,14 @@ func (w *Worker) Run(
 		w.generateBaseSpec,
 		w.filterEnvs,
 		w.setupRootfs,
+		w.setupHermetic,
 		w.setUserGroup,
 		w.setExitCodePath,
 		w.setupStdio,
 		w.setupOTel,
 		w.setupSecretScrubbing,
 		w.setProxyEnvs,
+		w.setDeterministicEnv,
 		w.enableGPU,
 		w.createCWD,
 		w.setupNestedClient,
@@ -58,6 +58,7 @@ const (
 	DaggerRedirectStderrEnv  = "_DAGGER_REDIRECT_STDERR"
 	DaggerHostnameAliasesEnv = "_DAGGER_HOSTNAME_ALIASES"
 	DaggerNoInitEnv          = "_DAGGER_NOINIT"
+	DaggerHermeticEnv        = "_DAGGER_HERMETIC"
 
 	DaggerSessionPortEnv  = "DAGGER_SESSION_PORT"
 	DaggerSessionTokenEnv = "DAGGER_SESSION_TOKEN"
@@ -79,6 +80,7 @@ var removeEnvs = map[string]struct{}{
 	DaggerRedirectStderrEnv:  {},
 	DaggerHostnameAliasesEnv: {},
 	DaggerNoInitEnv:          {},
+	DaggerHermeticEnv:        {},
 }
 
 type execState struct {
@@ -810,6 +812,21 @@ func (w *Worker) setupSecretScrubbing(ctx context.Context, state *execState) err
 	return nil
 }
 
+// setDeterministicEnv pins the timezone and locale of user execs in
+// deterministic mode, and sets SOURCE_DATE_EPOCH for tools that support it
+// unless the user set it already.
+func (w *Worker) setDeterministicEnv(_ context.Context, state *execState) error {
+	if w.deterministic == nil || (w.execMD != nil && w.execMD.Internal) {
+		return nil
+	}
+	state.spec.Process.Env = NormalizeEnv(state.spec.Process.Env)
+	if _, ok := state.origEnvMap["SOURCE_DATE_EPOCH"]; !ok {
+		state.spec.Process.Env = append(state.spec.Process.Env,
+			"SOURCE_DATE_EPOCH="+strconv.FormatInt(w.deterministic.Epoch.Unix(), 10))
+	}
+	return nil
+}
+
 func (w *Worker) setProxyEnvs(_ context.Context, state *execState) error {
 	for _, upperProxyEnvName := range engine.ProxyEnvNames {
 		upperProxyVal, upperSet := state.origEnvMap[upperProxyEnvName]
@@ -1010,6 +1027,10 @@ func (w *Worker) setupNestedClient(ctx context.Context, state *execState) (rerr
 		return nil
 	})
 
+	// the nested client is gone once its exec exits, even if it crashed
+	state.cleanups.Add("release nested client", Infallible(func() {
+		w.sessionHandler.NestedClientExited(w.execMD)
+	}))
 	state.cleanups.Add("wait for nested client server pool", srvPool.Wait)
 	// state.cleanups.ReAdd(stopSessionSrv)
 	state.cleanups.Add("close nested client http server", httpSrv.Close)
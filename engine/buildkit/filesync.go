@@ -10,17 +10,24 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/containerd/continuity/fs"
-	bkclient "github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/exporter/local"
 	bkgw "github.com/moby/buildkit/frontend/gateway/client"
 	"github.com/moby/buildkit/session/filesync"
 	"github.com/moby/buildkit/snapshot"
 	bksolverpb "github.com/moby/buildkit/solver/pb"
 	"github.com/moby/buildkit/util/bklog"
+	"github.com/opencontainers/go-digest"
+	"github.com/tonistiigi/fsutil"
 	fsutiltypes "github.com/tonistiigi/fsutil/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 
 	"github.com/dagger/dagger/engine"
+	"github.com/dagger/dagger/engine/secretscan"
 )
 
 func (c *Client) diffcopy(ctx context.Context, opts engine.LocalImportOpts, msg any) error {
@@ -80,6 +87,7 @@ func (c *Client) LocalDirExport(
 	def *bksolverpb.Definition,
 	destPath string,
 	merge bool,
+	progress ExportProgress,
 ) (rerr error) {
 	ctx = bklog.WithLogger(ctx, bklog.G(ctx).WithField("export_path", destPath))
 	bklog.G(ctx).Debug("exporting local dir")
@@ -102,6 +110,10 @@ func (c *Client) LocalDirExport(
 		return fmt.Errorf("path %q escapes workdir; use an absolute path instead", destPath)
 	}
 
+	def, err = c.Deterministic.normalizeDir(ctx, def)
+	if err != nil {
+		return err
+	}
 	res, err := c.Solve(ctx, bkgw.SolveRequest{Definition: def})
 	if err != nil {
 		return fmt.Errorf("failed to solve for local export: %w", err)
@@ -110,35 +122,59 @@ func (c *Client) LocalDirExport(
 	if err != nil {
 		return fmt.Errorf("failed to convert result: %w", err)
 	}
-
-	exporter, err := c.Worker.Exporter(bkclient.ExporterLocal, c.SessionManager)
-	if err != nil {
+	if err := c.scanForSecrets(ctx, cacheRes); err != nil {
 		return err
 	}
-
-	expInstance, err := exporter.Resolve(ctx, 0, nil)
-	if err != nil {
-		return fmt.Errorf("failed to resolve exporter: %w", err)
+	if err := c.verifyReproducible(ctx, map[string]*bksolverpb.Definition{"": def}, cacheRes); err != nil {
+		return err
 	}
 
 	clientMetadata, err := engine.ClientMetadataFromContext(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get requester session ID: %w", err)
 	}
+	outputFS, cleanup, err := local.CreateFS(ctx, clientMetadata.ClientID, "", cacheRes.Ref, nil, time.Now().Truncate(time.Second), local.CreateFSOpts{})
+	if err != nil {
+		return fmt.Errorf("failed to create export fs: %w", err)
+	}
+	if cleanup != nil {
+		defer cleanup()
+	}
+	sendFS, err := newExportFS(ctx, outputFS, progress)
+	if err != nil {
+		return err
+	}
+
+	finishExport, err := c.startHostExport(ctx, destPath)
+	if err != nil {
+		return err
+	}
+	defer finishExport()
 
 	ctx = engine.LocalExportOpts{
 		Path:  destPath,
 		Merge: merge,
 	}.AppendToOutgoingContext(ctx)
 
-	_, descRef, err := expInstance.Export(ctx, cacheRes, nil, clientMetadata.ClientID)
+	clientCaller, err := c.GetSessionCaller(ctx, false)
 	if err != nil {
+		return fmt.Errorf("failed to get requester session: %w", err)
+	}
+	var trailer metadata.MD
+	diffCopyClient, err := filesync.NewFileSendClient(clientCaller.Conn()).DiffCopy(ctx, grpc.Trailer(&trailer))
+	if err != nil {
+		return fmt.Errorf("failed to create diff copy client: %w", err)
+	}
+	defer diffCopyClient.CloseSend()
+
+	if err := fsutil.Send(ctx, diffCopyClient, sendFS, nil); err != nil {
 		return fmt.Errorf("failed to export: %w", err)
 	}
-	if descRef != nil {
-		descRef.Release()
+	if err := waitForExport(diffCopyClient); err != nil {
+		return err
 	}
-	return nil
+	progress.report(sendFS.total, sendFS.total)
+	return verifyExportDigest(trailer, sendFS.digest())
 }
 
 func (c *Client) LocalFileExport(
@@ -147,6 +183,7 @@ func (c *Client) LocalFileExport(
 	destPath string,
 	filePath string,
 	allowParentDirPath bool,
+	progress ExportProgress,
 ) (rerr error) {
 	ctx = bklog.WithLogger(ctx, bklog.G(ctx).
 		WithField("export_path", destPath).
@@ -196,6 +233,13 @@ func (c *Client) LocalFileExport(
 	if err != nil {
 		return fmt.Errorf("failed to get root path: %w", err)
 	}
+	findings, err := c.SecretScanner.ScanFile(ctx, mntFilePath, filePath)
+	if err != nil {
+		return fmt.Errorf("failed to scan for secrets: %w", err)
+	}
+	if err := secretscan.Report(ctx, findings); err != nil {
+		return err
+	}
 	file, err := os.Open(mntFilePath)
 	if err != nil {
 		return fmt.Errorf("failed to open file: %w", err)
@@ -206,29 +250,40 @@ func (c *Client) LocalFileExport(
 		return fmt.Errorf("failed to stat file: %w", err)
 	}
 
+	finishExport, err := c.startHostExport(ctx, destPath)
+	if err != nil {
+		return err
+	}
+	defer finishExport()
+
 	ctx = engine.LocalExportOpts{
 		Path:               destPath,
 		IsFileStream:       true,
 		FileOriginalName:   filepath.Base(filePath),
 		AllowParentDirPath: allowParentDirPath,
 		FileMode:           stat.Mode().Perm(),
+		FileSize:           stat.Size(),
 	}.AppendToOutgoingContext(ctx)
 
 	clientCaller, err := c.GetSessionCaller(ctx, false)
 	if err != nil {
 		return fmt.Errorf("failed to get requester session: %w", err)
 	}
-	diffCopyClient, err := filesync.NewFileSendClient(clientCaller.Conn()).DiffCopy(ctx)
+	var trailer metadata.MD
+	diffCopyClient, err := filesync.NewFileSendClient(clientCaller.Conn()).DiffCopy(ctx, grpc.Trailer(&trailer))
 	if err != nil {
 		return fmt.Errorf("failed to create diff copy client: %w", err)
 	}
 	defer diffCopyClient.CloseSend()
 
+	// digest the file as it's sent so that the receiver's copy can be checked
+	digester := digest.Canonical.Digester()
+	sending := io.TeeReader(file, digester.Hash())
 	fileSizeLeft := stat.Size()
 	chunkSize := int64(MaxFileContentsChunkSize)
 	for fileSizeLeft > 0 {
 		buf := new(bytes.Buffer) // TODO: more efficient to use bufio.Writer, reuse buffers, sync.Pool, etc.
-		n, err := io.CopyN(buf, file, chunkSize)
+		n, err := io.CopyN(buf, sending, chunkSize)
 		if errors.Is(err, io.EOF) {
 			err = nil
 		}
@@ -245,11 +300,20 @@ func (c *Client) LocalFileExport(
 		} else if err != nil {
 			return fmt.Errorf("failed to send file chunk: %w", err)
 		}
+		progress.report(stat.Size()-fileSizeLeft, stat.Size())
+	}
+	if err := waitForExport(diffCopyClient); err != nil {
+		return err
 	}
+	return verifyExportDigest(trailer, digester.Digest())
+}
+
+// waitForExport closes the sending side of an export to the caller and waits
+// for the caller to finish writing it.
+func waitForExport(diffCopyClient filesync.FileSend_DiffCopyClient) error {
 	if err := diffCopyClient.CloseSend(); err != nil {
 		return fmt.Errorf("failed to close send: %w", err)
 	}
-	// wait for receiver to finish
 	var msg filesync.BytesMessage
 	if err := diffCopyClient.RecvMsg(&msg); err != io.EOF {
 		return fmt.Errorf("unexpected closing recv msg: %w", err)
@@ -257,6 +321,107 @@ func (c *Client) LocalFileExport(
 	return nil
 }
 
+// verifyExportDigest checks the digest of what the caller wrote, as returned
+// in the trailer of an export, against the digest of what was sent. Callers
+// that predate the trailer return none, in which case there's nothing to check.
+func verifyExportDigest(trailer metadata.MD, sent digest.Digest) error {
+	written, ok, err := engine.ExportDigestFromMD(trailer)
+	if err != nil {
+		return err
+	}
+	if ok && written != sent {
+		return fmt.Errorf("export is corrupt: the host wrote content with digest %s, expected %s", written, sent)
+	}
+	return nil
+}
+
+// ExportProgress is called as a file or directory is exported to the caller,
+// with the number of bytes sent so far and in total.
+type ExportProgress func(sent, total int64)
+
+func (progress ExportProgress) report(sent, total int64) {
+	if progress != nil {
+		progress(sent, total)
+	}
+}
+
+// exportFS is the filesystem sent by a directory export. It digests the
+// contents of each file as it's read to be sent, and reports the progress.
+type exportFS struct {
+	fsutil.FS
+	progress ExportProgress
+	total    int64
+
+	mu      sync.Mutex
+	sent    int64
+	digests map[string]digest.Digest
+}
+
+func newExportFS(ctx context.Context, fs fsutil.FS, progress ExportProgress) (*exportFS, error) {
+	efs := &exportFS{FS: fs, progress: progress, digests: map[string]digest.Digest{}}
+	err := fs.Walk(ctx, "", func(_ string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		efs.total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to size export: %w", err)
+	}
+	return efs, nil
+}
+
+func (efs *exportFS) Open(p string) (io.ReadCloser, error) {
+	rc, err := efs.FS.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	return &exportFile{ReadCloser: rc, fs: efs, path: p, digester: digest.Canonical.Digester()}, nil
+}
+
+// digest returns the digest of every file sent, to compare with the files the
+// caller wrote.
+func (efs *exportFS) digest() digest.Digest {
+	efs.mu.Lock()
+	defer efs.mu.Unlock()
+	return engine.ExportedFilesDigest(efs.digests)
+}
+
+type exportFile struct {
+	io.ReadCloser
+	fs       *exportFS
+	path     string
+	digester digest.Digester
+}
+
+func (f *exportFile) Read(p []byte) (int, error) {
+	n, err := f.ReadCloser.Read(p)
+	f.digester.Hash().Write(p[:n])
+	f.fs.mu.Lock()
+	f.fs.sent += int64(n)
+	sent := f.fs.sent
+	f.fs.mu.Unlock()
+	if n > 0 {
+		f.fs.progress.report(sent, f.fs.total)
+	}
+	return n, err
+}
+
+func (f *exportFile) Close() error {
+	f.fs.mu.Lock()
+	f.fs.digests[filepath.ToSlash(f.path)] = f.digester.Digest()
+	f.fs.mu.Unlock()
+	return f.ReadCloser.Close()
+}
+
 // IOReaderExport exports the contents of an io.Reader to the caller's local fs as a file
 // TODO: de-dupe this with the above method to extent possible
 func (c *Client) IOReaderExport(ctx context.Context, r io.Reader, destPath string, destMode os.FileMode) (rerr error) {
@@ -270,6 +435,12 @@ func (c *Client) IOReaderExport(ctx context.Context, r io.Reader, destPath strin
 		lg.Trace("finished exporting bytes")
 	}()
 
+	finishExport, err := c.startHostExport(ctx, destPath)
+	if err != nil {
+		return err
+	}
+	defer finishExport()
+
 	ctx = engine.LocalExportOpts{
 		Path:             destPath,
 		IsFileStream:     true,
@@ -0,0 +1,47 @@
+package buildkit
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+	"github.com/tonistiigi/fsutil"
+
+	"github.com/dagger/dagger/engine"
+)
+
+func TestExportFS(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a"), []byte("aaaa"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "b"), []byte("bb"), 0o600))
+	require.NoError(t, os.Symlink("a", filepath.Join(dir, "link")))
+	fs, err := fsutil.NewFS(dir)
+	require.NoError(t, err)
+
+	var sent, total int64
+	efs, err := newExportFS(ctx, fs, func(s, t int64) {
+		sent, total = s, t
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(6), efs.total)
+
+	for _, p := range []string{"a", "sub/b"} {
+		f, err := efs.Open(p)
+		require.NoError(t, err)
+		_, err = io.Copy(io.Discard, f)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+	require.Equal(t, int64(6), sent)
+	require.Equal(t, int64(6), total)
+	require.Equal(t, engine.ExportedFilesDigest(map[string]digest.Digest{
+		"a":     digest.FromString("aaaa"),
+		"sub/b": digest.FromString("bb"),
+	}), efs.digest())
+}
@@ -0,0 +1,164 @@
+package buildkit
+
+import (
+	"context"
+	"debug/elf"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/containerd/continuity/fs"
+	"github.com/moby/buildkit/solver/pb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"dagger.io/dagger/telemetry"
+)
+
+// faketimeLibPaths are where distros install libfaketime, which fixes the
+// clock of the processes it's preloaded into.
+var faketimeLibPaths = []string{
+	"/usr/lib/faketime/libfaketime.so.1",
+	"/usr/lib/x86_64-linux-gnu/faketime/libfaketime.so.1",
+	"/usr/lib/aarch64-linux-gnu/faketime/libfaketime.so.1",
+	"/usr/lib/arm-linux-gnueabihf/faketime/libfaketime.so.1",
+	"/usr/lib64/faketime/libfaketime.so.1",
+	"/usr/local/lib/faketime/libfaketime.so.1",
+}
+
+// setupHermetic pins the timezone and locale of hermetic execs, which already
+// run without network access, and fixes their clock where it can.
+//
+// Fixing the clock is best-effort: it relies on libfaketime being installed
+// in the container, and only affects dynamically linked programs that read
+// the clock through libc. Whether it was fixed is recorded on the exec's span
+// rather than failing the exec.
+func (w *Worker) setupHermetic(ctx context.Context, state *execState) error {
+	if w.execMD == nil || !w.execMD.Hermetic {
+		return nil
+	}
+	if state.procInfo.Meta.NetMode != pb.NetMode_NONE {
+		// should never happen, but the guarantee is worth checking
+		return errors.New("hermetic exec must run without network access")
+	}
+	span := trace.SpanFromContext(ctx)
+	lib, err := findFaketimeLib(state.rootfsPath)
+	if err == nil {
+		err = checkDynamicallyLinked(state.rootfsPath, execPath(state.procInfo.Meta.Args), state.spec.Process.Env)
+	}
+	if err != nil {
+		state.spec.Process.Env = NormalizeEnv(state.spec.Process.Env)
+		span.SetAttributes(attribute.Bool(telemetry.ExecHermeticClockAttr, false))
+		span.AddEvent("Clock not fixed", trace.WithAttributes(attribute.String("reason", err.Error())))
+		return nil
+	}
+	epoch := time.Unix(0, 0)
+	if w.deterministic != nil {
+		epoch = w.deterministic.Epoch
+	}
+	state.spec.Process.Env = HermeticEnv(state.spec.Process.Env, lib, epoch)
+	span.SetAttributes(attribute.Bool(telemetry.ExecHermeticClockAttr, true))
+	return nil
+}
+
+// findFaketimeLib returns the path of libfaketime in the container's rootfs.
+func findFaketimeLib(rootfs string) (string, error) {
+	for _, lib := range faketimeLibPaths {
+		p, err := fs.RootPath(rootfs, lib)
+		if err != nil {
+			continue
+		}
+		if info, err := os.Stat(p); err == nil && info.Mode().IsRegular() {
+			return lib, nil
+		}
+	}
+	return "", errors.New("libfaketime is not installed in the container, " +
+		`e.g. "apk add libfaketime" or "apt-get install faketime"`)
+}
+
+// execPath returns the program an exec runs, skipping Dagger's init.
+func execPath(args []string) string {
+	if len(args) > 0 && args[0] == "/.init" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// checkDynamicallyLinked returns an error if the program, looked up in the
+// container's rootfs and PATH, is a statically linked executable, which
+// libfaketime can't be preloaded into. Programs that can't be found or aren't
+// ELF executables, e.g. scripts, are assumed to be fine.
+func checkDynamicallyLinked(rootfs, program string, env []string) error {
+	if program == "" {
+		return nil
+	}
+	candidates := []string{program}
+	if !strings.Contains(program, "/") {
+		candidates = nil
+		for _, kv := range env {
+			if path, ok := strings.CutPrefix(kv, "PATH="); ok {
+				for _, dir := range filepath.SplitList(path) {
+					candidates = append(candidates, filepath.Join(dir, program))
+				}
+			}
+		}
+	}
+	for _, candidate := range candidates {
+		p, err := fs.RootPath(rootfs, candidate)
+		if err != nil {
+			continue
+		}
+		f, err := elf.Open(p)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			// not an ELF executable
+			return nil
+		}
+		static := f.Type == elf.ET_EXEC || f.Type == elf.ET_DYN
+		for _, prog := range f.Progs {
+			if prog.Type == elf.PT_INTERP {
+				static = false
+			}
+		}
+		f.Close()
+		if static {
+			return fmt.Errorf("%s is statically linked", candidate)
+		}
+		return nil
+	}
+	return nil
+}
+
+// HermeticEnv returns env with the clock fixed to start at the epoch through
+// the given libfaketime, and with the timezone and locale pinned. Monotonic
+// clocks are left alone so that timeouts still fire.
+func HermeticEnv(env []string, lib string, epoch time.Time) []string {
+	var preload []string
+	hermetic := make([]string, 0, len(env)+3)
+	for _, kv := range NormalizeEnv(env) {
+		k, v, _ := strings.Cut(kv, "=")
+		switch {
+		case k == "LD_PRELOAD":
+			if v != "" {
+				preload = append(preload, v)
+			}
+			continue
+		case strings.HasPrefix(k, "FAKETIME"):
+			continue
+		}
+		hermetic = append(hermetic, kv)
+	}
+	return append(hermetic,
+		"LD_PRELOAD="+strings.Join(append([]string{lib}, preload...), ":"),
+		fmt.Sprintf("FAKETIME=@%s", epoch.UTC().Format(time.DateTime)),
+		"FAKETIME_DONT_FAKE_MONOTONIC=1",
+	)
+}
@@ -0,0 +1,98 @@
+package buildkit
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHermeticEnv(t *testing.T) {
+	require.Equal(t, []string{
+		"PATH=/bin",
+		"TZ=UTC",
+		"LC_ALL=C",
+		"LD_PRELOAD=/usr/lib/faketime/libfaketime.so.1:/usr/lib/libjemalloc.so",
+		"FAKETIME=@1970-01-01 00:00:00",
+		"FAKETIME_DONT_FAKE_MONOTONIC=1",
+	}, HermeticEnv(
+		[]string{"PATH=/bin", "TZ=Europe/Paris", "LD_PRELOAD=/usr/lib/libjemalloc.so", "FAKETIME=+1d"},
+		"/usr/lib/faketime/libfaketime.so.1",
+		time.Unix(0, 0),
+	))
+}
+
+func TestFindFaketimeLib(t *testing.T) {
+	rootfs := t.TempDir()
+	_, err := findFaketimeLib(rootfs)
+	require.ErrorContains(t, err, "libfaketime is not installed")
+
+	// a symlink out of the rootfs is resolved within it
+	host := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(host, "faketime"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(host, "faketime", "libfaketime.so.1"), nil, 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(rootfs, "usr"), 0o755))
+	require.NoError(t, os.Symlink(host, filepath.Join(rootfs, "usr", "lib")))
+	_, err = findFaketimeLib(rootfs)
+	require.Error(t, err)
+
+	lib := "/usr/lib/x86_64-linux-gnu/faketime/libfaketime.so.1"
+	require.NoError(t, os.Remove(filepath.Join(rootfs, "usr", "lib")))
+	require.NoError(t, os.MkdirAll(filepath.Join(rootfs, filepath.Dir(lib)), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(rootfs, lib), nil, 0o644))
+	found, err := findFaketimeLib(rootfs)
+	require.NoError(t, err)
+	require.Equal(t, lib, found)
+}
+
+func TestCheckDynamicallyLinked(t *testing.T) {
+	rootfs := t.TempDir()
+	writeELF := func(path string, interp bool) {
+		var progs []elf.Prog64
+		if interp {
+			progs = append(progs, elf.Prog64{Type: uint32(elf.PT_INTERP)})
+		}
+		progs = append(progs, elf.Prog64{Type: uint32(elf.PT_LOAD)})
+		hdr := elf.Header64{
+			Type:      uint16(elf.ET_EXEC),
+			Machine:   uint16(elf.EM_X86_64),
+			Version:   uint32(elf.EV_CURRENT),
+			Phoff:     uint64(binary.Size(elf.Header64{})),
+			Ehsize:    uint16(binary.Size(elf.Header64{})),
+			Phentsize: uint16(binary.Size(elf.Prog64{})),
+			Phnum:     uint16(len(progs)),
+		}
+		copy(hdr.Ident[:], elf.ELFMAG)
+		hdr.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+		hdr.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+		hdr.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+		var buf bytes.Buffer
+		require.NoError(t, binary.Write(&buf, binary.LittleEndian, hdr))
+		require.NoError(t, binary.Write(&buf, binary.LittleEndian, progs))
+		require.NoError(t, os.MkdirAll(filepath.Join(rootfs, filepath.Dir(path)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(rootfs, path), buf.Bytes(), 0o755))
+	}
+	writeELF("/usr/bin/dynamic", true)
+	writeELF("/usr/local/bin/static", false)
+	require.NoError(t, os.WriteFile(filepath.Join(rootfs, "usr", "bin", "script"), []byte("#!/bin/sh\n"), 0o755))
+	env := []string{"PATH=/usr/local/bin:/usr/bin"}
+
+	require.NoError(t, checkDynamicallyLinked(rootfs, "dynamic", env))
+	require.NoError(t, checkDynamicallyLinked(rootfs, "/usr/bin/dynamic", nil))
+	require.ErrorContains(t, checkDynamicallyLinked(rootfs, "static", env), "/usr/local/bin/static is statically linked")
+	require.Error(t, checkDynamicallyLinked(rootfs, "/usr/local/bin/static", nil))
+	// scripts and programs that can't be found are given the benefit of the
+	// doubt
+	require.NoError(t, checkDynamicallyLinked(rootfs, "script", env))
+	require.NoError(t, checkDynamicallyLinked(rootfs, "missing", env))
+	require.NoError(t, checkDynamicallyLinked(rootfs, "static", nil))
+
+	require.Equal(t, "static", execPath([]string{"/.init", "static", "-v"}))
+	require.Equal(t, "static", execPath([]string{"static"}))
+	require.Empty(t, execPath([]string{"/.init"}))
+}
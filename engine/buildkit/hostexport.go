@@ -0,0 +1,140 @@
+package buildkit
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"dagger.io/dagger/telemetry"
+	"github.com/dagger/dagger/engine"
+)
+
+// hostExports tracks the client's exports to its host that are in progress,
+// so that exports to overlapping paths don't race their writes.
+type hostExports struct {
+	mu      sync.Mutex
+	running []*hostExport
+	// the client's working directory, which relative export paths are
+	// resolved against, once looked up
+	workdir string
+}
+
+type hostExport struct {
+	path string
+	// the span of the export, linked to by the exports waiting for it
+	span trace.SpanContext
+	done chan struct{}
+}
+
+// startHostExport registers an export to destPath on the client's host,
+// returning a func to call once it's done. If an export to an overlapping
+// path is in progress, it either waits for it to finish in a span linked to
+// it, or fails, depending on the session's export conflict policy.
+func (c *Client) startHostExport(ctx context.Context, destPath string) (func(), error) {
+	destPath, err := c.hostAbsPath(ctx, destPath)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		c.hostExports.mu.Lock()
+		conflict := c.hostExports.overlapping(destPath)
+		if conflict == nil {
+			export := &hostExport{
+				path: destPath,
+				span: trace.SpanContextFromContext(ctx),
+				done: make(chan struct{}),
+			}
+			c.hostExports.running = append(c.hostExports.running, export)
+			c.hostExports.mu.Unlock()
+			return func() {
+				c.hostExports.mu.Lock()
+				defer c.hostExports.mu.Unlock()
+				c.hostExports.running = slices.DeleteFunc(c.hostExports.running, func(other *hostExport) bool {
+					return other == export
+				})
+				close(export.done)
+			}, nil
+		}
+		c.hostExports.mu.Unlock()
+
+		if c.ExportConflict == engine.ExportConflictFail {
+			return nil, fmt.Errorf("export to %q conflicts with export to %q in progress; export to separate paths, or run the exports one after the other", destPath, conflict.path)
+		}
+		if err := waitForHostExport(ctx, conflict); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// hostAbsPath resolves a path on the client's host to a clean, absolute path,
+// so that different paths to the same location compare equal.
+func (c *Client) hostAbsPath(ctx context.Context, hostPath string) (string, error) {
+	if path.IsAbs(hostPath) {
+		return path.Clean(hostPath), nil
+	}
+	c.hostExports.mu.Lock()
+	workdir := c.hostExports.workdir
+	c.hostExports.mu.Unlock()
+	if workdir == "" {
+		stat, err := c.StatCallerHostPath(ctx, ".", true)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve host path %q: %w", hostPath, err)
+		}
+		workdir = stat.Path
+		c.hostExports.mu.Lock()
+		c.hostExports.workdir = workdir
+		c.hostExports.mu.Unlock()
+	}
+	return path.Join(workdir, hostPath), nil
+}
+
+// waitForHostExport waits for an export to finish, in a span linked to it so
+// that the trace shows what the export waited for.
+func waitForHostExport(ctx context.Context, export *hostExport) (rerr error) {
+	var opts []trace.SpanStartOption
+	if export.span.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: export.span}))
+	}
+	ctx, span := Tracer(ctx).Start(ctx, fmt.Sprintf("wait for export to %s", export.path), opts...)
+	defer telemetry.End(span, func() error { return rerr })
+	select {
+	case <-export.done:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// overlapping returns the export in progress whose path overlaps with
+// destPath, if any. The caller must hold the lock.
+func (exports *hostExports) overlapping(destPath string) *hostExport {
+	for _, export := range exports.running {
+		if hostPathsOverlap(export.path, destPath) {
+			return export
+		}
+	}
+	return nil
+}
+
+// hostPathsOverlap returns whether two clean, absolute paths are the same, or
+// one is under the other.
+func hostPathsOverlap(a, b string) bool {
+	return hostPathWithin(a, b) || hostPathWithin(b, a)
+}
+
+func hostPathWithin(child, parent string) bool {
+	switch {
+	case child == parent:
+		return true
+	case strings.HasSuffix(parent, "/"):
+		// the root
+		return strings.HasPrefix(child, parent)
+	default:
+		return strings.HasPrefix(child, parent+"/")
+	}
+}
@@ -0,0 +1,84 @@
+package buildkit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/engine"
+)
+
+func TestHostPathsOverlap(t *testing.T) {
+	for _, tc := range []struct {
+		a, b    string
+		overlap bool
+	}{
+		{"/out", "/out", true},
+		{"/out", "/out/bin/app", true},
+		{"/out/bin", "/out", true},
+		{"/out", "/output", false},
+		{"/out/a", "/out/b", false},
+		{"/", "/tmp/out", true},
+	} {
+		require.Equal(t, tc.overlap, hostPathsOverlap(tc.a, tc.b), "%s and %s", tc.a, tc.b)
+	}
+}
+
+func TestStartHostExport(t *testing.T) {
+	ctx := context.Background()
+	c := &Client{Opts: &Opts{}}
+	c.hostExports.workdir = "/work"
+
+	finishDir, err := c.startHostExport(ctx, "out")
+	require.NoError(t, err)
+	// unrelated paths don't wait
+	finishOther, err := c.startHostExport(ctx, "other")
+	require.NoError(t, err)
+	finishOther()
+
+	started := make(chan func())
+	go func() {
+		finish, err := c.startHostExport(ctx, "out/app")
+		if err != nil {
+			close(started)
+			return
+		}
+		started <- finish
+	}()
+	select {
+	case <-started:
+		t.Fatal("overlapping export didn't wait")
+	case <-time.After(50 * time.Millisecond):
+	}
+	finishDir()
+	select {
+	case finish, ok := <-started:
+		require.True(t, ok)
+		finish()
+	case <-time.After(5 * time.Second):
+		t.Fatal("overlapping export didn't start")
+	}
+
+	c.ExportConflict = engine.ExportConflictFail
+	finishDir, err = c.startHostExport(ctx, "out")
+	require.NoError(t, err)
+	defer finishDir()
+	_, err = c.startHostExport(ctx, "out/app")
+	require.ErrorContains(t, err, `export to "/work/out/app" conflicts with export to "/work/out" in progress`)
+	// relative and absolute paths to the same location conflict
+	_, err = c.startHostExport(ctx, "/work/out/../out/app")
+	require.ErrorContains(t, err, `export to "/work/out/app" conflicts with export to "/work/out" in progress`)
+	_, err = c.startHostExport(ctx, "./out/")
+	require.ErrorContains(t, err, `export to "/work/out" conflicts with export to "/work/out" in progress`)
+	finishOther, err = c.startHostExport(ctx, "/out")
+	require.NoError(t, err)
+	finishOther()
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	c.ExportConflict = engine.ExportConflictWait
+	_, err = c.startHostExport(canceled, "out")
+	require.ErrorIs(t, err, context.Canceled)
+}
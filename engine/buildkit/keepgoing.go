@@ -0,0 +1,59 @@
+package buildkit
+
+import (
+	"context"
+
+	bkfrontend "github.com/moby/buildkit/frontend"
+	bksolver "github.com/moby/buildkit/solver"
+	"github.com/sourcegraph/conc/pool"
+)
+
+// solveBranches solves the independent branches of a definition on their own
+// before it's solved as a whole. In a single solve, buildkit cancels the other
+// inputs of an op as soon as one of them fails; solving them separately lets
+// them all run to completion (and get cached), like make -k, so that every
+// failure can be reported at once.
+func (c *Client) solveBranches(ctx context.Context, gw *filteringGateway, req bkfrontend.SolveRequest) error {
+	dag, err := DefToDAG(req.Definition)
+	if err != nil || dag == nil {
+		return err
+	}
+	return c.solveFanIn(ctx, gw, req, dag)
+}
+
+// solveFanIn finds the first op with more than one input under the given one
+// and solves each of its inputs separately, returning all of their errors.
+func (c *Client) solveFanIn(ctx context.Context, gw *filteringGateway, req bkfrontend.SolveRequest, dag *OpDAG) error {
+	for len(dag.Inputs) == 1 {
+		dag = dag.Inputs[0]
+	}
+	if len(dag.Inputs) < 2 {
+		return nil
+	}
+	p := pool.New().WithErrors()
+	for _, input := range dag.Inputs {
+		p.Go(func() error {
+			// failures further down make this input fail too, so don't bother
+			// solving it if there are any
+			if err := c.solveFanIn(ctx, gw, req, input); err != nil {
+				return err
+			}
+			def, err := input.Marshal()
+			if err != nil {
+				return err
+			}
+			branchReq := req
+			branchReq.Definition = def
+			res, err := gw.Solve(ctx, branchReq, c.ID())
+			if err != nil {
+				return WrapError(ctx, err, c)
+			}
+			// the result is cached, so it will be reused when the whole
+			// definition is solved
+			return res.EachRef(func(rp bksolver.ResultProxy) error {
+				return rp.Release(context.WithoutCancel(ctx))
+			})
+		})
+	}
+	return p.Wait()
+}
@@ -0,0 +1,192 @@
+package buildkit
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/containerd/containerd/archive/compression"
+	"github.com/containerd/containerd/content"
+	bkcacheconfig "github.com/moby/buildkit/cache/config"
+	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	bksession "github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/solver/pb"
+	bkcompression "github.com/moby/buildkit/util/compression"
+	"github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ImageLayer describes a layer of a filesystem, as it would be exported in an
+// image.
+type ImageLayer struct {
+	// Digest is the digest of the compressed layer blob.
+	Digest digest.Digest
+	// Size is the size of the compressed layer blob.
+	Size int64
+	// Command is a description of what created the layer, e.g. the exec
+	// that ran.
+	Command string
+	// WastedSize is the total size of files in the layer that are replaced
+	// or removed by a later layer, which are shipped with the image but never
+	// seen in the container.
+	WastedSize int64
+}
+
+const (
+	whiteoutPrefix = ".wh."
+	opaqueWhiteout = ".wh..wh..opq"
+)
+
+// ImageLayers returns the layers of the filesystem built by def, bottom
+// first, creating their blobs if they haven't been already.
+func (c *Client) ImageLayers(ctx context.Context, def *pb.Definition) ([]ImageLayer, error) {
+	ctx, cancel, err := c.withClientCloseCancel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel(errors.New("image layers done"))
+
+	res, err := c.Solve(ctx, bkgw.SolveRequest{
+		Definition: def,
+		Evaluate:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	cacheRes, err := ConvertToWorkerCacheResult(ctx, res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert result: %w", err)
+	}
+	ref, err := cacheRes.SingleRef()
+	if err != nil {
+		return nil, err
+	}
+	// empty filesystem, i.e. llb.Scratch()
+	if ref == nil {
+		return []ImageLayer{}, nil
+	}
+
+	ctx = withDescHandlerCacheOpts(ctx, ref)
+	remotes, err := ref.GetRemotes(ctx, true, bkcacheconfig.RefConfig{
+		Compression: bkcompression.New(bkcompression.Default),
+	}, false, bksession.NewGroup(c.ID()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get layer blobs: %w", err)
+	}
+	if len(remotes) == 0 {
+		return []ImageLayer{}, nil
+	}
+	remote := remotes[0]
+
+	chain := ref.LayerChain()
+	defer chain.Release(context.WithoutCancel(ctx))
+	// the chain lines up with the top of the blobs, like in the image
+	// exporter
+	offset := len(remote.Descriptors) - len(chain)
+
+	layers := make([]ImageLayer, len(remote.Descriptors))
+	files := make([]map[string]int64, len(remote.Descriptors))
+	for i, desc := range remote.Descriptors {
+		layers[i] = ImageLayer{
+			Digest: desc.Digest,
+			Size:   desc.Size,
+		}
+		if j := i - offset; j >= 0 && j < len(chain) {
+			layers[i].Command = chain[j].GetDescription()
+		}
+		files[i], err = layerFiles(ctx, remote.Provider, desc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files of layer %s: %w", desc.Digest, err)
+		}
+	}
+	for i, wasted := range WastedLayerSpace(files) {
+		layers[i].WastedSize = wasted
+	}
+	return layers, nil
+}
+
+// layerFiles returns the paths of the entries in a layer blob, along with
+// their sizes, whiteouts included.
+func layerFiles(ctx context.Context, provider content.Provider, desc ocispecs.Descriptor) (map[string]int64, error) {
+	ra, err := provider.ReaderAt(ctx, desc)
+	if err != nil {
+		return nil, err
+	}
+	defer ra.Close()
+	r, err := compression.DecompressStream(io.NewSectionReader(ra, 0, ra.Size()))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	files := map[string]int64{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var size int64
+		if hdr.Typeflag == tar.TypeReg {
+			size = hdr.Size
+		}
+		files[cleanLayerPath(hdr.Name)] = size
+	}
+}
+
+// WastedLayerSpace returns, for each layer, the total size of the files in
+// it that are replaced or removed by a later layer. Layers are given bottom
+// first, as the paths and sizes of the entries in their tarballs, whiteouts
+// included.
+func WastedLayerSpace(layers []map[string]int64) []int64 {
+	wasted := make([]int64, len(layers))
+	// paths replaced or removed by the layers above, and directories whose
+	// contents were removed altogether
+	hidden := map[string]bool{}
+	hiddenDirs := map[string]bool{}
+	isHidden := func(p string) bool {
+		if hidden[p] {
+			return true
+		}
+		for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+			if hiddenDirs[dir] {
+				return true
+			}
+		}
+		return false
+	}
+	for i := len(layers) - 1; i >= 0; i-- {
+		for p, size := range layers[i] {
+			if size > 0 && !strings.HasPrefix(path.Base(p), whiteoutPrefix) && isHidden(p) {
+				wasted[i] += size
+			}
+		}
+		// a layer only hides what's below it, so apply it afterwards
+		for p := range layers[i] {
+			dir, base := path.Split(p)
+			dir = path.Clean(dir)
+			switch {
+			case base == opaqueWhiteout:
+				hiddenDirs[dir] = true
+			case strings.HasPrefix(base, whiteoutPrefix):
+				removed := path.Join(dir, strings.TrimPrefix(base, whiteoutPrefix))
+				hidden[removed] = true
+				hiddenDirs[removed] = true
+			default:
+				hidden[p] = true
+			}
+		}
+	}
+	return wasted
+}
+
+func cleanLayerPath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
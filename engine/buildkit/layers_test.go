@@ -0,0 +1,34 @@
+package buildkit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWastedLayerSpace(t *testing.T) {
+	wasted := WastedLayerSpace([]map[string]int64{
+		{
+			"etc":                           0,
+			"etc/os-release":                10,
+			"usr/bin/tool":                  100,
+			"var/cache/apt":                 0,
+			"var/cache/apt/pkgcache.bin":    1000,
+			"var/cache/apt/srcpkgcache.bin": 2000,
+		},
+		{
+			// replaces a file
+			"etc/os-release": 20,
+			// creates and later removes a file
+			"tmp/build.tar": 5000,
+		},
+		{
+			// removes a directory and a file
+			"var/cache/.wh.apt": 0,
+			"tmp/.wh.build.tar": 0,
+			// hides everything under a directory, with nothing below it
+			"opt/.wh..wh..opq": 0,
+		},
+	})
+	require.Equal(t, []int64{3010, 5000, 0}, wasted)
+}
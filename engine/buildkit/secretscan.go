@@ -0,0 +1,63 @@
+package buildkit
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	bkcache "github.com/moby/buildkit/cache"
+	bksession "github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/snapshot"
+	solverresult "github.com/moby/buildkit/solver/result"
+
+	"github.com/dagger/dagger/engine/secretscan"
+)
+
+// scanForSecrets scans the filesystems of a result that's about to leave the
+// engine, failing if any secrets are found. Findings in multi-platform results
+// are prefixed with their platform.
+func (c *Client) scanForSecrets(ctx context.Context, res *solverresult.Result[bkcache.ImmutableRef]) error {
+	if c.SecretScanner == nil {
+		return nil
+	}
+	refs := map[string]bkcache.ImmutableRef{}
+	if res.Ref != nil {
+		refs[""] = res.Ref
+	}
+	for platform, ref := range res.Refs {
+		refs[platform] = ref
+	}
+	var findings []secretscan.Finding
+	for _, platform := range slices.Sorted(maps.Keys(refs)) {
+		ref := refs[platform]
+		if ref == nil {
+			continue
+		}
+		found, err := c.scanRef(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("failed to scan for secrets: %w", err)
+		}
+		for _, finding := range found {
+			if platform != "" {
+				finding.Path = platform + ":" + finding.Path
+			}
+			findings = append(findings, finding)
+		}
+	}
+	return secretscan.Report(ctx, findings)
+}
+
+func (c *Client) scanRef(ctx context.Context, ref bkcache.ImmutableRef) ([]secretscan.Finding, error) {
+	mountable, err := ref.Mount(ctx, true, bksession.NewGroup(c.ID()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mountable: %w", err)
+	}
+	mounter := snapshot.LocalMounter(mountable)
+	root, err := mounter.Mount()
+	if err != nil {
+		return nil, fmt.Errorf("failed to mount: %w", err)
+	}
+	defer mounter.Unmount()
+	return c.SecretScanner.ScanDir(ctx, root)
+}
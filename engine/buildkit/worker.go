@@ -1,6 +1,7 @@
 package buildkit
 
 import (
+	"context"
 	"net/http"
 	"sync"
 
@@ -57,6 +58,7 @@ type sharedWorkerState struct {
 	entitlements     entitlements.Set
 	parallelismSem   *semaphore.Weighted
 	workerCache      bkcache.Manager
+	deterministic    *DeterministicOpts
 
 	running map[string]*execState
 	mu      sync.RWMutex
@@ -64,6 +66,8 @@ type sharedWorkerState struct {
 
 type sessionHandler interface {
 	ServeHTTPToNestedClient(http.ResponseWriter, *http.Request, *ExecutionMetadata)
+	WaitIfPaused(ctx context.Context, sessionID string) error
+	NestedClientExited(*ExecutionMetadata)
 }
 
 type NewWorkerOpts struct {
@@ -85,6 +89,7 @@ type NewWorkerOpts struct {
 	NetworkProviders    map[pb.NetMode]network.Provider
 	ParallelismSem      *semaphore.Weighted
 	WorkerCache         bkcache.Manager
+	Deterministic       *DeterministicOpts
 }
 
 func NewWorker(opts *NewWorkerOpts) *Worker {
@@ -107,6 +112,7 @@ func NewWorker(opts *NewWorkerOpts) *Worker {
 		entitlements:     opts.Entitlements,
 		parallelismSem:   opts.ParallelismSem,
 		workerCache:      opts.WorkerCache,
+		deterministic:    opts.Deterministic,
 
 		running: make(map[string]*execState),
 	}}
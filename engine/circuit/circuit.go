@@ -0,0 +1,203 @@
+// Package circuit implements circuit breakers for the external dependencies
+// of the engine, like registries and git hosts, so that pipelines fail fast
+// rather than retrying against a dependency that is down.
+package circuit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"dagger.io/dagger/telemetry"
+	"github.com/dagger/dagger/engine/slog"
+)
+
+const (
+	// DefaultThreshold is the default number of consecutive failures that
+	// opens a circuit.
+	DefaultThreshold = 5
+
+	// DefaultCooldown is how long a circuit stays open by default before a
+	// probe is let through.
+	DefaultCooldown = 30 * time.Second
+)
+
+// Breakers tracks a circuit breaker for each endpoint.
+type Breakers struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+	now      func() time.Time
+}
+
+type breaker struct {
+	failures  int
+	lastErr   error
+	openUntil time.Time
+	probing   bool
+}
+
+// NewBreakers returns circuit breakers that open after the given number of
+// consecutive failures, and stay open for the given cooldown before letting a
+// probe through. A threshold below 1 disables them.
+func NewBreakers(threshold int, cooldown time.Duration) *Breakers {
+	return &Breakers{
+		threshold: threshold,
+		cooldown:  cooldown,
+		breakers:  map[string]*breaker{},
+		now:       time.Now,
+	}
+}
+
+// OpenError is returned for calls to an endpoint whose circuit is open.
+type OpenError struct {
+	Endpoint string
+	Until    time.Time
+	Cause    error
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("circuit open for %s after repeated failures, retrying after %s: %v",
+		e.Endpoint, e.Until.Format(time.TimeOnly), e.Cause)
+}
+
+func (e *OpenError) Extensions() map[string]any {
+	return map[string]any{
+		"_type":    "CIRCUIT_OPEN",
+		"endpoint": e.Endpoint,
+		"until":    e.Until,
+	}
+}
+
+// Do calls fn unless the endpoint's circuit is open, recording whether it
+// failed. Once the cooldown passes, a single call is let through as a probe;
+// the circuit closes if it succeeds, and stays open otherwise.
+//
+// Only failures that suggest the endpoint is unavailable count, so that e.g.
+// a missing image doesn't open the circuit for its registry.
+func (b *Breakers) Do(ctx context.Context, endpoint string, fn func() error) error {
+	if b == nil || b.threshold < 1 || endpoint == "" {
+		return fn()
+	}
+	probe, err := b.allow(endpoint)
+	if err != nil {
+		trace.SpanFromContext(ctx).SetAttributes(
+			attribute.String(telemetry.ErrorCategoryAttr, telemetry.ErrorCategoryCircuitOpen))
+		return err
+	}
+	err = fn()
+	b.record(ctx, endpoint, probe, err)
+	return err
+}
+
+func (b *Breakers) allow(endpoint string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	br := b.breakers[endpoint]
+	if br == nil || br.openUntil.IsZero() {
+		return false, nil
+	}
+	if br.probing || b.now().Before(br.openUntil) {
+		return false, &OpenError{Endpoint: endpoint, Until: br.openUntil, Cause: br.lastErr}
+	}
+	br.probing = true
+	return true, nil
+}
+
+func (b *Breakers) record(ctx context.Context, endpoint string, probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	br := b.breakers[endpoint]
+	if probe && br != nil {
+		br.probing = false
+	}
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// canceled by the caller, which says nothing about the endpoint
+	case IsUnavailable(err):
+		if br == nil {
+			br = &breaker{}
+			b.breakers[endpoint] = br
+		}
+		br.failures++
+		br.lastErr = err
+		if probe || br.failures >= b.threshold {
+			br.openUntil = b.now().Add(b.cooldown)
+			slog.Warn("circuit opened", "endpoint", endpoint, "failures", br.failures, "error", err)
+		}
+	case br != nil:
+		// the endpoint responded, even if the call failed, so it's back
+		if !br.openUntil.IsZero() {
+			slog.Info("circuit closed", "endpoint", endpoint)
+		}
+		delete(b.breakers, endpoint)
+	}
+}
+
+// State is the state of an endpoint's circuit.
+type State struct {
+	Failures int       `json:"failures"`
+	Open     bool      `json:"open"`
+	Until    time.Time `json:"until"`
+}
+
+// States returns the state of each endpoint that has failed recently.
+func (b *Breakers) States() map[string]State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	states := make(map[string]State, len(b.breakers))
+	for endpoint, br := range b.breakers {
+		states[endpoint] = State{
+			Failures: br.failures,
+			Open:     !br.openUntil.IsZero(),
+			Until:    br.openUntil,
+		}
+	}
+	return states
+}
+
+// unavailableErrors are fragments of error messages that indicate an
+// endpoint is unavailable. Errors lose their types when they cross the gRPC
+// boundary with buildkit, so they are matched by message.
+var unavailableErrors = []string{
+	"connection refused",
+	"connection reset",
+	"broken pipe",
+	"i/o timeout",
+	"no such host",
+	"TLS handshake timeout",
+	"unexpected EOF",
+	"invalid response status 5",
+	"server misbehaving",
+	"503 Service Unavailable",
+	"502 Bad Gateway",
+	"504 Gateway Timeout",
+}
+
+// IsUnavailable returns whether an error suggests that the endpoint it came
+// from is unavailable, as opposed to e.g. rejecting the request.
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	msg := err.Error()
+	for _, fragment := range unavailableErrors {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
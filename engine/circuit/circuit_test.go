@@ -0,0 +1,56 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreakers(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	b := NewBreakers(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	down := errors.New("dial tcp: connection refused")
+	notFound := errors.New("manifest unknown")
+	var calls int
+	call := func(err error) func() error {
+		return func() error {
+			calls++
+			return err
+		}
+	}
+
+	// errors that don't suggest the host is down don't count
+	require.ErrorIs(t, b.Do(ctx, "registry", call(notFound)), notFound)
+	require.Empty(t, b.States())
+
+	// the circuit opens after repeated failures
+	require.ErrorIs(t, b.Do(ctx, "registry", call(down)), down)
+	require.ErrorIs(t, b.Do(ctx, "registry", call(down)), down)
+	calls = 0
+	var openErr *OpenError
+	require.ErrorAs(t, b.Do(ctx, "registry", call(nil)), &openErr)
+	require.Equal(t, "registry", openErr.Endpoint)
+	require.ErrorIs(t, openErr.Cause, down)
+	require.Zero(t, calls)
+
+	// other endpoints are unaffected
+	require.NoError(t, b.Do(ctx, "git", call(nil)))
+	require.Equal(t, 1, calls)
+
+	// a failed probe reopens it
+	now = now.Add(time.Minute)
+	require.ErrorIs(t, b.Do(ctx, "registry", call(down)), down)
+	require.ErrorAs(t, b.Do(ctx, "registry", call(nil)), &openErr)
+
+	// and a successful one closes it
+	now = now.Add(time.Minute)
+	require.NoError(t, b.Do(ctx, "registry", call(nil)))
+	require.NoError(t, b.Do(ctx, "registry", call(nil)))
+	require.Empty(t, b.States())
+}
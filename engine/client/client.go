@@ -72,6 +72,41 @@ type Params struct {
 
 	DisableHostRW bool
 
+	// Offline disallows network access for module sources and images; only
+	// the images listed in OfflineImages and the module sources listed in
+	// OfflineModules may be used.
+	Offline        bool
+	OfflineImages  map[string]string
+	OfflineModules map[string]string
+
+	// SessionTimeout is how long the session may run before the engine
+	// cancels all of its calls. It is unlimited if zero.
+	SessionTimeout time.Duration
+
+	// KeepGoing lets independent branches of builds keep running after one
+	// of them fails, so that all of the failures can be reported at once.
+	KeepGoing bool
+
+	// PayloadSizes records the size of each call's arguments and result as
+	// metrics.
+	PayloadSizes bool
+
+	// CallTargets limits the module functions that run to these, plus the
+	// functions that they call, skipping the rest.
+	CallTargets []string
+
+	// ExportConflict is what the engine does when an export to the host
+	// overlaps with one in progress: wait for it, or fail.
+	ExportConflict engine.ExportConflictPolicy
+
+	// AllowedHostPorts are the host:port addresses on the client's host that
+	// containers may reach through reverse tunnels.
+	AllowedHostPorts []string
+
+	// CacheExportConfigs are upstream cache exports to perform when the
+	// session ends, in addition to any configured in the environment.
+	CacheExportConfigs []*controlapi.CacheOptionsEntry
+
 	EngineCallback   func(context.Context, string, string, string)
 	CloudURLCallback func(context.Context, string, string, bool)
 
@@ -205,6 +240,7 @@ func Connect(ctx context.Context, params Params) (_ *Client, _ context.Context,
 	if err != nil {
 		return nil, nil, fmt.Errorf("cache config from env: %w", err)
 	}
+	c.upstreamCacheExportOptions = append(c.upstreamCacheExportOptions, c.CacheExportConfigs...)
 
 	c.stableClientID = GetHostStableID(slog)
 
@@ -561,7 +597,7 @@ func (c *Client) Close() (rerr error) {
 }
 
 type otlpConsumer struct {
-	httpClient *httpClient
+	httpClient sse.Doer
 	path       string
 	traceID    trace.TraceID
 	clientID   string
@@ -639,11 +675,12 @@ func (c *Client) exportTraces(ctx context.Context, httpClient *httpClient) error
 	// seeing what's going on, even during shutdown
 	ctx = context.WithoutCancel(ctx)
 
+	capsClient := &enginetel.CapabilitiesClient{Client: httpClient}
 	exp := &otlpConsumer{
 		path:       "/v1/traces",
 		traceID:    trace.SpanContextFromContext(ctx).TraceID(),
 		clientID:   c.ID,
-		httpClient: httpClient,
+		httpClient: capsClient,
 		eg:         c.telemetry,
 	}
 
@@ -653,7 +690,9 @@ func (c *Client) exportTraces(ctx context.Context, httpClient *httpClient) error
 			return fmt.Errorf("unmarshal: %w", err)
 		}
 
-		spans := telemetry.SpansFromPB(req.GetResourceSpans())
+		// only forward what the engine and this client agreed on, in case the
+		// engine predates a capability
+		spans := capsClient.Capabilities().DowngradeSpans(telemetry.SpansFromPB(req.GetResourceSpans()))
 
 		slog.ExtraDebug("received spans from engine", "len", len(spans))
 
@@ -738,6 +777,57 @@ func (c *Client) init(ctx context.Context) error {
 	return resp.Body.Close()
 }
 
+// CancelSpan cancels the call running in the given span, along with
+// everything it started, leaving the rest of the session running.
+func (c *Client) CancelSpan(ctx context.Context, spanID trace.SpanID) error {
+	u := "http://dagger" + engine.CancelEndpoint + "?" + url.Values{"span": {spanID.String()}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, "POST", u, nil)
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+
+	req.SetBasicAuth(c.SecretToken, "")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("do cancel: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("cancel span %s: %s", spanID, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// SetPaused pauses or resumes the session. While paused, no new execs are
+// started, but the ones already running are left to finish.
+func (c *Client) SetPaused(ctx context.Context, paused bool) error {
+	endpoint := engine.ResumeEndpoint
+	if paused {
+		endpoint = engine.PauseEndpoint
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", "http://dagger"+endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+
+	req.SetBasicAuth(c.SecretToken, "")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("do %s: %w", strings.TrimPrefix(endpoint, "/"), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s session: %s", strings.TrimPrefix(endpoint, "/"), strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
 func (c *Client) shutdownServer() error {
 	// don't immediately cancel shutdown if we're shutting down because we were
 	// canceled
@@ -1021,6 +1111,13 @@ func cacheConfigFromEnv(envName string) ([]*controlapi.CacheOptionsEntry, error)
 	if !ok {
 		return nil, nil
 	}
+	return ParseCacheConfigs(envVal)
+}
+
+// ParseCacheConfigs parses cache configs in the same form as the
+// _EXPERIMENTAL_DAGGER_CACHE_CONFIG env var, e.g.
+// "type=local,dest=./cache".
+func ParseCacheConfigs(envVal string) ([]*controlapi.CacheOptionsEntry, error) {
 	configKVs := strings.Split(envVal, ";")
 	// handle '\;' as an escape in case ';' needs to be used in a cache config setting rather than as
 	// a delimiter between multiple cache configs
@@ -1117,6 +1214,15 @@ func (c *Client) clientMetadata() engine.ClientMetadata {
 		Interactive:               c.Interactive,
 		InteractiveCommand:        c.InteractiveCommand,
 		SSHAuthSocketPath:         sshAuthSock,
+		Offline:                   c.Offline,
+		OfflineImages:             c.OfflineImages,
+		OfflineModules:            c.OfflineModules,
+		SessionTimeout:            c.SessionTimeout,
+		KeepGoing:                 c.KeepGoing,
+		PayloadSizes:              c.PayloadSizes,
+		CallTargets:               c.CallTargets,
+		ExportConflict:            c.ExportConflict,
+		AllowedHostPorts:          c.AllowedHostPorts,
 	}
 }
 
@@ -1,15 +1,21 @@
 package client
 
 import (
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
+	"maps"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"sync"
 
 	"github.com/docker/docker/pkg/idtools"
 	"github.com/moby/buildkit/session/filesync"
+	"github.com/opencontainers/go-digest"
 	"github.com/tonistiigi/fsutil"
 	fstypes "github.com/tonistiigi/fsutil/types"
 	"google.golang.org/grpc"
@@ -143,17 +149,26 @@ func (t FilesyncTarget) DiffCopy(stream filesync.FileSend_DiffCopyServer) (rerr
 			return fmt.Errorf("failed to create synctarget dest dir %s: %w", absPath, err)
 		}
 
-		err := fsutil.Receive(stream.Context(), stream, absPath, fsutil.ReceiveOpt{
+		sent := newSentFiles(stream)
+		err := fsutil.Receive(stream.Context(), sent, absPath, fsutil.ReceiveOpt{
 			Merge: opts.Merge,
 			Filter: func(path string, stat *fstypes.Stat) bool {
 				stat.Uid = t.uid
 				stat.Gid = t.gid
 				return true
 			},
+			ContentHasher: func(*fstypes.Stat) (hash.Hash, error) {
+				return sha256.New(), nil
+			},
+			NotifyHashed: sent.hashed,
 		})
 		if err != nil {
 			return fmt.Errorf("failed to receive fs changes: %w", err)
 		}
+		if err := sent.verify(absPath); err != nil {
+			return err
+		}
+		stream.SetTrailer(engine.ExportDigestMD(sent.digest()))
 		return nil
 	}
 
@@ -221,20 +236,128 @@ func (t FilesyncTarget) DiffCopy(stream filesync.FileSend_DiffCopyServer) (rerr
 		}
 	}
 
+	// digest the file as it's written, for the sender to check against what
+	// it sent
+	digester := digest.Canonical.Digester()
+	w := io.MultiWriter(destF, digester.Hash())
+	var written int64
 	for {
 		msg := filesync.BytesMessage{}
 		if err := stream.RecvMsg(&msg); err != nil {
 			if errors.Is(err, io.EOF) {
+				if err := destF.Close(); err != nil {
+					return fmt.Errorf("failed to close synctarget dest file %s: %w", finalDestPath, err)
+				}
+				if err := verifyExportedFile(finalDestPath, written, opts.FileSize); err != nil {
+					return err
+				}
+				stream.SetTrailer(engine.ExportDigestMD(digester.Digest()))
 				return nil
 			}
 			return err
 		}
-		if _, err := destF.Write(msg.Data); err != nil {
+		n, err := w.Write(msg.Data)
+		written += int64(n)
+		if err != nil {
 			return err
 		}
 	}
 }
 
+// verifyExportedFile checks that the file written by an export has all the
+// bytes received, and that those are as many as were sent, if known, so that
+// truncated exports fail instead of going unnoticed.
+func verifyExportedFile(path string, written, size int64) error {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to verify exported file: %w", err)
+	}
+	if stat.Size() != written {
+		return fmt.Errorf("exported file %s is incomplete: it has %d bytes, expected %d", path, stat.Size(), written)
+	}
+	if size > 0 && written != size {
+		return fmt.Errorf("exported file %s is incomplete: received %d bytes, expected %d", path, written, size)
+	}
+	return nil
+}
+
+// sentFiles records the sizes of the files sent to a directory export, and
+// the digests of those written, so that the export can be checked: the sizes
+// against the files on disk, and the digests by the sender.
+type sentFiles struct {
+	fsutil.Stream
+	sizes map[string]int64
+
+	mu      sync.Mutex
+	digests map[string]digest.Digest
+}
+
+func newSentFiles(stream fsutil.Stream) *sentFiles {
+	return &sentFiles{
+		Stream:  stream,
+		sizes:   map[string]int64{},
+		digests: map[string]digest.Digest{},
+	}
+}
+
+func (s *sentFiles) RecvMsg(m any) error {
+	if err := s.Stream.RecvMsg(m); err != nil {
+		return err
+	}
+	if p, ok := m.(*fstypes.Packet); ok && p.Type == fstypes.PACKET_STAT && p.Stat != nil &&
+		os.FileMode(p.Stat.Mode).IsRegular() {
+		s.sizes[p.Stat.Path] = p.Stat.Size_
+	}
+	return nil
+}
+
+// hashed records the digest of a file once its contents are written. It's
+// called concurrently as files are written.
+func (s *sentFiles) hashed(kind fsutil.ChangeKind, path string, fi os.FileInfo, err error) error {
+	if err != nil || kind == fsutil.ChangeKindDelete || !fi.Mode().IsRegular() {
+		return err
+	}
+	// hardlinks are linked rather than written, so have no content to digest
+	if stat, ok := fi.Sys().(*fstypes.Stat); ok && stat.Linkname != "" {
+		return nil
+	}
+	hashed, ok := fi.(interface{ Digest() digest.Digest })
+	if !ok {
+		return nil
+	}
+	s.mu.Lock()
+	s.digests[filepath.ToSlash(path)] = hashed.Digest()
+	s.mu.Unlock()
+	return nil
+}
+
+// digest returns the digest of every file written.
+func (s *sentFiles) digest() digest.Digest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return engine.ExportedFilesDigest(s.digests)
+}
+
+// verify checks that every regular file sent was written in full under dest,
+// so that truncated exports fail instead of going unnoticed.
+func (s *sentFiles) verify(dest string) error {
+	var errs []error
+	for _, path := range slices.Sorted(maps.Keys(s.sizes)) {
+		stat, err := os.Lstat(filepath.Join(dest, filepath.FromSlash(path)))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("exported file %s is missing: %w", path, err))
+			continue
+		}
+		if stat.Size() != s.sizes[path] {
+			errs = append(errs, fmt.Errorf("exported file %s is incomplete: it has %d bytes, expected %d", path, stat.Size(), s.sizes[path]))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to verify export to %s: %w", dest, errors.Join(errs...))
+	}
+	return nil
+}
+
 func (f Filesyncer) fullRootPathAndBaseName(reqPath string, fullyResolvePath bool) (_ string, err error) {
 	// NOTE: filepath.Clean also handles calling FromSlash (relevant when this is a Windows client)
 	reqPath = filepath.Clean(reqPath)
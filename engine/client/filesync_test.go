@@ -0,0 +1,74 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+	"github.com/tonistiigi/fsutil"
+	fstypes "github.com/tonistiigi/fsutil/types"
+
+	"github.com/dagger/dagger/engine"
+)
+
+func TestVerifyExportedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out")
+	contents := []byte("hello world")
+	require.NoError(t, os.WriteFile(path, contents, 0o600))
+	size := int64(len(contents))
+
+	require.NoError(t, verifyExportedFile(path, size, size))
+	// the sent size isn't known
+	require.NoError(t, verifyExportedFile(path, size, 0))
+
+	require.ErrorContains(t, verifyExportedFile(path, 100, 100), "it has 11 bytes, expected 100")
+	require.ErrorContains(t, verifyExportedFile(path, size, 100), "received 11 bytes, expected 100")
+}
+
+func TestSentFilesVerify(t *testing.T) {
+	dest := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dest, "sub"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dest, "sub", "a"), []byte("aaaa"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dest, "b"), []byte("bb"), 0o600))
+
+	sent := newSentFiles(nil)
+	sent.sizes = map[string]int64{"sub/a": 4, "b": 2}
+	require.NoError(t, sent.verify(dest))
+
+	sent.sizes["b"] = 20
+	sent.sizes["c"] = 1
+	err := sent.verify(dest)
+	require.ErrorContains(t, err, "b is incomplete: it has 2 bytes, expected 20")
+	require.ErrorContains(t, err, "c is missing")
+}
+
+func TestSentFilesDigest(t *testing.T) {
+	sent := newSentFiles(nil)
+	a := digest.FromString("a")
+	b := digest.FromString("b")
+	require.NoError(t, sent.hashed(fsutil.ChangeKindAdd, "a", hashedFile{stat: fstypes.Stat{Path: "a", Mode: 0o600}, dgst: a}, nil))
+	require.NoError(t, sent.hashed(fsutil.ChangeKindModify, "sub/b", hashedFile{stat: fstypes.Stat{Path: "sub/b", Mode: 0o600}, dgst: b}, nil))
+	// only the contents of regular files that were written count
+	require.NoError(t, sent.hashed(fsutil.ChangeKindAdd, "sub", hashedFile{stat: fstypes.Stat{Path: "sub", Mode: uint32(os.ModeDir | 0o700)}}, nil))
+	require.NoError(t, sent.hashed(fsutil.ChangeKindAdd, "link", hashedFile{stat: fstypes.Stat{Path: "link", Mode: 0o600, Linkname: "a"}}, nil))
+	require.NoError(t, sent.hashed(fsutil.ChangeKindDelete, "gone", nil, nil))
+
+	require.Equal(t, engine.ExportedFilesDigest(map[string]digest.Digest{"a": a, "sub/b": b}), sent.digest())
+}
+
+// hashedFile is a file as notified by fsutil once it's written.
+type hashedFile struct {
+	stat fstypes.Stat
+	dgst digest.Digest
+}
+
+func (f hashedFile) Name() string          { return filepath.Base(f.stat.Path) }
+func (f hashedFile) Size() int64           { return f.stat.Size_ }
+func (f hashedFile) Mode() os.FileMode     { return os.FileMode(f.stat.Mode) }
+func (f hashedFile) ModTime() time.Time    { return time.Unix(0, f.stat.ModTime) }
+func (f hashedFile) IsDir() bool           { return f.Mode().IsDir() }
+func (f hashedFile) Sys() any              { return &f.stat }
+func (f hashedFile) Digest() digest.Digest { return f.dgst }
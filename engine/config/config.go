@@ -29,6 +29,18 @@ type Config struct {
 
 	// Security allows configuring various security settings for the engine.
 	Security Security `json:"security,omitempty"`
+
+	// CircuitBreaker configures how the engine stops calling external
+	// dependencies, like registries and git hosts, that fail repeatedly.
+	CircuitBreaker CircuitBreakerConfig `json:"circuitBreaker,omitempty"`
+
+	// SecretScan configures scanning of exported files and published images
+	// for potential secrets, before they leave the engine.
+	SecretScan SecretScanConfig `json:"secretScan,omitempty"`
+
+	// Deterministic configures normalization of exported directories and
+	// images, so that builds are reproducible.
+	Deterministic DeterministicConfig `json:"deterministic,omitempty"`
 }
 
 type LogLevel string
@@ -209,3 +221,46 @@ type Security struct {
 	// privileged, and is a basic form of security hardening.
 	InsecureRootCapabilities *bool `json:"insecureRootCapabilities,omitempty"`
 }
+
+type CircuitBreakerConfig struct {
+	// Enabled controls whether circuit breakers are used. They are enabled
+	// by default.
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Threshold is the number of consecutive failures to reach a host after
+	// which its circuit opens. It defaults to 5.
+	Threshold int `json:"threshold,omitempty"`
+
+	// Cooldown is how long a circuit stays open before a single call is let
+	// through to probe whether the host has recovered. It defaults to 30
+	// seconds.
+	Cooldown Duration `json:"cooldown,omitempty"`
+}
+
+type SecretScanConfig struct {
+	// Enabled controls whether exports and publishes are scanned for
+	// secrets. Scanning is disabled by default.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// MaxFileSize is the size in bytes above which files aren't scanned. It
+	// defaults to 1MiB.
+	MaxFileSize int64 `json:"maxFileSize,omitempty"`
+
+	// Exclude lists glob patterns of paths that aren't scanned, relative to
+	// the root of the exported directory or image, e.g. "usr/share/*".
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+type DeterministicConfig struct {
+	// Enabled controls whether exported directories and images are
+	// normalized. It's disabled by default.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Epoch is the Unix timestamp, in seconds, that file mtimes and image
+	// creation times are set to. It defaults to 0.
+	Epoch int64 `json:"epoch,omitempty"`
+
+	// Verify builds every exported directory and image a second time,
+	// without cache, to check that both builds have the same digest.
+	Verify bool `json:"verify,omitempty"`
+}
@@ -67,6 +67,9 @@ const (
 	InitEndpoint               = "/init"
 	QueryEndpoint              = "/query"
 	ShutdownEndpoint           = "/shutdown"
+	CancelEndpoint             = "/cancel"
+	PauseEndpoint              = "/pause"
+	ResumeEndpoint             = "/resume"
 
 	// Buildkit-interpreted session keys, can't change
 	SessionIDMetaKey         = "X-Docker-Expose-Session-Uuid"
@@ -5,14 +5,20 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"time"
 	"unicode"
 
+	"github.com/distribution/reference"
 	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/opencontainers/go-digest"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -23,6 +29,10 @@ const (
 	localImportOptsMetaKey = "X-Dagger-Local-Import-Opts"
 	localExportOptsMetaKey = "X-Dagger-Local-Export-Opts"
 
+	// trailer in which the receiver of a local export returns the digest of
+	// what it wrote, for the sender to check against what it sent
+	exportDigestMetaKey = "X-Dagger-Export-Digest"
+
 	// local dir import (set by buildkit, can't change)
 	localDirImportDirNameMetaKey         = "dir-name"
 	localDirImportIncludePatternsMetaKey = "include-patterns"
@@ -82,6 +92,107 @@ type ClientMetadata struct {
 
 	// SSH auth socket path
 	SSHAuthSocketPath string
+
+	// Offline mode: all module sources and images must come from a
+	// pre-seeded bundle instead of the network.
+	Offline bool `json:"offline"`
+
+	// Image refs available in the pre-seeded bundle, mapped to their
+	// canonical (digest-pinned) refs. Only used in offline mode.
+	OfflineImages map[string]string `json:"offline_images"`
+
+	// Module source refs available in the pre-seeded bundle, mapped to the
+	// host paths of their bundled copies. Only used in offline mode.
+	OfflineModules map[string]string `json:"offline_modules"`
+
+	// SessionTimeout is how long the session may run, from when it starts,
+	// before all of its calls are canceled. It is unlimited if zero.
+	SessionTimeout time.Duration `json:"session_timeout"`
+
+	// KeepGoing lets independent branches of the session's builds keep
+	// running after one of them fails, like make -k.
+	KeepGoing bool `json:"keep_going"`
+
+	// PayloadSizes records the size of each call's arguments and result as
+	// metrics, warning about large ones. It's off by default, since measuring
+	// them means serializing every call's payload.
+	PayloadSizes bool `json:"payload_sizes,omitempty"`
+
+	// CallTargets limits the module functions called from other functions
+	// during the session to these, plus whatever they call, like make
+	// targets. Calls to other functions are skipped. All functions are called
+	// if empty.
+	CallTargets []string `json:"call_targets"`
+
+	// ExportConflict is what to do when an export to the host overlaps with
+	// an export still in progress. Exports wait for each other if empty.
+	ExportConflict ExportConflictPolicy `json:"export_conflict,omitempty"`
+
+	// AllowedHostPorts are the host:port addresses that the client granted
+	// containers access to through reverse tunnels, normalized by
+	// ParseHostPortGrant.
+	AllowedHostPorts []string `json:"allowed_host_ports,omitempty"`
+}
+
+// ParseHostPortGrant parses a grant of access to a port on the client's host,
+// either a port on localhost, e.g. 3000, or host:port, e.g. db.local:5432,
+// into its normalized host:port form.
+func ParseHostPortGrant(str string) (string, error) {
+	host, portStr, err := net.SplitHostPort(str)
+	if err != nil {
+		host, portStr = "localhost", str
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return "", fmt.Errorf("invalid host port %q: must be a port, or host:port", str)
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port)), nil
+}
+
+// HostPortAllowed returns whether the client granted containers access to a
+// port on its host.
+func (m ClientMetadata) HostPortAllowed(host string, port int) bool {
+	return slices.Contains(m.AllowedHostPorts, net.JoinHostPort(host, strconv.Itoa(port)))
+}
+
+// ExportConflictPolicy is what to do when an export to the host targets a
+// path that overlaps with an export still in progress, e.g. the same file,
+// or a file under an exported directory.
+type ExportConflictPolicy string
+
+const (
+	// ExportConflictWait runs overlapping exports one after the other, in
+	// the order they started, so that their writes don't race.
+	ExportConflictWait ExportConflictPolicy = "wait"
+	// ExportConflictFail rejects an export that overlaps with one in
+	// progress.
+	ExportConflictFail ExportConflictPolicy = "fail"
+)
+
+func ParseExportConflictPolicy(str string) (ExportConflictPolicy, error) {
+	switch policy := ExportConflictPolicy(str); policy {
+	case "":
+		return ExportConflictWait, nil
+	case ExportConflictWait, ExportConflictFail:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid export conflict policy %q: must be wait or fail", str)
+	}
+}
+
+// NormalizeImageRef parses an image ref the way it's resolved by
+// container.from, expanding it to its fully qualified name and adding a
+// default :latest tag if it has neither a tag nor a digest. Offline bundles
+// key their images by this form.
+func NormalizeImageRef(ref string) (reference.Named, error) {
+	refName, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return nil, err
+	}
+	return reference.TagNameOnly(refName), nil
 }
 
 type clientMetadataCtxKey struct{}
@@ -205,6 +316,9 @@ type LocalExportOpts struct {
 	FileOriginalName   string      `json:"file_original_name"`
 	AllowParentDirPath bool        `json:"allow_parent_dir_path"`
 	FileMode           os.FileMode `json:"file_mode"`
+	// the size of the file being streamed, if known, which the receiver
+	// verifies once the file is written
+	FileSize int64 `json:"file_size,omitempty"`
 	// whether to just merge in contents of a directory to the target on the host
 	// or to replace the target entirely such that it matches the source directory,
 	// which includes deleting any files that are not in the source directory
@@ -250,6 +364,37 @@ func LocalExportOptsFromContext(ctx context.Context) (*LocalExportOpts, error) {
 	return opts, nil
 }
 
+// ExportDigestMD returns the trailer with which the receiver of a local export
+// reports the digest of what it wrote.
+func ExportDigestMD(dgst digest.Digest) metadata.MD {
+	return metadata.Pairs(exportDigestMetaKey, dgst.String())
+}
+
+// ExportDigestFromMD returns the digest reported in the trailer of a local
+// export, if any.
+func ExportDigestFromMD(md metadata.MD) (digest.Digest, bool, error) {
+	vals := md.Get(exportDigestMetaKey)
+	if len(vals) == 0 {
+		return "", false, nil
+	}
+	dgst, err := digest.Parse(vals[0])
+	if err != nil {
+		return "", false, fmt.Errorf("invalid export digest: %w", err)
+	}
+	return dgst, true, nil
+}
+
+// ExportedFilesDigest digests the contents of the files written by a directory
+// export, keyed by their slash-separated path, so that the sender and the
+// receiver can compare them.
+func ExportedFilesDigest(files map[string]digest.Digest) digest.Digest {
+	digester := digest.Canonical.Digester()
+	for _, path := range slices.Sorted(maps.Keys(files)) {
+		fmt.Fprintf(digester.Hash(), "%s\x00%s\n", path, files[path])
+	}
+	return digester.Digest()
+}
+
 func encodeMeta(key string, v interface{}) metadata.MD {
 	b, err := json.Marshal(v)
 	if err != nil {
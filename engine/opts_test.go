@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestParseHostPortGrant(t *testing.T) {
+	for str, expected := range map[string]string{
+		"3000":          "localhost:3000",
+		":3000":         "localhost:3000",
+		"db.local:5432": "db.local:5432",
+		"[::1]:8080":    "[::1]:8080",
+	} {
+		grant, err := ParseHostPortGrant(str)
+		require.NoError(t, err, str)
+		require.Equal(t, expected, grant, str)
+	}
+	for _, str := range []string{"", "http", "localhost:http", "70000", "db.local:0"} {
+		_, err := ParseHostPortGrant(str)
+		require.Error(t, err, str)
+	}
+
+	md := ClientMetadata{AllowedHostPorts: []string{"localhost:3000", "[::1]:8080"}}
+	require.True(t, md.HostPortAllowed("localhost", 3000))
+	require.True(t, md.HostPortAllowed("::1", 8080))
+	require.False(t, md.HostPortAllowed("localhost", 3001))
+	require.False(t, md.HostPortAllowed("127.0.0.1", 3000))
+}
+
+func TestNormalizeImageRef(t *testing.T) {
+	for ref, expected := range map[string]string{
+		"alpine":                         "docker.io/library/alpine:latest",
+		"alpine:3.20":                    "docker.io/library/alpine:3.20",
+		"docker.io/library/alpine:3.20":  "docker.io/library/alpine:3.20",
+		"ghcr.io/dagger/engine:v0.1":     "ghcr.io/dagger/engine:v0.1",
+		"alpine@sha256:" + testDigestHex: "docker.io/library/alpine@sha256:" + testDigestHex,
+		"index.docker.io/library/alpine": "docker.io/library/alpine:latest",
+	} {
+		refName, err := NormalizeImageRef(ref)
+		require.NoError(t, err, ref)
+		require.Equal(t, expected, refName.String(), ref)
+	}
+	_, err := NormalizeImageRef("Not A Ref")
+	require.Error(t, err)
+}
+
+func TestExportDigest(t *testing.T) {
+	dgst, ok, err := ExportDigestFromMD(nil)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	files := map[string]digest.Digest{
+		"a":     digest.FromString("a"),
+		"sub/b": digest.FromString("b"),
+	}
+	dgst, ok, err = ExportDigestFromMD(ExportDigestMD(ExportedFilesDigest(files)))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, ExportedFilesDigest(maps.Clone(files)), dgst)
+
+	// moving or changing a file changes the digest
+	require.NotEqual(t, dgst, ExportedFilesDigest(map[string]digest.Digest{
+		"b":     digest.FromString("a"),
+		"sub/b": digest.FromString("b"),
+	}))
+	require.NotEqual(t, dgst, ExportedFilesDigest(map[string]digest.Digest{
+		"a":     digest.FromString("a"),
+		"sub/b": digest.FromString("c"),
+	}))
+
+	_, _, err = ExportDigestFromMD(metadata.Pairs(exportDigestMetaKey, "nope"))
+	require.Error(t, err)
+}
+
+const testDigestHex = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
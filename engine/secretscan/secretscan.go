@@ -0,0 +1,314 @@
+// Package secretscan finds credentials in files before they leave the engine,
+// e.g. in a directory exported to the client or an image pushed to a
+// registry.
+package secretscan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"dagger.io/dagger/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultMaxFileSize is the size above which files aren't scanned, unless
+// configured otherwise. Credentials are rarely found in large files, and
+// scanning them would slow exports down for little benefit.
+const DefaultMaxFileSize = 1 << 20
+
+// maxFindings is the number of findings reported for a single scan. Anything
+// leaking that many secrets is better fixed at the source than reviewed line
+// by line.
+const maxFindings = 100
+
+// Rule names, reported with each finding.
+const (
+	RuleAWSAccessKey = "aws-access-key"
+	RuleGitHubToken  = "github-token"
+	RuleGitLabToken  = "gitlab-token"
+	RuleSlackToken   = "slack-token"
+	RuleGoogleAPIKey = "google-api-key"
+	RuleStripeKey    = "stripe-key"
+	RulePrivateKey   = "private-key"
+	RuleHighEntropy  = "high-entropy"
+)
+
+const (
+	// highEntropyMinLen is the length below which values assigned to
+	// secret-looking keys aren't reported, e.g. placeholders.
+	highEntropyMinLen = 20
+
+	// highEntropyThreshold is the Shannon entropy, in bits per character,
+	// above which a value assigned to a secret-looking key is reported.
+	// Random base64 scores close to 6, while words and paths score below 4.
+	highEntropyThreshold = 4.0
+)
+
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// rules are patterns of well-known credentials, which are reported wherever
+// they're found.
+var rules = []rule{
+	{RuleAWSAccessKey, regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{RuleGitHubToken, regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{60,})\b`)},
+	{RuleGitLabToken, regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{RuleSlackToken, regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`)},
+	{RuleGoogleAPIKey, regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{RuleStripeKey, regexp.MustCompile(`\b[sr]k_live_[0-9A-Za-z]{20,}\b`)},
+	{RulePrivateKey, regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )*PRIVATE KEY-----`)},
+}
+
+// assignmentPattern matches values assigned to secret-looking keys, e.g.
+// `API_TOKEN=...` or `"password": "..."`. Only those values are checked for
+// entropy, since plenty of innocent data is random-looking, like checksums
+// and certificates.
+var assignmentPattern = regexp.MustCompile(
+	`(?i)(?:secret|token|passw(?:or)?d|api_?key|access_?key|auth|credential)[a-z0-9_.-]*["']?\s*[:=]\s*["']?([A-Za-z0-9+/=_.~-]+)`)
+
+// Finding is a potential secret found by a scan.
+type Finding struct {
+	// Path is the path of the file, relative to the root of the scan.
+	Path string `json:"path"`
+	// Line is the 1-based line number of the finding.
+	Line int `json:"line"`
+	// Rule is the name of the rule that matched.
+	Rule string `json:"rule"`
+	// Match is the matched text, redacted so that the secret isn't leaked by
+	// the report itself.
+	Match string `json:"match"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: %s (%s)", f.Path, f.Line, f.Rule, f.Match)
+}
+
+// FindingsError is returned for exports that were stopped because secrets
+// were found.
+type FindingsError struct {
+	Findings []Finding
+}
+
+func (e *FindingsError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "secret scan found %d potential secrets", len(e.Findings))
+	for i, f := range e.Findings {
+		if i == 5 {
+			fmt.Fprintf(&sb, "\n  ... and %d more", len(e.Findings)-i)
+			break
+		}
+		fmt.Fprintf(&sb, "\n  %s", f)
+	}
+	return sb.String()
+}
+
+// Scanner scans files for secrets. A nil Scanner scans nothing, so that
+// scanning can be disabled without checks at every call site.
+type Scanner struct {
+	maxFileSize int64
+	exclude     []string
+}
+
+// NewScanner returns a scanner that skips files larger than maxFileSize, or
+// DefaultMaxFileSize if it's 0, and files whose path relative to the root of
+// the scan matches one of the exclude patterns. Patterns follow
+// filepath.Match, and also match everything under a matching directory.
+func NewScanner(maxFileSize int64, exclude []string) (*Scanner, error) {
+	if maxFileSize == 0 {
+		maxFileSize = DefaultMaxFileSize
+	}
+	for _, pattern := range exclude {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+	}
+	return &Scanner{maxFileSize: maxFileSize, exclude: exclude}, nil
+}
+
+// Report returns a FindingsError if there are any findings, attaching them
+// to the span in ctx so that they can be reviewed from the trace.
+func Report(ctx context.Context, findings []Finding) error {
+	if len(findings) == 0 {
+		return nil
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String(telemetry.ErrorCategoryAttr, telemetry.ErrorCategorySecretLeak),
+	}
+	if payload, err := json.Marshal(findings); err == nil {
+		attrs = append(attrs, attribute.String(telemetry.SecretFindingsAttr, string(payload)))
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attrs...)
+	return &FindingsError{Findings: findings}
+}
+
+// ScanDir scans every regular file under root, returning findings sorted by
+// path and line.
+func (s *Scanner) ScanDir(ctx context.Context, root string) ([]Finding, error) {
+	if s == nil {
+		return nil, nil
+	}
+	var findings []Finding
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel != "." && s.excluded(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		found, err := s.scanPath(path, rel)
+		if err != nil {
+			return err
+		}
+		findings = append(findings, found...)
+		if len(findings) >= maxFindings {
+			return errTooManyFindings
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errTooManyFindings) {
+		return nil, err
+	}
+	return findings[:min(len(findings), maxFindings)], nil
+}
+
+var errTooManyFindings = errors.New("too many findings")
+
+// ScanFile scans a single file, reporting findings under the given name.
+func (s *Scanner) ScanFile(ctx context.Context, path, name string) ([]Finding, error) {
+	if s == nil {
+		return nil, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.scanPath(path, name)
+}
+
+func (s *Scanner) excluded(rel string) bool {
+	for _, pattern := range s.exclude {
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Scanner) scanPath(path, name string) ([]Finding, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if stat.Size() > s.maxFileSize {
+		return nil, nil
+	}
+	return Scan(f, name)
+}
+
+// Scan scans text read from r, reporting findings under the given name.
+// Binary content is skipped.
+func Scan(r io.Reader, name string) ([]Finding, error) {
+	br := bufio.NewReader(r)
+	if head, _ := br.Peek(8000); bytes.IndexByte(head, 0) != -1 {
+		return nil, nil
+	}
+	var findings []Finding
+	sc := bufio.NewScanner(br)
+	sc.Buffer(make([]byte, 0, 64*1024), DefaultMaxFileSize)
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		findings = append(findings, scanLine(sc.Text(), name, lineNo)...)
+	}
+	if err := sc.Err(); err != nil && !errors.Is(err, bufio.ErrTooLong) {
+		return nil, err
+	}
+	return findings, nil
+}
+
+func scanLine(line, name string, lineNo int) []Finding {
+	var findings []Finding
+	matched := false
+	for _, rule := range rules {
+		for _, match := range rule.pattern.FindAllString(line, -1) {
+			matched = true
+			findings = append(findings, Finding{
+				Path:  name,
+				Line:  lineNo,
+				Rule:  rule.name,
+				Match: redact(match),
+			})
+		}
+	}
+	if matched {
+		// don't report the same secret twice
+		return findings
+	}
+	for _, groups := range assignmentPattern.FindAllStringSubmatch(line, -1) {
+		value := groups[1]
+		if len(value) < highEntropyMinLen || entropy(value) < highEntropyThreshold {
+			continue
+		}
+		findings = append(findings, Finding{
+			Path:  name,
+			Line:  lineNo,
+			Rule:  RuleHighEntropy,
+			Match: redact(value),
+		})
+	}
+	return findings
+}
+
+// entropy returns the Shannon entropy of str, in bits per character.
+func entropy(str string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range str {
+		counts[r]++
+	}
+	var ent float64
+	n := float64(len(str))
+	for _, count := range counts {
+		p := float64(count) / n
+		ent -= p * math.Log2(p)
+	}
+	return ent
+}
+
+// redact keeps just enough of a match to recognize it.
+func redact(match string) string {
+	const keep = 4
+	if strings.HasPrefix(match, "-----") || len(match) <= keep*2 {
+		return match
+	}
+	return match[:keep] + strings.Repeat("*", 8)
+}
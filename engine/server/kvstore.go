@@ -0,0 +1,54 @@
+package server
+
+import (
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/dagger/dagger/core"
+)
+
+// boltKV persists the values of KV stores across sessions, with a bucket per
+// namespace.
+type boltKV struct {
+	db *bolt.DB
+}
+
+var _ core.KVBackend = boltKV{}
+
+func (kv boltKV) Get(namespace, key string) (value string, found bool, err error) {
+	err = kv.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(kvBucketName(namespace))
+		if bucket == nil {
+			return nil
+		}
+		if val := bucket.Get([]byte(key)); val != nil {
+			value, found = string(val), true
+		}
+		return nil
+	})
+	return value, found, err
+}
+
+func (kv boltKV) Set(namespace, key, value string) error {
+	return kv.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(kvBucketName(namespace))
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(key), []byte(value))
+	})
+}
+
+func (kv boltKV) Delete(namespace, key string) error {
+	return kv.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(kvBucketName(namespace))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete([]byte(key))
+	})
+}
+
+// kvBucketName returns the bucket of a namespace.
+func kvBucketName(namespace string) []byte {
+	return []byte("ns:" + namespace)
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// pauseGate holds back new execs in a session while it's paused, letting the
+// ones already running finish.
+type pauseGate struct {
+	mu sync.Mutex
+
+	// resumed is closed when the session is resumed; nil unless paused
+	resumed chan struct{}
+
+	// span covers the time spent paused, so frontends can leave it out of
+	// their durations
+	span trace.Span
+}
+
+// Pause pauses the session, recording the time spent paused in the span
+// returned by startSpan, which is ended on Resume. It returns false if the
+// session was already paused, in which case no span is started.
+func (g *pauseGate) Pause(startSpan func() trace.Span) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resumed != nil {
+		return false
+	}
+	g.resumed = make(chan struct{})
+	g.span = startSpan()
+	return true
+}
+
+// Resume lets execs run again, returning false if the session wasn't paused.
+func (g *pauseGate) Resume() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resumed == nil {
+		return false
+	}
+	close(g.resumed)
+	g.resumed = nil
+	g.span.End()
+	g.span = nil
+	return true
+}
+
+// Wait blocks until the session isn't paused.
+func (g *pauseGate) Wait(ctx context.Context) error {
+	g.mu.Lock()
+	resumed := g.resumed
+	g.mu.Unlock()
+	if resumed == nil {
+		return nil
+	}
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// WaitIfPaused blocks while the given session is paused, so that it doesn't
+// start any new execs.
+func (srv *Server) WaitIfPaused(ctx context.Context, sessionID string) error {
+	srv.daggerSessionsMu.RLock()
+	sess, ok := srv.daggerSessions[sessionID]
+	srv.daggerSessionsMu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return sess.pause.Wait(ctx)
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPauseGate(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	startSpan := func() trace.Span {
+		_, span := tracer.Start(context.Background(), "paused")
+		return span
+	}
+	ctx := context.Background()
+	var g pauseGate
+
+	require.NoError(t, g.Wait(ctx))
+	require.False(t, g.Resume())
+
+	require.True(t, g.Pause(startSpan))
+	// pausing again doesn't start a span that would never end
+	require.False(t, g.Pause(startSpan))
+	require.Len(t, recorder.Started(), 1)
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, g.Wait(timeout), context.DeadlineExceeded)
+
+	waited := make(chan error, 1)
+	go func() { waited <- g.Wait(ctx) }()
+	require.True(t, g.Resume())
+	require.NoError(t, <-waited)
+	require.Len(t, recorder.Ended(), 1)
+	require.False(t, g.Resume())
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"errors"
+	"expvar"
 	"fmt"
 	"io"
 	"os"
@@ -68,11 +69,14 @@ import (
 	"golang.org/x/sync/semaphore"
 	"google.golang.org/grpc"
 
+	"github.com/dagger/dagger/core"
 	"github.com/dagger/dagger/engine"
 	"github.com/dagger/dagger/engine/buildkit"
 	daggercache "github.com/dagger/dagger/engine/cache"
+	"github.com/dagger/dagger/engine/circuit"
 	"github.com/dagger/dagger/engine/clientdb"
 	"github.com/dagger/dagger/engine/distconsts"
+	"github.com/dagger/dagger/engine/secretscan"
 	"github.com/dagger/dagger/engine/slog"
 	"github.com/dagger/dagger/engine/sources/blob"
 	"github.com/dagger/dagger/engine/sources/gitdns"
@@ -94,6 +98,7 @@ type Server struct {
 
 	rootDir           string
 	solverCacheDBPath string
+	kvDBPath          string
 
 	workerRootDir         string
 	snapshotterRootDir    string
@@ -122,6 +127,7 @@ type Server struct {
 	SolverCache          daggercache.Manager
 	containerdMetaBoltDB *bolt.DB
 	containerdMetaDB     *ctdmetadata.DB
+	kvDB                 *bolt.DB
 	localContentStore    content.Store
 	contentStore         *containerdsnapshot.Store
 
@@ -150,6 +156,7 @@ type Server struct {
 	enabledPlatforms []ocispecs.Platform
 	defaultPlatform  ocispecs.Platform
 	registryHosts    docker.RegistryHosts
+	rateLimiters     *core.RateLimiters
 
 	//
 	// telemetry config+state
@@ -171,6 +178,15 @@ type Server struct {
 	daggerSessions   map[string]*daggerSession // session id -> session state
 	daggerSessionsMu sync.RWMutex
 	clientDBs        *clientdb.DBs
+
+	// circuit breakers for external dependencies, shared by all clients
+	breakers *circuit.Breakers
+
+	// scanner for secrets in exports and publishes, nil if disabled
+	secretScanner *secretscan.Scanner
+
+	// normalization of exports and publishes, nil if disabled
+	deterministic *buildkit.DeterministicOpts
 }
 
 type NewServerOpts struct {
@@ -220,6 +236,7 @@ func NewServer(ctx context.Context, opts *NewServerOpts) (*Server, error) {
 		return nil, err
 	}
 	srv.solverCacheDBPath = filepath.Join(srv.rootDir, "cache.db")
+	srv.kvDBPath = filepath.Join(srv.rootDir, "kv.db")
 
 	srv.workerRootDir = filepath.Join(srv.rootDir, "worker")
 	if err := os.MkdirAll(srv.workerRootDir, 0700); err != nil {
@@ -244,6 +261,15 @@ func NewServer(ctx context.Context, opts *NewServerOpts) (*Server, error) {
 	srv.clientDBs = clientdb.NewDBs(srv.clientDBDir)
 	srv.telemetryPubSub = NewPubSub(srv)
 
+	srv.breakers = getCircuitBreakers(*cfg)
+	publishCircuitStates(srv.breakers)
+
+	srv.secretScanner, err = getSecretScanner(*cfg)
+	if err != nil {
+		return nil, err
+	}
+	srv.deterministic = getDeterministicOpts(*cfg)
+
 	//
 	// setup config derived from engine config
 	//
@@ -265,6 +291,7 @@ func NewServer(ctx context.Context, opts *NewServerOpts) (*Server, error) {
 	}
 
 	srv.defaultPlatform = platforms.Normalize(platforms.DefaultSpec())
+	srv.rateLimiters = core.NewRateLimiters()
 	if platformsStr := ociCfg.Platforms; len(platformsStr) != 0 {
 		var err error
 		srv.enabledPlatforms, err = parsePlatforms(platformsStr)
@@ -306,6 +333,11 @@ func NewServer(ctx context.Context, opts *NewServerOpts) (*Server, error) {
 		return nil, fmt.Errorf("failed to open metadata db: %w", err)
 	}
 
+	srv.kvDB, err = bolt.Open(srv.kvDBPath, 0600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open kv db: %w", err)
+	}
+
 	srv.containerdMetaDB = ctdmetadata.NewDB(srv.containerdMetaBoltDB, srv.localContentStore, map[string]ctdsnapshot.Snapshotter{
 		srv.snapshotterName: srv.snapshotter,
 	})
@@ -434,6 +466,7 @@ func NewServer(ctx context.Context, opts *NewServerOpts) (*Server, error) {
 			CacheAccessor: srv.workerCache,
 		},
 		BaseDNSConfig: srv.dns,
+		Breakers:      srv.breakers,
 	})
 	if err != nil {
 		return nil, err
@@ -475,6 +508,7 @@ func NewServer(ctx context.Context, opts *NewServerOpts) (*Server, error) {
 		NetworkProviders:    srv.networkProviders,
 		ParallelismSem:      srv.parallelismSem,
 		WorkerCache:         srv.workerCache,
+		Deterministic:       srv.deterministic,
 	})
 
 	//
@@ -574,7 +608,7 @@ func (srv *Server) Close() error {
 		err = errors.Join(err, srv.removeDaggerSession(context.Background(), s))
 		s.stateMu.Unlock()
 	}
-	return err
+	return errors.Join(err, srv.kvDB.Close())
 }
 
 func (srv *Server) Info(context.Context, *controlapi.InfoRequest) (*controlapi.InfoResponse, error) {
@@ -622,6 +656,57 @@ func (srv *Server) gcClientDBs() {
 	}
 }
 
+func getCircuitBreakers(cfg config.Config) *circuit.Breakers {
+	cb := cfg.CircuitBreaker
+	if cb.Enabled != nil && !*cb.Enabled {
+		return circuit.NewBreakers(0, 0)
+	}
+	threshold := cb.Threshold
+	if threshold == 0 {
+		threshold = circuit.DefaultThreshold
+	}
+	cooldown := cb.Cooldown.Duration
+	if cooldown == 0 {
+		cooldown = circuit.DefaultCooldown
+	}
+	return circuit.NewBreakers(threshold, cooldown)
+}
+
+// publishCircuitStates exposes the circuit breakers that have tripped, or are
+// close to, on the engine's metrics endpoint.
+func publishCircuitStates(breakers *circuit.Breakers) {
+	if expvar.Get("circuits") != nil {
+		// already published, e.g. by another server in the same process
+		return
+	}
+	expvar.Publish("circuits", expvar.Func(func() any {
+		return breakers.States()
+	}))
+}
+
+func getSecretScanner(cfg config.Config) (*secretscan.Scanner, error) {
+	ss := cfg.SecretScan
+	if !ss.Enabled {
+		return nil, nil
+	}
+	scanner, err := secretscan.NewScanner(ss.MaxFileSize, ss.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("secret scan config: %w", err)
+	}
+	return scanner, nil
+}
+
+func getDeterministicOpts(cfg config.Config) *buildkit.DeterministicOpts {
+	d := cfg.Deterministic
+	if !d.Enabled {
+		return nil
+	}
+	return &buildkit.DeterministicOpts{
+		Epoch:  time.Unix(d.Epoch, 0).UTC(),
+		Verify: d.Verify,
+	}
+}
+
 func (srv *Server) activeClientIDs() map[string]bool {
 	keep := map[string]bool{}
 
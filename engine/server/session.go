@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"runtime"
 	"runtime/debug"
+	"slices"
 	"sync"
 	"time"
 
@@ -75,6 +76,12 @@ type daggerSession struct {
 
 	services *core.Services
 
+	events *core.EventBus
+
+	locks *core.Locks
+
+	kv *core.KVStore
+
 	analytics analytics.Tracker
 
 	authProvider *auth.RegistryAuthProvider
@@ -92,6 +99,28 @@ type daggerSession struct {
 
 	interactive        bool
 	interactiveCommand []string
+
+	// whether independent branches keep going after a failure
+	keepGoing bool
+
+	// whether to record the size of calls' arguments and results
+	payloadSizes bool
+
+	// what to do when exports to the host overlap
+	exportConflict engine.ExportConflictPolicy
+
+	// the functions that the session is limited to, if any
+	callTargets []string
+
+	// when the session times out, if it has a timeout
+	deadline time.Time
+	timeout  time.Duration
+
+	// running calls, to cancel individually
+	callCancels *core.CallCancels
+
+	// holds back new execs while the session is paused
+	pause pauseGate
 }
 
 type daggerSessionState string
@@ -230,6 +259,9 @@ func (srv *Server) initializeDaggerSession(
 	sess.endpoints = map[string]http.Handler{}
 	sess.shutdownCh = make(chan struct{})
 	sess.services = core.NewServices()
+	sess.events = core.NewEventBus()
+	sess.locks = core.NewLocks()
+	sess.kv = core.NewKVStore(boltKV{srv.kvDB})
 	sess.authProvider = auth.NewRegistryAuthProvider()
 	sess.refs = map[buildkit.Reference]struct{}{}
 	sess.containers = map[bkgw.Container]struct{}{}
@@ -237,6 +269,15 @@ func (srv *Server) initializeDaggerSession(
 	sess.telemetryPubSub = srv.telemetryPubSub
 	sess.interactive = clientMetadata.Interactive
 	sess.interactiveCommand = clientMetadata.InteractiveCommand
+	sess.keepGoing = clientMetadata.KeepGoing
+	sess.payloadSizes = clientMetadata.PayloadSizes
+	sess.exportConflict = clientMetadata.ExportConflict
+	sess.callTargets = clientMetadata.CallTargets
+	sess.callCancels = core.NewCallCancels()
+	if clientMetadata.SessionTimeout > 0 {
+		sess.timeout = clientMetadata.SessionTimeout
+		sess.deadline = time.Now().Add(sess.timeout)
+	}
 
 	sess.analytics = analytics.New(analytics.Config{
 		DoNotTrack: clientMetadata.DoNotTrack || analytics.DoNotTrack(),
@@ -304,6 +345,9 @@ func (srv *Server) removeDaggerSession(ctx context.Context, sess *daggerSession)
 
 	sess.state = sessionStateRemoved
 
+	// don't leave anything waiting on a session that's going away
+	sess.pause.Resume()
+
 	var errs error
 
 	// in theory none of this should block very long, but add a safeguard just in case
@@ -538,6 +582,12 @@ func (srv *Server) initializeDaggerClient(
 
 		Interactive:        client.daggerSession.interactive,
 		InteractiveCommand: client.daggerSession.interactiveCommand,
+		KeepGoing:          client.daggerSession.keepGoing,
+
+		Breakers:       srv.breakers,
+		SecretScanner:  srv.secretScanner,
+		Deterministic:  srv.deterministic,
+		ExportConflict: client.daggerSession.exportConflict,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create buildkit client: %w", err)
@@ -975,6 +1025,9 @@ func (srv *Server) serveHTTPToClient(w http.ResponseWriter, r *http.Request, opt
 		mux.Handle(engine.QueryEndpoint, httpHandlerFunc(srv.serveQuery, client))
 		mux.Handle(engine.InitEndpoint, httpHandlerFunc(srv.serveInit, client))
 		mux.Handle(engine.ShutdownEndpoint, httpHandlerFunc(srv.serveShutdown, client))
+		mux.Handle(engine.CancelEndpoint, httpHandlerFunc(srv.serveCancel, client))
+		mux.Handle(engine.PauseEndpoint, httpHandlerFunc(srv.servePause, client))
+		mux.Handle(engine.ResumeEndpoint, httpHandlerFunc(srv.serveResume, client))
 		sess.endpointMu.RLock()
 		for path, handler := range sess.endpoints {
 			mux.Handle(path, handler)
@@ -1063,6 +1116,17 @@ func (srv *Server) serveSessionAttachables(w http.ResponseWriter, r *http.Reques
 func (srv *Server) serveQuery(w http.ResponseWriter, r *http.Request, client *daggerClient) (rerr error) {
 	ctx := r.Context()
 
+	// enforce the session's timeout on every query, including those of
+	// nested clients
+	if sess := client.daggerSession; !sess.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadlineCause(ctx, sess.deadline, &core.TimeoutError{
+			Timeout: sess.timeout,
+			Session: true,
+		})
+		defer cancel()
+	}
+
 	// only record telemetry if the request is traced, otherwise
 	// we end up with orphaned spans in their own separate traces from tests etc.
 	if trace.SpanContextFromContext(ctx).IsValid() {
@@ -1078,6 +1142,11 @@ func (srv *Server) serveQuery(w http.ResponseWriter, r *http.Request, client *da
 		defer telemetry.End(span, func() error { return rerr })
 	}
 
+	ctx = core.WithCallCancels(ctx, client.daggerSession.callCancels)
+	if client.daggerSession.payloadSizes {
+		ctx = core.WithPayloadSizes(ctx)
+	}
+
 	// install a logger+meter provider that records to the client's DB
 	ctx = telemetry.WithLoggerProvider(ctx, client.loggerProvider)
 	ctx = telemetry.WithMeterProvider(ctx, client.meterProvider)
@@ -1133,6 +1202,81 @@ func (srv *Server) serveInit(w http.ResponseWriter, _ *http.Request, client *dag
 	return nil
 }
 
+// serveCancel cancels a single running call in the session by its span,
+// along with everything it started, leaving the rest of the session running.
+func (srv *Server) serveCancel(w http.ResponseWriter, r *http.Request, client *daggerClient) error {
+	if r.Method != http.MethodPost {
+		return httpErr(fmt.Errorf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+	}
+	if err := requireMainClient(client); err != nil {
+		return err
+	}
+	spanID, err := trace.SpanIDFromHex(r.URL.Query().Get("span"))
+	if err != nil {
+		return httpErr(fmt.Errorf("invalid span: %w", err), http.StatusBadRequest)
+	}
+	if !client.daggerSession.callCancels.Cancel(spanID) {
+		return httpErr(fmt.Errorf("no running call for span %s", spanID), http.StatusNotFound)
+	}
+	slog.Info("canceled call", "span", spanID, "clientID", client.clientID)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// servePause stops the session from starting any new execs until it's
+// resumed, letting the ones already running finish.
+func (srv *Server) servePause(w http.ResponseWriter, r *http.Request, client *daggerClient) error {
+	if r.Method != http.MethodPost {
+		return httpErr(fmt.Errorf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+	}
+	if err := requireMainClient(client); err != nil {
+		return err
+	}
+	// record the time spent paused under the caller's span, if any, so it can
+	// be left out of durations
+	paused := client.daggerSession.pause.Pause(func() trace.Span {
+		ctx := context.WithoutCancel(r.Context())
+		span := trace.SpanFromContext(ctx)
+		if span.SpanContext().IsValid() {
+			_, span = client.tracerProvider.Tracer(InstrumentationLibrary).Start(ctx, "paused",
+				trace.WithAttributes(attribute.Bool(telemetry.UIPauseAttr, true)))
+		}
+		return span
+	})
+	if !paused {
+		return httpErr(errors.New("session is already paused"), http.StatusConflict)
+	}
+	slog.Info("paused session", "sessionID", client.daggerSession.sessionID, "clientID", client.clientID)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// serveResume lets a paused session start new execs again.
+func (srv *Server) serveResume(w http.ResponseWriter, r *http.Request, client *daggerClient) error {
+	if r.Method != http.MethodPost {
+		return httpErr(fmt.Errorf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+	}
+	if err := requireMainClient(client); err != nil {
+		return err
+	}
+	if !client.daggerSession.pause.Resume() {
+		return httpErr(errors.New("session is not paused"), http.StatusConflict)
+	}
+	slog.Info("resumed session", "sessionID", client.daggerSession.sessionID, "clientID", client.clientID)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// requireMainClient returns an error unless the client is the session's main
+// client, which alone may control the session, e.g. pause it; nested clients
+// like module functions only act within their own calls.
+func requireMainClient(client *daggerClient) error {
+	if client.clientID != client.daggerSession.mainClientCallerID {
+		return httpErr(errors.New("only the main client can control the session"), http.StatusForbidden)
+	}
+	return nil
+}
+
 func (srv *Server) serveShutdown(w http.ResponseWriter, r *http.Request, client *daggerClient) (rerr error) {
 	ctx := r.Context()
 
@@ -1246,6 +1390,32 @@ func (srv *Server) CurrentFunctionCall(ctx context.Context) (*core.FunctionCall,
 	return client.fnCall, nil
 }
 
+// The functions that the session is limited to calling, and whether the
+// current client is running within a call to one of them
+func (srv *Server) CallTargets(ctx context.Context) ([]string, bool, error) {
+	client, err := srv.clientFromContext(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	targets := client.daggerSession.callTargets
+	if len(targets) == 0 {
+		return nil, true, nil
+	}
+	inFunction := false
+	for _, c := range append(slices.Clone(client.parents), client) {
+		if c.fnCall == nil {
+			continue
+		}
+		inFunction = true
+		if core.IsCallTarget(targets, c.fnCall.ParentName, c.fnCall.Name) {
+			return targets, true, nil
+		}
+	}
+	// calls from outside of any function, e.g. the CLI, are where the
+	// targets are reached from, so they always run
+	return targets, !inFunction, nil
+}
+
 // Return the list of deps being served to the current client
 func (srv *Server) CurrentServedDeps(ctx context.Context) (*core.ModDeps, error) {
 	client, err := srv.clientFromContext(ctx)
@@ -1340,6 +1510,50 @@ func (srv *Server) Services(ctx context.Context) (*core.Services, error) {
 	return client.daggerSession.services, nil
 }
 
+// The event bus for the current client's session
+func (srv *Server) Events(ctx context.Context) (*core.EventBus, error) {
+	client, err := srv.clientFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.daggerSession.events, nil
+}
+
+// The locks for the current client's session
+func (srv *Server) Locks(ctx context.Context) (*core.Locks, error) {
+	client, err := srv.clientFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.daggerSession.locks, nil
+}
+
+// NestedClientExited releases the locks held by a nested client, e.g. a
+// function call, once its exec has exited.
+func (srv *Server) NestedClientExited(execMD *buildkit.ExecutionMetadata) {
+	srv.daggerSessionsMu.RLock()
+	sess, ok := srv.daggerSessions[execMD.SessionID]
+	srv.daggerSessionsMu.RUnlock()
+	if !ok || sess.locks == nil {
+		return
+	}
+	sess.locks.Release(execMD.ClientID)
+}
+
+// The key/value store for the current client's session
+func (srv *Server) KV(ctx context.Context) (*core.KVStore, error) {
+	client, err := srv.clientFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.daggerSession.kv, nil
+}
+
+// The rate limiters for the engine as a whole
+func (srv *Server) RateLimiters() *core.RateLimiters {
+	return srv.rateLimiters
+}
+
 // The default platform for the engine as a whole
 func (srv *Server) Platform() core.Platform {
 	return core.Platform(srv.defaultPlatform)
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/engine/buildkit"
+)
+
+func TestServeCancel(t *testing.T) {
+	srv := &Server{}
+	client := &daggerClient{
+		daggerSession: &daggerSession{
+			mainClientCallerID: "main",
+			callCancels:        core.NewCallCancels(),
+		},
+		clientID: "main",
+	}
+	cancel := func(method, span string) int {
+		return serveStatus(t, srv.serveCancel, client, method, "/cancel?span="+span)
+	}
+
+	spanID := trace.SpanID{1}
+	ctx, done := client.daggerSession.callCancels.Track(context.Background(), spanID)
+	require.Equal(t, http.StatusMethodNotAllowed, cancel(http.MethodGet, spanID.String()))
+	require.Equal(t, http.StatusBadRequest, cancel(http.MethodPost, "nope"))
+	require.NoError(t, ctx.Err())
+
+	require.Equal(t, http.StatusNoContent, cancel(http.MethodPost, spanID.String()))
+	require.ErrorIs(t, context.Cause(ctx), core.ErrCanceledByUser)
+
+	// once the call has returned, there's nothing to cancel
+	done()
+	require.Equal(t, http.StatusNotFound, cancel(http.MethodPost, spanID.String()))
+}
+
+func TestSessionControlMainClientOnly(t *testing.T) {
+	srv := &Server{}
+	sess := &daggerSession{
+		mainClientCallerID: "main",
+		callCancels:        core.NewCallCancels(),
+	}
+	main := &daggerClient{daggerSession: sess, clientID: "main"}
+	nested := &daggerClient{daggerSession: sess, clientID: "nested"}
+
+	spanID := trace.SpanID{1}
+	ctx, done := sess.callCancels.Track(context.Background(), spanID)
+	defer done()
+
+	// nested clients, e.g. module functions, can't control the session
+	require.Equal(t, http.StatusForbidden, serveStatus(t, srv.serveCancel, nested, http.MethodPost, "/cancel?span="+spanID.String()))
+	require.NoError(t, ctx.Err())
+	require.Equal(t, http.StatusForbidden, serveStatus(t, srv.servePause, nested, http.MethodPost, "/pause"))
+	require.Equal(t, http.StatusNoContent, serveStatus(t, srv.servePause, main, http.MethodPost, "/pause"))
+	require.Equal(t, http.StatusForbidden, serveStatus(t, srv.serveResume, nested, http.MethodPost, "/resume"))
+	require.Equal(t, http.StatusNoContent, serveStatus(t, srv.serveResume, main, http.MethodPost, "/resume"))
+	require.Equal(t, http.StatusNoContent, serveStatus(t, srv.serveCancel, main, http.MethodPost, "/cancel?span="+spanID.String()))
+}
+
+// serveStatus calls an HTTP handler of the session, returning the status it
+// responds with.
+func serveStatus(t *testing.T, fn func(http.ResponseWriter, *http.Request, *daggerClient) error, client *daggerClient, method, target string) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	err := fn(w, httptest.NewRequest(method, target, nil), client)
+	if err == nil {
+		return w.Code
+	}
+	var httpErr httpError
+	require.ErrorAs(t, err, &httpErr)
+	return httpErr.code
+}
+
+func TestNestedClientExited(t *testing.T) {
+	sess := &daggerSession{sessionID: "sess", locks: core.NewLocks()}
+	srv := &Server{daggerSessions: map[string]*daggerSession{"sess": sess}}
+	ctx := context.Background()
+
+	_, err := sess.locks.Lock(ctx, "npm-cache", "fn")
+	require.NoError(t, err)
+	srv.NestedClientExited(&buildkit.ExecutionMetadata{SessionID: "sess", ClientID: "fn"})
+	lockCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	_, err = sess.locks.Lock(lockCtx, "npm-cache", "other")
+	require.NoError(t, err)
+
+	// sessions that are already gone are ignored
+	srv.NestedClientExited(&buildkit.ExecutionMetadata{SessionID: "gone", ClientID: "fn"})
+}
@@ -195,6 +195,8 @@ func (ps *PubSub) MetricsHandler(rw http.ResponseWriter, r *http.Request) {
 const otlpBatchSize = 1000
 
 func (ps *PubSub) TracesSubscribeHandler(w http.ResponseWriter, r *http.Request, client *daggerClient) error {
+	caps := enginetel.NegotiateCapabilities(r.Header.Get(enginetel.CapabilitiesHeader))
+	w.Header().Set(enginetel.CapabilitiesHeader, caps.String())
 	return ps.sseHandler(w, r, client, func(ctx context.Context, db *sql.DB, lastID string) (*sse.Event, bool, error) {
 		var since int64
 		if lastID != "" {
@@ -221,7 +223,7 @@ func (ps *PubSub) TracesSubscribeHandler(w http.ResponseWriter, r *http.Request,
 		}
 		// Marshal the spans to OTLP.
 		payload, err := protojson.Marshal(&coltracepb.ExportTraceServiceRequest{
-			ResourceSpans: telemetry.SpansToPB(roSpans),
+			ResourceSpans: telemetry.SpansToPB(caps.DowngradeSpans(roSpans)),
 		})
 		if err != nil {
 			return nil, false, fmt.Errorf("marshal spans: %w", err)
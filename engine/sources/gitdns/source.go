@@ -1,6 +1,7 @@
 package gitdns
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"fmt"
@@ -26,12 +27,14 @@ import (
 	"github.com/moby/buildkit/source"
 	srcgit "github.com/moby/buildkit/source/git"
 	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/gitutil"
 	"github.com/moby/buildkit/util/urlutil"
 	"github.com/moby/locker"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/dagger/dagger/engine/circuit"
 	"github.com/dagger/dagger/network"
 )
 
@@ -41,14 +44,16 @@ var defaultBranch = regexp.MustCompile(`refs/heads/(\S+)`)
 type Opt struct {
 	srcgit.Opt
 	BaseDNSConfig *oci.DNSConfig
+	Breakers      *circuit.Breakers
 }
 
 type gitSource struct {
 	src source.Source
 
-	cache  cache.Accessor
-	locker *locker.Locker
-	dns    *oci.DNSConfig
+	cache    cache.Accessor
+	locker   *locker.Locker
+	dns      *oci.DNSConfig
+	breakers *circuit.Breakers
 }
 
 func NewSource(opt Opt) (source.Source, error) {
@@ -57,10 +62,11 @@ func NewSource(opt Opt) (source.Source, error) {
 		return nil, err
 	}
 	gs := &gitSource{
-		src:    src,
-		cache:  opt.CacheAccessor,
-		locker: locker.New(),
-		dns:    opt.BaseDNSConfig,
+		src:      src,
+		cache:    opt.CacheAccessor,
+		locker:   locker.New(),
+		dns:      opt.BaseDNSConfig,
+		breakers: opt.Breakers,
 	}
 	return gs, nil
 }
@@ -342,6 +348,16 @@ func (gs *gitSourceHandler) mountKnownHosts() (string, func() error, error) {
 	return knownHosts.Name(), cleanup, nil
 }
 
+// withCircuit runs fn, which talks to the remote, through the circuit
+// breaker for the remote's host.
+func (gs *gitSourceHandler) withCircuit(ctx context.Context, fn func() error) error {
+	var host string
+	if remote, err := gitutil.ParseURL(gs.src.Remote); err == nil {
+		host = remote.Host
+	}
+	return gs.breakers.Do(ctx, host, fn)
+}
+
 func (gs *gitSourceHandler) dnsConfig() *oci.DNSConfig {
 	clientDomains := []string{}
 	if gs.src.Namespace != "" {
@@ -401,7 +417,10 @@ func (gs *gitSourceHandler) CacheKey(ctx context.Context, g session.Group, index
 
 	ref := gs.src.Ref
 	if ref == "" {
-		ref, err = getDefaultBranch(ctx, git, gs.src.Remote)
+		err = gs.withCircuit(ctx, func() (err error) {
+			ref, err = getDefaultBranch(ctx, git, gs.src.Remote)
+			return err
+		})
 		if err != nil {
 			return "", "", nil, false, err
 		}
@@ -409,7 +428,11 @@ func (gs *gitSourceHandler) CacheKey(ctx context.Context, g session.Group, index
 
 	// TODO: should we assume that remote tag is immutable? add a timer?
 
-	buf, err := git.run(ctx, "ls-remote", "origin", ref, ref+"^{}")
+	var buf *bytes.Buffer
+	err = gs.withCircuit(ctx, func() (err error) {
+		buf, err = git.run(ctx, "ls-remote", "origin", ref, ref+"^{}")
+		return err
+	})
 	if err != nil {
 		return "", "", nil, false, errors.Wrapf(err, "failed to fetch remote %s", urlutil.RedactCredentials(remote))
 	}
@@ -552,7 +575,10 @@ func (gs *gitSourceHandler) Snapshot(ctx context.Context, g session.Group) (out
 			// TODO: is there a better way to do this?
 			args = append(args, "--force", ref+":tags/"+ref)
 		}
-		if _, err := git.run(ctx, args...); err != nil {
+		if err := gs.withCircuit(ctx, func() error {
+			_, err := git.run(ctx, args...)
+			return err
+		}); err != nil {
 			return nil, errors.Wrapf(err, "failed to fetch remote %s", urlutil.RedactCredentials(gs.src.Remote))
 		}
 		_, err = git.run(ctx, "reflog", "expire", "--all", "--expire=now")
@@ -629,7 +655,10 @@ func (gs *gitSourceHandler) Snapshot(ctx context.Context, g session.Group) (out
 		default:
 			pullref += ":" + pullref
 		}
-		_, err = checkoutGit.run(ctx, "fetch", "-u", "--depth=1", "origin", pullref)
+		err = gs.withCircuit(ctx, func() error {
+			_, err := checkoutGit.run(ctx, "fetch", "-u", "--depth=1", "origin", pullref)
+			return err
+		})
 		if err != nil {
 			return nil, err
 		}
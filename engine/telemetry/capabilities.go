@@ -0,0 +1,166 @@
+package telemetry
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"dagger.io/dagger/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// CapabilitiesHeader lists the telemetry capabilities of a frontend, sent when
+// subscribing to the engine's telemetry. The engine responds with the same
+// header, listing the capabilities that both sides support.
+const CapabilitiesHeader = "X-Dagger-Telemetry-Capabilities"
+
+// Telemetry capabilities, negotiated between the engine and frontends so that
+// new telemetry features can roll out without breaking older frontends that
+// share an engine. The engine downgrades the telemetry it sends to frontends
+// that lack a capability.
+//
+// New telemetry features that older frontends would mishandle should add a
+// capability here, along with a downgrade in Capabilities.DowngradeSpans.
+const (
+	// CapabilitySpanEvents is support for span events.
+	CapabilitySpanEvents = "span-events"
+
+	// CapabilityAnnotations is support for span annotations. See
+	// telemetry.AnnotationAttrPrefix.
+	CapabilityAnnotations = "annotations"
+)
+
+// SupportedCapabilities are the capabilities of this engine and its
+// frontends.
+var SupportedCapabilities = Capabilities{
+	CapabilitySpanEvents,
+	CapabilityAnnotations,
+}
+
+// LegacyCapabilities are assumed of frontends that predate negotiation, i.e.
+// that don't send a CapabilitiesHeader.
+var LegacyCapabilities = Capabilities{
+	CapabilitySpanEvents,
+}
+
+// Capabilities is a set of telemetry capabilities.
+type Capabilities []string
+
+// ParseCapabilities parses the value of a CapabilitiesHeader, returning
+// LegacyCapabilities if it's empty.
+func ParseCapabilities(header string) Capabilities {
+	if header == "" {
+		return LegacyCapabilities
+	}
+	caps := Capabilities{}
+	for _, cap := range strings.Split(header, ",") {
+		if cap = strings.TrimSpace(cap); cap != "" && !caps.Has(cap) {
+			caps = append(caps, cap)
+		}
+	}
+	return caps
+}
+
+// NegotiateCapabilities returns the capabilities listed in a frontend's
+// CapabilitiesHeader that the engine also supports.
+func NegotiateCapabilities(header string) Capabilities {
+	caps := Capabilities{}
+	for _, cap := range ParseCapabilities(header) {
+		if SupportedCapabilities.Has(cap) {
+			caps = append(caps, cap)
+		}
+	}
+	return caps
+}
+
+// Has returns whether the set includes the capability.
+func (caps Capabilities) Has(cap string) bool {
+	return slices.Contains(caps, cap)
+}
+
+// String returns the capabilities as the value of a CapabilitiesHeader.
+func (caps Capabilities) String() string {
+	return strings.Join(caps, ",")
+}
+
+// DowngradeSpans removes the telemetry features that the capabilities don't
+// include from spans, leaving spans that need no changes as-is.
+func (caps Capabilities) DowngradeSpans(spans []sdktrace.ReadOnlySpan) []sdktrace.ReadOnlySpan {
+	events := caps.Has(CapabilitySpanEvents)
+	annotations := caps.Has(CapabilityAnnotations)
+	if events && annotations {
+		return spans
+	}
+	downgraded := make([]sdktrace.ReadOnlySpan, len(spans))
+	for i, span := range spans {
+		down := downgradedSpan{
+			ReadOnlySpan: span,
+			attrs:        span.Attributes(),
+			events:       span.Events(),
+		}
+		if !events {
+			down.events = nil
+		}
+		if !annotations {
+			down.attrs = slices.DeleteFunc(slices.Clone(down.attrs), func(kv attribute.KeyValue) bool {
+				return strings.HasPrefix(string(kv.Key), telemetry.AnnotationAttrPrefix)
+			})
+		}
+		downgraded[i] = down
+	}
+	return downgraded
+}
+
+// CapabilitiesClient is an HTTP client for subscribing to the engine's
+// telemetry. It sends the frontend's capabilities with each request and
+// records the ones the engine responds with, so that the frontend can
+// downgrade the telemetry it forwards to match.
+type CapabilitiesClient struct {
+	Client interface {
+		Do(*http.Request) (*http.Response, error)
+	}
+
+	negotiated atomic.Pointer[Capabilities]
+}
+
+func (c *CapabilitiesClient) Do(req *http.Request) (*http.Response, error) {
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.Header.Set(CapabilitiesHeader, SupportedCapabilities.String())
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	// engines that predate negotiation don't respond with the header, and
+	// are assumed to have only the legacy capabilities
+	caps := NegotiateCapabilities(resp.Header.Get(CapabilitiesHeader))
+	c.negotiated.Store(&caps)
+	return resp, nil
+}
+
+// Capabilities returns the capabilities negotiated with the engine, or
+// LegacyCapabilities until it has responded.
+func (c *CapabilitiesClient) Capabilities() Capabilities {
+	if caps := c.negotiated.Load(); caps != nil {
+		return *caps
+	}
+	return LegacyCapabilities
+}
+
+type downgradedSpan struct {
+	// Embed the interface to implement the private method.
+	sdktrace.ReadOnlySpan
+	attrs  []attribute.KeyValue
+	events []sdktrace.Event
+}
+
+func (s downgradedSpan) Attributes() []attribute.KeyValue {
+	return s.attrs
+}
+
+func (s downgradedSpan) Events() []sdktrace.Event {
+	return s.events
+}
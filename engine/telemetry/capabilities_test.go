@@ -0,0 +1,91 @@
+package telemetry_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	sdktelemetry "dagger.io/dagger/telemetry"
+	"github.com/dagger/dagger/engine/telemetry"
+)
+
+func TestNegotiateCapabilities(t *testing.T) {
+	require.Equal(t, telemetry.LegacyCapabilities, telemetry.NegotiateCapabilities(""))
+	require.Equal(t,
+		telemetry.Capabilities{telemetry.CapabilityAnnotations},
+		telemetry.NegotiateCapabilities(" annotations, from-the-future,annotations"))
+	require.Equal(t,
+		telemetry.SupportedCapabilities,
+		telemetry.NegotiateCapabilities(telemetry.SupportedCapabilities.String()))
+}
+
+func TestDowngradeSpans(t *testing.T) {
+	spans := tracetest.SpanStubs{
+		{
+			Name: "annotated",
+			Attributes: []attribute.KeyValue{
+				attribute.String("foo", "bar"),
+				attribute.String(sdktelemetry.AnnotationAttrPrefix+"owner", "me"),
+			},
+			Events: []sdktrace.Event{{Name: "event"}},
+		},
+	}.Snapshots()
+
+	require.Equal(t, spans, telemetry.SupportedCapabilities.DowngradeSpans(spans))
+
+	legacy := telemetry.LegacyCapabilities.DowngradeSpans(spans)
+	require.Len(t, legacy, 1)
+	require.Equal(t, "annotated", legacy[0].Name())
+	require.Equal(t, []attribute.KeyValue{attribute.String("foo", "bar")}, legacy[0].Attributes())
+	require.Len(t, legacy[0].Events(), 1)
+	// the original spans are left as-is
+	require.Len(t, spans[0].Attributes(), 2)
+
+	none := telemetry.Capabilities{}.DowngradeSpans(spans)
+	require.Empty(t, none[0].Events())
+}
+
+func TestCapabilitiesClient(t *testing.T) {
+	engineCaps := ""
+	var frontendCaps string
+	engine := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		frontendCaps = r.Header.Get(telemetry.CapabilitiesHeader)
+		if engineCaps != "" {
+			w.Header().Set(telemetry.CapabilitiesHeader, engineCaps)
+		}
+	}))
+	defer engine.Close()
+
+	subscribe := func(client *telemetry.CapabilitiesClient) {
+		req, err := http.NewRequest(http.MethodGet, engine.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	client := &telemetry.CapabilitiesClient{Client: engine.Client()}
+	require.Equal(t, telemetry.LegacyCapabilities, client.Capabilities())
+
+	engineCaps = telemetry.CapabilityAnnotations
+	subscribe(client)
+	require.Equal(t, telemetry.SupportedCapabilities.String(), frontendCaps)
+	require.Equal(t, telemetry.Capabilities{telemetry.CapabilityAnnotations}, client.Capabilities())
+
+	// engines that predate negotiation get the legacy capabilities
+	engineCaps = ""
+	subscribe(client)
+	require.Equal(t, telemetry.LegacyCapabilities, client.Capabilities())
+	spans := tracetest.SpanStubs{
+		{
+			Name:       "annotated",
+			Attributes: []attribute.KeyValue{attribute.String(sdktelemetry.AnnotationAttrPrefix+"owner", "me")},
+		},
+	}.Snapshots()
+	require.Empty(t, client.Capabilities().DowngradeSpans(spans)[0].Attributes())
+}
@@ -40,6 +40,14 @@ func Close() error {
 	return err
 }
 
+// Acquire a named lock shared by the session, waiting for it to be released if it's held.
+//
+// Returns a lease to release the lock with, using releaseLock. The lock is released anyway once the calling function exits. Time spent waiting is recorded as a metric of the call.
+func AcquireLock(ctx context.Context, key string) (string, error) {
+	client := initClient()
+	return client.AcquireLock(ctx, key)
+}
+
 // Retrieves a container builtin to the engine.
 func BuiltinContainer(digest string) *dagger.Container {
 	client := initClient()
@@ -52,6 +60,30 @@ func CacheVolume(key string, opts ...dagger.CacheVolumeOpts) *dagger.CacheVolume
 	return client.CacheVolume(key, opts...)
 }
 
+// Canonicalize an object ID, stripping fields that do not affect its result.
+//
+// IDs which would produce the same result have the same canonical ID, regardless of how they were constructed.
+func CanonicalID(ctx context.Context, id string) (string, error) {
+	client := initClient()
+	return client.CanonicalID(ctx, id)
+}
+
+// Claim a named once-per-session guard, for setup work that must only be done once.
+//
+// Returns true to the first caller, which should do the work, then call finishOnce. Other callers wait for the work to be finished and get false. If the calling function exits without finishing the work, a waiting caller claims the guard instead. Time spent waiting is recorded as a metric of the call.
+func ClaimOnce(ctx context.Context, key string) (bool, error) {
+	client := initClient()
+	return client.ClaimOnce(ctx, key)
+}
+
+// Create a new, empty configuration.
+//
+// Declare its keys with withKey, then load their values with withFile and withEnv, replacing ad-hoc parsing of environment variables.
+func Config() *dagger.Config {
+	client := initClient()
+	return client.Config()
+}
+
 // Creates a scratch container.
 //
 // Optional platform argument initializes new containers to execute and publish as that platform. Platform defaults to that of the builder's host.
@@ -80,6 +112,12 @@ func CurrentTypeDefs(ctx context.Context) ([]dagger.TypeDef, error) {
 	return client.CurrentTypeDefs(ctx)
 }
 
+// Decode an object ID into the chain of calls that produced it, as JSON.
+func DecodeID(ctx context.Context, id string) (dagger.JSON, error) {
+	client := initClient()
+	return client.DecodeID(ctx, id)
+}
+
 // The default platform of the engine.
 func DefaultPlatform(ctx context.Context) (dagger.Platform, error) {
 	client := initClient()
@@ -104,6 +142,12 @@ func Error(message string) *dagger.Error {
 	return client.Error(message)
 }
 
+// Finish the work guarded by a once-per-session guard claimed with claimOnce.
+func FinishOnce(ctx context.Context, key string, opts ...dagger.FinishOnceOpts) error {
+	client := initClient()
+	return client.FinishOnce(ctx, key, opts...)
+}
+
 // Creates a function.
 func Function(name string, returnType *dagger.TypeDef) *dagger.Function {
 	client := initClient()
@@ -134,18 +178,58 @@ func HTTP(url string, opts ...dagger.HTTPOpts) *dagger.File {
 	return client.HTTP(url, opts...)
 }
 
+// Delete a key from the session's key/value store, if it's set.
+func KvDelete(ctx context.Context, key string, opts ...dagger.KvDeleteOpts) error {
+	client := initClient()
+	return client.KvDelete(ctx, key, opts...)
+}
+
+// Get the value of a key in the session's key/value store, or null if it's not set.
+//
+// Keys are scoped to the calling module's source, and to the project and client it's called from.
+func KvGet(ctx context.Context, key string, opts ...dagger.KvGetOpts) (string, error) {
+	client := initClient()
+	return client.KvGet(ctx, key, opts...)
+}
+
+// Set the value of a key in the session's key/value store.
+//
+// Keys are scoped to the calling module's source, and to the project and client it's called from. Values last for the session, unless persisted by the engine across sessions, e.g. to compare the digests of artifacts with those of the previous run.
+func KvSet(ctx context.Context, key string, value string, opts ...dagger.KvSetOpts) error {
+	client := initClient()
+	return client.KvSet(ctx, key, value, opts...)
+}
+
 // Load a CacheVolume from its ID.
 func LoadCacheVolumeFromID(id dagger.CacheVolumeID) *dagger.CacheVolume {
 	client := initClient()
 	return client.LoadCacheVolumeFromID(id)
 }
 
+// Load a Config from its ID.
+func LoadConfigFromID(id dagger.ConfigID) *dagger.Config {
+	client := initClient()
+	return client.LoadConfigFromID(id)
+}
+
+// Load a ConfigKey from its ID.
+func LoadConfigKeyFromID(id dagger.ConfigKeyID) *dagger.ConfigKey {
+	client := initClient()
+	return client.LoadConfigKeyFromID(id)
+}
+
 // Load a Container from its ID.
 func LoadContainerFromID(id dagger.ContainerID) *dagger.Container {
 	client := initClient()
 	return client.LoadContainerFromID(id)
 }
 
+// Load a ContainerLayer from its ID.
+func LoadContainerLayerFromID(id dagger.ContainerLayerID) *dagger.ContainerLayer {
+	client := initClient()
+	return client.LoadContainerLayerFromID(id)
+}
+
 // Load a CurrentModule from its ID.
 func LoadCurrentModuleFromID(id dagger.CurrentModuleID) *dagger.CurrentModule {
 	client := initClient()
@@ -290,6 +374,12 @@ func LoadLabelFromID(id dagger.LabelID) *dagger.Label {
 	return client.LoadLabelFromID(id)
 }
 
+// Load a License from its ID.
+func LoadLicenseFromID(id dagger.LicenseID) *dagger.License {
+	client := initClient()
+	return client.LoadLicenseFromID(id)
+}
+
 // Load a ListTypeDef from its ID.
 func LoadListTypeDefFromID(id dagger.ListTypeDefID) *dagger.ListTypeDef {
 	client := initClient()
@@ -368,6 +458,12 @@ func LoadServiceFromID(id dagger.ServiceID) *dagger.Service {
 	return client.LoadServiceFromID(id)
 }
 
+// Load a SessionEvent from its ID.
+func LoadSessionEventFromID(id dagger.SessionEventID) *dagger.SessionEvent {
+	client := initClient()
+	return client.LoadSessionEventFromID(id)
+}
+
 // Load a Socket from its ID.
 func LoadSocketFromID(id dagger.SocketID) *dagger.Socket {
 	client := initClient()
@@ -410,6 +506,28 @@ func ModuleSource(refString string, opts ...dagger.ModuleSourceOpts) *dagger.Mod
 	return client.ModuleSource(refString, opts...)
 }
 
+// Publish an event to a topic of the session's event bus.
+//
+// Functions of any module in the session can wait for the event with waitForEvent, to coordinate without signaling through files.
+func PublishEvent(topic string, opts ...dagger.PublishEventOpts) *dagger.SessionEvent {
+	client := initClient()
+	return client.PublishEvent(topic, opts...)
+}
+
+// Re-base an object ID onto a different version of a module.
+//
+// Every call in the ID implemented by a module with the same name as the given module is switched over to it, allowing stored IDs to be migrated across module upgrades without re-running the calls that produced them.
+func RebaseID(ctx context.Context, id string, module *dagger.Module) (string, error) {
+	client := initClient()
+	return client.RebaseID(ctx, id, module)
+}
+
+// Release a named lock acquired with acquireLock.
+func ReleaseLock(ctx context.Context, key string, lease string) error {
+	client := initClient()
+	return client.ReleaseLock(ctx, key, lease)
+}
+
 // Creates a new secret.
 func Secret(uri string) *dagger.Secret {
 	client := initClient()
@@ -441,3 +559,11 @@ func Version(ctx context.Context) (string, error) {
 	client := initClient()
 	return client.Version(ctx)
 }
+
+// Wait for an event to be published to a topic of the session's event bus.
+//
+// Returns the first event published to the topic after the given sequence number, even if it was published before the wait began.
+func WaitForEvent(topic string, opts ...dagger.WaitForEventOpts) *dagger.SessionEvent {
+	client := initClient()
+	return client.WaitForEvent(topic, opts...)
+}
@@ -102,9 +102,18 @@ func (e *ExecError) Unwrap() error {
 // The `CacheVolumeID` scalar type represents an identifier for an object of type CacheVolume.
 type CacheVolumeID string
 
+// The `ConfigID` scalar type represents an identifier for an object of type Config.
+type ConfigID string
+
+// The `ConfigKeyID` scalar type represents an identifier for an object of type ConfigKey.
+type ConfigKeyID string
+
 // The `ContainerID` scalar type represents an identifier for an object of type Container.
 type ContainerID string
 
+// The `ContainerLayerID` scalar type represents an identifier for an object of type ContainerLayer.
+type ContainerLayerID string
+
 // The `CurrentModuleID` scalar type represents an identifier for an object of type CurrentModule.
 type CurrentModuleID string
 
@@ -180,6 +189,9 @@ type JSON string
 // The `LabelID` scalar type represents an identifier for an object of type Label.
 type LabelID string
 
+// The `LicenseID` scalar type represents an identifier for an object of type License.
+type LicenseID string
+
 // The `ListTypeDefID` scalar type represents an identifier for an object of type ListTypeDef.
 type ListTypeDefID string
 
@@ -221,6 +233,9 @@ type SecretID string
 // The `ServiceID` scalar type represents an identifier for an object of type Service.
 type ServiceID string
 
+// The `SessionEventID` scalar type represents an identifier for an object of type SessionEvent.
+type SessionEventID string
+
 // The `SocketID` scalar type represents an identifier for an object of type Socket.
 type SocketID string
 
@@ -274,6 +289,14 @@ type CacheVolume struct {
 
 	id *CacheVolumeID
 }
+type WithCacheVolumeFunc func(r *CacheVolume) *CacheVolume
+
+// With calls the provided function with current CacheVolume.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *CacheVolume) With(f WithCacheVolumeFunc) *CacheVolume {
+	return f(r)
+}
 
 func (r *CacheVolume) WithGraphQLQuery(q *querybuilder.Selection) *CacheVolume {
 	return &CacheVolume{
@@ -321,6 +344,410 @@ func (r *CacheVolume) MarshalJSON() ([]byte, error) {
 	return json.Marshal(id)
 }
 
+// Returns the CacheVolume unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *CacheVolume) WithTimeout(duration string) *CacheVolume {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &CacheVolume{
+		query: q,
+	}
+}
+
+// A typed configuration, declaring its keys and loading their values from files and environment variables.
+//
+// Values are validated against the declared keys before they're used, with errors pointing at the offending keys.
+type Config struct {
+	query *querybuilder.Selection
+
+	boolean  *bool
+	id       *ConfigID
+	integer  *int
+	string   *string
+	validate *Void
+}
+type WithConfigFunc func(r *Config) *Config
+
+// With calls the provided function with current Config.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *Config) With(f WithConfigFunc) *Config {
+	return f(r)
+}
+
+func (r *Config) WithGraphQLQuery(q *querybuilder.Selection) *Config {
+	return &Config{
+		query: q,
+	}
+}
+
+// Returns the value of a key of type BOOLEAN, or null if it's not set.
+//
+// Fails if any value of the configuration is invalid.
+func (r *Config) Boolean(ctx context.Context, name string) (bool, error) {
+	if r.boolean != nil {
+		return *r.boolean, nil
+	}
+	q := r.query.Select("boolean")
+	q = q.Arg("name", name)
+
+	var response bool
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// A unique identifier for this Config.
+func (r *Config) ID(ctx context.Context) (ConfigID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.query.Select("id")
+
+	var response ConfigID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *Config) XXX_GraphQLType() string {
+	return "Config"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *Config) XXX_GraphQLIDType() string {
+	return "ConfigID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *Config) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+func (r *Config) MarshalJSON() ([]byte, error) {
+	id, err := r.ID(marshalCtx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(id)
+}
+
+// Returns the value of a key of type INTEGER, or null if it's not set.
+//
+// Fails if any value of the configuration is invalid.
+func (r *Config) Integer(ctx context.Context, name string) (int, error) {
+	if r.integer != nil {
+		return *r.integer, nil
+	}
+	q := r.query.Select("integer")
+	q = q.Arg("name", name)
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The keys declared for the configuration, in order.
+func (r *Config) Keys(ctx context.Context) ([]ConfigKey, error) {
+	q := r.query.Select("keys")
+
+	q = q.Select("id")
+
+	type keys struct {
+		Id ConfigKeyID
+	}
+
+	convert := func(fields []keys) []ConfigKey {
+		out := []ConfigKey{}
+
+		for i := range fields {
+			val := ConfigKey{id: &fields[i].Id}
+			val.query = q.Root().Select("loadConfigKeyFromID").Arg("id", fields[i].Id)
+			out = append(out, val)
+		}
+
+		return out
+	}
+	var response []keys
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
+// Returns the value of a key of type STRING, or null if it's not set.
+//
+// Fails if any value of the configuration is invalid.
+func (r *Config) String(ctx context.Context, name string) (string, error) {
+	if r.string != nil {
+		return *r.string, nil
+	}
+	q := r.query.Select("string")
+	q = q.Arg("name", name)
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// Validates the values of all the declared keys, reporting all the offending keys at once.
+//
+// Configurations passed to a function are validated before the function is invoked; call it to fail fast elsewhere, e.g. in the client.
+func (r *Config) Validate(ctx context.Context) error {
+	if r.validate != nil {
+		return nil
+	}
+	q := r.query.Select("validate")
+
+	return q.Execute(ctx)
+}
+
+// ConfigWithEnvOpts contains options for Config.WithEnv
+type ConfigWithEnvOpts struct {
+	// The prefix of the variables' names.
+	Prefix string
+}
+
+// Loads values from environment variables, taking precedence over the values loaded so far.
+//
+// Variables are named after the keys in SCREAMING_SNAKE_CASE, after the prefix, e.g. "APP_LOG_LEVEL" for the key "logLevel" with the prefix "APP_".
+func (r *Config) WithEnv(variables []string, opts ...ConfigWithEnvOpts) *Config {
+	q := r.query.Select("withEnv")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `prefix` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Prefix) {
+			q = q.Arg("prefix", opts[i].Prefix)
+		}
+	}
+	q = q.Arg("variables", variables)
+
+	return &Config{
+		query: q,
+	}
+}
+
+// Loads values from a file, taking precedence over the values loaded so far.
+//
+// Files ending in .json must be a JSON object of values by key; other files are read as KEY=VALUE lines, e.g. a .env file. Keys that aren't declared are reported by validation.
+func (r *Config) WithFile(source *File) *Config {
+	assertNotNil("source", source)
+	q := r.query.Select("withFile")
+	q = q.Arg("source", source)
+
+	return &Config{
+		query: q,
+	}
+}
+
+// ConfigWithKeyOpts contains options for Config.WithKey
+type ConfigWithKeyOpts struct {
+	// Whether the key must be set, unless it has a default.
+	Required bool
+	// The value of the key when it's not set.
+	DefaultValue string
+	// A description of the key.
+	Description string
+}
+
+// Declares a key of the configuration, replacing any previous declaration of it.
+func (r *Config) WithKey(name string, valueType ConfigValueType, opts ...ConfigWithKeyOpts) *Config {
+	q := r.query.Select("withKey")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `required` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Required) {
+			q = q.Arg("required", opts[i].Required)
+		}
+		// `defaultValue` optional argument
+		if !querybuilder.IsZeroValue(opts[i].DefaultValue) {
+			q = q.Arg("defaultValue", opts[i].DefaultValue)
+		}
+		// `description` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Description) {
+			q = q.Arg("description", opts[i].Description)
+		}
+	}
+	q = q.Arg("name", name)
+	q = q.Arg("valueType", valueType)
+
+	return &Config{
+		query: q,
+	}
+}
+
+// Returns the Config unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *Config) WithTimeout(duration string) *Config {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &Config{
+		query: q,
+	}
+}
+
+// Sets the value of a key, taking precedence over the values loaded so far.
+func (r *Config) WithValue(name string, value string) *Config {
+	q := r.query.Select("withValue")
+	q = q.Arg("name", name)
+	q = q.Arg("value", value)
+
+	return &Config{
+		query: q,
+	}
+}
+
+// A key declared for a configuration.
+type ConfigKey struct {
+	query *querybuilder.Selection
+
+	defaultValue *string
+	description  *string
+	id           *ConfigKeyID
+	name         *string
+	required     *bool
+	valueType    *ConfigValueType
+}
+type WithConfigKeyFunc func(r *ConfigKey) *ConfigKey
+
+// With calls the provided function with current ConfigKey.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *ConfigKey) With(f WithConfigKeyFunc) *ConfigKey {
+	return f(r)
+}
+
+func (r *ConfigKey) WithGraphQLQuery(q *querybuilder.Selection) *ConfigKey {
+	return &ConfigKey{
+		query: q,
+	}
+}
+
+// The value of the key when it's not set, if any.
+func (r *ConfigKey) DefaultValue(ctx context.Context) (string, error) {
+	if r.defaultValue != nil {
+		return *r.defaultValue, nil
+	}
+	q := r.query.Select("defaultValue")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// A description of the key, if any.
+func (r *ConfigKey) Description(ctx context.Context) (string, error) {
+	if r.description != nil {
+		return *r.description, nil
+	}
+	q := r.query.Select("description")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// A unique identifier for this ConfigKey.
+func (r *ConfigKey) ID(ctx context.Context) (ConfigKeyID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.query.Select("id")
+
+	var response ConfigKeyID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *ConfigKey) XXX_GraphQLType() string {
+	return "ConfigKey"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *ConfigKey) XXX_GraphQLIDType() string {
+	return "ConfigKeyID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *ConfigKey) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+func (r *ConfigKey) MarshalJSON() ([]byte, error) {
+	id, err := r.ID(marshalCtx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(id)
+}
+
+// The name of the key.
+func (r *ConfigKey) Name(ctx context.Context) (string, error) {
+	if r.name != nil {
+		return *r.name, nil
+	}
+	q := r.query.Select("name")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// Whether the key must be set.
+func (r *ConfigKey) Required(ctx context.Context) (bool, error) {
+	if r.required != nil {
+		return *r.required, nil
+	}
+	q := r.query.Select("required")
+
+	var response bool
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The type of the key's value.
+func (r *ConfigKey) ValueType(ctx context.Context) (ConfigValueType, error) {
+	if r.valueType != nil {
+		return *r.valueType, nil
+	}
+	q := r.query.Select("valueType")
+
+	var response ConfigValueType
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// Returns the ConfigKey unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *ConfigKey) WithTimeout(duration string) *ConfigKey {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &ConfigKey{
+		query: q,
+	}
+}
+
 // An OCI-compatible container, also known as a Docker container.
 type Container struct {
 	query *querybuilder.Selection
@@ -875,6 +1302,41 @@ func (r *Container) Labels(ctx context.Context) ([]Label, error) {
 	return convert(response), nil
 }
 
+// Retrieves the layers of the container's root filesystem, bottom first.
+//
+// Each layer reports its size and the command that created it, along with how much of it is wasted on files that later layers replace or remove.
+func (r *Container) Layers(ctx context.Context) ([]ContainerLayer, error) {
+	q := r.query.Select("layers")
+
+	q = q.Select("id")
+
+	type layers struct {
+		Id ContainerLayerID
+	}
+
+	convert := func(fields []layers) []ContainerLayer {
+		out := []ContainerLayer{}
+
+		for i := range fields {
+			val := ContainerLayer{id: &fields[i].Id}
+			val.query = q.Root().Select("loadContainerLayerFromID").Arg("id", fields[i].Id)
+			out = append(out, val)
+		}
+
+		return out
+	}
+	var response []layers
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
 // Retrieves the list of paths where a directory is mounted.
 func (r *Container) Mounts(ctx context.Context) ([]string, error) {
 	q := r.query.Select("mounts")
@@ -1241,6 +1703,31 @@ func (r *Container) WithEntrypoint(args []string, opts ...ContainerWithEntrypoin
 	}
 }
 
+// ContainerWithEnvFileOpts contains options for Container.WithEnvFile
+type ContainerWithEnvFileOpts struct {
+	// Glob patterns of the names of the variables to set as secrets (e.g., "*_TOKEN").
+	SecretPatterns []string
+}
+
+// Retrieves this container plus the variables of an env file.
+//
+// Variables whose names match any of the secret patterns are set as secret variables, hidden from telemetry, and the rest as plain environment variables. Only the names of the variables are recorded.
+func (r *Container) WithEnvFile(source *File, opts ...ContainerWithEnvFileOpts) *Container {
+	assertNotNil("source", source)
+	q := r.query.Select("withEnvFile")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `secretPatterns` optional argument
+		if !querybuilder.IsZeroValue(opts[i].SecretPatterns) {
+			q = q.Arg("secretPatterns", opts[i].SecretPatterns)
+		}
+	}
+	q = q.Arg("source", source)
+
+	return &Container{
+		query: q,
+	}
+}
+
 // ContainerWithEnvVariableOpts contains options for Container.WithEnvVariable
 type ContainerWithEnvVariableOpts struct {
 	// Replace "${VAR}" or "$VAR" in the value according to the current environment variables defined in the container (e.g. "/opt/bin:$PATH").
@@ -1288,6 +1775,10 @@ type ContainerWithExecOpts struct {
 	//
 	// This should only be used if the user requires that their exec process be the pid 1 process in the container. Otherwise it may result in unexpected behavior.
 	NoInit bool
+	// Execute the command without network access, in UTC with the C locale, and with its clock fixed to the Unix epoch (or the engine's deterministic epoch) where possible.
+	//
+	// Fixing the clock is best-effort: it preloads libfaketime, so it only applies if libfaketime is installed in the container (e.g., "apk add libfaketime"), and not to statically linked programs. Otherwise the command runs with the real clock, and its span records that the clock wasn't fixed.
+	Hermetic bool
 }
 
 // Retrieves this container after executing the specified command inside it.
@@ -1330,6 +1821,10 @@ func (r *Container) WithExec(args []string, opts ...ContainerWithExecOpts) *Cont
 		if !querybuilder.IsZeroValue(opts[i].NoInit) {
 			q = q.Arg("noInit", opts[i].NoInit)
 		}
+		// `hermetic` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Hermetic) {
+			q = q.Arg("hermetic", opts[i].Hermetic)
+		}
 	}
 	q = q.Arg("args", args)
 
@@ -1706,6 +2201,36 @@ func (r *Container) WithRegistryAuth(address string, username string, secret *Se
 	}
 }
 
+// ContainerWithRetryOpts contains options for Container.WithRetry
+type ContainerWithRetryOpts struct {
+	// Delay before the first retry, doubled for each subsequent one (e.g., "500ms", "2s").
+	Backoff string
+	// Only retry errors of these categories. All errors are retried if empty.
+	RetryOn []ErrorCategory
+}
+
+// Evaluates the pipeline in the engine, retrying failures according to the given policy.
+//
+// Use it after the exec to retry, e.g. a flaky test, instead of retrying in a loop. Each attempt is reported in its own span.
+func (r *Container) WithRetry(attempts int, opts ...ContainerWithRetryOpts) *Container {
+	q := r.query.Select("withRetry")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `backoff` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Backoff) {
+			q = q.Arg("backoff", opts[i].Backoff)
+		}
+		// `retryOn` optional argument
+		if !querybuilder.IsZeroValue(opts[i].RetryOn) {
+			q = q.Arg("retryOn", opts[i].RetryOn)
+		}
+	}
+	q = q.Arg("attempts", attempts)
+
+	return &Container{
+		query: q,
+	}
+}
+
 // Retrieves the container with the given directory mounted to /.
 func (r *Container) WithRootfs(directory *Directory) *Container {
 	assertNotNil("directory", directory)
@@ -1747,6 +2272,16 @@ func (r *Container) WithServiceBinding(alias string, service *Service) *Containe
 	}
 }
 
+// Returns the Container unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *Container) WithTimeout(duration string) *Container {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &Container{
+		query: q,
+	}
+}
+
 // ContainerWithUnixSocketOpts contains options for Container.WithUnixSocket
 type ContainerWithUnixSocketOpts struct {
 	// A user:group to set for the mounted socket.
@@ -2060,6 +2595,133 @@ func (r *Container) Workdir(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx)
 }
 
+// A layer of a container's root filesystem, as it would be published.
+type ContainerLayer struct {
+	query *querybuilder.Selection
+
+	command    *string
+	digest     *string
+	id         *ContainerLayerID
+	size       *int
+	wastedSize *int
+}
+type WithContainerLayerFunc func(r *ContainerLayer) *ContainerLayer
+
+// With calls the provided function with current ContainerLayer.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *ContainerLayer) With(f WithContainerLayerFunc) *ContainerLayer {
+	return f(r)
+}
+
+func (r *ContainerLayer) WithGraphQLQuery(q *querybuilder.Selection) *ContainerLayer {
+	return &ContainerLayer{
+		query: q,
+	}
+}
+
+// A description of the operation that created the layer, e.g. the command it ran.
+func (r *ContainerLayer) Command(ctx context.Context) (string, error) {
+	if r.command != nil {
+		return *r.command, nil
+	}
+	q := r.query.Select("command")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The digest of the compressed layer.
+func (r *ContainerLayer) Digest(ctx context.Context) (string, error) {
+	if r.digest != nil {
+		return *r.digest, nil
+	}
+	q := r.query.Select("digest")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// A unique identifier for this ContainerLayer.
+func (r *ContainerLayer) ID(ctx context.Context) (ContainerLayerID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.query.Select("id")
+
+	var response ContainerLayerID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *ContainerLayer) XXX_GraphQLType() string {
+	return "ContainerLayer"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *ContainerLayer) XXX_GraphQLIDType() string {
+	return "ContainerLayerID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *ContainerLayer) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+func (r *ContainerLayer) MarshalJSON() ([]byte, error) {
+	id, err := r.ID(marshalCtx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(id)
+}
+
+// The size of the compressed layer, in bytes.
+func (r *ContainerLayer) Size(ctx context.Context) (int, error) {
+	if r.size != nil {
+		return *r.size, nil
+	}
+	q := r.query.Select("size")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The total size of files in the layer that are replaced or removed by a later layer, in bytes. These files are shipped with the image, but never seen in the container.
+func (r *ContainerLayer) WastedSize(ctx context.Context) (int, error) {
+	if r.wastedSize != nil {
+		return *r.wastedSize, nil
+	}
+	q := r.query.Select("wastedSize")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// Returns the ContainerLayer unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *ContainerLayer) WithTimeout(duration string) *ContainerLayer {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &ContainerLayer{
+		query: q,
+	}
+}
+
 // Reflective module API provided to functions at runtime.
 type CurrentModule struct {
 	query *querybuilder.Selection
@@ -2067,6 +2729,14 @@ type CurrentModule struct {
 	id   *CurrentModuleID
 	name *string
 }
+type WithCurrentModuleFunc func(r *CurrentModule) *CurrentModule
+
+// With calls the provided function with current CurrentModule.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *CurrentModule) With(f WithCurrentModuleFunc) *CurrentModule {
+	return f(r)
+}
 
 func (r *CurrentModule) WithGraphQLQuery(q *querybuilder.Selection) *CurrentModule {
 	return &CurrentModule{
@@ -2136,6 +2806,16 @@ func (r *CurrentModule) Source() *Directory {
 	}
 }
 
+// Returns the CurrentModule unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *CurrentModule) WithTimeout(duration string) *CurrentModule {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &CurrentModule{
+		query: q,
+	}
+}
+
 // CurrentModuleWorkdirOpts contains options for CurrentModule.Workdir
 type CurrentModuleWorkdirOpts struct {
 	// Exclude artifacts that match the given pattern (e.g., ["node_modules/", ".git*"]).
@@ -2419,6 +3099,39 @@ func (r *Directory) MarshalJSON() ([]byte, error) {
 	return json.Marshal(id)
 }
 
+// Detects the licenses of the directory and everything in it, including vendored dependencies, from license files like LICENSE or COPYING.
+func (r *Directory) Licenses(ctx context.Context) ([]License, error) {
+	q := r.query.Select("licenses")
+
+	q = q.Select("id")
+
+	type licenses struct {
+		Id LicenseID
+	}
+
+	convert := func(fields []licenses) []License {
+		out := []License{}
+
+		for i := range fields {
+			val := License{id: &fields[i].Id}
+			val.query = q.Root().Select("loadLicenseFromID").Arg("id", fields[i].Id)
+			out = append(out, val)
+		}
+
+		return out
+	}
+	var response []licenses
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
 // Force evaluation in the engine.
 func (r *Directory) Sync(ctx context.Context) (*Directory, error) {
 	q := r.query.Select("sync")
@@ -2595,6 +3308,16 @@ func (r *Directory) WithNewFile(path string, contents string, opts ...DirectoryW
 	}
 }
 
+// Returns the Directory unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *Directory) WithTimeout(duration string) *Directory {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &Directory{
+		query: q,
+	}
+}
+
 // Retrieves this directory with all file/dir timestamps set to the given time.
 func (r *Directory) WithTimestamps(timestamp int) *Directory {
 	q := r.query.Select("withTimestamps")
@@ -2641,6 +3364,14 @@ type Engine struct {
 
 	id *EngineID
 }
+type WithEngineFunc func(r *Engine) *Engine
+
+// With calls the provided function with current Engine.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *Engine) With(f WithEngineFunc) *Engine {
+	return f(r)
+}
 
 func (r *Engine) WithGraphQLQuery(q *querybuilder.Selection) *Engine {
 	return &Engine{
@@ -2697,6 +3428,16 @@ func (r *Engine) LocalCache() *EngineCache {
 	}
 }
 
+// Returns the Engine unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *Engine) WithTimeout(duration string) *Engine {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &Engine{
+		query: q,
+	}
+}
+
 // A cache storage for the Dagger engine
 type EngineCache struct {
 	query *querybuilder.Selection
@@ -2708,6 +3449,14 @@ type EngineCache struct {
 	prune         *Void
 	reservedSpace *int
 }
+type WithEngineCacheFunc func(r *EngineCache) *EngineCache
+
+// With calls the provided function with current EngineCache.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *EngineCache) With(f WithEngineCacheFunc) *EngineCache {
+	return f(r)
+}
 
 func (r *EngineCache) WithGraphQLQuery(q *querybuilder.Selection) *EngineCache {
 	return &EngineCache{
@@ -2838,6 +3587,16 @@ func (r *EngineCache) ReservedSpace(ctx context.Context) (int, error) {
 	return response, q.Execute(ctx)
 }
 
+// Returns the EngineCache unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *EngineCache) WithTimeout(duration string) *EngineCache {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &EngineCache{
+		query: q,
+	}
+}
+
 // An individual cache entry in a cache entry set
 type EngineCacheEntry struct {
 	query *querybuilder.Selection
@@ -2849,6 +3608,14 @@ type EngineCacheEntry struct {
 	id                        *EngineCacheEntryID
 	mostRecentUseTimeUnixNano *int
 }
+type WithEngineCacheEntryFunc func(r *EngineCacheEntry) *EngineCacheEntry
+
+// With calls the provided function with current EngineCacheEntry.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *EngineCacheEntry) With(f WithEngineCacheEntryFunc) *EngineCacheEntry {
+	return f(r)
+}
 
 func (r *EngineCacheEntry) WithGraphQLQuery(q *querybuilder.Selection) *EngineCacheEntry {
 	return &EngineCacheEntry{
@@ -2961,6 +3728,16 @@ func (r *EngineCacheEntry) MostRecentUseTimeUnixNano(ctx context.Context) (int,
 	return response, q.Execute(ctx)
 }
 
+// Returns the EngineCacheEntry unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *EngineCacheEntry) WithTimeout(duration string) *EngineCacheEntry {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &EngineCacheEntry{
+		query: q,
+	}
+}
+
 // A set of cache entries returned by a query to a cache
 type EngineCacheEntrySet struct {
 	query *querybuilder.Selection
@@ -2969,6 +3746,14 @@ type EngineCacheEntrySet struct {
 	entryCount     *int
 	id             *EngineCacheEntrySetID
 }
+type WithEngineCacheEntrySetFunc func(r *EngineCacheEntrySet) *EngineCacheEntrySet
+
+// With calls the provided function with current EngineCacheEntrySet.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *EngineCacheEntrySet) With(f WithEngineCacheEntrySetFunc) *EngineCacheEntrySet {
+	return f(r)
+}
 
 func (r *EngineCacheEntrySet) WithGraphQLQuery(q *querybuilder.Selection) *EngineCacheEntrySet {
 	return &EngineCacheEntrySet{
@@ -3075,6 +3860,16 @@ func (r *EngineCacheEntrySet) MarshalJSON() ([]byte, error) {
 	return json.Marshal(id)
 }
 
+// Returns the EngineCacheEntrySet unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *EngineCacheEntrySet) WithTimeout(duration string) *EngineCacheEntrySet {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &EngineCacheEntrySet{
+		query: q,
+	}
+}
+
 // A definition of a custom enum defined in a Module.
 type EnumTypeDef struct {
 	query *querybuilder.Selection
@@ -3084,6 +3879,14 @@ type EnumTypeDef struct {
 	name             *string
 	sourceModuleName *string
 }
+type WithEnumTypeDefFunc func(r *EnumTypeDef) *EnumTypeDef
+
+// With calls the provided function with current EnumTypeDef.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *EnumTypeDef) With(f WithEnumTypeDefFunc) *EnumTypeDef {
+	return f(r)
+}
 
 func (r *EnumTypeDef) WithGraphQLQuery(q *querybuilder.Selection) *EnumTypeDef {
 	return &EnumTypeDef{
@@ -3212,6 +4015,16 @@ func (r *EnumTypeDef) Values(ctx context.Context) ([]EnumValueTypeDef, error) {
 	return convert(response), nil
 }
 
+// Returns the EnumTypeDef unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *EnumTypeDef) WithTimeout(duration string) *EnumTypeDef {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &EnumTypeDef{
+		query: q,
+	}
+}
+
 // A definition of a value in a custom enum defined in a Module.
 type EnumValueTypeDef struct {
 	query *querybuilder.Selection
@@ -3220,6 +4033,14 @@ type EnumValueTypeDef struct {
 	id          *EnumValueTypeDefID
 	name        *string
 }
+type WithEnumValueTypeDefFunc func(r *EnumValueTypeDef) *EnumValueTypeDef
+
+// With calls the provided function with current EnumValueTypeDef.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *EnumValueTypeDef) With(f WithEnumValueTypeDefFunc) *EnumValueTypeDef {
+	return f(r)
+}
 
 func (r *EnumValueTypeDef) WithGraphQLQuery(q *querybuilder.Selection) *EnumValueTypeDef {
 	return &EnumValueTypeDef{
@@ -3302,6 +4123,16 @@ func (r *EnumValueTypeDef) SourceMap() *SourceMap {
 	}
 }
 
+// Returns the EnumValueTypeDef unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *EnumValueTypeDef) WithTimeout(duration string) *EnumValueTypeDef {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &EnumValueTypeDef{
+		query: q,
+	}
+}
+
 // An environment variable name and value.
 type EnvVariable struct {
 	query *querybuilder.Selection
@@ -3310,6 +4141,14 @@ type EnvVariable struct {
 	name  *string
 	value *string
 }
+type WithEnvVariableFunc func(r *EnvVariable) *EnvVariable
+
+// With calls the provided function with current EnvVariable.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *EnvVariable) With(f WithEnvVariableFunc) *EnvVariable {
+	return f(r)
+}
 
 func (r *EnvVariable) WithGraphQLQuery(q *querybuilder.Selection) *EnvVariable {
 	return &EnvVariable{
@@ -3383,12 +4222,30 @@ func (r *EnvVariable) Value(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx)
 }
 
+// Returns the EnvVariable unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *EnvVariable) WithTimeout(duration string) *EnvVariable {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &EnvVariable{
+		query: q,
+	}
+}
+
 type Error struct {
 	query *querybuilder.Selection
 
 	id      *ErrorID
 	message *string
 }
+type WithErrorFunc func(r *Error) *Error
+
+// With calls the provided function with current Error.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *Error) With(f WithErrorFunc) *Error {
+	return f(r)
+}
 
 func (r *Error) WithGraphQLQuery(q *querybuilder.Selection) *Error {
 	return &Error{
@@ -3449,6 +4306,16 @@ func (r *Error) Message(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx)
 }
 
+// Returns the Error unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *Error) WithTimeout(duration string) *Error {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &Error{
+		query: q,
+	}
+}
+
 // A definition of a field on a custom object defined in a Module.
 //
 // A field on an object has a static value, as opposed to a function on an object whose value is computed by invoking code (and can accept arguments).
@@ -3459,6 +4326,14 @@ type FieldTypeDef struct {
 	id          *FieldTypeDefID
 	name        *string
 }
+type WithFieldTypeDefFunc func(r *FieldTypeDef) *FieldTypeDef
+
+// With calls the provided function with current FieldTypeDef.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *FieldTypeDef) With(f WithFieldTypeDefFunc) *FieldTypeDef {
+	return f(r)
+}
 
 func (r *FieldTypeDef) WithGraphQLQuery(q *querybuilder.Selection) *FieldTypeDef {
 	return &FieldTypeDef{
@@ -3550,6 +4425,16 @@ func (r *FieldTypeDef) TypeDef() *TypeDef {
 	}
 }
 
+// Returns the FieldTypeDef unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *FieldTypeDef) WithTimeout(duration string) *FieldTypeDef {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &FieldTypeDef{
+		query: q,
+	}
+}
+
 // A file.
 type File struct {
 	query *querybuilder.Selection
@@ -3730,6 +4615,46 @@ func (r *File) WithName(name string) *File {
 	}
 }
 
+// FileWithRetryOpts contains options for File.WithRetry
+type FileWithRetryOpts struct {
+	// Delay before the first retry, doubled for each subsequent one (e.g., "500ms", "2s").
+	Backoff string
+	// Only retry errors of these categories. All errors are retried if empty.
+	RetryOn []ErrorCategory
+}
+
+// Force evaluation in the engine, retrying failures according to the given policy.
+//
+// Use it to retry, e.g. a flaky HTTP fetch. Each attempt is reported in its own span.
+func (r *File) WithRetry(attempts int, opts ...FileWithRetryOpts) *File {
+	q := r.query.Select("withRetry")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `backoff` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Backoff) {
+			q = q.Arg("backoff", opts[i].Backoff)
+		}
+		// `retryOn` optional argument
+		if !querybuilder.IsZeroValue(opts[i].RetryOn) {
+			q = q.Arg("retryOn", opts[i].RetryOn)
+		}
+	}
+	q = q.Arg("attempts", attempts)
+
+	return &File{
+		query: q,
+	}
+}
+
+// Returns the File unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *File) WithTimeout(duration string) *File {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &File{
+		query: q,
+	}
+}
+
 // Retrieves this file with its created/modified timestamps set to the given time.
 func (r *File) WithTimestamps(timestamp int) *File {
 	q := r.query.Select("withTimestamps")
@@ -3746,9 +4671,12 @@ func (r *File) WithTimestamps(timestamp int) *File {
 type Function struct {
 	query *querybuilder.Selection
 
-	description *string
-	id          *FunctionID
-	name        *string
+	daemon       *bool
+	description  *string
+	id           *FunctionID
+	name         *string
+	rateLimitKey *string
+	rateLimitRps *float64
 }
 type WithFunctionFunc func(r *Function) *Function
 
@@ -3795,7 +4723,20 @@ func (r *Function) Args(ctx context.Context) ([]FunctionArg, error) {
 		return nil, err
 	}
 
-	return convert(response), nil
+	return convert(response), nil
+}
+
+// Whether the function is a daemon, whose returned service keeps running after the call returns.
+func (r *Function) Daemon(ctx context.Context) (bool, error) {
+	if r.daemon != nil {
+		return *r.daemon, nil
+	}
+	q := r.query.Select("daemon")
+
+	var response bool
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
 }
 
 // A doc string for the function, if any.
@@ -3864,6 +4805,32 @@ func (r *Function) Name(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx)
 }
 
+// The key of the rate limit that calls to the function wait for, if any.
+func (r *Function) RateLimitKey(ctx context.Context) (string, error) {
+	if r.rateLimitKey != nil {
+		return *r.rateLimitKey, nil
+	}
+	q := r.query.Select("rateLimitKey")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The number of calls per second allowed by the function's rate limit, if any.
+func (r *Function) RateLimitRps(ctx context.Context) (float64, error) {
+	if r.rateLimitRps != nil {
+		return *r.rateLimitRps, nil
+	}
+	q := r.query.Select("rateLimitRps")
+
+	var response float64
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
 // The type returned by the function.
 func (r *Function) ReturnType() *TypeDef {
 	q := r.query.Select("returnType")
@@ -3930,6 +4897,17 @@ func (r *Function) WithArg(name string, typeDef *TypeDef, opts ...FunctionWithAr
 	}
 }
 
+// Returns the function marked as a daemon.
+//
+// A daemon function returns a Service, which is started when the function is called and keeps running after the call returns, until it's stopped or the session ends.
+func (r *Function) WithDaemon() *Function {
+	q := r.query.Select("withDaemon")
+
+	return &Function{
+		query: q,
+	}
+}
+
 // Returns the function with the given doc string.
 func (r *Function) WithDescription(description string) *Function {
 	q := r.query.Select("withDescription")
@@ -3940,6 +4918,19 @@ func (r *Function) WithDescription(description string) *Function {
 	}
 }
 
+// Returns the function with calls to it rate limited.
+//
+// Calls to functions of the same module sharing a rate limit key wait for each other, across every session of the engine, to hit external services like registries or third-party APIs no faster than they allow. If functions declare different rates for the same key, the lowest one applies. Time spent waiting is recorded as a metric of the call.
+func (r *Function) WithRateLimit(key string, rps float64) *Function {
+	q := r.query.Select("withRateLimit")
+	q = q.Arg("key", key)
+	q = q.Arg("rps", rps)
+
+	return &Function{
+		query: q,
+	}
+}
+
 // Returns the function with the given source map.
 func (r *Function) WithSourceMap(sourceMap *SourceMap) *Function {
 	assertNotNil("sourceMap", sourceMap)
@@ -3951,6 +4942,16 @@ func (r *Function) WithSourceMap(sourceMap *SourceMap) *Function {
 	}
 }
 
+// Returns the Function unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *Function) WithTimeout(duration string) *Function {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &Function{
+		query: q,
+	}
+}
+
 // An argument accepted by a function.
 //
 // This is a specification for an argument at function definition time, not an argument passed at function call time.
@@ -3963,6 +4964,14 @@ type FunctionArg struct {
 	id           *FunctionArgID
 	name         *string
 }
+type WithFunctionArgFunc func(r *FunctionArg) *FunctionArg
+
+// With calls the provided function with current FunctionArg.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *FunctionArg) With(f WithFunctionArgFunc) *FunctionArg {
+	return f(r)
+}
 
 func (r *FunctionArg) WithGraphQLQuery(q *querybuilder.Selection) *FunctionArg {
 	return &FunctionArg{
@@ -4090,6 +5099,16 @@ func (r *FunctionArg) TypeDef() *TypeDef {
 	}
 }
 
+// Returns the FunctionArg unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *FunctionArg) WithTimeout(duration string) *FunctionArg {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &FunctionArg{
+		query: q,
+	}
+}
+
 // An active function call.
 type FunctionCall struct {
 	query *querybuilder.Selection
@@ -4101,6 +5120,14 @@ type FunctionCall struct {
 	returnError *Void
 	returnValue *Void
 }
+type WithFunctionCallFunc func(r *FunctionCall) *FunctionCall
+
+// With calls the provided function with current FunctionCall.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *FunctionCall) With(f WithFunctionCallFunc) *FunctionCall {
+	return f(r)
+}
 
 func (r *FunctionCall) WithGraphQLQuery(q *querybuilder.Selection) *FunctionCall {
 	return &FunctionCall{
@@ -4243,6 +5270,16 @@ func (r *FunctionCall) ReturnValue(ctx context.Context, value JSON) error {
 	return q.Execute(ctx)
 }
 
+// Returns the FunctionCall unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *FunctionCall) WithTimeout(duration string) *FunctionCall {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &FunctionCall{
+		query: q,
+	}
+}
+
 // A value passed as a named argument to a function call.
 type FunctionCallArgValue struct {
 	query *querybuilder.Selection
@@ -4251,6 +5288,14 @@ type FunctionCallArgValue struct {
 	name  *string
 	value *JSON
 }
+type WithFunctionCallArgValueFunc func(r *FunctionCallArgValue) *FunctionCallArgValue
+
+// With calls the provided function with current FunctionCallArgValue.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *FunctionCallArgValue) With(f WithFunctionCallArgValueFunc) *FunctionCallArgValue {
+	return f(r)
+}
 
 func (r *FunctionCallArgValue) WithGraphQLQuery(q *querybuilder.Selection) *FunctionCallArgValue {
 	return &FunctionCallArgValue{
@@ -4324,6 +5369,16 @@ func (r *FunctionCallArgValue) Value(ctx context.Context) (JSON, error) {
 	return response, q.Execute(ctx)
 }
 
+// Returns the FunctionCallArgValue unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *FunctionCallArgValue) WithTimeout(duration string) *FunctionCallArgValue {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &FunctionCallArgValue{
+		query: q,
+	}
+}
+
 // The result of running an SDK's codegen.
 type GeneratedCode struct {
 	query *querybuilder.Selection
@@ -4414,6 +5469,16 @@ func (r *GeneratedCode) VcsIgnoredPaths(ctx context.Context) ([]string, error) {
 	return response, q.Execute(ctx)
 }
 
+// Returns the GeneratedCode unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *GeneratedCode) WithTimeout(duration string) *GeneratedCode {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &GeneratedCode{
+		query: q,
+	}
+}
+
 // Set the list of paths to mark generated in version control.
 func (r *GeneratedCode) WithVCSGeneratedPaths(paths []string) *GeneratedCode {
 	q := r.query.Select("withVCSGeneratedPaths")
@@ -4447,6 +5512,14 @@ type GitModuleSource struct {
 	rootSubpath *string
 	version     *string
 }
+type WithGitModuleSourceFunc func(r *GitModuleSource) *GitModuleSource
+
+// With calls the provided function with current GitModuleSource.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *GitModuleSource) With(f WithGitModuleSourceFunc) *GitModuleSource {
+	return f(r)
+}
 
 func (r *GitModuleSource) WithGraphQLQuery(q *querybuilder.Selection) *GitModuleSource {
 	return &GitModuleSource{
@@ -4594,6 +5667,16 @@ func (r *GitModuleSource) Version(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx)
 }
 
+// Returns the GitModuleSource unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *GitModuleSource) WithTimeout(duration string) *GitModuleSource {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &GitModuleSource{
+		query: q,
+	}
+}
+
 // A git ref (tag, branch, or commit).
 type GitRef struct {
 	query *querybuilder.Selection
@@ -4601,6 +5684,14 @@ type GitRef struct {
 	commit *string
 	id     *GitRefID
 }
+type WithGitRefFunc func(r *GitRef) *GitRef
+
+// With calls the provided function with current GitRef.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *GitRef) With(f WithGitRefFunc) *GitRef {
+	return f(r)
+}
 
 func (r *GitRef) WithGraphQLQuery(q *querybuilder.Selection) *GitRef {
 	return &GitRef{
@@ -4682,6 +5773,16 @@ func (r *GitRef) Tree(opts ...GitRefTreeOpts) *Directory {
 	}
 }
 
+// Returns the GitRef unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *GitRef) WithTimeout(duration string) *GitRef {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &GitRef{
+		query: q,
+	}
+}
+
 // A git repository.
 type GitRepository struct {
 	query *querybuilder.Selection
@@ -4836,12 +5937,30 @@ func (r *GitRepository) WithAuthToken(token *Secret) *GitRepository {
 	}
 }
 
+// Returns the GitRepository unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *GitRepository) WithTimeout(duration string) *GitRepository {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &GitRepository{
+		query: q,
+	}
+}
+
 // Information about the host environment.
 type Host struct {
 	query *querybuilder.Selection
 
 	id *HostID
 }
+type WithHostFunc func(r *Host) *Host
+
+// With calls the provided function with current Host.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *Host) With(f WithHostFunc) *Host {
+	return f(r)
+}
 
 func (r *Host) WithGraphQLQuery(q *querybuilder.Selection) *Host {
 	return &Host{
@@ -4927,6 +6046,36 @@ func (r *Host) MarshalJSON() ([]byte, error) {
 	return json.Marshal(id)
 }
 
+// HostReverseTunnelOpts contains options for Host.ReverseTunnel
+type HostReverseTunnelOpts struct {
+	// Host to forward traffic to, as resolved by the client.
+	Host string
+	// Transport layer protocol to use for traffic.
+	Protocol NetworkProtocol
+}
+
+// Creates a service that lets containers call back to a port on the host, e.g. a local development server, through a tunnel managed by the engine.
+//
+// Unless called by the main client, the port must be granted by the client, e.g. with "dagger --allow-host-port". The connections made through the tunnel are counted in the metrics of its span.
+func (r *Host) ReverseTunnel(port int, opts ...HostReverseTunnelOpts) *Service {
+	q := r.query.Select("reverseTunnel")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `host` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Host) {
+			q = q.Arg("host", opts[i].Host)
+		}
+		// `protocol` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Protocol) {
+			q = q.Arg("protocol", opts[i].Protocol)
+		}
+	}
+	q = q.Arg("port", port)
+
+	return &Service{
+		query: q,
+	}
+}
+
 // HostServiceOpts contains options for Host.Service
 type HostServiceOpts struct {
 	// Upstream host to forward traffic to.
@@ -5009,6 +6158,16 @@ func (r *Host) UnixSocket(path string) *Socket {
 	}
 }
 
+// Returns the Host unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *Host) WithTimeout(duration string) *Host {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &Host{
+		query: q,
+	}
+}
+
 // A graphql input type, which is essentially just a group of named args.
 // This is currently only used to represent pre-existing usage of graphql input types
 // in the core API. It is not used by user modules and shouldn't ever be as user
@@ -5019,6 +6178,14 @@ type InputTypeDef struct {
 	id   *InputTypeDefID
 	name *string
 }
+type WithInputTypeDefFunc func(r *InputTypeDef) *InputTypeDef
+
+// With calls the provided function with current InputTypeDef.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *InputTypeDef) With(f WithInputTypeDefFunc) *InputTypeDef {
+	return f(r)
+}
 
 func (r *InputTypeDef) WithGraphQLQuery(q *querybuilder.Selection) *InputTypeDef {
 	return &InputTypeDef{
@@ -5112,6 +6279,16 @@ func (r *InputTypeDef) Name(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx)
 }
 
+// Returns the InputTypeDef unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *InputTypeDef) WithTimeout(duration string) *InputTypeDef {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &InputTypeDef{
+		query: q,
+	}
+}
+
 // A definition of a custom interface defined in a Module.
 type InterfaceTypeDef struct {
 	query *querybuilder.Selection
@@ -5121,6 +6298,14 @@ type InterfaceTypeDef struct {
 	name             *string
 	sourceModuleName *string
 }
+type WithInterfaceTypeDefFunc func(r *InterfaceTypeDef) *InterfaceTypeDef
+
+// With calls the provided function with current InterfaceTypeDef.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *InterfaceTypeDef) With(f WithInterfaceTypeDefFunc) *InterfaceTypeDef {
+	return f(r)
+}
 
 func (r *InterfaceTypeDef) WithGraphQLQuery(q *querybuilder.Selection) *InterfaceTypeDef {
 	return &InterfaceTypeDef{
@@ -5193,12 +6378,120 @@ func (r *InterfaceTypeDef) XXX_GraphQLType() string {
 }
 
 // XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
-func (r *InterfaceTypeDef) XXX_GraphQLIDType() string {
-	return "InterfaceTypeDefID"
+func (r *InterfaceTypeDef) XXX_GraphQLIDType() string {
+	return "InterfaceTypeDefID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *InterfaceTypeDef) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+func (r *InterfaceTypeDef) MarshalJSON() ([]byte, error) {
+	id, err := r.ID(marshalCtx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(id)
+}
+
+// The name of the interface.
+func (r *InterfaceTypeDef) Name(ctx context.Context) (string, error) {
+	if r.name != nil {
+		return *r.name, nil
+	}
+	q := r.query.Select("name")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The location of this interface declaration.
+func (r *InterfaceTypeDef) SourceMap() *SourceMap {
+	q := r.query.Select("sourceMap")
+
+	return &SourceMap{
+		query: q,
+	}
+}
+
+// If this InterfaceTypeDef is associated with a Module, the name of the module. Unset otherwise.
+func (r *InterfaceTypeDef) SourceModuleName(ctx context.Context) (string, error) {
+	if r.sourceModuleName != nil {
+		return *r.sourceModuleName, nil
+	}
+	q := r.query.Select("sourceModuleName")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// Returns the InterfaceTypeDef unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *InterfaceTypeDef) WithTimeout(duration string) *InterfaceTypeDef {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &InterfaceTypeDef{
+		query: q,
+	}
+}
+
+// A simple key value object that represents a label.
+type Label struct {
+	query *querybuilder.Selection
+
+	id    *LabelID
+	name  *string
+	value *string
+}
+type WithLabelFunc func(r *Label) *Label
+
+// With calls the provided function with current Label.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *Label) With(f WithLabelFunc) *Label {
+	return f(r)
+}
+
+func (r *Label) WithGraphQLQuery(q *querybuilder.Selection) *Label {
+	return &Label{
+		query: q,
+	}
+}
+
+// A unique identifier for this Label.
+func (r *Label) ID(ctx context.Context) (LabelID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.query.Select("id")
+
+	var response LabelID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *Label) XXX_GraphQLType() string {
+	return "Label"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *Label) XXX_GraphQLIDType() string {
+	return "LabelID"
 }
 
 // XXX_GraphQLID is an internal function. It returns the underlying type ID
-func (r *InterfaceTypeDef) XXX_GraphQLID(ctx context.Context) (string, error) {
+func (r *Label) XXX_GraphQLID(ctx context.Context) (string, error) {
 	id, err := r.ID(ctx)
 	if err != nil {
 		return "", err
@@ -5206,7 +6499,7 @@ func (r *InterfaceTypeDef) XXX_GraphQLID(ctx context.Context) (string, error) {
 	return string(id), nil
 }
 
-func (r *InterfaceTypeDef) MarshalJSON() ([]byte, error) {
+func (r *Label) MarshalJSON() ([]byte, error) {
 	id, err := r.ID(marshalCtx)
 	if err != nil {
 		return nil, err
@@ -5214,8 +6507,8 @@ func (r *InterfaceTypeDef) MarshalJSON() ([]byte, error) {
 	return json.Marshal(id)
 }
 
-// The name of the interface.
-func (r *InterfaceTypeDef) Name(ctx context.Context) (string, error) {
+// The label name.
+func (r *Label) Name(ctx context.Context) (string, error) {
 	if r.name != nil {
 		return *r.name, nil
 	}
@@ -5227,21 +6520,12 @@ func (r *InterfaceTypeDef) Name(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx)
 }
 
-// The location of this interface declaration.
-func (r *InterfaceTypeDef) SourceMap() *SourceMap {
-	q := r.query.Select("sourceMap")
-
-	return &SourceMap{
-		query: q,
-	}
-}
-
-// If this InterfaceTypeDef is associated with a Module, the name of the module. Unset otherwise.
-func (r *InterfaceTypeDef) SourceModuleName(ctx context.Context) (string, error) {
-	if r.sourceModuleName != nil {
-		return *r.sourceModuleName, nil
+// The label value.
+func (r *Label) Value(ctx context.Context) (string, error) {
+	if r.value != nil {
+		return *r.value, nil
 	}
-	q := r.query.Select("sourceModuleName")
+	q := r.query.Select("value")
 
 	var response string
 
@@ -5249,46 +6533,65 @@ func (r *InterfaceTypeDef) SourceModuleName(ctx context.Context) (string, error)
 	return response, q.Execute(ctx)
 }
 
-// A simple key value object that represents a label.
-type Label struct {
+// Returns the Label unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *Label) WithTimeout(duration string) *Label {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &Label{
+		query: q,
+	}
+}
+
+// A license detected in a file.
+type License struct {
 	query *querybuilder.Selection
 
-	id    *LabelID
-	name  *string
-	value *string
+	id     *LicenseID
+	name   *string
+	path   *string
+	spdxId *string
 }
+type WithLicenseFunc func(r *License) *License
 
-func (r *Label) WithGraphQLQuery(q *querybuilder.Selection) *Label {
-	return &Label{
+// With calls the provided function with current License.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *License) With(f WithLicenseFunc) *License {
+	return f(r)
+}
+
+func (r *License) WithGraphQLQuery(q *querybuilder.Selection) *License {
+	return &License{
 		query: q,
 	}
 }
 
-// A unique identifier for this Label.
-func (r *Label) ID(ctx context.Context) (LabelID, error) {
+// A unique identifier for this License.
+func (r *License) ID(ctx context.Context) (LicenseID, error) {
 	if r.id != nil {
 		return *r.id, nil
 	}
 	q := r.query.Select("id")
 
-	var response LabelID
+	var response LicenseID
 
 	q = q.Bind(&response)
 	return response, q.Execute(ctx)
 }
 
 // XXX_GraphQLType is an internal function. It returns the native GraphQL type name
-func (r *Label) XXX_GraphQLType() string {
-	return "Label"
+func (r *License) XXX_GraphQLType() string {
+	return "License"
 }
 
 // XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
-func (r *Label) XXX_GraphQLIDType() string {
-	return "LabelID"
+func (r *License) XXX_GraphQLIDType() string {
+	return "LicenseID"
 }
 
 // XXX_GraphQLID is an internal function. It returns the underlying type ID
-func (r *Label) XXX_GraphQLID(ctx context.Context) (string, error) {
+func (r *License) XXX_GraphQLID(ctx context.Context) (string, error) {
 	id, err := r.ID(ctx)
 	if err != nil {
 		return "", err
@@ -5296,7 +6599,7 @@ func (r *Label) XXX_GraphQLID(ctx context.Context) (string, error) {
 	return string(id), nil
 }
 
-func (r *Label) MarshalJSON() ([]byte, error) {
+func (r *License) MarshalJSON() ([]byte, error) {
 	id, err := r.ID(marshalCtx)
 	if err != nil {
 		return nil, err
@@ -5304,8 +6607,8 @@ func (r *Label) MarshalJSON() ([]byte, error) {
 	return json.Marshal(id)
 }
 
-// The label name.
-func (r *Label) Name(ctx context.Context) (string, error) {
+// The name of the license, or the first line of the file if it wasn't recognized.
+func (r *License) Name(ctx context.Context) (string, error) {
 	if r.name != nil {
 		return *r.name, nil
 	}
@@ -5317,12 +6620,25 @@ func (r *Label) Name(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx)
 }
 
-// The label value.
-func (r *Label) Value(ctx context.Context) (string, error) {
-	if r.value != nil {
-		return *r.value, nil
+// The path of the file the license was detected in, relative to the directory.
+func (r *License) Path(ctx context.Context) (string, error) {
+	if r.path != nil {
+		return *r.path, nil
 	}
-	q := r.query.Select("value")
+	q := r.query.Select("path")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The SPDX identifier of the license (e.g., "Apache-2.0"), or NOASSERTION if it wasn't recognized.
+func (r *License) SpdxID(ctx context.Context) (string, error) {
+	if r.spdxId != nil {
+		return *r.spdxId, nil
+	}
+	q := r.query.Select("spdxId")
 
 	var response string
 
@@ -5330,12 +6646,30 @@ func (r *Label) Value(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx)
 }
 
+// Returns the License unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *License) WithTimeout(duration string) *License {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &License{
+		query: q,
+	}
+}
+
 // A definition of a list type in a Module.
 type ListTypeDef struct {
 	query *querybuilder.Selection
 
 	id *ListTypeDefID
 }
+type WithListTypeDefFunc func(r *ListTypeDef) *ListTypeDef
+
+// With calls the provided function with current ListTypeDef.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *ListTypeDef) With(f WithListTypeDefFunc) *ListTypeDef {
+	return f(r)
+}
 
 func (r *ListTypeDef) WithGraphQLQuery(q *querybuilder.Selection) *ListTypeDef {
 	return &ListTypeDef{
@@ -5392,6 +6726,16 @@ func (r *ListTypeDef) MarshalJSON() ([]byte, error) {
 	return json.Marshal(id)
 }
 
+// Returns the ListTypeDef unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *ListTypeDef) WithTimeout(duration string) *ListTypeDef {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &ListTypeDef{
+		query: q,
+	}
+}
+
 // Module source that that originates from a path locally relative to an arbitrary directory.
 type LocalModuleSource struct {
 	query *querybuilder.Selection
@@ -5400,6 +6744,14 @@ type LocalModuleSource struct {
 	relHostPath *string
 	rootSubpath *string
 }
+type WithLocalModuleSourceFunc func(r *LocalModuleSource) *LocalModuleSource
+
+// With calls the provided function with current LocalModuleSource.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *LocalModuleSource) With(f WithLocalModuleSourceFunc) *LocalModuleSource {
+	return f(r)
+}
 
 func (r *LocalModuleSource) WithGraphQLQuery(q *querybuilder.Selection) *LocalModuleSource {
 	return &LocalModuleSource{
@@ -5482,6 +6834,16 @@ func (r *LocalModuleSource) RootSubpath(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx)
 }
 
+// Returns the LocalModuleSource unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *LocalModuleSource) WithTimeout(duration string) *LocalModuleSource {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &LocalModuleSource{
+		query: q,
+	}
+}
+
 // A Dagger module.
 type Module struct {
 	query *querybuilder.Selection
@@ -5869,6 +7231,16 @@ func (r *Module) WithSource(source *ModuleSource, opts ...ModuleWithSourceOpts)
 	}
 }
 
+// Returns the Module unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *Module) WithTimeout(duration string) *Module {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &Module{
+		query: q,
+	}
+}
+
 // The configuration of dependency of a module.
 type ModuleDependency struct {
 	query *querybuilder.Selection
@@ -5876,6 +7248,14 @@ type ModuleDependency struct {
 	id   *ModuleDependencyID
 	name *string
 }
+type WithModuleDependencyFunc func(r *ModuleDependency) *ModuleDependency
+
+// With calls the provided function with current ModuleDependency.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *ModuleDependency) With(f WithModuleDependencyFunc) *ModuleDependency {
+	return f(r)
+}
 
 func (r *ModuleDependency) WithGraphQLQuery(q *querybuilder.Selection) *ModuleDependency {
 	return &ModuleDependency{
@@ -5945,6 +7325,16 @@ func (r *ModuleDependency) Source() *ModuleSource {
 	}
 }
 
+// Returns the ModuleDependency unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *ModuleDependency) WithTimeout(duration string) *ModuleDependency {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &ModuleDependency{
+		query: q,
+	}
+}
+
 // The source needed to load and run a module, along with any metadata about the source such as versions/urls/etc.
 type ModuleSource struct {
 	query *querybuilder.Selection
@@ -6400,6 +7790,16 @@ func (r *ModuleSource) WithSourceSubpath(path string) *ModuleSource {
 	}
 }
 
+// Returns the ModuleSource unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *ModuleSource) WithTimeout(duration string) *ModuleSource {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &ModuleSource{
+		query: q,
+	}
+}
+
 // Update one or more module dependencies.
 func (r *ModuleSource) WithUpdateDependencies(dependencies []string) *ModuleSource {
 	q := r.query.Select("withUpdateDependencies")
@@ -6438,6 +7838,14 @@ type ModuleSourceView struct {
 	id   *ModuleSourceViewID
 	name *string
 }
+type WithModuleSourceViewFunc func(r *ModuleSourceView) *ModuleSourceView
+
+// With calls the provided function with current ModuleSourceView.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *ModuleSourceView) With(f WithModuleSourceViewFunc) *ModuleSourceView {
+	return f(r)
+}
 
 func (r *ModuleSourceView) WithGraphQLQuery(q *querybuilder.Selection) *ModuleSourceView {
 	return &ModuleSourceView{
@@ -6508,6 +7916,16 @@ func (r *ModuleSourceView) Patterns(ctx context.Context) ([]string, error) {
 	return response, q.Execute(ctx)
 }
 
+// Returns the ModuleSourceView unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *ModuleSourceView) WithTimeout(duration string) *ModuleSourceView {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &ModuleSourceView{
+		query: q,
+	}
+}
+
 // A definition of a custom object defined in a Module.
 type ObjectTypeDef struct {
 	query *querybuilder.Selection
@@ -6517,6 +7935,14 @@ type ObjectTypeDef struct {
 	name             *string
 	sourceModuleName *string
 }
+type WithObjectTypeDefFunc func(r *ObjectTypeDef) *ObjectTypeDef
+
+// With calls the provided function with current ObjectTypeDef.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *ObjectTypeDef) With(f WithObjectTypeDefFunc) *ObjectTypeDef {
+	return f(r)
+}
 
 func (r *ObjectTypeDef) WithGraphQLQuery(q *querybuilder.Selection) *ObjectTypeDef {
 	return &ObjectTypeDef{
@@ -6687,6 +8113,16 @@ func (r *ObjectTypeDef) SourceModuleName(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx)
 }
 
+// Returns the ObjectTypeDef unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *ObjectTypeDef) WithTimeout(duration string) *ObjectTypeDef {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &ObjectTypeDef{
+		query: q,
+	}
+}
+
 // A port exposed by a container.
 type Port struct {
 	query *querybuilder.Selection
@@ -6697,6 +8133,14 @@ type Port struct {
 	port                        *int
 	protocol                    *NetworkProtocol
 }
+type WithPortFunc func(r *Port) *Port
+
+// With calls the provided function with current Port.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *Port) With(f WithPortFunc) *Port {
+	return f(r)
+}
 
 func (r *Port) WithGraphQLQuery(q *querybuilder.Selection) *Port {
 	return &Port{
@@ -6796,6 +8240,16 @@ func (r *Port) Protocol(ctx context.Context) (NetworkProtocol, error) {
 	return response, q.Execute(ctx)
 }
 
+// Returns the Port unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *Port) WithTimeout(duration string) *Port {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &Port{
+		query: q,
+	}
+}
+
 func (r *Client) WithGraphQLQuery(q *querybuilder.Selection) *Client {
 	return &Client{
 		query:  q,
@@ -6803,6 +8257,19 @@ func (r *Client) WithGraphQLQuery(q *querybuilder.Selection) *Client {
 	}
 }
 
+// Acquire a named lock shared by the session, waiting for it to be released if it's held.
+//
+// Returns a lease to release the lock with, using releaseLock. The lock is released anyway once the calling function exits. Time spent waiting is recorded as a metric of the call.
+func (r *Client) AcquireLock(ctx context.Context, key string) (string, error) {
+	q := r.query.Select("acquireLock")
+	q = q.Arg("key", key)
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
 // Retrieves a container builtin to the engine.
 func (r *Client) BuiltinContainer(digest string) *Container {
 	q := r.query.Select("builtinContainer")
@@ -6834,6 +8301,43 @@ func (r *Client) CacheVolume(key string, opts ...CacheVolumeOpts) *CacheVolume {
 	}
 }
 
+// Canonicalize an object ID, stripping fields that do not affect its result.
+//
+// IDs which would produce the same result have the same canonical ID, regardless of how they were constructed.
+func (r *Client) CanonicalID(ctx context.Context, id string) (string, error) {
+	q := r.query.Select("canonicalID")
+	q = q.Arg("id", id)
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// Claim a named once-per-session guard, for setup work that must only be done once.
+//
+// Returns true to the first caller, which should do the work, then call finishOnce. Other callers wait for the work to be finished and get false. If the calling function exits without finishing the work, a waiting caller claims the guard instead. Time spent waiting is recorded as a metric of the call.
+func (r *Client) ClaimOnce(ctx context.Context, key string) (bool, error) {
+	q := r.query.Select("claimOnce")
+	q = q.Arg("key", key)
+
+	var response bool
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// Create a new, empty configuration.
+//
+// Declare its keys with withKey, then load their values with withFile and withEnv, replacing ad-hoc parsing of environment variables.
+func (r *Client) Config() *Config {
+	q := r.query.Select("config")
+
+	return &Config{
+		query: q,
+	}
+}
+
 // ContainerOpts contains options for Client.Container
 type ContainerOpts struct {
 	// Platform to initialize the container with.
@@ -6910,6 +8414,17 @@ func (r *Client) CurrentTypeDefs(ctx context.Context) ([]TypeDef, error) {
 	return convert(response), nil
 }
 
+// Decode an object ID into the chain of calls that produced it, as JSON.
+func (r *Client) DecodeID(ctx context.Context, id string) (JSON, error) {
+	q := r.query.Select("decodeID")
+	q = q.Arg("id", id)
+
+	var response JSON
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
 // The default platform of the engine.
 func (r *Client) DefaultPlatform(ctx context.Context) (Platform, error) {
 	q := r.query.Select("defaultPlatform")
@@ -6948,6 +8463,26 @@ func (r *Client) Error(message string) *Error {
 	}
 }
 
+// FinishOnceOpts contains options for Client.FinishOnce
+type FinishOnceOpts struct {
+	// Give up the guard instead, because the work failed, so that a waiting caller claims it.
+	Failed bool
+}
+
+// Finish the work guarded by a once-per-session guard claimed with claimOnce.
+func (r *Client) FinishOnce(ctx context.Context, key string, opts ...FinishOnceOpts) error {
+	q := r.query.Select("finishOnce")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `failed` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Failed) {
+			q = q.Arg("failed", opts[i].Failed)
+		}
+	}
+	q = q.Arg("key", key)
+
+	return q.Execute(ctx)
+}
+
 // Creates a function.
 func (r *Client) Function(name string, returnType *TypeDef) *Function {
 	assertNotNil("returnType", returnType)
@@ -7009,37 +8544,105 @@ func (r *Client) Git(url string, opts ...GitOpts) *GitRepository {
 	return &GitRepository{
 		query: q,
 	}
-}
+}
+
+// Queries the host environment.
+func (r *Client) Host() *Host {
+	q := r.query.Select("host")
+
+	return &Host{
+		query: q,
+	}
+}
+
+// HTTPOpts contains options for Client.HTTP
+type HTTPOpts struct {
+	// A service which must be started before the URL is fetched.
+	ExperimentalServiceHost *Service
+}
+
+// Returns a file containing an http remote url content.
+func (r *Client) HTTP(url string, opts ...HTTPOpts) *File {
+	q := r.query.Select("http")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `experimentalServiceHost` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ExperimentalServiceHost) {
+			q = q.Arg("experimentalServiceHost", opts[i].ExperimentalServiceHost)
+		}
+	}
+	q = q.Arg("url", url)
+
+	return &File{
+		query: q,
+	}
+}
+
+// KvDeleteOpts contains options for Client.KvDelete
+type KvDeleteOpts struct {
+	// Delete the value persisted by the engine across sessions, rather than the session's.
+	Persistent bool
+}
+
+// Delete a key from the session's key/value store, if it's set.
+func (r *Client) KvDelete(ctx context.Context, key string, opts ...KvDeleteOpts) error {
+	q := r.query.Select("kvDelete")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `persistent` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Persistent) {
+			q = q.Arg("persistent", opts[i].Persistent)
+		}
+	}
+	q = q.Arg("key", key)
+
+	return q.Execute(ctx)
+}
+
+// KvGetOpts contains options for Client.KvGet
+type KvGetOpts struct {
+	// Get the value persisted by the engine across sessions, rather than the session's.
+	Persistent bool
+}
+
+// Get the value of a key in the session's key/value store, or null if it's not set.
+//
+// Keys are scoped to the calling module's source, and to the project and client it's called from.
+func (r *Client) KvGet(ctx context.Context, key string, opts ...KvGetOpts) (string, error) {
+	q := r.query.Select("kvGet")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `persistent` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Persistent) {
+			q = q.Arg("persistent", opts[i].Persistent)
+		}
+	}
+	q = q.Arg("key", key)
 
-// Queries the host environment.
-func (r *Client) Host() *Host {
-	q := r.query.Select("host")
+	var response string
 
-	return &Host{
-		query: q,
-	}
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
 }
 
-// HTTPOpts contains options for Client.HTTP
-type HTTPOpts struct {
-	// A service which must be started before the URL is fetched.
-	ExperimentalServiceHost *Service
+// KvSetOpts contains options for Client.KvSet
+type KvSetOpts struct {
+	// Persist the value across sessions.
+	Persistent bool
 }
 
-// Returns a file containing an http remote url content.
-func (r *Client) HTTP(url string, opts ...HTTPOpts) *File {
-	q := r.query.Select("http")
+// Set the value of a key in the session's key/value store.
+//
+// Keys are scoped to the calling module's source, and to the project and client it's called from. Values last for the session, unless persisted by the engine across sessions, e.g. to compare the digests of artifacts with those of the previous run.
+func (r *Client) KvSet(ctx context.Context, key string, value string, opts ...KvSetOpts) error {
+	q := r.query.Select("kvSet")
 	for i := len(opts) - 1; i >= 0; i-- {
-		// `experimentalServiceHost` optional argument
-		if !querybuilder.IsZeroValue(opts[i].ExperimentalServiceHost) {
-			q = q.Arg("experimentalServiceHost", opts[i].ExperimentalServiceHost)
+		// `persistent` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Persistent) {
+			q = q.Arg("persistent", opts[i].Persistent)
 		}
 	}
-	q = q.Arg("url", url)
+	q = q.Arg("key", key)
+	q = q.Arg("value", value)
 
-	return &File{
-		query: q,
-	}
+	return q.Execute(ctx)
 }
 
 // Load a CacheVolume from its ID.
@@ -7052,6 +8655,26 @@ func (r *Client) LoadCacheVolumeFromID(id CacheVolumeID) *CacheVolume {
 	}
 }
 
+// Load a Config from its ID.
+func (r *Client) LoadConfigFromID(id ConfigID) *Config {
+	q := r.query.Select("loadConfigFromID")
+	q = q.Arg("id", id)
+
+	return &Config{
+		query: q,
+	}
+}
+
+// Load a ConfigKey from its ID.
+func (r *Client) LoadConfigKeyFromID(id ConfigKeyID) *ConfigKey {
+	q := r.query.Select("loadConfigKeyFromID")
+	q = q.Arg("id", id)
+
+	return &ConfigKey{
+		query: q,
+	}
+}
+
 // Load a Container from its ID.
 func (r *Client) LoadContainerFromID(id ContainerID) *Container {
 	q := r.query.Select("loadContainerFromID")
@@ -7062,6 +8685,16 @@ func (r *Client) LoadContainerFromID(id ContainerID) *Container {
 	}
 }
 
+// Load a ContainerLayer from its ID.
+func (r *Client) LoadContainerLayerFromID(id ContainerLayerID) *ContainerLayer {
+	q := r.query.Select("loadContainerLayerFromID")
+	q = q.Arg("id", id)
+
+	return &ContainerLayer{
+		query: q,
+	}
+}
+
 // Load a CurrentModule from its ID.
 func (r *Client) LoadCurrentModuleFromID(id CurrentModuleID) *CurrentModule {
 	q := r.query.Select("loadCurrentModuleFromID")
@@ -7302,6 +8935,16 @@ func (r *Client) LoadLabelFromID(id LabelID) *Label {
 	}
 }
 
+// Load a License from its ID.
+func (r *Client) LoadLicenseFromID(id LicenseID) *License {
+	q := r.query.Select("loadLicenseFromID")
+	q = q.Arg("id", id)
+
+	return &License{
+		query: q,
+	}
+}
+
 // Load a ListTypeDef from its ID.
 func (r *Client) LoadListTypeDefFromID(id ListTypeDefID) *ListTypeDef {
 	q := r.query.Select("loadListTypeDefFromID")
@@ -7443,6 +9086,16 @@ func (r *Client) LoadServiceFromID(id ServiceID) *Service {
 	}
 }
 
+// Load a SessionEvent from its ID.
+func (r *Client) LoadSessionEventFromID(id SessionEventID) *SessionEvent {
+	q := r.query.Select("loadSessionEventFromID")
+	q = q.Arg("id", id)
+
+	return &SessionEvent{
+		query: q,
+	}
+}
+
 // Load a Socket from its ID.
 func (r *Client) LoadSocketFromID(id SocketID) *Socket {
 	q := r.query.Select("loadSocketFromID")
@@ -7549,6 +9202,54 @@ func (r *Client) ModuleSource(refString string, opts ...ModuleSourceOpts) *Modul
 	}
 }
 
+// PublishEventOpts contains options for Client.PublishEvent
+type PublishEventOpts struct {
+	// A payload for the event, if any.
+	Payload string
+}
+
+// Publish an event to a topic of the session's event bus.
+//
+// Functions of any module in the session can wait for the event with waitForEvent, to coordinate without signaling through files.
+func (r *Client) PublishEvent(topic string, opts ...PublishEventOpts) *SessionEvent {
+	q := r.query.Select("publishEvent")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `payload` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Payload) {
+			q = q.Arg("payload", opts[i].Payload)
+		}
+	}
+	q = q.Arg("topic", topic)
+
+	return &SessionEvent{
+		query: q,
+	}
+}
+
+// Re-base an object ID onto a different version of a module.
+//
+// Every call in the ID implemented by a module with the same name as the given module is switched over to it, allowing stored IDs to be migrated across module upgrades without re-running the calls that produced them.
+func (r *Client) RebaseID(ctx context.Context, id string, module *Module) (string, error) {
+	assertNotNil("module", module)
+	q := r.query.Select("rebaseID")
+	q = q.Arg("id", id)
+	q = q.Arg("module", module)
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// Release a named lock acquired with acquireLock.
+func (r *Client) ReleaseLock(ctx context.Context, key string, lease string) error {
+	q := r.query.Select("releaseLock")
+	q = q.Arg("key", key)
+	q = q.Arg("lease", lease)
+
+	return q.Execute(ctx)
+}
+
 // Creates a new secret.
 func (r *Client) Secret(uri string) *Secret {
 	q := r.query.Select("secret")
@@ -7603,6 +9304,30 @@ func (r *Client) Version(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx)
 }
 
+// WaitForEventOpts contains options for Client.WaitForEvent
+type WaitForEventOpts struct {
+	// Only return an event published after this sequence number, e.g. that of the last event received. By default, the first event published to the topic is returned.
+	After int
+}
+
+// Wait for an event to be published to a topic of the session's event bus.
+//
+// Returns the first event published to the topic after the given sequence number, even if it was published before the wait began.
+func (r *Client) WaitForEvent(topic string, opts ...WaitForEventOpts) *SessionEvent {
+	q := r.query.Select("waitForEvent")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `after` optional argument
+		if !querybuilder.IsZeroValue(opts[i].After) {
+			q = q.Arg("after", opts[i].After)
+		}
+	}
+	q = q.Arg("topic", topic)
+
+	return &SessionEvent{
+		query: q,
+	}
+}
+
 // The SDK config of the module.
 type SDKConfig struct {
 	query *querybuilder.Selection
@@ -7670,6 +9395,16 @@ func (r *SDKConfig) Source(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx)
 }
 
+// Returns the SDKConfig unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *SDKConfig) WithTimeout(duration string) *SDKConfig {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &SDKConfig{
+		query: q,
+	}
+}
+
 // A definition of a custom scalar defined in a Module.
 type ScalarTypeDef struct {
 	query *querybuilder.Selection
@@ -7679,6 +9414,14 @@ type ScalarTypeDef struct {
 	name             *string
 	sourceModuleName *string
 }
+type WithScalarTypeDefFunc func(r *ScalarTypeDef) *ScalarTypeDef
+
+// With calls the provided function with current ScalarTypeDef.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *ScalarTypeDef) With(f WithScalarTypeDefFunc) *ScalarTypeDef {
+	return f(r)
+}
 
 func (r *ScalarTypeDef) WithGraphQLQuery(q *querybuilder.Selection) *ScalarTypeDef {
 	return &ScalarTypeDef{
@@ -7765,6 +9508,16 @@ func (r *ScalarTypeDef) SourceModuleName(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx)
 }
 
+// Returns the ScalarTypeDef unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *ScalarTypeDef) WithTimeout(duration string) *ScalarTypeDef {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &ScalarTypeDef{
+		query: q,
+	}
+}
+
 // A reference to a secret value, which can be handled more safely than the value itself.
 type Secret struct {
 	query *querybuilder.Selection
@@ -7774,6 +9527,14 @@ type Secret struct {
 	plaintext *string
 	uri       *string
 }
+type WithSecretFunc func(r *Secret) *Secret
+
+// With calls the provided function with current Secret.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *Secret) With(f WithSecretFunc) *Secret {
+	return f(r)
+}
 
 func (r *Secret) WithGraphQLQuery(q *querybuilder.Selection) *Secret {
 	return &Secret{
@@ -7860,6 +9621,16 @@ func (r *Secret) URI(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx)
 }
 
+// Returns the Secret unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *Secret) WithTimeout(duration string) *Secret {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &Secret{
+		query: q,
+	}
+}
+
 // A content-addressed service providing TCP connectivity.
 type Service struct {
 	query *querybuilder.Selection
@@ -8087,12 +9858,143 @@ func (r *Service) WithHostname(hostname string) *Service {
 	}
 }
 
+// Returns the Service unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *Service) WithTimeout(duration string) *Service {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &Service{
+		query: q,
+	}
+}
+
+// An event published to the session's event bus.
+type SessionEvent struct {
+	query *querybuilder.Selection
+
+	id       *SessionEventID
+	payload  *string
+	sequence *int
+	topic    *string
+}
+type WithSessionEventFunc func(r *SessionEvent) *SessionEvent
+
+// With calls the provided function with current SessionEvent.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *SessionEvent) With(f WithSessionEventFunc) *SessionEvent {
+	return f(r)
+}
+
+func (r *SessionEvent) WithGraphQLQuery(q *querybuilder.Selection) *SessionEvent {
+	return &SessionEvent{
+		query: q,
+	}
+}
+
+// A unique identifier for this SessionEvent.
+func (r *SessionEvent) ID(ctx context.Context) (SessionEventID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.query.Select("id")
+
+	var response SessionEventID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *SessionEvent) XXX_GraphQLType() string {
+	return "SessionEvent"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *SessionEvent) XXX_GraphQLIDType() string {
+	return "SessionEventID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *SessionEvent) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+func (r *SessionEvent) MarshalJSON() ([]byte, error) {
+	id, err := r.ID(marshalCtx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(id)
+}
+
+// The payload of the event, if any.
+func (r *SessionEvent) Payload(ctx context.Context) (string, error) {
+	if r.payload != nil {
+		return *r.payload, nil
+	}
+	q := r.query.Select("payload")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The position of the event in its topic, starting at 1.
+func (r *SessionEvent) Sequence(ctx context.Context) (int, error) {
+	if r.sequence != nil {
+		return *r.sequence, nil
+	}
+	q := r.query.Select("sequence")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The topic the event was published to.
+func (r *SessionEvent) Topic(ctx context.Context) (string, error) {
+	if r.topic != nil {
+		return *r.topic, nil
+	}
+	q := r.query.Select("topic")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// Returns the SessionEvent unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *SessionEvent) WithTimeout(duration string) *SessionEvent {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &SessionEvent{
+		query: q,
+	}
+}
+
 // A Unix or TCP/IP socket that can be mounted into a container.
 type Socket struct {
 	query *querybuilder.Selection
 
 	id *SocketID
 }
+type WithSocketFunc func(r *Socket) *Socket
+
+// With calls the provided function with current Socket.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *Socket) With(f WithSocketFunc) *Socket {
+	return f(r)
+}
 
 func (r *Socket) WithGraphQLQuery(q *querybuilder.Selection) *Socket {
 	return &Socket{
@@ -8140,6 +10042,16 @@ func (r *Socket) MarshalJSON() ([]byte, error) {
 	return json.Marshal(id)
 }
 
+// Returns the Socket unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *Socket) WithTimeout(duration string) *Socket {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &Socket{
+		query: q,
+	}
+}
+
 // Source location information.
 type SourceMap struct {
 	query *querybuilder.Selection
@@ -8150,6 +10062,14 @@ type SourceMap struct {
 	line     *int
 	module   *string
 }
+type WithSourceMapFunc func(r *SourceMap) *SourceMap
+
+// With calls the provided function with current SourceMap.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *SourceMap) With(f WithSourceMapFunc) *SourceMap {
+	return f(r)
+}
 
 func (r *SourceMap) WithGraphQLQuery(q *querybuilder.Selection) *SourceMap {
 	return &SourceMap{
@@ -8249,6 +10169,16 @@ func (r *SourceMap) Module(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx)
 }
 
+// Returns the SourceMap unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *SourceMap) WithTimeout(duration string) *SourceMap {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &SourceMap{
+		query: q,
+	}
+}
+
 // An interactive terminal that clients can connect to.
 type Terminal struct {
 	query *querybuilder.Selection
@@ -8256,6 +10186,14 @@ type Terminal struct {
 	id   *TerminalID
 	sync *TerminalID
 }
+type WithTerminalFunc func(r *Terminal) *Terminal
+
+// With calls the provided function with current Terminal.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *Terminal) With(f WithTerminalFunc) *Terminal {
+	return f(r)
+}
 
 func (r *Terminal) WithGraphQLQuery(q *querybuilder.Selection) *Terminal {
 	return &Terminal{
@@ -8318,6 +10256,16 @@ func (r *Terminal) Sync(ctx context.Context) (*Terminal, error) {
 	}, nil
 }
 
+// Returns the Terminal unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *Terminal) WithTimeout(duration string) *Terminal {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &Terminal{
+		query: q,
+	}
+}
+
 // A definition of a parameter or return type in a Module.
 type TypeDef struct {
 	query *querybuilder.Selection
@@ -8679,6 +10627,16 @@ func (r *TypeDef) WithScalar(name string, opts ...TypeDefWithScalarOpts) *TypeDe
 	}
 }
 
+// Returns the TypeDef unchanged, failing the next call made on it if it doesn't complete within the given duration.
+func (r *TypeDef) WithTimeout(duration string) *TypeDef {
+	q := r.query.Select("withTimeout")
+	q = q.Arg("duration", duration)
+
+	return &TypeDef{
+		query: q,
+	}
+}
+
 // Sharing mode of the cache volume.
 type CacheSharingMode string
 
@@ -8695,6 +10653,35 @@ const (
 	CacheSharingModeShared CacheSharingMode = "SHARED"
 )
 
+// The type of the value of a configuration key.
+type ConfigValueType string
+
+func (ConfigValueType) IsEnum() {}
+
+const (
+	// A boolean, e.g. true or false.
+	ConfigValueTypeBoolean ConfigValueType = "BOOLEAN"
+
+	// A whole number, e.g. 42.
+	ConfigValueTypeInteger ConfigValueType = "INTEGER"
+
+	// Any string.
+	ConfigValueTypeString ConfigValueType = "STRING"
+)
+
+// A category of errors that may be retried.
+type ErrorCategory string
+
+func (ErrorCategory) IsEnum() {}
+
+const (
+	// A command exited with a non-zero exit code.
+	ErrorCategoryExec ErrorCategory = "EXEC"
+
+	// A network request failed, e.g. due to a timeout, a refused connection, or a server error.
+	ErrorCategoryNetwork ErrorCategory = "NETWORK"
+)
+
 // Compression algorithm to use for image layers.
 type ImageLayerCompression string
 
@@ -46,6 +46,37 @@ const (
 	// Substitute the span for its children and move its logs to its parent.
 	UIPassthroughAttr = "dagger.io/ui.passthrough" //nolint: gosec // lol
 
+	// Indicates that the span's step is quarantined, i.e. known to be flaky,
+	// so its failure should be reported as a warning instead.
+	UIQuarantineAttr = "dagger.io/ui.quarantine"
+
+	// Indicates that the span's step is allowed to fail, so its failure
+	// doesn't fail anything that depends on it.
+	UIFailureAllowedAttr = "dagger.io/ui.failure.allowed"
+
+	// Indicates that the span's step is expected to fail (xfail), so its
+	// failure doesn't fail anything that depends on it, but it passing is
+	// reported.
+	UIFailureExpectedAttr = "dagger.io/ui.failure.expected"
+
+	// Marks the parent span as skipped, with the reason as its value.
+	UISkipAttr = "dagger.io/ui.skip"
+
+	// The names of the axes of a matrix, set on the span grouping its cells.
+	UIMatrixAxesAttr = "dagger.io/ui.matrix.axes"
+
+	// The axis values of a matrix cell, formatted as name=value, in the
+	// order of the matrix's axes.
+	UIMatrixCellAttr = "dagger.io/ui.matrix.cell"
+
+	// The time at which the span's work times out, in Unix nanoseconds, so
+	// the UI can show the time remaining.
+	UIDeadlineAttr = "dagger.io/ui.deadline"
+
+	// Indicates that the span covers a time when the pipeline was paused, which
+	// the UI leaves out of durations.
+	UIPauseAttr = "dagger.io/ui.pause"
+
 	// NB: the following attributes are not currently used.
 
 	// Indicates that this span was a cache hit and did nothing.
@@ -61,6 +92,9 @@ const (
 	// Indicates that this span was interrupted.
 	CanceledAttr = "dagger.io/dag.canceled"
 
+	// Who or what interrupted a canceled span, one of the CanceledBy values.
+	CanceledByAttr = "dagger.io/dag.canceled.by"
+
 	// The IDs of effects which will be correlated to this span.
 	//
 	// This is typically a list of LLB operation digests, but can be any string.
@@ -87,9 +121,152 @@ const (
 	// Indicates whether the log should be shown globally.
 	LogsGlobalAttr = "dagger.io/logs.global"
 
+	// The category of a span's error, used to tell crashes of the runtime
+	// apart from errors returned by user code.
+	ErrorCategoryAttr = "dagger.io/error.category"
+
+	// The language of the module runtime that produced the span, e.g. "go".
+	RuntimeLanguageAttr = "dagger.io/runtime.language"
+
+	// The version of the module runtime's language, e.g. "go1.23.2".
+	RuntimeVersionAttr = "dagger.io/runtime.version"
+
+	// The potential secrets found in an export or publish that was stopped
+	// by the engine's secret scanner, as a JSON array of findings.
+	SecretFindingsAttr = "dagger.io/secretscan.findings"
+
+	// Set on withExec calls that run hermetically, without network access
+	// and with a fixed clock, so that their results only depend on their
+	// inputs.
+	ExecHermeticAttr = "dagger.io/exec.hermetic"
+
+	// Whether a hermetic exec's clock was fixed. Fixing it is best-effort: it
+	// needs libfaketime in the container, and a dynamically linked program.
+	ExecHermeticClockAttr = "dagger.io/exec.hermetic.clock"
+
+	// Whether an output built in deterministic mode came out the same when
+	// built again without cache.
+	ReproducibleAttr = "dagger.io/reproducible"
+
+	// The checksums of both builds of an output built in deterministic mode,
+	// as a JSON array of rebuilds, one per platform.
+	ReproducibleRebuildsAttr = "dagger.io/reproducible.rebuilds"
+
+	// Whether the span tracks a service started by a daemon function, which
+	// runs for as long as the service does.
+	DaemonAttr = "dagger.io/daemon"
+
+	// The health of a daemon's service. See DaemonHealthy.
+	DaemonHealthAttr = "dagger.io/daemon.health"
+
+	// The client that attached to or detached from a daemon, set on the
+	// daemon span's events.
+	DaemonClientAttr = "dagger.io/daemon.client"
+
+	// The topic of an event published to or received from a session's event
+	// bus, set on the span events recording it.
+	EventTopicAttr = "dagger.io/event.topic"
+
+	// The payload of an event published to or received from a session's
+	// event bus.
+	EventPayloadAttr = "dagger.io/event.payload"
+
+	// The position of an event in its topic, starting at 1.
+	EventSequenceAttr = "dagger.io/event.sequence"
+
+	// The arguments of a "dagger call" that the user was prompted for, as
+	// "<function> --<flag>". Their values are never recorded.
+	PromptedArgsAttr = "dagger.io/prompted.args"
+
+	// Marks the span as a test case, e.g. for reporting the run's tests in
+	// JUnit XML.
+	TestCaseAttr = "dagger.io/test.case"
+
+	// The name of the suite a test case belongs to, e.g. its package. Defaults
+	// to the name of the test case's parent span.
+	TestSuiteAttr = "dagger.io/test.suite"
+
+	// The names of the variables loaded from an env file as plain
+	// environment variables. Their values are never recorded.
+	EnvFileVariablesAttr = "dagger.io/envfile.variables"
+
+	// The names of the variables loaded from an env file as secrets.
+	EnvFileSecretsAttr = "dagger.io/envfile.secrets"
+
 	// OTel metric attribute so we can correlate metrics with spans
 	MetricsSpanIDAttr = "dagger.io/metrics.span"
 
 	// OTel metric attribute so we can correlate metrics with traces
 	MetricsTraceIDAttr = "dagger.io/metrics.trace"
 )
+
+// Values for ErrorCategoryAttr.
+const (
+	// A module runtime crashed, e.g. due to a panic or unhandled exception.
+	ErrorCategoryCrash = "crash"
+
+	// Calls to an external dependency, e.g. a registry, were short-circuited
+	// after it failed repeatedly.
+	ErrorCategoryCircuitOpen = "circuit-open"
+
+	// An export or publish was stopped because it contained potential
+	// secrets. See SecretFindingsAttr.
+	ErrorCategorySecretLeak = "secret-leak"
+)
+
+// CrashExitCode is the exit code of a module runtime that crashed, so that the
+// engine can categorize the failed call as ErrorCategoryCrash.
+const CrashExitCode = 3
+
+// Values for CanceledByAttr.
+const (
+	// The user interrupted the run, e.g. with Ctrl+C, or canceled the span
+	// from a frontend.
+	CanceledByUser = "user"
+
+	// A timeout elapsed.
+	CanceledByTimeout = "timeout"
+)
+
+// Values for DaemonHealthAttr.
+const (
+	// The daemon's service is running.
+	DaemonHealthy = "healthy"
+
+	// The daemon's service exited with an error.
+	DaemonUnhealthy = "unhealthy"
+
+	// The daemon's service was stopped.
+	DaemonStopped = "stopped"
+)
+
+// UserAttrPrefix is the namespace for attributes that modules set on their
+// spans to attach their own metadata, e.g. dagger.user.team. Unlike other
+// attributes, these are kept on spans as-is, so they show up in frontends and
+// exports.
+//
+// Names following the prefix must be lowercase, dot-separated words of
+// letters, digits, and underscores, and string values may be at most
+// UserAttrMaxLen bytes. Other attributes in the namespace are dropped.
+const UserAttrPrefix = "dagger.user."
+
+// UserAttrMaxLen is the maximum length of string values of user attributes.
+const UserAttrMaxLen = 1024
+
+// AnnotationAttrPrefix is the namespace for string annotations that modules
+// attach to their spans for users to see, e.g. dagger.annotation.ticket set
+// to "ENG-123". Unlike user attributes, which are metadata for exports and
+// queries, annotations are shown alongside the span in frontends.
+//
+// Names following the prefix follow the same rules as UserAttrPrefix, and
+// values may be at most AnnotationMaxLen bytes.
+const AnnotationAttrPrefix = "dagger.annotation."
+
+// AnnotationMaxLen is the maximum length of annotation values.
+const AnnotationMaxLen = 256
+
+// SessionLabelPrefix is the namespace for resource attributes that label a
+// session, e.g. dagger.io/label.pr=123, so that its trace can be found later
+// by more than its timestamp. Clients set them on their OTel resource, e.g.
+// with OTEL_RESOURCE_ATTRIBUTES or the CLI's --label flag.
+const SessionLabelPrefix = "dagger.io/label."
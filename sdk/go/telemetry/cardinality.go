@@ -0,0 +1,254 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// CardinalityOverflowValue replaces the values of an attribute once it has
+// taken more distinct values than allowed.
+const CardinalityOverflowValue = "__other__"
+
+// Reasons an attribute was truncated by a CardinalityGuard.
+const (
+	TruncatedLength      = "length"
+	TruncatedSlice       = "slice"
+	TruncatedCardinality = "cardinality"
+)
+
+// CardinalityLimits bounds the attributes of exported spans, so that
+// unbounded values like full command lines or long lists of digests don't
+// overwhelm downstream trace and metric backends. A zero limit is unbounded.
+type CardinalityLimits struct {
+	// MaxValueLength is the number of bytes a string value is truncated to.
+	MaxValueLength int
+
+	// MaxSliceLength is the number of elements a slice value is truncated to.
+	MaxSliceLength int
+
+	// MaxDistinctValues is the number of distinct values an attribute may
+	// take, after which its values are replaced with CardinalityOverflowValue.
+	MaxDistinctValues int
+
+	// Exempt attributes are exported as-is.
+	Exempt []string
+}
+
+// ParseCardinalityLimits parses limits from a comma-separated list of
+// key=value pairs, e.g. "length=1024,slice=32,distinct=1000". The "exempt"
+// key may be repeated to exempt several attributes.
+func ParseCardinalityLimits(str string) (CardinalityLimits, error) {
+	var limits CardinalityLimits
+	for _, pair := range strings.Split(str, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok {
+			return limits, fmt.Errorf("invalid attribute limit %q: expected key=value", pair)
+		}
+		if key == "exempt" {
+			limits.Exempt = append(limits.Exempt, val)
+			continue
+		}
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 0 {
+			return limits, fmt.Errorf("invalid attribute limit %q: expected a non-negative integer", pair)
+		}
+		switch key {
+		case "length":
+			limits.MaxValueLength = n
+		case "slice":
+			limits.MaxSliceLength = n
+		case "distinct":
+			limits.MaxDistinctValues = n
+		default:
+			return limits, fmt.Errorf("unknown attribute limit %q", key)
+		}
+	}
+	return limits, nil
+}
+
+// Truncation counts the span attributes truncated for a given reason.
+type Truncation struct {
+	Key    string
+	Reason string
+	Count  int
+}
+
+// CardinalityGuard enforces CardinalityLimits on the spans sent to the
+// exporters it wraps, keeping count of what it truncated.
+type CardinalityGuard struct {
+	limits CardinalityLimits
+
+	mu          sync.Mutex
+	distinct    map[string]map[string]struct{}
+	truncations map[Truncation]int
+}
+
+func NewCardinalityGuard(limits CardinalityLimits) *CardinalityGuard {
+	return &CardinalityGuard{
+		limits:      limits,
+		distinct:    map[string]map[string]struct{}{},
+		truncations: map[Truncation]int{},
+	}
+}
+
+// SpanExporter wraps an exporter so that the spans it receives have their
+// attributes bounded by the guard's limits.
+func (g *CardinalityGuard) SpanExporter(exp sdktrace.SpanExporter) sdktrace.SpanExporter {
+	return cardinalityExporter{exp, g}
+}
+
+// Report returns the truncations made so far, by key and reason.
+func (g *CardinalityGuard) Report() []Truncation {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	report := make([]Truncation, 0, len(g.truncations))
+	for t, count := range g.truncations {
+		t.Count = count
+		report = append(report, t)
+	}
+	slices.SortFunc(report, func(a, b Truncation) int {
+		if c := strings.Compare(a.Key, b.Key); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Reason, b.Reason)
+	})
+	return report
+}
+
+// Attributes returns the attributes bounded by the guard's limits, and
+// whether any were changed.
+func (g *CardinalityGuard) Attributes(attrs []attribute.KeyValue) ([]attribute.KeyValue, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var guarded []attribute.KeyValue
+	for i, kv := range attrs {
+		val, changed := g.guard(kv)
+		if changed && guarded == nil {
+			guarded = slices.Clone(attrs)
+		}
+		if guarded != nil {
+			guarded[i] = attribute.KeyValue{Key: kv.Key, Value: val}
+		}
+	}
+	if guarded == nil {
+		return attrs, false
+	}
+	return guarded, true
+}
+
+func (g *CardinalityGuard) guard(kv attribute.KeyValue) (attribute.Value, bool) {
+	key := string(kv.Key)
+	if slices.Contains(g.limits.Exempt, key) {
+		return kv.Value, false
+	}
+	val := kv.Value
+	changed := false
+	truncated := func(reason string) {
+		g.truncations[Truncation{Key: key, Reason: reason}]++
+		changed = true
+	}
+	if max := g.limits.MaxValueLength; max > 0 && val.Type() == attribute.STRING {
+		if str := val.AsString(); len(str) > max {
+			val = attribute.StringValue(truncateString(str, max))
+			truncated(TruncatedLength)
+		}
+	}
+	if max := g.limits.MaxSliceLength; max > 0 {
+		if sliced, ok := truncateSlice(val, max); ok {
+			val = sliced
+			truncated(TruncatedSlice)
+		}
+	}
+	if max := g.limits.MaxDistinctValues; max > 0 {
+		seen := g.distinct[key]
+		if seen == nil {
+			seen = map[string]struct{}{}
+			g.distinct[key] = seen
+		}
+		emitted := val.Emit()
+		if _, ok := seen[emitted]; !ok {
+			if len(seen) >= max {
+				val = attribute.StringValue(CardinalityOverflowValue)
+				truncated(TruncatedCardinality)
+			} else {
+				seen[emitted] = struct{}{}
+			}
+		}
+	}
+	return val, changed
+}
+
+// truncateString truncates a string to at most max bytes, without splitting
+// a multi-byte character, marking it with an ellipsis.
+func truncateString(str string, max int) string {
+	const ellipsis = "…"
+	if max <= len(ellipsis) {
+		return str[:max]
+	}
+	end := max - len(ellipsis)
+	for end > 0 && !utf8.RuneStart(str[end]) {
+		end--
+	}
+	return str[:end] + ellipsis
+}
+
+func truncateSlice(val attribute.Value, max int) (attribute.Value, bool) {
+	switch val.Type() {
+	case attribute.STRINGSLICE:
+		if s := val.AsStringSlice(); len(s) > max {
+			return attribute.StringSliceValue(s[:max]), true
+		}
+	case attribute.INT64SLICE:
+		if s := val.AsInt64Slice(); len(s) > max {
+			return attribute.Int64SliceValue(s[:max]), true
+		}
+	case attribute.FLOAT64SLICE:
+		if s := val.AsFloat64Slice(); len(s) > max {
+			return attribute.Float64SliceValue(s[:max]), true
+		}
+	case attribute.BOOLSLICE:
+		if s := val.AsBoolSlice(); len(s) > max {
+			return attribute.BoolSliceValue(s[:max]), true
+		}
+	}
+	return val, false
+}
+
+type cardinalityExporter struct {
+	sdktrace.SpanExporter
+	guard *CardinalityGuard
+}
+
+func (exp cardinalityExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	guarded := make([]sdktrace.ReadOnlySpan, len(spans))
+	for i, span := range spans {
+		if attrs, changed := exp.guard.Attributes(span.Attributes()); changed {
+			guarded[i] = guardedSpan{span, attrs}
+		} else {
+			guarded[i] = span
+		}
+	}
+	return exp.SpanExporter.ExportSpans(ctx, guarded)
+}
+
+type guardedSpan struct {
+	// Embed the interface to implement the private method.
+	sdktrace.ReadOnlySpan
+	attrs []attribute.KeyValue
+}
+
+func (s guardedSpan) Attributes() []attribute.KeyValue {
+	return s.attrs
+}
@@ -0,0 +1,114 @@
+package telemetry
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestParseCardinalityLimits(t *testing.T) {
+	limits, err := ParseCardinalityLimits("length=1024, slice=32,distinct=1000,exempt=a,exempt=b")
+	require.NoError(t, err)
+	require.Equal(t, CardinalityLimits{
+		MaxValueLength:    1024,
+		MaxSliceLength:    32,
+		MaxDistinctValues: 1000,
+		Exempt:            []string{"a", "b"},
+	}, limits)
+
+	limits, err = ParseCardinalityLimits("")
+	require.NoError(t, err)
+	require.Zero(t, limits)
+
+	for _, str := range []string{"length", "length=-1", "slice=lots", "depth=3"} {
+		_, err := ParseCardinalityLimits(str)
+		require.Error(t, err, str)
+	}
+}
+
+func TestCardinalityGuardLimits(t *testing.T) {
+	guard := NewCardinalityGuard(CardinalityLimits{
+		MaxValueLength: 8,
+		MaxSliceLength: 2,
+		Exempt:         []string{"exempt"},
+	})
+
+	attrs := []attribute.KeyValue{
+		attribute.String("short", "ok"),
+		attribute.String("long", "0123456789"),
+		attribute.String("unicode", "ééééé"),
+		attribute.StringSlice("strings", []string{"a", "b", "c"}),
+		attribute.Int64Slice("ints", []int64{1, 2, 3}),
+		attribute.String("exempt", "0123456789"),
+	}
+	guarded, changed := guard.Attributes(attrs)
+	require.True(t, changed)
+	require.Equal(t, []attribute.KeyValue{
+		attribute.String("short", "ok"),
+		attribute.String("long", "01234…"),
+		// multi-byte characters aren't split
+		attribute.String("unicode", "éé…"),
+		attribute.StringSlice("strings", []string{"a", "b"}),
+		attribute.Int64Slice("ints", []int64{1, 2}),
+		attribute.String("exempt", "0123456789"),
+	}, guarded)
+	// the given attributes are left as-is
+	require.Equal(t, "0123456789", attrs[1].Value.AsString())
+
+	unchanged := []attribute.KeyValue{attribute.String("short", "ok")}
+	guarded, changed = guard.Attributes(unchanged)
+	require.False(t, changed)
+	require.Equal(t, unchanged, guarded)
+
+	require.Equal(t, []Truncation{
+		{Key: "ints", Reason: TruncatedSlice, Count: 1},
+		{Key: "long", Reason: TruncatedLength, Count: 1},
+		{Key: "strings", Reason: TruncatedSlice, Count: 1},
+		{Key: "unicode", Reason: TruncatedLength, Count: 1},
+	}, guard.Report())
+}
+
+func TestCardinalityGuardOverflow(t *testing.T) {
+	guard := NewCardinalityGuard(CardinalityLimits{MaxDistinctValues: 2})
+
+	values := func(vals ...string) []string {
+		var guarded []string
+		for _, val := range vals {
+			attrs, _ := guard.Attributes([]attribute.KeyValue{attribute.String("digest", val)})
+			guarded = append(guarded, attrs[0].Value.AsString())
+		}
+		return guarded
+	}
+	require.Equal(t,
+		[]string{"a", "b", "a", CardinalityOverflowValue, "b", CardinalityOverflowValue},
+		values("a", "b", "a", "c", "b", "d"))
+
+	// each attribute has its own limit
+	attrs, changed := guard.Attributes([]attribute.KeyValue{attribute.String("other", "c")})
+	require.False(t, changed)
+	require.Equal(t, "c", attrs[0].Value.AsString())
+
+	require.Equal(t, []Truncation{
+		{Key: "digest", Reason: TruncatedCardinality, Count: 2},
+	}, guard.Report())
+}
+
+func TestCardinalityGuardSpanExporter(t *testing.T) {
+	guard := NewCardinalityGuard(CardinalityLimits{MaxValueLength: 4})
+	exp := tracetest.NewInMemoryExporter()
+
+	spans := tracetest.SpanStubs{
+		{Name: "long", Attributes: []attribute.KeyValue{attribute.String("cmd", strings.Repeat("x", 10))}},
+		{Name: "short", Attributes: []attribute.KeyValue{attribute.String("cmd", "x")}},
+	}.Snapshots()
+	require.NoError(t, guard.SpanExporter(exp).ExportSpans(context.Background(), spans))
+
+	exported := exp.GetSpans()
+	require.Len(t, exported, 2)
+	require.Equal(t, "x…", exported[0].Attributes[0].Value.AsString())
+	require.Equal(t, "x", exported[1].Attributes[0].Value.AsString())
+}
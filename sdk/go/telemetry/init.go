@@ -303,6 +303,10 @@ type Config struct {
 	// Resource is the resource describing this component and runtime
 	// environment.
 	Resource *resource.Resource
+
+	// CardinalityGuard, if set, bounds the attributes of spans sent to the
+	// exporter detected from OTEL_* env variables.
+	CardinalityGuard *CardinalityGuard
 }
 
 // NearlyImmediate is 100ms, below which has diminishing returns in terms of
@@ -374,6 +378,9 @@ func Init(ctx context.Context, cfg Config) context.Context {
 
 	if cfg.Detect {
 		if exp, ok := ConfiguredSpanExporter(ctx); ok {
+			if cfg.CardinalityGuard != nil {
+				exp = cfg.CardinalityGuard.SpanExporter(exp)
+			}
 			if LiveTracesEnabled {
 				cfg.LiveTraceExporters = append(cfg.LiveTraceExporters, exp)
 			} else {
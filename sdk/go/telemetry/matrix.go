@@ -0,0 +1,71 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MatrixAxis is a parameter of a matrix, along with the values to run with.
+type MatrixAxis struct {
+	Name   string
+	Values []string
+}
+
+// MatrixCell is one combination of matrix axis values, keyed by axis name.
+type MatrixCell map[string]string
+
+// Matrix runs fn concurrently for every combination of the axes' values,
+// returning all of their errors joined together.
+//
+// The runs are grouped under a span with the given name, each in a span
+// labeled with its axis values, so that the UI can show the results as a
+// pass/fail matrix.
+func Matrix(ctx context.Context, name string, axes []MatrixAxis, fn func(context.Context, MatrixCell) error) (rerr error) {
+	names := make([]string, len(axes))
+	for i, axis := range axes {
+		names[i] = axis.Name
+	}
+	tracer := sdkTracer(ctx)
+	ctx, span := tracer.Start(ctx, name,
+		trace.WithAttributes(attribute.StringSlice(UIMatrixAxesAttr, names)))
+	defer End(span, func() error { return rerr })
+
+	cells := []MatrixCell{{}}
+	for _, axis := range axes {
+		var product []MatrixCell
+		for _, cell := range cells {
+			for _, val := range axis.Values {
+				next := MatrixCell{axis.Name: val}
+				for k, v := range cell {
+					next[k] = v
+				}
+				product = append(product, next)
+			}
+		}
+		cells = product
+	}
+
+	errs := make([]error, len(cells))
+	var wg sync.WaitGroup
+	for i, cell := range cells {
+		labels := make([]string, len(axes))
+		for j, axis := range axes {
+			labels[j] = axis.Name + "=" + cell[axis.Name]
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, span := tracer.Start(ctx, strings.Join(labels, ", "),
+				trace.WithAttributes(attribute.StringSlice(UIMatrixCellAttr, labels)))
+			errs[i] = fn(ctx, cell)
+			End(span, func() error { return errs[i] })
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -56,6 +56,34 @@ const (
 	// OTel metric for number of transmitted packets dropped by a container, pulled from buildkit's network namespace representation
 	NetstatTxDropped = "dagger.io/metrics.netstat.tx.dropped"
 
+	// OTel metric for number of bytes of serialized arguments passed to a call
+	CallArgsBytes = "dagger.io/metrics.call.args.bytes"
+
+	// OTel metric for number of bytes of the serialized ID or value returned by a call
+	CallResultBytes = "dagger.io/metrics.call.result.bytes"
+
+	// OTel metric for microseconds a call spent waiting on a session lock or
+	// once-guard
+	LockWaitMicroseconds = "dagger.io/metrics.lock.wait"
+
+	// OTel metric for microseconds a call was throttled by a rate limit
+	RateLimitWaitMicroseconds = "dagger.io/metrics.ratelimit.wait"
+
+	// OTel metric for number of bytes of a file sent to the host so far by an
+	// export
+	ExportSentBytes = "dagger.io/metrics.export.sent.bytes"
+
+	// OTel metric for total number of bytes of a file exported to the host
+	ExportTotalBytes = "dagger.io/metrics.export.total.bytes"
+
+	// OTel metric for number of connections accepted so far by a tunnel to
+	// the host
+	TunnelConnections = "dagger.io/metrics.tunnel.connections"
+
+	// OTel metric for number of connections through a tunnel to the host that
+	// are still open
+	TunnelActiveConnections = "dagger.io/metrics.tunnel.connections.active"
+
 	// OTel metric units should be in UCUM format
 	// https://unitsofmeasure.org/ucum
 
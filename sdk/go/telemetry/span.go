@@ -3,6 +3,7 @@ package telemetry
 import (
 	"context"
 
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
@@ -36,12 +37,56 @@ func Passthrough() trace.SpanStartOption {
 	return trace.WithAttributes(attribute.Bool(UIPassthroughAttr, true))
 }
 
+// Quarantine can be applied to a span for a known-flaky step, to report its
+// failure as a warning rather than a failure.
+func Quarantine() trace.SpanStartOption {
+	return trace.WithAttributes(attribute.Bool(UIQuarantineAttr, true))
+}
+
+// AllowFailure can be applied to a span for a step that is allowed to fail,
+// so that its failure, and that of anything within it, doesn't fail the run.
+func AllowFailure() trace.SpanStartOption {
+	return trace.WithAttributes(attribute.Bool(UIFailureAllowedAttr, true))
+}
+
+// ExpectFailure can be applied to a span for a step that is expected to fail,
+// like an xfail test: its failure doesn't fail the run, but it passing is
+// reported.
+func ExpectFailure() trace.SpanStartOption {
+	return trace.WithAttributes(attribute.Bool(UIFailureExpectedAttr, true))
+}
+
+// Skip marks the current span as skipped for the given reason, e.g. because a
+// check doesn't apply. It is meant to be called from module code right before
+// returning without doing anything, so that the step shows up as skipped
+// rather than as having passed.
+//
+// The skip is recorded as an internal child span, since the current span may
+// belong to the caller, such as the engine's span for a function call.
+func Skip(ctx context.Context, reason string) {
+	_, span := sdkTracer(ctx).Start(ctx, "skip",
+		Internal(),
+		trace.WithAttributes(attribute.String(UISkipAttr, reason)))
+	span.End()
+}
+
 // Tracer returns a Tracer for the given library using the provider from
 // the current span.
 func Tracer(ctx context.Context, lib string) trace.Tracer {
 	return trace.SpanFromContext(ctx).TracerProvider().Tracer(lib)
 }
 
+// sdkTracer returns a Tracer for spans started on behalf of SDK users, whose
+// context may only hold the span of a remote caller.
+func sdkTracer(ctx context.Context) trace.Tracer {
+	tp := trace.SpanFromContext(ctx).TracerProvider()
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		// remote spans have a no-op provider; use the global one instead
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer("dagger.io/sdk.go")
+}
+
 // End is a helper to end a span with an error if the function returns an error.
 //
 // It is optimized for use as a defer one-liner with a function that has a
@@ -55,3 +100,21 @@ func End(span trace.Span, fn func() error) {
 	}
 	span.End()
 }
+
+// UserAttribute moves an attribute into the namespace for module metadata,
+// so that it's kept on the span for frontends and exports. See
+// UserAttrPrefix.
+//
+//	span.SetAttributes(telemetry.UserAttribute(attribute.String("team", "infra")))
+func UserAttribute(kv attribute.KeyValue) attribute.KeyValue {
+	kv.Key = attribute.Key(UserAttrPrefix) + kv.Key
+	return kv
+}
+
+// Annotate attaches an annotation to the span, to be shown alongside it in
+// frontends. See AnnotationAttrPrefix.
+//
+//	telemetry.Annotate(trace.SpanFromContext(ctx), "ticket", "ENG-123")
+func Annotate(span trace.Span, name, value string) {
+	span.SetAttributes(attribute.String(AnnotationAttrPrefix+name, value))
+}